@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/config"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/docker"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/license"
+	"github.com/spf13/cobra"
+)
+
+var CompletionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate shell completion scripts",
+	Long: `Generate a shell completion script for acontext.
+
+The logo banner is never printed for this command, so the output can be
+sourced or redirected into a file directly.
+
+Bash:
+  source <(acontext completion bash)
+  # or, to load for every session (Linux):
+  acontext completion bash > /etc/bash_completion.d/acontext
+
+Zsh:
+  acontext completion zsh > "${fpath[1]}/_acontext"
+
+Fish:
+  acontext completion fish > ~/.config/fish/completions/acontext.fish
+
+PowerShell:
+  acontext completion powershell | Out-String | Invoke-Expression
+`,
+	Args:                  cobra.ExactArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	DisableFlagsInUseLine: true,
+	RunE:                  runCompletion,
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	root := cmd.Root()
+	switch args[0] {
+	case "bash":
+		return root.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		return root.GenZshCompletion(os.Stdout)
+	case "fish":
+		return root.GenFishCompletion(os.Stdout, true)
+	case "powershell":
+		return root.GenPowerShellCompletionWithDesc(os.Stdout)
+	default:
+		return fmt.Errorf("unsupported shell: %s (supported: bash, zsh, fish, powershell)", args[0])
+	}
+}
+
+// completeTemplatePath completes --template-path values from the templates config.
+// Presets are suggested when defined; otherwise only language folders are offered,
+// since discovering templates requires cloning the repository.
+func completeTemplatePath(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.LoadTemplatesConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var suggestions []string
+	for _, language := range config.GetLanguages() {
+		presets := cfg.Presets[language]
+		if len(presets) == 0 {
+			suggestions = append(suggestions, language+"/")
+			continue
+		}
+		for _, preset := range presets {
+			path := strings.Replace(preset.Template, ".", "/", 1)
+			language, name, _ := strings.Cut(path, "/")
+			if tmpl, err := config.GetTemplate(language, name); err == nil && tmpl.Embedded {
+				// Embedded templates are not in the repository --template-path reads
+				continue
+			}
+			suggestions = append(suggestions, fmt.Sprintf("%s\t%s", path, preset.Name))
+		}
+	}
+
+	return suggestions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeLicense completes --license values with the known SPDX identifiers
+func completeLicense(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	suggestions := []string{license.None + "\tNo LICENSE file"}
+	for _, known := range license.Known {
+		suggestions = append(suggestions, fmt.Sprintf("%s\t%s", known.ID, known.Name))
+	}
+	return suggestions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeConfigKey completes the first argument of config subcommands with known keys
+func completeConfigKey(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	keys := make([]string, 0, len(config.KnownSettings))
+	for _, setting := range config.KnownSettings {
+		keys = append(keys, fmt.Sprintf("%s\t%s", setting.Key, setting.Description))
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeServiceArg completes the service argument of the docker
+// subcommands that take one, e.g., docker logs <service>
+func completeServiceArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return serviceSuggestions(cmd, nil), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeCopyArg completes the arguments of docker cp: the services, as
+// service: so the path in the container can follow, or local files when the
+// argument names none of them
+func completeCopyArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 1 || strings.Contains(toComplete, ":") {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var suggestions []string
+	for _, suggestion := range serviceSuggestions(cmd, nil) {
+		name, description, _ := strings.Cut(suggestion, "\t")
+		if !strings.HasPrefix(name, toComplete) {
+			continue
+		}
+		if description != "" {
+			suggestions = append(suggestions, name+":\t"+description)
+		} else {
+			suggestions = append(suggestions, name+":")
+		}
+	}
+	if len(suggestions) == 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return suggestions, cobra.ShellCompDirectiveNoSpace
+}
+
+// completeServiceFlag completes --service values, leaving out the services
+// already given
+func completeServiceFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	given, _ := cmd.Flags().GetStringArray("service")
+	return serviceSuggestions(cmd, given), cobra.ShellCompDirectiveNoFileComp
+}
+
+// serviceSuggestions returns the services, described by their image, of the
+// compose file the docker commands would use: the project's
+// docker-compose.yaml, or else the embedded one. The file is parsed rather
+// than resolved with docker compose, so completion is instant and works
+// without Docker; a file that cannot be read or parsed suggests nothing.
+func serviceSuggestions(cmd *cobra.Command, exclude []string) []string {
+	// Completion runs without the root hooks, so --working-dir is not applied yet
+	projectDir, err := getProjectDir()
+	if err != nil {
+		return nil
+	}
+	if flag := cmd.Flag("working-dir"); flag != nil && flag.Value.String() != "" {
+		if dir := flag.Value.String(); filepath.IsAbs(dir) {
+			projectDir = dir
+		} else {
+			projectDir = filepath.Join(projectDir, dir)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(projectDir, "docker-compose.yaml"))
+	if os.IsNotExist(err) {
+		content, err = []byte(docker.GetDockerComposeContent()), nil
+	}
+	if err != nil {
+		return nil
+	}
+	services, err := docker.DeclaredServices(content)
+	if err != nil {
+		return nil
+	}
+
+	var suggestions []string
+	for _, service := range services {
+		if slices.Contains(exclude, service.Name) {
+			continue
+		}
+		if service.Image == "" {
+			suggestions = append(suggestions, service.Name)
+		} else {
+			suggestions = append(suggestions, fmt.Sprintf("%s\t%s", service.Name, service.Image))
+		}
+	}
+	return suggestions
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage persistent CLI settings",
+	Long: `Manage persistent CLI settings stored in ~/.config/acontext/config.yaml,
+or $ACONTEXT_HOME/config.yaml when ACONTEXT_HOME is set. Pass --config to use
+another file instead; it is treated as empty until the first set.
+
+Settings are used as defaults by other commands (e.g., create, docker)
+before falling back to built-in values.
+
+Example:
+  acontext config set create.template python/openai
+  acontext config get create.template
+  acontext config list --all
+`,
+}
+
+var (
+	listAllSettings bool
+)
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a setting",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List settings",
+	Long:  "List all settings that are set (use --all to include every available key)",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigList,
+}
+
+func init() {
+	configGetCmd.ValidArgsFunction = completeConfigKey
+	configSetCmd.ValidArgsFunction = completeConfigKey
+	configUnsetCmd.ValidArgsFunction = completeConfigKey
+	configListCmd.Flags().BoolVar(&listAllSettings, "all", false, "Show all available keys, including unset ones")
+	ConfigCmd.AddCommand(configGetCmd)
+	ConfigCmd.AddCommand(configSetCmd)
+	ConfigCmd.AddCommand(configUnsetCmd)
+	ConfigCmd.AddCommand(configListCmd)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	if _, err := config.LookupSetting(args[0]); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadUserConfig()
+	if err != nil {
+		return err
+	}
+
+	value, ok := cfg.Get(args[0])
+	if !ok {
+		return fmt.Errorf("%s is not set", args[0])
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadUserConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Set(args[0], args[1]); err != nil {
+		return err
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Set %s = %s\n", args[0], args[1])
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadUserConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Unset(args[0]); err != nil {
+		return err
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Unset %s\n", args[0])
+	return nil
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadUserConfig()
+	if err != nil {
+		return err
+	}
+
+	if listAllSettings {
+		for _, setting := range config.KnownSettings {
+			value, ok := cfg.Get(setting.Key)
+			if !ok {
+				value = "(unset)"
+			}
+			fmt.Printf("%-20s %-20s %s\n", setting.Key, value, setting.Description)
+		}
+		return nil
+	}
+
+	keys := cfg.Keys()
+	if len(keys) == 0 {
+		fmt.Printf("No settings configured in %s\n", cfg.Path())
+		return nil
+	}
+	values := cfg.Values()
+	for _, key := range keys {
+		fmt.Printf("%s=%s\n", key, values[key])
+	}
+	return nil
+}
+
+// loadUserConfig loads the user config for command defaults, falling back to an
+// empty config (with a warning) so a broken settings file never blocks a command
+func loadUserConfig() *config.UserConfig {
+	cfg, err := config.LoadUserConfig()
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Failed to load config, using defaults: %v\n", err)
+		cfg = &config.UserConfig{}
+	}
+	return cfg
+}
@@ -1,186 +1,559 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/ci"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
 	"github.com/memodb-io/Acontext/acontext-cli/internal/config"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/deploy"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/editor"
 	"github.com/memodb-io/Acontext/acontext-cli/internal/git"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/install"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/interrupt"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/license"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/progress"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/telemetry"
 	"github.com/memodb-io/Acontext/acontext-cli/internal/template"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/tty"
+	"github.com/memodb-io/Acontext/acontext-cli/pkg/scaffold"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	templatePath string // Custom template path, e.g., "python/custom-template"
+	templatePath string   // Custom template path, e.g., "python/custom-template"
+	templateURL  string   // Custom template source, e.g., "git+https://github.com/org/template.git"
+	templateRev  string   // Branch, tag or commit of the --template-url template
+	templateDir  string   // Local template directory rendered in place, without caching
+	dryRun       bool     // Print what would be created without writing anything
+	noGit        bool     // Skip Git initialization
+	gitBranch    string   // Initial Git branch name
+	gitRemote    string   // URL of the origin remote added to the new repository
+	gitPush      bool     // Push the initial commit to --git-remote
+	nameFlag     string   // Project name (alternative to the positional argument)
+	templateKey  string   // Built-in template key, e.g., "python.openai"
+	searchPath   string   // Directories searched for --template keys before the built-in templates
+	authorName   string   // Author name passed to the template
+	licenseID    string   // SPDX identifier of the project's license, written to LICENSE
+	assumeYes    bool     // Disable all prompts and assume defaults
+	force        bool     // Overwrite files in a non-empty project directory
+	refresh      bool     // Re-fetch a cached --template-url template
+	offline      bool     // Only use a cached --template-url template
+	runInstall   bool     // Install dependencies after scaffolding
+	skipInstall  bool     // Do not install dependencies, overriding create.install
+	specFile     string   // YAML file with the answers, overridden by flags
+	listVars     bool     // Print the template's variables instead of creating a project
+	varFlags     []string // Template variable values, as name=value
+	setFlags     []string // Variables for the rendered template files, as KEY=VALUE
+	modulePath   string   // Go module path of a Go template
+	withGroups   []string // Feature groups to include
+	skipGroups   []string // Feature groups to leave out
+	minimalFlag  bool     // Only create the files outside every feature group
+	fullFlag     bool     // Create every feature group
+	withExamples bool     // Include the template's examples feature group
+	noProvenance bool     // Do not write .acontext/provenance.json
+	withCI       bool     // Write a GitHub Actions workflow
+	seedFlag     uint64   // Seed of the random values the template generates, random if not set
+	upgrade      bool     // Re-apply a newer template version to an existing project
+	postCreate   string   // Script run in the new project, instead of the template's
+	ignoreHooks  bool     // Keep the project when the post-create script fails
+	projectsFile string   // Manifest of several projects to create at once
+	monoGit      bool     // Initialize one Git repository for every project of --projects
+	keepPartial  bool     // Keep what a failed create wrote, and with --projects the projects created before one fails
+	quietSummary bool     // Print only the summary of the created project
+	openEditor   bool     // Open the created project in the user's editor
+	gitToken     string   // Token authenticating HTTPS clones of --template-url templates
 )
 
 var CreateCmd = &cobra.Command{
-	Use:   "create [project-name]",
+	Use:   "create [project-name] [path]",
 	Short: "Create a new Acontext project",
 	Long: `Create a new Acontext project with a template.
 	
 You will be guided through:
   1. Project name (if not provided)
-  2. Programming language selection
-  3. Template selection
-  4. Git initialization
-  5. Optional Docker deployment
+  2. Template selection (all languages, with a short description of each)
+  3. Git initialization
+  4. Optional Docker deployment
+
+The project is created in ./<project-name>, or in the directory given as the
+second argument (e.g., acontext create myapp ./services/myapp). Missing parent
+directories are created.
+
+When stdin is not a terminal, nothing is prompted: pass the project name and
+one of --template, --template-path, --template-url or --template-dir. Use
+--yes to disable all prompts for scripting and CI: values that are not passed
+as flags fall back to their defaults, and creation fails fast when a required
+value (such as the template) cannot be resolved.
 
 Use --template-path to specify a custom template folder from:
   https://github.com/memodb-io/Acontext-Examples
-  
+
+Use --template-url to create from your own template repository or a local
+directory, or --template-dir to render a template you are working on straight
+from its directory; --template-search-path lists directories of local
+templates that --template can name. A template must contain an
+acontext.template.yaml manifest.
+
+create refuses to write into a non-empty directory. Use --force to overwrite
+conflicting files; every overwritten file is backed up to .acontext-backup/.
+When create fails once it started writing the project (e.g., a template file
+fails to render, the post-create script or git init fails, or create is
+interrupted), what it wrote is removed and listed, unless --keep-partial is
+set. A directory that had files before (--force) is left as it is.
+
+The Git branch, license and install settings are resolved in this order of
+precedence: the flag (--git-branch, --license, --install/--no-install), the
+--from spec file, the user config (create.git_branch, create.license,
+create.install), the defaults of the template's manifest, and finally main,
+no license and no install. Template variables that are not set with --var or
+in the spec file are prompted for, or take their default when prompts are
+disabled.
+
+How the project was created is recorded in .acontext/provenance.json, which
+--upgrade uses to pull a newer version of its template into the project.
+--projects creates several projects at once from a manifest, and --output json
+prints a summary of the created project for tools. See the readme for the
+spec file, projects manifest and template manifest formats.
+
 Example:
   acontext create my-project --template-path "python/custom-template"
+  acontext create my-project --template-url git+https://github.com/myorg/acontext-template.git
+  acontext create my-project --template-url file:///path/to/template
+  acontext create my-project --template-dir ../my-template
+  acontext create my-project --template service --template-search-path ~/templates:/srv/templates
+  acontext create my-project --template-url git+https://github.com/myorg/acontext-template.git --ref v1.2.0
+  acontext create my-project --dry-run
+  acontext create my-project ./services/my-project
+  acontext create my-project --template python.openai --install
+  acontext create my-project --template python.openai --open
+  acontext create my-agent --template go.basic --module github.com/myorg/my-agent --yes
+  acontext create --name my-project --template python.openai --no-git --yes
+  acontext create --from acontext-spec.yaml --yes
+  acontext create my-project --template python.openai --git-remote git@github.com:myorg/my-project.git --git-push
+  acontext create my-project --template-url file:///path/to/template --var port=8000
+  acontext create my-project --template-url file:///path/to/template --seed 42 --no-provenance
+  acontext create my-project --template-url file:///path/to/template --minimal --with tests
+  acontext create my-agent --template go.basic --include-examples
+  acontext create --template-url file:///path/to/template --list-vars -o json
+  acontext create --upgrade ./my-project --ref v1.3.0 --dry-run
+  acontext create --projects acontext-projects.yaml ./my-monorepo --mono-git
 `,
-	Args: cobra.MaximumNArgs(1),
-	RunE: runCreate,
+	Args:        cobra.MaximumNArgs(2),
+	Annotations: longRunning(),
+	RunE:        runCreate,
 }
 
 func init() {
 	CreateCmd.Flags().StringVarP(&templatePath, "template-path", "t", "", "Custom template folder path from Acontext-Examples repository (e.g., python/custom-template)")
+	CreateCmd.Flags().StringVar(&templateURL, "template-url", "", "Custom template source (git+https://, git+ssh:// or file://) containing an acontext.template.yaml manifest")
+	CreateCmd.Flags().StringVar(&templateDir, "template-dir", "", "Local template directory to render in place, without copying or caching it")
+	CreateCmd.Flags().StringVar(&templateKey, "template", "", "Built-in template to use (e.g., python.openai or python/openai)")
+	CreateCmd.Flags().StringVar(&searchPath, "template-search-path", "", "Directories of local templates --template can name, separated like PATH (searched before create.template_search_path and the built-in templates)")
+	CreateCmd.MarkFlagsMutuallyExclusive("template", "template-path", "template-url", "template-dir")
+	CreateCmd.Flags().StringVar(&templateRev, "ref", "", "Branch, tag or commit of the --template-url template (alias --template-version, defaults to its default branch)")
+	CreateCmd.Flags().SetNormalizeFunc(func(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "template-version" {
+			name = "ref"
+		}
+		return pflag.NormalizedName(name)
+	})
+	_ = CreateCmd.RegisterFlagCompletionFunc("template-path", completeTemplatePath)
+	_ = CreateCmd.MarkFlagDirname("template-dir")
+	CreateCmd.Flags().BoolVar(&noGit, "no-git", false, "Skip Git repository initialization")
+	CreateCmd.Flags().StringVar(&gitBranch, "git-branch", "", "Initial Git branch name (defaults to create.git_branch, the template's default or "+git.DefaultBranch+")")
+	CreateCmd.Flags().StringVar(&gitRemote, "git-remote", "", "Add URL as the origin remote of the new Git repository")
+	CreateCmd.Flags().BoolVar(&gitPush, "git-push", false, "Push the initial commit to --git-remote (when the push fails, the project and its repository are kept)")
+	CreateCmd.Flags().StringVar(&nameFlag, "name", "", "Project name (alternative to the positional argument)")
+	CreateCmd.Flags().StringVar(&authorName, "author", "", "Author name for the project")
+	CreateCmd.Flags().StringVar(&licenseID, "license", "", "SPDX identifier of the project's license, written to LICENSE with the author and year (e.g., MIT, Apache-2.0, or none; defaults to create.license or the template's default)")
+	_ = CreateCmd.RegisterFlagCompletionFunc("license", completeLicense)
+	CreateCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Disable all prompts and assume defaults for unspecified values")
+	CreateCmd.Flags().BoolVar(&force, "force", false, "Scaffold into a non-empty directory, backing up overwritten files to "+template.BackupDir+"/")
+	CreateCmd.Flags().BoolVar(&refresh, "refresh", false, "Re-fetch the --template-url template even if it is cached")
+	CreateCmd.Flags().BoolVar(&offline, "offline", false, "Only use a cached --template-url template, fail if it is not cached")
+	CreateCmd.Flags().StringVar(&gitToken, "git-token", "", "Token authenticating the clone of an https:// --template-url template (defaults to "+template.GitTokenEnvVar+", else the git credential helpers)")
+	CreateCmd.MarkFlagsMutuallyExclusive("refresh", "offline")
+	CreateCmd.Flags().BoolVar(&runInstall, "install", false, "Install dependencies after creating the project (npm, poetry, pip or go, depending on the template)")
+	CreateCmd.Flags().BoolVar(&skipInstall, "no-install", false, "Do not install dependencies, even if create.install or the template enables it")
+	CreateCmd.MarkFlagsMutuallyExclusive("install", "no-install")
+	CreateCmd.Flags().StringVar(&specFile, "from", "", "YAML spec file with the project name, template, author, license, Git branch, install and template variables (flags take precedence over it)")
+	CreateCmd.Flags().StringArrayVar(&varFlags, "var", nil, "Set a template variable, as name=value, checked against the constraints the manifest declares (repeatable, overrides --from)")
+	CreateCmd.Flags().StringArrayVar(&setFlags, "set", nil, "Set a variable for the template's rendered files, as KEY=VALUE, even one it does not declare (repeatable, overrides --var)")
+	CreateCmd.Flags().StringVar(&modulePath, "module", "", "Go module path of a Go template (defaults to the project name)")
+	CreateCmd.Flags().StringSliceVar(&withGroups, "with", nil, "Include the template's feature groups (comma-separated, repeatable)")
+	CreateCmd.Flags().StringSliceVar(&skipGroups, "without", nil, "Leave out the template's feature groups (comma-separated, repeatable)")
+	CreateCmd.Flags().BoolVar(&minimalFlag, "minimal", false, "Only create the files outside the template's feature groups, plus --with")
+	CreateCmd.Flags().BoolVar(&fullFlag, "full", false, "Create every feature group of the template, except --without")
+	CreateCmd.MarkFlagsMutuallyExclusive("minimal", "full")
+	CreateCmd.Flags().BoolVar(&withExamples, "include-examples", false, "Include the template's runnable example code (its "+template.ExamplesFeature+" feature group), also with --minimal")
+	CreateCmd.Flags().BoolVar(&withCI, "with-github-actions", false, "Add a GitHub Actions workflow ("+ci.WorkflowFile+") that installs the dependencies and runs the tests")
+	CreateCmd.Flags().BoolVar(&listVars, "list-vars", false, "Print the variables and feature groups of the selected template without creating anything (e.g., to build a form from --output json)")
+	CreateCmd.Flags().BoolVar(&noProvenance, "no-provenance", false, "Do not record how the project was created in "+scaffold.ProvenanceFile)
+	CreateCmd.Flags().BoolVar(&upgrade, "upgrade", false, "Re-apply a newer version of its template to the project in [path] (default .), from its "+scaffold.ProvenanceFile+", showing the changes as a diff first; conflicting files get an .acontext-new copy")
+	CreateCmd.Flags().StringVar(&postCreate, "post-create-script", "", "Run this script in the new project after it is written, before Git initialization, instead of the template's post_create (relative to the current directory; the variables are in its environment as ACONTEXT_VAR_<NAME>)")
+	CreateCmd.Flags().BoolVar(&ignoreHooks, "ignore-hook-errors", false, "Keep the project when the post-create script fails")
+	CreateCmd.Flags().StringVar(&projectsFile, "projects", "", "YAML manifest of several projects to create at once, under [path] (default .)")
+	CreateCmd.Flags().BoolVar(&monoGit, "mono-git", false, "With --projects, initialize one Git repository in the root directory instead of one per project")
+	CreateCmd.Flags().BoolVar(&keepPartial, "keep-partial", false, "Keep what was written when create fails instead of removing it, and with --projects the projects created before one fails")
+	CreateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the files and steps that would run without writing anything to disk")
+	CreateCmd.Flags().Uint64Var(&seedFlag, "seed", 0, "Seed of the random values the template generates (randomHex, randomString, uuid), so the same inputs create byte-identical files (defaults to a random seed, shown with -v and recorded in "+scaffold.ProvenanceFile+")")
+	CreateCmd.Flags().BoolVar(&quietSummary, "quiet-summary", false, "Only print a summary of the created project to stdout, as key: value lines (progress goes to stderr)")
+	CreateCmd.MarkFlagsMutuallyExclusive("quiet-summary", "dry-run")
+	CreateCmd.MarkFlagsMutuallyExclusive("quiet-summary", "list-vars")
+	CreateCmd.Flags().BoolVar(&openEditor, "open", false, "Open the project in your editor once it is created (create.editor, $VISUAL, $EDITOR or code; skipped in CI and without a terminal)")
 }
 
 func runCreate(cmd *cobra.Command, args []string) error {
-	// 1. Get project name
-	var projectName string
-	if len(args) > 0 {
-		projectName = args[0]
-	} else {
-		defaultName := "my-acontext-app"
-		prompt := &survey.Input{
-			Message: "Project name:",
-			Help:    "Enter a name for your project (e.g., my-acontext-app)",
-			Default: defaultName,
-		}
-		if err := survey.AskOne(prompt, &projectName); err != nil {
-			return fmt.Errorf("failed to get project name: %w", err)
-		}
-		// If user just pressed Enter, use default value
-		if projectName == "" {
-			projectName = defaultName
+	ctx := cmd.Context()
+	if upgrade {
+		return runCreateUpgrade(cmd, args)
+	}
+	if err := checkProjectsFlags(cmd, args); err != nil {
+		return err
+	}
+	if projectsFile != "" {
+		return runCreateProjects(cmd, args)
+	}
+
+	// Fill in the answers from the spec file; flags take precedence
+	var spec *template.Spec
+	if specFile != "" {
+		var err error
+		spec, err = template.LoadSpec(specFile)
+		if err != nil {
+			return clierror.WithCode(clierror.Usage, err)
 		}
+		applySpec(cmd.Flags(), spec, len(args) > 0)
 	}
 
-	// Validate project name
-	if err := validateProjectName(projectName); err != nil {
+	if err := checkTemplateRef(); err != nil {
+		return err
+	}
+	if listVars {
+		return printTemplateVars(ctx)
+	}
+	if err := checkGitFlags(); err != nil {
 		return err
 	}
+	if err := checkHookFlags(); err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("seed") && seedFlag == 0 {
+		return clierror.UsageError("--seed must be a positive integer, leave it out for a random seed")
+	}
+	// Keep the progress messages out of the summary
+	if quietSummary {
+		output.RedirectProse()
+	}
 
-	// Check if directory already exists
-	projectDir, err := filepath.Abs(projectName)
+	// 1. Get project name
+	projectName, err := resolveProjectName(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the target directory, ./<name> unless a path is given
+	targetPath := projectName
+	if len(args) > 1 {
+		targetPath = args[1]
+	}
+	projectDir, err := filepath.Abs(targetPath)
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
+	if err := scaffold.ValidateDir(projectDir); err != nil {
+		return clierror.WithCode(clierror.Usage, err)
+	}
+	displayDir := relativeToCwd(projectDir)
 
-	if _, err := os.Stat(projectDir); err == nil {
-		return fmt.Errorf("directory %s already exists", projectName)
+	// Check if directory already exists
+	if err := checkProjectDir(projectDir, force); err != nil {
+		return err
 	}
 
 	fmt.Printf("📦 Creating project: %s\n", projectName)
 	fmt.Println()
 
-	var templateConfig *template.Config
+	// Fall back to the default template from user config
+	userConfig := loadUserConfig()
+	applyDefaultTemplate(userConfig)
 
-	// 2. If custom template path is specified, use it directly
-	if templatePath != "" {
-		fmt.Printf("✓ Using custom template: %s\n", templatePath)
-		fmt.Println()
-		templateConfig = &template.Config{
-			Repo:        "https://github.com/memodb-io/Acontext-Examples",
-			Path:        templatePath,
-			Description: fmt.Sprintf("Custom template from %s", templatePath),
-		}
+	// 2. If custom template source or path is specified, use it directly
+	var ref scaffold.TemplateRef
+	if templateURL != "" {
+		ref = scaffold.TemplateRef{URL: templateURL, Ref: templateRev, Refresh: refresh, Offline: offline}
+	} else if templateDir != "" {
+		ref = scaffold.TemplateRef{Dir: templateDir}
+	} else if templatePath != "" {
+		ref = scaffold.TemplateRef{Path: templatePath}
+	} else if templateKey != "" {
+		ref = templateKeyRef(ctx, userConfig, templateKey)
+	} else if assumeYes {
+		return clierror.UsageError("a template is required when prompts are disabled: pass --template, --template-path, --template-url or --template-dir")
 	} else {
-		// 3. Select language
-		language, err := promptLanguage()
-		if err != nil {
-			return err
+		// 3. Select template
+		if !tty.CanPrompt(ctx) {
+			return clierror.UsageError("no template specified and %s: pass --template, --template-path, --template-url or --template-dir", tty.NoPromptReason(ctx))
 		}
-		fmt.Printf("✓ Selected language: %s\n", language)
-		fmt.Println()
-
-		// 4. Load config and select template
-		templateKey, preset, err := promptTemplate(language)
+		key, preset, err := promptTemplate(ctx)
 		if err != nil {
 			return err
 		}
 		fmt.Printf("✓ Selected template: %s\n", preset.Name)
 		fmt.Println()
+		ref = scaffold.TemplateRef{Key: key}
+	}
 
-		// 5. Get template config
-		// Parse template key (e.g., "python.openai")
-		parts := strings.Split(templateKey, ".")
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid template key: %s", templateKey)
-		}
+	// 4. Get template config
+	tmpl, err := openTemplate(ctx, ref)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmpl.Close()
+	}()
+	if tmpl.Commit != "" {
+		fmt.Printf("✓ Using custom template: %s (%s)\n", tmpl.Name, describeTemplateVersion(tmpl))
+		fmt.Println()
+	} else if ref.URL != "" || ref.Path != "" {
+		fmt.Printf("✓ Using custom template: %s\n", tmpl.Name)
+		fmt.Println()
+	} else if templateKey != "" {
+		fmt.Printf("✓ Using template: %s\n", tmpl.Name)
+		fmt.Println()
+	}
 
-		// Try to get template from config first
-		tmpl, err := config.GetTemplate(parts[0], parts[1])
-		if err != nil {
-			// If not found in config, construct path dynamically
-			cfg, err := config.LoadTemplatesConfig()
-			if err != nil {
-				return fmt.Errorf("failed to load templates config: %w", err)
-			}
-			templateConfig = &template.Config{
-				Repo:        cfg.Repo,
-				Path:        fmt.Sprintf("%s/%s", parts[0], parts[1]),
-				Description: fmt.Sprintf("%s template", templateKey),
-			}
-		} else {
-			templateConfig = &template.Config{
-				Repo:        tmpl.Repo,
-				Path:        tmpl.Path,
-				Description: tmpl.Description,
-			}
+	// Settings not given by a flag or the spec file fall back to the user
+	// config, then to the template's defaults
+	projectLicense, err := resolveLicense(userConfig, licenseID, "--license", tmpl.Defaults)
+	if err != nil {
+		return err
+	}
+	gitBranch = resolveGitBranch(userConfig, gitBranch, tmpl.Defaults)
+
+	answers, err := parseVarFlags(varFlags, tmpl.Variables)
+	if err != nil {
+		return err
+	}
+	if err := applyModuleFlag(answers, tmpl); err != nil {
+		return err
+	}
+	setAnswers, extraVars, err := parseSetFlags(setFlags, tmpl.Variables)
+	if err != nil {
+		return err
+	}
+	if spec != nil {
+		applyVarFlags(spec, answers)
+		if err := spec.Validate(template.Variables(&template.Manifest{Variables: tmpl.Variables})); err != nil {
+			return clierror.WithCode(clierror.Usage, fmt.Errorf("%s: %w", specFile, err))
 		}
+		answers = spec.Variables
+	}
+	answers = overrideAnswers(answers, setAnswers)
+	features, err := selectFeatures(ctx, tmpl)
+	if err != nil {
+		return err
 	}
 
-	// 6. Create project directory
-	if err := os.MkdirAll(projectDir, 0755); err != nil {
-		return fmt.Errorf("failed to create project directory: %w", err)
+	if dryRun {
+		return printDryRun(ctx, projectName, displayDir, tmpl, features, projectLicense, shouldInstall(userConfig, tmpl.Defaults))
 	}
 
-	// 7. Download template with project name variable
-	vars := map[string]string{
-		"project_name": projectName,
+	// 5. Collect the remaining answers, so nothing is asked once files are written
+	author := authorName
+	if author == "" {
+		author, _ = userConfig.Get("create.author")
 	}
-	if err := template.DownloadTemplateWithVars(templateConfig, projectDir, vars); err != nil {
-		return fmt.Errorf("failed to download template: %w", err)
+	// Prompts stand in for the answers a spec file would give
+	interactive := spec == nil && !assumeYes && tty.CanPrompt(ctx)
+	values, err := resolveTemplateVars(scaffold.DefaultVariables(tmpl.Variables, projectName), answers, interactive)
+	if err != nil {
+		return err
 	}
-	fmt.Println()
-
-	// 8. Ask whether to initialize Git
-	initGit := false
-	prompt := &survey.Confirm{
-		Message: "Would you like to initialize a Git repository?",
-		Help:    "This will create a new Git repository and make an initial commit.",
-		Default: true,
+	initGit, err := confirmGitInit(ctx, projectDir)
+	if err != nil {
+		return err
 	}
 
-	if err := survey.AskOne(prompt, &initGit); err != nil {
-		return fmt.Errorf("failed to get Git initialization preference: %w", err)
+	// 6. Create project directory, render the template and initialize Git
+	if ref.URL != "" {
+		fmt.Println("📋 Copying template files...")
+	}
+	seed := seedFlag
+	if seed == 0 {
+		seed = template.NewSeed()
 	}
+	logging.FromContext(ctx).Info("rendering template", "seed", seed)
+	partial := trackProject(projectDir)
+	result, err := scaffold.Scaffold(ctx, scaffold.Options{
+		Name:      projectName,
+		Dir:       displayDir,
+		Template:  tmpl,
+		Vars:      values,
+		ExtraVars: extraVars,
+		Features:  features,
+		Seed:      seed,
+		Author:    author,
+		License:   projectLicense,
+		CI:        withCI,
+		Force:     force,
+		Git:       initGit,
+		GitBranch: gitBranch,
+		GitRemote: gitRemote,
+		GitPush:   gitPush,
 
-	if initGit {
-		fmt.Println("🔧 Initializing Git repository...")
-		if err := git.Init(projectDir); err != nil {
-			fmt.Printf("⚠️  Warning: Failed to initialize Git: %v\n", err)
-			fmt.Println("   You can initialize Git manually later with: git init")
-		} else {
-			fmt.Println("✓ Git repository initialized")
+		Provenance: !noProvenance,
+		Version:    cliVersion,
+
+		PostCreate:       postCreate,
+		IgnoreHookErrors: ignoreHooks,
+
+		// Rolled back below, listing what is removed
+		KeepPartial: true,
+	})
+	if result != nil && result.PostCreate != "" {
+		telemetry.RecordFlag("post_create_ok", strconv.FormatBool(result.HookErr == nil && err == nil))
+	}
+	if result == nil {
+		return rollbackCreate(ctx, partial, withScaffoldHint(err))
+	}
+	// A failed Git initialization rolls back a project create wrote from
+	// scratch, and only warns in a directory that had files before
+	if err == nil && result.GitErr != nil && partial.fresh && !keepPartial {
+		err = result.GitErr
+	}
+	if err == nil && ref.URL != "" {
+		fmt.Println("✅ Template rendered successfully")
+	}
+	if len(result.Overwritten) > 0 {
+		fmt.Printf("⚠️  Overwrote %d existing file(s), backups saved to %s/:\n", len(result.Overwritten), scaffold.BackupDir)
+		for _, file := range result.Overwritten {
+			fmt.Printf("   - %s\n", file)
 		}
+	}
+	if result.HookErr != nil {
+		fmt.Printf("⚠️  Warning: Post-create script failed, the project was kept (--ignore-hook-errors): %v\n", result.HookErr)
+	}
+	telemetry.RecordFlag("git_init", strconv.FormatBool(result.GitInitialized))
+	if gitRemote != "" {
+		telemetry.RecordFlag("git_push", strconv.FormatBool(result.Pushed))
+	}
+	if err != nil {
+		return rollbackCreate(ctx, partial, err)
+	}
+	fmt.Println()
+
+	// 7. Report Git initialization
+	if result.GitInitialized {
+		fmt.Printf("✓ Git repository initialized (branch: %s)\n", gitBranch)
 		fmt.Println()
-	} else {
-		fmt.Println("⏭️  Skipping Git initialization")
+	} else if result.GitErr != nil {
+		fmt.Printf("⚠️  Warning: Failed to initialize Git: %v\n", result.GitErr)
 		fmt.Println("   You can initialize Git manually later with: git init")
 		fmt.Println()
 	}
+	printRemoteStatus(result, displayDir)
+
+	// 8. Install dependencies
+	var installed *installStatus
+	if shouldInstall(userConfig, tmpl.Defaults) {
+		installed = installDependencies(ctx, projectDir, displayDir, tmpl.Install)
+		if err := setupStopped(ctx, displayDir); err != nil {
+			return err
+		}
+	}
 
 	// 9. Display success message
+	summary := createResult{
+		Envelope:       output.NewEnvelope("create"),
+		Project:        projectName,
+		Path:           projectDir,
+		Template:       tmpl.Name,
+		TemplateSource: tmpl.Source,
+		Commit:         tmpl.Commit,
+		License:        projectLicense,
+		Files:          result.Files,
+		FileCount:      len(result.Files),
+		Git:            result.GitInitialized,
+		Install:        installed,
+		Warnings:       createWarnings(tmpl, result, installed),
+	}
+	if output.IsJSON() {
+		if err := output.PrintJSON(summary); err != nil {
+			return err
+		}
+	} else if quietSummary {
+		printCreateSummary(summary)
+	} else {
+		printNextSteps(projectDir, displayDir)
+	}
+
+	// 10. Open the project in the editor
+	if openEditor {
+		openProject(ctx, userConfig, projectDir, displayDir)
+	}
+	return nil
+}
+
+// defaultProjectName is the project name used with --yes, and offered by
+// the name prompt
+const defaultProjectName = "my-acontext-app"
+
+// resolveProjectName returns the validated project name: the positional
+// argument or --name, which must agree when both are given, or else the
+// default with --yes, or else the answer to a prompt
+func resolveProjectName(ctx context.Context, args []string) (string, error) {
+	if len(args) > 0 && nameFlag != "" && args[0] != nameFlag {
+		return "", clierror.UsageError("conflicting project names: argument %q and --name %q", args[0], nameFlag)
+	}
+	var projectName string
+	if len(args) > 0 {
+		projectName = args[0]
+	} else if nameFlag != "" {
+		projectName = nameFlag
+	} else if assumeYes {
+		projectName = defaultProjectName
+	} else if !tty.CanPrompt(ctx) {
+		return "", clierror.UsageError("no project name specified and %s: pass it as an argument or with --name", tty.NoPromptReason(ctx))
+	} else {
+		prompt := &survey.Input{
+			Message: "Project name:",
+			Help:    "Enter a name for your project (e.g., " + defaultProjectName + ")",
+			Default: defaultProjectName,
+		}
+		if err := survey.AskOne(prompt, &projectName); err != nil {
+			return "", fmt.Errorf("failed to get project name: %w", err)
+		}
+		// If user just pressed Enter, use default value
+		if projectName == "" {
+			projectName = defaultProjectName
+		}
+	}
+
+	if err := scaffold.ValidateName(projectName); err != nil {
+		return "", clierror.WithCode(clierror.Usage, err)
+	}
+	return projectName, nil
+}
+
+// printNextSteps prints the success message and what to do with the project
+func printNextSteps(projectDir, displayDir string) {
 	fmt.Println()
 	fmt.Println("✅ Project created successfully!")
 	fmt.Println()
@@ -189,7 +562,7 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	fmt.Println("🚀 Next steps:")
 	fmt.Println()
 	fmt.Printf("   1. Navigate to your project:\n")
-	fmt.Printf("      cd %s\n", projectName)
+	fmt.Printf("      cd %s\n", displayDir)
 	fmt.Println()
 	fmt.Printf("   2. Read the README to get started:\n")
 	fmt.Printf("      cat README.md\n")
@@ -197,108 +570,1045 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	fmt.Printf("   3. Deploy with Docker (optional):\n")
 	fmt.Printf("      acontext docker up\n")
 	fmt.Println()
+}
+
+// openProject opens the created project in the user's editor for --open. It
+// is skipped with a note with --no-input, without an interactive terminal,
+// e.g., in CI, or when no editor is found, and an editor that fails is only
+// a warning, as the project is created either way.
+func openProject(ctx context.Context, userConfig *config.UserConfig, projectDir, displayDir string) {
+	if tty.NoInput(ctx) {
+		fmt.Println("ℹ️  Skipping --open: --no-input is set")
+		return
+	}
+	if os.Getenv("CI") != "" || !tty.IsStdinTerminal() || !tty.IsStdoutTerminal() {
+		fmt.Println("ℹ️  Skipping --open: not running in an interactive terminal")
+		return
+	}
+	configured, _ := userConfig.Get("create.editor")
+	ed, found, err := editor.Resolve(configured)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Cannot open the project, create.editor is invalid: %v\n", err)
+		return
+	}
+	if !found {
+		fmt.Printf("ℹ️  Skipping --open: no editor found, set create.editor, $VISUAL or $EDITOR, or put %s on PATH\n", editor.Code)
+		return
+	}
+
+	logging.FromContext(ctx).Info("opening project", "editor", ed.String(), "source", ed.Source)
+	fmt.Printf("📝 Opening %s with %s...\n", displayDir, ed.Args[0])
+	// A terminal editor gets Ctrl-C from the terminal itself
+	release := interrupt.Delegate()
+	defer release()
+	if err := editor.Open(ctx, ed, projectDir); err != nil {
+		fmt.Printf("⚠️  Warning: Failed to open the project: %v\n", err)
+	}
+}
+
+// createResult is the JSON result of create, a summary of the created
+// project that tools calling the CLI rely on: fields are only ever added
+type createResult struct {
+	output.Envelope
+	Project        string         `json:"project"`
+	Path           string         `json:"path,omitempty"`
+	Template       string         `json:"template"`
+	TemplateSource string         `json:"template_source"`           // The template's URL, ExamplesRepo or "embedded"
+	Commit         string         `json:"template_commit,omitempty"` // Commit of a remote --template-url template
+	License        string         `json:"license,omitempty"`         // SPDX identifier of the license written to LICENSE
+	DryRun         bool           `json:"dry_run,omitempty"`
+	Files          []string       `json:"files"`
+	FileCount      int            `json:"file_count"`
+	Git            bool           `json:"git_initialized"`
+	Install        *installStatus `json:"install"` // null when dependencies were not installed
+	Warnings       []string       `json:"warnings,omitempty"`
+}
+
+// createWarnings lists what went wrong without failing create, as the
+// warnings printed along the way report it
+func createWarnings(tmpl *scaffold.Template, result *scaffold.Result, installed *installStatus) []string {
+	var warnings []string
+	if tmpl.RefreshErr != nil {
+		warnings = append(warnings, fmt.Sprintf("could not refresh template, used cached copy: %v", tmpl.RefreshErr))
+	}
+	if len(result.Overwritten) > 0 {
+		warnings = append(warnings, fmt.Sprintf("overwrote %d existing file(s), backups saved to %s/", len(result.Overwritten), scaffold.BackupDir))
+	}
+	if result.HookErr != nil {
+		warnings = append(warnings, fmt.Sprintf("post-create script failed, the project was kept: %v", result.HookErr))
+	}
+	if result.GitErr != nil {
+		warnings = append(warnings, fmt.Sprintf("failed to initialize Git: %v", result.GitErr))
+	}
+	if result.RemoteErr != nil {
+		warnings = append(warnings, fmt.Sprintf("failed to add Git remote: %v", result.RemoteErr))
+	}
+	if result.PushErr != nil {
+		warnings = append(warnings, fmt.Sprintf("could not push to %s: %v", gitRemote, result.PushErr))
+	}
+	if installed != nil && !installed.OK {
+		warnings = append(warnings, fmt.Sprintf("failed to install dependencies: %s", installed.Error))
+	}
+	return warnings
+}
+
+// printCreateSummary prints summary as key: value lines for --quiet-summary,
+// one warning: line per warning
+func printCreateSummary(summary createResult) {
+	install := "skipped"
+	if summary.Install != nil && summary.Install.OK {
+		install = "ok"
+	} else if summary.Install != nil {
+		install = "failed"
+	}
+	w := output.Stdout()
+	fmt.Fprintf(w, "project: %s\n", summary.Project)
+	fmt.Fprintf(w, "path: %s\n", summary.Path)
+	fmt.Fprintf(w, "template: %s\n", summary.Template)
+	fmt.Fprintf(w, "files: %d\n", summary.FileCount)
+	fmt.Fprintf(w, "git: %t\n", summary.Git)
+	fmt.Fprintf(w, "install: %s\n", install)
+	for _, warning := range summary.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+}
+
+// installStatus is the outcome of installing dependencies after create
+type installStatus struct {
+	Command string `json:"command"`
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+}
+
+// applyDefaultTemplate uses the create.template setting when no template
+// flag is passed
+func applyDefaultTemplate(userConfig *config.UserConfig) {
+	if templateURL == "" && templateDir == "" && templatePath == "" && templateKey == "" {
+		if defaultTemplate, ok := userConfig.Get("create.template"); ok {
+			templatePath = defaultTemplate
+		}
+	}
+}
+
+// templateSearchPath returns the directories local templates are looked up
+// in: --template-search-path, then the create.template_search_path setting
+func templateSearchPath(userConfig *config.UserConfig) []string {
+	dirs := template.SplitSearchPath(searchPath)
+	value, _ := userConfig.Get("create.template_search_path")
+	return append(dirs, template.SplitSearchPath(value)...)
+}
+
+// templateKeyRef returns the template a --template key selects: the one of
+// that name on the template search path, which takes precedence over the
+// built-in templates, or else the built-in template
+func templateKeyRef(ctx context.Context, userConfig *config.UserConfig, key string) scaffold.TemplateRef {
+	local, found, problems := template.FindLocalTemplate(templateSearchPath(userConfig), key)
+	for _, problem := range problems {
+		fmt.Printf("⚠️  Warning: %v\n", problem)
+	}
+	if !found {
+		return scaffold.TemplateRef{Key: key}
+	}
+	if isBuiltinKey(local.Name) {
+		fmt.Printf("⚠️  Warning: Template %s in %s shadows the built-in template of the same name\n", local.Name, local.Root)
+	}
+	logging.FromContext(ctx).Info("using template from the search path", "template", local.Name, "dir", local.Dir)
+	return scaffold.TemplateRef{Dir: local.Dir}
+}
+
+// isBuiltinKey reports whether --template key would select a built-in
+// template: any <language>.<template> key of a supported language does
+func isBuiltinKey(key string) bool {
+	language, name, ok := strings.Cut(key, ".")
+	return ok && name != "" && slices.Contains(config.GetLanguages(), language)
+}
+
+// resolveLicense returns the canonical SPDX identifier of the project's
+// license: id, given by source (e.g., --license), or else the
+// create.license setting, or else the template's default, and "" for none.
+// An unknown identifier is a usage error suggesting the known ones close to
+// it.
+func resolveLicense(userConfig *config.UserConfig, id, source string, defaults scaffold.Defaults) (string, error) {
+	if id == "" {
+		id, _ = userConfig.Get("create.license")
+		source = "create.license"
+	}
+	if id == "" {
+		id, source = defaults.License, template.ManifestFile+" defaults.license"
+	}
+	if id == "" || strings.EqualFold(id, license.None) {
+		return "", nil
+	}
+	found, err := license.Lookup(id)
+	if err != nil {
+		return "", clierror.UsageError("%s: %v", source, err)
+	}
+	return found.ID, nil
+}
+
+// resolveGitBranch returns the initial Git branch of the project: branch,
+// given by --git-branch or the spec file, or else the create.git_branch
+// setting, or else the template's default, or else git.DefaultBranch
+func resolveGitBranch(userConfig *config.UserConfig, branch string, defaults scaffold.Defaults) string {
+	if branch == "" {
+		branch, _ = userConfig.Get("create.git_branch")
+	}
+	if branch == "" {
+		branch = defaults.GitBranch
+	}
+	if branch == "" {
+		branch = git.DefaultBranch
+	}
+	return branch
+}
+
+// openTemplate opens the selected template, showing progress while a
+// --template-url template is fetched
+func openTemplate(ctx context.Context, ref scaffold.TemplateRef) (*scaffold.Template, error) {
+	if ref.URL == "" {
+		return scaffold.OpenTemplate(ctx, ref)
+	}
+
+	ref.GitToken = resolveGitToken()
+	if ref.GitToken != "" {
+		logging.Redact(ref.GitToken)
+		if transport := template.URLTransport(ref.URL); transport != template.TransportHTTPS {
+			logging.FromContext(ctx).Debug("not sending the git token, the template is not fetched over HTTPS", "transport", transport)
+		}
+	}
+	var tmpl *scaffold.Template
+	err := progress.Run(ctx, fmt.Sprintf("📦 Fetching template from %s...", ref.URL), func() error {
+		var openErr error
+		tmpl, openErr = scaffold.OpenTemplate(ctx, ref)
+		return openErr
+	})
+	if err != nil {
+		return nil, withCloneHint(err, ref.URL)
+	}
+	if tmpl.RefreshErr != nil {
+		fmt.Printf("⚠️  Warning: Could not refresh template, using cached copy: %v\n", tmpl.RefreshErr)
+	}
+	return tmpl, nil
+}
+
+// resolveGitToken returns the token that authenticates HTTPS template
+// clones: --git-token, or else the GitTokenEnvVar environment variable
+func resolveGitToken() string {
+	if gitToken != "" {
+		return gitToken
+	}
+	return os.Getenv(template.GitTokenEnvVar)
+}
+
+// withCloneHint explains how to fix a clone of the template at rawURL that
+// failed because the credentials were missing or rejected, or because the
+// repository was not found
+func withCloneHint(err error, rawURL string) error {
+	switch {
+	case errors.Is(err, template.ErrAuthentication) && template.URLTransport(rawURL) == template.TransportSSH:
+		return clierror.WithHint(err, "Check that your SSH key is loaded (ssh-add -l) and can access the repository.")
+	case errors.Is(err, template.ErrAuthentication):
+		return clierror.WithHint(err, fmt.Sprintf("Pass a token with --git-token or %s, or configure a git credential helper.", template.GitTokenEnvVar))
+	case errors.Is(err, template.ErrRepoNotFound):
+		return clierror.WithHint(err, "Check the URL; a private repository is reported as not found too when the credentials cannot access it.")
+	}
+	return err
+}
+
+// checkTemplateRef rejects --ref unless it can select a version of a remote
+// --template-url template
+func checkTemplateRef() error {
+	if templateRev == "" {
+		return nil
+	}
+	if templateURL == "" {
+		return clierror.UsageError("--ref needs a remote template: pass --template-url")
+	}
+	if !template.IsRemoteURL(templateURL) {
+		return clierror.UsageError("--ref needs a remote --template-url (git+https:// or git+ssh://), %s has no versions", templateURL)
+	}
+	if strings.Contains(templateURL, "#") {
+		return clierror.UsageError("--ref and the ref after # in --template-url are contradictory, pass only one")
+	}
+	telemetry.RecordFlag("ref_kind", templateRefKind(templateRev))
+	return nil
+}
+
+// templateRefKind tells, for telemetry, whether ref names a commit or a
+// branch or tag, without recording the ref itself
+func templateRefKind(ref string) string {
+	if template.IsCommitRef(ref) {
+		return "commit"
+	}
+	return "branch_or_tag"
+}
+
+// describeTemplateVersion describes the ref and commit a remote template was
+// fetched at, e.g., "v1.2.0 at 3f2a9c1d0b7e"
+func describeTemplateVersion(tmpl *scaffold.Template) string {
+	return describeVersion(tmpl.Ref, tmpl.Commit)
+}
+
+// describeVersion describes a ref and the commit it resolved to
+func describeVersion(ref, commit string) string {
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	switch {
+	case ref == "":
+		return "default branch at " + commit
+	case template.IsCommitRef(ref):
+		return commit
+	}
+	return ref + " at " + commit
+}
+
+// checkGitFlags rejects --git-remote and --git-push when they cannot apply
+func checkGitFlags() error {
+	if noGit && gitRemote != "" {
+		return clierror.UsageError("--git-remote and --no-git are contradictory: a remote needs a Git repository")
+	}
+	if noGit && gitPush {
+		return clierror.UsageError("--git-push and --no-git are contradictory: pushing needs a Git repository")
+	}
+	if gitPush && gitRemote == "" {
+		return clierror.UsageError("--git-push needs --git-remote")
+	}
+	return nil
+}
 
+// checkHookFlags fails before any prompt when --post-create-script is not a
+// file
+func checkHookFlags() error {
+	if postCreate == "" {
+		return nil
+	}
+	info, err := os.Stat(postCreate)
+	if err != nil {
+		return clierror.UsageError("--post-create-script: %v", err)
+	}
+	if info.IsDir() {
+		return clierror.UsageError("--post-create-script: %s is a directory", postCreate)
+	}
 	return nil
 }
 
-// validateProjectName validates the project name
-func validateProjectName(name string) error {
-	if name == "" {
-		return fmt.Errorf("project name cannot be empty")
+// printRemoteStatus reports how adding the origin remote and pushing to it
+// went. The local repository is kept either way, so a failure is a warning
+// along with the commands that finish the setup by hand.
+func printRemoteStatus(result *scaffold.Result, displayDir string) {
+	switch {
+	case result.RemoteErr != nil:
+		fmt.Printf("⚠️  Warning: Failed to add Git remote: %v\n", result.RemoteErr)
+		fmt.Printf("   You can add it manually with: cd %s && git remote add %s %s\n", displayDir, git.DefaultRemote, gitRemote)
+	case result.PushErr != nil:
+		if errors.Is(result.PushErr, git.ErrAuthentication) {
+			fmt.Printf("⚠️  Warning: Could not push to %s: the remote rejected or needed credentials\n", gitRemote)
+		} else {
+			fmt.Printf("⚠️  Warning: Could not push to %s\n", gitRemote)
+		}
+		fmt.Printf("   %v\n", result.PushErr)
+		fmt.Println("   Your local repository is intact. Push it manually with:")
+		fmt.Printf("      cd %s && %s\n", displayDir, git.PushCommand(git.DefaultRemote, gitBranch))
+	case result.Pushed:
+		fmt.Printf("✓ Pushed initial commit to %s (%s)\n", git.DefaultRemote, gitRemote)
+	case result.RemoteAdded:
+		fmt.Printf("✓ Added Git remote %s (%s)\n", git.DefaultRemote, gitRemote)
+	default:
+		return
 	}
+	fmt.Println()
+}
 
-	// Check for invalid characters
-	invalidChars := []string{"/", "\\", ":", "*", "?", "\"", "<", ">", "|"}
-	for _, char := range invalidChars {
-		if strings.Contains(name, char) {
-			return fmt.Errorf("project name cannot contain: %s", char)
+// confirmGitInit reports whether a Git repository is initialized in the new
+// project, asking unless --no-git is set or prompts are disabled. A
+// --git-remote implies Git initialization.
+func confirmGitInit(ctx context.Context, projectDir string) (bool, error) {
+	if noGit {
+		if git.IsInsideWorkTree(ctx, filepath.Dir(projectDir)) {
+			fmt.Println("ℹ️  Skipping Git initialization: project is inside an existing Git repository")
+		} else {
+			fmt.Println("⏭️  Skipping Git initialization (--no-git)")
 		}
+		fmt.Println()
+		return false, nil
+	}
+	if gitRemote != "" || assumeYes || !tty.CanPrompt(ctx) {
+		// Use the prompt's default when prompts are disabled or impossible
+		return true, nil
 	}
 
-	// Check if reserved name
-	reserved := []string{".", "..", ".git", ".env"}
-	for _, reservedName := range reserved {
-		if name == reservedName {
-			return fmt.Errorf("'%s' is a reserved name and cannot be used", name)
+	initGit := false
+	prompt := &survey.Confirm{
+		Message: "Would you like to initialize a Git repository?",
+		Help:    "This will create a new Git repository and make an initial commit.",
+		Default: true,
+	}
+	if err := survey.AskOne(prompt, &initGit); err != nil {
+		return false, fmt.Errorf("failed to get Git initialization preference: %w", err)
+	}
+	if !initGit {
+		fmt.Println("⏭️  Skipping Git initialization")
+		fmt.Println("   You can initialize Git manually later with: git init")
+		fmt.Println()
+	}
+	return initGit, nil
+}
+
+// createVarsResult is the JSON result of create --list-vars
+type createVarsResult struct {
+	output.Envelope
+	Template       string              `json:"template"`
+	TemplateSource string              `json:"template_source"` // As in createResult
+	Variables      []template.Variable `json:"variables"`
+	Features       []template.Feature  `json:"features"`
+}
+
+// printTemplateVars prints the variables of the selected template without
+// rendering it
+func printTemplateVars(ctx context.Context) error {
+	userConfig := loadUserConfig()
+	applyDefaultTemplate(userConfig)
+
+	var ref scaffold.TemplateRef
+	switch {
+	case templateURL != "":
+		ref = scaffold.TemplateRef{URL: templateURL, Ref: templateRev, Refresh: refresh, Offline: offline}
+	case templateDir != "":
+		ref = scaffold.TemplateRef{Dir: templateDir}
+	case templatePath != "":
+		ref = scaffold.TemplateRef{Path: templatePath}
+	case templateKey != "":
+		ref = templateKeyRef(ctx, userConfig, templateKey)
+	default:
+		return clierror.UsageError("--list-vars needs a template: pass --template, --template-path, --template-url or --template-dir")
+	}
+
+	tmpl, err := openTemplate(ctx, ref)
+	if err != nil {
+		if ref.Key != "" {
+			return clierror.WithCode(clierror.Usage, err)
+		}
+		return err
+	}
+	defer func() {
+		_ = tmpl.Close()
+	}()
+	variables, err := tmpl.ListVariables(ctx)
+	if err != nil {
+		return err
+	}
+	features, err := tmpl.ListFeatures(ctx)
+	if err != nil {
+		return err
+	}
+
+	if output.IsJSON() {
+		if features == nil {
+			features = []template.Feature{}
 		}
+		return output.PrintJSON(createVarsResult{
+			Envelope:       output.NewEnvelope("create"),
+			Template:       tmpl.Name, // As create reports it, e.g., python/openai
+			TemplateSource: tmpl.Source,
+			Variables:      variables,
+			Features:       features,
+		})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tDEFAULT\tCONSTRAINTS\tDESCRIPTION")
+	for _, variable := range variables {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", variable.Name, valueOrDash(variable.Type), valueOrDash(variable.Default), valueOrDash(variable.Constraints()), valueOrDash(variable.Description))
+	}
+	if err := w.Flush(); err != nil {
+		return err
 	}
+	if len(features) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	return printFeatures(features)
+}
 
+// printFeatures prints a table of feature groups, whether each is created
+// by default and the paths it covers
+func printFeatures(features []template.Feature) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FEATURE\tDEFAULT\tPATHS\tDESCRIPTION")
+	for _, feature := range features {
+		included := "off"
+		if feature.IncludedByDefault() {
+			included = "on"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", feature.Name, included, strings.Join(feature.Paths, " "), valueOrDash(feature.Description))
+	}
+	return w.Flush()
+}
+
+// applySpec fills in the answers that were not passed on the command line
+// (as flags, or the project name as an argument) from spec
+func applySpec(flags *pflag.FlagSet, spec *template.Spec, nameArg bool) {
+	if !nameArg && !flags.Changed("name") && spec.Name != "" {
+		nameFlag = spec.Name
+	}
+	if !flags.Changed("template") && !flags.Changed("template-path") && !flags.Changed("template-url") && !flags.Changed("template-dir") {
+		templateKey, templatePath, templateURL = spec.Template, spec.TemplatePath, spec.TemplateURL
+	}
+	if !flags.Changed("author") && spec.Author != "" {
+		authorName = spec.Author
+	}
+	if !flags.Changed("license") && spec.License != "" {
+		licenseID = spec.License
+	}
+	if !flags.Changed("git-branch") && spec.GitBranch != "" {
+		gitBranch = spec.GitBranch
+	}
+	if !flags.Changed("install") && !flags.Changed("no-install") && spec.Install != nil {
+		runInstall, skipInstall = *spec.Install, !*spec.Install
+	}
+}
+
+// parseVarFlags parses --var name=value flags into answers for the template's
+// own variables, checking each value against the constraints of its variable
+func parseVarFlags(flags []string, variables []template.Variable) (map[string]string, error) {
+	answers := map[string]string{}
+	for _, flag := range flags {
+		name, value, ok := strings.Cut(flag, "=")
+		if !ok || name == "" {
+			return nil, clierror.UsageError("--var %s: expected name=value", flag)
+		}
+		variable, ok := findVariable(variables, name)
+		if !ok {
+			return nil, clierror.UsageError("--var %s: unknown variable %s (%s)", flag, name, availableVariables(variables))
+		}
+		if err := variable.Check(value); err != nil {
+			return nil, clierror.UsageError("--var %s: %v", flag, err)
+		}
+		answers[name] = value
+	}
+	return answers, nil
+}
+
+// setKeyPattern restricts --set keys to names a template can reference as
+// {{ .KEY }}
+var setKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseSetFlags parses --set KEY=VALUE flags into answers for the template's
+// own variables, checked against their constraints, and extra variables for
+// the others. The standard variables have their own argument and flags.
+func parseSetFlags(flags []string, variables []template.Variable) (map[string]string, map[string]string, error) {
+	answers, extra := map[string]string{}, map[string]string{}
+	for _, flag := range flags {
+		name, value, ok := strings.Cut(flag, "=")
+		if !ok || name == "" {
+			return nil, nil, clierror.UsageError("--set %s: expected KEY=VALUE", flag)
+		}
+		if !setKeyPattern.MatchString(name) {
+			return nil, nil, clierror.UsageError("--set %s: KEY may only contain letters, digits and _, and cannot start with a digit", flag)
+		}
+		if _, ok := findVariable(template.StandardVariables, name); ok {
+			return nil, nil, clierror.UsageError("--set %s: %s is a standard variable, set it with the project name argument, --author or --license", flag, name)
+		}
+		variable, ok := findVariable(variables, name)
+		if !ok {
+			extra[name] = value
+			continue
+		}
+		if err := variable.Check(value); err != nil {
+			return nil, nil, clierror.UsageError("--set %s: %v", flag, err)
+		}
+		answers[name] = value
+	}
+	return answers, extra, nil
+}
+
+// overrideAnswers returns answers with the values of overrides on top
+func overrideAnswers(answers, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return answers
+	}
+	merged := make(map[string]string, len(answers)+len(overrides))
+	for name, value := range answers {
+		merged[name] = value
+	}
+	for name, value := range overrides {
+		merged[name] = value
+	}
+	return merged
+}
+
+// applyModuleFlag sets the Go module path from --module, which only applies
+// to templates declaring scaffold.ModuleVariable
+func applyModuleFlag(answers map[string]string, tmpl *scaffold.Template) error {
+	if modulePath == "" {
+		return nil
+	}
+	variable, ok := findVariable(tmpl.Variables, scaffold.ModuleVariable)
+	if !ok {
+		return clierror.UsageError("--module only applies to Go templates, %s declares no %s variable", tmpl.Name, scaffold.ModuleVariable)
+	}
+	if err := variable.Check(modulePath); err != nil {
+		return clierror.UsageError("--module %s: %v", modulePath, err)
+	}
+	answers[scaffold.ModuleVariable] = modulePath
 	return nil
 }
 
-// promptLanguage prompts user to select a language
-func promptLanguage() (string, error) {
-	languages := config.GetLanguages()
-	if len(languages) == 0 {
-		return "", fmt.Errorf("no languages available in templates config")
+// selectFeatures returns the feature groups picked by --with, --without,
+// --minimal and --full, checking them against the groups the template
+// declares
+func selectFeatures(ctx context.Context, tmpl *scaffold.Template) (scaffold.FeatureSelection, error) {
+	sel := scaffold.FeatureSelection{Minimal: minimalFlag, Full: fullFlag, With: withGroups, Without: skipGroups}
+	if withExamples && !slices.Contains(withGroups, template.ExamplesFeature) {
+		sel.With = append(slices.Clone(withGroups), template.ExamplesFeature)
+	}
+	if sel.IsZero() {
+		return sel, nil
+	}
+	declared, err := tmpl.ListFeatures(ctx)
+	if err != nil {
+		return sel, err
+	}
+	if withExamples && !slices.ContainsFunc(declared, func(feature template.Feature) bool { return feature.Name == template.ExamplesFeature }) {
+		return sel, clierror.UsageError("--include-examples: template %s has no examples (its manifest declares no %s feature group)", tmpl.Name, template.ExamplesFeature)
+	}
+	if _, err := template.SelectFeatures(declared, sel); err != nil {
+		return sel, clierror.UsageError("%v", err)
 	}
 
-	var selected string
-	prompt := &survey.Select{
-		Message: "Choose a programming language:",
-		Options: languages,
-		Help:    "Select the language for your project",
+	switch {
+	case minimalFlag:
+		telemetry.RecordFlag("features", "minimal")
+	case fullFlag:
+		telemetry.RecordFlag("features", "full")
+	default:
+		telemetry.RecordFlag("features", "custom")
+	}
+	return sel, nil
+}
+
+// applyVarFlags overrides the spec's variables with the --var answers
+func applyVarFlags(spec *template.Spec, answers map[string]string) {
+	if len(answers) > 0 && spec.Variables == nil {
+		spec.Variables = map[string]string{}
 	}
+	for name, value := range answers {
+		spec.Variables[name] = value
+	}
+}
 
-	if err := survey.AskOne(prompt, &selected); err != nil {
-		return "", fmt.Errorf("failed to select language: %w", err)
+// resolveTemplateVars returns the values of the template's own variables:
+// the given answers, then a prompt for each remaining variable when
+// interactive, and the defaults otherwise. Prompts ask again until the value
+// satisfies the variable's constraints.
+func resolveTemplateVars(variables []template.Variable, answers map[string]string, interactive bool) (map[string]string, error) {
+	given := map[string]string{}
+	for name, value := range answers {
+		given[name] = value
+	}
+	if interactive {
+		for _, variable := range variables {
+			if _, ok := given[variable.Name]; ok {
+				continue
+			}
+			value, err := promptVariable(variable)
+			if err != nil {
+				return nil, err
+			}
+			given[variable.Name] = value
+		}
 	}
 
-	return selected, nil
+	values, err := scaffold.ResolveVariables(variables, given)
+	var missing *scaffold.MissingVariablesError
+	if errors.As(err, &missing) {
+		return nil, clierror.UsageError("%v (pass --var name=value)", err)
+	}
+	if err != nil {
+		return nil, clierror.WithCode(clierror.Usage, err)
+	}
+	for _, variable := range variables {
+		if variable.Sensitive {
+			logging.Redact(values[variable.Name])
+		}
+	}
+	return values, nil
 }
 
-// promptTemplate prompts user to select a template
-func promptTemplate(language string) (string, *config.Preset, error) {
-	// Check if we need to discover templates dynamically
-	needsDiscovery, err := config.NeedsTemplateDiscovery(language)
+// promptVariable asks for the value of a template variable, offering the
+// allowed values of an enum as choices
+func promptVariable(variable template.Variable) (string, error) {
+	message := variable.Name + ":"
+	if variable.Description != "" {
+		message = fmt.Sprintf("%s (%s):", variable.Description, variable.Name)
+	}
+
+	var value string
+	var err error
+	if len(variable.Enum) > 0 {
+		prompt := &survey.Select{Message: message, Options: variable.Enum}
+		if variable.Default != "" {
+			prompt.Default = variable.Default
+		}
+		err = survey.AskOne(prompt, &value)
+	} else if variable.Sensitive {
+		prompt := &survey.Password{Message: message, Help: variable.Constraints()}
+		err = survey.AskOne(prompt, &value, survey.WithValidator(func(answer interface{}) error {
+			value, _ := answer.(string)
+			if value == "" {
+				value = variable.Default
+			}
+			return variable.Check(value)
+		}))
+		if value == "" {
+			value = variable.Default
+		}
+	} else {
+		prompt := &survey.Input{Message: message, Default: variable.Default, Help: variable.Constraints()}
+		err = survey.AskOne(prompt, &value, survey.WithValidator(func(answer interface{}) error {
+			value, _ := answer.(string)
+			return variable.Check(value)
+		}))
+	}
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to check template discovery: %w", err)
+		return "", fmt.Errorf("failed to get %s: %w", variable.Name, err)
 	}
+	return value, nil
+}
 
-	// Show loading indicator if we need to discover templates
-	if needsDiscovery {
-		fmt.Print("🔍 Discovering templates from repository...")
+func findVariable(variables []template.Variable, name string) (template.Variable, bool) {
+	for _, variable := range variables {
+		if variable.Name == name {
+			return variable, true
+		}
+	}
+	return template.Variable{}, false
+}
+
+// availableVariables describes the variables --var can set, for errors
+func availableVariables(variables []template.Variable) string {
+	if len(variables) == 0 {
+		return "the template declares no variables"
 	}
+	names := make([]string, len(variables))
+	for i, variable := range variables {
+		names[i] = variable.Name
+	}
+	return "available: " + strings.Join(names, ", ")
+}
 
-	presets, err := config.GetPresets(language)
+// shouldInstall reports whether dependencies are installed after create:
+// --install and --no-install (or the spec file's install) take precedence
+// over the create.install setting, which takes precedence over the
+// template's default
+func shouldInstall(userConfig *config.UserConfig, defaults scaffold.Defaults) bool {
+	if runInstall || skipInstall {
+		return runInstall
+	}
+	fallback := false
+	if defaults.Install != nil {
+		fallback = *defaults.Install
+	}
+	return userConfig.GetBool("create.install", fallback)
+}
 
-	// Clear loading message if it was shown
-	if needsDiscovery {
-		fmt.Print("\r" + strings.Repeat(" ", 50) + "\r")
+// installDependencies installs the project's dependencies. A failure leaves
+// the project in place and is reported with the command to retry.
+func installDependencies(ctx context.Context, projectDir, displayDir, override string) *installStatus {
+	command, ok := install.Detect(projectDir, override)
+	if !ok {
+		fmt.Println("ℹ️  Skipping dependency install: no package.json, pyproject.toml, requirements.txt or go.mod found")
+		fmt.Println()
+		return nil
+	}
+
+	status := &installStatus{Command: command.String()}
+	fmt.Printf("📦 Installing dependencies (%s)...\n", command)
+	if err := install.Run(ctx, projectDir, command); err != nil {
+		status.Error = err.Error()
+		fmt.Printf("⚠️  Warning: Failed to install dependencies: %v\n", err)
+		fmt.Printf("   The project was created, retry with: cd %s && %s\n", displayDir, command)
+	} else {
+		status.OK = true
+		fmt.Println("✓ Dependencies installed")
+	}
+	fmt.Println()
+	telemetry.RecordFlag("install_ok", strconv.FormatBool(status.OK))
+	return status
+}
+
+// printDryRun prints the files that would be written and the steps that would run
+func printDryRun(ctx context.Context, projectName, displayDir string, tmpl *scaffold.Template, features scaffold.FeatureSelection, projectLicense string, installDeps bool) error {
+	files, err := tmpl.Files(ctx, features)
+	if err != nil {
+		return withScaffoldHint(fmt.Errorf("failed to list template files: %w", err))
+	}
+	if projectLicense != "" && !slices.Contains(files, license.FileName) {
+		files = append(files, license.FileName)
+		sort.Strings(files)
+	}
+	if withCI && !slices.Contains(files, ci.WorkflowFile) {
+		files = append(files, ci.WorkflowFile)
+		sort.Strings(files)
+	}
+
+	if output.IsJSON() {
+		for i, file := range files {
+			files[i] = filepath.ToSlash(filepath.Join(displayDir, file))
+		}
+		return output.PrintJSON(createResult{
+			Envelope:       output.NewEnvelope("create"),
+			Project:        projectName,
+			Template:       tmpl.Name,
+			TemplateSource: tmpl.Source,
+			Commit:         tmpl.Commit,
+			License:        projectLicense,
+			DryRun:         true,
+			Files:          files,
+			FileCount:      len(files),
+		})
+	}
+
+	fmt.Println("🔍 Dry run: nothing will be written to disk")
+	fmt.Println()
+	fmt.Println("Files:")
+	for _, file := range files {
+		fmt.Printf("  %s\n", filepath.ToSlash(filepath.Join(displayDir, file)))
+	}
+	fmt.Println()
+	fmt.Println("Steps:")
+	fmt.Printf("  mkdir -p %s\n", displayDir)
+	fmt.Printf("  copy template %s (%s)\n", tmpl.Name, tmpl.Source)
+	if len(tmpl.Features) > 0 {
+		fmt.Printf("  feature groups: %s\n", describeFeatures(tmpl.Features, features))
+	}
+	var manifests []string
+	for _, manifest := range []string{"pyproject.toml", "package.json", "Cargo.toml"} {
+		if slices.Contains(files, manifest) {
+			manifests = append(manifests, manifest)
+		}
+	}
+	if len(manifests) > 0 {
+		fmt.Printf("  update project name in %s\n", strings.Join(manifests, " / "))
+	}
+	if _, ok := findVariable(tmpl.Variables, scaffold.ModuleVariable); ok {
+		module := modulePath
+		if module == "" {
+			module = projectName
+		}
+		fmt.Printf("  set the module path in go.mod to %s\n", module)
+	}
+	if projectLicense != "" {
+		fmt.Printf("  write %s (%s)\n", license.FileName, projectLicense)
+	}
+	if withCI {
+		fmt.Printf("  write %s (install dependencies and run tests)\n", ci.WorkflowFile)
+	}
+	fmt.Printf("  write %s (if the template does not provide one)\n", deploy.ProjectFile)
+	if !noProvenance {
+		fmt.Printf("  write %s\n", scaffold.ProvenanceFile)
+	}
+	if postCreate != "" {
+		fmt.Printf("  run post-create script %s\n", postCreate)
+	} else if tmpl.PostCreate != "" {
+		fmt.Printf("  run post-create script %s (from the template)\n", tmpl.PostCreate)
+	}
+	if noGit {
+		fmt.Println("  git: skipped (--no-git)")
+	} else {
+		fmt.Printf("  git init (branch: %s) && git add . && git commit (if confirmed)\n", gitBranch)
+	}
+	if gitRemote != "" {
+		fmt.Printf("  git remote add %s %s\n", git.DefaultRemote, gitRemote)
+	}
+	if gitPush {
+		fmt.Printf("  %s\n", git.PushCommand(git.DefaultRemote, gitBranch))
+	}
+	if installDeps {
+		fmt.Println("  install dependencies (npm install, poetry install, pip install -r requirements.txt or go mod download, depending on the template)")
+	} else {
+		fmt.Println("  install: skipped (pass --install to install dependencies)")
+	}
+	fmt.Println("  docker: none (run 'acontext docker up' after creation)")
+	if openEditor {
+		fmt.Println("  open the project in your editor (create.editor, $VISUAL, $EDITOR or code)")
 	}
 
+	return nil
+}
+
+// describeFeatures lists the selected feature groups and those left out,
+// e.g., "tests, docker (left out: ci)"
+func describeFeatures(declared []template.Feature, sel scaffold.FeatureSelection) string {
+	selected, err := template.SelectFeatures(declared, sel)
+	if err != nil {
+		return err.Error()
+	}
+	included := map[string]bool{}
+	for _, name := range selected {
+		included[name] = true
+	}
+	var omitted []string
+	for _, feature := range declared {
+		if !included[feature.Name] {
+			omitted = append(omitted, feature.Name)
+		}
+	}
+
+	description := "none"
+	if len(selected) > 0 {
+		description = strings.Join(selected, ", ")
+	}
+	if len(omitted) > 0 {
+		description += " (left out: " + strings.Join(omitted, ", ") + ")"
+	}
+	return description
+}
+
+// isWithinDir reports whether path is dir or inside it
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// relativeToCwd returns dir relative to the current directory when it is
+// inside it, for shorter messages, and dir itself otherwise
+func relativeToCwd(dir string) string {
+	cwd, err := os.Getwd()
+	if err != nil || !isWithinDir(dir, cwd) {
+		return dir
+	}
+	rel, err := filepath.Rel(cwd, dir)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to get presets: %w", err)
+		return dir
+	}
+	return rel
+}
+
+// checkProjectDir fails when dir already exists and is not a directory, or is
+// a non-empty directory and force is not set
+func checkProjectDir(dir string, force bool) error {
+	return withScaffoldHint(scaffold.CheckDir(dir, force))
+}
+
+// withScaffoldHint explains how to fix a scaffold that failed because the
+// directory is not empty, the template does not exist or the project cannot
+// be written
+func withScaffoldHint(err error) error {
+	var notEmpty *scaffold.NotEmptyError
+	var pathErr *fs.PathError
+	switch {
+	case errors.As(err, &notEmpty):
+		return clierror.WithHint(err, fmt.Sprintf("Use --force to overwrite conflicting files (backups are saved to %s/), or choose another directory.", scaffold.BackupDir))
+	case errors.Is(err, template.ErrTemplateNotFound):
+		return clierror.WithHint(err, "Run `acontext template list` to see the available templates, or check --template-path.")
+	case errors.Is(err, fs.ErrPermission) && errors.As(err, &pathErr):
+		return clierror.WithHint(err, fmt.Sprintf("Check that you can write to %s, or create the project in another directory.", filepath.Dir(pathErr.Path)))
+	}
+	return err
+}
+
+// setupStopped returns an error when ctx was cancelled after the project was
+// written, so the remaining setup steps are skipped but the project is kept
+func setupStopped(ctx context.Context, displayDir string) error {
+	if cause := context.Cause(ctx); cause != nil {
+		return fmt.Errorf("project created in %s, remaining setup skipped: %w", displayDir, cause)
+	}
+	return nil
+}
+
+// templateChoice is a template offered by the interactive picker
+type templateChoice struct {
+	Language string
+	Preset   config.Preset
+}
+
+// listTemplateChoices returns the templates of every language, sorted by
+// language and in preset order within a language
+func listTemplateChoices(ctx context.Context) ([]templateChoice, error) {
+	languages := config.GetLanguages()
+	if len(languages) == 0 {
+		return nil, fmt.Errorf("no languages available in templates config")
+	}
+	sort.Strings(languages)
+
+	var choices []templateChoice
+	for _, language := range languages {
+		presets, err := config.GetPresets(ctx, language)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get presets for %s: %w", language, err)
+		}
+		for _, preset := range presets {
+			choices = append(choices, templateChoice{Language: language, Preset: preset})
+		}
+	}
+
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("no templates available")
+	}
+	return choices, nil
+}
+
+// templateOptions returns the picker entry for each choice, showing the
+// language next to the template name, and the one-line summary of each
+func templateOptions(choices []templateChoice) ([]string, []string) {
+	width := 0
+	for _, choice := range choices {
+		width = max(width, len(choice.Language))
 	}
 
-	if len(presets) == 0 {
-		return "", nil, fmt.Errorf("no presets available for language: %s", language)
+	options := make([]string, len(choices))
+	summaries := make([]string, len(choices))
+	for i, choice := range choices {
+		options[i] = fmt.Sprintf("%-*s  %s", width, choice.Language, choice.Preset.Name)
+		summaries[i] = choice.Preset.Description
+	}
+	return options, summaries
+}
+
+// promptTemplate prompts user to select a template from all languages
+func promptTemplate(ctx context.Context) (string, *config.Preset, error) {
+	// Show loading indicator if we need to discover templates
+	needsDiscovery := false
+	for _, language := range config.GetLanguages() {
+		if discover, err := config.NeedsTemplateDiscovery(language); err == nil && discover {
+			needsDiscovery = true
+		}
+	}
+	var spinner *progress.Spinner
+	if needsDiscovery {
+		spinner = progress.StartTransient(ctx, "🔍 Discovering templates from repository...")
 	}
 
-	// Create options list (simplified - just show template names)
-	options := make([]string, len(presets))
-	optionsMap := make(map[string]*config.Preset)
+	choices, err := listTemplateChoices(ctx)
+	if spinner != nil {
+		spinner.Stop()
+	}
 
-	for i, preset := range presets {
-		options[i] = preset.Name
-		optionsMap[preset.Name] = &presets[i]
+	if err != nil {
+		return "", nil, err
 	}
 
-	var selectedOption string
+	options, summaries := templateOptions(choices)
+	var selected int
 	prompt := &survey.Select{
 		Message: "Choose a template:",
 		Options: options,
 		Help:    "Select a template that matches your needs",
+		Description: func(_ string, index int) string {
+			return summaries[index]
+		},
 	}
 
-	if err := survey.AskOne(prompt, &selectedOption); err != nil {
+	if err := survey.AskOne(prompt, &selected, survey.WithPageSize(12)); err != nil {
 		return "", nil, fmt.Errorf("failed to select template: %w", err)
 	}
 
-	preset, ok := optionsMap[selectedOption]
-	if !ok {
-		return "", nil, fmt.Errorf("selected preset not found")
-	}
-
+	preset := &choices[selected].Preset
 	return preset.Template, preset, nil
 }
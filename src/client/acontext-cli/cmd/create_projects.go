@@ -0,0 +1,426 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/config"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/git"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/telemetry"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/template"
+	"github.com/memodb-io/Acontext/acontext-cli/pkg/scaffold"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// projectsFlags are the create flags that apply to --projects; the others
+// describe a single project, which the manifest describes for each one
+var projectsFlags = map[string]bool{
+	"projects":             true,
+	"mono-git":             true,
+	"keep-partial":         true,
+	"no-git":               true,
+	"git-branch":           true,
+	"author":               true,
+	"license":              true,
+	"with-github-actions":  true,
+	"yes":                  true,
+	"force":                true,
+	"refresh":              true,
+	"offline":              true,
+	"git-token":            true,
+	"install":              true,
+	"no-install":           true,
+	"no-provenance":        true,
+	"post-create-script":   true,
+	"ignore-hook-errors":   true,
+	"dry-run":              true,
+	"template-search-path": true,
+}
+
+// Statuses of the projects of a manifest
+const (
+	projectPlanned    = "planned"
+	projectCreated    = "created"
+	projectFailed     = "failed"
+	projectRolledBack = "rolled back"
+	projectKept       = "kept"
+	projectSkipped    = "skipped"
+)
+
+// createdProject is the outcome of one project of a manifest
+type createdProject struct {
+	Name     string         `json:"name"`
+	Path     string         `json:"path"`
+	Template string         `json:"template"`
+	Status   string         `json:"status"`
+	Files    []string       `json:"files,omitempty"`
+	Git      bool           `json:"git_initialized,omitempty"`
+	Install  *installStatus `json:"install,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// createProjectsResult is the JSON result of create --projects
+type createProjectsResult struct {
+	output.Envelope
+	Root     string           `json:"root"`
+	MonoGit  bool             `json:"mono_git"`
+	DryRun   bool             `json:"dry_run,omitempty"`
+	Projects []createdProject `json:"projects"`
+}
+
+// plannedProject is a project of the manifest, resolved before anything is
+// written
+type plannedProject struct {
+	spec   template.ProjectSpec
+	dir    string // Absolute project directory
+	tmpl   *scaffold.Template
+	values map[string]string
+	// license is the SPDX identifier of the project's license, "" for none
+	license string
+	// branch is the initial branch of the project's Git repository
+	branch string
+	// installDeps reports whether the project's dependencies are installed
+	installDeps bool
+	// partial records what rolling the project back removes
+	partial partialProject
+	result  *scaffold.Result
+	status  string
+	err     error
+	install *installStatus
+}
+
+// runCreateProjects creates every project of the --projects manifest under
+// args[0], or the current directory. Everything is checked, and every
+// template opened, before the first project is written; a project that
+// fails rolls back the ones created before it, unless --keep-partial is set.
+func runCreateProjects(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	manifest, err := template.LoadProjects(projectsFile)
+	if err != nil {
+		return clierror.WithCode(clierror.Usage, err)
+	}
+	rootDir := "."
+	if len(args) > 0 {
+		rootDir = args[0]
+	}
+	root, err := filepath.Abs(rootDir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	telemetry.RecordFlag("project_count", strconv.Itoa(len(manifest.Projects)))
+
+	userConfig := loadUserConfig()
+	planned := make([]*plannedProject, len(manifest.Projects))
+	for i, spec := range manifest.Projects {
+		if err := scaffold.ValidateName(spec.Name); err != nil {
+			return clierror.UsageError("project %s: %v", spec.Name, err)
+		}
+		dir := filepath.Join(root, filepath.FromSlash(spec.Dir()))
+		if err := scaffold.ValidateDir(dir); err != nil {
+			return clierror.UsageError("project %s: %v", spec.Name, err)
+		}
+		if err := checkProjectDir(dir, force); err != nil {
+			return fmt.Errorf("project %s: %w", spec.Name, err)
+		}
+		planned[i] = &plannedProject{spec: spec, dir: dir, status: projectPlanned}
+	}
+
+	// Open every template first, so a bad one fails before anything is written
+	for _, project := range planned {
+		if project.tmpl, err = openTemplate(ctx, projectTemplateRef(ctx, userConfig, project.spec)); err != nil {
+			return fmt.Errorf("project %s: %w", project.spec.Name, err)
+		}
+		defer func(tmpl *scaffold.Template) {
+			_ = tmpl.Close()
+		}(project.tmpl)
+
+		spec := project.spec.Spec
+		variables := scaffold.DefaultVariables(project.tmpl.Variables, spec.Name)
+		if err := spec.Validate(template.Variables(&template.Manifest{Variables: variables})); err != nil {
+			return clierror.WithCode(clierror.Usage, fmt.Errorf("%s: project %s: %w", projectsFile, spec.Name, err))
+		}
+		project.values, err = resolveTemplateVars(variables, spec.Variables, false)
+		if err != nil {
+			return fmt.Errorf("project %s: %w", spec.Name, err)
+		}
+		id, source := spec.License, projectsFile
+		if id == "" {
+			id, source = licenseID, "--license"
+		}
+		if project.license, err = resolveLicense(userConfig, id, source, project.tmpl.Defaults); err != nil {
+			return fmt.Errorf("project %s: %w", spec.Name, err)
+		}
+		branch := spec.GitBranch
+		if branch == "" {
+			branch = gitBranch
+		}
+		project.branch = resolveGitBranch(userConfig, branch, project.tmpl.Defaults)
+		project.installDeps = shouldInstall(userConfig, project.tmpl.Defaults)
+		if spec.Install != nil {
+			project.installDeps = *spec.Install
+		}
+	}
+
+	if dryRun {
+		return printProjects(root, planned, true)
+	}
+
+	author := authorName
+	if author == "" {
+		author, _ = userConfig.Get("create.author")
+	}
+	var createErr error
+	for _, project := range planned {
+		if createErr != nil {
+			project.status = projectSkipped
+			continue
+		}
+		if err := createProject(ctx, project, author); err != nil {
+			project.status, project.err = projectFailed, err
+			createErr = fmt.Errorf("project %s: %w", project.spec.Name, err)
+			continue
+		}
+		project.status = projectCreated
+	}
+	if createErr != nil {
+		if !keepPartial {
+			rollbackProjects(planned)
+		}
+		if err := printProjects(root, planned, false); err != nil {
+			return err
+		}
+		return createErr
+	}
+
+	if monoGit {
+		initMonoGit(ctx, root, resolveGitBranch(userConfig, gitBranch, scaffold.Defaults{}))
+	}
+	for _, project := range planned {
+		if !project.installDeps {
+			continue
+		}
+		displayDir := relativeToCwd(project.dir)
+		project.install = installDependencies(ctx, project.dir, displayDir, project.tmpl.Install)
+		if err := setupStopped(ctx, displayDir); err != nil {
+			return err
+		}
+	}
+	return printProjects(root, planned, false)
+}
+
+// checkProjectsFlags rejects the arguments and create flags that do not
+// apply to --projects, and --mono-git without it
+func checkProjectsFlags(cmd *cobra.Command, args []string) error {
+	if projectsFile == "" {
+		switch {
+		case monoGit:
+			return clierror.UsageError("--mono-git needs --projects")
+		}
+		return nil
+	}
+	if len(args) > 1 {
+		return clierror.UsageError("--projects takes at most one argument, the root directory of the projects")
+	}
+	var conflicting []string
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if !projectsFlags[flag.Name] && cmd.InheritedFlags().Lookup(flag.Name) == nil {
+			conflicting = append(conflicting, "--"+flag.Name)
+		}
+	})
+	if len(conflicting) > 0 {
+		return clierror.UsageError("--projects cannot be combined with %s: the manifest describes each project", strings.Join(conflicting, ", "))
+	}
+	if monoGit && noGit {
+		return clierror.UsageError("--mono-git and --no-git are contradictory")
+	}
+	return nil
+}
+
+// projectTemplateRef returns the template a project of the manifest is
+// created from
+func projectTemplateRef(ctx context.Context, userConfig *config.UserConfig, spec template.ProjectSpec) scaffold.TemplateRef {
+	switch {
+	case spec.TemplateURL != "":
+		return scaffold.TemplateRef{URL: spec.TemplateURL, Refresh: refresh, Offline: offline}
+	case spec.TemplatePath != "":
+		return scaffold.TemplateRef{Path: spec.TemplatePath}
+	}
+	return templateKeyRef(ctx, userConfig, spec.Template)
+}
+
+// createProject writes one project of the manifest, recording what rolling
+// it back removes
+func createProject(ctx context.Context, project *plannedProject, author string) error {
+	project.partial = trackProject(project.dir)
+
+	if project.spec.Author != "" {
+		author = project.spec.Author
+	}
+	fmt.Printf("📦 Creating project: %s\n", project.spec.Name)
+	result, err := scaffold.Scaffold(ctx, scaffold.Options{
+		Name:      project.spec.Name,
+		Dir:       project.dir,
+		Template:  project.tmpl,
+		Vars:      project.values,
+		Author:    author,
+		License:   project.license,
+		CI:        withCI,
+		Force:     force,
+		Git:       !noGit && !monoGit,
+		GitBranch: project.branch,
+
+		Provenance: !noProvenance,
+		Version:    cliVersion,
+
+		PostCreate:       postCreate,
+		IgnoreHookErrors: ignoreHooks,
+	})
+	project.result = result
+	if err != nil {
+		return err
+	}
+	if result.GitErr != nil {
+		if project.partial.fresh && !keepPartial {
+			return result.GitErr
+		}
+		fmt.Printf("⚠️  Warning: Failed to initialize Git in %s: %v\n", relativeToCwd(project.dir), result.GitErr)
+	}
+	if result.HookErr != nil {
+		fmt.Printf("⚠️  Warning: Post-create script failed in %s, the project was kept (--ignore-hook-errors): %v\n", relativeToCwd(project.dir), result.HookErr)
+	}
+	return nil
+}
+
+// missingAncestor returns the topmost directory of dir and its parents that
+// does not exist, or "" when dir exists
+func missingAncestor(dir string) string {
+	missing := ""
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return missing
+		}
+		missing = dir
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return missing
+		}
+		dir = parent
+	}
+}
+
+// rollbackProjects removes the projects that were written, keeping the ones
+// written into a directory that had files before
+func rollbackProjects(planned []*plannedProject) {
+	for _, project := range planned {
+		if project.result == nil {
+			continue
+		}
+		if !project.partial.fresh {
+			if project.status == projectCreated {
+				project.status = projectKept
+			}
+			continue
+		}
+		_, _ = project.partial.rollback()
+		project.result = nil
+		if project.status == projectCreated {
+			project.status = projectRolledBack
+		}
+	}
+}
+
+// initMonoGit initializes a single Git repository with every project in
+// root, unless root is already inside one
+func initMonoGit(ctx context.Context, root, branch string) {
+	if git.IsInsideWorkTree(ctx, root) {
+		fmt.Println("ℹ️  Skipping Git initialization: the projects are inside an existing Git repository")
+		return
+	}
+	if err := git.Init(ctx, root, branch); err != nil {
+		fmt.Printf("⚠️  Warning: Failed to initialize Git in %s: %v\n", relativeToCwd(root), err)
+		fmt.Println("   You can initialize Git manually later with: git init")
+		return
+	}
+	fmt.Printf("✓ Git repository initialized in %s (branch: %s)\n", relativeToCwd(root), branch)
+	telemetry.RecordFlag("git_init", "true")
+}
+
+// printProjects reports the outcome of each project of the manifest as a
+// summary table, or of a dry run
+func printProjects(root string, planned []*plannedProject, dryRun bool) error {
+	projects := make([]createdProject, len(planned))
+	failed := false
+	for i, project := range planned {
+		projects[i] = createdProject{
+			Name:     project.spec.Name,
+			Path:     project.dir,
+			Template: project.spec.TemplateSource(),
+			Status:   project.status,
+			Install:  project.install,
+		}
+		if project.result != nil {
+			projects[i].Files = project.result.Files
+			projects[i].Git = project.result.GitInitialized
+		}
+		if project.err != nil {
+			projects[i].Error = project.err.Error()
+			failed = true
+		}
+	}
+
+	if output.IsJSON() {
+		return output.PrintJSON(createProjectsResult{
+			Envelope: output.NewEnvelope("create"),
+			Root:     root,
+			MonoGit:  monoGit,
+			DryRun:   dryRun,
+			Projects: projects,
+		})
+	}
+
+	fmt.Println()
+	if dryRun {
+		fmt.Println("🔍 Dry run: nothing will be written to disk")
+		fmt.Println()
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tPATH\tTEMPLATE\tSTATUS\tFILES")
+	for _, project := range projects {
+		files := "-"
+		if project.Files != nil {
+			files = strconv.Itoa(len(project.Files))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", project.Name, relativeToCwd(project.Path), project.Template, project.Status, files)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Println()
+
+	switch {
+	case dryRun:
+	case !failed:
+		fmt.Printf("✅ Created %d project(s) in %s\n", len(projects), relativeToCwd(root))
+		fmt.Println()
+	case keepPartial:
+		fmt.Println("⚠️  The projects created before the failure were kept (--keep-partial), the others were not created")
+		fmt.Println()
+	default:
+		fmt.Println("⚠️  Rolled back the projects created before the failure, pass --keep-partial to keep them")
+		for _, project := range projects {
+			if project.Status == projectKept {
+				fmt.Printf("   %s was kept: it was created in a directory that had files before\n", project.Name)
+			}
+		}
+		fmt.Println()
+	}
+	return nil
+}
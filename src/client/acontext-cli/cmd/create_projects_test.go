@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/pkg/scaffold"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckProjectsFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "single project", args: []string{"my-agent", "--template", "go.basic"}},
+		{name: "projects flags", args: []string{"--projects", "projects.yaml", "--mono-git", "--keep-partial", "--force", "-o", "json", "./monorepo"}},
+		{name: "create flags", args: []string{"--projects", "projects.yaml", "--template", "go.basic"}, wantErr: "--projects cannot be combined with --template"},
+		{name: "two arguments", args: []string{"--projects", "projects.yaml", "a", "b"}, wantErr: "--projects takes at most one argument"},
+		{name: "mono-git without projects", args: []string{"my-agent", "--mono-git"}, wantErr: "--mono-git needs --projects"},
+		{name: "keep-partial without projects", args: []string{"my-agent", "--keep-partial"}},
+		{name: "mono-git and no-git", args: []string{"--projects", "projects.yaml", "--mono-git", "--no-git"}, wantErr: "--mono-git and --no-git are contradictory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				projectsFile, monoGit, keepPartial, noGit, force = "", false, false, false, false
+			})
+			root := &cobra.Command{Use: "acontext"}
+			root.PersistentFlags().StringP("output", "o", "text", "")
+			cmd := &cobra.Command{Use: "create"}
+			cmd.Flags().StringVar(&projectsFile, "projects", "", "")
+			cmd.Flags().BoolVar(&monoGit, "mono-git", false, "")
+			cmd.Flags().BoolVar(&keepPartial, "keep-partial", false, "")
+			cmd.Flags().BoolVar(&noGit, "no-git", false, "")
+			cmd.Flags().BoolVar(&force, "force", false, "")
+			cmd.Flags().String("template", "", "")
+			root.AddCommand(cmd)
+			require.NoError(t, cmd.ParseFlags(tt.args))
+
+			err := checkProjectsFlags(cmd, cmd.Flags().Args())
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, clierror.Usage, clierror.Code(err))
+		})
+	}
+}
+
+func TestMissingAncestor(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, "", missingAncestor(dir))
+	assert.Equal(t, filepath.Join(dir, "services"), missingAncestor(filepath.Join(dir, "services", "api")))
+}
+
+func TestRollbackProjects(t *testing.T) {
+	root := t.TempDir()
+	existing := filepath.Join(root, "existing")
+	require.NoError(t, os.MkdirAll(existing, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(existing, "keep.txt"), nil, 0644))
+	empty := filepath.Join(root, "empty")
+	require.NoError(t, os.MkdirAll(filepath.Join(empty, "src"), 0755))
+	created := filepath.Join(root, "services")
+	require.NoError(t, os.MkdirAll(filepath.Join(created, "api"), 0755))
+
+	planned := []*plannedProject{
+		{dir: filepath.Join(created, "api"), partial: partialProject{dir: filepath.Join(created, "api"), created: created, fresh: true}, result: &scaffold.Result{}, status: projectCreated},
+		{dir: empty, partial: partialProject{dir: empty, fresh: true}, result: &scaffold.Result{}, status: projectCreated},
+		{dir: existing, result: &scaffold.Result{}, status: projectCreated},
+		{dir: filepath.Join(root, "failed"), status: projectFailed},
+		{dir: filepath.Join(root, "skipped"), status: projectSkipped},
+	}
+	rollbackProjects(planned)
+
+	assert.NoDirExists(t, created)
+	entries, err := os.ReadDir(empty)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the directory existed, only its content is removed")
+	assert.FileExists(t, filepath.Join(existing, "keep.txt"))
+
+	var statuses []string
+	for _, project := range planned {
+		statuses = append(statuses, project.status)
+	}
+	assert.Equal(t, []string{projectRolledBack, projectRolledBack, projectKept, projectFailed, projectSkipped}, statuses)
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/memodb-io/Acontext/acontext-cli/pkg/scaffold"
+)
+
+// partialProject records what writing a project into dir creates, taken
+// before anything is written, so that a create that fails can be rolled back
+type partialProject struct {
+	dir string
+	// created is the topmost directory that did not exist before the project
+	// was written, removed to roll it back; empty when the project directory
+	// existed
+	created string
+	// fresh reports whether the project directory was missing or empty, so
+	// rolling back leaves it as it was
+	fresh bool
+}
+
+// removedPath is a file or directory removed by rolling back a project
+type removedPath struct {
+	Path  string // Absolute path
+	Dir   bool   // The path was a directory
+	Files int    // Number of files the directory held
+}
+
+// String returns the path relative to the current directory, directories
+// with a trailing separator and the number of files they held
+func (r removedPath) String() string {
+	if !r.Dir {
+		return relativeToCwd(r.Path)
+	}
+	return fmt.Sprintf("%s%c (%d file(s))", relativeToCwd(r.Path), filepath.Separator, r.Files)
+}
+
+// trackProject records the state of dir before a project is written into it
+func trackProject(dir string) partialProject {
+	entries, _ := os.ReadDir(dir)
+	return partialProject{dir: dir, created: missingAncestor(dir), fresh: len(entries) == 0}
+}
+
+// written returns what was written since trackProject that rolling back
+// removes, sorted by path: the topmost directory create made, or else the
+// entries of a project directory that was empty. Nothing is removed from a
+// directory that had files before.
+func (p partialProject) written() []string {
+	if !p.fresh {
+		return nil
+	}
+	if p.created != "" {
+		if _, err := os.Lstat(p.created); err != nil {
+			return nil
+		}
+		return []string{p.created}
+	}
+	entries, _ := os.ReadDir(p.dir)
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		paths = append(paths, filepath.Join(p.dir, entry.Name()))
+	}
+	return paths
+}
+
+// rollback removes what was written since trackProject, leaving the
+// filesystem as it was before, and returns what it removed. It stops at the
+// first path it cannot remove.
+func (p partialProject) rollback() ([]removedPath, error) {
+	var removed []removedPath
+	for _, path := range p.written() {
+		entry := removedPath{Path: path}
+		if info, err := os.Lstat(path); err == nil && info.IsDir() {
+			entry.Dir = true
+			_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					entry.Files++
+				}
+				return nil
+			})
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", relativeToCwd(path), err)
+		}
+		removed = append(removed, entry)
+	}
+	return removed, nil
+}
+
+// rollbackCreate removes what a create that failed with err wrote, unless
+// --keep-partial is set, and prints what it removed. It returns err, telling
+// that the project is gone when it was rolled back.
+func rollbackCreate(ctx context.Context, partial partialProject, err error) error {
+	if len(partial.written()) == 0 {
+		return err
+	}
+	if keepPartial {
+		fmt.Printf("⚠️  Kept the partially created project in %s (--keep-partial)\n", relativeToCwd(partial.dir))
+		return err
+	}
+
+	removed, rollbackErr := partial.rollback()
+	if len(removed) > 0 {
+		fmt.Println("⚠️  Rolled back the partially created project, pass --keep-partial to keep it. Removed:")
+		for _, path := range removed {
+			fmt.Printf("   - %s\n", path)
+		}
+	}
+	if rollbackErr != nil {
+		fmt.Printf("⚠️  Warning: Failed to roll back the partially created project: %v\n", rollbackErr)
+		return err
+	}
+
+	var hookErr *scaffold.HookError
+	if errors.As(err, &hookErr) {
+		hookErr.Kept = false
+	}
+	if cause := context.Cause(ctx); cause != nil {
+		return fmt.Errorf("project was not created: %w", cause)
+	}
+	return err
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/tty"
+	"github.com/memodb-io/Acontext/acontext-cli/pkg/scaffold"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTree writes files, relative paths to their content, under dir
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// listTree returns the relative paths of the files and directories under dir
+func listTree(t *testing.T, dir string) []string {
+	t.Helper()
+	paths := []string{}
+	require.NoError(t, filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	}))
+	return paths
+}
+
+func TestPartialProjectRollback(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      map[string]string // Files under the root before the project is written
+		emptyDirs   []string          // Empty directories under the root before the project is written
+		dir         string            // Project directory, relative to the root
+		wantRemoved []removedPath     // Relative to the root
+	}{
+		{
+			name:        "created with its parents",
+			dir:         "apps/services/api",
+			wantRemoved: []removedPath{{Path: "apps", Dir: true, Files: 3}},
+		},
+		{
+			name:        "created in an existing parent",
+			before:      map[string]string{"apps/web/index.html": ""},
+			dir:         "apps/api",
+			wantRemoved: []removedPath{{Path: "apps/api", Dir: true, Files: 3}},
+		},
+		{
+			name:      "empty directory",
+			emptyDirs: []string{"api"},
+			dir:       "api",
+			wantRemoved: []removedPath{
+				{Path: "api/README.md"},
+				{Path: "api/src", Dir: true, Files: 2},
+			},
+		},
+		{
+			name:   "directory with files",
+			before: map[string]string{"api/notes.txt": "mine"},
+			dir:    "api",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.before)
+			dir := filepath.Join(root, filepath.FromSlash(tt.dir))
+			for _, empty := range tt.emptyDirs {
+				require.NoError(t, os.MkdirAll(filepath.Join(root, empty), 0755))
+			}
+			before := listTree(t, root)
+
+			partial := trackProject(dir)
+			writeTree(t, dir, map[string]string{"README.md": "# api", "src/main.go": "package main", "src/util.go": "package main"})
+			removed, err := partial.rollback()
+			require.NoError(t, err)
+
+			for i := range tt.wantRemoved {
+				tt.wantRemoved[i].Path = filepath.Join(root, filepath.FromSlash(tt.wantRemoved[i].Path))
+			}
+			assert.Equal(t, tt.wantRemoved, removed)
+			if len(tt.wantRemoved) > 0 {
+				assert.Equal(t, before, listTree(t, root), "the filesystem is as it was before")
+			} else {
+				assert.FileExists(t, filepath.Join(dir, "README.md"), "a directory that had files is left as it is")
+			}
+		})
+	}
+}
+
+func TestRemovedPathString(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	assert.Equal(t, "my-app"+string(filepath.Separator)+" (3 file(s))", removedPath{Path: filepath.Join(cwd, "my-app"), Dir: true, Files: 3}.String())
+	assert.Equal(t, filepath.Join("my-app", "README.md"), removedPath{Path: filepath.Join(cwd, "my-app", "README.md")}.String())
+}
+
+func TestCreateRollsBackPartialProject(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	// The post-create script fails once the template files are written
+	tmplDir := t.TempDir()
+	writeTree(t, tmplDir, map[string]string{
+		"acontext.template.yaml": "name: failing\npost_create: scripts/setup.sh\n",
+		"README.md":              "# failing\n",
+		"scripts/setup.sh":       "echo partial > hook.txt\nexit 3\n",
+	})
+	t.Chdir(t.TempDir())
+	t.Setenv("ACONTEXT_HOME", t.TempDir())
+	CreateCmd.SetContext(tty.WithNoInput(context.Background()))
+	templateDir = tmplDir
+	t.Cleanup(func() {
+		CreateCmd.SetContext(nil)
+		templateDir, keepPartial = "", false
+	})
+
+	t.Run("created directories are removed", func(t *testing.T) {
+		err := runCreate(CreateCmd, []string{"my-app", filepath.Join("apps", "my-app")})
+		var hookErr *scaffold.HookError
+		require.ErrorAs(t, err, &hookErr)
+		assert.ErrorContains(t, err, "exit status 3 (the project was removed)")
+		assert.NoDirExists(t, "apps", "the parent directory create made is removed too")
+	})
+
+	t.Run("an empty directory is emptied", func(t *testing.T) {
+		require.NoError(t, os.Mkdir("empty", 0755))
+		err := runCreate(CreateCmd, []string{"my-app", "empty"})
+		assert.ErrorContains(t, err, "exit status 3")
+		assert.Empty(t, listTree(t, "empty"))
+	})
+
+	t.Run("keep partial", func(t *testing.T) {
+		keepPartial = true
+		err := runCreate(CreateCmd, []string{"my-app", "kept"})
+		assert.ErrorContains(t, err, "exit status 3 (the project was kept in ")
+		assert.FileExists(t, filepath.Join("kept", "README.md"))
+		assert.FileExists(t, filepath.Join("kept", "hook.txt"))
+	})
+}
+
+func TestCreateRollsBackFailedGitInit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	// git fails for every command, so Git initialization fails once the
+	// template files are written
+	bin := t.TempDir()
+	writeTree(t, bin, map[string]string{"git": "#!/bin/sh\necho 'git: broken' >&2\nexit 1\n"})
+	require.NoError(t, os.Chmod(filepath.Join(bin, "git"), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tmplDir := t.TempDir()
+	writeTree(t, tmplDir, map[string]string{"acontext.template.yaml": "name: app\n", "README.md": "# app\n"})
+	t.Chdir(t.TempDir())
+	t.Setenv("ACONTEXT_HOME", t.TempDir())
+	CreateCmd.SetContext(tty.WithNoInput(context.Background()))
+	templateDir = tmplDir
+	t.Cleanup(func() {
+		CreateCmd.SetContext(nil)
+		templateDir, keepPartial = "", false
+	})
+
+	t.Run("the project is removed", func(t *testing.T) {
+		err := runCreate(CreateCmd, []string{"my-app", filepath.Join("apps", "my-app")})
+		assert.ErrorContains(t, err, "failed to initialize git: exit status 1")
+		assert.NoDirExists(t, "apps")
+	})
+
+	t.Run("keep partial", func(t *testing.T) {
+		keepPartial = true
+		require.NoError(t, runCreate(CreateCmd, []string{"my-app", "kept"}), "a failed Git initialization only warns")
+		assert.FileExists(t, filepath.Join("kept", "README.md"))
+	})
+}
@@ -1,93 +1,1000 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/config"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/template"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/tty"
+	"github.com/memodb-io/Acontext/acontext-cli/pkg/scaffold"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestValidateProjectName(t *testing.T) {
+func TestCheckProjectDir(t *testing.T) {
+	root := t.TempDir()
+
+	emptyDir := filepath.Join(root, "empty")
+	require.NoError(t, os.Mkdir(emptyDir, 0755))
+
+	nonEmptyDir := filepath.Join(root, "non-empty")
+	require.NoError(t, os.Mkdir(nonEmptyDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(nonEmptyDir, "main.py"), []byte("print()\n"), 0644))
+
+	file := filepath.Join(root, "file")
+	require.NoError(t, os.WriteFile(file, []byte("x"), 0644))
+
 	tests := []struct {
 		name    string
-		input   string
-		wantErr bool
+		dir     string
+		force   bool
+		wantErr string
+	}{
+		{name: "missing directory", dir: filepath.Join(root, "missing")},
+		{name: "empty directory", dir: emptyDir},
+		{name: "non-empty directory", dir: nonEmptyDir, wantErr: "already exists and is not empty:\n  - main.py"},
+		{name: "non-empty directory with force", dir: nonEmptyDir, force: true},
+		{name: "existing file", dir: file, wantErr: "is not a directory"},
+		{name: "existing file with force", dir: file, force: true, wantErr: "is not a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkProjectDir(tt.dir, tt.force)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithScaffoldHint(t *testing.T) {
+	notEmpty := &scaffold.NotEmptyError{Dir: "/tmp/my-agent", Entries: []string{"main.py"}}
+	notFound := fmt.Errorf("failed to download template: %w: go/missing", template.ErrTemplateNotFound)
+	denied := fmt.Errorf("failed to render template: %w", &fs.PathError{Op: "open", Path: "/srv/my-agent/main.go", Err: fs.ErrPermission})
+	other := errors.New("disk full")
+
+	tests := []struct {
+		name     string
+		err      error
+		wantHint string
+	}{
+		{name: "not empty", err: notEmpty, wantHint: "Use --force to overwrite conflicting files"},
+		{name: "template not found", err: notFound, wantHint: "acontext template list"},
+		{name: "permission denied", err: denied, wantHint: "Check that you can write to /srv/my-agent"},
+		{name: "other", err: other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := withScaffoldHint(tt.err)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.err.Error(), err.Error())
+			if tt.wantHint == "" {
+				assert.Empty(t, clierror.Hint(err))
+				return
+			}
+			assert.Contains(t, clierror.Hint(err), tt.wantHint)
+		})
+	}
+}
+
+func TestTemplateOptions(t *testing.T) {
+	choices := []templateChoice{
+		{Language: "python", Preset: config.Preset{Name: "OpenAI", Description: "Chat agent using the OpenAI SDK"}},
+		{Language: "typescript", Preset: config.Preset{Name: "Vercel AI"}},
+	}
+
+	options, summaries := templateOptions(choices)
+	assert.Equal(t, []string{
+		"python      OpenAI",
+		"typescript  Vercel AI",
+	}, options)
+	assert.Equal(t, []string{"Chat agent using the OpenAI SDK", ""}, summaries)
+}
+
+func TestShouldInstall(t *testing.T) {
+	enabled, err := config.LoadUserConfigFile(filepath.Join(t.TempDir(), "config.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, enabled.Set("create.install", "true"))
+	enable := true
+
+	tests := []struct {
+		name        string
+		userConfig  *config.UserConfig
+		install     bool
+		noInstall   bool
+		defaults    scaffold.Defaults
+		wantInstall bool
+	}{
+		{name: "default", userConfig: &config.UserConfig{}},
+		{name: "config", userConfig: enabled, wantInstall: true},
+		{name: "flag", userConfig: &config.UserConfig{}, install: true, wantInstall: true},
+		{name: "no-install overrides config", userConfig: enabled, noInstall: true},
+		{name: "template default", userConfig: &config.UserConfig{}, defaults: scaffold.Defaults{Install: &enable}, wantInstall: true},
+		{name: "no-install overrides template default", userConfig: &config.UserConfig{}, noInstall: true, defaults: scaffold.Defaults{Install: &enable}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runInstall, skipInstall = tt.install, tt.noInstall
+			t.Cleanup(func() { runInstall, skipInstall = false, false })
+			assert.Equal(t, tt.wantInstall, shouldInstall(tt.userConfig, tt.defaults))
+		})
+	}
+}
+
+func TestTemplateKeyRef(t *testing.T) {
+	flagDir, configDir := t.TempDir(), t.TempDir()
+	for _, dir := range []string{flagDir, configDir} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "python", "openai"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "python", "openai", template.ManifestFile), []byte("name: team-openai\n"), 0644))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(configDir, "service"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "service", template.ManifestFile), []byte("name: service\n"), 0644))
+	userConfig, err := config.LoadUserConfigFile(filepath.Join(t.TempDir(), "config.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, userConfig.Set("create.template_search_path", configDir))
+	searchPath = flagDir
+	t.Cleanup(func() { searchPath = "" })
+
+	assert.Equal(t, []string{flagDir, configDir}, templateSearchPath(userConfig))
+	ctx := context.Background()
+	assert.Equal(t, scaffold.TemplateRef{Dir: filepath.Join(flagDir, "python", "openai")}, templateKeyRef(ctx, userConfig, "python/openai"), "--template-search-path comes first, and shadows the built-in template")
+	assert.Equal(t, scaffold.TemplateRef{Dir: filepath.Join(configDir, "service")}, templateKeyRef(ctx, userConfig, "service"))
+	assert.Equal(t, scaffold.TemplateRef{Key: "go.basic"}, templateKeyRef(ctx, userConfig, "go.basic"))
+
+	searchPath = ""
+	assert.Equal(t, scaffold.TemplateRef{Key: "python.openai"}, templateKeyRef(ctx, &config.UserConfig{}, "python.openai"), "without a search path")
+}
+
+func TestIsBuiltinKey(t *testing.T) {
+	assert.True(t, isBuiltinKey("python.openai"))
+	assert.True(t, isBuiltinKey("go.basic"))
+	assert.False(t, isBuiltinKey("service"))
+	assert.False(t, isBuiltinKey("cobol.batch"))
+}
+
+func TestResolveLicense(t *testing.T) {
+	configured, err := config.LoadUserConfigFile(filepath.Join(t.TempDir(), "config.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, configured.Set("create.license", "Apache-2.0"))
+
+	tests := []struct {
+		name       string
+		userConfig *config.UserConfig
+		id         string
+		defaults   scaffold.Defaults
+		expected   string
+		wantErr    string
+	}{
+		{name: "none by default", userConfig: &config.UserConfig{}},
+		{name: "flag", userConfig: &config.UserConfig{}, id: "mit", expected: "MIT"},
+		{name: "config", userConfig: configured, expected: "Apache-2.0"},
+		{name: "flag overrides config", userConfig: configured, id: "MIT", expected: "MIT"},
+		{name: "none skips config", userConfig: configured, id: "none"},
+		{name: "template default", userConfig: &config.UserConfig{}, defaults: scaffold.Defaults{License: "bsd-3-clause"}, expected: "BSD-3-Clause"},
+		{name: "config overrides template default", userConfig: configured, defaults: scaffold.Defaults{License: "MIT"}, expected: "Apache-2.0"},
+		{name: "template default of none", userConfig: &config.UserConfig{}, defaults: scaffold.Defaults{License: "none"}},
+		{name: "typo", userConfig: &config.UserConfig{}, id: "Apache2", wantErr: `--license: unknown license "Apache2", did you mean Apache-2.0?`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := resolveLicense(tt.userConfig, tt.id, "--license", tt.defaults)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Equal(t, clierror.Usage, clierror.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, id)
+		})
+	}
+}
+
+func TestCreateSettingsPrecedence(t *testing.T) {
+	userConfig, err := config.LoadUserConfigFile(filepath.Join(t.TempDir(), "config.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, userConfig.Set("create.git_branch", "config-branch"))
+	require.NoError(t, userConfig.Set("create.license", "Apache-2.0"))
+	require.NoError(t, userConfig.Set("create.install", "false"))
+
+	enabled, disabled := true, false
+	defaults := scaffold.Defaults{GitBranch: "template-branch", License: "BSD-3-Clause", Install: &enabled}
+	spec := &template.Spec{GitBranch: "spec-branch", License: "MPL-2.0", Install: &enabled}
+
+	tests := []struct {
+		name        string
+		args        []string
+		spec        *template.Spec
+		userConfig  *config.UserConfig
+		defaults    scaffold.Defaults
+		wantBranch  string
+		wantLicense string
+		wantInstall bool
 	}{
 		{
-			name:    "valid project name",
-			input:   "my-project",
-			wantErr: false,
+			name:       "hardcoded default",
+			userConfig: &config.UserConfig{},
+			wantBranch: "main",
 		},
 		{
-			name:    "valid with numbers",
-			input:   "project123",
-			wantErr: false,
+			name:        "template default",
+			userConfig:  &config.UserConfig{},
+			defaults:    defaults,
+			wantBranch:  "template-branch",
+			wantLicense: "BSD-3-Clause",
+			wantInstall: true,
 		},
 		{
-			name:    "valid with underscores",
-			input:   "my_project",
-			wantErr: false,
+			name:        "user config",
+			userConfig:  userConfig,
+			defaults:    defaults,
+			wantBranch:  "config-branch",
+			wantLicense: "Apache-2.0",
 		},
 		{
-			name:    "valid with hyphens",
-			input:   "my-acontext-app",
-			wantErr: false,
+			name:        "spec file",
+			spec:        spec,
+			userConfig:  userConfig,
+			defaults:    defaults,
+			wantBranch:  "spec-branch",
+			wantLicense: "MPL-2.0",
+			wantInstall: true,
 		},
 		{
-			name:    "empty name",
-			input:   "",
-			wantErr: true,
+			name:        "flag",
+			args:        []string{"--git-branch", "flag-branch", "--license", "MIT", "--no-install"},
+			spec:        spec,
+			userConfig:  userConfig,
+			defaults:    defaults,
+			wantBranch:  "flag-branch",
+			wantLicense: "MIT",
 		},
 		{
-			name:    "contains slash",
-			input:   "project/name",
-			wantErr: true,
+			name:        "install flag overrides the spec file",
+			args:        []string{"--install"},
+			spec:        &template.Spec{Install: &disabled},
+			userConfig:  &config.UserConfig{},
+			wantBranch:  "main",
+			wantInstall: true,
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				gitBranch, licenseID, runInstall, skipInstall = "", "", false, false
+			})
+			flags := pflag.NewFlagSet("create", pflag.ContinueOnError)
+			flags.StringVar(&gitBranch, "git-branch", "", "")
+			flags.StringVar(&licenseID, "license", "", "")
+			flags.BoolVar(&runInstall, "install", false, "")
+			flags.BoolVar(&skipInstall, "no-install", false, "")
+			require.NoError(t, flags.Parse(tt.args))
+			if tt.spec != nil {
+				applySpec(flags, tt.spec, false)
+			}
+
+			projectLicense, err := resolveLicense(tt.userConfig, licenseID, "--license", tt.defaults)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLicense, projectLicense)
+			assert.Equal(t, tt.wantBranch, resolveGitBranch(tt.userConfig, gitBranch, tt.defaults))
+			assert.Equal(t, tt.wantInstall, shouldInstall(tt.userConfig, tt.defaults))
+		})
+	}
+}
+
+func TestCheckGitFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		noGit   bool
+		remote  string
+		push    bool
+		wantErr string
+	}{
+		{name: "none"},
+		{name: "remote", remote: "git@github.com:org/app.git"},
+		{name: "remote and push", remote: "git@github.com:org/app.git", push: true},
+		{name: "no-git", noGit: true},
 		{
-			name:    "contains backslash",
-			input:   "project\\name",
-			wantErr: true,
+			name:    "remote and no-git",
+			noGit:   true,
+			remote:  "git@github.com:org/app.git",
+			wantErr: "--git-remote and --no-git are contradictory",
 		},
 		{
-			name:    "reserved name .git",
-			input:   ".git",
-			wantErr: true,
+			name:    "push and no-git",
+			noGit:   true,
+			push:    true,
+			wantErr: "--git-push and --no-git are contradictory",
 		},
 		{
-			name:    "reserved name .env",
-			input:   ".env",
-			wantErr: true,
+			name:    "push without remote",
+			push:    true,
+			wantErr: "--git-push needs --git-remote",
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noGit, gitRemote, gitPush = tt.noGit, tt.remote, tt.push
+			t.Cleanup(func() { noGit, gitRemote, gitPush = false, "", false })
+			err := checkGitFlags()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, clierror.Usage, clierror.Code(err))
+		})
+	}
+}
+
+func TestApplySpec(t *testing.T) {
+	spec := &template.Spec{
+		Name:     "from-spec",
+		Template: "python.openai",
+		Author:   "Spec Author",
+		License:  "MIT",
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		nameArg      bool
+		wantName     string
+		wantTemplate string
+		wantAuthor   string
+	}{
+		{name: "spec values", wantName: "from-spec", wantTemplate: "python.openai", wantAuthor: "Spec Author"},
+		{name: "flags override", args: []string{"--author", "Flag Author", "--template-path", "python/custom"}, wantName: "from-spec", wantAuthor: "Flag Author"},
+		{name: "name argument overrides", nameArg: true, wantTemplate: "python.openai", wantAuthor: "Spec Author"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				nameFlag, templateKey, templatePath, templateURL, authorName, licenseID = "", "", "", "", "", ""
+			})
+			flags := pflag.NewFlagSet("create", pflag.ContinueOnError)
+			flags.StringVar(&nameFlag, "name", "", "")
+			flags.StringVar(&templateKey, "template", "", "")
+			flags.StringVar(&templatePath, "template-path", "", "")
+			flags.StringVar(&templateURL, "template-url", "", "")
+			flags.StringVar(&authorName, "author", "", "")
+			flags.StringVar(&licenseID, "license", "", "")
+			require.NoError(t, flags.Parse(tt.args))
+
+			applySpec(flags, spec, tt.nameArg)
+			assert.Equal(t, tt.wantName, nameFlag)
+			assert.Equal(t, tt.wantTemplate, templateKey)
+			assert.Equal(t, tt.wantAuthor, authorName)
+			assert.Equal(t, "MIT", licenseID)
+		})
+	}
+}
+
+func TestRelativeToCwd(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join("services", "my-app"), relativeToCwd(filepath.Join(cwd, "services", "my-app")))
+	assert.Equal(t, ".", relativeToCwd(cwd))
+
+	outside := filepath.Join(filepath.Dir(cwd), "elsewhere")
+	assert.Equal(t, outside, relativeToCwd(outside))
+}
+
+func TestParseVarFlags(t *testing.T) {
+	maxPort := 65535.0
+	variables := []template.Variable{
+		{Name: "port", Type: "integer", Max: &maxPort},
+		{Name: "region", Enum: []string{"us-east-1", "eu-west-1"}},
+	}
+
+	tests := []struct {
+		name   string
+		flags  []string
+		want   map[string]string
+		errMsg string
+	}{
 		{
-			name:    "reserved name .",
-			input:   ".",
-			wantErr: true,
+			name:  "valid values",
+			flags: []string{"port=8000", "region=eu-west-1"},
+			want:  map[string]string{"port": "8000", "region": "eu-west-1"},
 		},
 		{
-			name:    "reserved name ..",
-			input:   "..",
-			wantErr: true,
+			name:   "missing value",
+			flags:  []string{"port"},
+			errMsg: "--var port: expected name=value",
 		},
 		{
-			name:    "contains colon",
-			input:   "project:name",
-			wantErr: true,
+			name:   "unknown variable",
+			flags:  []string{"author=Jane"},
+			errMsg: "--var author=Jane: unknown variable author (available: port, region)",
 		},
 		{
-			name:    "contains asterisk",
-			input:   "project*name",
-			wantErr: true,
+			name:   "constraint failure",
+			flags:  []string{"region=mars-1"},
+			errMsg: `--var region=mars-1: "mars-1" is not one of us-east-1, eu-west-1`,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateProjectName(tt.input)
-			if tt.wantErr {
-				assert.Error(t, err)
-			} else {
+			answers, err := parseVarFlags(tt.flags, variables)
+			if tt.errMsg == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, answers)
+				return
+			}
+			assert.EqualError(t, err, tt.errMsg)
+			assert.Equal(t, clierror.Usage, clierror.Code(err))
+		})
+	}
+}
+
+func TestParseSetFlags(t *testing.T) {
+	variables := []template.Variable{
+		{Name: "region", Enum: []string{"us-east-1", "eu-west-1"}},
+	}
+
+	tests := []struct {
+		name       string
+		flags      []string
+		wantVars   map[string]string
+		wantExtras map[string]string
+		errMsg     string
+	}{
+		{
+			name:       "declared and extra variables",
+			flags:      []string{"region=eu-west-1", "team=core", "banner=a=b"},
+			wantVars:   map[string]string{"region": "eu-west-1"},
+			wantExtras: map[string]string{"team": "core", "banner": "a=b"},
+		},
+		{
+			name:       "last value wins",
+			flags:      []string{"team=core", "team=infra"},
+			wantVars:   map[string]string{},
+			wantExtras: map[string]string{"team": "infra"},
+		},
+		{
+			name:   "missing value",
+			flags:  []string{"team"},
+			errMsg: "--set team: expected KEY=VALUE",
+		},
+		{
+			name:   "invalid key",
+			flags:  []string{"my-team=core"},
+			errMsg: "--set my-team=core: KEY may only contain letters, digits and _, and cannot start with a digit",
+		},
+		{
+			name:   "standard variable",
+			flags:  []string{"author=Jane"},
+			errMsg: "--set author=Jane: author is a standard variable, set it with the project name argument, --author or --license",
+		},
+		{
+			name:   "constraint failure",
+			flags:  []string{"region=mars-1"},
+			errMsg: `--set region=mars-1: "mars-1" is not one of us-east-1, eu-west-1`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answers, extra, err := parseSetFlags(tt.flags, variables)
+			if tt.errMsg == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantVars, answers)
+				assert.Equal(t, tt.wantExtras, extra)
+				return
+			}
+			assert.EqualError(t, err, tt.errMsg)
+			assert.Equal(t, clierror.Usage, clierror.Code(err))
+		})
+	}
+}
+
+func TestSetFlagsOverrideVarFlagsAndPrompts(t *testing.T) {
+	variables := []template.Variable{{Name: "region", Default: "us-east-1"}, {Name: "model"}}
+
+	answers, err := parseVarFlags([]string{"region=eu-west-1", "model=gpt-4o"}, variables)
+	require.NoError(t, err)
+	setAnswers, _, err := parseSetFlags([]string{"region=us-east-1"}, variables)
+	require.NoError(t, err)
+	merged := overrideAnswers(answers, setAnswers)
+	assert.Equal(t, map[string]string{"region": "us-east-1", "model": "gpt-4o"}, merged)
+	assert.Equal(t, "eu-west-1", answers["region"], "the --var answers are left unchanged")
+
+	// Answered variables are not prompted for, so --set also wins over prompts
+	values, err := resolveTemplateVars(variables, overrideAnswers(nil, map[string]string{"model": "o3"}), false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"region": "us-east-1", "model": "o3"}, values)
+}
+
+func TestApplyModuleFlag(t *testing.T) {
+	goTemplate := &scaffold.Template{Name: "go/basic", Variables: []template.Variable{{Name: "module", Required: true, Pattern: `[a-z./-]+`}}}
+	pythonTemplate := &scaffold.Template{Name: "python/openai"}
+
+	tests := []struct {
+		name    string
+		module  string
+		tmpl    *scaffold.Template
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "unset", tmpl: pythonTemplate, want: map[string]string{}},
+		{name: "go template", module: "github.com/myorg/my-agent", tmpl: goTemplate, want: map[string]string{"module": "github.com/myorg/my-agent"}},
+		{name: "not a go template", module: "github.com/myorg/my-agent", tmpl: pythonTemplate, wantErr: "--module only applies to Go templates, python/openai declares no module variable"},
+		{name: "invalid path", module: "github.com/My Org", tmpl: goTemplate, wantErr: "--module github.com/My Org: "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modulePath = tt.module
+			t.Cleanup(func() { modulePath = "" })
+			answers := map[string]string{}
+
+			err := applyModuleFlag(answers, tt.tmpl)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Equal(t, clierror.Usage, clierror.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, answers)
+		})
+	}
+}
+
+func TestSelectFeatures(t *testing.T) {
+	tmpl, err := scaffold.OpenTemplate(context.Background(), scaffold.TemplateRef{Key: "go.basic"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		with     []string
+		skip     []string
+		minimal  bool
+		full     bool
+		examples bool
+		want     scaffold.FeatureSelection
+		wantErr  string
+	}{
+		{name: "defaults"},
+		{name: "minimal", minimal: true, want: scaffold.FeatureSelection{Minimal: true}},
+		{name: "full without tests", full: true, skip: []string{"tests"}, want: scaffold.FeatureSelection{Full: true, Without: []string{"tests"}}},
+		{name: "unknown group", with: []string{"docker"}, wantErr: "unknown feature group docker (available: tests, examples)"},
+		{name: "examples", examples: true, want: scaffold.FeatureSelection{With: []string{"examples"}}},
+		{name: "minimal with examples", minimal: true, examples: true, want: scaffold.FeatureSelection{Minimal: true, With: []string{"examples"}}},
+		{name: "examples named twice", with: []string{"examples"}, examples: true, want: scaffold.FeatureSelection{With: []string{"examples"}}},
+		{name: "examples left out", skip: []string{"examples"}, examples: true, wantErr: "feature group examples is both included and left out"},
+		{name: "with and without", with: []string{"tests"}, skip: []string{"tests"}, wantErr: "feature group tests is both included and left out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withGroups, skipGroups, minimalFlag, fullFlag, withExamples = tt.with, tt.skip, tt.minimal, tt.full, tt.examples
+			t.Cleanup(func() {
+				withGroups, skipGroups, minimalFlag, fullFlag, withExamples = nil, nil, false, false, false
+			})
+
+			sel, err := selectFeatures(context.Background(), tmpl)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Equal(t, clierror.Usage, clierror.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sel)
+		})
+	}
+}
+
+func TestSelectFeaturesWithoutExamples(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, template.ManifestFile), []byte("name: plain\n"), 0644))
+	tmpl, err := scaffold.OpenTemplate(context.Background(), scaffold.TemplateRef{Dir: dir})
+	require.NoError(t, err)
+
+	withExamples = true
+	t.Cleanup(func() { withExamples = false })
+	_, err = selectFeatures(context.Background(), tmpl)
+	assert.ErrorContains(t, err, "--include-examples: template")
+	assert.ErrorContains(t, err, "has no examples (its manifest declares no examples feature group)")
+	assert.Equal(t, clierror.Usage, clierror.Code(err))
+}
+
+func TestDescribeFeatures(t *testing.T) {
+	off := false
+	features := []template.Feature{{Name: "tests"}, {Name: "ci", Default: &off}, {Name: "docker"}}
+
+	assert.Equal(t, "tests, docker (left out: ci)", describeFeatures(features, scaffold.FeatureSelection{}))
+	assert.Equal(t, "tests, ci, docker", describeFeatures(features, scaffold.FeatureSelection{Full: true}))
+	assert.Equal(t, "none (left out: tests, ci, docker)", describeFeatures(features, scaffold.FeatureSelection{Minimal: true}))
+}
+
+func TestVarFlagsOverrideSpec(t *testing.T) {
+	maxPort := 65535.0
+	manifest := &template.Manifest{
+		Name:      "custom",
+		Variables: []template.Variable{{Name: "port", Type: "integer", Max: &maxPort}},
+	}
+
+	// A valid spec value overridden by an invalid flag fails on the flag
+	_, err := parseVarFlags([]string{"port=70000"}, manifest.Variables)
+	assert.EqualError(t, err, "--var port=70000: 70000 is greater than the maximum 65535")
+
+	// An invalid spec value overridden by a valid flag passes
+	spec := &template.Spec{Variables: map[string]string{"port": "99999"}}
+	require.Error(t, spec.Validate(template.Variables(manifest)))
+	answers, err := parseVarFlags([]string{"port=8000"}, manifest.Variables)
+	require.NoError(t, err)
+	applyVarFlags(spec, answers)
+	require.NoError(t, spec.Validate(template.Variables(manifest)))
+	assert.Equal(t, "8000", spec.Variables["port"])
+}
+
+func TestResolveTemplateVars(t *testing.T) {
+	variables := []template.Variable{
+		{Name: "model", Default: "gpt-4o-mini"},
+		{Name: "api_base"},
+	}
+
+	values, err := resolveTemplateVars(variables, map[string]string{"api_base": "http://localhost:8029"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"model": "gpt-4o-mini", "api_base": "http://localhost:8029"}, values)
+
+	_, err = resolveTemplateVars(variables, nil, false)
+	assert.EqualError(t, err, "missing template variables: api_base (pass --var name=value)")
+	assert.Equal(t, clierror.Usage, clierror.Code(err))
+}
+
+func TestResolveGitToken(t *testing.T) {
+	t.Setenv(template.GitTokenEnvVar, "from-env")
+	assert.Equal(t, "from-env", resolveGitToken())
+
+	gitToken = "from-flag"
+	t.Cleanup(func() { gitToken = "" })
+	assert.Equal(t, "from-flag", resolveGitToken())
+}
+
+func TestWithCloneHint(t *testing.T) {
+	authErr := fmt.Errorf("failed to clone template: %w: fatal: Authentication failed", template.ErrAuthentication)
+	notFoundErr := fmt.Errorf("failed to clone template: %w: remote: Repository not found.", template.ErrRepoNotFound)
+	otherErr := errors.New("could not resolve host")
+
+	tests := []struct {
+		name     string
+		err      error
+		url      string
+		wantHint string
+	}{
+		{name: "https authentication", err: authErr, url: "git+https://github.com/myorg/private.git", wantHint: "Pass a token with --git-token or ACONTEXT_GIT_TOKEN"},
+		{name: "ssh authentication", err: authErr, url: "git@github.com:myorg/private.git", wantHint: "Check that your SSH key is loaded (ssh-add -l)"},
+		{name: "not found", err: notFoundErr, url: "git+https://github.com/myorg/missing.git", wantHint: "Check the URL; a private repository is reported as not found too"},
+		{name: "other", err: otherErr, url: "git+https://example.invalid/repo.git"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := withCloneHint(tt.err, tt.url)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.wantHint == "" {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			assert.Contains(t, clierror.Hint(err), tt.wantHint)
+		})
+	}
+}
+
+func TestCheckTemplateRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		ref     string
+		wantErr string
+	}{
+		{name: "no ref"},
+		{name: "no ref with a url", url: "file:///path/to/template"},
+		{name: "remote", url: "git+https://github.com/myorg/template.git", ref: "v1.2.0"},
+		{name: "without a url", ref: "v1.2.0", wantErr: "--ref needs a remote template: pass --template-url"},
+		{
+			name:    "local template",
+			url:     "file:///path/to/template",
+			ref:     "v1.2.0",
+			wantErr: "--ref needs a remote --template-url (git+https:// or git+ssh://), file:///path/to/template has no versions",
+		},
+		{
+			name:    "ref in the url",
+			url:     "git+https://github.com/myorg/template.git#main",
+			ref:     "v1.2.0",
+			wantErr: "--ref and the ref after # in --template-url are contradictory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateURL, templateRev = tt.url, tt.ref
+			t.Cleanup(func() { templateURL, templateRev = "", "" })
+			err := checkTemplateRef()
+			if tt.wantErr == "" {
 				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, clierror.Usage, clierror.Code(err))
+		})
+	}
+}
+
+func TestTemplateVersionFlagAlias(t *testing.T) {
+	t.Cleanup(func() { templateRev = "" })
+	require.NoError(t, CreateCmd.Flags().Set("template-version", "v1.2.0"))
+	t.Cleanup(func() { CreateCmd.Flags().Lookup("ref").Changed = false })
+	assert.Equal(t, "v1.2.0", templateRev)
+}
+
+func TestDescribeTemplateVersion(t *testing.T) {
+	commit := "3f2a9c1d0b7e4a5f6b8c9d0e1f2a3b4c5d6e7f80"
+	assert.Equal(t, "v1.2.0 at 3f2a9c1d0b7e", describeTemplateVersion(&scaffold.Template{Ref: "v1.2.0", Commit: commit}))
+	assert.Equal(t, "default branch at 3f2a9c1d0b7e", describeTemplateVersion(&scaffold.Template{Commit: commit}))
+	assert.Equal(t, "3f2a9c1d0b7e", describeTemplateVersion(&scaffold.Template{Ref: commit, Commit: commit}), "a commit ref is only named once")
+}
+
+func TestCreateResultSchema(t *testing.T) {
+	// Tools parse this summary: changing a key breaks them
+	data, err := json.Marshal(createResult{
+		Envelope:       output.NewEnvelope("create"),
+		Project:        "my-project",
+		Path:           "/work/my-project",
+		Template:       "python/openai",
+		TemplateSource: scaffold.ExamplesRepo,
+		Commit:         "0123456789abcdef",
+		License:        "MIT",
+		Files:          []string{"README.md"},
+		FileCount:      1,
+		Git:            true,
+		Install:        &installStatus{Command: "pip install -e .", OK: false, Error: "exit status 1"},
+		Warnings:       []string{"failed to install dependencies: exit status 1"},
+	})
+	require.NoError(t, err)
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.ElementsMatch(t, []string{
+		"command", "version", "project", "path", "template", "template_source", "template_commit",
+		"license", "files", "file_count", "git_initialized", "install", "warnings",
+	}, slices.Collect(maps.Keys(fields)))
+	assert.JSONEq(t, `{"command":"pip install -e .","ok":false,"error":"exit status 1"}`, string(fields["install"]))
+
+	// Fields a tool checks are present even when empty
+	data, err = json.Marshal(createResult{Envelope: output.NewEnvelope("create"), Project: "my-project"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"command":"create","version":"dev","project":"my-project","template":"","template_source":"","files":null,"file_count":0,"git_initialized":false,"install":null}`, string(data))
+}
+
+func TestCreateWarnings(t *testing.T) {
+	gitRemote = "git@example.com:org/repo.git"
+	t.Cleanup(func() { gitRemote = "" })
+
+	assert.Empty(t, createWarnings(&scaffold.Template{}, &scaffold.Result{GitInitialized: true}, &installStatus{OK: true}))
+	assert.Equal(t, []string{
+		"could not refresh template, used cached copy: offline",
+		"overwrote 2 existing file(s), backups saved to " + scaffold.BackupDir + "/",
+		"post-create script failed, the project was kept: exit status 2",
+		"failed to initialize Git: git not found",
+		"could not push to git@example.com:org/repo.git: rejected",
+		"failed to install dependencies: exit status 1",
+	}, createWarnings(
+		&scaffold.Template{RefreshErr: errors.New("offline")},
+		&scaffold.Result{
+			Overwritten: []string{"README.md", "main.py"},
+			HookErr:     errors.New("exit status 2"),
+			GitErr:      errors.New("git not found"),
+			PushErr:     errors.New("rejected"),
+		},
+		&installStatus{Error: "exit status 1"},
+	))
+}
+
+func TestCreateNoInput(t *testing.T) {
+	ctx := tty.WithNoInput(context.Background())
+	t.Chdir(t.TempDir())
+	t.Setenv("ACONTEXT_HOME", t.TempDir())
+
+	// A missing value without a default is an error instead of a prompt
+	CreateCmd.SetContext(ctx)
+	t.Cleanup(func() { CreateCmd.SetContext(nil) })
+	err := runCreate(CreateCmd, nil)
+	assert.EqualError(t, err, "no project name specified and --no-input is set: pass it as an argument or with --name")
+	assert.Equal(t, clierror.Usage, clierror.Code(err))
+
+	err = runCreate(CreateCmd, []string{"my-app"})
+	assert.EqualError(t, err, "no template specified and --no-input is set: pass --template, --template-path, --template-url or --template-dir")
+	assert.Equal(t, clierror.Usage, clierror.Code(err))
+
+	// A prompt with a default is bypassed for it
+	initGit, err := confirmGitInit(ctx, filepath.Join(t.TempDir(), "my-app"))
+	require.NoError(t, err)
+	assert.True(t, initGit, "Git is initialized, the default of the prompt")
+}
+
+func TestResolveProjectName(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		nameFlag string
+		yes      bool
+		want     string
+		wantErr  string
+		wantCode int
+	}{
+		{name: "positional argument", args: []string{"my-agent"}, want: "my-agent"},
+		{name: "positional argument and path", args: []string{"my-agent", "./services/agent"}, want: "my-agent"},
+		{name: "flag", nameFlag: "my-agent", want: "my-agent"},
+		{name: "argument and flag agree", args: []string{"my-agent"}, nameFlag: "my-agent", want: "my-agent"},
+		{name: "conflict", args: []string{"my-agent"}, nameFlag: "other-agent", wantErr: `conflicting project names: argument "my-agent" and --name "other-agent"`, wantCode: clierror.Usage},
+		{name: "invalid argument", args: []string{"My_Agent"}, wantErr: `project name must be lowercase (try "my_agent")`, wantCode: clierror.Usage},
+		{name: "invalid flag", nameFlag: "-agent", wantErr: "project name cannot start with '.', '_' or '-'", wantCode: clierror.Usage},
+		{name: "reserved name", args: []string{"CON"}, wantErr: `invalid project name "CON"`, wantCode: clierror.Usage},
+		{name: "default with --yes", yes: true, want: defaultProjectName},
+		{name: "no name without prompts", wantErr: "no project name specified and --no-input is set: pass it as an argument or with --name", wantCode: clierror.Usage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nameFlag, assumeYes = tt.nameFlag, tt.yes
+			t.Cleanup(func() { nameFlag, assumeYes = "", false })
+
+			name, err := resolveProjectName(tty.WithNoInput(context.Background()), tt.args)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Equal(t, tt.wantCode, clierror.Code(err))
+				return
 			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, name)
 		})
 	}
 }
 
+func TestCreateRefusesFilesystemRoot(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	CreateCmd.SetContext(tty.WithNoInput(context.Background()))
+	t.Cleanup(func() { CreateCmd.SetContext(nil) })
+
+	root := filepath.VolumeName(cwd) + string(filepath.Separator)
+	err = runCreate(CreateCmd, []string{"my-app", root})
+	assert.ErrorContains(t, err, "refusing to create a project in the filesystem root")
+	assert.Equal(t, clierror.Usage, clierror.Code(err))
+}
+
+// captureStdout returns what fn prints to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stdout")
+	require.NoError(t, err)
+	defer f.Close()
+	stdout := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = stdout }()
+	fn()
+	content, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestPrintTemplateVarsOfDirectory(t *testing.T) {
+	dir := t.TempDir()
+	manifest := "name: svc\nvariables:\n  - name: model\n    description: Default chat model\n    default: gpt-4o-mini\nfeatures:\n  - name: tests\n    description: Unit tests\n    paths: [tests/]\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, template.ManifestFile), []byte(manifest), 0644))
+	t.Setenv("ACONTEXT_HOME", t.TempDir())
+	templateDir = dir
+	t.Cleanup(func() { templateDir = "" })
+	ctx := context.Background()
+
+	t.Run("text", func(t *testing.T) {
+		var err error
+		out := captureStdout(t, func() { err = printTemplateVars(ctx) })
+		require.NoError(t, err)
+		assert.Regexp(t, `(?m)^model +- +gpt-4o-mini +- +Default chat model$`, out)
+		assert.Regexp(t, `(?m)^tests +on +tests/ +Unit tests$`, out)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		stdout, original := output.Stdout(), os.Stdout
+		output.SetStdout(&buf)
+		output.SetFormat(output.JSON)
+		t.Cleanup(func() {
+			output.SetFormat(output.Text)
+			output.SetStdout(stdout)
+			os.Stdout = original
+		})
+
+		require.NoError(t, printTemplateVars(ctx))
+		var result createVarsResult
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+		assert.Equal(t, "svc", result.Template, "named by its manifest, as the created project")
+		assert.Equal(t, "file://"+filepath.ToSlash(dir), result.TemplateSource)
+		assert.Contains(t, result.Variables, template.Variable{Name: "model", Description: "Default chat model", Default: "gpt-4o-mini"})
+		require.Len(t, result.Features, 1)
+		assert.Equal(t, "tests", result.Features[0].Name)
+		assert.True(t, result.Features[0].IncludedByDefault())
+	})
+}
+
+func TestPrintDryRunProjectNameStep(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, template.ManifestFile), []byte("name: web\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name": "web"}`), 0644))
+	web, err := scaffold.OpenTemplate(ctx, scaffold.TemplateRef{Dir: dir})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = web.Close() })
+	goBasic, err := scaffold.OpenTemplate(ctx, scaffold.TemplateRef{Key: "go.basic"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		tmpl *scaffold.Template
+		want string
+	}{
+		{name: "package.json", tmpl: web, want: "update project name in package.json"},
+		{name: "go.mod only", tmpl: goBasic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := captureStdout(t, func() {
+				require.NoError(t, printDryRun(ctx, "my-agent", "my-agent", tt.tmpl, scaffold.FeatureSelection{}, "", false))
+			})
+			if tt.want == "" {
+				assert.NotContains(t, out, "update project name")
+				return
+			}
+			assert.Contains(t, out, tt.want)
+		})
+	}
+}
+
+func TestPrintTemplateVarsOfBuiltinTemplate(t *testing.T) {
+	t.Setenv("ACONTEXT_HOME", t.TempDir())
+	templateKey = "go.basic"
+	t.Cleanup(func() { templateKey = "" })
+
+	var buf bytes.Buffer
+	stdout := output.Stdout()
+	output.SetStdout(&buf)
+	output.SetFormat(output.JSON)
+	original := os.Stdout
+	t.Cleanup(func() {
+		output.SetFormat(output.Text)
+		output.SetStdout(stdout)
+		os.Stdout = original
+	})
+
+	require.NoError(t, printTemplateVars(context.Background()))
+	var result createVarsResult
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, "go/basic", result.Template, "named as create reports it")
+}
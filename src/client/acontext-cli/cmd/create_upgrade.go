@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/color"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/telemetry"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/template"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/tty"
+	"github.com/memodb-io/Acontext/acontext-cli/pkg/scaffold"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// upgradeFlags are the create flags that apply to --upgrade; the others
+// describe a new project, which the provenance of an existing one replaces
+var upgradeFlags = map[string]bool{
+	"upgrade":   true,
+	"ref":       true,
+	"var":       true,
+	"refresh":   true,
+	"offline":   true,
+	"git-token": true,
+	"yes":       true,
+	"dry-run":   true,
+}
+
+// createUpgradeResult is the JSON result of create --upgrade
+type createUpgradeResult struct {
+	output.Envelope
+	Path     string                      `json:"path"`
+	From     scaffold.ProvenanceTemplate `json:"from"`
+	To       scaffold.ProvenanceTemplate `json:"to"`
+	Merged   bool                        `json:"merged"`
+	DryRun   bool                        `json:"dry_run,omitempty"`
+	Changes  []scaffold.FileChange       `json:"changes"`
+	BackedUp []string                    `json:"backed_up,omitempty"`
+}
+
+// runCreateUpgrade re-applies a newer version of its template to the
+// project in args[0], or the current directory, from its provenance
+func runCreateUpgrade(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if err := checkUpgradeFlags(cmd, args); err != nil {
+		return err
+	}
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	projectDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	displayDir := relativeToCwd(projectDir)
+
+	provenance, err := scaffold.LoadProvenance(projectDir)
+	if err != nil {
+		return clierror.WithCode(clierror.Usage, fmt.Errorf("%w\n--upgrade needs a project created by acontext create without --no-provenance", err))
+	}
+	ref := provenance.TemplateRef()
+	if templateRev != "" && !template.IsRemoteURL(ref.URL) {
+		return clierror.UsageError("--ref needs a project created from a remote --template-url template, %s has no versions", provenance.Template.Source)
+	}
+	ref.Ref, ref.Refresh, ref.Offline = templateRev, refresh, offline
+
+	tmpl, err := openTemplate(ctx, ref)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmpl.Close()
+	}()
+	base := openBaseTemplate(ctx, provenance)
+	if base != nil {
+		defer func() {
+			_ = base.Close()
+		}()
+	}
+
+	answers, err := parseVarFlags(varFlags, tmpl.Variables)
+	if err != nil {
+		return err
+	}
+	setAnswers, extraVars, err := parseSetFlags(setFlags, tmpl.Variables)
+	if err != nil {
+		return err
+	}
+	answers = overrideAnswers(answers, setAnswers)
+	if !assumeYes && tty.CanPrompt(ctx) {
+		// Only ask for what the provenance does not answer: redacted values
+		// and the variables the newer version adds
+		recorded := provenance.Answers(tmpl.Variables)
+		for _, variable := range tmpl.Variables {
+			if _, ok := recorded[variable.Name]; ok {
+				continue
+			}
+			if _, ok := answers[variable.Name]; ok {
+				continue
+			}
+			if answers[variable.Name], err = promptVariable(variable); err != nil {
+				return err
+			}
+		}
+	}
+
+	plan, err := scaffold.PlanUpgrade(ctx, scaffold.UpgradeOptions{
+		Dir:       projectDir,
+		Template:  tmpl,
+		Base:      base,
+		Vars:      answers,
+		ExtraVars: extraVars,
+		Version:   cliVersion,
+	})
+	var missing *scaffold.MissingVariablesError
+	if errors.As(err, &missing) {
+		return clierror.UsageError("%v (pass --var name=value)", err)
+	}
+	if err != nil {
+		return err
+	}
+	telemetry.RecordFlag("upgrade_merged", strconv.FormatBool(plan.Merged))
+
+	if dryRun || len(plan.Changes) == 0 {
+		return printUpgradePlan(plan, displayDir, dryRun, nil)
+	}
+	if !output.IsJSON() {
+		printUpgradeChanges(plan)
+	}
+	if !assumeYes && tty.CanPrompt(ctx) && !output.IsJSON() {
+		apply := true
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Apply these changes to %s?", displayDir),
+			Default: true,
+		}
+		if err := survey.AskOne(prompt, &apply); err != nil {
+			return fmt.Errorf("failed to get confirmation: %w", err)
+		}
+		if !apply {
+			fmt.Println("⏭️  Upgrade cancelled, nothing was written")
+			return nil
+		}
+	}
+
+	backedUp, err := plan.Apply()
+	if err != nil {
+		return err
+	}
+	return printUpgradePlan(plan, displayDir, false, backedUp)
+}
+
+// checkUpgradeFlags rejects the arguments and create flags that do not
+// apply to --upgrade. Flags inherited from the root command, such as
+// --output, apply to every command.
+func checkUpgradeFlags(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return clierror.UsageError("--upgrade takes at most one argument, the project directory")
+	}
+	var conflicting []string
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if !upgradeFlags[flag.Name] && cmd.InheritedFlags().Lookup(flag.Name) == nil {
+			conflicting = append(conflicting, "--"+flag.Name)
+		}
+	})
+	if len(conflicting) > 0 {
+		return clierror.UsageError("--upgrade cannot be combined with %s: the project's %s answers them", strings.Join(conflicting, ", "), scaffold.ProvenanceFile)
+	}
+	return nil
+}
+
+// openBaseTemplate opens the template version the project was created
+// from, or returns nil when it is not known or cannot be fetched, in which
+// case every file the project and the newer version disagree on is a
+// conflict
+func openBaseTemplate(ctx context.Context, provenance *scaffold.Provenance) *scaffold.Template {
+	ref, ok := provenance.BaseRef()
+	if !ok {
+		return nil
+	}
+	ref.Offline = offline
+	base, err := openTemplate(ctx, ref)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Could not fetch the template version the project was created from, changed files will be conflicts: %v\n", err)
+		return nil
+	}
+	return base
+}
+
+// printUpgradeChanges lists the files upgrading changes, with their diffs
+func printUpgradeChanges(plan *scaffold.UpgradePlan) {
+	if plan.From.Commit == "" && plan.To.Commit == "" {
+		// Only remote templates have versions to tell apart
+		fmt.Printf("⬆️  Re-applying template %s (%s)\n", plan.To.Name, plan.To.Source)
+	} else {
+		fmt.Printf("⬆️  Upgrading from %s to %s\n", describeProvenanceTemplate(plan.From), describeProvenanceTemplate(plan.To))
+	}
+	if !plan.Merged {
+		fmt.Println("   The template version the project was created from is unknown: every changed file is a conflict")
+	}
+	fmt.Println()
+	fmt.Printf("%d file(s) change:\n", len(plan.Changes))
+	for _, change := range plan.Changes {
+		fmt.Printf("  %-8s  %s\n", change.Kind, change.Path)
+	}
+	fmt.Println()
+	for _, change := range plan.Changes {
+		fmt.Print(colorDiff(change.Diff))
+	}
+	fmt.Println()
+}
+
+// printUpgradePlan reports the outcome of an upgrade, or of a dry run
+func printUpgradePlan(plan *scaffold.UpgradePlan, displayDir string, dryRun bool, backedUp []string) error {
+	if output.IsJSON() {
+		changes := plan.Changes
+		if changes == nil {
+			changes = []scaffold.FileChange{}
+		}
+		return output.PrintJSON(createUpgradeResult{
+			Envelope: output.NewEnvelope("create"),
+			Path:     plan.Dir,
+			From:     plan.From,
+			To:       plan.To,
+			Merged:   plan.Merged,
+			DryRun:   dryRun,
+			Changes:  changes,
+			BackedUp: backedUp,
+		})
+	}
+
+	if len(plan.Changes) == 0 {
+		fmt.Printf("✓ %s is up to date with %s\n", displayDir, describeProvenanceTemplate(plan.To))
+		return nil
+	}
+	if dryRun {
+		fmt.Println("🔍 Dry run: nothing will be written to disk")
+		fmt.Println()
+		printUpgradeChanges(plan)
+		return nil
+	}
+
+	fmt.Printf("✅ Upgraded %s to %s\n", displayDir, describeProvenanceTemplate(plan.To))
+	if len(backedUp) > 0 {
+		fmt.Printf("   Backups of %d overwritten or deleted file(s) saved to %s/\n", len(backedUp), scaffold.BackupDir)
+	}
+	if conflicts := plan.Conflicts(); len(conflicts) > 0 {
+		fmt.Println()
+		fmt.Printf("⚠️  %d conflict(s) to resolve by hand:\n", len(conflicts))
+		for _, conflict := range conflicts {
+			if path := conflict.ConflictPath(); path != "" {
+				fmt.Printf("   - %s: the template's version is in %s\n", conflict.Path, path)
+			} else {
+				fmt.Printf("   - %s: removed from the template, delete it unless the project still needs it\n", conflict.Path)
+			}
+		}
+		fmt.Printf("   Merge each %s file into the project's, then delete it.\n", scaffold.ConflictSuffix)
+	}
+	return nil
+}
+
+// describeProvenanceTemplate names a recorded template version, e.g.,
+// "starter (v1.2.0 at 3f2a9c1d0b7e)"
+func describeProvenanceTemplate(t scaffold.ProvenanceTemplate) string {
+	if t.Commit == "" {
+		return t.Name
+	}
+	return fmt.Sprintf("%s (%s)", t.Name, describeVersion(t.Ref, t.Commit))
+}
+
+// colorDiff colors the lines of a unified diff: removals red, additions
+// green and hunk headers cyan
+func colorDiff(diff string) string {
+	if !color.Enabled() {
+		return diff
+	}
+	lines := strings.SplitAfter(diff, "\n")
+	for i, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(text, "---"), strings.HasPrefix(text, "+++"):
+			text = color.Bold(text)
+		case strings.HasPrefix(text, "@@"):
+			text = color.Cyan(text)
+		case strings.HasPrefix(text, "-"):
+			text = color.Red(text)
+		case strings.HasPrefix(text, "+"):
+			text = color.Green(text)
+		}
+		if strings.HasSuffix(line, "\n") {
+			text += "\n"
+		}
+		lines[i] = text
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/color"
+	"github.com/memodb-io/Acontext/acontext-cli/pkg/scaffold"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUpgradeFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "upgrade flags", args: []string{"--upgrade", "--ref", "v2.0.0", "--var", "model=gpt-4o", "--dry-run", "-o", "json", "./my-agent"}},
+		{name: "create flags", args: []string{"--upgrade", "--template", "go.basic", "--force"}, wantErr: "--upgrade cannot be combined with --force, --template"},
+		{name: "two arguments", args: []string{"--upgrade", "my-agent", "./my-agent"}, wantErr: "--upgrade takes at most one argument, the project directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &cobra.Command{Use: "acontext"}
+			root.PersistentFlags().StringP("output", "o", "text", "")
+			cmd := &cobra.Command{Use: "create"}
+			for _, name := range []string{"upgrade", "dry-run", "force"} {
+				cmd.Flags().Bool(name, false, "")
+			}
+			for _, name := range []string{"ref", "var", "template"} {
+				cmd.Flags().String(name, "", "")
+			}
+			root.AddCommand(cmd)
+			require.NoError(t, cmd.ParseFlags(tt.args))
+
+			err := checkUpgradeFlags(cmd, cmd.Flags().Args())
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, clierror.Usage, clierror.Code(err))
+		})
+	}
+}
+
+func TestDescribeProvenanceTemplate(t *testing.T) {
+	assert.Equal(t, "starter (v1.2.0 at 3f2a9c1d0b7e)", describeProvenanceTemplate(scaffold.ProvenanceTemplate{Name: "starter", Ref: "v1.2.0", Commit: "3f2a9c1d0b7e4a5f"}))
+	assert.Equal(t, "starter", describeProvenanceTemplate(scaffold.ProvenanceTemplate{Name: "starter", Source: "file:///path/to/template"}))
+}
+
+func TestColorDiff(t *testing.T) {
+	diff := "--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a\n+b\n"
+	assert.Equal(t, diff, colorDiff(diff), "plain without color")
+
+	color.SetMode(color.Always)
+	t.Cleanup(func() { color.SetMode(color.Auto) })
+	assert.Equal(t, "\033[1m--- a/f\033[0m\n\033[1m+++ b/f\033[0m\n\033[36m@@ -1 +1 @@\033[0m\n\033[31m-a\033[0m\n\033[32m+b\033[0m\n", colorDiff(diff))
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/config"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/deploy"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/progress"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deployDryRun bool
+	deployOutput string
+)
+
+var DeployCmd = &cobra.Command{
+	Use:   "deploy",
+	Short: "Package the project for deployment",
+	Long: `Package the Acontext project in the current directory into a deployable
+artifact and hand it to the configured deploy target.
+
+The project must contain an acontext.yaml file at its root, which is first
+checked against its schema as acontext validate does. The artifact is a
+.tar.gz of the project written to .acontext/artifacts/, without .git, .env,
+installed dependencies (node_modules, .venv) or caches.
+
+The target is read from the deploy.target setting (default: local, which keeps
+the artifact on disk and prints how to ship it), with deploy.endpoint as its
+remote address.
+
+Use --dry-run to list the files that would be packaged without writing anything.`,
+	Example: `  acontext deploy
+  acontext deploy --dry-run
+  acontext deploy --output-file /tmp/my-app.tar.gz`,
+	Args:        cobra.NoArgs,
+	Annotations: longRunning(),
+	RunE:        runDeploy,
+}
+
+func init() {
+	DeployCmd.Flags().BoolVar(&deployDryRun, "dry-run", false, "List the files that would be packaged without writing anything")
+	DeployCmd.Flags().StringVar(&deployOutput, "output-file", "", "Write the artifact to this path instead of .acontext/artifacts/")
+}
+
+// deployResult is the JSON result of deploy
+type deployResult struct {
+	output.Envelope
+	Project   string        `json:"project"`
+	Target    deploy.Target `json:"target"`
+	Artifact  string        `json:"artifact"`
+	DryRun    bool          `json:"dry_run,omitempty"`
+	Files     []string      `json:"files"`
+	NextSteps []string      `json:"next_steps,omitempty"`
+}
+
+func runDeploy(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	projectDir, err := getProjectDir()
+	if err != nil {
+		return err
+	}
+	if err := validateProject(projectDir); err != nil {
+		return err
+	}
+	project, err := deploy.LoadProject(projectDir)
+	if err != nil {
+		return err
+	}
+
+	target := deployTarget(loadUserConfig())
+	transport, err := deploy.Lookup(target.Transport)
+	if err != nil {
+		return err
+	}
+
+	files, err := deploy.Files(projectDir)
+	if err != nil {
+		return err
+	}
+
+	artifact := filepath.Join(projectDir, deploy.ArtifactDir, deploy.ArtifactName(project, time.Now()))
+	if deployOutput != "" {
+		if artifact, err = filepath.Abs(deployOutput); err != nil {
+			return fmt.Errorf("failed to get absolute path: %w", err)
+		}
+	}
+	displayArtifact := relativeToCwd(artifact)
+
+	result := deployResult{
+		Envelope: output.NewEnvelope("deploy"),
+		Project:  project.Name,
+		Target:   target,
+		Artifact: displayArtifact,
+		DryRun:   deployDryRun,
+		Files:    files,
+	}
+
+	if deployDryRun {
+		if output.IsJSON() {
+			return output.PrintJSON(result)
+		}
+		fmt.Println("🔍 Dry run: nothing will be written to disk")
+		fmt.Println()
+		fmt.Println("Files:")
+		for _, file := range files {
+			fmt.Printf("  %s\n", file)
+		}
+		fmt.Println()
+		fmt.Println("Steps:")
+		fmt.Printf("  package %d file(s) into %s\n", len(files), displayArtifact)
+		fmt.Printf("  deploy with the %s target%s\n", target.Transport, endpointSuffix(target))
+		return nil
+	}
+
+	err = progress.Run(ctx, fmt.Sprintf("📦 Packaging %s...", project.Name), func() error {
+		return deploy.Package(projectDir, project, files, artifact)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Packaged %d file(s) into %s\n", len(files), displayArtifact)
+
+	steps, err := transport.Push(ctx, displayArtifact, target)
+	if err != nil {
+		return fmt.Errorf("failed to deploy with the %s target: %w", target.Transport, err)
+	}
+	result.NextSteps = steps
+
+	if output.IsJSON() {
+		return output.PrintJSON(result)
+	}
+	if len(steps) > 0 {
+		fmt.Println()
+		fmt.Println("Next steps:")
+		for i, step := range steps {
+			fmt.Printf("  %d. %s\n", i+1, step)
+		}
+	}
+	return nil
+}
+
+// deployTarget returns the deploy target from the deploy.* settings
+func deployTarget(userConfig *config.UserConfig) deploy.Target {
+	target := deploy.Target{Transport: deploy.DefaultTransport}
+	if transport, ok := userConfig.Get("deploy.target"); ok {
+		target.Transport = transport
+	}
+	if endpoint, ok := userConfig.Get("deploy.endpoint"); ok {
+		target.Endpoint = endpoint
+	}
+	return target
+}
+
+// endpointSuffix describes the target's endpoint, if it has one
+func endpointSuffix(target deploy.Target) string {
+	if target.Endpoint == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", target.Endpoint)
+}
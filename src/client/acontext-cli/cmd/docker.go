@@ -1,13 +1,32 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/color"
 	"github.com/memodb-io/Acontext/acontext-cli/internal/docker"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/interrupt"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/progress"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/telemetry"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/tty"
 	"github.com/spf13/cobra"
 )
 
@@ -18,59 +37,520 @@ var DockerCmd = &cobra.Command{
 
 This command helps you:
   - Start local development services (PostgreSQL, Redis, RabbitMQ, etc.)
-  - Stop services
-  - View service status and logs
+  - Pull service images ahead of time, e.g., to start them offline later
+  - Build the images of services with a build section, apart from starting them
+  - Stop, restart and force-recreate services
+  - View service status, logs, published ports and resource usage
+  - Run commands in service containers and copy files in and out of them
+  - Inspect the resolved compose configuration
   - Generate .env configuration files
+
+Every docker command runs the project's docker-compose.yaml if it exists,
+and the default compose file embedded in the CLI otherwise.
+
+Every docker command except env accepts --profile (repeatable) to activate
+compose profiles, e.g., --profile dev --profile observability. Without it,
+the comma-separated docker.profiles setting is used.
+
+Every docker command accepts --env-file (repeatable) to pass env files to docker
+compose instead of .env, later files overriding earlier ones. Without it, the
+comma-separated docker.env_files setting is used. Relative paths are resolved
+against the project directory.
+
+Every docker command except port, exec, cp and env accepts --retries N to
+retry docker commands that fail with a transient error, such as a daemon that
+refuses connections or an image pull that times out, with exponential backoff
+from --retry-delay. Errors such as an invalid compose file are not retried.
+Use -v to log each retry.
+
+The docker compose v2 plugin is used, or else a standalone docker-compose
+binary. The deprecated docker-compose v1, whose flags differ from v2, is
+refused unless --allow-compose-v1 is set.
 `,
 }
 
 var (
-	detachedMode bool
+	detachedMode        bool
+	upBuild             bool
+	upServices          []string
+	upParallel          int
+	upNoParallel        bool
+	upPull              string
+	upWait              bool
+	upWaitTimeout       time.Duration
+	pullServices        []string
+	pullNoProgress      bool
+	buildServices       []string
+	buildArgs           []string
+	buildNoCache        bool
+	buildPull           bool
+	dockerProfiles      []string
+	dockerEnvFiles      []string
+	dockerRetries       int
+	dockerRetryDelay    time.Duration
+	downVolumes         bool
+	downRemoveOrphans   bool
+	downYes             bool
+	downTimeout         int
+	restartWaitTimeout  time.Duration
+	recreateServices    []string
+	recreateNoDeps      bool
+	recreateWaitTimeout time.Duration
+	logsFollow          bool
+	logsServices        []string
+	logsSince           time.Duration
+	logsUntil           time.Duration
+	logsTail            string
+	logsNoPrefix        bool
+	logsTimestamps      bool
+	statsServices       []string
+	statsWatch          bool
+	statsInterval       time.Duration
+	portOnly            bool
+	envExport           bool
+	envWriteDotenv      bool
+	envForce            bool
+	envDiff             bool
+	envShowValues       bool
+	configServices      bool
+	allowComposeV1      bool
 )
 
+// longRunning returns the annotations that mark a command as long-running, so
+// its telemetry is given the full window to be sent
+func longRunning() map[string]string {
+	return map[string]string{telemetry.LongRunningAnnotation: "true"}
+}
+
 var dockerUpCmd = &cobra.Command{
 	Use:   "up",
 	Short: "Start Docker services",
-	Long:  "Start all Docker Compose services (use -d to run in detached mode)",
-	RunE:  runDockerUp,
+	Long: `Start Docker Compose services.
+
+By default the services run in the foreground and their combined logs are
+streamed until you press Ctrl-C, which stops them gracefully (press it again
+to force). Use -d to start them in the background and wait until they are
+healthy instead.
+
+Use --build to rebuild images before starting, and --service (repeatable) to
+only start specific services.
+
+Services are started concurrently, at most --parallel at once (one per CPU
+by default). When one fails to start, the startups still in progress are
+cancelled and the services that failed are reported. Use --no-parallel to
+start them one at a time, in dependency order, when debugging dependency
+issues.
+
+--pull sets when images are pulled: always, missing (the default, only
+images that are not present) or never. With never, the images the services
+need are checked first and the missing ones are reported, so they can be
+fetched beforehand with acontext docker pull.
+
+--wait starts the services in the background and blocks until every service
+is running and those with a health check are healthy, using compose's own
+--wait when it supports it (Compose 2.17 or later). When --wait-timeout
+(default 2m) passes first, the services that are not healthy yet are listed
+and the command fails, so it can gate a script:
+
+  acontext docker up --wait && run-integration-tests
+
+When the current directory has an acontext.yaml, it is first checked against
+its schema as acontext validate does.`,
+	Example: `  acontext docker up
+  acontext docker up -d --build
+  acontext docker up --wait --wait-timeout 5m
+  acontext docker up -d --service acontext-server-api --service acontext-server-core
+  acontext docker up -d --parallel 8
+  acontext docker up --no-parallel
+  acontext docker up -d --pull never`,
+	Args:        cobra.NoArgs,
+	Annotations: longRunning(),
+	RunE:        runDockerUp,
+}
+
+var dockerPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Pull Docker service images",
+	Long: `Pull the images of Docker Compose services without starting them, like
+docker compose pull, so a later acontext docker up --pull never can start
+them without network access. The images are those of the compose file
+docker up starts the services from.
+
+Use --service (repeatable) to only pull the images of specific services, and
+--no-progress to hide the pull progress.`,
+	Example: `  acontext docker pull
+  acontext docker pull --service acontext-server-pg --service acontext-server-redis
+  acontext docker pull --no-progress --retries 3`,
+	Args:        cobra.NoArgs,
+	Annotations: longRunning(),
+	RunE:        runDockerPull,
+}
+
+var dockerBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build Docker service images",
+	Long: `Build the images of the Docker Compose services that have a build section,
+like docker compose build, without starting them, so the images can be built
+in one CI stage and started with acontext docker up in another. The services
+are those of the compose file docker up starts them from.
+
+Each service is built in turn, with compose's build output streamed as it
+goes, and whether it was built is reported once all are done. A failed build
+does not stop the others; the command fails if any did.
+
+Use --service (repeatable) to only build specific services, --build-arg
+KEY=VALUE (repeatable) to set build arguments (a bare KEY takes its value
+from the environment), --no-cache to build without the cache and --pull to
+pull newer versions of the base images. Build argument values are never sent
+with telemetry.`,
+	Example: `  acontext docker build
+  acontext docker build --service acontext-server-api --build-arg VERSION=1.2.3
+  acontext docker build --no-cache --pull --build-arg HTTP_PROXY`,
+	Args:        cobra.NoArgs,
+	Annotations: longRunning(),
+	RunE:        runDockerBuild,
 }
 
 var dockerDownCmd = &cobra.Command{
 	Use:   "down",
 	Short: "Stop Docker services",
-	Long:  "Stop and remove all Docker Compose services",
-	RunE:  runDockerDown,
+	Long: `Stop and remove all Docker Compose services.
+
+--volumes also deletes the named volumes declared in the compose file, and
+the data in them. You are asked to confirm first unless --yes is passed,
+which is required when stdin is not a terminal. Data the default services
+keep in ./acontext_data (bind mounts) is never removed.
+
+Unlike with docker compose down, -v is the global --verbose flag: it does not
+remove the volumes, and a note says they are kept. Pass --volumes in full.
+
+--remove-orphans also removes containers for services that are no longer
+defined in the compose file.
+
+Each container gets SIGTERM and --stop-timeout seconds to stop, then
+SIGKILL. Raise it for services that need time to flush their state, or set
+docker.stop_timeout to change the default, compose's 10 seconds. The
+containers that had to be killed are reported once the services are down.
+Unlike the global --timeout, it takes seconds and does not limit the command,
+which ends once the last container is stopped or killed.`,
+	Example: `  acontext docker down
+  acontext docker down --remove-orphans
+  acontext docker down --stop-timeout 60
+  acontext docker down --volumes --yes`,
+	Args:        cobra.NoArgs,
+	Annotations: longRunning(),
+	RunE:        runDockerDown,
+}
+
+var dockerRestartCmd = &cobra.Command{
+	Use:         "restart [service]",
+	Short:       "Restart Docker services",
+	Long:        "Restart all Docker Compose services, or a single service, and wait for them to become healthy",
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: longRunning(),
+	RunE:        runDockerRestart,
+}
+
+var dockerRecreateCmd = &cobra.Command{
+	Use:   "recreate",
+	Short: "Force-recreate Docker service containers",
+	Long: `Recreate the containers of all Docker Compose services, or of --service
+(repeatable), from scratch even if compose considers them up to date, e.g.,
+after changing a config file they read at startup, then wait for them to
+become healthy. Like docker compose up -d --force-recreate, it saves stopping
+and starting the services again.
+
+The services a recreated service depends on are recreated too, unless
+--no-deps is set. The recreated containers are listed once they are up.`,
+	Example: `  acontext docker recreate
+  acontext docker recreate --service acontext-server-core --no-deps`,
+	Args:        cobra.NoArgs,
+	Annotations: longRunning(),
+	RunE:        runDockerRecreate,
 }
 
 var dockerStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show Docker services status",
-	Long:  "Display the status of all Docker Compose services",
-	RunE:  runDockerStatus,
+	Long: `Display the state, health, uptime and published ports of all Docker Compose services.
+
+Exits non-zero when any service is unhealthy, restarting or has failed, so it
+can be used as a health gate.`,
+	RunE: runDockerStatus,
+}
+
+var dockerStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the resource usage of Docker services",
+	Long: `Display a snapshot of the CPU, memory, network and disk I/O and process
+count of the running containers of the project's Docker Compose services,
+like docker stats --no-stream limited to the project.
+
+Use --service (repeatable) to only show specific services, and --watch to
+refresh the snapshot every --interval until interrupted with Ctrl-C. With
+--output json and --watch, a JSON object is printed for each snapshot.`,
+	Example: `  acontext docker stats
+  acontext docker stats --watch --interval 5s
+  acontext docker stats --service acontext-server-pg -o json`,
+	Args:        cobra.NoArgs,
+	Annotations: longRunning(),
+	RunE:        runDockerStats,
+}
+
+var dockerPortCmd = &cobra.Command{
+	Use:   "port [service] [container-port]",
+	Short: "Show the host ports of Docker services",
+	Long: `Show which host address the container port of a running service is
+published on, like docker compose port. The address is printed alone, as
+host:port, so it can be used in scripts; with --port-only only the port is.
+
+The container port is a number, optionally with its protocol, e.g., 5432 or
+53/udp (tcp if not given). Without it, every port the service publishes is
+listed, and without a service, those of every running service. It fails when
+the service is not running or does not publish the port.`,
+	Example: `  acontext docker port acontext-server-pg 5432
+  psql -h localhost -p "$(acontext docker port acontext-server-pg 5432 --port-only)" -U acontext
+  acontext docker port
+  acontext docker port acontext-server-pg -o json`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: runDockerPort,
 }
 
 var dockerLogsCmd = &cobra.Command{
 	Use:   "logs [service]",
 	Short: "View Docker services logs",
-	Long:  "Display logs from Docker Compose services",
-	Args:  cobra.MaximumNArgs(1),
-	RunE:  runDockerLogs,
+	Long: `Display logs from Docker Compose services.
+
+Use --follow to stream new lines until interrupted with Ctrl-C, and --service
+(repeatable) to only show logs from specific services.
+
+Use --tail N to only show the last N lines of each service's logs (or all).
+With --follow, the last N lines are shown before new ones are streamed, and
+N defaults to 100 so a long history does not scroll by first.
+
+Use --since and --until to only show logs from a time window, e.g., --since
+1h --until 30m for the lines logged between an hour and half an hour ago.
+Use --no-log-prefix to drop the service name in front of each line, e.g.,
+when showing the logs of a single service.`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: longRunning(),
+	RunE:        runDockerLogs,
+}
+
+var dockerExecCmd = &cobra.Command{
+	Use:   "exec [service] [command...]",
+	Short: "Run a command in a running service container",
+	Long: `Run a command in the running container of a service, like
+docker compose exec. Without a command an interactive shell is opened (sh,
+falling back to bash). Without a service you are asked to pick one of the
+running services.
+
+A TTY is allocated when both stdin and stdout are terminals, so interactive
+sessions work and piped output stays clean otherwise. Everything after the
+service is passed to the command, flags included. The command's exit code
+is passed through.`,
+	Example: `  acontext docker exec acontext-server-pg
+  acontext docker exec acontext-server-pg psql -U acontext
+  acontext docker exec acontext-server-redis redis-cli ping`,
+	Annotations: longRunning(),
+	RunE:        runDockerExec,
+}
+
+var dockerCpCmd = &cobra.Command{
+	Use:   "cp <service:path> <path> | cp <path> <service:path>",
+	Short: "Copy files between a service container and the host",
+	Long: `Copy a file or directory out of, or into, the running container of a
+service, like docker compose cp. The container side is written
+service:/path, the other one is a local path, resolved against the current
+directory.
+
+The service must be running. The local source must exist, and so must the
+directory a local destination is created in. End the source with /. to copy
+the contents of a directory rather than the directory itself. - streams a
+tar archive through stdin or stdout instead of a local path. A failed copy
+exits with the exit code of docker compose cp.`,
+	Example: `  acontext docker cp acontext-server-pg:/tmp/dump.sql ./dump.sql
+  acontext docker cp ./seed.sql acontext-server-pg:/docker-entrypoint-initdb.d/
+  acontext docker cp acontext-server-core:/app/logs/. ./logs
+  acontext docker cp acontext-server-core:/app/config - | tar -t`,
+	Args:              cobra.ExactArgs(2),
+	Annotations:       longRunning(),
+	ValidArgsFunction: completeCopyArg,
+	RunE:              runDockerCp,
+}
+
+var dockerConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Print the resolved compose configuration",
+	Long: `Print the fully-resolved compose configuration, like docker compose
+config: the compose files merged in order, with variables from the
+environment and .env interpolated and the active profiles applied.
+
+The compose files that were selected are listed first, in the order they
+are merged, on stderr so the configuration itself can be redirected. They
+are the ones up starts the services from: the project's docker-compose.yaml
+if it exists, and the default compose file embedded in the CLI otherwise.
+
+--services prints just the names of the services, one per line.`,
+	Example: `  acontext docker config
+  acontext docker config --services
+  acontext docker config --profile dev > resolved.yaml
+  acontext docker config -o json`,
+	Args: cobra.NoArgs,
+	RunE: runDockerConfig,
 }
 
 var dockerEnvCmd = &cobra.Command{
 	Use:   "env",
-	Short: "Generate .env file",
-	Long:  "Generate a new .env file with random secrets",
-	RunE:  runDockerEnv,
+	Short: "Generate or print the .env configuration",
+	Long: `Generate a new .env file with random secrets, or print the resolved
+environment for use in your shell.
+
+Without flags, you are prompted for the configuration and a .env file is
+written to the project directory, same as --write-dotenv.
+
+--export prints "export KEY='VALUE'" lines, quoted for POSIX shells, so they can
+be loaded with: eval "$(acontext docker env --export)". The values come from the
+existing .env file, or are prompted for when there is none.
+
+--output json prints the resolved values as a JSON object.
+
+An existing .env file is never overwritten unless --force is passed. When env
+files are passed to compose with --env-file or docker.env_files, keys that
+both they and the generated .env set are reported, with the value compose
+uses.
+
+--diff compares the .env file, if there is one, with the environment docker
+compose resolves for the compose file docker up runs: the env files passed to
+it (.env when there are none), later ones overriding earlier ones, and the
+variables of your environment, which override them all. Each key that was
+added, removed or changed is listed with where its resolved value comes from.
+Values are masked, since they may be credentials, unless --show-values is
+set. The command exits with 1 when there are differences, so it can be used
+as a drift check in CI; with --output json the differences are printed as a
+structured list.`,
+	Example: `  acontext docker env
+  eval "$(acontext docker env --export)"
+  acontext docker env --write-dotenv --force
+  acontext docker env -o json
+  acontext docker env --diff --env-file .env.ci
+  acontext docker env --diff --show-values -o json`,
+	Args: cobra.NoArgs,
+	RunE: runDockerEnv,
 }
 
 func init() {
+	DockerCmd.PersistentFlags().BoolVar(&allowComposeV1, "allow-compose-v1", false, "Run compose commands with the deprecated docker-compose v1 when Compose v2 is not installed")
 	dockerUpCmd.Flags().BoolVarP(&detachedMode, "detach", "d", false, "Run containers in the background")
+	dockerUpCmd.Flags().BoolVar(&upBuild, "build", false, "Rebuild images before starting containers")
+	dockerUpCmd.Flags().StringArrayVar(&upServices, "service", nil, "Only start this service (repeatable)")
+	dockerUpCmd.Flags().IntVar(&upParallel, "parallel", docker.DefaultParallelism(), "Maximum number of services started at once")
+	dockerUpCmd.Flags().BoolVar(&upNoParallel, "no-parallel", false, "Start services one at a time, in dependency order")
+	dockerUpCmd.MarkFlagsMutuallyExclusive("parallel", "no-parallel")
+	dockerUpCmd.Flags().StringVar(&upPull, "pull", "", "When to pull images: always, missing or never (default missing)")
+	dockerUpCmd.Flags().BoolVar(&upWait, "wait", false, "Start in the background and fail unless every service is healthy within --wait-timeout")
+	dockerUpCmd.Flags().DurationVar(&upWaitTimeout, "wait-timeout", 2*time.Minute, "How long --wait waits for services to become healthy")
+	dockerPullCmd.Flags().StringArrayVar(&pullServices, "service", nil, "Only pull the images of this service (repeatable)")
+	dockerPullCmd.Flags().BoolVar(&pullNoProgress, "no-progress", false, "Do not print pull progress")
+	dockerBuildCmd.Flags().StringArrayVar(&buildServices, "service", nil, "Only build the image of this service (repeatable)")
+	dockerBuildCmd.Flags().StringArrayVar(&buildArgs, "build-arg", nil, "Set a build argument, as KEY=VALUE or KEY to take it from the environment (repeatable)")
+	dockerBuildCmd.Flags().BoolVar(&buildNoCache, "no-cache", false, "Do not use the build cache")
+	dockerBuildCmd.Flags().BoolVar(&buildPull, "pull", false, "Always pull newer versions of the base images")
+	for _, c := range []*cobra.Command{dockerUpCmd, dockerPullCmd, dockerBuildCmd, dockerDownCmd, dockerRestartCmd, dockerRecreateCmd, dockerStatusCmd, dockerStatsCmd, dockerPortCmd, dockerLogsCmd, dockerExecCmd, dockerCpCmd, dockerConfigCmd} {
+		c.Flags().StringArrayVar(&dockerProfiles, "profile", nil, "Activate a compose profile (repeatable, defaults to the docker.profiles setting)")
+	}
+	for _, c := range []*cobra.Command{dockerUpCmd, dockerPullCmd, dockerBuildCmd, dockerDownCmd, dockerRestartCmd, dockerRecreateCmd, dockerStatusCmd, dockerStatsCmd, dockerPortCmd, dockerLogsCmd, dockerExecCmd, dockerCpCmd, dockerConfigCmd, dockerEnvCmd} {
+		c.Flags().StringArrayVar(&dockerEnvFiles, "env-file", nil, "Pass this env file to docker compose (repeatable, defaults to the docker.env_files setting)")
+	}
+	// exec and cp are not retried, a retry could run the command in the
+	// container twice or copy from a half-written file
+	for _, c := range []*cobra.Command{dockerUpCmd, dockerPullCmd, dockerBuildCmd, dockerDownCmd, dockerRestartCmd, dockerRecreateCmd, dockerStatusCmd, dockerStatsCmd, dockerLogsCmd, dockerConfigCmd} {
+		c.Flags().IntVar(&dockerRetries, "retries", 0, "Retry docker commands that fail with a transient error this many times")
+		c.Flags().DurationVar(&dockerRetryDelay, "retry-delay", docker.DefaultRetryDelay, "Delay before the first retry, doubled before each of the next ones")
+	}
 	DockerCmd.AddCommand(dockerUpCmd)
+	DockerCmd.AddCommand(dockerPullCmd)
+	DockerCmd.AddCommand(dockerBuildCmd)
+	dockerRestartCmd.Flags().DurationVar(&restartWaitTimeout, "wait-timeout", 2*time.Minute, "How long to wait for restarted services to become healthy")
+	dockerLogsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Stream new log lines until interrupted")
+	dockerLogsCmd.Flags().StringArrayVar(&logsServices, "service", nil, "Only show logs from this service (repeatable)")
+	dockerLogsCmd.Flags().DurationVar(&logsSince, "since", 0, "Only show logs newer than this duration (e.g., 10m, 1h)")
+	dockerLogsCmd.Flags().DurationVar(&logsUntil, "until", 0, "Only show logs older than this duration (e.g., 5m)")
+	dockerLogsCmd.Flags().StringVarP(&logsTail, "tail", "n", "", fmt.Sprintf("Number of lines to show from the end of each service's logs, or all (default all, %d with --follow)", defaultFollowTail))
+	dockerLogsCmd.Flags().BoolVar(&logsNoPrefix, "no-log-prefix", false, "Do not prefix each line with its service name")
+	dockerLogsCmd.Flags().BoolVar(&logsTimestamps, "timestamps", false, "Show timestamps")
+	// -v is taken by the global --verbose flag
+	dockerDownCmd.Flags().BoolVar(&downVolumes, "volumes", false, "Also remove named volumes and the data in them")
+	dockerDownCmd.Flags().BoolVar(&downRemoveOrphans, "remove-orphans", false, "Also remove containers for services not defined in the compose file")
+	dockerDownCmd.Flags().BoolVarP(&downYes, "yes", "y", false, "Do not ask for confirmation before removing volumes")
+	dockerDownCmd.Flags().IntVar(&downTimeout, "stop-timeout", 0, "Seconds each container may take to stop gracefully before it is killed (defaults to docker.stop_timeout, else compose's 10)")
 	DockerCmd.AddCommand(dockerDownCmd)
+	DockerCmd.AddCommand(dockerRestartCmd)
+	dockerRecreateCmd.Flags().StringArrayVar(&recreateServices, "service", nil, "Only recreate the containers of this service (repeatable)")
+	dockerRecreateCmd.Flags().BoolVar(&recreateNoDeps, "no-deps", false, "Do not recreate the services that --service depends on")
+	dockerRecreateCmd.Flags().DurationVar(&recreateWaitTimeout, "wait-timeout", 2*time.Minute, "How long to wait for recreated services to become healthy")
+	DockerCmd.AddCommand(dockerRecreateCmd)
 	DockerCmd.AddCommand(dockerStatusCmd)
+	dockerStatsCmd.Flags().StringArrayVar(&statsServices, "service", nil, "Only show the resource usage of this service (repeatable)")
+	dockerStatsCmd.Flags().BoolVarP(&statsWatch, "watch", "w", false, "Refresh the snapshot every --interval until interrupted")
+	dockerStatsCmd.Flags().DurationVar(&statsInterval, "interval", 2*time.Second, "How often --watch refreshes the snapshot")
+	DockerCmd.AddCommand(dockerStatsCmd)
+	// Not --quiet, the global flag that hides the port too
+	dockerPortCmd.Flags().BoolVar(&portOnly, "port-only", false, "Only print the host port")
+	DockerCmd.AddCommand(dockerPortCmd)
 	DockerCmd.AddCommand(dockerLogsCmd)
+	// Flags after the service belong to the command run in the container
+	dockerExecCmd.Flags().SetInterspersed(false)
+	DockerCmd.AddCommand(dockerExecCmd)
+	DockerCmd.AddCommand(dockerCpCmd)
+	dockerConfigCmd.Flags().BoolVar(&configServices, "services", false, "Only print the service names")
+	DockerCmd.AddCommand(dockerConfigCmd)
+	dockerEnvCmd.Flags().BoolVar(&envExport, "export", false, "Print export KEY='VALUE' lines for eval in a shell")
+	dockerEnvCmd.Flags().BoolVar(&envWriteDotenv, "write-dotenv", false, "Write the .env file into the project directory")
+	dockerEnvCmd.Flags().BoolVar(&envForce, "force", false, "Overwrite an existing .env file")
+	dockerEnvCmd.Flags().BoolVar(&envDiff, "diff", false, "Compare .env with the resolved compose environment, failing when they differ")
+	dockerEnvCmd.Flags().BoolVar(&envShowValues, "show-values", false, "With --diff, show the values instead of masking them")
+	for _, flag := range []string{"export", "write-dotenv", "force"} {
+		dockerEnvCmd.MarkFlagsMutuallyExclusive("diff", flag)
+	}
 	DockerCmd.AddCommand(dockerEnvCmd)
+	for _, c := range []*cobra.Command{dockerRestartCmd, dockerPortCmd, dockerLogsCmd, dockerExecCmd} {
+		c.ValidArgsFunction = completeServiceArg
+	}
+	for _, c := range []*cobra.Command{dockerUpCmd, dockerPullCmd, dockerBuildCmd, dockerRecreateCmd, dockerStatsCmd, dockerLogsCmd} {
+		_ = c.RegisterFlagCompletionFunc("service", completeServiceFlag)
+	}
+	for _, c := range []*cobra.Command{dockerUpCmd, dockerDownCmd, dockerLogsCmd, dockerStatusCmd} {
+		DockerShortcuts = append(DockerShortcuts, dockerShortcut(c))
+	}
+}
+
+// DockerShortcuts are the top-level commands running the most used docker
+// subcommands, e.g., acontext up for acontext docker up
+var DockerShortcuts []*cobra.Command
+
+// dockerShortcut returns a top-level command that runs the docker subcommand
+// target. It shares target's flags, so they are set the same way whichever
+// command parsed them, and reports target's command path to telemetry.
+func dockerShortcut(target *cobra.Command) *cobra.Command {
+	canonical := DockerCmd.Name() + " " + target.Name()
+	annotations := map[string]string{telemetry.CommandPathAnnotation: DockerCmd.Name() + "." + target.Name()}
+	for key, value := range target.Annotations {
+		annotations[key] = value
+	}
+	shortcut := &cobra.Command{
+		Use:               target.Use,
+		Short:             fmt.Sprintf("%s (shortcut for acontext %s)", target.Short, canonical),
+		Long:              fmt.Sprintf("Shortcut for acontext %s.\n\n%s", canonical, target.Long),
+		Example:           target.Example,
+		Args:              target.Args,
+		ValidArgsFunction: target.ValidArgsFunction,
+		Annotations:       annotations,
+		RunE:              target.RunE,
+	}
+	shortcut.Flags().AddFlagSet(target.Flags())
+	shortcut.Flags().AddFlagSet(DockerCmd.PersistentFlags())
+	return shortcut
 }
 
 func runDockerUp(cmd *cobra.Command, args []string) error {
@@ -78,26 +558,48 @@ func runDockerUp(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if err := applyRetries(cmd); err != nil {
+		return err
+	}
+	envFiles, err := applyEnvFiles(cmd, projectDir)
+	if err != nil {
+		return err
+	}
 
-	// Check Docker
-	if err := docker.CheckDockerInstalled(); err != nil {
-		return fmt.Errorf("docker check failed: %w", err)
+	if !cmd.Flags().Changed("detach") {
+		detachedMode = loadUserConfig().GetBool("docker.detach", detachedMode)
+	}
+	parallel := upParallel
+	if upNoParallel {
+		parallel = 1
+	} else if parallel < 1 {
+		return clierror.UsageError("--parallel must be at least 1, got %d", parallel)
+	}
+	telemetry.RecordFlag("parallel", strconv.Itoa(parallel))
+	if err := validatePullPolicy(upPull); err != nil {
+		return err
+	}
+	if err := validateProject(projectDir); err != nil {
+		return err
+	}
+
+	if err := checkDockerPrerequisites(cmd); err != nil {
+		return err
 	}
 
-	// Create temporary docker-compose file
-	composeFile, err := docker.CreateTempDockerCompose(projectDir)
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
 	if err != nil {
-		return fmt.Errorf("failed to create temporary docker-compose file: %w", err)
+		return err
 	}
-	defer func() {
-		_ = os.Remove(composeFile) // Clean up temp file
-	}()
+	defer cleanup()
+	applyProfiles(cmd, projectDir, composeFile)
 
-	// Check if .env file exists
+	// Check if .env file exists. Compose reads the env files instead of .env
+	// when they are given, so it is not needed then.
 	envFile := filepath.Join(projectDir, ".env")
-	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+	if _, err := os.Stat(envFile); os.IsNotExist(err) && len(envFiles) == 0 {
 		fmt.Println("🔐 .env file not found. Please provide the following configuration:")
-		envConfig, err := promptEnvConfig()
+		envConfig, err := promptEnvConfig(cmd.Context())
 		if err != nil {
 			return fmt.Errorf("failed to get environment configuration: %w", err)
 		}
@@ -107,152 +609,1754 @@ func runDockerUp(cmd *cobra.Command, args []string) error {
 		fmt.Println("✅ Generated .env file")
 	}
 
-	fmt.Println("🚀 Starting Docker services...")
-	if err := docker.Up(projectDir, composeFile, detachedMode); err != nil {
-		return fmt.Errorf("failed to start services: %w", err)
+	if upWait {
+		// Waiting for services needs them in the background
+		detachedMode = true
+	} else if cmd.Flags().Changed("wait-timeout") {
+		return clierror.UsageError("--wait-timeout needs --wait")
+	}
+	if upWaitTimeout <= 0 {
+		return clierror.UsageError("--wait-timeout must be positive, got %s", upWaitTimeout)
 	}
 
-	if detachedMode {
-		fmt.Println("⏳ Waiting for services to be healthy...")
-		if err := docker.WaitForHealth(projectDir, composeFile, 120*time.Second); err != nil {
-			fmt.Printf("⚠️  Warning: %v\n", err)
-			fmt.Println("   Services may still be starting. Check status with: acontext docker status")
-		} else {
-			fmt.Println()
-			fmt.Println("🎉 All services are running!")
+	opts := docker.UpOptions{
+		Detach:   detachedMode,
+		Build:    upBuild,
+		Services: upServices,
+		Parallel: parallel,
+		Pull:     upPull,
+	}
+	if opts.Pull == docker.PullNever {
+		if err := checkImagesPresent(cmd.Context(), projectDir, composeFile, opts.Services); err != nil {
+			return err
+		}
+	}
+	logging.FromContext(cmd.Context()).Debug("starting services", "parallel", parallel)
+	if len(opts.Services) > 0 {
+		fmt.Printf("🚀 Starting %s...\n", strings.Join(opts.Services, ", "))
+	} else {
+		fmt.Println("🚀 Starting Docker services...")
+	}
+
+	if !opts.Detach {
+		// Compose gets Ctrl-C from the terminal and stops its containers gracefully
+		release := interrupt.Delegate()
+		defer release()
+		err := docker.Up(cmd.Context(), projectDir, composeFile, opts)
+		if interrupt.Received() && !errors.Is(cmd.Context().Err(), context.DeadlineExceeded) {
+			// Compose stopped the services after Ctrl-C
+			fmt.Println("✅ Services stopped")
+			return nil
+		}
+		if err != nil {
+			return upError(cmd.Context(), projectDir, composeFile, opts.Services, err)
+		}
+		return nil
+	}
+
+	if upWait {
+		if err := upAndWait(cmd.Context(), projectDir, composeFile, opts); err != nil {
+			return err
+		}
+		fmt.Println()
+		fmt.Println("🎉 All services are healthy!")
+		return nil
+	}
+
+	if err := docker.Up(cmd.Context(), projectDir, composeFile, opts); err != nil {
+		return upError(cmd.Context(), projectDir, composeFile, opts.Services, err)
+	}
+
+	err = progress.Run(cmd.Context(), "⏳ Waiting for services to be healthy...", func() error {
+		if len(opts.Services) > 0 {
+			return docker.WaitForServices(cmd.Context(), projectDir, composeFile, opts.Services, 120*time.Second)
 		}
+		return docker.WaitForHealth(cmd.Context(), projectDir, composeFile, 120*time.Second)
+	})
+	if err != nil {
+		fmt.Printf("⚠️  Warning: %v\n", err)
+		fmt.Println("   Services may still be starting. Check status with: acontext docker status")
+	} else {
+		fmt.Println()
+		fmt.Println("🎉 All services are running!")
 	}
 
 	return nil
 }
 
-func runDockerDown(cmd *cobra.Command, args []string) error {
-	projectDir, err := getProjectDir()
-	if err != nil {
-		return err
+// upAndWait starts the services in the background and waits until they are
+// healthy, at most --wait-timeout: with compose's --wait when it supports it,
+// by polling their status otherwise. A timeout fails with the services that
+// are not healthy yet.
+func upAndWait(ctx context.Context, projectDir, composeFile string, opts docker.UpOptions) error {
+	if docker.SupportsWait(ctx) {
+		opts.Wait, opts.WaitTimeout = true, upWaitTimeout
+		telemetry.RecordFlag("wait_mode", "compose")
+		err := docker.Up(ctx, projectDir, composeFile, opts)
+		if err == nil {
+			return nil
+		}
+		// Compose fails the same way whether a service did not start or did
+		// not become healthy in time, tell them apart from their status
+		if ctx.Err() == nil {
+			if infos, listErr := docker.ListServices(ctx, projectDir, composeFile); listErr == nil && len(docker.FailedServices(infos, opts.Services)) == 0 {
+				if pending := docker.UnhealthyServices(infos, opts.Services); len(pending) > 0 {
+					return waitTimeoutError(pending)
+				}
+			}
+		}
+		return upError(ctx, projectDir, composeFile, opts.Services, err)
 	}
 
-	// Try to find existing compose file or create temp one
-	composeFile := filepath.Join(projectDir, "docker-compose.yaml")
-	if _, err := os.Stat(composeFile); os.IsNotExist(err) {
-		tmpFile, err := docker.CreateTempDockerCompose(projectDir)
-		if err != nil {
-			return fmt.Errorf("failed to create temporary docker-compose file: %w", err)
+	telemetry.RecordFlag("wait_mode", "poll")
+	if err := docker.Up(ctx, projectDir, composeFile, opts); err != nil {
+		return upError(ctx, projectDir, composeFile, opts.Services, err)
+	}
+	err := progress.Run(ctx, "⏳ Waiting for services to be healthy...", func() error {
+		return docker.WaitForServices(ctx, projectDir, composeFile, opts.Services, upWaitTimeout)
+	})
+	if err != nil && ctx.Err() == nil {
+		if infos, listErr := docker.ListServices(ctx, projectDir, composeFile); listErr == nil {
+			if pending := docker.UnhealthyServices(infos, opts.Services); len(pending) > 0 {
+				return waitTimeoutError(pending)
+			}
 		}
-		defer func() {
-			_ = os.Remove(tmpFile)
-		}()
-		composeFile = tmpFile
+		return clierror.WithCode(clierror.Docker, err)
 	}
+	return err
+}
 
-	fmt.Println("🛑 Stopping Docker services...")
-	if err := docker.Down(projectDir, composeFile); err != nil {
-		return fmt.Errorf("failed to stop services: %w", err)
+// waitTimeoutError reports the services that were not healthy when
+// --wait-timeout passed
+func waitTimeoutError(pending []string) error {
+	return clierror.WithCode(clierror.Docker, fmt.Errorf("services not healthy after %s: %s\ncheck them with: acontext docker status, or acontext docker logs --service <name>", upWaitTimeout, strings.Join(pending, ", ")))
+}
+
+// upError reports a failed docker up, naming the services that failed to
+// start when compose can still list them
+func upError(ctx context.Context, projectDir, composeFile string, services []string, err error) error {
+	if ctx.Err() == nil {
+		if infos, listErr := docker.ListServices(ctx, projectDir, composeFile); listErr == nil {
+			if failed := docker.FailedServices(infos, services); len(failed) > 0 {
+				err = fmt.Errorf("failed to start %s: %w", strings.Join(failed, ", "), err)
+				return clierror.WithCode(clierror.Docker, err)
+			}
+		}
 	}
+	return clierror.WithCode(clierror.Docker, fmt.Errorf("failed to start services: %w", err))
+}
 
-	fmt.Println("✅ Services stopped")
+// validatePullPolicy checks the value of up's --pull flag
+func validatePullPolicy(policy string) error {
+	if policy == "" {
+		return nil
+	}
+	if !docker.IsPullPolicy(policy) {
+		return clierror.UsageError("invalid --pull %q (use %s)", policy, strings.Join(docker.PullPolicies, ", "))
+	}
+	telemetry.RecordFlag("pull", policy)
 	return nil
 }
 
-func runDockerStatus(cmd *cobra.Command, args []string) error {
+// checkImagesPresent fails, naming the missing images, when up --pull never
+// could not start services because their images are not present
+func checkImagesPresent(ctx context.Context, projectDir, composeFile string, services []string) error {
+	missing, err := docker.MissingImages(ctx, projectDir, composeFile, services)
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	err = fmt.Errorf("%d required image(s) are not present and --pull never prevents fetching them: %s", len(missing), strings.Join(missing, ", "))
+	return clierror.WithCode(clierror.Docker, clierror.WithHint(err, "Pull them first with: acontext docker pull"))
+}
+
+func runDockerPull(cmd *cobra.Command, args []string) error {
 	projectDir, err := getProjectDir()
 	if err != nil {
 		return err
 	}
+	if err := applyRetries(cmd); err != nil {
+		return err
+	}
+	if _, err := applyEnvFiles(cmd, projectDir); err != nil {
+		return err
+	}
+
+	if err := checkDockerPrerequisites(cmd); err != nil {
+		return err
+	}
+
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	applyProfiles(cmd, projectDir, composeFile)
 
-	// Try to find existing compose file or create temp one
-	composeFile := filepath.Join(projectDir, "docker-compose.yaml")
-	if _, err := os.Stat(composeFile); os.IsNotExist(err) {
-		tmpFile, err := docker.CreateTempDockerCompose(projectDir)
-		if err != nil {
-			return fmt.Errorf("failed to create temporary docker-compose file: %w", err)
-		}
-		defer func() {
-			_ = os.Remove(tmpFile)
-		}()
-		composeFile = tmpFile
+	if len(pullServices) > 0 {
+		fmt.Printf("📥 Pulling images for %s...\n", strings.Join(pullServices, ", "))
+	} else {
+		fmt.Println("📥 Pulling Docker images...")
+	}
+	opts := docker.PullOptions{
+		Services: pullServices,
+		Quiet:    pullNoProgress,
+	}
+	if err := docker.Pull(cmd.Context(), projectDir, composeFile, opts); err != nil {
+		return clierror.WithCode(clierror.Docker, fmt.Errorf("failed to pull images: %w", err))
 	}
 
-	return docker.Status(projectDir, composeFile)
+	fmt.Println("✅ Images pulled")
+	return nil
 }
 
-func runDockerLogs(cmd *cobra.Command, args []string) error {
+// dockerBuildResult is the JSON result of docker build
+type dockerBuildResult struct {
+	output.Envelope
+	Services []docker.BuildResult `json:"services"`
+}
+
+func runDockerBuild(cmd *cobra.Command, args []string) error {
+	for _, arg := range buildArgs {
+		if err := docker.ValidateBuildArg(arg); err != nil {
+			return clierror.WithCode(clierror.Usage, err)
+		}
+	}
+
 	projectDir, err := getProjectDir()
 	if err != nil {
 		return err
 	}
+	if err := applyRetries(cmd); err != nil {
+		return err
+	}
+	if _, err := applyEnvFiles(cmd, projectDir); err != nil {
+		return err
+	}
 
-	// Try to find existing compose file or create temp one
-	composeFile := filepath.Join(projectDir, "docker-compose.yaml")
-	if _, err := os.Stat(composeFile); os.IsNotExist(err) {
-		tmpFile, err := docker.CreateTempDockerCompose(projectDir)
-		if err != nil {
-			return fmt.Errorf("failed to create temporary docker-compose file: %w", err)
+	if err := checkDockerPrerequisites(cmd); err != nil {
+		return err
+	}
+
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	applyProfiles(cmd, projectDir, composeFile)
+
+	services, err := docker.BuildableServices(cmd.Context(), projectDir, composeFile, buildServices)
+	if err != nil {
+		if len(buildServices) > 0 {
+			return clierror.WithCode(clierror.Usage, err)
 		}
-		defer func() {
-			_ = os.Remove(tmpFile)
-		}()
-		composeFile = tmpFile
+		return clierror.WithCode(clierror.Docker, err)
 	}
 
-	service := ""
-	if len(args) > 0 {
-		service = args[0]
+	if output.IsJSON() {
+		output.RedirectProse()
+	}
+	if len(services) == 0 {
+		fmt.Println("ℹ️  Skipping build: no service has a build section")
+	}
+	opts := docker.BuildOptions{
+		BuildArgs: buildArgs,
+		NoCache:   buildNoCache,
+		Pull:      buildPull,
+	}
+	results := make([]docker.BuildResult, 0, len(services))
+	for _, service := range services {
+		fmt.Printf("🔨 Building %s...\n", service)
+		results = append(results, docker.Build(cmd.Context(), projectDir, composeFile, service, opts))
+		if err := cmd.Context().Err(); err != nil {
+			return err
+		}
+	}
+
+	var failed []string
+	if len(results) > 0 {
+		fmt.Println()
+		fmt.Println("Build results:")
+	}
+	for _, result := range results {
+		if result.Built {
+			fmt.Printf("  ✅ %s (%s)\n", result.Service, time.Duration(result.Duration)*time.Millisecond)
+			continue
+		}
+		failed = append(failed, result.Service)
+		fmt.Printf("  ❌ %s: %s\n", result.Service, result.Error)
+	}
+
+	var buildErr error
+	if len(failed) > 0 {
+		buildErr = clierror.WithCode(clierror.Docker, fmt.Errorf("failed to build %s", strings.Join(failed, ", ")))
 	}
 
-	return docker.Logs(projectDir, composeFile, service)
+	if output.IsJSON() {
+		if err := output.PrintJSON(dockerBuildResult{
+			Envelope: output.NewEnvelope("docker.build"),
+			Services: results,
+		}); err != nil {
+			return err
+		}
+		if buildErr != nil {
+			return output.Reported(buildErr)
+		}
+		return nil
+	}
+	if buildErr != nil {
+		return buildErr
+	}
+	if len(results) > 0 {
+		fmt.Println("✅ Images built")
+	}
+	return nil
 }
 
-func runDockerEnv(cmd *cobra.Command, args []string) error {
+func runDockerDown(cmd *cobra.Command, args []string) error {
 	projectDir, err := getProjectDir()
 	if err != nil {
 		return err
 	}
+	if err := applyRetries(cmd); err != nil {
+		return err
+	}
+	if _, err := applyEnvFiles(cmd, projectDir); err != nil {
+		return err
+	}
 
-	envFile := filepath.Join(projectDir, ".env")
+	if downVolumes && !downYes && !tty.CanPrompt(cmd.Context()) {
+		return clierror.UsageError("--volumes permanently deletes data; pass --yes to confirm when %s", tty.NoPromptReason(cmd.Context()))
+	}
+	// docker compose down -v out of habit only turns on verbose output here
+	if verbose := cmd.Flags().Lookup("verbose"); verbose != nil && verbose.Changed && !downVolumes {
+		fmt.Println("ℹ️  Keeping the named volumes: -v is --verbose, pass --volumes to also delete them")
+	}
+	stopTimeout, err := resolveStopTimeout(cmd)
+	if err != nil {
+		return err
+	}
 
-	// Check if file already exists
-	if _, err := os.Stat(envFile); err == nil {
-		fmt.Printf("⚠️  .env file already exists at %s\n", envFile)
-		fmt.Println("   This will overwrite the existing file.")
-		fmt.Print("   Continue? (y/N): ")
-		var response string
-		if _, err := fmt.Scanln(&response); err != nil {
-			fmt.Println("Cancelled.")
-			return nil
+	if err := checkDockerPrerequisites(cmd); err != nil {
+		return err
+	}
+
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	applyProfiles(cmd, projectDir, composeFile)
+
+	if downVolumes && !downYes {
+		volumes, err := docker.ListVolumes(cmd.Context(), projectDir, composeFile)
+		if err != nil {
+			return err
+		}
+		confirmed, err := confirmRemoveVolumes(volumes)
+		if err != nil {
+			return err
 		}
-		if response != "y" && response != "Y" {
-			fmt.Println("Cancelled.")
+		if !confirmed {
+			fmt.Println("Aborted, nothing was stopped")
 			return nil
 		}
 	}
 
-	fmt.Println("🔐 Generating .env file...")
-	fmt.Println("   Please provide the following configuration:")
-	envConfig, err := promptEnvConfig()
-	if err != nil {
-		return fmt.Errorf("failed to get environment configuration: %w", err)
+	fmt.Println("🛑 Stopping Docker services...")
+	// Stop the containers before down removes them, so their exit codes
+	// tell which ones had to be killed
+	killed := stopServices(cmd.Context(), projectDir, composeFile, stopTimeout)
+	opts := docker.DownOptions{
+		Volumes:       downVolumes,
+		RemoveOrphans: downRemoveOrphans,
+		StopTimeout:   stopTimeout,
 	}
-	if err := docker.GenerateEnvFile(envFile, envConfig); err != nil {
-		return fmt.Errorf("failed to generate .env file: %w", err)
+	if err := docker.Down(cmd.Context(), projectDir, composeFile, opts); err != nil {
+		return clierror.WithCode(clierror.Docker, fmt.Errorf("failed to stop services: %w", err))
 	}
 
-	fmt.Printf("✅ Generated .env file at %s\n", envFile)
+	fmt.Println("✅ Services stopped")
+	if len(killed) > 0 {
+		limit := docker.DefaultStopTimeout
+		if stopTimeout != nil {
+			limit = time.Duration(*stopTimeout) * time.Second
+		}
+		fmt.Printf("⚠️  Warning: %d container(s) did not stop within %s and were killed:\n", len(killed), limit)
+		for _, info := range killed {
+			fmt.Printf("   - %s (%s)\n", info.Name, info.Service)
+		}
+		fmt.Println("   Give them more time with --stop-timeout or the docker.stop_timeout setting")
+	}
 	return nil
 }
 
-// getProjectDir gets the current project directory
-// It always returns the current working directory, allowing commands to be run from anywhere
-func getProjectDir() (string, error) {
-	cwd, err := os.Getwd()
+// resolveStopTimeout returns the seconds docker down gives containers to
+// stop: --stop-timeout, else the docker.stop_timeout setting, else nil for
+// compose's default
+func resolveStopTimeout(cmd *cobra.Command) (*int, error) {
+	if cmd.Flags().Changed("stop-timeout") {
+		if downTimeout < 0 {
+			return nil, clierror.UsageError("--stop-timeout must be 0 or more seconds, got %d", downTimeout)
+		}
+		return &downTimeout, nil
+	}
+	value, ok := loadUserConfig().Get("docker.stop_timeout")
+	if !ok {
+		return nil, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		fmt.Printf("⚠️  Warning: Ignoring docker.stop_timeout %q, expected a number of seconds\n", value)
+		return nil, nil
+	}
+	return &seconds, nil
+}
+
+// stopServices stops the running services with timeout and returns the
+// containers that were killed. When the services cannot be listed, nothing
+// is reported and down stops them instead.
+func stopServices(ctx context.Context, projectDir, composeFile string, timeout *int) []docker.ServiceInfo {
+	logger := logging.FromContext(ctx)
+	before, err := docker.ListServices(ctx, projectDir, composeFile)
 	if err != nil {
-		return "", err
+		logger.Info("not reporting killed containers", "error", err)
+		return nil
 	}
-	return cwd, nil
+	if err := docker.Stop(ctx, projectDir, composeFile, timeout); err != nil {
+		logger.Info("not reporting killed containers", "error", err)
+		return nil
+	}
+	after, err := docker.ListServices(ctx, projectDir, composeFile)
+	if err != nil {
+		logger.Info("not reporting killed containers", "error", err)
+		return nil
+	}
+	return docker.KilledContainers(before, after)
+}
+
+// confirmRemoveVolumes asks before docker down deletes volumes, naming the
+// ones whose data will be lost
+func confirmRemoveVolumes(volumes []string) (bool, error) {
+	if len(volumes) > 0 {
+		fmt.Println("⚠️  The following volumes and all data in them will be permanently deleted:")
+		for _, volume := range volumes {
+			fmt.Printf("   - %s\n", volume)
+		}
+	} else {
+		fmt.Println("⚠️  No named volumes are declared, but anonymous volumes attached to the containers will be permanently deleted.")
+	}
+
+	confirmed := false
+	prompt := &survey.Confirm{
+		Message: "Stop the services and delete these volumes?",
+		Default: false,
+	}
+	if err := survey.AskOne(prompt, &confirmed); err != nil {
+		return false, fmt.Errorf("failed to get confirmation: %w", err)
+	}
+	return confirmed, nil
 }
 
-// promptEnvConfig prompts user for required environment configuration
-func promptEnvConfig() (*docker.EnvConfig, error) {
+func runDockerRestart(cmd *cobra.Command, args []string) error {
+	projectDir, err := getProjectDir()
+	if err != nil {
+		return err
+	}
+	if err := applyRetries(cmd); err != nil {
+		return err
+	}
+	if _, err := applyEnvFiles(cmd, projectDir); err != nil {
+		return err
+	}
+
+	if err := checkDockerPrerequisites(cmd); err != nil {
+		return err
+	}
+
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	applyProfiles(cmd, projectDir, composeFile)
+
+	service := ""
+	var services []string
+	if len(args) > 0 {
+		service = args[0]
+		services = []string{service}
+	}
+
+	if service != "" {
+		fmt.Printf("🔄 Restarting %s...\n", service)
+	} else {
+		fmt.Println("🔄 Restarting Docker services...")
+	}
+	if err := docker.Restart(cmd.Context(), projectDir, composeFile, service); err != nil {
+		return clierror.WithCode(clierror.Docker, fmt.Errorf("failed to restart services: %w", err))
+	}
+
+	err = progress.Run(cmd.Context(), "⏳ Waiting for services to be healthy...", func() error {
+		return docker.WaitForServices(cmd.Context(), projectDir, composeFile, services, restartWaitTimeout)
+	})
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+
+	fmt.Println("✅ Services restarted")
+	return nil
+}
+
+func runDockerRecreate(cmd *cobra.Command, args []string) error {
+	projectDir, err := getProjectDir()
+	if err != nil {
+		return err
+	}
+	if err := applyRetries(cmd); err != nil {
+		return err
+	}
+	if _, err := applyEnvFiles(cmd, projectDir); err != nil {
+		return err
+	}
+	if recreateNoDeps && len(recreateServices) == 0 {
+		return clierror.UsageError("--no-deps needs --service: without it every service is recreated")
+	}
+	if recreateWaitTimeout <= 0 {
+		return clierror.UsageError("--wait-timeout must be positive, got %s", recreateWaitTimeout)
+	}
+
+	if err := checkDockerPrerequisites(cmd); err != nil {
+		return err
+	}
+
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	applyProfiles(cmd, projectDir, composeFile)
+
+	before, err := docker.ListServices(cmd.Context(), projectDir, composeFile)
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+
+	opts := docker.UpOptions{
+		Detach:        true,
+		Services:      recreateServices,
+		ForceRecreate: true,
+		NoDeps:        recreateNoDeps,
+	}
+	if len(opts.Services) > 0 {
+		fmt.Printf("🔁 Recreating %s...\n", strings.Join(opts.Services, ", "))
+	} else {
+		fmt.Println("🔁 Recreating Docker services...")
+	}
+	if err := docker.Up(cmd.Context(), projectDir, composeFile, opts); err != nil {
+		return upError(cmd.Context(), projectDir, composeFile, opts.Services, err)
+	}
+
+	err = progress.Run(cmd.Context(), "⏳ Waiting for services to be healthy...", func() error {
+		return docker.WaitForServices(cmd.Context(), projectDir, composeFile, opts.Services, recreateWaitTimeout)
+	})
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+
+	after, err := docker.ListServices(cmd.Context(), projectDir, composeFile)
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+	recreated := docker.RecreatedContainers(before, after)
+	fmt.Printf("✅ Recreated %d container(s)\n", len(recreated))
+	for _, info := range recreated {
+		fmt.Printf("   - %s (%s)\n", info.Name, info.Service)
+	}
+	return nil
+}
+
+func runDockerStatus(cmd *cobra.Command, args []string) error {
+	projectDir, err := getProjectDir()
+	if err != nil {
+		return err
+	}
+	if err := applyRetries(cmd); err != nil {
+		return err
+	}
+	if _, err := applyEnvFiles(cmd, projectDir); err != nil {
+		return err
+	}
+	if err := detectCompose(cmd); err != nil {
+		return err
+	}
+
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	profiles := applyProfiles(cmd, projectDir, composeFile)
+
+	services, err := docker.ListServices(cmd.Context(), projectDir, composeFile)
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+
+	var failing []string
+	for _, service := range services {
+		if service.NeedsAttention() {
+			failing = append(failing, service.Service)
+		}
+	}
+	var statusErr error
+	if len(failing) > 0 {
+		statusErr = clierror.WithCode(clierror.Docker, fmt.Errorf("%d service(s) need attention: %s", len(failing), strings.Join(failing, ", ")))
+	}
+
+	if output.IsJSON() {
+		if err := output.PrintJSON(dockerStatusResult{
+			Envelope: output.NewEnvelope("docker.status"),
+			Healthy:  statusErr == nil,
+			Profiles: profiles,
+			Services: newServiceStatuses(services),
+		}); err != nil {
+			return err
+		}
+		if statusErr != nil {
+			return output.Reported(statusErr)
+		}
+		return nil
+	}
+
+	if len(services) == 0 {
+		fmt.Println("No services are running. Start them with: acontext docker up")
+		return nil
+	}
+
+	if len(profiles) > 0 {
+		fmt.Printf("Profiles: %s\n\n", strings.Join(profiles, ", "))
+	}
+	printServicesTable(services)
+	return statusErr
+}
+
+// printServicesTable prints services as a column-aligned table, marking the
+// ones that need attention and, when color is enabled, coloring their rows
+func printServicesTable(services []docker.ServiceInfo) {
+	// Rows are colored once aligned, since escape codes would count as width
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "  SERVICE\tSTATE\tHEALTH\tUPTIME\tPORTS")
+	for _, service := range services {
+		marker := " "
+		if service.NeedsAttention() {
+			marker = "⚠"
+		}
+		_, _ = fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\t%s\n",
+			marker,
+			service.Service,
+			service.State,
+			valueOrDash(service.Health),
+			valueOrDash(service.Uptime()),
+			valueOrDash(service.PortSummary()),
+		)
+	}
+	_ = w.Flush()
+
+	rows := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	fmt.Println(color.Bold(rows[0]))
+	for i, service := range services {
+		row := rows[i+1]
+		switch {
+		case service.NeedsAttention():
+			row = color.Red(row)
+		case service.IsHealthy():
+			row = color.Green(row)
+		}
+		fmt.Println(row)
+	}
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// dockerStatusResult is the JSON result of docker status
+type dockerStatusResult struct {
+	output.Envelope
+	Healthy  bool            `json:"healthy"`
+	Profiles []string        `json:"profiles,omitempty"`
+	Services []serviceStatus `json:"services"`
+}
+
+// serviceStatus is a service in the docker status JSON result
+type serviceStatus struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Service    string          `json:"service"`
+	State      string          `json:"state"`
+	Status     string          `json:"status"`
+	Health     string          `json:"health"`
+	ExitCode   int             `json:"exit_code"`
+	Ports      string          `json:"ports"`
+	Publishers []portPublisher `json:"publishers"`
+}
+
+// portPublisher is a container port of a service in the docker status JSON
+// result, and the host port it is published on, 0 if it is only exposed
+type portPublisher struct {
+	HostIP        string `json:"host_ip"`
+	ContainerPort int    `json:"container_port"`
+	HostPort      int    `json:"host_port"`
+	Protocol      string `json:"protocol"`
+}
+
+// newServiceStatuses maps the services docker compose lists to the docker
+// status JSON result
+func newServiceStatuses(services []docker.ServiceInfo) []serviceStatus {
+	statuses := make([]serviceStatus, 0, len(services))
+	for _, service := range services {
+		publishers := make([]portPublisher, 0, len(service.Publishers))
+		for _, publisher := range service.Publishers {
+			publishers = append(publishers, portPublisher{
+				HostIP:        publisher.URL,
+				ContainerPort: publisher.TargetPort,
+				HostPort:      publisher.PublishedPort,
+				Protocol:      strings.ToLower(publisher.Protocol),
+			})
+		}
+		statuses = append(statuses, serviceStatus{
+			ID:         service.ID,
+			Name:       service.Name,
+			Service:    service.Service,
+			State:      service.State,
+			Status:     service.Status,
+			Health:     service.Health,
+			ExitCode:   service.ExitCode,
+			Ports:      service.PortSummary(),
+			Publishers: publishers,
+		})
+	}
+	return statuses
+}
+
+func runDockerStats(cmd *cobra.Command, args []string) error {
+	if statsWatch && statsInterval <= 0 {
+		return clierror.UsageError("invalid --interval %s (use a positive duration, e.g., 2s)", statsInterval)
+	}
+	projectDir, err := getProjectDir()
+	if err != nil {
+		return err
+	}
+	if err := applyRetries(cmd); err != nil {
+		return err
+	}
+	if _, err := applyEnvFiles(cmd, projectDir); err != nil {
+		return err
+	}
+	if err := detectCompose(cmd); err != nil {
+		return err
+	}
+
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	applyProfiles(cmd, projectDir, composeFile)
+
+	ctx := cmd.Context()
+	clearScreen := statsWatch && !output.IsJSON() && tty.IsStdoutTerminal()
+	for {
+		stats, err := docker.Stats(ctx, projectDir, composeFile, statsServices)
+		if err != nil {
+			if statsWatch && interrupt.IsInterrupted(ctx) {
+				// Interrupted by the user
+				return nil
+			}
+			return clierror.WithCode(clierror.Docker, err)
+		}
+		if clearScreen {
+			// Move the cursor home and clear the screen, like watch(1)
+			fmt.Print("\033[H\033[2J")
+		}
+		if err := printStats(stats); err != nil {
+			return err
+		}
+		if !statsWatch {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(statsInterval):
+		}
+		if !clearScreen && !output.IsJSON() {
+			fmt.Println()
+		}
+	}
+}
+
+// printStats prints a docker stats snapshot as a JSON result or a table
+func printStats(stats []docker.ServiceStats) error {
+	if output.IsJSON() {
+		return output.PrintJSON(dockerStatsResult{
+			Envelope: output.NewEnvelope("docker.stats"),
+			Time:     time.Now().UTC().Format(time.RFC3339),
+			Services: stats,
+		})
+	}
+	if statsWatch {
+		fmt.Printf("Every %s: %s (Ctrl-C to stop)\n\n", statsInterval, time.Now().Format(time.TimeOnly))
+	}
+	if len(stats) == 0 {
+		fmt.Println("No services are running. Start them with: acontext docker up")
+		return nil
+	}
+	printStatsTable(stats)
+	return nil
+}
+
+// printStatsTable prints a docker stats snapshot as a column-aligned table
+func printStatsTable(stats []docker.ServiceStats) {
+	// The header is made bold once aligned, since escape codes would count as width
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SERVICE\tCPU %\tMEM USAGE / LIMIT\tMEM %\tNET I/O\tBLOCK I/O\tPIDS")
+	for _, s := range stats {
+		_, _ = fmt.Fprintf(w, "%s\t%.2f%%\t%s / %s\t%.2f%%\t%s\t%s\t%d\n",
+			s.Service,
+			s.CPUPercent,
+			valueOrDash(s.MemoryUsage),
+			valueOrDash(s.MemoryLimit),
+			s.MemoryPercent,
+			valueOrDash(s.NetIO),
+			valueOrDash(s.BlockIO),
+			s.PIDs,
+		)
+	}
+	_ = w.Flush()
+
+	header, rows, _ := strings.Cut(table.String(), "\n")
+	fmt.Println(color.Bold(header))
+	fmt.Print(rows)
+}
+
+// dockerStatsResult is the JSON result of docker stats, one per snapshot
+// with --watch
+type dockerStatsResult struct {
+	output.Envelope
+	Time     string                `json:"time"`
+	Services []docker.ServiceStats `json:"services"`
+}
+
+// dockerPortResult is the JSON result of docker port
+type dockerPortResult struct {
+	output.Envelope
+	Ports []docker.PortMapping `json:"ports"`
+}
+
+func runDockerPort(cmd *cobra.Command, args []string) error {
+	var port int
+	var protocol string
+	if len(args) > 1 {
+		var err error
+		if port, protocol, err = docker.ParseContainerPort(args[1]); err != nil {
+			return clierror.WithCode(clierror.Usage, err)
+		}
+	}
+	projectDir, err := getProjectDir()
+	if err != nil {
+		return err
+	}
+	if _, err := applyEnvFiles(cmd, projectDir); err != nil {
+		return err
+	}
+	if err := detectCompose(cmd); err != nil {
+		return err
+	}
+
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	applyProfiles(cmd, projectDir, composeFile)
+
+	infos, err := docker.ListServices(cmd.Context(), projectDir, composeFile)
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+
+	var mappings []docker.PortMapping
+	switch len(args) {
+	case 0:
+		mappings = docker.PublishedPorts(infos, nil)
+	case 1:
+		if err := docker.CheckRunning(infos, args[0]); err != nil {
+			return clierror.WithCode(clierror.Docker, err)
+		}
+		mappings = docker.PublishedPorts(infos, args[:1])
+	default:
+		if mappings, err = docker.LookupPort(infos, args[0], port, protocol); err != nil {
+			return clierror.WithCode(clierror.Docker, err)
+		}
+	}
+
+	if output.IsJSON() {
+		return output.PrintJSON(dockerPortResult{
+			Envelope: output.NewEnvelope("docker.port"),
+			Ports:    mappings,
+		})
+	}
+	if portOnly {
+		for _, mapping := range mappings {
+			fmt.Println(mapping.HostPort)
+		}
+		return nil
+	}
+	if len(args) > 1 {
+		for _, mapping := range mappings {
+			fmt.Println(mapping.Address())
+		}
+		return nil
+	}
+	if len(mappings) == 0 {
+		if len(docker.RunningServices(infos)) == 0 {
+			fmt.Println("No services are running. Start them with: acontext docker up -d")
+		} else {
+			fmt.Println("No ports are published on the host")
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tPORT\tADDRESS")
+	for _, mapping := range mappings {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", mapping.Service, mapping.Port(), mapping.Address())
+	}
+	return w.Flush()
+}
+
+func runDockerLogs(cmd *cobra.Command, args []string) error {
+	tail, err := logsTailLines(logsTail, logsFollow)
+	if err != nil {
+		return err
+	}
+	if err := validateLogsWindow(logsSince, logsUntil); err != nil {
+		return err
+	}
+	projectDir, err := getProjectDir()
+	if err != nil {
+		return err
+	}
+	if err := applyRetries(cmd); err != nil {
+		return err
+	}
+	if _, err := applyEnvFiles(cmd, projectDir); err != nil {
+		return err
+	}
+	if err := detectCompose(cmd); err != nil {
+		return err
+	}
+
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	applyProfiles(cmd, projectDir, composeFile)
+
+	services := append([]string{}, logsServices...)
+	services = append(services, args...)
+
+	err = docker.Logs(cmd.Context(), projectDir, composeFile, docker.LogsOptions{
+		Services:   services,
+		Follow:     logsFollow,
+		Since:      logsSince,
+		Until:      logsUntil,
+		Tail:       tail,
+		Timestamps: logsTimestamps,
+		NoPrefix:   logsNoPrefix,
+	})
+	if err != nil && interrupt.IsInterrupted(cmd.Context()) {
+		// Interrupted by the user
+		return nil
+	}
+	return clierror.WithCode(clierror.Docker, err)
+}
+
+// defaultFollowTail is the number of lines logs --follow shows from the end
+// of each service's logs before streaming new ones, unless --tail is set
+const defaultFollowTail = 100
+
+// logsTailLines checks the value of logs' --tail flag, a number of lines or
+// "all", and returns the value to pass to compose: all lines by default, or
+// the last defaultFollowTail when following
+func logsTailLines(tail string, follow bool) (string, error) {
+	if tail == "" {
+		if follow {
+			return strconv.Itoa(defaultFollowTail), nil
+		}
+		return "", nil
+	}
+	if tail == "all" {
+		return tail, nil
+	}
+	if lines, err := strconv.Atoi(tail); err != nil || lines < 0 {
+		return "", clierror.UsageError("invalid --tail %q (use a number of lines or all)", tail)
+	}
+	return tail, nil
+}
+
+// validateLogsWindow checks that logs' --since and --until select a time
+// window logs can fall in
+func validateLogsWindow(since, until time.Duration) error {
+	if since < 0 || until < 0 {
+		return clierror.UsageError("--since and --until must not be negative")
+	}
+	if since > 0 && until >= since {
+		return clierror.UsageError("--until %s is not more recent than --since %s: no logs can match", until, since)
+	}
+	return nil
+}
+
+func runDockerExec(cmd *cobra.Command, args []string) error {
+	projectDir, err := getProjectDir()
+	if err != nil {
+		return err
+	}
+	if _, err := applyEnvFiles(cmd, projectDir); err != nil {
+		return err
+	}
+
+	if err := checkDockerPrerequisites(cmd); err != nil {
+		return err
+	}
+
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	applyProfiles(cmd, projectDir, composeFile)
+
+	infos, err := docker.ListServices(cmd.Context(), projectDir, composeFile)
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+
+	var service string
+	var command []string
+	if len(args) > 0 {
+		service, command = args[0], args[1:]
+	} else {
+		service, err = pickRunningService(cmd.Context(), infos)
+		if err != nil {
+			return err
+		}
+	}
+	if err := docker.CheckRunning(infos, service); err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+
+	if len(command) == 0 {
+		shell, err := docker.DefaultShell(cmd.Context(), projectDir, composeFile, service)
+		if err != nil {
+			return clierror.WithCode(clierror.Docker, err)
+		}
+		command = []string{shell}
+	}
+
+	// The command gets Ctrl-C from the terminal itself
+	release := interrupt.Delegate()
+	defer release()
+	opts := docker.ExecOptions{TTY: tty.IsStdinTerminal() && tty.IsStdoutTerminal()}
+	err = docker.Exec(cmd.Context(), projectDir, composeFile, service, command, opts)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return clierror.WithCode(exitErr.ExitCode(), fmt.Errorf("%s exited with status %d in %s", command[0], exitErr.ExitCode(), service))
+	}
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, fmt.Errorf("failed to exec into %s: %w", service, err))
+	}
+	return nil
+}
+
+// dockerCpResult is the JSON result of docker cp
+type dockerCpResult struct {
+	output.Envelope
+	Service     string `json:"service"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+func runDockerCp(cmd *cobra.Command, args []string) error {
+	source, destination, service, err := docker.CopyPaths(args[0], args[1])
+	if err != nil {
+		return clierror.WithCode(clierror.Usage, err)
+	}
+	if err := docker.CheckLocalPath(source, true); err != nil {
+		return clierror.WithCode(clierror.Usage, err)
+	}
+	if err := docker.CheckLocalPath(destination, false); err != nil {
+		return clierror.WithCode(clierror.Usage, err)
+	}
+	// The archive and the JSON result would both be written to stdout
+	if destination.Path == "-" && output.IsJSON() {
+		return clierror.UsageError("cannot copy to - with --output json")
+	}
+
+	projectDir, err := getProjectDir()
+	if err != nil {
+		return err
+	}
+	if _, err := applyEnvFiles(cmd, projectDir); err != nil {
+		return err
+	}
+
+	if err := checkDockerPrerequisites(cmd); err != nil {
+		return err
+	}
+
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	applyProfiles(cmd, projectDir, composeFile)
+
+	infos, err := docker.ListServices(cmd.Context(), projectDir, composeFile)
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+	if err := docker.CheckRunning(infos, service); err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+
+	if output.IsJSON() {
+		output.RedirectProse()
+	}
+	err = docker.Copy(cmd.Context(), projectDir, composeFile, source, destination)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return clierror.WithCode(exitErr.ExitCode(), fmt.Errorf("failed to copy %s to %s: docker compose cp exited with status %d", source, destination, exitErr.ExitCode()))
+	}
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, fmt.Errorf("failed to copy %s to %s: %w", source, destination, err))
+	}
+
+	if output.IsJSON() {
+		return output.PrintJSON(dockerCpResult{
+			Envelope:    output.NewEnvelope("docker.cp"),
+			Service:     service,
+			Source:      source.String(),
+			Destination: destination.String(),
+		})
+	}
+	// Keep the archive written to stdout intact
+	if destination.Path != "-" {
+		fmt.Printf("✅ Copied %s to %s\n", source, destination)
+	}
+	return nil
+}
+
+// pickRunningService asks which running service docker exec should use
+func pickRunningService(ctx context.Context, infos []docker.ServiceInfo) (string, error) {
+	running := docker.RunningServices(infos)
+	if len(running) == 0 {
+		return "", clierror.WithCode(clierror.Docker, errors.New("no services are running, start them with: acontext docker up -d"))
+	}
+	if !tty.CanPrompt(ctx) {
+		return "", clierror.UsageError("a service is required when %s (running services: %s)", tty.NoPromptReason(ctx), strings.Join(running, ", "))
+	}
+
+	var service string
+	prompt := &survey.Select{
+		Message: "Select a service:",
+		Options: running,
+	}
+	if err := survey.AskOne(prompt, &service); err != nil {
+		return "", fmt.Errorf("failed to select a service: %w", err)
+	}
+	return service, nil
+}
+
+func runDockerConfig(cmd *cobra.Command, args []string) error {
+	projectDir, err := getProjectDir()
+	if err != nil {
+		return err
+	}
+	if err := applyRetries(cmd); err != nil {
+		return err
+	}
+	envFiles, err := applyEnvFiles(cmd, projectDir)
+	if err != nil {
+		return err
+	}
+
+	if err := checkDockerPrerequisites(cmd); err != nil {
+		return err
+	}
+
+	// Keep prose out of the printed configuration
+	output.RedirectProse()
+
+	composeFile, cleanup, err := resolveComposeFile(cmd.Context(), projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	profiles := applyProfiles(cmd, projectDir, composeFile)
+	files := []composeFileInfo{{Path: composeFile, Source: composeFileSource(projectDir, composeFile)}}
+
+	services, err := docker.ListServiceNames(cmd.Context(), projectDir, composeFile)
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+
+	if output.IsJSON() {
+		result := dockerConfigResult{
+			Envelope: output.NewEnvelope("docker.config"),
+			Files:    files,
+			EnvFiles: envFiles,
+			Profiles: profiles,
+			Services: services,
+		}
+		if !configServices {
+			config, err := docker.Config(cmd.Context(), projectDir, composeFile, true)
+			if err != nil {
+				return clierror.WithCode(clierror.Docker, err)
+			}
+			result.Config = config
+		}
+		return output.PrintJSON(result)
+	}
+
+	fmt.Println("📄 Compose files, in merge order:")
+	for i, file := range files {
+		source := "project file"
+		if file.Source == "embedded" {
+			source = "embedded default, temporary copy"
+		}
+		fmt.Printf("   %d. %s (%s)\n", i+1, file.Path, source)
+	}
+	if len(envFiles) > 0 {
+		fmt.Printf("   Env files: %s\n", strings.Join(envFiles, ", "))
+	}
+	if len(profiles) > 0 {
+		fmt.Printf("   Profiles: %s\n", strings.Join(profiles, ", "))
+	}
+	fmt.Println()
+
+	if configServices {
+		for _, service := range services {
+			fmt.Fprintln(output.Stdout(), service)
+		}
+		return nil
+	}
+	config, err := docker.Config(cmd.Context(), projectDir, composeFile, false)
+	if err != nil {
+		return clierror.WithCode(clierror.Docker, err)
+	}
+	_, err = output.Stdout().Write(config)
+	return err
+}
+
+// composeFileInfo describes a compose file selected for the project
+type composeFileInfo struct {
+	Path   string `json:"path"`
+	Source string `json:"source"` // "project" or "embedded"
+}
+
+// dockerConfigResult is the JSON result of docker config
+type dockerConfigResult struct {
+	output.Envelope
+	Files    []composeFileInfo `json:"files"`
+	EnvFiles []string          `json:"env_files,omitempty"`
+	Profiles []string          `json:"profiles,omitempty"`
+	Services []string          `json:"services"`
+	Config   json.RawMessage   `json:"config,omitempty"`
+}
+
+func runDockerEnv(cmd *cobra.Command, args []string) error {
+	if envShowValues && !envDiff {
+		return clierror.UsageError("--show-values needs --diff")
+	}
+	projectDir, err := getProjectDir()
+	if err != nil {
+		return err
+	}
+	envFiles, err := applyEnvFiles(cmd, projectDir)
+	if err != nil {
+		return err
+	}
+	if envDiff {
+		return runDockerEnvDiff(cmd.Context(), projectDir, envFiles)
+	}
+
+	envFile := filepath.Join(projectDir, ".env")
+	_, statErr := os.Stat(envFile)
+	envFileExists := statErr == nil
+
+	// Writing is the default when nothing is printed
+	printing := envExport || output.IsJSON()
+	write := envWriteDotenv || !printing
+	if write && envFileExists && !envForce {
+		return clierror.UsageError(".env file already exists at %s; use --force to overwrite it", envFile)
+	}
+
+	// Keep prompts and prose out of the exported lines
+	if envExport {
+		output.RedirectProse()
+	}
+
+	var env map[string]string
+	var warnings []string
+	if envFileExists && !write {
+		env, err = docker.ParseEnvFile(envFile)
+		if err != nil {
+			return err
+		}
+	} else {
+		fmt.Println("🔐 Generating .env file...")
+		fmt.Println("   Please provide the following configuration:")
+		envConfig, err := promptEnvConfig(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to get environment configuration: %w", err)
+		}
+		env = docker.EnvValues(envConfig)
+
+		if write {
+			if err := docker.GenerateEnvFile(envFile, envConfig); err != nil {
+				return fmt.Errorf("failed to generate .env file: %w", err)
+			}
+			fmt.Printf("✅ Generated .env file at %s\n", envFile)
+		}
+		warnings, err = envFileConflicts(envFile, env, envFiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	logging.Redact(docker.SecretValues(env)...)
+
+	if output.IsJSON() {
+		return output.PrintJSON(dockerEnvResult{
+			Envelope: output.NewEnvelope("docker.env"),
+			Path:     envFile,
+			Written:  write,
+			Env:      env,
+			Warnings: warnings,
+		})
+	}
+	for _, warning := range warnings {
+		fmt.Printf("⚠️  Warning: %s\n", warning)
+	}
+	if envExport {
+		for _, line := range docker.ExportLines(env) {
+			fmt.Fprintln(output.Stdout(), line)
+		}
+	}
+	return nil
+}
+
+// dockerEnvResult is the JSON result of docker env
+type dockerEnvResult struct {
+	output.Envelope
+	Path     string            `json:"path"`
+	Written  bool              `json:"written"`
+	Env      map[string]string `json:"env"`
+	Warnings []string          `json:"warnings,omitempty"`
+}
+
+// dockerEnvDiffResult is the JSON result of docker env --diff
+type dockerEnvDiffResult struct {
+	output.Envelope
+	Path        string             `json:"path"`
+	ComposeFile composeFileInfo    `json:"compose_file"`
+	EnvFiles    []string           `json:"env_files"`
+	InSync      bool               `json:"in_sync"`
+	Differences []docker.EnvChange `json:"differences"`
+}
+
+// runDockerEnvDiff compares the .env file of projectDir with the
+// environment compose resolves from envFiles and the CLI's environment for
+// the compose file up runs
+func runDockerEnvDiff(ctx context.Context, projectDir string, envFiles []string) error {
+	envFile := filepath.Join(projectDir, ".env")
+	dotenv, err := docker.ParseEnvFile(envFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		dotenv = map[string]string{}
+	} else if err != nil {
+		return err
+	}
+	// Compose reads .env when no env files are passed to it
+	files := envFiles
+	if len(files) == 0 {
+		files = []string{envFile}
+	}
+	composeFile, cleanup, err := resolveComposeFile(ctx, projectDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	content, err := os.ReadFile(composeFile)
+	if err != nil {
+		return fmt.Errorf("failed to read compose file: %w", err)
+	}
+	variables := docker.ComposeVariables(string(content))
+	resolved, err := docker.ResolveComposeEnv(variables, files, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	resolvedEnv := make(map[string]string, len(resolved))
+	for key, value := range resolved {
+		resolvedEnv[key] = value.Value
+	}
+	logging.Redact(docker.SecretValues(dotenv)...)
+	logging.Redact(docker.SecretValues(resolvedEnv)...)
+
+	changes := docker.DiffEnv(dotenv, resolved, envShowValues)
+	var diffErr error
+	if len(changes) > 0 {
+		diffErr = fmt.Errorf("%d variable(s) of the resolved compose environment differ from %s", len(changes), relativeToCwd(envFile))
+	}
+
+	if output.IsJSON() {
+		if err := output.PrintJSON(dockerEnvDiffResult{
+			Envelope:    output.NewEnvelope("docker.env.diff"),
+			Path:        envFile,
+			ComposeFile: composeFileInfo{Path: composeFile, Source: composeFileSource(projectDir, composeFile)},
+			EnvFiles:    files,
+			InSync:      diffErr == nil,
+			Differences: changes,
+		}); err != nil {
+			return err
+		}
+		if diffErr != nil {
+			return output.Reported(diffErr)
+		}
+		return nil
+	}
+
+	if diffErr == nil {
+		fmt.Printf("✅ %s matches the resolved compose environment\n", relativeToCwd(envFile))
+		return nil
+	}
+	fmt.Printf("🔍 Differences between %s and the resolved compose environment:\n", relativeToCwd(envFile))
+	for _, change := range changes {
+		fmt.Printf("   %s\n", formatEnvChange(change))
+	}
+	if !slices.Contains(files, envFile) {
+		fmt.Println("ℹ️  docker compose does not read .env when env files are passed to it")
+	}
+	return diffErr
+}
+
+// formatEnvChange describes a change docker env --diff found, as + KEY for an
+// added key, - KEY for a removed one and ~ KEY for a changed one, with the
+// values unless they are masked and where the resolved value comes from
+func formatEnvChange(change docker.EnvChange) string {
+	value := func(v *string) string {
+		if v == nil {
+			return "***"
+		}
+		return *v
+	}
+	source := "the environment"
+	if change.Source != docker.EnvSourceShell {
+		source = relativeToCwd(change.Source)
+	}
+
+	switch change.Change {
+	case docker.EnvAdded:
+		if change.Resolved == nil {
+			return fmt.Sprintf("+ %s (from %s)", change.Key, source)
+		}
+		return fmt.Sprintf("+ %s=%s (from %s)", change.Key, *change.Resolved, source)
+	case docker.EnvRemoved:
+		if change.DotEnv == nil {
+			return "- " + change.Key
+		}
+		return fmt.Sprintf("- %s=%s", change.Key, *change.DotEnv)
+	}
+	return fmt.Sprintf("~ %s: %s → %s (from %s)", change.Key, value(change.DotEnv), value(change.Resolved), source)
+}
+
+// envFileConflicts reports the keys of the generated .env at envFile that
+// the env files passed to compose also set, and which value compose uses.
+// Later env files override earlier ones, and compose only reads .env when it
+// is one of them.
+func envFileConflicts(envFile string, env map[string]string, envFiles []string) ([]string, error) {
+	dotenv := slices.Index(envFiles, envFile)
+	var warnings []string
+	for i, file := range envFiles {
+		if i == dotenv {
+			continue
+		}
+		other, err := docker.ParseEnvFile(file)
+		if err != nil {
+			return nil, err
+		}
+		shared := docker.SharedKeys(env, other)
+		if len(shared) == 0 {
+			continue
+		}
+
+		used := file + ", since docker compose does not read .env when env files are passed to it"
+		if dotenv >= 0 && i < dotenv {
+			used = "the generated .env, which is passed after it"
+		} else if dotenv >= 0 {
+			used = file + ", which is passed after .env"
+		}
+		warnings = append(warnings, fmt.Sprintf("%s also sets %s; docker compose uses the values from %s", file, strings.Join(shared, ", "), used))
+	}
+	return warnings, nil
+}
+
+// applyProfiles activates the compose profiles from --profile, or else the
+// docker.profiles setting, on every compose command run with cmd's context,
+// and returns them. Profiles the compose file does not declare are reported
+// as warnings, since they match no services.
+func applyProfiles(cmd *cobra.Command, projectDir, composeFile string) []string {
+	profiles := dockerProfiles
+	if !cmd.Flags().Changed("profile") {
+		value, _ := loadUserConfig().Get("docker.profiles")
+		profiles = docker.ParseProfiles(value)
+	}
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	if available, err := docker.ListProfiles(cmd.Context(), projectDir, composeFile); err == nil {
+		declared := "the compose file declares no profiles"
+		if len(available) > 0 {
+			declared = "available: " + strings.Join(available, ", ")
+		}
+		for _, profile := range docker.UnknownProfiles(profiles, available) {
+			fmt.Printf("⚠️  Warning: profile %q is not declared in the compose file and matches no services (%s)\n", profile, declared)
+		}
+	}
+
+	cmd.SetContext(docker.WithProfiles(cmd.Context(), profiles))
+	return profiles
+}
+
+// applyEnvFiles passes the env files from --env-file, or else the
+// docker.env_files setting, to every compose command run with cmd's context,
+// and returns them. Relative paths are resolved against projectDir, and each
+// file must exist and be readable.
+func applyEnvFiles(cmd *cobra.Command, projectDir string) ([]string, error) {
+	files, source := dockerEnvFiles, "--env-file"
+	if !cmd.Flags().Changed("env-file") {
+		value, _ := loadUserConfig().Get("docker.env_files")
+		files, source = docker.ParseEnvFiles(value), "docker.env_files"
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	resolved := make([]string, 0, len(files))
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(projectDir, file)
+		}
+		resolved = append(resolved, file)
+	}
+	if err := docker.CheckEnvFiles(resolved); err != nil {
+		return nil, clierror.UsageError("%s: %v", source, err)
+	}
+
+	cmd.SetContext(docker.WithEnvFiles(cmd.Context(), resolved))
+	return resolved, nil
+}
+
+// applyRetries makes every docker command run with cmd's context retry
+// transient failures as --retries and --retry-delay ask
+func applyRetries(cmd *cobra.Command) error {
+	if dockerRetries < 0 {
+		return clierror.UsageError("--retries must not be negative, got %d", dockerRetries)
+	}
+	if dockerRetryDelay < 0 {
+		return clierror.UsageError("--retry-delay must not be negative, got %s", dockerRetryDelay)
+	}
+	if dockerRetries == 0 {
+		return nil
+	}
+	telemetry.RecordFlag("retries", strconv.Itoa(dockerRetries))
+	cmd.SetContext(docker.WithRetry(cmd.Context(), docker.RetryPolicy{Retries: dockerRetries, Delay: dockerRetryDelay}))
+	return nil
+}
+
+// resolveComposeFile returns the compose file every docker command runs: the
+// project's docker-compose.yaml if it exists, or otherwise a temporary copy of
+// the embedded one, along with a cleanup function
+func resolveComposeFile(ctx context.Context, projectDir string) (string, func(), error) {
+	logger := logging.FromContext(ctx)
+	composeFile := filepath.Join(projectDir, "docker-compose.yaml")
+	if _, err := os.Stat(composeFile); err == nil {
+		logger.Debug("using project compose file", "path", composeFile)
+		return composeFile, func() {}, nil
+	}
+
+	tmpFile, err := docker.CreateTempDockerCompose(projectDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary docker-compose file: %w", err)
+	}
+	logger.Debug("using embedded compose file", "path", tmpFile)
+	return tmpFile, func() {
+		_ = os.Remove(tmpFile)
+	}, nil
+}
+
+// composeFileSource reports whether composeFile, as returned by
+// resolveComposeFile, is the project's own compose file or the embedded one
+func composeFileSource(projectDir, composeFile string) string {
+	if composeFile == filepath.Join(projectDir, "docker-compose.yaml") {
+		return "project"
+	}
+	return "embedded"
+}
+
+// prerequisiteCheck runs a prerequisite check once and caches its result for
+// the rest of the invocation
+type prerequisiteCheck struct {
+	once    sync.Once
+	check   func(ctx context.Context) (docker.Compose, error)
+	compose docker.Compose
+	err     error
+}
+
+func (c *prerequisiteCheck) run(ctx context.Context) (docker.Compose, error) {
+	c.once.Do(func() {
+		c.compose, c.err = c.check(ctx)
+	})
+	return c.compose, c.err
+}
+
+var (
+	dockerPrerequisites = &prerequisiteCheck{check: docker.CheckPrerequisites}
+	composeDetection    = &prerequisiteCheck{check: docker.FindCompose}
+)
+
+// composeV1Flags are the flags whose compose counterparts docker-compose v1
+// lacks, refused with it rather than failing in compose
+var composeV1Flags = []string{"pull", "wait", "since", "until"}
+
+// checkDockerPrerequisites verifies that Docker and Docker Compose are
+// installed and the daemon is running, returning an error with remediation
+// steps that exits with clierror.MissingDependency otherwise, and runs every
+// compose command with cmd's context with the Compose found
+func checkDockerPrerequisites(cmd *cobra.Command) error {
+	spinner := progress.StartTransient(cmd.Context(), "🔍 Checking Docker...")
+	compose, err := dockerPrerequisites.run(cmd.Context())
+	spinner.Stop()
+	if err != nil {
+		return prerequisiteError(err)
+	}
+	return applyCompose(cmd, compose)
+}
+
+// detectCompose runs every compose command with cmd's context with the
+// Compose found, like checkDockerPrerequisites but without checking the
+// daemon, for commands that report its errors themselves
+func detectCompose(cmd *cobra.Command) error {
+	compose, err := composeDetection.run(cmd.Context())
+	if err != nil {
+		return prerequisiteError(err)
+	}
+	return applyCompose(cmd, compose)
+}
+
+// applyCompose runs every compose command with cmd's context with compose.
+// docker-compose v1 is refused unless --allow-compose-v1 is set, and then
+// it is warned about and the flags it lacks are refused.
+func applyCompose(cmd *cobra.Command, compose docker.Compose) error {
+	if !compose.Legacy {
+		cmd.SetContext(docker.WithCompose(cmd.Context(), compose))
+		return nil
+	}
+	if !allowComposeV1 {
+		return prerequisiteError(docker.ErrComposeV1)
+	}
+	for _, name := range composeV1Flags {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			return clierror.UsageError("--%s is not supported with docker-compose v1, upgrade to Docker Compose v2: %s", name, docker.ComposeUpgradeURL)
+		}
+	}
+	version := compose.Version
+	if version == "" {
+		version = "unknown version"
+	}
+	fmt.Printf("⚠️  Warning: using the deprecated docker-compose v1 (%s), some commands may not work as expected.\n", version)
+	fmt.Printf("   Upgrade to Docker Compose v2: %s\n", docker.ComposeUpgradeURL)
+	cmd.SetContext(docker.WithCompose(cmd.Context(), compose))
+	return nil
+}
+
+// prerequisiteError adds remediation steps to a docker.CheckPrerequisites or
+// docker.FindCompose error as its hint
+func prerequisiteError(err error) error {
+	if err == nil {
+		return nil
+	}
+	// The command was cancelled or timed out before the checks finished
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var remediation string
+	switch {
+	case errors.Is(err, docker.ErrDockerNotInstalled):
+		remediation = "Install Docker from https://docs.docker.com/get-docker/ and make sure `docker` is on your PATH."
+	case errors.Is(err, docker.ErrComposeNotAvailable):
+		remediation = "Install the Docker Compose v2 plugin (https://docs.docker.com/compose/install/) or update Docker Desktop."
+	case errors.Is(err, docker.ErrComposeV1):
+		remediation = "Install the Docker Compose v2 plugin or update Docker Desktop, see " + docker.ComposeUpgradeURL + ".\n" +
+			"To use docker-compose v1 anyway, whose flags differ from v2, pass --allow-compose-v1."
+	case errors.Is(err, docker.ErrDaemonNotRunning):
+		remediation = "Start Docker Desktop, or on Linux run `sudo systemctl start docker`, then try again.\n" +
+			"Run `docker info` to see why the daemon is not reachable."
+	}
+	if remediation != "" {
+		err = clierror.WithHint(err, remediation)
+	}
+	return clierror.WithCode(clierror.MissingDependency, err)
+}
+
+// getProjectDir gets the current project directory
+// It always returns the current working directory, allowing commands to be run from anywhere
+func getProjectDir() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return cwd, nil
+}
+
+// redactAnswer keeps a secret answer out of the --log-file transcript. It
+// runs as a validator, so before the answer is printed.
+func redactAnswer(answer interface{}) error {
+	if value, ok := answer.(string); ok {
+		logging.Redact(value)
+	}
+	return nil
+}
+
+// promptEnvConfig prompts user for required environment configuration. It
+// has no defaults to fall back to, so it fails when prompts are disabled.
+func promptEnvConfig(ctx context.Context) (*docker.EnvConfig, error) {
+	if !tty.CanPrompt(ctx) {
+		return nil, clierror.UsageError("cannot prompt for the .env configuration when %s: generate .env with acontext docker env in a terminal first", tty.NoPromptReason(ctx))
+	}
 	fmt.Println()
 
 	// Prompt for LLM SDK
@@ -273,9 +2377,10 @@ func promptEnvConfig() (*docker.EnvConfig, error) {
 		Message: "2. Enter LLM API Key:",
 		Help:    fmt.Sprintf("Your %s API key (e.g., sk-xxx for OpenAI, sk-ant-xxx for Anthropic)", llmSDK),
 	}
-	if err := survey.AskOne(llmAPIKeyPrompt, &llmAPIKey, survey.WithValidator(survey.Required)); err != nil {
+	if err := survey.AskOne(llmAPIKeyPrompt, &llmAPIKey, survey.WithValidator(survey.Required), survey.WithValidator(redactAnswer)); err != nil {
 		return nil, fmt.Errorf("failed to get LLM API key: %w", err)
 	}
+	logging.Redact(llmAPIKey)
 
 	// Prompt for LLM Base URL (with default)
 	var llmBaseURL string
@@ -302,14 +2407,14 @@ func promptEnvConfig() (*docker.EnvConfig, error) {
 		Message: "4. Pass a string to build Acontext token:",
 		Default: "your-root-api-bearer-token",
 		Help:    "'sk-ac-' prefix will be added automatically. Enter token part only (xxxx).",
-	}, &rootAPIBearerToken); err != nil {
+	}, &rootAPIBearerToken, survey.WithValidator(redactAnswer)); err != nil {
 		return nil, fmt.Errorf("failed to get Root API Bearer Token: %w", err)
 	}
+	logging.Redact(rootAPIBearerToken)
 
 	// Build complete API key and display it
 	completeAPIKey := fmt.Sprintf("sk-ac-%s", rootAPIBearerToken)
-	// Use ANSI color codes: \033[1m = bold, \033[93m = bright yellow, \033[0m = reset
-	fmt.Printf("  🔑 \033[1m\033[93mACONTEXT_API_KEY=\"%s\"\033[0m\n", completeAPIKey)
+	fmt.Printf("  🔑 %s\n", color.Bold(color.Yellow(fmt.Sprintf("ACONTEXT_API_KEY=\"%s\"", completeAPIKey))))
 
 	// Prompt for Core Config YAML File (optional)
 	var coreConfigYAMLFile string
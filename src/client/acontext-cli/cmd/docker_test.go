@@ -0,0 +1,501 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/docker"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/telemetry"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/tty"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrerequisiteError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantHint string
+	}{
+		{name: "docker not installed", err: docker.ErrDockerNotInstalled, wantHint: "https://docs.docker.com/get-docker/"},
+		{name: "compose not available", err: docker.ErrComposeNotAvailable, wantHint: "Docker Compose v2 plugin"},
+		{name: "compose v1 only", err: docker.ErrComposeV1, wantHint: "--allow-compose-v1"},
+		{name: "daemon not running", err: fmt.Errorf("%w (no response)", docker.ErrDaemonNotRunning), wantHint: "Start Docker Desktop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := prerequisiteError(tt.err)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Contains(t, clierror.Hint(err), tt.wantHint)
+			assert.Equal(t, clierror.MissingDependency, clierror.Code(err))
+		})
+	}
+
+	assert.NoError(t, prerequisiteError(nil))
+
+	err := prerequisiteError(context.DeadlineExceeded)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, clierror.Failure, clierror.Code(err))
+}
+
+func TestPrerequisiteCheckCachesResult(t *testing.T) {
+	calls := 0
+	check := &prerequisiteCheck{check: func(context.Context) (docker.Compose, error) {
+		calls++
+		return docker.Compose{}, errors.New("daemon down")
+	}}
+
+	for range 3 {
+		_, err := check.run(context.Background())
+		assert.EqualError(t, err, "daemon down")
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestApplyCompose(t *testing.T) {
+	v1 := docker.Compose{Command: []string{"docker-compose"}, Version: "1.29.2", Legacy: true}
+	tests := []struct {
+		name     string
+		compose  docker.Compose
+		allow    bool
+		args     []string
+		wantErr  string
+		wantCode int
+	}{
+		{name: "v2", compose: docker.Compose{Command: []string{"docker", "compose"}, Version: "2.24.5"}, args: []string{"--since", "1h"}},
+		{name: "v1 refused", compose: v1, wantErr: "only the deprecated docker-compose v1 is available", wantCode: clierror.MissingDependency},
+		{name: "v1 allowed", compose: v1, allow: true, args: []string{"--tail", "10"}},
+		{name: "v1 lacks flag", compose: v1, allow: true, args: []string{"--since", "1h"}, wantErr: "--since is not supported with docker-compose v1", wantCode: clierror.Usage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowComposeV1 = tt.allow
+			t.Cleanup(func() { allowComposeV1 = false })
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			cmd.Flags().Duration("since", 0, "")
+			cmd.Flags().String("tail", "", "")
+			require.NoError(t, cmd.ParseFlags(tt.args))
+
+			err := applyCompose(cmd, tt.compose)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.compose, docker.ComposeFromContext(cmd.Context()))
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantCode, clierror.Code(err))
+		})
+	}
+}
+
+func TestDockerShortcuts(t *testing.T) {
+	targets := map[string]*cobra.Command{"up": dockerUpCmd, "down": dockerDownCmd, "logs": dockerLogsCmd, "status": dockerStatusCmd}
+	require.Len(t, DockerShortcuts, len(targets))
+
+	for _, shortcut := range DockerShortcuts {
+		t.Run(shortcut.Name(), func(t *testing.T) {
+			target, ok := targets[shortcut.Name()]
+			require.True(t, ok)
+			assert.Equal(t, "docker."+target.Name(), shortcut.Annotations[telemetry.CommandPathAnnotation], "telemetry records the canonical path")
+			assert.Equal(t, target.Annotations[telemetry.LongRunningAnnotation], shortcut.Annotations[telemetry.LongRunningAnnotation])
+			target.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+				assert.Same(t, flag, shortcut.Flags().Lookup(flag.Name), "--%s is shared", flag.Name)
+			})
+			assert.NotNil(t, shortcut.Flags().Lookup("allow-compose-v1"))
+		})
+	}
+}
+
+func TestApplyRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		retries    int
+		delay      time.Duration
+		wantPolicy docker.RetryPolicy
+		wantErr    string
+	}{
+		{name: "disabled", delay: docker.DefaultRetryDelay},
+		{name: "enabled", retries: 3, delay: time.Second, wantPolicy: docker.RetryPolicy{Retries: 3, Delay: time.Second}},
+		{name: "negative retries", retries: -1, delay: time.Second, wantErr: "--retries must not be negative, got -1"},
+		{name: "negative delay", retries: 1, delay: -time.Second, wantErr: "--retry-delay must not be negative, got -1s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dockerRetries, dockerRetryDelay = tt.retries, tt.delay
+			t.Cleanup(func() { dockerRetries, dockerRetryDelay = 0, docker.DefaultRetryDelay })
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+
+			err := applyRetries(cmd)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Equal(t, clierror.Usage, clierror.Code(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPolicy, docker.RetryFromContext(cmd.Context()))
+		})
+	}
+}
+
+func TestApplyEnvFiles(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".env"), []byte("A=1\n"), 0644))
+	absolute := filepath.Join(t.TempDir(), "shared.env")
+	require.NoError(t, os.WriteFile(absolute, []byte("B=2\n"), 0644))
+
+	newCmd := func(t *testing.T, files ...string) *cobra.Command {
+		t.Cleanup(func() { dockerEnvFiles = nil })
+		cmd := &cobra.Command{}
+		cmd.Flags().StringArrayVar(&dockerEnvFiles, "env-file", nil, "")
+		for _, file := range files {
+			require.NoError(t, cmd.Flags().Set("env-file", file))
+		}
+		cmd.SetContext(context.Background())
+		return cmd
+	}
+
+	t.Run("relative and absolute", func(t *testing.T) {
+		cmd := newCmd(t, ".env", absolute)
+		files, err := applyEnvFiles(cmd, projectDir)
+		require.NoError(t, err)
+		want := []string{filepath.Join(projectDir, ".env"), absolute}
+		assert.Equal(t, want, files)
+		assert.Equal(t, want, docker.EnvFilesFromContext(cmd.Context()))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := applyEnvFiles(newCmd(t, ".env.local"), projectDir)
+		assert.EqualError(t, err, "--env-file: env file "+filepath.Join(projectDir, ".env.local")+" does not exist")
+		assert.Equal(t, clierror.Usage, clierror.Code(err))
+	})
+}
+
+func TestEnvFileConflicts(t *testing.T) {
+	dir := t.TempDir()
+	dotenv := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	base := filepath.Join(dir, "base.env")
+	require.NoError(t, os.WriteFile(local, []byte("OPENAI_API_KEY=local\nOTHER=1\nDATABASE_USER=local\n"), 0644))
+	require.NoError(t, os.WriteFile(base, []byte("UNRELATED=1\n"), 0644))
+	env := map[string]string{"DATABASE_USER": "acontext", "OPENAI_API_KEY": "sk-test"}
+
+	tests := []struct {
+		name     string
+		envFiles []string
+		want     []string
+	}{
+		{name: "no env files"},
+		{name: "no shared keys", envFiles: []string{base}},
+		{
+			name:     "without .env",
+			envFiles: []string{base, local},
+			want:     []string{local + " also sets DATABASE_USER, OPENAI_API_KEY; docker compose uses the values from " + local + ", since docker compose does not read .env when env files are passed to it"},
+		},
+		{
+			name:     "after .env",
+			envFiles: []string{dotenv, local},
+			want:     []string{local + " also sets DATABASE_USER, OPENAI_API_KEY; docker compose uses the values from " + local + ", which is passed after .env"},
+		},
+		{
+			name:     "before .env",
+			envFiles: []string{local, dotenv},
+			want:     []string{local + " also sets DATABASE_USER, OPENAI_API_KEY; docker compose uses the values from the generated .env, which is passed after it"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := envFileConflicts(dotenv, env, tt.envFiles)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, warnings)
+		})
+	}
+}
+
+func TestDockerEnvDiffComposeFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Setenv("ACONTEXT_DIFF_TEST_REGION", "eu-west-1")
+	require.NoError(t, os.WriteFile(".env", []byte("LLM_SDK=openai\n"), 0600))
+	ctx := context.Background()
+
+	assert.NoError(t, runDockerEnvDiff(ctx, cwd, nil), "the embedded compose file does not use the variable")
+
+	require.NoError(t, os.WriteFile("docker-compose.yaml", []byte("services:\n  web:\n    image: nginx\n    environment:\n      REGION: ${ACONTEXT_DIFF_TEST_REGION}\n"), 0644))
+	err = runDockerEnvDiff(ctx, cwd, nil)
+	assert.EqualError(t, err, "1 variable(s) of the resolved compose environment differ from .env", "the project compose file, which up runs, uses it")
+	assert.Equal(t, clierror.Failure, clierror.Code(err))
+}
+
+func TestFormatEnvChange(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	ci := filepath.Join(cwd, ".env.ci")
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name   string
+		change docker.EnvChange
+		want   string
+	}{
+		{
+			name:   "added, masked",
+			change: docker.EnvChange{Key: "DATABASE_USER", Change: docker.EnvAdded, Source: docker.EnvSourceShell},
+			want:   "+ DATABASE_USER (from the environment)",
+		},
+		{
+			name:   "added",
+			change: docker.EnvChange{Key: "DATABASE_USER", Change: docker.EnvAdded, Source: ci, Resolved: str("ci")},
+			want:   "+ DATABASE_USER=ci (from .env.ci)",
+		},
+		{
+			name:   "removed, masked",
+			change: docker.EnvChange{Key: "DEBUG", Change: docker.EnvRemoved},
+			want:   "- DEBUG",
+		},
+		{
+			name:   "removed",
+			change: docker.EnvChange{Key: "DEBUG", Change: docker.EnvRemoved, DotEnv: str("1")},
+			want:   "- DEBUG=1",
+		},
+		{
+			name:   "changed, masked",
+			change: docker.EnvChange{Key: "LLM_API_KEY", Change: docker.EnvChanged, Source: ci},
+			want:   "~ LLM_API_KEY: *** → *** (from .env.ci)",
+		},
+		{
+			name:   "changed",
+			change: docker.EnvChange{Key: "LLM_SDK", Change: docker.EnvChanged, Source: docker.EnvSourceShell, DotEnv: str("openai"), Resolved: str("anthropic")},
+			want:   "~ LLM_SDK: openai → anthropic (from the environment)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatEnvChange(tt.change))
+		})
+	}
+}
+
+func TestValidatePullPolicy(t *testing.T) {
+	for _, policy := range []string{"", "always", "missing", "never"} {
+		assert.NoError(t, validatePullPolicy(policy), policy)
+	}
+
+	err := validatePullPolicy("sometimes")
+	assert.EqualError(t, err, `invalid --pull "sometimes" (use always, missing, never)`)
+	assert.Equal(t, clierror.Usage, clierror.Code(err))
+}
+
+func TestLogsTailLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		tail     string
+		follow   bool
+		expected string
+		wantErr  string
+	}{
+		{name: "all by default"},
+		{name: "last lines when following", follow: true, expected: "100"},
+		{name: "lines", tail: "20", expected: "20"},
+		{name: "only new lines when following", tail: "0", follow: true, expected: "0"},
+		{name: "all when following", tail: "all", follow: true, expected: "all"},
+		{name: "not a number", tail: "ten", wantErr: `invalid --tail "ten" (use a number of lines or all)`},
+		{name: "negative", tail: "-1", wantErr: `invalid --tail "-1" (use a number of lines or all)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tail, err := logsTailLines(tt.tail, tt.follow)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Equal(t, clierror.Usage, clierror.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tail)
+		})
+	}
+}
+
+func TestValidateLogsWindow(t *testing.T) {
+	assert.NoError(t, validateLogsWindow(0, 0))
+	assert.NoError(t, validateLogsWindow(time.Hour, 30*time.Minute))
+	assert.NoError(t, validateLogsWindow(0, 30*time.Minute), "--until alone shows everything older")
+
+	err := validateLogsWindow(10*time.Minute, time.Hour)
+	assert.EqualError(t, err, "--until 1h0m0s is not more recent than --since 10m0s: no logs can match")
+	assert.Equal(t, clierror.Usage, clierror.Code(err))
+	assert.Error(t, validateLogsWindow(-time.Minute, 0))
+}
+
+func TestServiceSuggestions(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("working-dir", "", "")
+		cmd.Flags().StringArray("service", nil, "")
+		require.NoError(t, cmd.ParseFlags(args))
+		return cmd
+	}
+
+	embedded := serviceSuggestions(newCmd(), nil)
+	assert.NotEmpty(t, embedded, "the embedded compose file is used without a project one")
+
+	project := filepath.Join(dir, "project")
+	require.NoError(t, os.Mkdir(project, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(project, "docker-compose.yaml"), []byte("services:\n  web:\n    build: .\n  pg:\n    image: postgres:16\n"), 0644))
+	assert.Equal(t, embedded, serviceSuggestions(newCmd(), nil), "--working-dir is not set")
+	assert.Equal(t, []string{"pg\tpostgres:16", "web"}, serviceSuggestions(newCmd("--working-dir", "project"), nil))
+	assert.Equal(t, []string{"web"}, serviceSuggestions(newCmd("--working-dir", project), []string{"pg"}))
+
+	cmd := newCmd("--working-dir", project, "--service", "web")
+	suggestions, directive := completeServiceFlag(cmd, nil, "")
+	assert.Equal(t, []string{"pg\tpostgres:16"}, suggestions)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	suggestions, _ = completeServiceArg(cmd, []string{"pg"}, "")
+	assert.Empty(t, suggestions, "only the first argument is a service")
+
+	suggestions, directive = completeCopyArg(cmd, nil, "p")
+	assert.Equal(t, []string{"pg:\tpostgres:16"}, suggestions)
+	assert.Equal(t, cobra.ShellCompDirectiveNoSpace, directive)
+	suggestions, directive = completeCopyArg(cmd, []string{"pg:/tmp/dump.sql"}, "./du")
+	assert.Empty(t, suggestions)
+	assert.Equal(t, cobra.ShellCompDirectiveDefault, directive, "local paths complete files")
+	_, directive = completeCopyArg(cmd, nil, "pg:/tmp")
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	require.NoError(t, os.WriteFile(filepath.Join(project, "docker-compose.yaml"), []byte("services: [\n"), 0644))
+	assert.Empty(t, serviceSuggestions(newCmd("--working-dir", project), nil), "an invalid compose file suggests nothing")
+}
+
+func TestDockerNoInput(t *testing.T) {
+	ctx := tty.WithNoInput(context.Background())
+
+	_, err := pickRunningService(ctx, []docker.ServiceInfo{{Service: "pg", State: "running"}, {Service: "core", State: "running"}})
+	assert.EqualError(t, err, "a service is required when --no-input is set (running services: core, pg)")
+	assert.Equal(t, clierror.Usage, clierror.Code(err))
+
+	_, err = promptEnvConfig(ctx)
+	assert.EqualError(t, err, "cannot prompt for the .env configuration when --no-input is set: generate .env with acontext docker env in a terminal first")
+	assert.Equal(t, clierror.Usage, clierror.Code(err))
+}
+
+func TestUpRunsTheComposeFileConfigReports(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	// A fake compose that logs its arguments, one invocation per line
+	bin := t.TempDir()
+	log := filepath.Join(bin, "compose.log")
+	compose := filepath.Join(bin, "compose")
+	require.NoError(t, os.WriteFile(compose, []byte("#!/bin/sh\necho \"$*\" >> "+log+"\ncase \"$*\" in *\"config --services\"*) echo web;; esac\n"), 0755))
+	original := dockerPrerequisites
+	dockerPrerequisites = &prerequisiteCheck{check: func(context.Context) (docker.Compose, error) {
+		return docker.Compose{Command: []string{compose}, Version: "2.29.0"}, nil
+	}}
+	t.Setenv("ACONTEXT_HOME", t.TempDir())
+	upWait, configServices = true, true
+	t.Cleanup(func() {
+		dockerPrerequisites = original
+		upWait, configServices = false, false
+		dockerUpCmd.SetContext(nil)
+		dockerConfigCmd.SetContext(nil)
+	})
+
+	// composeFiles runs up and config, and returns the compose file each ran
+	composeFiles := func(t *testing.T) (up, config string) {
+		require.NoError(t, os.WriteFile(log, nil, 0644))
+		dockerUpCmd.SetContext(context.Background())
+		require.NoError(t, runDockerUp(dockerUpCmd, nil))
+		dockerConfigCmd.SetContext(context.Background())
+		require.NoError(t, runDockerConfig(dockerConfigCmd, nil))
+
+		content, err := os.ReadFile(log)
+		require.NoError(t, err)
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			args := strings.Fields(line)
+			i := slices.Index(args, "-f")
+			require.NotEqual(t, -1, i, "compose is run without -f: %s", line)
+			switch {
+			case slices.Contains(args, "up"):
+				up = args[i+1]
+			case slices.Contains(args, "--services"):
+				config = args[i+1]
+			}
+		}
+		return up, config
+	}
+
+	t.Run("project compose file", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		cwd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(".env", []byte("LLM_SDK=openai\n"), 0600))
+		require.NoError(t, os.WriteFile("docker-compose.yaml", []byte("services:\n  web:\n    image: nginx\n"), 0644))
+
+		up, config := composeFiles(t)
+		assert.Equal(t, filepath.Join(cwd, "docker-compose.yaml"), up)
+		assert.Equal(t, up, config)
+	})
+
+	t.Run("embedded compose file", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		require.NoError(t, os.WriteFile(".env", []byte("LLM_SDK=openai\n"), 0600))
+
+		up, config := composeFiles(t)
+		for _, file := range []string{up, config} {
+			matched, err := filepath.Match(".docker-compose-*.yaml", filepath.Base(file))
+			require.NoError(t, err)
+			assert.True(t, matched, "%s is a temporary copy of the embedded compose file", file)
+		}
+	})
+}
+
+func TestServiceStatusJSON(t *testing.T) {
+	services := []docker.ServiceInfo{{
+		ID:         "3f2a",
+		Name:       "acontext-server-pg-1",
+		Service:    "acontext-server-pg",
+		State:      "running",
+		Status:     "Up 2 minutes (healthy)",
+		Health:     "healthy",
+		Publishers: []docker.Publisher{{URL: "0.0.0.0", TargetPort: 5432, PublishedPort: 15432, Protocol: "tcp"}},
+	}}
+
+	content, err := json.Marshal(newServiceStatuses(services))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{
+		"id": "3f2a",
+		"name": "acontext-server-pg-1",
+		"service": "acontext-server-pg",
+		"state": "running",
+		"status": "Up 2 minutes (healthy)",
+		"health": "healthy",
+		"exit_code": 0,
+		"ports": "0.0.0.0:15432->5432/tcp",
+		"publishers": [{"host_ip": "0.0.0.0", "container_port": 5432, "host_port": 15432, "protocol": "tcp"}]
+	}]`, string(content))
+}
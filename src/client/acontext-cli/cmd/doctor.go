@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/config"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+// toolTimeout bounds how long a single tool version query may take
+const toolTimeout = 10 * time.Second
+
+// checkStatus is the outcome of a doctor check
+type checkStatus string
+
+const (
+	checkPass checkStatus = "pass"
+	checkWarn checkStatus = "warn"
+	checkFail checkStatus = "fail"
+)
+
+// doctorCheck is the result of a single doctor check
+type doctorCheck struct {
+	Name   string      `json:"name"`
+	Status checkStatus `json:"status"`
+	Detail string      `json:"detail"`
+	Hint   string      `json:"hint,omitempty"`
+}
+
+// doctorSummary counts doctor checks by status
+type doctorSummary struct {
+	Passed   int `json:"passed"`
+	Warnings int `json:"warnings"`
+	Failed   int `json:"failed"`
+}
+
+// doctorResult is the JSON result of doctor
+type doctorResult struct {
+	output.Envelope
+	OK      bool          `json:"ok"`
+	Checks  []doctorCheck `json:"checks"`
+	Summary doctorSummary `json:"summary"`
+}
+
+var DoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check your environment for common problems",
+	Long: `Check your environment for common problems and print a report you can paste
+into bug reports.
+
+The following is checked:
+  - CLI version, Go version, OS and architecture
+  - git, docker and docker compose are installed (required)
+  - the Docker daemon is running
+  - the telemetry endpoint is reachable
+  - the config file is readable
+
+Each check passes, warns or fails. The command exits non-zero if a required
+check fails. Use --output json to get the results as JSON.
+`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	checks := runDoctorChecks(cmd.Context())
+	summary := summarizeChecks(checks)
+
+	var err error
+	if summary.Failed > 0 {
+		err = clierror.WithCode(clierror.MissingDependency, fmt.Errorf("%d required check(s) failed", summary.Failed))
+	}
+
+	if output.IsJSON() {
+		result := doctorResult{
+			Envelope: output.NewEnvelope("doctor"),
+			OK:       summary.Failed == 0,
+			Checks:   checks,
+			Summary:  summary,
+		}
+		if printErr := output.PrintJSON(result); printErr != nil {
+			return printErr
+		}
+		return output.Reported(err)
+	}
+
+	printDoctorReport(checks, summary)
+	return err
+}
+
+// runDoctorChecks runs every doctor check in report order
+func runDoctorChecks(ctx context.Context) []doctorCheck {
+	return []doctorCheck{
+		{Name: "acontext", Status: checkPass, Detail: cliVersion},
+		{Name: "platform", Status: checkPass, Detail: fmt.Sprintf("%s %s/%s", runtime.Version(), runtime.GOOS, runtime.GOARCH)},
+		checkTool(ctx, "git", []string{"git", "--version"}, "Install git from https://git-scm.com/downloads"),
+		checkTool(ctx, "docker", []string{"docker", "--version"}, "Install Docker from https://docs.docker.com/get-docker/"),
+		checkTool(ctx, "docker compose", []string{"docker", "compose", "version"}, "Install the Docker Compose v2 plugin: https://docs.docker.com/compose/install/"),
+		checkDockerDaemon(ctx),
+		checkTelemetryEndpoint(ctx),
+		checkConfigFile(),
+	}
+}
+
+// checkTool checks that a required tool is installed by running argv and
+// reporting the first line of its output
+func checkTool(ctx context.Context, name string, argv []string, hint string) doctorCheck {
+	check := doctorCheck{Name: name}
+	if _, err := exec.LookPath(argv[0]); err != nil {
+		check.Status = checkFail
+		check.Detail = fmt.Sprintf("%s not found on PATH", argv[0])
+		check.Hint = hint
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, toolTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	out, err := logging.Output(ctx, cmd)
+	if err != nil {
+		check.Status = checkFail
+		check.Detail = fmt.Sprintf("`%s` failed: %v", strings.Join(argv, " "), err)
+		check.Hint = hint
+		return check
+	}
+
+	check.Status = checkPass
+	check.Detail = firstLine(string(out))
+	return check
+}
+
+// checkDockerDaemon checks that the Docker daemon responds
+func checkDockerDaemon(ctx context.Context) doctorCheck {
+	check := doctorCheck{Name: "docker daemon"}
+	if _, err := exec.LookPath("docker"); err != nil {
+		check.Status = checkWarn
+		check.Detail = "skipped, docker is not installed"
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, toolTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "docker", "info", "--format", "{{.ServerVersion}}")
+	out, err := logging.Output(ctx, cmd)
+	if err != nil {
+		check.Status = checkWarn
+		check.Detail = "not running"
+		check.Hint = "Start Docker Desktop, or on Linux run `sudo systemctl start docker`"
+		return check
+	}
+
+	check.Status = checkPass
+	check.Detail = "running (server " + firstLine(string(out)) + ")"
+	return check
+}
+
+// checkTelemetryEndpoint checks that the configured telemetry endpoint is
+// valid and can be reached
+func checkTelemetryEndpoint(ctx context.Context) doctorCheck {
+	check := doctorCheck{Name: "telemetry endpoint"}
+	if telemetry.Disabled() {
+		check.Status = checkPass
+		check.Detail = "telemetry disabled by " + telemetry.DisabledEnvVar
+		return check
+	}
+
+	telemetryConfig, err := telemetry.ResolveConfig(loadUserConfig().Get)
+	if err == nil {
+		err = telemetry.Configure(telemetryConfig)
+	}
+	if err != nil {
+		check.Status = checkWarn
+		check.Detail = err.Error()
+		check.Hint = "Usage telemetry is not sent until telemetry.endpoint (or " + telemetry.EndpointEnvVar + ") is fixed"
+		return check
+	}
+
+	if err := telemetry.CheckEndpoint(ctx); err != nil {
+		check.Status = checkWarn
+		check.Detail = err.Error()
+		check.Hint = "Usage telemetry will not be sent; this does not affect other commands"
+		return check
+	}
+
+	check.Status = checkPass
+	check.Detail = telemetry.Endpoint() + " reachable"
+	return check
+}
+
+// checkConfigFile checks that the user config file, if any, can be read
+func checkConfigFile() doctorCheck {
+	check := doctorCheck{Name: "config file"}
+	path, err := config.UserConfigPath()
+	if err != nil {
+		check.Status = checkWarn
+		check.Detail = err.Error()
+		return check
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		check.Status = checkPass
+		check.Detail = path + " (not created yet, defaults in use)"
+		return check
+	}
+
+	if _, err := config.LoadUserConfigFile(path); err != nil {
+		check.Status = checkWarn
+		check.Detail = err.Error()
+		check.Hint = "Fix or remove the file; defaults are used until it can be read"
+		return check
+	}
+
+	check.Status = checkPass
+	check.Detail = path
+	return check
+}
+
+// summarizeChecks counts checks by status
+func summarizeChecks(checks []doctorCheck) doctorSummary {
+	var summary doctorSummary
+	for _, check := range checks {
+		switch check.Status {
+		case checkPass:
+			summary.Passed++
+		case checkWarn:
+			summary.Warnings++
+		case checkFail:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// printDoctorReport prints checks as an aligned report followed by a summary
+func printDoctorReport(checks []doctorCheck, summary doctorSummary) {
+	fmt.Println("🩺 Acontext doctor")
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, check := range checks {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", statusSymbol(check.Status), check.Name, check.Detail)
+		if check.Hint != "" {
+			fmt.Fprintf(w, "  \t\t→ %s\n", check.Hint)
+		}
+	}
+	_ = w.Flush()
+
+	fmt.Println()
+	fmt.Printf("%d passed, %d warning(s), %d failed\n", summary.Passed, summary.Warnings, summary.Failed)
+}
+
+// statusSymbol returns the symbol shown for a check status
+func statusSymbol(status checkStatus) string {
+	switch status {
+	case checkPass:
+		return "✓"
+	case checkWarn:
+		return "⚠"
+	default:
+		return "✗"
+	}
+}
+
+// firstLine returns the first non-empty line of s, trimmed
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeChecks(t *testing.T) {
+	checks := []doctorCheck{
+		{Name: "a", Status: checkPass},
+		{Name: "b", Status: checkPass},
+		{Name: "c", Status: checkWarn},
+		{Name: "d", Status: checkFail},
+	}
+
+	assert.Equal(t, doctorSummary{Passed: 2, Warnings: 1, Failed: 1}, summarizeChecks(checks))
+}
+
+func TestCheckToolMissing(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	check := checkTool(context.Background(), "git", []string{"git", "--version"}, "Install git")
+	assert.Equal(t, checkFail, check.Status)
+	assert.Contains(t, check.Detail, "not found on PATH")
+	assert.Equal(t, "Install git", check.Hint)
+}
+
+func TestCheckConfigFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantStatus checkStatus
+	}{
+		{name: "missing file", wantStatus: checkPass},
+		{name: "valid file", content: "telemetry:\n  enabled: \"false\"\n", wantStatus: checkPass},
+		{name: "invalid file", content: "telemetry: [", wantStatus: checkWarn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configHome := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", configHome)
+			if tt.content != "" {
+				path := filepath.Join(configHome, "acontext", "config.yaml")
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+			}
+
+			check := checkConfigFile()
+			assert.Equal(t, tt.wantStatus, check.Status, check.Detail)
+		})
+	}
+}
+
+func TestFirstLine(t *testing.T) {
+	assert.Equal(t, "Docker Compose version v2.29.1", firstLine("\nDocker Compose version v2.29.1\nextra\n"))
+	assert.Equal(t, "", firstLine(""))
+}
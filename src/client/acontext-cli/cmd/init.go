@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/deploy"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/docker"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/git"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/install"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/telemetry"
+	"github.com/memodb-io/Acontext/acontext-cli/pkg/scaffold"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// projectComposeFile is the compose file the docker commands use instead of
+// the embedded one when a project has it
+const projectComposeFile = "docker-compose.yaml"
+
+var (
+	initName      string
+	initForce     bool
+	initNoGit     bool
+	initNoCompose bool
+)
+
+var InitCmd = &cobra.Command{
+	Use:   "init [directory]",
+	Short: "Add Acontext to an existing project",
+	Long: `Add Acontext to the existing project in the current directory, or in the
+given one, without scaffolding a template.
+
+init writes only the Acontext files:
+  - acontext.yaml, which marks the project for acontext deploy
+  - docker-compose.yaml, the compose file acontext docker uses (skip with --no-compose
+    to keep using the one embedded in the CLI)
+  - .gitignore entries for .env, backups and deploy artifacts, appended to the
+    existing .gitignore
+
+The project's language is detected from its files (package.json, pyproject.toml,
+requirements.txt or go.mod) to show how to add the Acontext SDK.
+
+Existing files are never overwritten unless --force is set, in which case they
+are first backed up to .acontext-backup/. A Git repository is initialized
+unless one already contains the project or --no-git is set.`,
+	Example: `  acontext init
+  acontext init ./my-app --name my-app
+  acontext init --force --no-compose`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runInit,
+}
+
+func init() {
+	InitCmd.Flags().StringVar(&initName, "name", "", "Project name written to acontext.yaml (defaults to the directory name)")
+	InitCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite existing Acontext files, backing them up to "+scaffold.BackupDir+"/")
+	InitCmd.Flags().BoolVar(&initNoGit, "no-git", false, "Skip Git repository initialization")
+	InitCmd.Flags().BoolVar(&initNoCompose, "no-compose", false, "Do not write "+projectComposeFile+", use the compose file embedded in the CLI")
+}
+
+// initResult is the JSON result of init
+type initResult struct {
+	output.Envelope
+	Project        string   `json:"project"`
+	Path           string   `json:"path"`
+	Language       string   `json:"language,omitempty"`
+	Files          []string `json:"files"`
+	Unchanged      []string `json:"unchanged,omitempty"`
+	BackedUp       []string `json:"backed_up,omitempty"`
+	Gitignore      []string `json:"gitignore,omitempty"`
+	GitInitialized bool     `json:"git_initialized"`
+	SDK            string   `json:"sdk_install,omitempty"`
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	projectDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	if info, err := os.Stat(projectDir); err != nil {
+		return clierror.UsageError("%s does not exist: acontext init adds Acontext to an existing project, use acontext create for a new one", dir)
+	} else if !info.IsDir() {
+		return clierror.UsageError("%s is not a directory", dir)
+	}
+	displayDir := relativeToCwd(projectDir)
+
+	name, err := initProjectName(projectDir)
+	if err != nil {
+		return err
+	}
+	files, err := initFiles(name)
+	if err != nil {
+		return err
+	}
+
+	// Refuse to clobber anything before writing, so a failed init writes nothing
+	var write, unchanged, conflicts []string
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		existing, err := os.ReadFile(filepath.Join(projectDir, path))
+		switch {
+		case os.IsNotExist(err):
+			write = append(write, path)
+		case err != nil:
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		case bytes.Equal(existing, files[path]):
+			unchanged = append(unchanged, path)
+		default:
+			conflicts = append(conflicts, path)
+		}
+	}
+	if len(conflicts) > 0 && !initForce {
+		return fmt.Errorf("%s already contains %s\nuse --force to overwrite them (backups are saved to %s/)", displayDir, strings.Join(conflicts, ", "), scaffold.BackupDir)
+	}
+	write = append(write, conflicts...)
+	sort.Strings(write)
+
+	backedUp, err := writeInitFiles(projectDir, files, write)
+	if err != nil {
+		return err
+	}
+	ignored, err := git.AddIgnores(projectDir, ".env", scaffold.BackupDir+"/", deploy.ArtifactDir+"/")
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Failed to update .gitignore: %v\n", err)
+	}
+
+	gitInitialized := false
+	var gitErr error
+	switch {
+	case git.IsInsideWorkTree(ctx, projectDir):
+		// Covers a repository at projectDir as well as one containing it
+	case initNoGit:
+	default:
+		if gitErr = git.Init(ctx, projectDir, git.DefaultBranch); gitErr == nil {
+			gitInitialized = true
+		}
+	}
+	telemetry.RecordFlag("git_init", strconv.FormatBool(gitInitialized))
+
+	language := install.DetectLanguage(projectDir)
+	sdk, hasSDK := install.SDK(projectDir)
+	telemetry.RecordFlag("language", language)
+
+	if output.IsJSON() {
+		result := initResult{
+			Envelope:       output.NewEnvelope("init"),
+			Project:        name,
+			Path:           projectDir,
+			Language:       language,
+			Files:          write,
+			Unchanged:      unchanged,
+			BackedUp:       backedUp,
+			Gitignore:      ignored,
+			GitInitialized: gitInitialized,
+		}
+		if result.Files == nil {
+			result.Files = []string{}
+		}
+		if hasSDK {
+			result.SDK = sdk.String()
+		}
+		return output.PrintJSON(result)
+	}
+
+	if len(write) == 0 {
+		fmt.Printf("✓ %s already has the Acontext files\n", displayDir)
+	} else {
+		fmt.Printf("✅ Added Acontext to %s\n", displayDir)
+		for _, path := range write {
+			fmt.Printf("   + %s\n", path)
+		}
+	}
+	if len(ignored) > 0 {
+		fmt.Printf("   + .gitignore: %s\n", strings.Join(ignored, " "))
+	}
+	if len(backedUp) > 0 {
+		fmt.Printf("⚠️  Overwrote %d existing file(s), backups saved to %s/:\n", len(backedUp), scaffold.BackupDir)
+		for _, file := range backedUp {
+			fmt.Printf("   - %s\n", file)
+		}
+	}
+	fmt.Println()
+
+	switch {
+	case gitInitialized:
+		fmt.Printf("✓ Git repository initialized (branch: %s)\n", git.DefaultBranch)
+		fmt.Println()
+	case gitErr != nil:
+		fmt.Printf("⚠️  Warning: Failed to initialize Git: %v\n", gitErr)
+		fmt.Println("   You can initialize Git manually later with: git init")
+		fmt.Println()
+	}
+
+	fmt.Println("🚀 Next steps:")
+	fmt.Println()
+	step := 1
+	if language != "" {
+		fmt.Printf("   %d. Add the Acontext SDK to your %s project:\n", step, languageNames[language])
+		if hasSDK {
+			fmt.Printf("      %s\n", sdk)
+		} else {
+			fmt.Println("      There is no Acontext SDK for Go yet, call the Acontext HTTP API directly")
+		}
+		fmt.Println()
+		step++
+	}
+	fmt.Printf("   %d. Configure and start the Acontext services:\n", step)
+	fmt.Println("      acontext docker env")
+	fmt.Println("      acontext docker up")
+	fmt.Println()
+	return nil
+}
+
+// languageNames are the display names of the languages install detects
+var languageNames = map[string]string{
+	install.LanguageTypeScript: "TypeScript",
+	install.LanguagePython:     "Python",
+	install.LanguageGo:         "Go",
+}
+
+// initProjectName returns --name, or a valid project name derived from the
+// name of projectDir
+func initProjectName(projectDir string) (string, error) {
+	if initName != "" {
+		if err := scaffold.ValidateName(initName); err != nil {
+			return "", clierror.UsageError("%v", err)
+		}
+		return initName, nil
+	}
+	name := filepath.Base(projectDir)
+	if scaffold.ValidateName(name) == nil {
+		return name, nil
+	}
+	if sanitized := scaffold.SanitizeName(name); sanitized != "" {
+		return sanitized, nil
+	}
+	return "", clierror.UsageError("cannot derive a project name from %q, pass --name", name)
+}
+
+// initFiles returns the content of the files init writes, by relative path
+func initFiles(name string) (map[string][]byte, error) {
+	project, err := yaml.Marshal(&deploy.Project{Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", deploy.ProjectFile, err)
+	}
+	files := map[string][]byte{deploy.ProjectFile: project}
+	if !initNoCompose {
+		files[projectComposeFile] = []byte(docker.GetDockerComposeContent())
+	}
+	return files, nil
+}
+
+// writeInitFiles writes the files at paths into projectDir, moving the ones
+// they replace to a timestamped directory in BackupDir. It returns the
+// replaced paths.
+func writeInitFiles(projectDir string, files map[string][]byte, paths []string) ([]string, error) {
+	backupDir := filepath.Join(projectDir, scaffold.BackupDir, time.Now().Format("20060102-150405"))
+	var backedUp []string
+	for _, path := range paths {
+		target := filepath.Join(projectDir, path)
+		if _, err := os.Lstat(target); err == nil {
+			if err := os.MkdirAll(backupDir, 0755); err != nil {
+				return backedUp, fmt.Errorf("failed to create backup directory: %w", err)
+			}
+			if err := os.Rename(target, filepath.Join(backupDir, path)); err != nil {
+				return backedUp, fmt.Errorf("failed to back up %s: %w", path, err)
+			}
+			backedUp = append(backedUp, path)
+		}
+		if err := os.WriteFile(target, files[path], 0644); err != nil {
+			return backedUp, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return backedUp, nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitProjectName(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		flag    string
+		want    string
+		wantErr string
+	}{
+		{name: "directory name", dir: "/work/my-agent", want: "my-agent"},
+		{name: "sanitized directory name", dir: "/work/My Agent", want: "my-agent"},
+		{name: "flag", dir: "/work/src", flag: "my-agent", want: "my-agent"},
+		{name: "invalid flag", dir: "/work/src", flag: "My Agent", wantErr: "invalid project name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initName = tt.flag
+			t.Cleanup(func() { initName = "" })
+
+			name, err := initProjectName(tt.dir)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, name)
+		})
+	}
+}
+
+func TestWriteInitFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "acontext.yaml"), []byte("name: old\n"), 0644))
+	files := map[string][]byte{
+		"acontext.yaml":       []byte("name: new\n"),
+		"docker-compose.yaml": []byte("services: {}\n"),
+	}
+
+	backedUp, err := writeInitFiles(dir, files, []string{"acontext.yaml", "docker-compose.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acontext.yaml"}, backedUp)
+
+	content, err := os.ReadFile(filepath.Join(dir, "acontext.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: new\n", string(content))
+	backups, err := filepath.Glob(filepath.Join(dir, ".acontext-backup", "*", "acontext.yaml"))
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	content, err = os.ReadFile(backups[0])
+	require.NoError(t, err)
+	assert.Equal(t, "name: old\n", string(content))
+	assert.FileExists(t, filepath.Join(dir, "docker-compose.yaml"))
+}
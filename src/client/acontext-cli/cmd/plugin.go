@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/interrupt"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/plugin"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+var PluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Manage external subcommands (plugins)",
+	Long: `Plugins add subcommands to the CLI. Like git and kubectl plugins, acontext
+foo runs an executable named acontext-foo found on PATH when foo is not a
+built-in command.
+
+Every argument after the plugin name is passed to it as is, flags included,
+and so are stdin, stdout and stderr; the plugin's exit code is the CLI's.
+Built-in commands always take precedence over plugins of the same name.`,
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the plugins found on PATH",
+	Long: `List the acontext-<name> executables found on PATH and the command that runs
+each one. When several directories have a plugin of the same name, the first
+one on PATH is used and the others are reported as shadowed, and so are
+plugins named after a built-in command.`,
+	Example: `  acontext plugin list
+  acontext plugin list -o json`,
+	Args: cobra.NoArgs,
+	RunE: runPluginList,
+}
+
+// pluginExecCmd runs the plugin named by its first argument. acontext foo is
+// dispatched to it when foo is a plugin, so hooks and telemetry apply.
+var pluginExecCmd = &cobra.Command{
+	Use:    "exec NAME [args...]",
+	Short:  "Run a plugin",
+	Hidden: true,
+	Args:   cobra.MinimumNArgs(1),
+	// The arguments, flags included, belong to the plugin
+	DisableFlagParsing: true,
+	// The plugin reports its own errors
+	SilenceUsage: true,
+	Annotations: map[string]string{
+		telemetry.LongRunningAnnotation: "true",
+		telemetry.NoArgsAnnotation:      "true",
+	},
+	RunE: runPluginExec,
+}
+
+func init() {
+	PluginCmd.AddCommand(pluginListCmd)
+	PluginCmd.AddCommand(pluginExecCmd)
+}
+
+// pluginListResult is the JSON result of plugin list
+type pluginListResult struct {
+	output.Envelope
+	Plugins  []plugin.Plugin `json:"plugins"`
+	Warnings []string        `json:"warnings,omitempty"`
+}
+
+// PluginArgs returns the arguments that make root run the plugin args[0]
+// names, or nil when args[0] is a flag, a built-in command or not a plugin
+func PluginArgs(root *cobra.Command, args []string) []string {
+	if len(args) == 0 || isBuiltinCommand(root, args[0]) || plugin.Lookup(args[0]) == "" {
+		return nil
+	}
+	return append([]string{PluginCmd.Name(), pluginExecCmd.Name()}, args...)
+}
+
+// isBuiltinCommand reports whether name is a subcommand of root (or an
+// alias of one), including the ones cobra adds when it runs
+func isBuiltinCommand(root *cobra.Command, name string) bool {
+	switch name {
+	case "help", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	found, _, err := root.Find([]string{name})
+	return err == nil && found != root
+}
+
+func runPluginList(cmd *cobra.Command, args []string) error {
+	plugins := []plugin.Plugin{}
+	var warnings []string
+	for _, p := range plugin.List() {
+		if isBuiltinCommand(cmd.Root(), p.Name) {
+			warnings = append(warnings, fmt.Sprintf("%s is shadowed by the built-in %s command and never runs", p.Path, p.Name))
+			continue
+		}
+		for _, path := range p.Shadowed {
+			warnings = append(warnings, fmt.Sprintf("%s is shadowed by %s, which comes first on PATH", path, p.Path))
+		}
+		plugins = append(plugins, p)
+	}
+
+	if output.IsJSON() {
+		return output.PrintJSON(pluginListResult{
+			Envelope: output.NewEnvelope("plugin.list"),
+			Plugins:  plugins,
+			Warnings: warnings,
+		})
+	}
+
+	for _, warning := range warnings {
+		fmt.Printf("⚠️  Warning: %s\n", warning)
+	}
+	if len(plugins) == 0 {
+		fmt.Printf("No plugins found on PATH. Plugins are executables named %s<name>, run with: acontext <name>\n", plugin.Prefix)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMMAND\tPATH")
+	for _, p := range plugins {
+		fmt.Fprintf(w, "acontext %s\t%s\n", p.Name, p.Path)
+	}
+	return w.Flush()
+}
+
+func runPluginExec(cmd *cobra.Command, args []string) error {
+	path := plugin.Lookup(args[0])
+	if path == "" {
+		return clierror.UsageError("unknown plugin %q: no %s%s found on PATH", args[0], plugin.Prefix, args[0])
+	}
+
+	// The plugin gets Ctrl-C from the terminal itself
+	release := interrupt.Delegate()
+	defer release()
+	err := plugin.Run(cmd.Context(), path, args[1:])
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return clierror.WithCode(exitErr.ExitCode(), fmt.Errorf("%s exited with status %d", filepath.Base(path), exitErr.ExitCode()))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run plugin %s: %w", path, err)
+	}
+	return nil
+}
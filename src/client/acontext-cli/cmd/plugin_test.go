@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts require a POSIX shell")
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"acontext-foo", "acontext-docker", "acontext-help"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755))
+	}
+	t.Setenv("PATH", dir)
+
+	root := &cobra.Command{Use: "acontext"}
+	root.AddCommand(&cobra.Command{Use: "docker", Aliases: []string{"d"}, Run: func(*cobra.Command, []string) {}})
+	root.AddCommand(PluginCmd)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{name: "plugin", args: []string{"foo", "--output", "json", "bar"}, expected: []string{"plugin", "exec", "foo", "--output", "json", "bar"}},
+		{name: "no arguments"},
+		{name: "flag first", args: []string{"--no-logo", "foo"}},
+		{name: "built-in command", args: []string{"docker", "up"}},
+		{name: "help", args: []string{"help"}},
+		{name: "unknown command", args: []string{"bar"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, PluginArgs(root, tt.args))
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/config"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+var TelemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Inspect and clear anonymous usage telemetry",
+	Long: `Inspect and clear the anonymous usage telemetry the CLI sends.
+
+Turn telemetry off with acontext config set telemetry.enabled false, the
+--no-telemetry flag, or ACONTEXT_TELEMETRY=0. These commands send no
+telemetry themselves.
+
+Example:
+  acontext telemetry status
+  acontext telemetry purge
+`,
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:         "status",
+	Short:       "Show whether telemetry is enabled, where it is sent and how many events are queued",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{telemetry.SkipAnnotation: ""},
+	RunE:        runTelemetryStatus,
+}
+
+var telemetryPurgeCmd = &cobra.Command{
+	Use:         "purge",
+	Short:       "Delete queued events and the one-time notice state",
+	Long:        "Delete the events queued for a later run and forget that the telemetry notice was shown, so it is shown again the next time telemetry is sent",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{telemetry.SkipAnnotation: ""},
+	RunE:        runTelemetryPurge,
+}
+
+func init() {
+	TelemetryCmd.AddCommand(telemetryStatusCmd)
+	TelemetryCmd.AddCommand(telemetryPurgeCmd)
+}
+
+// telemetryStatusResult is the JSON result of telemetry status
+type telemetryStatusResult struct {
+	output.Envelope
+	Enabled       bool     `json:"enabled"`
+	Reason        string   `json:"reason"`
+	Endpoint      string   `json:"endpoint,omitempty"`
+	EndpointError string   `json:"endpoint_error,omitempty"`
+	SampleRate    *float64 `json:"sample_rate,omitempty"` // Fraction of successful commands whose event is sent
+	Queued        int      `json:"queued"`
+	QueueDir      string   `json:"queue_dir,omitempty"`
+	NoticeShown   bool     `json:"notice_shown"`
+}
+
+// telemetryPurgeResult is the JSON result of telemetry purge
+type telemetryPurgeResult struct {
+	output.Envelope
+	Purged      int  `json:"purged"`
+	NoticeReset bool `json:"notice_reset"`
+}
+
+func runTelemetryStatus(cmd *cobra.Command, args []string) error {
+	cfg := loadUserConfig()
+
+	flagOff := false
+	if flag := cmd.Flag("no-telemetry"); flag != nil {
+		flagOff = flag.Value.String() == "true"
+	}
+	decision := telemetry.Decide(cliVersion, flagOff, cfg.Get)
+	result := telemetryStatusResult{
+		Envelope:    output.NewEnvelope("telemetry.status"),
+		Enabled:     decision.Enabled,
+		Reason:      decision.Reason,
+		NoticeShown: cfg.GetBool("telemetry.notice_shown", false),
+	}
+
+	telemetryConfig, err := telemetry.ResolveConfig(cfg.Get)
+	if err == nil {
+		result.Endpoint = telemetryConfig.Endpoint
+		result.SampleRate = &telemetryConfig.SampleRate
+		err = telemetryConfig.Validate()
+	}
+	if err != nil {
+		result.EndpointError = err.Error()
+	}
+
+	if queue, err := telemetry.DefaultQueue(); err == nil {
+		result.Queued = queue.Len()
+		result.QueueDir = queue.Dir
+	}
+
+	if output.IsJSON() {
+		return output.PrintJSON(result)
+	}
+
+	if result.Enabled {
+		fmt.Printf("Telemetry:  enabled (%s)\n", result.Reason)
+	} else {
+		fmt.Printf("Telemetry:  disabled (%s)\n", result.Reason)
+	}
+	switch {
+	case result.EndpointError != "":
+		fmt.Printf("Endpoint:   ⚠️  %s\n", result.EndpointError)
+	default:
+		fmt.Printf("Endpoint:   %s\n", result.Endpoint)
+	}
+	if result.SampleRate != nil && *result.SampleRate < 1 {
+		fmt.Printf("Sampling:   %g%% of successful commands (failures are always sent)\n", *result.SampleRate*100)
+	}
+	if result.QueueDir != "" {
+		fmt.Printf("Queued:     %d event(s) in %s\n", result.Queued, result.QueueDir)
+	} else {
+		fmt.Printf("Queued:     %d event(s)\n", result.Queued)
+	}
+	if result.NoticeShown {
+		fmt.Println("Notice:     shown")
+	} else {
+		fmt.Println("Notice:     not shown yet")
+	}
+	return nil
+}
+
+func runTelemetryPurge(cmd *cobra.Command, args []string) error {
+	queue, err := telemetry.DefaultQueue()
+	if err != nil {
+		return err
+	}
+	purged, err := queue.Purge()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadUserConfig()
+	if err != nil {
+		return err
+	}
+	_, noticeReset := cfg.Get("telemetry.notice_shown")
+	if noticeReset {
+		if err := cfg.Unset("telemetry.notice_shown"); err != nil {
+			return err
+		}
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+	}
+
+	if output.IsJSON() {
+		return output.PrintJSON(telemetryPurgeResult{
+			Envelope:    output.NewEnvelope("telemetry.purge"),
+			Purged:      purged,
+			NoticeReset: noticeReset,
+		})
+	}
+	fmt.Printf("✓ Deleted %d queued event(s)\n", purged)
+	if noticeReset {
+		fmt.Println("✓ Reset the telemetry notice, it is shown again the next time telemetry is sent")
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/config"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/progress"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/template"
+	"github.com/spf13/cobra"
+)
+
+// Where a listed template comes from
+const (
+	sourceBuiltin      = "built-in"
+	sourceSearchPath   = "search-path"
+	sourceCachedRemote = "cached-remote"
+)
+
+// templateEntry is a template shown by template list and template info
+type templateEntry struct {
+	Name        string `json:"name"`
+	Language    string `json:"language"`
+	Description string `json:"description"`
+	Source      string `json:"source"`
+	URL         string `json:"url,omitempty"`
+	Commit      string `json:"commit,omitempty"`
+	Dir         string `json:"dir,omitempty"` // Directory of a search path template
+
+	manifest *template.Manifest // Manifest of an embedded, search path or cached remote template
+}
+
+// templateListResult is the JSON result of template list
+type templateListResult struct {
+	output.Envelope
+	Templates []templateEntry `json:"templates"`
+	Warnings  []string        `json:"warnings,omitempty"`
+}
+
+// templateInfoResult is the JSON result of template info
+type templateInfoResult struct {
+	output.Envelope
+	templateEntry
+	Variables []template.Variable `json:"variables"`
+	Features  []template.Feature  `json:"features,omitempty"`
+	Usage     string              `json:"usage"`
+}
+
+var TemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage project templates",
+	Long: `Manage project templates used by acontext create.
+
+Templates fetched with --template-url are cached, so later creates work
+offline and skip the clone. A cached ref is re-resolved after 24 hours.
+
+Local templates are found in the directories of --template-search-path and of
+the create.template_search_path setting, separated like PATH. A directory
+with an acontext.template.yaml manifest in one of them is a template named
+after it, and so is one in a language directory of one, as in the
+Acontext-Examples repository (python/openai is python.openai). It takes
+precedence over the built-in template of the same name, and the first one on
+the search path over the next ones.
+
+Example:
+  acontext template list
+  acontext template info python.openai
+  acontext template cache clear
+`,
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available templates",
+	Long: `List the built-in templates from the Acontext-Examples repository, the
+local templates on the template search path and the remote templates in the
+cache. Built-in templates shadowed by a local one of the same name are left
+out, with a warning.
+
+When the repository cannot be reached, the built-in templates from the last
+successful discovery are shown, if any.`,
+	Args: cobra.NoArgs,
+	RunE: runTemplateList,
+}
+
+var templateInfoCmd = &cobra.Command{
+	Use:   "info <name>",
+	Short: "Show a template's variables and usage",
+	Long: `Show details about a template, the variables it is rendered with and how
+to create a project from it.
+
+The name is a built-in template key (e.g., python.openai), the name of a
+template on the template search path or that of a cached remote template, as
+shown by acontext template list.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplateInfo,
+}
+
+var templateCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the template cache",
+}
+
+var templateCacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached templates",
+	Args:  cobra.NoArgs,
+	RunE:  runTemplateCacheClear,
+}
+
+func init() {
+	templateCacheCmd.AddCommand(templateCacheClearCmd)
+	TemplateCmd.AddCommand(templateListCmd)
+	TemplateCmd.AddCommand(templateInfoCmd)
+	TemplateCmd.AddCommand(templateCacheCmd)
+	TemplateCmd.PersistentFlags().StringVar(&searchPath, "template-search-path", "", "Directories of local templates, separated like PATH (searched before create.template_search_path)")
+}
+
+func runTemplateList(cmd *cobra.Command, args []string) error {
+	spinner := progress.StartTransient(cmd.Context(), "🔍 Discovering templates from repository...")
+	entries, warnings, err := collectTemplates(cmd.Context())
+	spinner.Stop()
+	if err != nil {
+		return err
+	}
+
+	if output.IsJSON() {
+		return output.PrintJSON(templateListResult{
+			Envelope:  output.NewEnvelope("template.list"),
+			Templates: entries,
+			Warnings:  warnings,
+		})
+	}
+
+	for _, warning := range warnings {
+		fmt.Printf("⚠️  Warning: %s\n", warning)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No templates available")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tLANGUAGE\tSOURCE\tDESCRIPTION")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Name, valueOrDash(entry.Language), entry.Source, valueOrDash(entry.Description))
+	}
+	return w.Flush()
+}
+
+func runTemplateInfo(cmd *cobra.Command, args []string) error {
+	spinner := progress.StartTransient(cmd.Context(), "🔍 Discovering templates from repository...")
+	entries, warnings, err := collectTemplates(cmd.Context())
+	spinner.Stop()
+	if err != nil {
+		return err
+	}
+
+	entry, ok := findTemplate(entries, args[0])
+	if !ok {
+		if len(warnings) > 0 {
+			return fmt.Errorf("template not found: %s (%s)", args[0], strings.Join(warnings, "; "))
+		}
+		return clierror.WithHint(fmt.Errorf("template not found: %s", args[0]), "Run `acontext template list` to see the available templates.")
+	}
+
+	usage := templateUsage(entry)
+	variables := template.Variables(entry.manifest)
+	var features []template.Feature
+	if entry.manifest != nil {
+		features = entry.manifest.Features
+	}
+	if output.IsJSON() {
+		return output.PrintJSON(templateInfoResult{
+			Envelope:      output.NewEnvelope("template.info"),
+			templateEntry: entry,
+			Variables:     variables,
+			Features:      features,
+			Usage:         usage,
+		})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", entry.Name)
+	fmt.Fprintf(w, "Language:\t%s\n", valueOrDash(entry.Language))
+	fmt.Fprintf(w, "Source:\t%s\n", entry.Source)
+	if entry.URL != "" {
+		fmt.Fprintf(w, "URL:\t%s\n", entry.URL)
+	}
+	if entry.Dir != "" {
+		fmt.Fprintf(w, "Directory:\t%s\n", entry.Dir)
+	}
+	if entry.Commit != "" {
+		fmt.Fprintf(w, "Commit:\t%s\n", entry.Commit)
+	}
+	fmt.Fprintf(w, "Description:\t%s\n", valueOrDash(entry.Description))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("Variables:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, variable := range variables {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", variable.Name, variable.Type, variable.Description)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(features) > 0 {
+		fmt.Println()
+		fmt.Println("Feature groups (--with, --without, --minimal, --full):")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, feature := range features {
+			included := "optional"
+			if feature.IncludedByDefault() {
+				included = "default"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", feature.Name, included, feature.Description)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Printf("  %s\n", usage)
+	return nil
+}
+
+func runTemplateCacheClear(cmd *cobra.Command, args []string) error {
+	cache, err := template.DefaultCache()
+	if err != nil {
+		return err
+	}
+	if err := cache.Clear(); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Cleared template cache (%s)\n", cache.Dir)
+	return nil
+}
+
+// collectTemplates returns the built-in templates followed by the ones on
+// the template search path, which leave out the built-in ones they shadow,
+// and the cached remote ones. Languages whose templates cannot be discovered
+// are reported as warnings so the rest can still be listed offline, and so
+// are the problems of the search path.
+func collectTemplates(ctx context.Context) ([]templateEntry, []string, error) {
+	languages := config.GetLanguages()
+	sort.Strings(languages)
+
+	var warnings []string
+	local, problems := template.SearchTemplates(templateSearchPath(loadUserConfig()))
+	for _, problem := range problems {
+		warnings = append(warnings, problem.Error())
+	}
+	shadowing := map[string]template.LocalTemplate{}
+	for _, tmpl := range local {
+		shadowing[tmpl.Name] = tmpl
+		for _, dir := range tmpl.Shadowed {
+			warnings = append(warnings, fmt.Sprintf("%s is shadowed by %s, which comes first on the template search path", dir, tmpl.Dir))
+		}
+	}
+
+	var entries []templateEntry
+	for _, language := range languages {
+		presets, err := config.GetPresets(ctx, language)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, ctx.Err()
+			}
+			warnings = append(warnings, fmt.Sprintf("could not list built-in %s templates: %v", language, err))
+			continue
+		}
+		for _, preset := range presets {
+			if tmpl, ok := shadowing[preset.Template]; ok {
+				warnings = append(warnings, fmt.Sprintf("the built-in template %s is shadowed by %s on the template search path", preset.Template, tmpl.Dir))
+				continue
+			}
+			entry := templateEntry{
+				Name:        preset.Template,
+				Language:    language,
+				Description: preset.Description,
+				Source:      sourceBuiltin,
+			}
+			// The manifest of an embedded template is at hand, for its variables
+			_, name, _ := strings.Cut(preset.Template, ".")
+			if tmpl, err := config.GetTemplate(language, name); err == nil && tmpl.Embedded {
+				entry.manifest, _ = template.BuiltinManifest(tmpl.Path)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	for _, tmpl := range local {
+		language := tmpl.Manifest.Language
+		if prefix, _, ok := strings.Cut(tmpl.Name, "."); ok && language == "" {
+			language = prefix
+		}
+		entries = append(entries, templateEntry{
+			Name:        tmpl.Name,
+			Language:    language,
+			Description: tmpl.Manifest.Description,
+			Source:      sourceSearchPath,
+			Dir:         tmpl.Dir,
+			manifest:    tmpl.Manifest,
+		})
+	}
+
+	cache, err := template.DefaultCache()
+	if err != nil {
+		return nil, nil, err
+	}
+	cached, err := cache.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, tmpl := range cached {
+		entries = append(entries, templateEntry{
+			Name:        tmpl.Manifest.Name,
+			Language:    tmpl.Manifest.Language,
+			Description: tmpl.Manifest.Description,
+			Source:      sourceCachedRemote,
+			URL:         tmpl.SourceURL(),
+			Commit:      tmpl.Commit,
+			manifest:    tmpl.Manifest,
+		})
+	}
+
+	return entries, warnings, nil
+}
+
+// findTemplate looks up a template by name. Built-in and search path keys
+// may also be written as language/template, and cached remote templates by
+// their URL.
+func findTemplate(entries []templateEntry, name string) (templateEntry, bool) {
+	key := strings.Replace(name, "/", ".", 1)
+	for _, entry := range entries {
+		if (entry.Source == sourceBuiltin || entry.Source == sourceSearchPath) && entry.Name == key {
+			return entry, true
+		}
+	}
+	for _, entry := range entries {
+		if entry.Source == sourceCachedRemote && (entry.Name == name || entry.URL == name) {
+			return entry, true
+		}
+	}
+	return templateEntry{}, false
+}
+
+// templateUsage returns an example create command for a template
+func templateUsage(entry templateEntry) string {
+	if entry.Source == sourceCachedRemote {
+		return fmt.Sprintf("acontext create my-app --template-url %s", entry.URL)
+	}
+	return fmt.Sprintf("acontext create my-app --template %s", entry.Name)
+}
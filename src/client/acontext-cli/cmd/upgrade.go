@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/update"
+	"github.com/spf13/cobra"
+)
+
+// cliVersion is the running CLI version, set by main via SetVersion
+var cliVersion = "dev"
+
+// SetVersion sets the running CLI version used by version-aware commands
+func SetVersion(v string) {
+	cliVersion = v
+}
+
+var (
+	upgradeCheckOnly bool
+)
+
+var UpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade the CLI to the latest release",
+	Long: `Upgrade the CLI to the latest release from GitHub.
+
+The release archive for your platform is downloaded, its checksum is verified
+and the running binary is replaced atomically.
+
+Use --check-only to report whether an update is available without installing it.
+The command exits non-zero when an update is available.
+`,
+	Args:        cobra.NoArgs,
+	Annotations: longRunning(),
+	RunE:        runUpgrade,
+}
+
+func init() {
+	UpgradeCmd.Flags().BoolVar(&upgradeCheckOnly, "check-only", false, "Only check whether an update is available (exits non-zero if one is)")
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	if cliVersion == "dev" {
+		return fmt.Errorf("this is a development build and cannot self-update; install a release with: curl -fsSL https://install.acontext.io | sh")
+	}
+
+	current, err := update.ParseVersion(cliVersion)
+	if err != nil {
+		return fmt.Errorf("failed to parse current version: %w", err)
+	}
+
+	fmt.Println("🔍 Checking for updates...")
+	latest, err := update.LatestRelease(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to check for updates: %w", err)
+	}
+
+	if latest.Version.Compare(current) <= 0 {
+		fmt.Printf("✓ You are running the latest version (%s)\n", current)
+		return nil
+	}
+
+	fmt.Printf("⬆️  A new version is available: %s (current: %s)\n", latest.Version, current)
+	fmt.Printf("   Release notes: %s\n", latest.HTMLURL)
+
+	if upgradeCheckOnly {
+		fmt.Println("   Run 'acontext upgrade' to install it.")
+		return fmt.Errorf("update available: %s", latest.Version)
+	}
+
+	execPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate current binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(execPath); err == nil {
+		execPath = resolved
+	}
+
+	fmt.Printf("📦 Downloading %s...\n", update.AssetName())
+	if err := update.Install(cmd.Context(), latest, execPath); err != nil {
+		return fmt.Errorf("failed to upgrade: %w", err)
+	}
+
+	fmt.Printf("✅ Upgraded to %s\n", latest.Version)
+	return nil
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/deploy"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var ValidateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Check acontext.yaml against its schema",
+	Long: `Check the project's acontext.yaml against the schema bundled with the CLI and
+report every error with its line and column.
+
+path is the project directory or the acontext.yaml file itself (default: the
+current directory). docker up and deploy run the same check first, and stop
+before doing anything when it fails.
+
+The command exits with code 6 when the file does not conform to the schema.
+Use --output json to get the errors as JSON, e.g., in CI.`,
+	Example: `  acontext validate
+  acontext validate ./my-project
+  acontext validate --output json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runValidate,
+}
+
+// validateResult is the JSON result of validate
+type validateResult struct {
+	output.Envelope
+	File   string             `json:"file"`
+	Valid  bool               `json:"valid"`
+	Errors []schema.Violation `json:"errors"`
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	path := deploy.ProjectFile
+	if len(args) > 0 {
+		path = args[0]
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			path = filepath.Join(path, deploy.ProjectFile)
+		}
+	}
+
+	err := deploy.ValidateProjectFile(path)
+	var validationErr *deploy.ValidationError
+	if err != nil && !errors.As(err, &validationErr) {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%s not found: run acontext validate from the root of an Acontext project", path)
+		}
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if output.IsJSON() {
+		result := validateResult{
+			Envelope: output.NewEnvelope("validate"),
+			File:     path,
+			Valid:    validationErr == nil,
+			Errors:   []schema.Violation{},
+		}
+		if validationErr != nil {
+			result.Errors = validationErr.Violations
+		}
+		if printErr := output.PrintJSON(result); printErr != nil {
+			return printErr
+		}
+		if validationErr != nil {
+			return output.Reported(clierror.WithCode(clierror.Validation, err))
+		}
+		return nil
+	}
+
+	if validationErr != nil {
+		for _, v := range validationErr.Violations {
+			fmt.Printf("❌ %s\n", validationErr.Locate(v))
+		}
+		return clierror.WithCode(clierror.Validation, fmt.Errorf("%s has %d schema error(s)", path, len(validationErr.Violations)))
+	}
+	fmt.Printf("✅ %s is valid\n", path)
+	return nil
+}
+
+// validateProject is the schema preflight of the commands that use the
+// project's acontext.yaml. Only a file that does not conform fails: without
+// one there is nothing to check, and reading errors are left to LoadProject.
+func validateProject(projectDir string) error {
+	err := deploy.ValidateProjectFile(filepath.Join(projectDir, deploy.ProjectFile))
+	var validationErr *deploy.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil
+	}
+	validationErr.File = relativeToCwd(validationErr.File)
+	return clierror.WithCode(clierror.Validation, fmt.Errorf("%w\n   Run acontext validate after fixing it", err))
+}
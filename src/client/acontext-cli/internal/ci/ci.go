@@ -0,0 +1,55 @@
+// Package ci renders the GitHub Actions workflow of new projects, which
+// installs their dependencies and runs their tests
+package ci
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// WorkflowFile is the file the workflow is written to, relative to the project
+const WorkflowFile = ".github/workflows/ci.yml"
+
+//go:embed workflow.yml
+var workflowText string
+
+// Workflow describes the CI workflow of a project
+type Workflow struct {
+	Language string // Project language, e.g., go, which selects the toolchain to set up (none if empty)
+	Install  string // Dependency install command, e.g., npm install (skipped if empty)
+	Test     string // Test command, e.g., npm test
+}
+
+// Render returns the workflow file
+func (w Workflow) Render() (string, error) {
+	if strings.TrimSpace(w.Test) == "" {
+		return "", errors.New("the workflow needs a test command")
+	}
+	for _, command := range []string{w.Install, w.Test} {
+		if strings.ContainsAny(command, "\r\n") {
+			return "", fmt.Errorf("workflow command %q spans several lines", command)
+		}
+	}
+	tmpl, err := template.New(WorkflowFile).Parse(workflowText)
+	if err != nil {
+		return "", fmt.Errorf("failed to load the CI workflow: %w", err)
+	}
+	var text strings.Builder
+	if err := tmpl.Execute(&text, struct {
+		Workflow
+		Poetry bool
+	}{w, usesPoetry(w.Install) || usesPoetry(w.Test)}); err != nil {
+		return "", fmt.Errorf("failed to render the CI workflow: %w", err)
+	}
+	return text.String(), nil
+}
+
+// usesPoetry reports whether command runs Poetry, which the Python runners
+// do not come with
+func usesPoetry(command string) bool {
+	name, _, _ := strings.Cut(strings.TrimSpace(command), " ")
+	return name == "poetry"
+}
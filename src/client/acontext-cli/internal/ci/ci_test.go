@@ -0,0 +1,74 @@
+package ci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name      string
+		workflow  Workflow
+		wantSetup string
+		wantSteps []string
+	}{
+		{name: "typescript", workflow: Workflow{Language: "typescript", Install: "npm install", Test: "npm test"}, wantSetup: "actions/setup-node@v4", wantSteps: []string{"npm install", "npm test"}},
+		{name: "python", workflow: Workflow{Language: "python", Install: "pip install -r requirements.txt", Test: "pytest"}, wantSetup: "actions/setup-python@v5", wantSteps: []string{"pip install -r requirements.txt", "pytest"}},
+		{name: "poetry", workflow: Workflow{Language: "python", Install: "poetry install", Test: "poetry run pytest"}, wantSetup: "actions/setup-python@v5", wantSteps: []string{"pipx install poetry", "poetry install", "poetry run pytest"}},
+		{name: "go", workflow: Workflow{Language: "go", Install: "go mod download", Test: "go test ./..."}, wantSetup: "actions/setup-go@v5", wantSteps: []string{"go mod download", "go test ./..."}},
+		{name: "no install", workflow: Workflow{Test: "make test: all # ci"}, wantSteps: []string{"make test: all # ci"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := tt.workflow.Render()
+			require.NoError(t, err)
+
+			var parsed struct {
+				Jobs struct {
+					Test struct {
+						Steps []struct {
+							Uses string `yaml:"uses"`
+							Run  string `yaml:"run"`
+						} `yaml:"steps"`
+					} `yaml:"test"`
+				} `yaml:"jobs"`
+			}
+			require.NoError(t, yaml.Unmarshal([]byte(text), &parsed), text)
+
+			var uses, runs []string
+			for _, step := range parsed.Jobs.Test.Steps {
+				if step.Uses != "" {
+					uses = append(uses, step.Uses)
+				}
+				if step.Run != "" {
+					runs = append(runs, step.Run)
+				}
+			}
+			wantUses := []string{"actions/checkout@v4"}
+			if tt.wantSetup != "" {
+				wantUses = append(wantUses, tt.wantSetup)
+			}
+			assert.Equal(t, wantUses, uses)
+			wantRuns := make([]string, len(tt.wantSteps))
+			for i, step := range tt.wantSteps {
+				wantRuns[i] = step
+				if step != "pipx install poetry" {
+					wantRuns[i] += "\n"
+				}
+			}
+			assert.Equal(t, wantRuns, runs)
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	_, err := Workflow{Language: "go"}.Render()
+	assert.EqualError(t, err, "the workflow needs a test command")
+
+	_, err = Workflow{Test: "npm test\nrm -rf /"}.Render()
+	assert.ErrorContains(t, err, "spans several lines")
+}
@@ -0,0 +1,102 @@
+package clierror
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes returned by the CLI
+const (
+	// Failure is the exit code for errors without a specific category
+	Failure = 1
+	// Usage is the exit code for invalid flags, arguments or flag combinations
+	Usage = 2
+	// MissingDependency is the exit code when a required tool (e.g., Docker) is
+	// missing or not running
+	MissingDependency = 3
+	// Docker is the exit code when a docker compose command fails or services
+	// are unhealthy
+	Docker = 4
+	// Timeout is the exit code when a command exceeds its --timeout
+	Timeout = 5
+	// Validation is the exit code when a project file, e.g., acontext.yaml,
+	// does not conform to its schema
+	Validation = 6
+	// Interrupted is the exit code when the command was stopped by Ctrl-C or
+	// SIGTERM, following the shell convention of 128 + SIGINT
+	Interrupted = 130
+)
+
+// ExitError is an error that makes the CLI exit with a specific code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// WithCode wraps err so that the CLI exits with code when it is returned
+func WithCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// UsageError returns an error that exits with Usage
+func UsageError(format string, args ...any) error {
+	return WithCode(Usage, fmt.Errorf(format, args...))
+}
+
+// Code returns the exit code for err: 0 for nil, the code of the outermost
+// ExitError if there is one, and Failure otherwise
+func Code(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return Failure
+}
+
+// HintError is an error that carries a short remediation hint, rendered
+// after the error message: "Hint: <hint>" in text mode, and as the hint
+// field of the JSON error result
+type HintError struct {
+	Hint string
+	Err  error
+}
+
+func (e *HintError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *HintError) Unwrap() error {
+	return e.Err
+}
+
+// WithHint wraps err so that hint is shown when it is reported
+func WithHint(err error, hint string) error {
+	if err == nil {
+		return nil
+	}
+	return &HintError{Hint: hint, Err: err}
+}
+
+// Hint returns the hint of the outermost HintError in err's chain, or "" if
+// there is none
+func Hint(err error) string {
+	var hintErr *HintError
+	if errors.As(err, &hintErr) {
+		return hintErr.Hint
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package clierror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCode(t *testing.T) {
+	base := errors.New("docker is not installed")
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", err: nil, want: 0},
+		{name: "plain error", err: base, want: Failure},
+		{name: "exit error", err: WithCode(MissingDependency, base), want: MissingDependency},
+		{name: "wrapped exit error", err: fmt.Errorf("docker up: %w", WithCode(MissingDependency, base)), want: MissingDependency},
+		{name: "outermost code wins", err: WithCode(Timeout, fmt.Errorf("docker up: %w", WithCode(Docker, base))), want: Timeout},
+		{name: "usage error", err: UsageError("unknown flag: %s", "--foo"), want: Usage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Code(tt.err))
+		})
+	}
+}
+
+func TestWithCode(t *testing.T) {
+	assert.NoError(t, WithCode(MissingDependency, nil))
+
+	base := errors.New("boom")
+	err := WithCode(MissingDependency, base)
+	assert.EqualError(t, err, "boom")
+	assert.ErrorIs(t, err, base)
+}
+
+func TestHint(t *testing.T) {
+	base := errors.New("docker daemon is not running")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "plain error", err: base, want: ""},
+		{name: "hint error", err: WithHint(base, "start Docker"), want: "start Docker"},
+		{name: "wrapped hint error", err: WithCode(MissingDependency, fmt.Errorf("docker up: %w", WithHint(base, "start Docker"))), want: "start Docker"},
+		{name: "outermost hint wins", err: WithHint(fmt.Errorf("docker up: %w", WithHint(base, "start Docker")), "run docker info"), want: "run docker info"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Hint(tt.err))
+		})
+	}
+}
+
+func TestWithHint(t *testing.T) {
+	assert.NoError(t, WithHint(nil, "start Docker"))
+
+	base := errors.New("boom")
+	err := WithHint(WithCode(MissingDependency, base), "start Docker")
+	assert.EqualError(t, err, "boom")
+	assert.ErrorIs(t, err, base)
+	assert.Equal(t, MissingDependency, Code(err))
+}
@@ -0,0 +1,119 @@
+// Package color decides whether output is colored, following --color and
+// the NO_COLOR convention, and styles text with ANSI escape codes.
+package color
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/tty"
+)
+
+// Mode is the color mode selected with --color
+type Mode string
+
+const (
+	// Auto colors output when stdout is a terminal and NO_COLOR is not set (default)
+	Auto Mode = "auto"
+	// Always colors output, even when it is piped
+	Always Mode = "always"
+	// Never prints plain output
+	Never Mode = "never"
+)
+
+// NoColorEnvVar disables color in Auto mode when set to any non-empty value,
+// see https://no-color.org
+const NoColorEnvVar = "NO_COLOR"
+
+// ANSI escape codes
+const (
+	reset  = "\033[0m"
+	bold   = "\033[1m"
+	red    = "\033[31m"
+	green  = "\033[32m"
+	cyan   = "\033[36m"
+	yellow = "\033[93m"
+)
+
+var (
+	current = Auto
+	enabled = false
+)
+
+// Parse parses a --color flag value
+func Parse(value string) (Mode, error) {
+	switch Mode(value) {
+	case Auto, Always, Never:
+		return Mode(value), nil
+	}
+	return "", fmt.Errorf("invalid color mode %q (supported: auto, always, never)", value)
+}
+
+// SetMode sets the color mode. In Auto mode color is enabled if os.Stdout, as
+// it is when SetMode is called, is a terminal.
+func SetMode(mode Mode) {
+	current = mode
+	enabled = resolve(mode, os.Getenv(NoColorEnvVar) != "", tty.IsStdoutTerminal())
+}
+
+// resolve reports whether mode colors output
+func resolve(mode Mode, noColor, terminal bool) bool {
+	switch mode {
+	case Always:
+		return true
+	case Never:
+		return false
+	}
+	return !noColor && terminal
+}
+
+// CurrentMode returns the color mode set with SetMode
+func CurrentMode() Mode {
+	return current
+}
+
+// Enabled reports whether output is colored
+func Enabled() bool {
+	return enabled
+}
+
+// Bold returns text in bold when color is enabled
+func Bold(text string) string {
+	return style(text, bold)
+}
+
+// Red returns text in red when color is enabled
+func Red(text string) string {
+	return style(text, red)
+}
+
+// Green returns text in green when color is enabled
+func Green(text string) string {
+	return style(text, green)
+}
+
+// Cyan returns text in cyan when color is enabled
+func Cyan(text string) string {
+	return style(text, cyan)
+}
+
+// Yellow returns text in bright yellow when color is enabled
+func Yellow(text string) string {
+	return style(text, yellow)
+}
+
+// style wraps each line of text in code and a reset, so the style does not
+// leak into the next line when the output is paged or prefixed
+func style(text string, code string) string {
+	if !enabled || text == "" {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = code + line + reset
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,67 @@
+package color
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, value := range []string{"auto", "always", "never"} {
+		mode, err := Parse(value)
+		assert.NoError(t, err)
+		assert.Equal(t, Mode(value), mode)
+	}
+
+	_, err := Parse("yes")
+	assert.EqualError(t, err, `invalid color mode "yes" (supported: auto, always, never)`)
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     Mode
+		noColor  bool
+		terminal bool
+		expected bool
+	}{
+		{name: "auto on a terminal", mode: Auto, terminal: true, expected: true},
+		{name: "auto piped", mode: Auto},
+		{name: "auto with NO_COLOR", mode: Auto, noColor: true, terminal: true},
+		{name: "always piped", mode: Always, expected: true},
+		{name: "always overrides NO_COLOR", mode: Always, noColor: true, expected: true},
+		{name: "never on a terminal", mode: Never, terminal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, resolve(tt.mode, tt.noColor, tt.terminal))
+		})
+	}
+}
+
+func TestSetMode(t *testing.T) {
+	t.Cleanup(func() { SetMode(Auto) })
+
+	SetMode(Always)
+	assert.Equal(t, Always, CurrentMode())
+	assert.True(t, Enabled())
+	assert.Equal(t, "\033[1mACONTEXT\033[0m", Bold("ACONTEXT"))
+
+	t.Setenv(NoColorEnvVar, "1")
+	SetMode(Auto)
+	assert.False(t, Enabled())
+
+	SetMode(Never)
+	assert.Equal(t, "ACONTEXT", Cyan("ACONTEXT"))
+}
+
+func TestStyleLines(t *testing.T) {
+	t.Cleanup(func() { SetMode(Auto) })
+	SetMode(Always)
+
+	// Each line is styled on its own, empty lines are left alone
+	assert.Equal(t, "\033[36m╔══╗\033[0m\n\n\033[36m╚══╝\033[0m", Cyan("╔══╗\n\n╚══╝"))
+	assert.Equal(t, "\033[31mfailed\033[0m\n", Red("failed\n"))
+	assert.Equal(t, "", Green(""))
+}
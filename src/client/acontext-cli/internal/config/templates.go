@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	_ "embed"
 	"fmt"
 	"io"
@@ -10,12 +11,16 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/paths"
 )
 
 type TemplateConfig struct {
 	Repo        string `yaml:"repo"`
 	Path        string `yaml:"path"`
 	Description string `yaml:"description"`
+	Embedded    bool   `yaml:"embedded,omitempty"` // Shipped with the CLI, Path is relative to its built-in templates
 }
 
 type Preset struct {
@@ -89,7 +94,7 @@ func NeedsTemplateDiscovery(language string) (bool, error) {
 
 // GetPresets gets preset list for specified language
 // If presets are defined in YAML, use them; otherwise, dynamically discover from repo
-func GetPresets(language string) ([]Preset, error) {
+func GetPresets(ctx context.Context, language string) ([]Preset, error) {
 	config, err := LoadTemplatesConfig()
 	if err != nil {
 		return nil, err
@@ -100,36 +105,111 @@ func GetPresets(language string) ([]Preset, error) {
 		return presets, nil
 	}
 
-	// Otherwise, dynamically discover templates from repository
-	return discoverTemplates(config.Repo, language)
+	// Otherwise, dynamically discover templates from repository, falling back
+	// to the last discovered list when the repository cannot be reached
+	presets, err := discoverTemplates(ctx, config.Repo, language)
+	if err != nil {
+		if cached, cacheErr := loadDiscoveredPresets(language); cacheErr == nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Debug("using cached template list", "language", language, "error", err)
+			return cached, nil
+		}
+		return nil, err
+	}
+	if err := saveDiscoveredPresets(language, presets); err != nil {
+		logging.FromContext(ctx).Debug("failed to cache template list", "language", language, "error", err)
+	}
+	return presets, nil
+}
+
+// discoveredPresetsPath returns where the templates last discovered for
+// language are kept for offline use
+func discoveredPresetsPath(language string) (string, error) {
+	cacheDir, err := paths.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "discovered", language+".yaml"), nil
+}
+
+// loadDiscoveredPresets returns the templates last discovered for language
+func loadDiscoveredPresets(language string) ([]Preset, error) {
+	path, err := discoveredPresetsPath(language)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var presets []Preset
+	if err := yaml.Unmarshal(data, &presets); err != nil {
+		return nil, err
+	}
+	if len(presets) == 0 {
+		return nil, fmt.Errorf("no cached templates for language: %s", language)
+	}
+	return presets, nil
+}
+
+// saveDiscoveredPresets keeps the templates discovered for language so they
+// can be listed offline
+func saveDiscoveredPresets(language string, presets []Preset) error {
+	path, err := discoveredPresetsPath(language)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(presets)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Write atomically so concurrent runs never read a partial list
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+language+"-*.yaml")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
 }
 
-// GetLanguages gets all supported languages
+// GetLanguages gets all supported languages: those with templates, whether
+// they are discovered or listed as presets
 func GetLanguages() []string {
 	config, err := LoadTemplatesConfig()
 	if err != nil {
 		return []string{}
 	}
 
-	// If presets are defined, use them
-	if len(config.Presets) > 0 {
-		languages := make([]string, 0, len(config.Presets))
-		for lang := range config.Presets {
-			languages = append(languages, lang)
-		}
-		return languages
-	}
-
-	// Otherwise, use templates
 	languages := make([]string, 0, len(config.Templates))
 	for lang := range config.Templates {
 		languages = append(languages, lang)
 	}
+	for lang := range config.Presets {
+		if _, ok := config.Templates[lang]; !ok {
+			languages = append(languages, lang)
+		}
+	}
 	return languages
 }
 
 // discoverTemplates dynamically discovers templates from the repository
-func discoverTemplates(repo, language string) ([]Preset, error) {
+func discoverTemplates(ctx context.Context, repo, language string) ([]Preset, error) {
 	// Create temporary directory
 	tempDir, err := os.MkdirTemp("", "acontext-discover-*")
 	if err != nil {
@@ -140,7 +220,7 @@ func discoverTemplates(repo, language string) ([]Preset, error) {
 	}()
 
 	// Sparse clone repository
-	cmd := exec.Command(
+	cmd := exec.CommandContext(ctx,
 		"git", "clone",
 		"--filter=blob:none",
 		"--sparse",
@@ -151,24 +231,24 @@ func discoverTemplates(repo, language string) ([]Preset, error) {
 	)
 	cmd.Stdout = io.Discard
 	cmd.Stderr = io.Discard
-	if err := cmd.Run(); err != nil {
+	if err := logging.Run(ctx, cmd); err != nil {
 		return nil, fmt.Errorf("failed to clone repo: %w", err)
 	}
 
 	// Enable sparse-checkout for the language directory
-	cmd = exec.Command("git", "sparse-checkout", "init", "--cone")
+	cmd = exec.CommandContext(ctx, "git", "sparse-checkout", "init", "--cone")
 	cmd.Dir = tempDir
 	cmd.Stdout = io.Discard
 	cmd.Stderr = io.Discard
-	if err := cmd.Run(); err != nil {
+	if err := logging.Run(ctx, cmd); err != nil {
 		return nil, fmt.Errorf("failed to init sparse-checkout: %w", err)
 	}
 
-	cmd = exec.Command("git", "sparse-checkout", "set", language)
+	cmd = exec.CommandContext(ctx, "git", "sparse-checkout", "set", language)
 	cmd.Dir = tempDir
 	cmd.Stdout = io.Discard
 	cmd.Stderr = io.Discard
-	if err := cmd.Run(); err != nil {
+	if err := logging.Run(ctx, cmd); err != nil {
 		return nil, fmt.Errorf("failed to set sparse-checkout: %w", err)
 	}
 
@@ -189,8 +269,9 @@ func discoverTemplates(repo, language string) ([]Preset, error) {
 			}
 
 			presets = append(presets, Preset{
-				Name:     formatTemplateName(language, templateName),
-				Template: fmt.Sprintf("%s.%s", language, templateName),
+				Name:        formatTemplateName(language, templateName),
+				Description: templateSummary(filepath.Join(langDir, templateName)),
+				Template:    fmt.Sprintf("%s.%s", language, templateName),
 			})
 		}
 	}
@@ -202,6 +283,45 @@ func discoverTemplates(repo, language string) ([]Preset, error) {
 	return presets, nil
 }
 
+// maxSummaryLength is the longest template summary shown in the picker
+const maxSummaryLength = 80
+
+// templateSummary returns a one-line summary of the template in dir, taken from
+// the description in its acontext.template.yaml manifest or the first line of
+// prose in its README.md
+func templateSummary(dir string) string {
+	var summary string
+	if data, err := os.ReadFile(filepath.Join(dir, "acontext.template.yaml")); err == nil {
+		var manifest struct {
+			Description string `yaml:"description"`
+		}
+		if yaml.Unmarshal(data, &manifest) == nil {
+			summary = manifest.Description
+		}
+	}
+
+	if summary == "" {
+		if data, err := os.ReadFile(filepath.Join(dir, "README.md")); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				line = strings.TrimSpace(line)
+				// Skip headings, badges, images and HTML
+				if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[!") ||
+					strings.HasPrefix(line, "![") || strings.HasPrefix(line, "<") {
+					continue
+				}
+				summary = line
+				break
+			}
+		}
+	}
+
+	summary = strings.Join(strings.Fields(summary), " ")
+	if len(summary) > maxSummaryLength {
+		summary = strings.TrimSpace(summary[:maxSummaryLength-3]) + "..."
+	}
+	return summary
+}
+
 // formatTemplateName formats template name for display
 func formatTemplateName(language, templateName string) string {
 	// Capitalize first letter and replace hyphens/underscores with spaces
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLoadTemplatesConfig(t *testing.T) {
@@ -19,6 +23,18 @@ func TestGetLanguages(t *testing.T) {
 	assert.NotEmpty(t, languages)
 	assert.Contains(t, languages, "python")
 	assert.Contains(t, languages, "typescript")
+	assert.Contains(t, languages, "go")
+}
+
+func TestEmbeddedGoTemplate(t *testing.T) {
+	tmpl, err := GetTemplate("go", "basic")
+	require.NoError(t, err)
+	assert.True(t, tmpl.Embedded)
+	assert.Equal(t, "go/basic", tmpl.Path)
+
+	needsDiscovery, err := NeedsTemplateDiscovery("go")
+	assert.NoError(t, err)
+	assert.False(t, needsDiscovery, "go templates are listed as presets")
 }
 
 func TestNeedsTemplateDiscovery(t *testing.T) {
@@ -72,3 +88,61 @@ func TestFormatTemplateName(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			name:     "manifest description",
+			files:    map[string]string{"acontext.template.yaml": "name: openai\ndescription: Chat agent using the OpenAI SDK\n", "README.md": "Ignored"},
+			expected: "Chat agent using the OpenAI SDK",
+		},
+		{
+			name:     "readme prose after heading and badge",
+			files:    map[string]string{"README.md": "# OpenAI\n\n![badge](x.svg)\n\nA minimal   OpenAI agent.\nMore text.\n"},
+			expected: "A minimal OpenAI agent.",
+		},
+		{
+			name:     "long summary is truncated",
+			files:    map[string]string{"README.md": strings.Repeat("word ", 30)},
+			expected: strings.TrimSpace(strings.Repeat("word ", 30)[:maxSummaryLength-3]) + "...",
+		},
+		{
+			name:     "no files",
+			files:    map[string]string{},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+			}
+			summary := templateSummary(dir)
+			assert.Equal(t, tt.expected, summary)
+			assert.LessOrEqual(t, len(summary), maxSummaryLength)
+		})
+	}
+}
+
+func TestDiscoveredPresetsRoundTrip(t *testing.T) {
+	t.Setenv("ACONTEXT_HOME", t.TempDir())
+
+	_, err := loadDiscoveredPresets("python")
+	assert.Error(t, err)
+
+	presets := []Preset{
+		{Name: "Openai", Description: "OpenAI starter", Template: "python.openai"},
+		{Name: "Anthropic", Template: "python.anthropic"},
+	}
+	require.NoError(t, saveDiscoveredPresets("python", presets))
+
+	loaded, err := loadDiscoveredPresets("python")
+	require.NoError(t, err)
+	assert.Equal(t, presets, loaded)
+}
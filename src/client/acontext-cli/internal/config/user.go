@@ -0,0 +1,303 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/license"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/paths"
+	"gopkg.in/yaml.v3"
+)
+
+// Setting describes a known user config key
+type Setting struct {
+	Key         string
+	Description string
+	Validate    func(value string) error
+}
+
+// KnownSettings lists all keys accepted by `acontext config set`
+var KnownSettings = []Setting{
+	{Key: "telemetry.enabled", Description: "Send anonymous usage telemetry (true/false)", Validate: validateBool},
+	{Key: "telemetry.endpoint", Description: "URL telemetry is sent to, e.g., an internal collector (https unless telemetry.insecure is set)", Validate: validateURL},
+	{Key: "telemetry.insecure", Description: "Allow a plaintext http telemetry.endpoint (true/false)", Validate: validateBool},
+	{Key: "telemetry.timeout", Description: "How long sending a telemetry event may take (default 5s)", Validate: validatePositiveDuration},
+	{Key: "telemetry.sample_rate", Description: "Fraction of successful commands whose telemetry is sent, from 0 to 1 (default 1); failures are always sent", Validate: validateSampleRate},
+	{Key: "telemetry.notice_shown", Description: "Whether the telemetry notice has been shown (set automatically)", Validate: validateBool},
+	{Key: "create.template", Description: "Default template path for create (e.g., python/openai)"},
+	{Key: "create.template_search_path", Description: "Directories of local templates create --template can name, separated like PATH (e.g., /srv/templates:/home/me/templates)"},
+	{Key: "create.author", Description: "Default author name for new projects"},
+	{Key: "create.license", Description: "Default SPDX license of new projects, written to LICENSE (e.g., MIT, none for no LICENSE)", Validate: validateLicense},
+	{Key: "create.git_branch", Description: "Default initial Git branch of new projects (default main)"},
+	{Key: "create.install", Description: "Install dependencies after create by default (true/false)", Validate: validateBool},
+	{Key: "create.editor", Description: "Editor command create --open runs on the project (e.g., code or \"subl --wait\"; defaults to $VISUAL, $EDITOR or code)"},
+	{Key: "deploy.target", Description: "Deploy target used by acontext deploy (default: local)"},
+	{Key: "deploy.endpoint", Description: "Remote address of the deploy target"},
+	{Key: "docker.profiles", Description: "Compose profiles activated by docker commands by default (comma-separated, e.g., dev,observability)"},
+	{Key: "docker.env_files", Description: "Env files passed to docker compose by default (comma-separated, relative to the project directory)"},
+	{Key: "docker.detach", Description: "Run docker up in detached mode by default (true/false)", Validate: validateBool},
+	{Key: "docker.stop_timeout", Description: "Seconds docker down gives each container to stop before killing it (default 10)", Validate: validateSeconds},
+	{Key: "update.notice", Description: "Show a notice under the logo banner when the last release check found a newer version (true/false, default true)", Validate: validateBool},
+}
+
+// UserConfig holds persistent CLI settings stored in ~/.config/acontext/config.yaml
+// (or $ACONTEXT_HOME/config.yaml).
+// The zero value is an empty in-memory config that cannot be saved.
+type UserConfig struct {
+	path   string
+	values map[string]map[string]string
+}
+
+// userConfigPathOverride is the config file set with --config, if any
+var userConfigPathOverride string
+
+// SetUserConfigPath makes path the user config file for the rest of the
+// invocation, instead of the default location
+func SetUserConfigPath(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid config path %s: %w", path, err)
+	}
+	userConfigPathOverride = absPath
+	return nil
+}
+
+// UserConfigPath returns the user config file path: the one set with
+// SetUserConfigPath, or the default one, which is under ACONTEXT_HOME when it is set
+func UserConfigPath() (string, error) {
+	if userConfigPathOverride != "" {
+		return userConfigPathOverride, nil
+	}
+	configDir, err := paths.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "config.yaml"), nil
+}
+
+// LoadUserConfig loads the user config from UserConfigPath
+func LoadUserConfig() (*UserConfig, error) {
+	path, err := UserConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return LoadUserConfigFile(path)
+}
+
+// LoadUserConfigFile loads the user config from path. A missing file yields an empty config.
+func LoadUserConfigFile(path string) (*UserConfig, error) {
+	cfg := &UserConfig{
+		path:   path,
+		values: map[string]map[string]string{},
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &cfg.values); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if cfg.values == nil {
+		cfg.values = map[string]map[string]string{}
+	}
+
+	return cfg, nil
+}
+
+// Path returns the file path backing the config
+func (c *UserConfig) Path() string {
+	return c.path
+}
+
+// Get returns the value for key and whether it is set
+func (c *UserConfig) Get(key string) (string, bool) {
+	section, name, ok := splitKey(key)
+	if !ok {
+		return "", false
+	}
+	value, ok := c.values[section][name]
+	return value, ok
+}
+
+// GetBool returns the boolean value for key, or def if it is unset or invalid
+func (c *UserConfig) GetBool(key string, def bool) bool {
+	value, ok := c.Get(key)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// Set validates and sets the value for a known key
+func (c *UserConfig) Set(key, value string) error {
+	setting, err := LookupSetting(key)
+	if err != nil {
+		return err
+	}
+	if setting.Validate != nil {
+		if err := setting.Validate(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+	}
+
+	section, name, _ := splitKey(key)
+	if c.values == nil {
+		c.values = map[string]map[string]string{}
+	}
+	if c.values[section] == nil {
+		c.values[section] = map[string]string{}
+	}
+	c.values[section][name] = value
+	return nil
+}
+
+// Unset removes key from the config
+func (c *UserConfig) Unset(key string) error {
+	if _, err := LookupSetting(key); err != nil {
+		return err
+	}
+
+	section, name, _ := splitKey(key)
+	delete(c.values[section], name)
+	if len(c.values[section]) == 0 {
+		delete(c.values, section)
+	}
+	return nil
+}
+
+// Values returns all set keys and values, flattened to dotted keys
+func (c *UserConfig) Values() map[string]string {
+	values := make(map[string]string)
+	for section, entries := range c.values {
+		for name, value := range entries {
+			values[section+"."+name] = value
+		}
+	}
+	return values
+}
+
+// Keys returns all set keys, sorted
+func (c *UserConfig) Keys() []string {
+	values := c.Values()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Save writes the config atomically by writing to a temp file and renaming it
+func (c *UserConfig) Save() error {
+	if c.path == "" {
+		return fmt.Errorf("config has no file path")
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(c.values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(c.path), ".config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to create temp config file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer func() {
+		_ = os.Remove(tmpPath) // No-op after a successful rename
+	}()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to write temp config file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write temp config file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		return fmt.Errorf("failed to save config file: %w", err)
+	}
+	return nil
+}
+
+// LookupSetting returns the known setting for key
+func LookupSetting(key string) (*Setting, error) {
+	for i := range KnownSettings {
+		if KnownSettings[i].Key == key {
+			return &KnownSettings[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown config key: %s (run 'acontext config list --all' to see available keys)", key)
+}
+
+// splitKey splits a dotted key (e.g., "create.template") into section and name
+func splitKey(key string) (string, string, bool) {
+	section, name, ok := strings.Cut(key, ".")
+	if !ok || section == "" || name == "" {
+		return "", "", false
+	}
+	return section, name, true
+}
+
+func validateURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("expected an http(s) URL, got %q", value)
+	}
+	return nil
+}
+
+func validatePositiveDuration(value string) error {
+	if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+		return fmt.Errorf("expected a positive duration (e.g., 5s), got %q", value)
+	}
+	return nil
+}
+
+func validateSeconds(value string) error {
+	if seconds, err := strconv.Atoi(value); err != nil || seconds < 0 {
+		return fmt.Errorf("expected a number of seconds (e.g., 30), got %q", value)
+	}
+	return nil
+}
+
+func validateSampleRate(value string) error {
+	if rate, err := strconv.ParseFloat(value, 64); err != nil || rate < 0 || rate > 1 {
+		return fmt.Errorf("expected a number from 0 to 1 (e.g., 0.1), got %q", value)
+	}
+	return nil
+}
+
+func validateLicense(value string) error {
+	if strings.EqualFold(value, license.None) {
+		return nil
+	}
+	_, err := license.Lookup(value)
+	return err
+}
+
+func validateBool(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("expected true or false, got %q", value)
+	}
+	return nil
+}
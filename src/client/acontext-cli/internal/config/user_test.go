@@ -0,0 +1,176 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserConfigSetGetUnset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acontext", "config.yaml")
+
+	cfg, err := LoadUserConfigFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Keys())
+
+	require.NoError(t, cfg.Set("create.template", "python/openai"))
+	require.NoError(t, cfg.Set("telemetry.enabled", "false"))
+	require.NoError(t, cfg.Save())
+
+	loaded, err := LoadUserConfigFile(path)
+	require.NoError(t, err)
+	value, ok := loaded.Get("create.template")
+	assert.True(t, ok)
+	assert.Equal(t, "python/openai", value)
+	assert.False(t, loaded.GetBool("telemetry.enabled", true))
+	assert.Equal(t, []string{"create.template", "telemetry.enabled"}, loaded.Keys())
+
+	require.NoError(t, loaded.Unset("create.template"))
+	require.NoError(t, loaded.Save())
+
+	loaded, err = LoadUserConfigFile(path)
+	require.NoError(t, err)
+	_, ok = loaded.Get("create.template")
+	assert.False(t, ok)
+
+	// No temp files should be left behind by atomic saves
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestUserConfigSetValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{
+			name:  "known string key",
+			key:   "create.author",
+			value: "Jane Doe",
+		},
+		{
+			name:  "known bool key",
+			key:   "docker.detach",
+			value: "true",
+		},
+		{
+			name:    "unknown key",
+			key:     "create.tempalte",
+			value:   "python/openai",
+			wantErr: true,
+		},
+		{
+			name:  "license",
+			key:   "create.license",
+			value: "Apache-2.0",
+		},
+		{
+			name:    "unknown license",
+			key:     "create.license",
+			value:   "MTI",
+			wantErr: true,
+		},
+		{
+			name:    "invalid bool value",
+			key:     "telemetry.enabled",
+			value:   "maybe",
+			wantErr: true,
+		},
+		{
+			name:  "telemetry endpoint",
+			key:   "telemetry.endpoint",
+			value: "https://collector.internal/v1/events",
+		},
+		{
+			name:    "telemetry endpoint without scheme",
+			key:     "telemetry.endpoint",
+			value:   "collector.internal/v1/events",
+			wantErr: true,
+		},
+		{
+			name:    "non-positive telemetry timeout",
+			key:     "telemetry.timeout",
+			value:   "0s",
+			wantErr: true,
+		},
+		{
+			name:  "stop timeout",
+			key:   "docker.stop_timeout",
+			value: "0",
+		},
+		{
+			name:    "negative stop timeout",
+			key:     "docker.stop_timeout",
+			value:   "-5",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadUserConfigFile(filepath.Join(t.TempDir(), "config.yaml"))
+			require.NoError(t, err)
+
+			err = cfg.Set(tt.key, tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Empty(t, cfg.Keys())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoadUserConfigFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("not: [valid"), 0644))
+
+	_, err := LoadUserConfigFile(path)
+	assert.Error(t, err)
+}
+
+func TestZeroUserConfig(t *testing.T) {
+	var cfg UserConfig
+	_, ok := cfg.Get("create.template")
+	assert.False(t, ok)
+	assert.True(t, cfg.GetBool("telemetry.enabled", true))
+}
+
+func TestZeroUserConfigSet(t *testing.T) {
+	var cfg UserConfig
+	require.NoError(t, cfg.Set("telemetry.notice_shown", "true"))
+	assert.True(t, cfg.GetBool("telemetry.notice_shown", false))
+	assert.Error(t, cfg.Save())
+}
+
+func TestSetUserConfigPath(t *testing.T) {
+	t.Cleanup(func() { userConfigPathOverride = "" })
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, SetUserConfigPath("ci.yaml"))
+
+	path, err := UserConfigPath()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "ci.yaml"), path)
+
+	// A missing explicit file is empty, and saving writes back to it
+	cfg, err := LoadUserConfig()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Keys())
+	require.NoError(t, cfg.Set("create.author", "CI"))
+	require.NoError(t, cfg.Save())
+
+	reloaded, err := LoadUserConfigFile(filepath.Join(dir, "ci.yaml"))
+	require.NoError(t, err)
+	value, ok := reloaded.Get("create.author")
+	assert.True(t, ok)
+	assert.Equal(t, "CI", value)
+}
@@ -0,0 +1,341 @@
+package deploy
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/template"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFile is the file that marks a directory as a deployable Acontext project
+const ProjectFile = "acontext.yaml"
+
+// ArtifactDir is where artifacts are written by default, relative to the project
+const ArtifactDir = ".acontext/artifacts"
+
+// DefaultTransport is the transport used when deploy.target is not set
+const DefaultTransport = "local"
+
+// excludedNames are files and directories never packaged: VCS metadata,
+// installed dependencies, caches, backups, previous artifacts and secrets
+var excludedNames = map[string]bool{
+	".git":             true,
+	".acontext":        true,
+	template.BackupDir: true,
+	"node_modules":     true,
+	".venv":            true,
+	"venv":             true,
+	"__pycache__":      true,
+	".env":             true,
+}
+
+// Project is a project's acontext.yaml
+type Project struct {
+	Name     string           `yaml:"name"`               // Defaults to the project directory name
+	License  string           `yaml:"license,omitempty"`  // SPDX identifier of the project's license, if any
+	Template *TemplateVersion `yaml:"template,omitempty"` // Remote template the project was created from, if recorded
+}
+
+// TemplateVersion records which version of a remote template a project was
+// created from
+type TemplateVersion struct {
+	URL    string `yaml:"url"`           // Template URL, without the ref
+	Ref    string `yaml:"ref,omitempty"` // Branch, tag or commit that was asked for, empty for the default branch
+	Commit string `yaml:"commit"`        // Commit the ref resolved to
+}
+
+// LoadProject loads acontext.yaml from the project directory
+func LoadProject(dir string) (*Project, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ProjectFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s not found in %s: run acontext deploy from the root of an Acontext project", ProjectFile, dir)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", ProjectFile, err)
+	}
+
+	var project Project
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ProjectFile, err)
+	}
+	if project.Name == "" {
+		project.Name = filepath.Base(dir)
+	}
+	return &project, nil
+}
+
+// InitProject writes an acontext.yaml for project into dir, unless there
+// already is one (e.g., shipped by the template), in which case only the
+// license and template version are added to it, if it does not record them
+// already
+func InitProject(dir string, project *Project) error {
+	path := filepath.Join(dir, ProjectFile)
+	if existing, err := os.ReadFile(path); err == nil {
+		return appendMissing(path, existing, project)
+	}
+	data, err := yaml.Marshal(project)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", ProjectFile, err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ProjectFile, err)
+	}
+	return nil
+}
+
+// appendMissing adds the license and template version of project under the
+// license and template keys at the end of the acontext.yaml at path, whose
+// content is existing, keeping the rest of the file as it is. Keys the file
+// already has are left alone, and so are files that are not a mapping.
+func appendMissing(path string, existing []byte, project *Project) error {
+	var fields map[string]any
+	if err := yaml.Unmarshal(existing, &fields); err != nil {
+		return nil
+	}
+	var missing struct {
+		License  string           `yaml:"license,omitempty"`
+		Template *TemplateVersion `yaml:"template,omitempty"`
+	}
+	if _, ok := fields["license"]; !ok {
+		missing.License = project.License
+	}
+	if _, ok := fields["template"]; !ok {
+		missing.Template = project.Template
+	}
+	if missing.License == "" && missing.Template == nil {
+		return nil
+	}
+	data, err := yaml.Marshal(missing)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", ProjectFile, err)
+	}
+	if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+		data = append([]byte("\n"), data...)
+	}
+	if err := os.WriteFile(path, append(existing, data...), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ProjectFile, err)
+	}
+	return nil
+}
+
+// SetTemplateVersion records version under the template key of the
+// acontext.yaml in dir, e.g., once the project is upgraded to a newer version
+// of its template, replacing the version recorded so far. Comments are kept,
+// but the file is re-indented. A missing acontext.yaml, or one that is not a
+// mapping, is left alone.
+func SetTemplateVersion(dir string, version *TemplateVersion) error {
+	path := filepath.Join(dir, ProjectFile)
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", ProjectFile, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(existing, &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	fields := doc.Content[0]
+	index := -1
+	for i := 0; i+1 < len(fields.Content); i += 2 {
+		if fields.Content[i].Value == "template" {
+			index = i + 1
+		}
+	}
+	if index < 0 {
+		return appendMissing(path, existing, &Project{Template: version})
+	}
+	var value yaml.Node
+	if err := value.Encode(version); err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", ProjectFile, err)
+	}
+	fields.Content[index] = &value
+	data, err := yaml.Marshal(&doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", ProjectFile, err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ProjectFile, err)
+	}
+	return nil
+}
+
+// Target is where a project is deployed, from the deploy.* settings
+type Target struct {
+	Transport string `json:"transport"`
+	Endpoint  string `json:"endpoint,omitempty"`
+}
+
+// Transport delivers a packaged artifact to a target
+type Transport interface {
+	// Push delivers the artifact and returns the next steps to show the user
+	Push(ctx context.Context, artifact string, target Target) ([]string, error)
+}
+
+var transports = map[string]Transport{
+	DefaultTransport: localTransport{},
+}
+
+// Register makes a transport available as a deploy.target value
+func Register(name string, transport Transport) {
+	transports[name] = transport
+}
+
+// Lookup returns the transport registered under name
+func Lookup(name string) (Transport, error) {
+	transport, ok := transports[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown deploy target %q (available: %s)", name, strings.Join(Transports(), ", "))
+	}
+	return transport, nil
+}
+
+// Transports returns the names of the registered transports, sorted
+func Transports() []string {
+	names := make([]string, 0, len(transports))
+	for name := range transports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// localTransport leaves the artifact on disk for the user to ship
+type localTransport struct{}
+
+func (localTransport) Push(ctx context.Context, artifact string, target Target) ([]string, error) {
+	return []string{
+		fmt.Sprintf("Copy %s to your environment", artifact),
+		fmt.Sprintf("Extract it with: tar -xzf %s", filepath.Base(artifact)),
+		"Provide the .env configuration there, it is not included in the artifact",
+	}, nil
+}
+
+// ArtifactName returns the file name of an artifact for project built at t
+func ArtifactName(project *Project, t time.Time) string {
+	return fmt.Sprintf("%s-%s.tar.gz", project.Name, t.UTC().Format("20060102-150405"))
+}
+
+// Files returns the files that are packaged from dir, relative to it and
+// sorted, skipping excludedNames
+func Files(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if excludedNames[d.Name()] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() || d.Type()&fs.ModeSymlink != 0 {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project files: %w", err)
+	}
+	return files, nil
+}
+
+// Package writes files from dir to a gzipped tarball at path, under a
+// top-level directory named after the project. The artifact is written to a
+// temporary file first so a failure never leaves a partial one behind.
+func Package(dir string, project *Project, files []string, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".artifact-*.tar.gz")
+	if err != nil {
+		return fmt.Errorf("failed to create artifact: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer func() {
+		_ = os.Remove(tmpPath) // No-op after a successful rename
+	}()
+
+	gz := gzip.NewWriter(tmpFile)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		if err := addFile(tw, dir, project.Name, file); err != nil {
+			_ = tmpFile.Close()
+			return fmt.Errorf("failed to package %s: %w", file, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+	return nil
+}
+
+// addFile adds the file at rel (slash-separated) under dir to tw
+func addFile(tw *tar.Writer, dir, prefix, rel string) error {
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	link := ""
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = prefix + "/" + rel
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	_, err = io.Copy(tw, f)
+	return err
+}
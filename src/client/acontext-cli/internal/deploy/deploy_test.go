@@ -0,0 +1,267 @@
+package deploy
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestLoadProject(t *testing.T) {
+	t.Run("missing", func(t *testing.T) {
+		_, err := LoadProject(t.TempDir())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "acontext.yaml not found")
+	})
+
+	t.Run("name defaults to directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "my-app")
+		writeFiles(t, dir, map[string]string{ProjectFile: "{}\n"})
+		project, err := LoadProject(dir)
+		require.NoError(t, err)
+		assert.Equal(t, "my-app", project.Name)
+	})
+
+	t.Run("explicit name", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{ProjectFile: "name: chat-bot\n"})
+		project, err := LoadProject(dir)
+		require.NoError(t, err)
+		assert.Equal(t, "chat-bot", project.Name)
+	})
+}
+
+func TestInitProject(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, InitProject(dir, &Project{Name: "my-app"}))
+	project, err := LoadProject(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "my-app", project.Name)
+
+	// An existing file is kept
+	writeFiles(t, dir, map[string]string{ProjectFile: "name: from-template\n"})
+	require.NoError(t, InitProject(dir, &Project{Name: "my-app"}))
+	project, err = LoadProject(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "from-template", project.Name)
+}
+
+func TestFilesSkipsExcluded(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		ProjectFile:                  "name: my-app\n",
+		"main.py":                    "print('hi')\n",
+		"src/app/agent.py":           "",
+		".env":                       "OPENAI_API_KEY=secret\n",
+		".git/HEAD":                  "ref: refs/heads/main\n",
+		"node_modules/pkg/index.js":  "",
+		".acontext/artifacts/old.gz": "",
+		"src/__pycache__/agent.pyc":  "",
+	})
+
+	files, err := Files(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ProjectFile, "main.py", "src/app/agent.py"}, files)
+}
+
+func TestPackage(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		ProjectFile: "name: my-app\n",
+		"main.py":   "print('hi')\n",
+	})
+	project := &Project{Name: "my-app"}
+	files, err := Files(dir)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, ArtifactDir, ArtifactName(project, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)))
+	require.NoError(t, Package(dir, project, files, path))
+	assert.Equal(t, "my-app-20260102-030405.tar.gz", filepath.Base(path))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	contents := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		contents[header.Name] = string(data)
+	}
+	assert.Equal(t, map[string]string{
+		"my-app/acontext.yaml": "name: my-app\n",
+		"my-app/main.py":       "print('hi')\n",
+	}, contents)
+
+	// Packaging again skips the previous artifact
+	files, err = Files(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ProjectFile, "main.py"}, files)
+}
+
+type recordingTransport struct {
+	artifact string
+}
+
+func (r *recordingTransport) Push(ctx context.Context, artifact string, target Target) ([]string, error) {
+	r.artifact = artifact
+	return nil, nil
+}
+
+func TestLookup(t *testing.T) {
+	_, err := Lookup("s3")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown deploy target "s3" (available: local)`)
+
+	recorder := &recordingTransport{}
+	Register("recording", recorder)
+	t.Cleanup(func() { delete(transports, "recording") })
+
+	transport, err := Lookup("recording")
+	require.NoError(t, err)
+	_, err = transport.Push(context.Background(), "my-app.tar.gz", Target{Transport: "recording"})
+	require.NoError(t, err)
+	assert.Equal(t, "my-app.tar.gz", recorder.artifact)
+}
+
+func TestInitProjectTemplateVersion(t *testing.T) {
+	version := &TemplateVersion{URL: "git+https://github.com/myorg/template.git", Ref: "v1.2.0", Commit: "3f2a9c1d0b7e"}
+
+	dir := t.TempDir()
+	require.NoError(t, InitProject(dir, &Project{Name: "my-app", Template: version}))
+	project, err := LoadProject(dir)
+	require.NoError(t, err)
+	assert.Equal(t, version, project.Template)
+
+	tests := []struct {
+		name     string
+		existing string
+		expected string
+	}{
+		{
+			name:     "added to the template's file",
+			existing: "# Shipped by the template\nname: from-template",
+			expected: "# Shipped by the template\nname: from-template\ntemplate:\n    url: git+https://github.com/myorg/template.git\n    ref: v1.2.0\n    commit: 3f2a9c1d0b7e\n",
+		},
+		{
+			name:     "already recorded",
+			existing: "name: from-template\ntemplate:\n  url: git+https://github.com/myorg/other.git\n",
+			expected: "name: from-template\ntemplate:\n  url: git+https://github.com/myorg/other.git\n",
+		},
+		{
+			name:     "not a mapping",
+			existing: "- name: from-template\n",
+			expected: "- name: from-template\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{ProjectFile: tt.existing})
+			require.NoError(t, InitProject(dir, &Project{Name: "my-app", Template: version}))
+			data, err := os.ReadFile(filepath.Join(dir, ProjectFile))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(data))
+		})
+	}
+}
+
+func TestInitProjectLicense(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, InitProject(dir, &Project{Name: "my-app", License: "MIT"}))
+	project, err := LoadProject(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "MIT", project.License)
+
+	tests := []struct {
+		name     string
+		existing string
+		expected string
+	}{
+		{
+			name:     "added to the template's file",
+			existing: "name: from-template\n",
+			expected: "name: from-template\nlicense: MIT\n",
+		},
+		{
+			name:     "already recorded",
+			existing: "name: from-template\nlicense: Apache-2.0\n",
+			expected: "name: from-template\nlicense: Apache-2.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{ProjectFile: tt.existing})
+			require.NoError(t, InitProject(dir, &Project{Name: "my-app", License: "MIT"}))
+			data, err := os.ReadFile(filepath.Join(dir, ProjectFile))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(data))
+		})
+	}
+}
+
+func TestSetTemplateVersion(t *testing.T) {
+	version := &TemplateVersion{URL: "git+https://github.com/myorg/template.git", Ref: "v2.0.0", Commit: "9b8c7d6e5f4a"}
+
+	tests := []struct {
+		name     string
+		existing string
+		expected string
+	}{
+		{
+			name:     "replaces the recorded version",
+			existing: "# Project settings\nname: my-app\ntemplate:\n    url: git+https://github.com/myorg/template.git\n    ref: v1.2.0\n    commit: 3f2a9c1d0b7e\ndeploy: {}\n",
+			expected: "# Project settings\nname: my-app\ntemplate:\n    url: git+https://github.com/myorg/template.git\n    ref: v2.0.0\n    commit: 9b8c7d6e5f4a\ndeploy: {}\n",
+		},
+		{
+			name:     "adds a missing version",
+			existing: "name: my-app\n",
+			expected: "name: my-app\ntemplate:\n    url: git+https://github.com/myorg/template.git\n    ref: v2.0.0\n    commit: 9b8c7d6e5f4a\n",
+		},
+		{
+			name:     "not a mapping",
+			existing: "- name: my-app\n",
+			expected: "- name: my-app\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{ProjectFile: tt.existing})
+			require.NoError(t, SetTemplateVersion(dir, version))
+			data, err := os.ReadFile(filepath.Join(dir, ProjectFile))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(data))
+		})
+	}
+
+	assert.NoError(t, SetTemplateVersion(t.TempDir(), version), "a project without acontext.yaml is left alone")
+}
@@ -0,0 +1,68 @@
+package deploy
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/schema"
+)
+
+// ProjectSchema is the JSON schema acontext.yaml must conform to
+//
+//go:embed acontext.schema.json
+var ProjectSchema []byte
+
+// projectSchema parses ProjectSchema once
+var projectSchema = sync.OnceValues(func() (*schema.Schema, error) {
+	return schema.Parse(ProjectSchema)
+})
+
+// ValidationError is returned by ValidateProjectFile when a file does not
+// conform to the project schema
+type ValidationError struct {
+	File       string // As passed to ValidateProjectFile
+	Violations []schema.Violation
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Violations) == 1 {
+		return fmt.Sprintf("%s is invalid: %s", e.File, e.Locate(e.Violations[0]))
+	}
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = "  " + e.Locate(v)
+	}
+	return fmt.Sprintf("%s has %d schema errors:\n%s", e.File, len(e.Violations), strings.Join(lines, "\n"))
+}
+
+// Locate prefixes v with its position in the file, e.g., acontext.yaml:3:5
+func (e *ValidationError) Locate(v schema.Violation) string {
+	switch {
+	case v.Line == 0:
+		return fmt.Sprintf("%s: %s", e.File, v)
+	case v.Column == 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, v.Line, v)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, v.Line, v.Column, v)
+}
+
+// ValidateProjectFile validates the acontext.yaml at path against
+// ProjectSchema, returning a *ValidationError listing where it does not
+// conform. Reading errors, such as a missing file, are returned as is.
+func ValidateProjectFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	s, err := projectSchema()
+	if err != nil {
+		return err
+	}
+	if violations := s.Validate(data); len(violations) > 0 {
+		return &ValidationError{File: path, Violations: violations}
+	}
+	return nil
+}
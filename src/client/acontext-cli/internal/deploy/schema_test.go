@@ -0,0 +1,79 @@
+package deploy
+
+import (
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateProjectFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []schema.Violation
+	}{
+		{name: "empty", content: ""},
+		{name: "name only", content: "name: my-app\n"},
+		{
+			name:    "created from a template",
+			content: "name: my-app\nlicense: MIT\ntemplate:\n  url: git+https://github.com/myorg/template.git\n  ref: v1.2.0\n  commit: 3f2a9c1d0b7e\n",
+		},
+		{
+			name:    "invalid name",
+			content: "name: My App\n",
+			want:    []schema.Violation{{Path: "name", Line: 1, Column: 7, Message: `"My App" does not match the pattern ^[a-z0-9][a-z0-9_-]*$`}},
+		},
+		{
+			name:    "unknown key",
+			content: "name: my-app\nlicence: MIT\n",
+			want:    []schema.Violation{{Path: "licence", Line: 2, Column: 1, Message: "unknown property (known: license, name, template)"}},
+		},
+		{
+			name:    "incomplete template",
+			content: "template:\n  url: https://github.com/myorg/template.git\n  ref: main\n",
+			want: []schema.Violation{
+				{Path: "template", Line: 2, Column: 3, Message: "missing required property commit"},
+				{Path: "template.url", Line: 2, Column: 8, Message: `"https://github.com/myorg/template.git" does not match the pattern ^(git\+[a-z]+://|git\+git@|file://)`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{ProjectFile: tt.content})
+			path := filepath.Join(dir, ProjectFile)
+			err := ValidateProjectFile(path)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, path, validationErr.File)
+			assert.Equal(t, tt.want, validationErr.Violations)
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		err := ValidateProjectFile(filepath.Join(t.TempDir(), ProjectFile))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	one := &ValidationError{File: "acontext.yaml", Violations: []schema.Violation{
+		{Path: "name", Line: 1, Column: 7, Message: "expected a string, got an integer"},
+	}}
+	assert.Equal(t, "acontext.yaml is invalid: acontext.yaml:1:7: name: expected a string, got an integer", one.Error())
+
+	many := &ValidationError{File: "acontext.yaml", Violations: []schema.Violation{
+		{Path: "name", Line: 1, Column: 7, Message: "expected a string, got an integer"},
+		{Line: 3, Message: "invalid YAML: did not find expected key"},
+	}}
+	assert.Equal(t, "acontext.yaml has 2 schema errors:\n  acontext.yaml:1:7: name: expected a string, got an integer\n  acontext.yaml:3: invalid YAML: did not find expected key", many.Error())
+}
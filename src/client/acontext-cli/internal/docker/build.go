@@ -0,0 +1,107 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BuildOptions controls how Build builds the images of services
+type BuildOptions struct {
+	BuildArgs []string // KEY=VALUE build arguments, passed on as --build-arg
+	NoCache   bool     // Do not use the build cache
+	Pull      bool     // Always pull newer versions of the base images
+}
+
+// BuildResult is the outcome of building the image of one service
+type BuildResult struct {
+	Service  string `json:"service"`
+	Built    bool   `json:"built"`
+	Duration int64  `json:"duration_ms"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ValidateBuildArg checks that arg is a KEY=VALUE build argument. A bare KEY
+// is also accepted, which compose takes the value of from the environment.
+func ValidateBuildArg(arg string) error {
+	key, _, _ := strings.Cut(arg, "=")
+	if key == "" || strings.ContainsAny(key, " \t\n") {
+		return fmt.Errorf("invalid build argument %q: expected KEY=VALUE", arg)
+	}
+	return nil
+}
+
+// BuildableServices returns the services with a build section in the
+// resolved compose configuration, sorted. When services are given, only
+// those are returned, and naming one that does not exist or has no build
+// section is an error.
+func BuildableServices(ctx context.Context, projectDir string, composeFile string, services []string) ([]string, error) {
+	output, err := composeOutput(ctx, projectDir, composeFile, configArgs(true)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve compose configuration: %w", err)
+	}
+	var config struct {
+		Services map[string]composeService `json:"services"`
+	}
+	if err := json.Unmarshal(output, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse compose configuration: %w", err)
+	}
+	return buildableServices(config.Services, services)
+}
+
+// buildableServices picks the services of all BuildableServices returns
+func buildableServices(all map[string]composeService, services []string) ([]string, error) {
+	buildable := []string{}
+	if len(services) == 0 {
+		for name, service := range all {
+			if service.Build != nil {
+				buildable = append(buildable, name)
+			}
+		}
+		sort.Strings(buildable)
+		return buildable, nil
+	}
+
+	for _, name := range services {
+		service, ok := all[name]
+		if !ok {
+			return nil, fmt.Errorf("no such service: %s", name)
+		}
+		if service.Build == nil {
+			return nil, fmt.Errorf("service %s has no build section, it runs the image %s", name, service.Image)
+		}
+		buildable = append(buildable, name)
+	}
+	return buildable, nil
+}
+
+// Build builds the image of service, streaming compose's build output. It
+// builds a single service so that callers building several can report the
+// result of each one.
+func Build(ctx context.Context, projectDir string, composeFile string, service string, opts BuildOptions) BuildResult {
+	start := time.Now()
+	err := RunDockerComposeContext(ctx, projectDir, composeFile, buildArgs(opts, service)...)
+	result := BuildResult{Service: service, Built: err == nil, Duration: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// buildArgs builds docker compose build arguments from opts for services
+func buildArgs(opts BuildOptions, services ...string) []string {
+	args := []string{"build"}
+	for _, arg := range opts.BuildArgs {
+		args = append(args, "--build-arg", arg)
+	}
+	if opts.NoCache {
+		args = append(args, "--no-cache")
+	}
+	if opts.Pull {
+		args = append(args, "--pull")
+	}
+	return append(args, services...)
+}
@@ -0,0 +1,96 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     BuildOptions
+		services []string
+		expected []string
+	}{
+		{
+			name:     "defaults",
+			services: []string{"api"},
+			expected: []string{"build", "api"},
+		},
+		{
+			name:     "all options",
+			opts:     BuildOptions{BuildArgs: []string{"VERSION=1.2.3", "HTTP_PROXY"}, NoCache: true, Pull: true},
+			services: []string{"api", "worker"},
+			expected: []string{"build", "--build-arg", "VERSION=1.2.3", "--build-arg", "HTTP_PROXY", "--no-cache", "--pull", "api", "worker"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, buildArgs(tt.opts, tt.services...))
+		})
+	}
+}
+
+func TestValidateBuildArg(t *testing.T) {
+	for _, arg := range []string{"VERSION=1.2.3", "EMPTY=", "FROM_ENV", "URL=https://example.com/?a=b"} {
+		assert.NoError(t, ValidateBuildArg(arg), arg)
+	}
+	for _, arg := range []string{"", "=value", "MY KEY=value"} {
+		assert.EqualError(t, ValidateBuildArg(arg), `invalid build argument "`+arg+`": expected KEY=VALUE`)
+	}
+}
+
+func TestBuildableServices(t *testing.T) {
+	all := map[string]composeService{
+		"worker": {Build: map[string]any{"context": "./worker"}},
+		"api":    {Build: map[string]any{"context": "./api"}},
+		"pg":     {Image: "postgres:16"},
+	}
+
+	services, err := buildableServices(all, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "worker"}, services)
+
+	services, err = buildableServices(all, []string{"worker"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"worker"}, services)
+
+	_, err = buildableServices(all, []string{"pg"})
+	assert.EqualError(t, err, "service pg has no build section, it runs the image postgres:16")
+	_, err = buildableServices(all, []string{"web"})
+	assert.EqualError(t, err, "no such service: web")
+}
+
+func TestBuild(t *testing.T) {
+	var calls [][]string
+	runCommand = func(cmd *exec.Cmd) error {
+		calls = append(calls, cmd.Args[1:])
+		if slices.Contains(cmd.Args, "worker") {
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+	t.Cleanup(func() { runCommand = (*exec.Cmd).Run })
+
+	opts := BuildOptions{BuildArgs: []string{"VERSION=1.2.3", "TOKEN=a=b c"}, Pull: true}
+	api := Build(context.Background(), t.TempDir(), "compose.yaml", "api", opts)
+	worker := Build(context.Background(), t.TempDir(), "compose.yaml", "worker", opts)
+
+	require.Len(t, calls, 2)
+	for i, service := range []string{"api", "worker"} {
+		assert.Equal(t, []string{"compose", "-f", "compose.yaml", "build", "--build-arg", "VERSION=1.2.3", "--build-arg", "TOKEN=a=b c", "--pull", service}, calls[i], "build arguments are forwarded as they are")
+	}
+	assert.Equal(t, "api", api.Service)
+	assert.True(t, api.Built)
+	assert.Empty(t, api.Error)
+	assert.Equal(t, "worker", worker.Service)
+	assert.False(t, worker.Built)
+	assert.Equal(t, "exit status 1", worker.Error)
+}
@@ -1,32 +1,65 @@
 package docker
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+)
+
+// Errors returned by CheckPrerequisites
+var (
+	ErrDockerNotInstalled  = errors.New("docker is not installed")
+	ErrComposeNotAvailable = errors.New("docker compose is not available")
+	ErrDaemonNotRunning    = errors.New("docker daemon is not running")
 )
 
-// CheckDockerInstalled checks if Docker is installed and running
-func CheckDockerInstalled() error {
+// daemonTimeout bounds how long `docker info` may take before the daemon is
+// considered unresponsive
+const daemonTimeout = 15 * time.Second
+
+// CheckPrerequisites checks that the docker binary is on PATH, that a
+// Docker Compose is available and that the Docker daemon responds, and
+// returns the Compose found by FindCompose. The daemon check is retried
+// according to the RetryPolicy carried by ctx.
+func CheckPrerequisites(ctx context.Context) (Compose, error) {
 	// Check if docker command is available
 	if _, err := exec.LookPath("docker"); err != nil {
-		return fmt.Errorf("docker is not installed. Please install Docker first")
+		return Compose{}, ErrDockerNotInstalled
 	}
 
-	// Check if Docker daemon is running
-	cmd := exec.Command("docker", "info")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("docker daemon is not running. Please start Docker first")
+	// Check which Compose is available, this does not need the daemon
+	compose, err := FindCompose(ctx)
+	if err != nil {
+		return Compose{}, err
 	}
 
-	// Check if docker compose command is available
-	if _, err := exec.LookPath("docker"); err == nil {
-		// Try docker compose version
-		cmd = exec.Command("docker", "compose", "version")
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("docker compose is not available. Please install Docker Compose V2")
+	// Check if Docker daemon is running, retrying while it refuses connections
+	timedOut := false
+	err = withRetries(ctx, func() (string, error) {
+		infoCtx, cancel := context.WithTimeout(ctx, daemonTimeout)
+		defer cancel()
+		var stderr tailBuffer
+		cmd := exec.CommandContext(infoCtx, "docker", "info")
+		cmd.Stderr = &stderr
+		done := logging.Command(ctx, cmd)
+		err := runCommand(cmd)
+		done()
+		timedOut = err != nil && infoCtx.Err() != nil
+		return stderr.String(), err
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return Compose{}, ctx.Err()
+		}
+		if timedOut {
+			return Compose{}, fmt.Errorf("%w (no response after %s)", ErrDaemonNotRunning, daemonTimeout)
 		}
+		return Compose{}, ErrDaemonNotRunning
 	}
 
-	return nil
+	return compose, nil
 }
-
@@ -0,0 +1,66 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDocker puts a docker script on PATH that fails for the given subcommand
+func fakeDocker(t *testing.T, failing string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker script requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$1\" = \"" + failing + "\" ]; then exit 1; fi\nexit 0\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0755))
+	t.Setenv("PATH", dir)
+}
+
+func TestCheckPrerequisites(t *testing.T) {
+	tests := []struct {
+		name    string
+		failing string
+		wantErr error
+	}{
+		{name: "all available", failing: "none"},
+		{name: "compose missing", failing: "compose", wantErr: ErrComposeNotAvailable},
+		{name: "daemon not running", failing: "info", wantErr: ErrDaemonNotRunning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeDocker(t, tt.failing)
+
+			_, err := CheckPrerequisites(context.Background())
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckPrerequisitesDockerNotInstalled(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := CheckPrerequisites(context.Background())
+	assert.ErrorIs(t, err, ErrDockerNotInstalled)
+}
+
+func TestCheckPrerequisitesCancelled(t *testing.T) {
+	fakeDocker(t, "none")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := CheckPrerequisites(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,99 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/update"
+)
+
+// ErrComposeV1 reports that only the legacy standalone docker-compose v1
+// binary is available, for callers that refuse to use it
+var ErrComposeV1 = errors.New("only the deprecated docker-compose v1 is available")
+
+// ComposeUpgradeURL explains how to move from docker-compose v1 to v2
+const ComposeUpgradeURL = "https://docs.docker.com/compose/migrate/"
+
+// Compose is the Docker Compose implementation compose commands are run with
+type Compose struct {
+	Command []string // Program and leading arguments, e.g., docker compose
+	Version string   // As printed by version --short, e.g., "2.24.5" (empty if unknown)
+	Legacy  bool     // The standalone docker-compose v1, whose flags differ from v2
+}
+
+// defaultCompose is the Compose v2 plugin, used when no Compose was detected
+var defaultCompose = Compose{Command: []string{"docker", "compose"}}
+
+// String returns the command compose commands are run with
+func (c Compose) String() string {
+	return strings.Join(c.Command, " ")
+}
+
+type composeKey struct{}
+
+// WithCompose returns a context that runs every compose command with c
+func WithCompose(ctx context.Context, c Compose) context.Context {
+	return context.WithValue(ctx, composeKey{}, c)
+}
+
+// ComposeFromContext returns the Compose carried by ctx, the docker compose
+// v2 plugin if none is
+func ComposeFromContext(ctx context.Context) Compose {
+	if ctx != nil {
+		if c, ok := ctx.Value(composeKey{}).(Compose); ok {
+			return c
+		}
+	}
+	return defaultCompose
+}
+
+// VersionRunner runs a version command and returns its standard output
+type VersionRunner func(ctx context.Context, name string, args ...string) (string, error)
+
+// runVersion runs a version command on the host
+func runVersion(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	output, err := logging.Output(ctx, cmd)
+	return string(output), err
+}
+
+// FindCompose finds the Compose installed on the host, see DetectCompose
+func FindCompose(ctx context.Context) (Compose, error) {
+	return DetectCompose(ctx, runVersion)
+}
+
+// DetectCompose finds the Compose to run compose commands with, preferring
+// the docker compose v2 plugin over a standalone docker-compose binary. A
+// standalone binary reporting a 1.x release, or a version that cannot be
+// parsed, is Legacy. ErrComposeNotAvailable is returned if there is neither.
+func DetectCompose(ctx context.Context, run VersionRunner) (Compose, error) {
+	candidates := [][]string{{"docker", "compose"}, {"docker-compose"}}
+	for i, command := range candidates {
+		c := Compose{Command: command}
+		version, err := c.version(ctx, run)
+		if ctx.Err() != nil {
+			return Compose{}, ctx.Err()
+		}
+		if err != nil {
+			continue
+		}
+		c.Version = version
+		// The plugin only exists since Compose v2
+		if i > 0 {
+			v, parseErr := update.ParseVersion(c.Version)
+			c.Legacy = parseErr != nil || v.Major < 2
+		}
+		return c, nil
+	}
+	return Compose{}, ErrComposeNotAvailable
+}
+
+// version asks c for its version with run
+func (c Compose) version(ctx context.Context, run VersionRunner) (string, error) {
+	output, err := run(ctx, c.Command[0], append(slices.Clone(c.Command[1:]), "version", "--short")...)
+	return strings.TrimSpace(output), err
+}
@@ -0,0 +1,91 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVersions returns a VersionRunner reporting the given version for each
+// Compose command line, e.g., "docker compose", and failing for the others
+func fakeVersions(versions map[string]string) VersionRunner {
+	return func(_ context.Context, name string, args ...string) (string, error) {
+		command := strings.Join(append([]string{name}, args[:len(args)-2]...), " ")
+		if version, ok := versions[command]; ok {
+			return version + "\n", nil
+		}
+		return "", errors.New("exit status 1")
+	}
+}
+
+func TestDetectCompose(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions map[string]string
+		want     Compose
+		wantErr  error
+	}{
+		{
+			name:     "v2 plugin",
+			versions: map[string]string{"docker compose": "2.24.5"},
+			want:     Compose{Command: []string{"docker", "compose"}, Version: "2.24.5"},
+		},
+		{
+			name:     "v2 preferred over v1",
+			versions: map[string]string{"docker compose": "2.24.5", "docker-compose": "1.29.2"},
+			want:     Compose{Command: []string{"docker", "compose"}, Version: "2.24.5"},
+		},
+		{
+			name:     "v1 only",
+			versions: map[string]string{"docker-compose": "1.29.2"},
+			want:     Compose{Command: []string{"docker-compose"}, Version: "1.29.2", Legacy: true},
+		},
+		{
+			name:     "standalone v2",
+			versions: map[string]string{"docker-compose": "v2.24.5"},
+			want:     Compose{Command: []string{"docker-compose"}, Version: "v2.24.5"},
+		},
+		{
+			name:     "standalone with unknown version",
+			versions: map[string]string{"docker-compose": ""},
+			want:     Compose{Command: []string{"docker-compose"}, Legacy: true},
+		},
+		{
+			name:    "none",
+			wantErr: ErrComposeNotAvailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compose, err := DetectCompose(context.Background(), fakeVersions(tt.versions))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, compose)
+		})
+	}
+}
+
+func TestDetectComposeCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := DetectCompose(ctx, fakeVersions(map[string]string{"docker compose": "2.24.5"}))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestComposeExec(t *testing.T) {
+	ctx := WithProfiles(context.Background(), []string{"dev"})
+	assert.Equal(t, []string{"docker", "compose", "--profile", "dev", "-f", "compose.yaml", "ps"}, composeExec(ctx, "compose.yaml", "ps").Args)
+
+	ctx = WithCompose(ctx, Compose{Command: []string{"docker-compose"}, Version: "1.29.2", Legacy: true})
+	assert.Equal(t, []string{"docker-compose", "--profile", "dev", "-f", "compose.yaml", "ps"}, composeExec(ctx, "compose.yaml", "ps").Args)
+	assert.False(t, SupportsWait(ctx), "docker-compose v1 has no up --wait")
+}
@@ -0,0 +1,101 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// Config returns the fully-resolved compose configuration, merged and with
+// variables interpolated, as printed by docker compose config. With asJSON
+// it is returned as JSON instead of YAML.
+func Config(ctx context.Context, projectDir string, composeFile string, asJSON bool) ([]byte, error) {
+	output, err := composeOutput(ctx, projectDir, composeFile, configArgs(asJSON)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve compose configuration: %w", err)
+	}
+	if asJSON && !json.Valid(output) {
+		return nil, errors.New("failed to resolve compose configuration: docker compose config did not print valid JSON")
+	}
+	return output, nil
+}
+
+// configArgs builds docker compose config arguments
+func configArgs(asJSON bool) []string {
+	args := []string{"config"}
+	if asJSON {
+		args = append(args, "--format", "json")
+	}
+	return args
+}
+
+// ListServiceNames returns the names of the services in the resolved compose
+// configuration, including only those enabled by the active profiles
+func ListServiceNames(ctx context.Context, projectDir string, composeFile string) ([]string, error) {
+	output, err := composeOutput(ctx, projectDir, composeFile, "config", "--services")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// DeclaredService is a service as declared in a compose file
+type DeclaredService struct {
+	Name  string
+	Image string // As written, before interpolation; empty for services that are only built
+}
+
+// DeclaredServices returns the services declared in the compose file content,
+// sorted by name. Unlike ListServiceNames it does not run docker compose, so
+// it is fast enough for shell completion, but it neither merges override
+// files nor applies profiles.
+func DeclaredServices(content []byte) ([]DeclaredService, error) {
+	var file struct {
+		Services map[string]struct {
+			Image string `yaml:"image"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	services := make([]DeclaredService, 0, len(file.Services))
+	for name, service := range file.Services {
+		services = append(services, DeclaredService{Name: name, Image: service.Image})
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services, nil
+}
+
+// composeOutput runs a docker compose command and returns its stdout. When
+// the command fails, its stderr is included in the error, since that is
+// where compose explains what is wrong with the compose file. Transient
+// failures are retried according to the RetryPolicy carried by ctx.
+func composeOutput(ctx context.Context, projectDir string, composeFile string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	err := withRetries(ctx, func() (string, error) {
+		stdout.Reset()
+		stderr.Reset()
+		cmd := composeExec(ctx, composeFile, args...)
+		cmd.Dir = projectDir
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		done := logging.Command(ctx, cmd)
+		err := runCommand(cmd)
+		done()
+		return stderr.String(), err
+	})
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%w: %s", err, message)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
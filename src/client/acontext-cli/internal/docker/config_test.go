@@ -0,0 +1,122 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDockerScript puts a docker script with the given body on PATH
+func fakeDockerScript(t *testing.T, body string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker script requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker"), []byte("#!/bin/sh\n"+body+"\n"), 0755))
+	t.Setenv("PATH", dir)
+}
+
+func TestConfigArgs(t *testing.T) {
+	assert.Equal(t, []string{"config"}, configArgs(false))
+	assert.Equal(t, []string{"config", "--format", "json"}, configArgs(true))
+}
+
+func TestListServiceNames(t *testing.T) {
+	// Fails unless the profiles and compose file are passed
+	fakeDockerScript(t, `[ "$*" = "compose --profile dev -f compose.yaml config --services" ] || exit 1; printf 'pg\nredis\n'`)
+	ctx := WithProfiles(context.Background(), []string{"dev"})
+
+	services, err := ListServiceNames(ctx, t.TempDir(), "compose.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pg", "redis"}, services)
+}
+
+func TestConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		asJSON   bool
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "yaml",
+			script:   `printf 'name: demo\nservices: {}\n'`,
+			expected: "name: demo\nservices: {}\n",
+		},
+		{
+			name:     "json",
+			script:   `printf '{"name":"demo"}'`,
+			asJSON:   true,
+			expected: `{"name":"demo"}`,
+		},
+		{
+			name:    "invalid json",
+			script:  `printf 'name: demo'`,
+			asJSON:  true,
+			wantErr: "did not print valid JSON",
+		},
+		{
+			name:    "compose error includes stderr",
+			script:  `echo "services.pg.image must be a string" >&2; exit 15`,
+			wantErr: "exit status 15: services.pg.image must be a string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeDockerScript(t, tt.script)
+
+			output, err := Config(context.Background(), t.TempDir(), "compose.yaml", tt.asJSON)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+func TestDeclaredServices(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []DeclaredService
+		wantErr bool
+	}{
+		{
+			name:    "images and builds",
+			content: "services:\n  web:\n    build: .\n  pg:\n    image: postgres:16\n",
+			want:    []DeclaredService{{Name: "pg", Image: "postgres:16"}, {Name: "web"}},
+		},
+		{name: "no services", content: "volumes:\n  data: {}\n", want: []DeclaredService{}},
+		{name: "invalid YAML", content: "services: [\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			services, err := DeclaredServices([]byte(tt.content))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, services)
+		})
+	}
+
+	t.Run("embedded compose file", func(t *testing.T) {
+		services, err := DeclaredServices([]byte(GetDockerComposeContent()))
+		require.NoError(t, err)
+		assert.NotEmpty(t, services)
+	})
+}
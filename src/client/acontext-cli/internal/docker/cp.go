@@ -0,0 +1,140 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CopyPath is a source or destination of docker cp: a path in the container
+// of Service, or a local path when Service is empty
+type CopyPath struct {
+	Service string
+	Path    string
+}
+
+// IsContainer reports whether p is a path in a service container
+func (p CopyPath) IsContainer() bool {
+	return p.Service != ""
+}
+
+// String returns p as docker compose cp takes it, e.g., pg:/tmp/dump.sql
+func (p CopyPath) String() string {
+	if p.IsContainer() {
+		return p.Service + ":" + p.Path
+	}
+	return p.Path
+}
+
+// ParseCopyPath parses a docker cp argument: service:/path for a path in the
+// container of service, anything else for a local path. An argument starting
+// with "." or "~", or with a path separator before the first colon, is local,
+// so ./a:b and C:\dump.sql are not taken for services.
+func ParseCopyPath(arg string) (CopyPath, error) {
+	if arg == "" {
+		return CopyPath{}, errors.New("empty path")
+	}
+	service, path, found := strings.Cut(arg, ":")
+	if !found || strings.ContainsAny(service, `/\`) || strings.HasPrefix(service, ".") || strings.HasPrefix(service, "~") || isDriveLetter(service) {
+		return CopyPath{Path: arg}, nil
+	}
+	if service == "" {
+		return CopyPath{}, fmt.Errorf("invalid path %q: the service is missing before the colon", arg)
+	}
+	if path == "" {
+		return CopyPath{}, fmt.Errorf("invalid path %q: the path in the container of %s is missing after the colon", arg, service)
+	}
+	return CopyPath{Service: service, Path: path}, nil
+}
+
+// isDriveLetter reports whether s is a Windows drive letter, e.g., C
+func isDriveLetter(s string) bool {
+	return len(s) == 1 && filepath.VolumeName(s+":") != ""
+}
+
+// CopyPaths parses the source and destination of docker cp, exactly one of
+// which must be in a service container, and returns the service it copies
+// from or to
+func CopyPaths(source, destination string) (CopyPath, CopyPath, string, error) {
+	src, err := ParseCopyPath(source)
+	if err != nil {
+		return CopyPath{}, CopyPath{}, "", err
+	}
+	dst, err := ParseCopyPath(destination)
+	if err != nil {
+		return CopyPath{}, CopyPath{}, "", err
+	}
+	switch {
+	case src.IsContainer() && dst.IsContainer():
+		return CopyPath{}, CopyPath{}, "", errors.New("cannot copy between two containers, copy to a local path first")
+	case src.IsContainer():
+		return src, dst, src.Service, nil
+	case dst.IsContainer():
+		return src, dst, dst.Service, nil
+	default:
+		return CopyPath{}, CopyPath{}, "", errors.New("either the source or the destination must be in a container, as service:/path")
+	}
+}
+
+// CheckLocalPath checks the local side of a copy before docker is run: a
+// source must exist, and the parent directory of a destination must. "-"
+// streams a tar archive through stdin or stdout and is not checked.
+func CheckLocalPath(p CopyPath, isSource bool) error {
+	if p.IsContainer() || p.Path == "-" {
+		return nil
+	}
+	if isSource {
+		if _, err := os.Stat(p.Path); err != nil {
+			return fmt.Errorf("source %s does not exist", p.Path)
+		}
+		return nil
+	}
+	parent := filepath.Dir(filepath.Clean(p.Path))
+	info, err := os.Stat(parent)
+	if err != nil {
+		return fmt.Errorf("destination directory %s does not exist", parent)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("destination directory %s is not a directory", parent)
+	}
+	return nil
+}
+
+// Copy copies source to destination, one of which is in the running
+// container of a service, with docker compose cp. Local paths are resolved
+// against the working directory first, as compose runs from the project
+// directory. A failed copy is returned as an *exec.ExitError.
+func Copy(ctx context.Context, projectDir string, composeFile string, source, destination CopyPath) error {
+	args, err := copyArgs(source, destination)
+	if err != nil {
+		return err
+	}
+	return RunDockerComposeContext(ctx, projectDir, composeFile, args...)
+}
+
+// copyArgs builds docker compose cp arguments, with local paths made absolute
+func copyArgs(source, destination CopyPath) ([]string, error) {
+	args := []string{"cp"}
+	for _, p := range []CopyPath{source, destination} {
+		if !p.IsContainer() && p.Path != "-" {
+			abs, err := filepath.Abs(p.Path)
+			if err != nil {
+				return nil, err
+			}
+			// Abs drops the trailing /. that copies the contents of a directory,
+			// and the trailing / that requires the destination to be one
+			sep := string(filepath.Separator)
+			if p.Path == "." || strings.HasSuffix(p.Path, sep+".") {
+				abs += sep + "."
+			} else if strings.HasSuffix(p.Path, sep) && abs != sep {
+				abs += sep
+			}
+			p.Path = abs
+		}
+		args = append(args, p.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,133 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCopyPath(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    CopyPath
+		wantErr string
+	}{
+		{arg: "pg:/tmp/dump.sql", want: CopyPath{Service: "pg", Path: "/tmp/dump.sql"}},
+		{arg: "acontext-server.core:logs", want: CopyPath{Service: "acontext-server.core", Path: "logs"}},
+		{arg: "./dump.sql", want: CopyPath{Path: "./dump.sql"}},
+		{arg: "dump.sql", want: CopyPath{Path: "dump.sql"}},
+		{arg: "./a:b", want: CopyPath{Path: "./a:b"}},
+		{arg: "/tmp/a:b", want: CopyPath{Path: "/tmp/a:b"}},
+		{arg: "~/backup:1", want: CopyPath{Path: "~/backup:1"}},
+		{arg: "-", want: CopyPath{Path: "-"}},
+		{arg: ":/tmp", wantErr: `invalid path ":/tmp": the service is missing before the colon`},
+		{arg: "pg:", wantErr: `invalid path "pg:": the path in the container of pg is missing after the colon`},
+		{arg: "", wantErr: "empty path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := ParseCopyPath(tt.arg)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCopyPaths(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		destination string
+		wantService string
+		wantErr     string
+	}{
+		{name: "from a container", source: "pg:/tmp/dump.sql", destination: "dump.sql", wantService: "pg"},
+		{name: "into a container", source: "seed.sql", destination: "pg:/tmp/", wantService: "pg"},
+		{name: "between containers", source: "pg:/a", destination: "core:/b", wantErr: "cannot copy between two containers, copy to a local path first"},
+		{name: "local only", source: "a", destination: "b", wantErr: "either the source or the destination must be in a container, as service:/path"},
+		{name: "invalid", source: "pg:", destination: "b", wantErr: `invalid path "pg:": the path in the container of pg is missing after the colon`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dst, service, err := CopyPaths(tt.source, tt.destination)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantService, service)
+			assert.Equal(t, tt.source, src.String())
+			assert.Equal(t, tt.destination, dst.String())
+		})
+	}
+}
+
+func TestCheckLocalPath(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "seed.sql")
+	require.NoError(t, os.WriteFile(file, []byte("select 1;"), 0644))
+	missing := filepath.Join(dir, "missing")
+
+	assert.NoError(t, CheckLocalPath(CopyPath{Path: file}, true))
+	assert.EqualError(t, CheckLocalPath(CopyPath{Path: missing}, true), "source "+missing+" does not exist")
+	assert.NoError(t, CheckLocalPath(CopyPath{Path: filepath.Join(dir, "dump.sql")}, false))
+	assert.NoError(t, CheckLocalPath(CopyPath{Path: dir + "/"}, false))
+	assert.EqualError(t, CheckLocalPath(CopyPath{Path: filepath.Join(missing, "dump.sql")}, false), "destination directory "+missing+" does not exist")
+	assert.EqualError(t, CheckLocalPath(CopyPath{Path: filepath.Join(file, "dump.sql")}, false), "destination directory "+file+" is not a directory")
+	assert.NoError(t, CheckLocalPath(CopyPath{Path: "-"}, true), "- streams through stdin")
+	assert.NoError(t, CheckLocalPath(CopyPath{Service: "pg", Path: "/missing/dump.sql"}, false), "container paths are checked by docker")
+}
+
+func TestCopyArgs(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		source      CopyPath
+		destination CopyPath
+		expected    []string
+	}{
+		{
+			name:        "relative destination",
+			source:      CopyPath{Service: "pg", Path: "/tmp/dump.sql"},
+			destination: CopyPath{Path: "dump.sql"},
+			expected:    []string{"cp", "pg:/tmp/dump.sql", filepath.Join(wd, "dump.sql")},
+		},
+		{
+			name:        "directory contents",
+			source:      CopyPath{Path: "logs/."},
+			destination: CopyPath{Service: "core", Path: "/app/logs"},
+			expected:    []string{"cp", filepath.Join(wd, "logs") + "/.", "core:/app/logs"},
+		},
+		{
+			name:        "into a directory",
+			source:      CopyPath{Service: "core", Path: "/app/config"},
+			destination: CopyPath{Path: "out/"},
+			expected:    []string{"cp", "core:/app/config", filepath.Join(wd, "out") + "/"},
+		},
+		{
+			name:        "to stdout",
+			source:      CopyPath{Service: "core", Path: "/app/config"},
+			destination: CopyPath{Path: "-"},
+			expected:    []string{"cp", "core:/app/config", "-"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := copyArgs(tt.source, tt.destination)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package docker
 
 import (
+	"bufio"
 	"fmt"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
 	"text/template"
 )
 
@@ -10,15 +14,15 @@ import (
 func GenerateEnvFile(filePath string, config *EnvConfig) error {
 	tmpl := `# Required Configuration
 # LLM Configuration
-LLM_API_KEY={{.LLMAPIKey}}
-LLM_BASE_URL={{.LLMBaseURL}}
-LLM_SDK={{.LLMSDK}}
+LLM_API_KEY={{quote .LLMAPIKey}}
+LLM_BASE_URL={{quote .LLMBaseURL}}
+LLM_SDK={{quote .LLMSDK}}
 
 # API Bearer Token (for root API access)
-ROOT_API_BEARER_TOKEN={{.RootAPIBearerToken}}
+ROOT_API_BEARER_TOKEN={{quote .RootAPIBearerToken}}
 {{if .CoreConfigYAMLFile}}
 # Core Configuration YAML File
-CORE_CONFIG_YAML_FILE={{.CoreConfigYAMLFile}}
+CORE_CONFIG_YAML_FILE={{quote .CoreConfigYAMLFile}}
 {{end}}
 # Optional: Override defaults if needed
 # All other settings use defaults from docker-compose.yaml
@@ -47,7 +51,7 @@ CORE_CONFIG_YAML_FILE={{.CoreConfigYAMLFile}}
 		CoreConfigYAMLFile: config.CoreConfigYAMLFile,
 	}
 
-	t, err := template.New("env").Parse(tmpl)
+	t, err := template.New("env").Funcs(template.FuncMap{"quote": QuoteEnvValue}).Parse(tmpl)
 	if err != nil {
 		return fmt.Errorf("failed to parse env template: %w", err)
 	}
@@ -80,3 +84,146 @@ type EnvConfig struct {
 	RootAPIBearerToken string
 	CoreConfigYAMLFile string
 }
+
+// EnvValues returns the variables GenerateEnvFile writes for config
+func EnvValues(config *EnvConfig) map[string]string {
+	env := map[string]string{
+		"LLM_API_KEY":           config.LLMConfig.APIKey,
+		"LLM_BASE_URL":          config.LLMConfig.BaseURL,
+		"LLM_SDK":               config.LLMConfig.SDK,
+		"ROOT_API_BEARER_TOKEN": config.RootAPIBearerToken,
+	}
+	if config.CoreConfigYAMLFile != "" {
+		env["CORE_CONFIG_YAML_FILE"] = config.CoreConfigYAMLFile
+	}
+	return env
+}
+
+// secretEnvKeys are the variables of the .env file holding credentials
+var secretEnvKeys = []string{"LLM_API_KEY", "ROOT_API_BEARER_TOKEN"}
+
+// SecretValues returns the credentials set in env, e.g., to keep them out of
+// logs
+func SecretValues(env map[string]string) []string {
+	var values []string
+	for _, key := range secretEnvKeys {
+		if value := env[key]; value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseEnvFile reads the variables set in a .env file. Comments, blank lines
+// and an optional "export " prefix are ignored. Single-quoted values are
+// literal, double-quoted values support \n, \", \\ and $$ escapes, and unquoted
+// values end at an inline " #" comment.
+func ParseEnvFile(filePath string) (map[string]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid line %d in %s", lineNum, filePath)
+		}
+
+		value, err := unquoteEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s on line %d in %s: %w", key, lineNum, filePath, err)
+		}
+		env[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	return env, nil
+}
+
+// unquoteEnvValue parses a single .env value
+func unquoteEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return value[1 : end+1], nil
+	case strings.HasPrefix(value, `"`):
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			c := value[i]
+			switch {
+			case c == '"':
+				return b.String(), nil
+			case c == '$' && i+1 < len(value) && value[i+1] == '$':
+				i++
+				b.WriteByte('$')
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				default:
+					b.WriteByte(value[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double quote")
+	}
+
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// QuoteEnvValue quotes value for a .env file read by docker compose. Values
+// without special characters are written as is, values without single quotes
+// or newlines are single-quoted (literal), anything else is double-quoted.
+func QuoteEnvValue(value string) string {
+	if !strings.ContainsAny(value, " \t\n\r#'\"\\$`") {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'"
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "", "$", "$$")
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// ExportLines formats env as `export KEY='VALUE'` lines sorted by key, quoted
+// for POSIX shells so the output can be passed to eval
+func ExportLines(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = fmt.Sprintf("export %s='%s'", key, strings.ReplaceAll(env[key], "'", `'\''`))
+	}
+	return lines
+}
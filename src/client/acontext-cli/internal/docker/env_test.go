@@ -2,6 +2,7 @@ package docker
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -96,3 +97,104 @@ func TestGenerateEnvFile(t *testing.T) {
 	}
 }
 
+func TestQuoteEnvValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "sk-abc123", expected: "sk-abc123"},
+		{value: "https://api.openai.com/v1", expected: "https://api.openai.com/v1"},
+		{value: "", expected: ""},
+		{value: "/path/with space/config.yaml", expected: "'/path/with space/config.yaml'"},
+		{value: "pa$$word#1", expected: "'pa$$word#1'"},
+		{value: `it's "quoted"`, expected: `"it's \"quoted\""`},
+		{value: "it's $HOME", expected: `"it's $$HOME"`},
+		{value: "line1\nline2", expected: `"line1\nline2"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.expected, QuoteEnvValue(tt.value))
+		})
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	content := `# Comment
+LLM_SDK=openai
+export LLM_BASE_URL=https://api.openai.com/v1 # inline comment
+
+EMPTY=
+SINGLE='literal $HOME "x"'
+DOUBLE="it's \"quoted\"\nnext $$HOME"
+# DATABASE_USER=acontext
+`
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	env, err := ParseEnvFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"LLM_SDK":      "openai",
+		"LLM_BASE_URL": "https://api.openai.com/v1",
+		"EMPTY":        "",
+		"SINGLE":       `literal $HOME "x"`,
+		"DOUBLE":       "it's \"quoted\"\nnext $HOME",
+	}, env)
+}
+
+func TestParseEnvFileInvalid(t *testing.T) {
+	for _, content := range []string{"NOT A LINE", "1KEY=value", `KEY="unterminated`, "KEY='unterminated"} {
+		t.Run(content, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			require.NoError(t, os.WriteFile(path, []byte(content+"\n"), 0644))
+
+			_, err := ParseEnvFile(path)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGenerateEnvFileRoundTrip(t *testing.T) {
+	envConfig := &EnvConfig{
+		LLMConfig: &LLMConfig{
+			APIKey:  `sk-"tricky" $key`,
+			BaseURL: "https://api.example.com",
+			SDK:     "openai",
+		},
+		RootAPIBearerToken: "token with spaces",
+		CoreConfigYAMLFile: "/path/with space/config.yaml",
+	}
+
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, GenerateEnvFile(path, envConfig))
+
+	env, err := ParseEnvFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, EnvValues(envConfig), env)
+}
+
+func TestExportLines(t *testing.T) {
+	env := map[string]string{
+		"B_KEY": "it's a value",
+		"A_KEY": "plain",
+	}
+
+	assert.Equal(t, []string{
+		"export A_KEY='plain'",
+		`export B_KEY='it'\''s a value'`,
+	}, ExportLines(env))
+}
+
+func TestExportLinesEval(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	value := `spaces, 'single' "double" $HOME \backslash` + "\nnewline"
+	script := strings.Join(ExportLines(map[string]string{"ACONTEXT_TEST_VALUE": value}), "\n") + "\nprintf '%s' \"$ACONTEXT_TEST_VALUE\""
+
+	output, err := exec.Command("sh", "-c", script).Output()
+	require.NoError(t, err)
+	assert.Equal(t, value, string(output))
+}
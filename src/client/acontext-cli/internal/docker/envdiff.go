@@ -0,0 +1,119 @@
+package docker
+
+import (
+	"errors"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// EnvSourceShell is the source of resolved values taken from the environment
+// of the CLI, which compose gives precedence over every env file
+const EnvSourceShell = "environment"
+
+// Kinds of EnvChange
+const (
+	EnvAdded   = "added"   // Resolved, but not set in .env
+	EnvRemoved = "removed" // Set in .env, but not resolved
+	EnvChanged = "changed" // Resolved to another value than the one in .env
+)
+
+// ResolvedValue is the value compose interpolates a variable with, and
+// where it comes from: an env file or EnvSourceShell
+type ResolvedValue struct {
+	Value  string
+	Source string
+}
+
+// EnvChange is a variable whose resolved value differs from .env
+type EnvChange struct {
+	Key      string  `json:"key"`
+	Change   string  `json:"change"`             // EnvAdded, EnvRemoved or EnvChanged
+	Source   string  `json:"source,omitempty"`   // Where the resolved value comes from, empty when removed
+	DotEnv   *string `json:"dotenv,omitempty"`   // Value in .env, unless added or masked
+	Resolved *string `json:"resolved,omitempty"` // Resolved value, unless removed or masked
+}
+
+// composeVariablePattern matches the variables interpolated in a compose
+// file, as ${VAR}, ${VAR:-default}, ${VAR?error} and so on, or $VAR
+var composeVariablePattern = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// ComposeVariables returns the variables a compose file interpolates,
+// sorted. $$ is an escaped dollar sign, not a variable.
+func ComposeVariables(content string) []string {
+	content = strings.ReplaceAll(content, "$$", "")
+	seen := map[string]bool{}
+	var variables []string
+	for _, match := range composeVariablePattern.FindAllStringSubmatch(content, -1) {
+		name := match[1] + match[2]
+		if !seen[name] {
+			seen[name] = true
+			variables = append(variables, name)
+		}
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+// ResolveComposeEnv returns the values compose interpolates a compose file
+// with: the variables set in the env files, later ones overriding earlier
+// ones, overridden in turn by those lookup finds in the environment for them
+// or for the variables the compose file references. Missing env files are
+// skipped, as compose does with the .env it reads by default.
+func ResolveComposeEnv(variables []string, envFiles []string, lookup func(string) (string, bool)) (map[string]ResolvedValue, error) {
+	resolved := map[string]ResolvedValue{}
+	for _, file := range envFiles {
+		env, err := ParseEnvFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range env {
+			resolved[key] = ResolvedValue{Value: value, Source: file}
+		}
+	}
+
+	keys := append([]string{}, variables...)
+	for key := range resolved {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if value, ok := lookup(key); ok {
+			resolved[key] = ResolvedValue{Value: value, Source: EnvSourceShell}
+		}
+	}
+	return resolved, nil
+}
+
+// DiffEnv returns the variables whose resolved value differs from the one
+// set in dotenv, sorted by key. Values are left out unless showValues is
+// set, since they may be credentials.
+func DiffEnv(dotenv map[string]string, resolved map[string]ResolvedValue, showValues bool) []EnvChange {
+	value := func(v string) *string {
+		if !showValues {
+			return nil
+		}
+		return &v
+	}
+
+	changes := []EnvChange{}
+	for key, current := range dotenv {
+		r, ok := resolved[key]
+		switch {
+		case !ok:
+			changes = append(changes, EnvChange{Key: key, Change: EnvRemoved, DotEnv: value(current)})
+		case r.Value != current:
+			changes = append(changes, EnvChange{Key: key, Change: EnvChanged, Source: r.Source, DotEnv: value(current), Resolved: value(r.Value)})
+		}
+	}
+	for key, r := range resolved {
+		if _, ok := dotenv[key]; !ok {
+			changes = append(changes, EnvChange{Key: key, Change: EnvAdded, Source: r.Source, Resolved: value(r.Value)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposeVariables(t *testing.T) {
+	content := `services:
+  pg:
+    image: postgres:${PG_VERSION:-16}
+    environment:
+      POSTGRES_PASSWORD: ${DATABASE_PASSWORD?set it in .env}
+      POSTGRES_USER: $DATABASE_USER
+      PRICE: $$5
+      ESCAPED: $${NOT_A_VARIABLE}
+    ports:
+      - "${DATABASE_EXPORT_PORT:-15432}:5432"
+      - "${DATABASE_EXPORT_PORT}:5433"
+`
+	assert.Equal(t, []string{"DATABASE_EXPORT_PORT", "DATABASE_PASSWORD", "DATABASE_USER", "PG_VERSION"}, ComposeVariables(content))
+
+	variables := ComposeVariables(GetDockerComposeContent())
+	assert.Contains(t, variables, "LLM_API_KEY")
+	assert.Contains(t, variables, "ROOT_API_BEARER_TOKEN")
+}
+
+func TestResolveComposeEnv(t *testing.T) {
+	dir := t.TempDir()
+	dotenv := filepath.Join(dir, ".env")
+	ci := filepath.Join(dir, ".env.ci")
+	require.NoError(t, os.WriteFile(dotenv, []byte("LLM_SDK=openai\nLLM_API_KEY=sk-local\n"), 0600))
+	require.NoError(t, os.WriteFile(ci, []byte("LLM_API_KEY=sk-ci\n"), 0600))
+	environment := map[string]string{"LLM_SDK": "anthropic", "DATABASE_USER": "ci", "PATH": "/usr/bin"}
+	lookup := func(key string) (string, bool) {
+		value, ok := environment[key]
+		return value, ok
+	}
+
+	resolved, err := ResolveComposeEnv([]string{"DATABASE_USER", "REDIS_PASSWORD"}, []string{dotenv, ci, filepath.Join(dir, "missing.env")}, lookup)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ResolvedValue{
+		"LLM_SDK":       {Value: "anthropic", Source: EnvSourceShell},
+		"LLM_API_KEY":   {Value: "sk-ci", Source: ci},
+		"DATABASE_USER": {Value: "ci", Source: EnvSourceShell},
+	}, resolved, "the environment overrides the env files, only for the variables compose uses")
+
+	require.NoError(t, os.WriteFile(ci, []byte("not a variable\n"), 0600))
+	_, err = ResolveComposeEnv(nil, []string{ci}, lookup)
+	assert.Error(t, err)
+}
+
+func TestDiffEnv(t *testing.T) {
+	dotenv := map[string]string{"LLM_SDK": "openai", "LLM_API_KEY": "sk-local", "DEBUG": "1"}
+	resolved := map[string]ResolvedValue{
+		"LLM_SDK":       {Value: "openai", Source: ".env"},
+		"LLM_API_KEY":   {Value: "sk-ci", Source: ".env.ci"},
+		"DATABASE_USER": {Value: "ci", Source: EnvSourceShell},
+	}
+	str := func(s string) *string { return &s }
+
+	assert.Equal(t, []EnvChange{
+		{Key: "DATABASE_USER", Change: EnvAdded, Source: EnvSourceShell},
+		{Key: "DEBUG", Change: EnvRemoved},
+		{Key: "LLM_API_KEY", Change: EnvChanged, Source: ".env.ci"},
+	}, DiffEnv(dotenv, resolved, false), "values are masked")
+
+	assert.Equal(t, []EnvChange{
+		{Key: "DATABASE_USER", Change: EnvAdded, Source: EnvSourceShell, Resolved: str("ci")},
+		{Key: "DEBUG", Change: EnvRemoved, DotEnv: str("1")},
+		{Key: "LLM_API_KEY", Change: EnvChanged, Source: ".env.ci", DotEnv: str("sk-local"), Resolved: str("sk-ci")},
+	}, DiffEnv(dotenv, resolved, true))
+
+	assert.Empty(t, DiffEnv(map[string]string{"LLM_SDK": "openai"}, map[string]ResolvedValue{"LLM_SDK": {Value: "openai", Source: ".env"}}, false))
+}
@@ -0,0 +1,69 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+type envFilesKey struct{}
+
+// WithEnvFiles returns a context that passes the given env files to every
+// docker compose command run with it, in order, as --env-file
+func WithEnvFiles(ctx context.Context, files []string) context.Context {
+	return context.WithValue(ctx, envFilesKey{}, files)
+}
+
+// EnvFilesFromContext returns the env files passed by ctx, if any
+func EnvFilesFromContext(ctx context.Context) []string {
+	if ctx != nil {
+		if files, ok := ctx.Value(envFilesKey{}).([]string); ok {
+			return files
+		}
+	}
+	return nil
+}
+
+// ParseEnvFiles splits a comma-separated list of env files, as in the
+// docker.env_files setting, dropping empty entries
+func ParseEnvFiles(value string) []string {
+	return splitList(value)
+}
+
+// CheckEnvFiles checks that every env file is a file that can be read, so a
+// mistyped path fails before docker compose runs
+func CheckEnvFiles(files []string) error {
+	for _, file := range files {
+		f, err := os.Open(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("env file %s does not exist", file)
+		}
+		if err != nil {
+			return fmt.Errorf("env file %s cannot be read: %w", file, err)
+		}
+		info, err := f.Stat()
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("env file %s cannot be read: %w", file, err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("env file %s is a directory", file)
+		}
+	}
+	return nil
+}
+
+// SharedKeys returns the keys set in both env and other, sorted
+func SharedKeys(env, other map[string]string) []string {
+	var shared []string
+	for key := range env {
+		if _, ok := other[key]; ok {
+			shared = append(shared, key)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
@@ -0,0 +1,56 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	env := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(env, []byte("A=1\n"), 0644))
+	unreadable := filepath.Join(dir, "unreadable.env")
+	require.NoError(t, os.WriteFile(unreadable, []byte("A=1\n"), 0000))
+
+	tests := []struct {
+		name    string
+		files   []string
+		wantErr string
+	}{
+		{name: "none"},
+		{name: "readable", files: []string{env}},
+		{name: "missing", files: []string{env, filepath.Join(dir, "missing.env")}, wantErr: "env file " + filepath.Join(dir, "missing.env") + " does not exist"},
+		{name: "directory", files: []string{dir}, wantErr: "env file " + dir + " is a directory"},
+		{name: "unreadable", files: []string{unreadable}, wantErr: "env file " + unreadable + " cannot be read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.name == "unreadable" && (runtime.GOOS == "windows" || os.Geteuid() == 0) {
+				t.Skip("file permissions do not stop this user from reading")
+			}
+			err := CheckEnvFiles(tt.files)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestParseEnvFiles(t *testing.T) {
+	assert.Equal(t, []string{".env", ".env.local"}, ParseEnvFiles(" .env, ,.env.local "))
+	assert.Nil(t, ParseEnvFiles(""))
+}
+
+func TestSharedKeys(t *testing.T) {
+	env := map[string]string{"B": "1", "A": "1", "C": "1"}
+	assert.Equal(t, []string{"A", "B"}, SharedKeys(env, map[string]string{"B": "2", "A": "2", "D": "2"}))
+	assert.Empty(t, SharedKeys(env, map[string]string{"D": "2"}))
+}
@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+)
+
+// DefaultShells are the shells tried, in order, when docker exec is given no
+// command
+var DefaultShells = []string{"sh", "bash"}
+
+// ExecOptions controls how Exec runs a command in a service container
+type ExecOptions struct {
+	TTY bool // Allocate a pseudo-terminal, for interactive sessions
+}
+
+// Exec runs command in the running container of service, attached to the
+// CLI's stdin, stdout and stderr. The inner command's exit status is returned
+// as an *exec.ExitError.
+func Exec(ctx context.Context, projectDir string, composeFile string, service string, command []string, opts ExecOptions) error {
+	return RunDockerComposeContext(ctx, projectDir, composeFile, execArgs(service, command, opts)...)
+}
+
+// execArgs builds docker compose exec arguments; compose allocates a TTY
+// unless -T is passed
+func execArgs(service string, command []string, opts ExecOptions) []string {
+	args := []string{"exec"}
+	if !opts.TTY {
+		args = append(args, "-T")
+	}
+	args = append(args, service)
+	return append(args, command...)
+}
+
+// DefaultShell returns the first of DefaultShells available in the running
+// container of service
+func DefaultShell(ctx context.Context, projectDir string, composeFile string, service string) (string, error) {
+	for _, shell := range DefaultShells {
+		cmd := composeExec(ctx, composeFile, "exec", "-T", service, shell, "-c", "exit 0")
+		cmd.Dir = projectDir
+		if err := logging.Run(ctx, cmd); err == nil {
+			return shell, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+	}
+	return "", fmt.Errorf("no shell found in %s (tried %s), pass the command to run", service, strings.Join(DefaultShells, ", "))
+}
+
+// CheckRunning returns an error explaining how to start service unless it has
+// a running container in infos
+func CheckRunning(infos []ServiceInfo, service string) error {
+	for _, info := range infos {
+		if info.Service != service {
+			continue
+		}
+		if info.State == "running" {
+			return nil
+		}
+		return fmt.Errorf("service %s is not running (%s), start it with: acontext docker up -d --service %s", service, info.State, service)
+	}
+	if running := RunningServices(infos); len(running) > 0 {
+		return fmt.Errorf("service %s is not running (running services: %s)", service, strings.Join(running, ", "))
+	}
+	return fmt.Errorf("service %s is not running, start it with: acontext docker up -d --service %s", service, service)
+}
+
+// RunningServices returns the names of the services with a running container
+func RunningServices(infos []ServiceInfo) []string {
+	var running []string
+	for _, info := range infos {
+		if info.State == "running" {
+			running = append(running, info.Service)
+		}
+	}
+	sort.Strings(running)
+	return running
+}
@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  []string
+		opts     ExecOptions
+		expected []string
+	}{
+		{
+			name:     "interactive shell",
+			command:  []string{"sh"},
+			opts:     ExecOptions{TTY: true},
+			expected: []string{"exec", "api", "sh"},
+		},
+		{
+			name:     "without a terminal",
+			command:  []string{"ls", "-la", "/app"},
+			expected: []string{"exec", "-T", "api", "ls", "-la", "/app"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, execArgs("api", tt.command, tt.opts))
+		})
+	}
+}
+
+func TestCheckRunning(t *testing.T) {
+	infos := []ServiceInfo{
+		{Service: "pg", State: "running"},
+		{Service: "api", State: "running"},
+		{Service: "migrate", State: "exited", ExitCode: 0},
+	}
+
+	tests := []struct {
+		name    string
+		infos   []ServiceInfo
+		service string
+		wantErr string
+	}{
+		{
+			name:    "running",
+			infos:   infos,
+			service: "api",
+		},
+		{
+			name:    "stopped",
+			infos:   infos,
+			service: "migrate",
+			wantErr: "service migrate is not running (exited), start it with: acontext docker up -d --service migrate",
+		},
+		{
+			name:    "unknown",
+			infos:   infos,
+			service: "redis",
+			wantErr: "service redis is not running (running services: api, pg)",
+		},
+		{
+			name:    "nothing running",
+			service: "api",
+			wantErr: "service api is not running, start it with: acontext docker up -d --service api",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRunning(tt.infos, tt.service)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
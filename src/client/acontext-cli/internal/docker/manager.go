@@ -1,66 +1,314 @@
 package docker
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/color"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
 )
 
 // RunDockerCompose directly executes docker compose command
 // If composeFile is provided, use it as the compose file, otherwise use default docker-compose.yaml
 func RunDockerCompose(projectDir string, composeFile string, args ...string) error {
-	cmdArgs := []string{"compose"}
-	if composeFile != "" {
-		cmdArgs = append(cmdArgs, "-f", composeFile)
-	}
-	cmdArgs = append(cmdArgs, args...)
-	cmd := exec.Command("docker", cmdArgs...)
+	return RunDockerComposeContext(context.Background(), projectDir, composeFile, args...)
+}
+
+// RunDockerComposeContext executes docker compose command and stops it when ctx is cancelled.
+// The child process is interrupted first so compose can shut down gracefully, and killed
+// if it has not exited shortly after. Transient failures are retried
+// according to the RetryPolicy carried by ctx.
+func RunDockerComposeContext(ctx context.Context, projectDir string, composeFile string, args ...string) error {
+	return runCompose(ctx, func() *exec.Cmd {
+		return composeCommand(ctx, projectDir, composeFile, args...)
+	})
+}
+
+// composeCommand builds a docker compose command attached to the terminal,
+// which is interrupted when ctx is cancelled
+func composeCommand(ctx context.Context, projectDir string, composeFile string, args ...string) *exec.Cmd {
+	cmd := composeExec(ctx, composeFile, args...)
 	cmd.Dir = projectDir
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
+	cmd.Cancel = func() error {
+		return interruptProcess(cmd.Process)
+	}
+	cmd.WaitDelay = 10 * time.Second
+	return cmd
+}
 
-	return cmd.Run()
+// runCompose runs a command built by build, which is called again for each
+// retry of a transient failure, logging how long each attempt took
+func runCompose(ctx context.Context, build func() *exec.Cmd) error {
+	retrying := RetryFromContext(ctx).Retries > 0
+	return withRetries(ctx, func() (string, error) {
+		cmd := build()
+		// Only capture stderr when it is needed, since compose renders its
+		// progress differently when stderr is not a terminal
+		var stderr tailBuffer
+		if retrying {
+			cmd.Stderr = io.MultiWriter(cmd.Stderr, &stderr)
+		}
+		done := logging.Command(ctx, cmd)
+		start := time.Now()
+		err := runCommand(cmd)
+		done()
+		logging.FromContext(ctx).Debug("command finished", "argv", logging.QuoteArgs(cmd.Args), "duration", time.Since(start), "error", err)
+		return stderr.String(), err
+	})
+}
+
+// composeExec builds a compose command for args, run with the Compose
+// carried by ctx
+func composeExec(ctx context.Context, composeFile string, args ...string) *exec.Cmd {
+	program := ComposeFromContext(ctx).Command[0]
+	return exec.CommandContext(ctx, program, composeArgs(ctx, composeFile, args...)...)
+}
+
+// composeArgs builds the compose argv for args, after the program name of
+// the Compose carried by ctx, activating the profiles and passing the env
+// files carried by ctx, and using composeFile if it is not empty. An
+// explicit --color always or never is passed on as --ansi; in auto mode
+// compose detects whether its output is a terminal itself.
+func composeArgs(ctx context.Context, composeFile string, args ...string) []string {
+	cmdArgs := slices.Clone(ComposeFromContext(ctx).Command[1:])
+	switch mode := color.CurrentMode(); mode {
+	case color.Always, color.Never:
+		cmdArgs = append(cmdArgs, "--ansi", string(mode))
+	}
+	for _, profile := range ProfilesFromContext(ctx) {
+		cmdArgs = append(cmdArgs, "--profile", profile)
+	}
+	for _, file := range EnvFilesFromContext(ctx) {
+		cmdArgs = append(cmdArgs, "--env-file", file)
+	}
+	if composeFile != "" {
+		cmdArgs = append(cmdArgs, "-f", composeFile)
+	}
+	return append(cmdArgs, args...)
+}
+
+// interruptProcess asks a process to stop, falling back to killing it where
+// interrupts are not supported (Windows)
+func interruptProcess(process *os.Process) error {
+	if err := process.Signal(os.Interrupt); err != nil {
+		return process.Kill()
+	}
+	return nil
+}
+
+// UpOptions controls how Up starts services
+type UpOptions struct {
+	Detach   bool     // Run containers in the background instead of streaming their logs
+	Build    bool     // Rebuild images before starting containers
+	Services []string // Services to start (all if empty)
+	Parallel int      // Maximum number of services started at once (compose's default if 0)
+	Pull     string   // When to pull images, one of PullPolicies (compose's default if empty)
+
+	// ForceRecreate recreates the containers even if their configuration
+	// and image have not changed, and NoDeps leaves the services the
+	// started ones depend on alone
+	ForceRecreate bool
+	NoDeps        bool
+
+	// Wait makes compose wait until the services are running and healthy,
+	// failing if they are not within WaitTimeout (no limit if 0). It needs
+	// a Compose release that SupportsWait.
+	Wait        bool
+	WaitTimeout time.Duration
+}
+
+// ParallelLimitEnvVar is the environment variable compose reads the maximum
+// number of concurrent operations from
+const ParallelLimitEnvVar = "COMPOSE_PARALLEL_LIMIT"
+
+// DefaultParallelism returns the default number of services started at once:
+// one per CPU, but at least 2
+func DefaultParallelism() int {
+	return max(runtime.NumCPU(), 2)
 }
 
 // Up starts Docker Compose services using a temporary compose file
-// If detached is false, services run in foreground (no -d flag)
-// If detached is true, services run in background (with -d flag)
-func Up(projectDir string, composeFile string, detached bool) error {
+// Without Detach, compose streams the combined logs of the services until
+// it is interrupted, then stops them. When a service fails to start, compose
+// cancels the startups still in progress and fails.
+func Up(ctx context.Context, projectDir string, composeFile string, opts UpOptions) error {
+	return runCompose(ctx, func() *exec.Cmd {
+		cmd := composeCommand(ctx, projectDir, composeFile, upArgs(opts)...)
+		if opts.Parallel > 0 {
+			cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%d", ParallelLimitEnvVar, opts.Parallel))
+		}
+		return cmd
+	})
+}
+
+// upArgs builds docker compose up arguments from opts
+func upArgs(opts UpOptions) []string {
 	args := []string{"up"}
-	if detached {
+	if opts.Detach {
 		args = append(args, "-d")
 	}
-	return RunDockerCompose(projectDir, composeFile, args...)
+	if opts.Build {
+		args = append(args, "--build")
+	}
+	if opts.Pull != "" {
+		args = append(args, "--pull", opts.Pull)
+	}
+	if opts.ForceRecreate {
+		args = append(args, "--force-recreate")
+	}
+	if opts.NoDeps {
+		args = append(args, "--no-deps")
+	}
+	if opts.Wait {
+		args = append(args, "--wait")
+		if opts.WaitTimeout > 0 {
+			// Compose takes whole seconds, round up so it never waits less
+			seconds := (opts.WaitTimeout + time.Second - 1) / time.Second
+			args = append(args, "--wait-timeout", strconv.Itoa(int(seconds)))
+		}
+	}
+	return append(args, opts.Services...)
+}
+
+// DefaultStopTimeout is how long compose waits for a container to stop
+// gracefully before killing it, when no timeout is given
+const DefaultStopTimeout = 10 * time.Second
+
+// killedExitCode is the exit code of a container killed with SIGKILL
+const killedExitCode = 128 + 9
+
+// DownOptions controls what Down removes besides the containers
+type DownOptions struct {
+	Volumes       bool // Also remove named volumes and anonymous volumes attached to containers
+	RemoveOrphans bool // Also remove containers for services not defined in the compose file
+
+	// StopTimeout is how many seconds a container may take to stop after
+	// SIGTERM before it is killed, DefaultStopTimeout if nil
+	StopTimeout *int
 }
 
 // Down stops Docker Compose services
-func Down(projectDir string, composeFile string) error {
-	return RunDockerCompose(projectDir, composeFile, "down")
+func Down(ctx context.Context, projectDir string, composeFile string, opts DownOptions) error {
+	return RunDockerComposeContext(ctx, projectDir, composeFile, downArgs(opts)...)
 }
 
-// Status checks Docker Compose services status
-func Status(projectDir string, composeFile string) error {
-	return RunDockerCompose(projectDir, composeFile, "ps")
+// downArgs builds docker compose down arguments from opts
+func downArgs(opts DownOptions) []string {
+	args := []string{"down"}
+	if opts.Volumes {
+		args = append(args, "--volumes")
+	}
+	if opts.RemoveOrphans {
+		args = append(args, "--remove-orphans")
+	}
+	if opts.StopTimeout != nil {
+		args = append(args, "--timeout", strconv.Itoa(*opts.StopTimeout))
+	}
+	return args
 }
 
-// Logs views Docker Compose services logs
-func Logs(projectDir string, composeFile string, service string) error {
-	args := []string{"logs", "-f"}
+// Stop stops Docker Compose services without removing their containers,
+// killing the ones still running after timeout seconds (DefaultStopTimeout
+// if nil)
+func Stop(ctx context.Context, projectDir string, composeFile string, timeout *int) error {
+	return RunDockerComposeContext(ctx, projectDir, composeFile, stopArgs(timeout)...)
+}
+
+// stopArgs builds docker compose stop arguments
+func stopArgs(timeout *int) []string {
+	args := []string{"stop"}
+	if timeout != nil {
+		args = append(args, "--timeout", strconv.Itoa(*timeout))
+	}
+	return args
+}
+
+// ListVolumes returns the named volumes declared in the compose file
+func ListVolumes(ctx context.Context, projectDir string, composeFile string) ([]string, error) {
+	cmd := composeExec(ctx, composeFile, "config", "--volumes")
+	cmd.Dir = projectDir
+	output, err := logging.Output(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// Restart restarts Docker Compose services
+// If service is empty, all services are restarted
+func Restart(ctx context.Context, projectDir string, composeFile string, service string) error {
+	args := []string{"restart"}
 	if service != "" {
 		args = append(args, service)
 	}
-	return RunDockerCompose(projectDir, composeFile, args...)
+	return RunDockerComposeContext(ctx, projectDir, composeFile, args...)
 }
 
-// WaitForHealth waits for services health check to pass
-func WaitForHealth(projectDir string, composeFile string, timeout time.Duration) error {
-	fmt.Println("⏳ Waiting for services to be healthy...")
+// Status checks Docker Compose services status
+func Status(ctx context.Context, projectDir string, composeFile string) error {
+	return RunDockerComposeContext(ctx, projectDir, composeFile, "ps")
+}
+
+// LogsOptions controls which logs are shown by Logs
+type LogsOptions struct {
+	Services   []string      // Services to show logs for (all if empty)
+	Follow     bool          // Stream new log lines until cancelled
+	Since      time.Duration // Only show logs newer than this (0 for all)
+	Until      time.Duration // Only show logs older than this (0 for all)
+	Tail       string        // Number of lines to show from the end of each service's logs, or "all" (all if empty)
+	Timestamps bool          // Prefix each line with its timestamp
+	NoPrefix   bool          // Do not prefix each line with its service name
+}
+
+// Logs views Docker Compose services logs
+// Compose prefixes each line with its (color-coded) service name, keeping
+// interleaved logs from multiple services readable, unless opts.NoPrefix is
+// set. When following, the last opts.Tail lines are shown before new ones
+// are streamed.
+func Logs(ctx context.Context, projectDir string, composeFile string, opts LogsOptions) error {
+	return RunDockerComposeContext(ctx, projectDir, composeFile, logsArgs(opts)...)
+}
+
+// logsArgs builds docker compose logs arguments from opts
+func logsArgs(opts LogsOptions) []string {
+	args := []string{"logs"}
+	if opts.Follow {
+		args = append(args, "--follow")
+	}
+	if opts.Since > 0 {
+		args = append(args, "--since", opts.Since.String())
+	}
+	if opts.Until > 0 {
+		args = append(args, "--until", opts.Until.String())
+	}
+	if opts.Tail != "" {
+		args = append(args, "--tail", opts.Tail)
+	}
+	if opts.Timestamps {
+		args = append(args, "--timestamps")
+	}
+	if opts.NoPrefix {
+		args = append(args, "--no-log-prefix")
+	}
+	return append(args, opts.Services...)
+}
 
+// WaitForHealth waits for services health check to pass
+func WaitForHealth(ctx context.Context, projectDir string, composeFile string, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
 	ticker := time.NewTicker(3 * time.Second)
 	defer ticker.Stop()
@@ -70,23 +318,18 @@ func WaitForHealth(projectDir string, composeFile string, timeout time.Duration)
 		checkCount++
 
 		// Check critical services health status
-		var cmdArgs []string
-		if composeFile != "" {
-			cmdArgs = []string{"compose", "-f", composeFile, "ps", "--format", "json"}
-		} else {
-			cmdArgs = []string{"compose", "ps", "--format", "json"}
-		}
-		cmd := exec.Command("docker", cmdArgs...)
+		cmd := composeExec(ctx, composeFile, "ps", "--format", "json")
 		cmd.Dir = projectDir
 		cmd.Stderr = nil // Hide error output
-		output, err := cmd.Output()
+		output, err := logging.Output(ctx, cmd)
 		if err != nil {
 			// If command fails, continue waiting
 			select {
 			case <-ticker.C:
-				fmt.Print(".")
 			case <-time.After(time.Until(deadline)):
 				return fmt.Errorf("timeout waiting for services to start")
+			case <-ctx.Done():
+				return ctx.Err()
 			}
 			continue
 		}
@@ -94,23 +337,16 @@ func WaitForHealth(projectDir string, composeFile string, timeout time.Duration)
 		// Check if there are running services
 		if len(output) > 0 {
 			// Check health status: use docker compose ps to view health status
-			if composeFile != "" {
-				cmdArgs = []string{"compose", "-f", composeFile, "ps", "--format", "{{.Service}}:{{.Status}}"}
-			} else {
-				cmdArgs = []string{"compose", "ps", "--format", "{{.Service}}:{{.Status}}"}
-			}
-			cmd = exec.Command("docker", cmdArgs...)
+			cmd = composeExec(ctx, composeFile, "ps", "--format", "{{.Service}}:{{.Status}}")
 			cmd.Dir = projectDir
 			cmd.Stderr = nil
-			output, err = cmd.Output()
+			output, err = logging.Output(ctx, cmd)
 			if err == nil && len(output) > 0 {
 				// Check if there are services running
 				outputStr := string(output)
 				if strings.Contains(outputStr, "Up") || strings.Contains(outputStr, "running") {
 					// Wait a bit to ensure services are stable
 					if checkCount >= 2 {
-						fmt.Println()
-						fmt.Println("✅ Services are running")
 						return nil
 					}
 				}
@@ -119,15 +355,13 @@ func WaitForHealth(projectDir string, composeFile string, timeout time.Duration)
 
 		select {
 		case <-ticker.C:
-			if checkCount%10 == 0 {
-				fmt.Print(".")
-			}
 		case <-time.After(time.Until(deadline)):
 			return fmt.Errorf("timeout waiting for services to be healthy")
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 
-	fmt.Println()
 	return fmt.Errorf("timeout waiting for services to be healthy")
 }
 
@@ -160,20 +394,247 @@ func CreateTempDockerCompose(projectDir string) (string, error) {
 
 // ServiceInfo represents docker compose service information
 type ServiceInfo struct {
-	Service string `json:"Service"`
-	State   string `json:"State"`
-	Ports   string `json:"Ports"`
+	ID         string      `json:"ID"`
+	Name       string      `json:"Name"`
+	Service    string      `json:"Service"`
+	State      string      `json:"State"`
+	Status     string      `json:"Status"`
+	Health     string      `json:"Health"`
+	ExitCode   int         `json:"ExitCode"`
+	Ports      string      `json:"Ports"`
+	Publishers []Publisher `json:"Publishers,omitempty"`
 }
 
-// GetServicePorts queries docker compose for service ports and returns a map of service name to ports
-func GetServicePorts(projectDir string, composeFile string) (map[string]string, error) {
-	cmdArgs := []string{"compose", "ps", "--format", "json"}
-	if composeFile != "" {
-		cmdArgs = []string{"compose", "-f", composeFile, "ps", "--format", "json"}
+// IsHealthy reports whether the service is running and, if it has a health check, healthy.
+// One-shot services (e.g., setup jobs) that exited successfully are also considered healthy.
+func (s ServiceInfo) IsHealthy() bool {
+	if s.State == "exited" {
+		return s.ExitCode == 0
+	}
+	return s.State == "running" && (s.Health == "" || s.Health == "healthy")
+}
+
+// NeedsAttention reports whether the service is unhealthy, restarting or has failed
+func (s ServiceInfo) NeedsAttention() bool {
+	switch s.State {
+	case "restarting", "dead":
+		return true
+	case "exited":
+		return s.ExitCode != 0
+	}
+	return s.Health == "unhealthy"
+}
+
+// Uptime returns how long the service has been up (e.g., "2 minutes"), parsed from its status
+func (s ServiceInfo) Uptime() string {
+	uptime, ok := strings.CutPrefix(s.Status, "Up ")
+	if !ok {
+		return ""
+	}
+	if i := strings.Index(uptime, " ("); i >= 0 {
+		uptime = uptime[:i]
+	}
+	return uptime
+}
+
+// PortSummary returns the ports of the service as docker compose ps lists
+// them, e.g., "0.0.0.0:15432->5432/tcp", built from Publishers when Ports
+// is empty
+func (s ServiceInfo) PortSummary() string {
+	if s.Ports != "" || len(s.Publishers) == 0 {
+		return s.Ports
+	}
+	ports := make([]string, 0, len(s.Publishers))
+	for _, publisher := range s.Publishers {
+		protocol := strings.ToLower(publisher.Protocol)
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		port := fmt.Sprintf("%d/%s", publisher.TargetPort, protocol)
+		if publisher.PublishedPort != 0 {
+			host := publisher.URL
+			if host == "" {
+				host = "0.0.0.0"
+			}
+			port = net.JoinHostPort(host, strconv.Itoa(publisher.PublishedPort)) + "->" + port
+		}
+		ports = append(ports, port)
+	}
+	return strings.Join(ports, ", ")
+}
+
+// WaitForServices waits until the given services (or all services if none are given)
+// are running and healthy, returning an error listing the ones that are not on timeout
+func WaitForServices(ctx context.Context, projectDir string, composeFile string, services []string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		infos, err := ListServices(ctx, projectDir, composeFile)
+		var pending []string
+		if err == nil {
+			pending = UnhealthyServices(infos, services)
+			if len(pending) == 0 {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("timeout waiting for services to be healthy: %w", err)
+			}
+			return fmt.Errorf("timeout waiting for services to be healthy: %s", strings.Join(pending, ", "))
+		}
+		select {
+		case <-time.After(2 * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// UnhealthyServices returns the targeted services (all if none are given)
+// that are missing or not healthy
+func UnhealthyServices(infos []ServiceInfo, services []string) []string {
+	byService := make(map[string]ServiceInfo, len(infos))
+	for _, info := range infos {
+		byService[info.Service] = info
+	}
+
+	if len(services) == 0 {
+		for _, info := range infos {
+			services = append(services, info.Service)
+		}
+	}
+
+	var pending []string
+	for _, service := range services {
+		info, ok := byService[service]
+		if !ok {
+			pending = append(pending, service+" (not found)")
+			continue
+		}
+		if !info.IsHealthy() {
+			state := info.State
+			if info.Health != "" {
+				state += ", " + info.Health
+			}
+			pending = append(pending, fmt.Sprintf("%s (%s)", service, state))
+		}
+	}
+	return pending
+}
+
+// FailedServices returns the targeted services (or all services if none are
+// given) that failed or were never started, e.g., because compose cancelled
+// their startup after another service failed
+func FailedServices(infos []ServiceInfo, services []string) []string {
+	targeted := make(map[string]bool, len(services))
+	for _, service := range services {
+		targeted[service] = true
 	}
-	cmd := exec.Command("docker", cmdArgs...)
+
+	var failed []string
+	for _, info := range infos {
+		if len(services) > 0 && !targeted[info.Service] {
+			continue
+		}
+		switch {
+		case info.State == "exited" && info.ExitCode != 0:
+			failed = append(failed, fmt.Sprintf("%s (exited with code %d)", info.Service, info.ExitCode))
+		case info.State == "created" || info.NeedsAttention():
+			state := info.State
+			if info.Health != "" {
+				state += ", " + info.Health
+			}
+			failed = append(failed, fmt.Sprintf("%s (%s)", info.Service, state))
+		}
+	}
+	return failed
+}
+
+// RecreatedContainers returns the containers of after that are not in
+// before, the ones listed by ListServices before and after an up: those
+// recreated, which get a new ID, and those created
+func RecreatedContainers(before, after []ServiceInfo) []ServiceInfo {
+	existing := make(map[string]bool, len(before))
+	for _, info := range before {
+		existing[info.ID] = true
+	}
+	recreated := []ServiceInfo{}
+	for _, info := range after {
+		if !existing[info.ID] {
+			recreated = append(recreated, info)
+		}
+	}
+	return recreated
+}
+
+// KilledContainers returns the containers running in before that exited
+// with SIGKILL in after, the ones listed by ListServices before and after a
+// Stop: those that did not stop gracefully in time and were killed
+func KilledContainers(before, after []ServiceInfo) []ServiceInfo {
+	running := make(map[string]bool, len(before))
+	for _, info := range before {
+		if info.State == "running" {
+			running[info.ID] = true
+		}
+	}
+	killed := []ServiceInfo{}
+	for _, info := range after {
+		if running[info.ID] && info.State == "exited" && info.ExitCode == killedExitCode {
+			killed = append(killed, info)
+		}
+	}
+	return killed
+}
+
+// ListServices queries docker compose for the project's services
+func ListServices(ctx context.Context, projectDir string, composeFile string) ([]ServiceInfo, error) {
+	cmd := composeExec(ctx, composeFile, "ps", "--all", "--format", "json")
+	cmd.Dir = projectDir
+	output, err := logging.Output(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	return parseServices(output)
+}
+
+// parseServices parses `docker compose ps --format json` output, which is either a
+// JSON array (older Compose v2 releases) or one JSON object per line
+func parseServices(output []byte) ([]ServiceInfo, error) {
+	trimmed := strings.TrimSpace(string(output))
+	if trimmed == "" {
+		return []ServiceInfo{}, nil
+	}
+
+	if strings.HasPrefix(trimmed, "[") {
+		var services []ServiceInfo
+		if err := json.Unmarshal([]byte(trimmed), &services); err != nil {
+			return nil, fmt.Errorf("failed to parse services: %w", err)
+		}
+		return services, nil
+	}
+
+	services := []ServiceInfo{}
+	for _, line := range strings.Split(trimmed, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var info ServiceInfo
+		if err := json.Unmarshal([]byte(line), &info); err != nil {
+			return nil, fmt.Errorf("failed to parse services: %w", err)
+		}
+		services = append(services, info)
+	}
+	return services, nil
+}
+
+// GetServicePorts queries docker compose for service ports and returns a map of service name to ports
+func GetServicePorts(ctx context.Context, projectDir string, composeFile string) (map[string]string, error) {
+	cmd := composeExec(ctx, composeFile, "ps", "--format", "json")
 	cmd.Dir = projectDir
-	output, err := cmd.Output()
+	output, err := logging.Output(ctx, cmd)
 	if err != nil {
 		return nil, err
 	}
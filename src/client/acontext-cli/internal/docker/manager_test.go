@@ -0,0 +1,403 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServices(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected []ServiceInfo
+	}{
+		{
+			name:     "empty output",
+			output:   "",
+			expected: []ServiceInfo{},
+		},
+		{
+			name: "one object per line",
+			output: `{"Name":"acontext-server-pg","Service":"acontext-server-pg","State":"running","Status":"Up 2 minutes","Ports":"0.0.0.0:15432->5432/tcp"}
+{"Name":"acontext-server-redis","Service":"acontext-server-redis","State":"exited","Status":"Exited (1) 5 seconds ago","Ports":""}
+`,
+			expected: []ServiceInfo{
+				{Name: "acontext-server-pg", Service: "acontext-server-pg", State: "running", Status: "Up 2 minutes", Ports: "0.0.0.0:15432->5432/tcp"},
+				{Name: "acontext-server-redis", Service: "acontext-server-redis", State: "exited", Status: "Exited (1) 5 seconds ago"},
+			},
+		},
+		{
+			name:   "published ports",
+			output: `{"Name":"acontext-server-pg","Service":"acontext-server-pg","State":"running","Ports":"0.0.0.0:15432->5432/tcp","Publishers":[{"URL":"0.0.0.0","TargetPort":5432,"PublishedPort":15432,"Protocol":"tcp"}]}`,
+			expected: []ServiceInfo{
+				{Name: "acontext-server-pg", Service: "acontext-server-pg", State: "running", Ports: "0.0.0.0:15432->5432/tcp", Publishers: []Publisher{{URL: "0.0.0.0", TargetPort: 5432, PublishedPort: 15432, Protocol: "tcp"}}},
+			},
+		},
+		{
+			name:   "json array",
+			output: `[{"Name":"acontext-server-pg","Service":"acontext-server-pg","State":"running","Status":"Up 2 minutes","Ports":""}]`,
+			expected: []ServiceInfo{
+				{Name: "acontext-server-pg", Service: "acontext-server-pg", State: "running", Status: "Up 2 minutes"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			services, err := parseServices([]byte(tt.output))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, services)
+		})
+	}
+}
+
+func TestParseServicesInvalid(t *testing.T) {
+	_, err := parseServices([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestUnhealthyServices(t *testing.T) {
+	infos := []ServiceInfo{
+		{Service: "pg", State: "running", Health: "healthy"},
+		{Service: "redis", State: "running", Health: "starting"},
+		{Service: "api", State: "running"},
+		{Service: "worker", State: "restarting"},
+		{Service: "setup", State: "exited", ExitCode: 0},
+		{Service: "migrate", State: "exited", ExitCode: 1},
+	}
+
+	tests := []struct {
+		name     string
+		services []string
+		expected []string
+	}{
+		{
+			name:     "all services",
+			expected: []string{"redis (running, starting)", "worker (restarting)", "migrate (exited)"},
+		},
+		{
+			name:     "healthy service",
+			services: []string{"pg"},
+		},
+		{
+			name:     "running service without health check",
+			services: []string{"api"},
+		},
+		{
+			name:     "unhealthy service",
+			services: []string{"redis"},
+			expected: []string{"redis (running, starting)"},
+		},
+		{
+			name:     "unknown service",
+			services: []string{"missing"},
+			expected: []string{"missing (not found)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, UnhealthyServices(infos, tt.services))
+		})
+	}
+}
+
+func TestUpArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     UpOptions
+		expected []string
+	}{
+		{
+			name:     "foreground",
+			expected: []string{"up"},
+		},
+		{
+			name: "all options",
+			opts: UpOptions{
+				Detach:   true,
+				Build:    true,
+				Services: []string{"acontext-server-api", "acontext-server-core"},
+				Pull:     PullNever,
+			},
+			expected: []string{"up", "-d", "--build", "--pull", "never", "acontext-server-api", "acontext-server-core"},
+		},
+		{
+			name:     "wait",
+			opts:     UpOptions{Detach: true, Wait: true, WaitTimeout: 90500 * time.Millisecond},
+			expected: []string{"up", "-d", "--wait", "--wait-timeout", "91"},
+		},
+		{
+			name:     "force recreate",
+			opts:     UpOptions{Detach: true, ForceRecreate: true, NoDeps: true, Services: []string{"acontext-server-core"}},
+			expected: []string{"up", "-d", "--force-recreate", "--no-deps", "acontext-server-core"},
+		},
+		{
+			name:     "wait without timeout",
+			opts:     UpOptions{Detach: true, Wait: true},
+			expected: []string{"up", "-d", "--wait"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, upArgs(tt.opts))
+		})
+	}
+}
+
+func TestUpParallelLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		parallel int
+		expected string
+	}{
+		{name: "compose default", expected: "unset"},
+		{name: "limited", parallel: 4, expected: "4"},
+		{name: "sequential", parallel: 1, expected: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "limit")
+			fakeDockerScript(t, `echo "${COMPOSE_PARALLEL_LIMIT:-unset}" > "`+out+`"`)
+			t.Setenv(ParallelLimitEnvVar, "")
+			require.NoError(t, os.Unsetenv(ParallelLimitEnvVar))
+
+			require.NoError(t, Up(context.Background(), t.TempDir(), "compose.yaml", UpOptions{Detach: true, Parallel: tt.parallel}))
+			data, err := os.ReadFile(out)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, strings.TrimSpace(string(data)))
+		})
+	}
+}
+
+func TestFailedServices(t *testing.T) {
+	infos := []ServiceInfo{
+		{Service: "pg", State: "running", Health: "healthy"},
+		{Service: "redis", State: "created"},
+		{Service: "api", State: "exited", ExitCode: 1},
+		{Service: "core", State: "running", Health: "unhealthy"},
+		{Service: "setup", State: "exited", ExitCode: 0},
+	}
+
+	tests := []struct {
+		name     string
+		services []string
+		expected []string
+	}{
+		{
+			name:     "all services",
+			expected: []string{"redis (created)", "api (exited with code 1)", "core (running, unhealthy)"},
+		},
+		{
+			name:     "targeted services",
+			services: []string{"pg", "api"},
+			expected: []string{"api (exited with code 1)"},
+		},
+		{
+			name:     "none failed",
+			services: []string{"pg", "setup"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FailedServices(infos, tt.services))
+		})
+	}
+}
+
+func TestRecreatedContainers(t *testing.T) {
+	before := []ServiceInfo{
+		{ID: "a1", Name: "acontext-acontext-server-pg-1", Service: "acontext-server-pg"},
+		{ID: "b1", Name: "acontext-acontext-server-core-1", Service: "acontext-server-core"},
+	}
+	after := []ServiceInfo{
+		{ID: "a1", Name: "acontext-acontext-server-pg-1", Service: "acontext-server-pg"},
+		{ID: "b2", Name: "acontext-acontext-server-core-1", Service: "acontext-server-core"},
+		{ID: "c1", Name: "acontext-acontext-server-api-1", Service: "acontext-server-api"},
+	}
+
+	assert.Equal(t, after[1:], RecreatedContainers(before, after))
+	assert.Empty(t, RecreatedContainers(after, after))
+}
+
+func TestDefaultParallelism(t *testing.T) {
+	assert.GreaterOrEqual(t, DefaultParallelism(), 2)
+}
+
+func TestDownArgs(t *testing.T) {
+	timeout, immediately := 60, 0
+	tests := []struct {
+		name     string
+		opts     DownOptions
+		expected []string
+	}{
+		{
+			name:     "defaults",
+			expected: []string{"down"},
+		},
+		{
+			name:     "all options",
+			opts:     DownOptions{Volumes: true, RemoveOrphans: true, StopTimeout: &timeout},
+			expected: []string{"down", "--volumes", "--remove-orphans", "--timeout", "60"},
+		},
+		{
+			name:     "kill immediately",
+			opts:     DownOptions{StopTimeout: &immediately},
+			expected: []string{"down", "--timeout", "0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, downArgs(tt.opts))
+		})
+	}
+}
+
+func TestStopArgs(t *testing.T) {
+	timeout := 30
+	assert.Equal(t, []string{"stop"}, stopArgs(nil))
+	assert.Equal(t, []string{"stop", "--timeout", "30"}, stopArgs(&timeout))
+}
+
+func TestKilledContainers(t *testing.T) {
+	before := []ServiceInfo{
+		{ID: "a1", Name: "acontext-acontext-server-pg-1", State: "running"},
+		{ID: "b1", Name: "acontext-acontext-server-core-1", State: "running"},
+		{ID: "c1", Name: "acontext-acontext-server-redis-1", State: "running"},
+		{ID: "d1", Name: "acontext-acontext-server-api-1", State: "exited", ExitCode: 137},
+	}
+	after := []ServiceInfo{
+		{ID: "a1", Name: "acontext-acontext-server-pg-1", State: "exited", ExitCode: 0},
+		{ID: "b1", Name: "acontext-acontext-server-core-1", State: "exited", ExitCode: 137},
+		{ID: "c1", Name: "acontext-acontext-server-redis-1", State: "exited", ExitCode: 1},
+		{ID: "d1", Name: "acontext-acontext-server-api-1", State: "exited", ExitCode: 137},
+	}
+
+	assert.Equal(t, after[1:2], KilledContainers(before, after), "containers that had already exited are not reported")
+	assert.Empty(t, KilledContainers(before, before))
+}
+
+func TestLogsArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     LogsOptions
+		expected []string
+	}{
+		{
+			name:     "defaults",
+			expected: []string{"logs"},
+		},
+		{
+			name:     "all lines",
+			opts:     LogsOptions{Tail: "all"},
+			expected: []string{"logs", "--tail", "all"},
+		},
+		{
+			name: "all options",
+			opts: LogsOptions{
+				Services:   []string{"acontext-server-api", "acontext-server-core"},
+				Follow:     true,
+				Since:      10 * time.Minute,
+				Until:      5 * time.Minute,
+				Tail:       "100",
+				Timestamps: true,
+				NoPrefix:   true,
+			},
+			expected: []string{"logs", "--follow", "--since", "10m0s", "--until", "5m0s", "--tail", "100", "--timestamps", "--no-log-prefix", "acontext-server-api", "acontext-server-core"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, logsArgs(tt.opts))
+		})
+	}
+}
+
+func TestServiceInfoStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		info           ServiceInfo
+		needsAttention bool
+		uptime         string
+	}{
+		{
+			name:   "healthy",
+			info:   ServiceInfo{State: "running", Health: "healthy", Status: "Up 2 minutes (healthy)"},
+			uptime: "2 minutes",
+		},
+		{
+			name:           "unhealthy",
+			info:           ServiceInfo{State: "running", Health: "unhealthy", Status: "Up About an hour (unhealthy)"},
+			needsAttention: true,
+			uptime:         "About an hour",
+		},
+		{
+			name:           "restarting",
+			info:           ServiceInfo{State: "restarting", Status: "Restarting (1) 3 seconds ago"},
+			needsAttention: true,
+		},
+		{
+			name: "one-shot job completed",
+			info: ServiceInfo{State: "exited", ExitCode: 0, Status: "Exited (0) 5 minutes ago"},
+		},
+		{
+			name:           "failed",
+			info:           ServiceInfo{State: "exited", ExitCode: 1, Status: "Exited (1) 5 minutes ago"},
+			needsAttention: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.needsAttention, tt.info.NeedsAttention())
+			assert.Equal(t, tt.uptime, tt.info.Uptime())
+		})
+	}
+}
+
+func TestServiceInfoPortSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		info ServiceInfo
+		want string
+	}{
+		{
+			name: "listed ports",
+			info: ServiceInfo{Ports: "0.0.0.0:15432->5432/tcp", Publishers: []Publisher{{URL: "0.0.0.0", TargetPort: 5432, PublishedPort: 15432, Protocol: "tcp"}}},
+			want: "0.0.0.0:15432->5432/tcp",
+		},
+		{
+			name: "publishers only",
+			info: ServiceInfo{Publishers: []Publisher{{URL: "0.0.0.0", TargetPort: 5432, PublishedPort: 15432, Protocol: "tcp"}, {URL: "::", TargetPort: 5432, PublishedPort: 15432, Protocol: "tcp"}}},
+			want: "0.0.0.0:15432->5432/tcp, [::]:15432->5432/tcp",
+		},
+		{
+			name: "exposed port",
+			info: ServiceInfo{Publishers: []Publisher{{TargetPort: 6379, Protocol: "TCP"}}},
+			want: "6379/tcp",
+		},
+		{
+			name: "no ports",
+			info: ServiceInfo{},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.info.PortSummary())
+		})
+	}
+}
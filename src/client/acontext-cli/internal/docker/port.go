@@ -0,0 +1,144 @@
+package docker
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Publisher is a container port of a service container and the host port it
+// is published on, 0 if it is only exposed, as listed by docker compose ps
+type Publisher struct {
+	URL           string `json:"URL"` // Host IP, e.g., 0.0.0.0 or ::
+	TargetPort    int    `json:"TargetPort"`
+	PublishedPort int    `json:"PublishedPort"`
+	Protocol      string `json:"Protocol"`
+}
+
+// PortMapping is a container port of a running service published on the host
+type PortMapping struct {
+	Service       string `json:"service"`
+	Container     string `json:"container"`
+	ContainerPort int    `json:"container_port"`
+	Protocol      string `json:"protocol"`
+	HostIP        string `json:"host_ip"`
+	HostPort      int    `json:"host_port"`
+}
+
+// Port returns the container port and its protocol, e.g., 5432/tcp
+func (m PortMapping) Port() string {
+	return fmt.Sprintf("%d/%s", m.ContainerPort, m.Protocol)
+}
+
+// Address returns the host address the port is published on, as host:port
+func (m PortMapping) Address() string {
+	return net.JoinHostPort(m.HostIP, strconv.Itoa(m.HostPort))
+}
+
+// ParseContainerPort parses a container port such as 5432 or 53/udp into
+// its number and protocol, tcp if it is not given
+func ParseContainerPort(value string) (int, string, error) {
+	number, protocol, found := strings.Cut(value, "/")
+	if !found {
+		protocol = "tcp"
+	}
+	port, err := strconv.Atoi(number)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, "", fmt.Errorf("invalid container port %q (expected a port number, optionally with /tcp or /udp, e.g., 5432)", value)
+	}
+	protocol = strings.ToLower(protocol)
+	if protocol != "tcp" && protocol != "udp" && protocol != "sctp" {
+		return 0, "", fmt.Errorf("invalid protocol %q in container port %q (expected tcp, udp or sctp)", protocol, value)
+	}
+	return port, protocol, nil
+}
+
+// PublishedPorts returns the ports the running containers of services (every
+// service if none are given) publish on the host, sorted by service, container
+// port and host port. A port published on both IPv4 and IPv6 is listed once,
+// with its IPv4 address.
+func PublishedPorts(infos []ServiceInfo, services []string) []PortMapping {
+	mappings := []PortMapping{}
+	seen := map[string]bool{}
+	for _, info := range infos {
+		if info.State != "running" || (len(services) > 0 && !slices.Contains(services, info.Service)) {
+			continue
+		}
+		publishers := slices.Clone(info.Publishers)
+		// IPv4 addresses first, so they are the ones kept
+		sort.SliceStable(publishers, func(i, j int) bool {
+			return !strings.Contains(publishers[i].URL, ":") && strings.Contains(publishers[j].URL, ":")
+		})
+		for _, publisher := range publishers {
+			if publisher.PublishedPort == 0 {
+				continue
+			}
+			protocol := strings.ToLower(publisher.Protocol)
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			key := fmt.Sprintf("%s/%d/%s/%d", info.Name, publisher.TargetPort, protocol, publisher.PublishedPort)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			hostIP := publisher.URL
+			if hostIP == "" {
+				hostIP = "0.0.0.0"
+			}
+			mappings = append(mappings, PortMapping{
+				Service:       info.Service,
+				Container:     info.Name,
+				ContainerPort: publisher.TargetPort,
+				Protocol:      protocol,
+				HostIP:        hostIP,
+				HostPort:      publisher.PublishedPort,
+			})
+		}
+	}
+	sort.SliceStable(mappings, func(i, j int) bool {
+		a, b := mappings[i], mappings[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.ContainerPort != b.ContainerPort {
+			return a.ContainerPort < b.ContainerPort
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		return a.HostPort < b.HostPort
+	})
+	return mappings
+}
+
+// LookupPort returns where the container port of service is published on
+// the host, one mapping for each of its running containers. It returns an
+// error explaining why when service is not running or does not publish the
+// port.
+func LookupPort(infos []ServiceInfo, service string, port int, protocol string) ([]PortMapping, error) {
+	if err := CheckRunning(infos, service); err != nil {
+		return nil, err
+	}
+	published := PublishedPorts(infos, []string{service})
+	var mappings []PortMapping
+	var ports []string
+	for _, mapping := range published {
+		if mapping.ContainerPort == port && mapping.Protocol == protocol {
+			mappings = append(mappings, mapping)
+		}
+		if !slices.Contains(ports, mapping.Port()) {
+			ports = append(ports, mapping.Port())
+		}
+	}
+	if len(mappings) > 0 {
+		return mappings, nil
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("port %d/%s of service %s is not published, and the service publishes no ports", port, protocol, service)
+	}
+	return nil, fmt.Errorf("port %d/%s of service %s is not published (published ports: %s)", port, protocol, service, strings.Join(ports, ", "))
+}
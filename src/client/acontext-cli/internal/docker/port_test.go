@@ -0,0 +1,99 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// portInfos are the services of a project, as docker compose ps lists them
+var portInfos = []ServiceInfo{
+	{Name: "acontext-server-pg-1", Service: "acontext-server-pg", State: "running", Publishers: []Publisher{
+		{URL: "::", TargetPort: 5432, PublishedPort: 15432, Protocol: "tcp"},
+		{URL: "0.0.0.0", TargetPort: 5432, PublishedPort: 15432, Protocol: "tcp"},
+	}},
+	{Name: "acontext-server-core-1", Service: "acontext-server-core", State: "running", Publishers: []Publisher{
+		{URL: "127.0.0.1", TargetPort: 8000, PublishedPort: 8019, Protocol: "tcp"},
+		{URL: "0.0.0.0", TargetPort: 53, PublishedPort: 10053, Protocol: "udp"},
+		{TargetPort: 9000, Protocol: "tcp"},
+	}},
+	{Name: "acontext-server-redis-1", Service: "acontext-server-redis", State: "running", Publishers: []Publisher{
+		{TargetPort: 6379, Protocol: "tcp"},
+	}},
+	{Name: "acontext-server-rabbitmq-1", Service: "acontext-server-rabbitmq", State: "exited", Publishers: []Publisher{
+		{URL: "0.0.0.0", TargetPort: 5672, PublishedPort: 15672, Protocol: "tcp"},
+	}},
+}
+
+func TestPublishedPorts(t *testing.T) {
+	pg := PortMapping{Service: "acontext-server-pg", Container: "acontext-server-pg-1", ContainerPort: 5432, Protocol: "tcp", HostIP: "0.0.0.0", HostPort: 15432}
+	dns := PortMapping{Service: "acontext-server-core", Container: "acontext-server-core-1", ContainerPort: 53, Protocol: "udp", HostIP: "0.0.0.0", HostPort: 10053}
+	api := PortMapping{Service: "acontext-server-core", Container: "acontext-server-core-1", ContainerPort: 8000, Protocol: "tcp", HostIP: "127.0.0.1", HostPort: 8019}
+
+	assert.Equal(t, []PortMapping{dns, api, pg}, PublishedPorts(portInfos, nil), "exposed ports, stopped services and IPv6 duplicates are left out")
+	assert.Equal(t, []PortMapping{pg}, PublishedPorts(portInfos, []string{"acontext-server-pg"}))
+	assert.Equal(t, []PortMapping{}, PublishedPorts(portInfos, []string{"acontext-server-redis"}))
+	assert.Equal(t, "0.0.0.0:15432", pg.Address())
+	assert.Equal(t, "5432/tcp", pg.Port())
+	assert.Equal(t, "[::1]:8019", PortMapping{HostIP: "::1", HostPort: 8019}.Address())
+}
+
+func TestLookupPort(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  string
+		port     int
+		protocol string
+		wantAddr string
+		wantErr  string
+	}{
+		{name: "published", service: "acontext-server-pg", port: 5432, protocol: "tcp", wantAddr: "0.0.0.0:15432"},
+		{name: "udp", service: "acontext-server-core", port: 53, protocol: "udp", wantAddr: "0.0.0.0:10053"},
+		{name: "other protocol", service: "acontext-server-core", port: 53, protocol: "tcp", wantErr: "port 53/tcp of service acontext-server-core is not published (published ports: 53/udp, 8000/tcp)"},
+		{name: "exposed only", service: "acontext-server-core", port: 9000, protocol: "tcp", wantErr: "port 9000/tcp of service acontext-server-core is not published (published ports: 53/udp, 8000/tcp)"},
+		{name: "no published ports", service: "acontext-server-redis", port: 6379, protocol: "tcp", wantErr: "port 6379/tcp of service acontext-server-redis is not published, and the service publishes no ports"},
+		{name: "not running", service: "acontext-server-rabbitmq", port: 5672, protocol: "tcp", wantErr: "service acontext-server-rabbitmq is not running (exited), start it with: acontext docker up -d --service acontext-server-rabbitmq"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mappings, err := LookupPort(portInfos, tt.service, tt.port, tt.protocol)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, mappings, 1)
+			assert.Equal(t, tt.wantAddr, mappings[0].Address())
+		})
+	}
+}
+
+func TestParseContainerPort(t *testing.T) {
+	tests := []struct {
+		value        string
+		wantPort     int
+		wantProtocol string
+		wantErr      string
+	}{
+		{value: "5432", wantPort: 5432, wantProtocol: "tcp"},
+		{value: "53/UDP", wantPort: 53, wantProtocol: "udp"},
+		{value: "http", wantErr: `invalid container port "http"`},
+		{value: "70000", wantErr: `invalid container port "70000"`},
+		{value: "80/icmp", wantErr: `invalid protocol "icmp"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			port, protocol, err := ParseContainerPort(tt.value)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPort, port)
+			assert.Equal(t, tt.wantProtocol, protocol)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+)
+
+type profilesKey struct{}
+
+// WithProfiles returns a context that activates the given compose profiles
+// on every docker compose command run with it
+func WithProfiles(ctx context.Context, profiles []string) context.Context {
+	return context.WithValue(ctx, profilesKey{}, profiles)
+}
+
+// ProfilesFromContext returns the compose profiles activated by ctx, if any
+func ProfilesFromContext(ctx context.Context) []string {
+	if ctx != nil {
+		if profiles, ok := ctx.Value(profilesKey{}).([]string); ok {
+			return profiles
+		}
+	}
+	return nil
+}
+
+// ListProfiles returns the profiles declared by the services in the compose file
+func ListProfiles(ctx context.Context, projectDir string, composeFile string) ([]string, error) {
+	cmd := composeExec(ctx, composeFile, "config", "--profiles")
+	cmd.Dir = projectDir
+	output, err := logging.Output(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// UnknownProfiles returns the requested profiles that are not declared in
+// available, in order
+func UnknownProfiles(requested, available []string) []string {
+	declared := make(map[string]bool, len(available))
+	for _, profile := range available {
+		declared[profile] = true
+	}
+	var unknown []string
+	for _, profile := range requested {
+		if !declared[profile] {
+			unknown = append(unknown, profile)
+		}
+	}
+	return unknown
+}
+
+// ParseProfiles splits a comma-separated profile list, as in the
+// docker.profiles setting, dropping empty entries
+func ParseProfiles(value string) []string {
+	return splitList(value)
+}
+
+// splitList splits a comma-separated setting, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,67 @@
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposeArgsProfiles(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, []string{"compose", "-f", "compose.yaml", "ps"}, composeArgs(ctx, "compose.yaml", "ps"))
+	assert.Equal(t, []string{"compose", "ps"}, composeArgs(ctx, "", "ps"))
+
+	ctx = WithProfiles(ctx, []string{"dev", "observability"})
+	assert.Equal(t, []string{"dev", "observability"}, ProfilesFromContext(ctx))
+	assert.Equal(t,
+		[]string{"compose", "--profile", "dev", "--profile", "observability", "-f", "compose.yaml", "up", "-d"},
+		composeArgs(ctx, "compose.yaml", "up", "-d"),
+	)
+}
+
+func TestComposeArgsEnvFiles(t *testing.T) {
+	ctx := WithEnvFiles(WithProfiles(context.Background(), []string{"dev"}), []string{"/p/.env", "/p/.env.local"})
+	assert.Equal(t, []string{"/p/.env", "/p/.env.local"}, EnvFilesFromContext(ctx))
+	assert.Equal(t,
+		[]string{"compose", "--profile", "dev", "--env-file", "/p/.env", "--env-file", "/p/.env.local", "-f", "compose.yaml", "config"},
+		composeArgs(ctx, "compose.yaml", "config"),
+	)
+}
+
+func TestUnknownProfiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested []string
+		available []string
+		want      []string
+	}{
+		{name: "all declared", requested: []string{"dev"}, available: []string{"dev", "observability"}},
+		{name: "typo", requested: []string{"dev", "obsevability"}, available: []string{"dev", "observability"}, want: []string{"obsevability"}},
+		{name: "none declared", requested: []string{"dev"}, want: []string{"dev"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, UnknownProfiles(tt.requested, tt.available))
+		})
+	}
+}
+
+func TestParseProfiles(t *testing.T) {
+	assert.Equal(t, []string{"dev", "observability"}, ParseProfiles(" dev, ,observability "))
+	assert.Nil(t, ParseProfiles(""))
+}
+
+func TestComposeArgsColor(t *testing.T) {
+	t.Cleanup(func() { color.SetMode(color.Auto) })
+	ctx := context.Background()
+
+	color.SetMode(color.Never)
+	assert.Equal(t, []string{"compose", "--ansi", "never", "-f", "compose.yaml", "logs"}, composeArgs(ctx, "compose.yaml", "logs"))
+	color.SetMode(color.Always)
+	assert.Equal(t, []string{"compose", "--ansi", "always", "logs"}, composeArgs(ctx, "", "logs"))
+	color.SetMode(color.Auto)
+	assert.Equal(t, []string{"compose", "logs"}, composeArgs(ctx, "", "logs"), "compose detects a terminal itself in auto mode")
+}
@@ -0,0 +1,142 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"sort"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+)
+
+// Pull policies for UpOptions.Pull, as taken by docker compose up --pull
+const (
+	PullAlways  = "always"  // Pull every image before starting, even if it is present
+	PullMissing = "missing" // Only pull images that are not present (compose's default)
+	PullNever   = "never"   // Never pull, fail if an image is not present
+)
+
+// PullPolicies lists the valid pull policies
+var PullPolicies = []string{PullAlways, PullMissing, PullNever}
+
+// IsPullPolicy reports whether policy is one of PullPolicies
+func IsPullPolicy(policy string) bool {
+	return slices.Contains(PullPolicies, policy)
+}
+
+// PullOptions controls which images Pull fetches
+type PullOptions struct {
+	Services []string // Services to pull the images of (all if empty)
+	Quiet    bool     // Do not print pull progress
+}
+
+// Pull fetches the images of Docker Compose services without starting them
+func Pull(ctx context.Context, projectDir string, composeFile string, opts PullOptions) error {
+	return RunDockerComposeContext(ctx, projectDir, composeFile, pullArgs(opts)...)
+}
+
+// pullArgs builds docker compose pull arguments from opts
+func pullArgs(opts PullOptions) []string {
+	args := []string{"pull"}
+	if opts.Quiet {
+		args = append(args, "--quiet")
+	}
+	return append(args, opts.Services...)
+}
+
+// composeService is the part of a service in docker compose config --format
+// json output that tells which image it runs
+type composeService struct {
+	Image     string                     `json:"image"`
+	Build     any                        `json:"build"`
+	DependsOn map[string]json.RawMessage `json:"depends_on"`
+}
+
+// MissingImages returns the images that up would need to pull to start the
+// given services (or all services if none are given) and their dependencies,
+// sorted. Images of services with a build section are left out, since compose
+// builds them instead.
+func MissingImages(ctx context.Context, projectDir string, composeFile string, services []string) ([]string, error) {
+	output, err := composeOutput(ctx, projectDir, composeFile, configArgs(true)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve compose configuration: %w", err)
+	}
+	var config struct {
+		Services map[string]composeService `json:"services"`
+	}
+	if err := json.Unmarshal(output, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse compose configuration: %w", err)
+	}
+
+	missing := []string{}
+	checked := map[string]bool{}
+	for _, image := range requiredImages(config.Services, services) {
+		if checked[image] {
+			continue
+		}
+		checked[image] = true
+		present, err := imagePresent(ctx, image)
+		if err != nil {
+			return nil, err
+		}
+		if !present {
+			missing = append(missing, image)
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// requiredImages returns the images of the targeted services and the
+// services they depend on, leaving out the ones compose builds
+func requiredImages(all map[string]composeService, services []string) []string {
+	targeted := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		if targeted[name] {
+			return
+		}
+		targeted[name] = true
+		for dependency := range all[name].DependsOn {
+			visit(dependency)
+		}
+	}
+	if len(services) == 0 {
+		for name := range all {
+			visit(name)
+		}
+	}
+	for _, name := range services {
+		visit(name)
+	}
+
+	var images []string
+	for name := range targeted {
+		service, ok := all[name]
+		if !ok || service.Image == "" || service.Build != nil {
+			continue
+		}
+		images = append(images, service.Image)
+	}
+	sort.Strings(images)
+	return images
+}
+
+// imagePresent reports whether image is in the local image cache
+func imagePresent(ctx context.Context, image string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{.Id}}", image)
+	done := logging.Command(ctx, cmd)
+	err := runCommand(cmd)
+	done()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && ctx.Err() == nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect image %s: %w", image, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,97 @@
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     PullOptions
+		expected []string
+	}{
+		{
+			name:     "all services",
+			expected: []string{"pull"},
+		},
+		{
+			name:     "quiet services",
+			opts:     PullOptions{Services: []string{"acontext-server-pg", "acontext-server-redis"}, Quiet: true},
+			expected: []string{"pull", "--quiet", "acontext-server-pg", "acontext-server-redis"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, pullArgs(tt.opts))
+		})
+	}
+}
+
+func TestIsPullPolicy(t *testing.T) {
+	for _, policy := range PullPolicies {
+		assert.True(t, IsPullPolicy(policy), policy)
+	}
+	assert.False(t, IsPullPolicy("build"))
+	assert.False(t, IsPullPolicy(""))
+}
+
+// pullConfig has an api built locally that depends on pg, which depends on
+// redis, and a worker sharing redis's image
+const pullConfig = `{"services":{` +
+	`"api":{"build":{"context":"."},"image":"acontext/api","depends_on":{"pg":{"condition":"service_healthy"}}},` +
+	`"pg":{"image":"postgres:16","depends_on":{"redis":{"condition":"service_started"}}},` +
+	`"redis":{"image":"redis:7"},` +
+	`"worker":{"image":"redis:7"},` +
+	`"ui":{"image":"acontext/ui"}}}`
+
+func TestMissingImages(t *testing.T) {
+	// Only postgres:16 and acontext/ui are present
+	fakeDockerScript(t, `case "$*" in
+  "compose -f compose.yaml config --format json") printf '%s' '`+pullConfig+`' ;;
+  "image inspect --format {{.Id}} postgres:16"|"image inspect --format {{.Id}} acontext/ui") echo sha256:abc ;;
+  "image inspect "*) echo "Error: No such image" >&2; exit 1 ;;
+  *) exit 2 ;;
+esac`)
+
+	tests := []struct {
+		name     string
+		services []string
+		expected []string
+	}{
+		{name: "all services", expected: []string{"redis:7"}},
+		{name: "dependencies are included", services: []string{"api"}, expected: []string{"redis:7"}},
+		{name: "all present", services: []string{"ui"}, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, err := MissingImages(context.Background(), t.TempDir(), "compose.yaml", tt.services)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, missing)
+		})
+	}
+}
+
+func TestRequiredImages(t *testing.T) {
+	all := map[string]composeService{
+		"api":   {Image: "acontext/api", Build: map[string]any{"context": "."}},
+		"pg":    {Image: "postgres:16"},
+		"setup": {Image: "postgres:16"},
+	}
+	assert.Equal(t, []string{"postgres:16", "postgres:16"}, requiredImages(all, nil))
+	assert.Empty(t, requiredImages(all, []string{"api"}), "images compose builds are not required")
+	assert.Empty(t, requiredImages(all, []string{"unknown"}))
+}
+
+func TestMissingImagesConfigError(t *testing.T) {
+	fakeDockerScript(t, `echo "service pg has neither an image nor a build context" >&2; exit 15`)
+
+	_, err := MissingImages(context.Background(), t.TempDir(), "compose.yaml", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service pg has neither an image nor a build context")
+}
@@ -0,0 +1,149 @@
+package docker
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+)
+
+// DefaultRetryDelay is the delay before the first retry when none is given
+const DefaultRetryDelay = 2 * time.Second
+
+// maxRetryDelay caps the exponential backoff between retries
+const maxRetryDelay = time.Minute
+
+// RetryPolicy controls how docker compose commands that fail with a
+// transient error are retried
+type RetryPolicy struct {
+	Retries int           // Retries after the first attempt (none if 0)
+	Delay   time.Duration // Delay before the first retry, doubled before each of the next ones
+}
+
+// backoff returns the delay before retry number attempt, counting from 0
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.Delay
+	for i := 0; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+type retryKey struct{}
+
+// WithRetry returns a context that retries every docker compose command run
+// with it according to policy
+func WithRetry(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryKey{}, policy)
+}
+
+// RetryFromContext returns the retry policy carried by ctx, which retries
+// nothing if there is none
+func RetryFromContext(ctx context.Context) RetryPolicy {
+	if ctx != nil {
+		if policy, ok := ctx.Value(retryKey{}).(RetryPolicy); ok {
+			return policy
+		}
+	}
+	return RetryPolicy{}
+}
+
+// runCommand runs a docker command; tests replace it to simulate failures
+var runCommand = (*exec.Cmd).Run
+
+// transientFailures are messages docker prints when the daemon or a registry
+// could not be reached for a moment
+var transientFailures = []string{
+	"cannot connect to the docker daemon",
+	"error during connect",
+	"connection refused",
+	"connection reset by peer",
+	"i/o timeout",
+	"tls handshake timeout",
+	"unexpected eof",
+	"temporary failure in name resolution",
+	"toomanyrequests",
+	"too many requests",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+}
+
+// permanentFailures are messages about mistakes that retrying cannot fix,
+// such as an invalid compose file, even when a transient message appears too
+var permanentFailures = []string{
+	"yaml:",
+	"validating ",
+	"no such service",
+	"pull access denied",
+	"manifest unknown",
+}
+
+// IsRetryable reports whether a docker command that printed stderr failed
+// with a transient error worth retrying
+func IsRetryable(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, failure := range permanentFailures {
+		if strings.Contains(stderr, failure) {
+			return false
+		}
+	}
+	for _, failure := range transientFailures {
+		if strings.Contains(stderr, failure) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRetries calls run, which runs a docker command once and returns what
+// it printed on stderr, until it succeeds, fails with an error that is not
+// retryable, or the retries of ctx's policy are used up. Each retry is
+// logged at verbose level.
+func withRetries(ctx context.Context, run func() (string, error)) error {
+	policy := RetryFromContext(ctx)
+	for attempt := 0; ; attempt++ {
+		stderr, err := run()
+		if err == nil || attempt >= policy.Retries || ctx.Err() != nil || !IsRetryable(stderr) {
+			return err
+		}
+
+		delay := policy.backoff(attempt)
+		logging.FromContext(ctx).Info("retrying command",
+			"retry", attempt+1, "retries", policy.Retries, "delay", delay, "reason", lastLine(stderr))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// lastLine returns the last non-empty line of s
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// stderrTailSize is how much of a command's stderr is kept to tell
+// retryable failures apart
+const stderrTailSize = 8 << 10
+
+// tailBuffer keeps the last stderrTailSize bytes written to it
+type tailBuffer struct {
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if extra := len(b.buf) - stderrTailSize; extra > 0 {
+		b.buf = b.buf[extra:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
@@ -0,0 +1,182 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const daemonDown = "Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?\n"
+
+// fakeRunner replaces runCommand with one that fails the first failures
+// calls, printing stderr, and then prints stdout and succeeds. It returns
+// the number of calls so far.
+func fakeRunner(t *testing.T, failures int, stderr, stdout string) *int {
+	t.Helper()
+	calls := 0
+	runCommand = func(cmd *exec.Cmd) error {
+		calls++
+		if calls <= failures {
+			_, _ = fmt.Fprint(cmd.Stderr, stderr)
+			return errors.New("exit status 1")
+		}
+		if cmd.Stdout != nil {
+			_, _ = fmt.Fprint(cmd.Stdout, stdout)
+		}
+		return nil
+	}
+	t.Cleanup(func() { runCommand = (*exec.Cmd).Run })
+	return &calls
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   bool
+	}{
+		{name: "daemon down", stderr: daemonDown, want: true},
+		{name: "daemon connection refused", stderr: "error during connect: Get \"http://%2F%2F.%2Fpipe%2FdockerDesktopLinuxEngine/v1.46/containers/json\": open //./pipe/dockerDesktopLinuxEngine: connection refused", want: true},
+		{name: "pull timeout", stderr: "Error response from daemon: Get \"https://registry-1.docker.io/v2/\": net/http: TLS handshake timeout", want: true},
+		{name: "rate limited", stderr: "toomanyrequests: You have reached your pull rate limit.", want: true},
+		{name: "registry unavailable", stderr: "error pulling image: received unexpected HTTP status: 503 Service Unavailable", want: true},
+		{name: "invalid compose file", stderr: "yaml: line 4: mapping values are not allowed in this context"},
+		{name: "invalid service", stderr: "validating /app/docker-compose.yaml: services.api additional properties 'imgae' not allowed"},
+		{name: "unknown image", stderr: "Error response from daemon: pull access denied for acontext/missing, repository does not exist"},
+		{name: "unknown service", stderr: "no such service: worker"},
+		{name: "no stderr"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryable(tt.stderr))
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{Retries: 10, Delay: time.Second}
+	assert.Equal(t, time.Second, policy.backoff(0))
+	assert.Equal(t, 2*time.Second, policy.backoff(1))
+	assert.Equal(t, 4*time.Second, policy.backoff(2))
+	assert.Equal(t, maxRetryDelay, policy.backoff(10))
+	assert.Equal(t, maxRetryDelay, RetryPolicy{Delay: 2 * time.Minute}.backoff(0))
+}
+
+func TestRunDockerComposeRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int
+		stderr    string
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "succeeds after retries", retries: 3, failures: 2, stderr: daemonDown, wantCalls: 3},
+		{name: "succeeds on the last retry", retries: 2, failures: 2, stderr: daemonDown, wantCalls: 3},
+		{name: "retries used up", retries: 2, failures: 3, stderr: daemonDown, wantCalls: 3, wantErr: true},
+		{name: "no retries", failures: 1, stderr: daemonDown, wantCalls: 1, wantErr: true},
+		{name: "invalid compose file", retries: 3, failures: 1, stderr: "yaml: line 4: did not find expected key\n", wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakeRunner(t, tt.failures, tt.stderr, "")
+			ctx := WithRetry(context.Background(), RetryPolicy{Retries: tt.retries, Delay: time.Millisecond})
+
+			err := RunDockerComposeContext(ctx, t.TempDir(), "compose.yaml", "down")
+			assert.Equal(t, tt.wantCalls, *calls)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestComposeOutputRetries(t *testing.T) {
+	calls := fakeRunner(t, 1, "Error response from daemon: Get \"https://registry-1.docker.io/v2/\": dial tcp: i/o timeout\n", "pg\nredis\n")
+	ctx := WithRetry(context.Background(), RetryPolicy{Retries: 1, Delay: time.Millisecond})
+
+	services, err := ListServiceNames(ctx, t.TempDir(), "compose.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, []string{"pg", "redis"}, services, "output of failed attempts is dropped")
+
+	// The error of the last attempt explains the failure
+	calls = fakeRunner(t, 1, "yaml: line 4: did not find expected key\n", "")
+	_, err = ListServiceNames(ctx, t.TempDir(), "compose.yaml")
+	assert.EqualError(t, err, "failed to list services: exit status 1: yaml: line 4: did not find expected key")
+	assert.Equal(t, 1, *calls)
+}
+
+func TestRetryLogging(t *testing.T) {
+	fakeRunner(t, 1, daemonDown, "")
+	var logs bytes.Buffer
+	ctx := logging.NewContext(context.Background(), logging.New(&logs, logging.Verbose))
+	ctx = WithRetry(ctx, RetryPolicy{Retries: 2, Delay: time.Millisecond})
+
+	require.NoError(t, Restart(ctx, t.TempDir(), "compose.yaml", ""))
+	assert.Contains(t, logs.String(), `msg="retrying command" retry=1 retries=2 delay=1ms reason="Cannot connect to the Docker daemon`)
+}
+
+func TestRetryStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = WithRetry(ctx, RetryPolicy{Retries: 3, Delay: time.Hour})
+	calls := 0
+	runCommand = func(cmd *exec.Cmd) error {
+		calls++
+		_, _ = fmt.Fprint(cmd.Stderr, daemonDown)
+		cancel()
+		return errors.New("signal: interrupt")
+	}
+	t.Cleanup(func() { runCommand = (*exec.Cmd).Run })
+
+	assert.Error(t, Status(ctx, t.TempDir(), "compose.yaml"))
+	assert.Equal(t, 1, calls)
+}
+
+func TestTailBuffer(t *testing.T) {
+	var b tailBuffer
+	_, _ = b.Write(bytes.Repeat([]byte("x"), stderrTailSize))
+	_, _ = b.Write([]byte("yaml: line 4\n"))
+	assert.Len(t, b.String(), stderrTailSize)
+	assert.Contains(t, b.String(), "yaml: line 4\n")
+}
+
+func TestCheckPrerequisitesRetriesDaemon(t *testing.T) {
+	fakeDocker(t, "none")
+	infoCalls := 0
+	runCommand = func(cmd *exec.Cmd) error {
+		if cmd.Args[1] != "info" {
+			return nil
+		}
+		infoCalls++
+		if infoCalls < 3 {
+			_, _ = fmt.Fprint(cmd.Stderr, daemonDown)
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+	t.Cleanup(func() { runCommand = (*exec.Cmd).Run })
+
+	ctx := WithRetry(context.Background(), RetryPolicy{Retries: 1, Delay: time.Millisecond})
+	_, err := CheckPrerequisites(ctx)
+	assert.ErrorIs(t, err, ErrDaemonNotRunning)
+	assert.Equal(t, 2, infoCalls)
+
+	infoCalls = 0
+	ctx = WithRetry(context.Background(), RetryPolicy{Retries: 2, Delay: time.Millisecond})
+	_, err = CheckPrerequisites(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, infoCalls)
+}
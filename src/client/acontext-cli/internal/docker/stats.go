@@ -0,0 +1,136 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+)
+
+// ServiceStats is the resource usage of a running service container, as
+// sampled by docker stats
+type ServiceStats struct {
+	Service       string  `json:"service"`
+	Container     string  `json:"container"`
+	CPUPercent    float64 `json:"cpu_percent"`
+	MemoryUsage   string  `json:"memory_usage"`
+	MemoryLimit   string  `json:"memory_limit"`
+	MemoryPercent float64 `json:"memory_percent"`
+	NetIO         string  `json:"net_io"`   // Received / sent
+	BlockIO       string  `json:"block_io"` // Read / written
+	PIDs          int     `json:"pids"`
+}
+
+// containerStats is a line of docker stats --format '{{json .}}' output
+type containerStats struct {
+	Name     string `json:"Name"`
+	CPUPerc  string `json:"CPUPerc"`
+	MemUsage string `json:"MemUsage"`
+	MemPerc  string `json:"MemPerc"`
+	NetIO    string `json:"NetIO"`
+	BlockIO  string `json:"BlockIO"`
+	PIDs     string `json:"PIDs"`
+}
+
+// Stats takes a one-shot sample of the resource usage of the running
+// containers of the given services (or all services if none are given),
+// sorted by service. It returns no stats, and no error, when none of them
+// are running.
+func Stats(ctx context.Context, projectDir string, composeFile string, services []string) ([]ServiceStats, error) {
+	infos, err := ListServices(ctx, projectDir, composeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceOf := map[string]string{}
+	var containers []string
+	for _, info := range infos {
+		if info.State != "running" || (len(services) > 0 && !slices.Contains(services, info.Service)) {
+			continue
+		}
+		serviceOf[info.Name] = info.Service
+		containers = append(containers, info.Name)
+	}
+	if len(containers) == 0 {
+		return []ServiceStats{}, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	err = withRetries(ctx, func() (string, error) {
+		stdout.Reset()
+		stderr.Reset()
+		args := append([]string{"stats", "--no-stream", "--format", "{{json .}}"}, containers...)
+		cmd := exec.CommandContext(ctx, "docker", args...)
+		cmd.Dir = projectDir
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		done := logging.Command(ctx, cmd)
+		err := runCommand(cmd)
+		done()
+		return stderr.String(), err
+	})
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("failed to get container stats: %w: %s", err, message)
+		}
+		return nil, fmt.Errorf("failed to get container stats: %w", err)
+	}
+	return parseStats(stdout.Bytes(), serviceOf)
+}
+
+// parseStats parses docker stats --format '{{json .}}' output, one JSON
+// object per line, naming each container's service from serviceOf
+func parseStats(output []byte, serviceOf map[string]string) ([]ServiceStats, error) {
+	stats := []ServiceStats{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var container containerStats
+		if err := json.Unmarshal([]byte(line), &container); err != nil {
+			return nil, fmt.Errorf("failed to parse container stats: %w", err)
+		}
+		usage, limit, _ := strings.Cut(container.MemUsage, " / ")
+		pids, _ := strconv.Atoi(container.PIDs)
+		service := serviceOf[container.Name]
+		if service == "" {
+			service = container.Name
+		}
+		stats = append(stats, ServiceStats{
+			Service:       service,
+			Container:     container.Name,
+			CPUPercent:    parsePercent(container.CPUPerc),
+			MemoryUsage:   strings.TrimSpace(usage),
+			MemoryLimit:   strings.TrimSpace(limit),
+			MemoryPercent: parsePercent(container.MemPerc),
+			NetIO:         container.NetIO,
+			BlockIO:       container.BlockIO,
+			PIDs:          pids,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read container stats: %w", err)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Service < stats[j].Service })
+	return stats, nil
+}
+
+// parsePercent parses a docker stats percentage such as "12.34%". Containers
+// that are starting or stopping report "--", which is taken as 0.
+func parsePercent(value string) float64 {
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	if err != nil {
+		return 0
+	}
+	return percent
+}
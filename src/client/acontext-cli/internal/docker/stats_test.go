@@ -0,0 +1,57 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStats(t *testing.T) {
+	serviceOf := map[string]string{
+		"acontext-acontext-server-pg-1":    "acontext-server-pg",
+		"acontext-acontext-server-redis-1": "acontext-server-redis",
+	}
+
+	tests := []struct {
+		name     string
+		output   string
+		expected []ServiceStats
+	}{
+		{
+			name:     "empty output",
+			output:   "",
+			expected: []ServiceStats{},
+		},
+		{
+			name: "sorted by service",
+			output: `{"BlockIO":"0B / 4.1kB","CPUPerc":"0.35%","Container":"b2","ID":"b2","MemPerc":"0.12%","MemUsage":"9.5MiB / 7.66GiB","Name":"acontext-acontext-server-redis-1","NetIO":"1.2kB / 0B","PIDs":"6"}
+{"BlockIO":"12.3MB / 45.6MB","CPUPerc":"12.50%","Container":"a1","ID":"a1","MemPerc":"1.02%","MemUsage":"80.1MiB / 7.66GiB","Name":"acontext-acontext-server-pg-1","NetIO":"3.4kB / 2.2kB","PIDs":"14"}
+`,
+			expected: []ServiceStats{
+				{Service: "acontext-server-pg", Container: "acontext-acontext-server-pg-1", CPUPercent: 12.5, MemoryUsage: "80.1MiB", MemoryLimit: "7.66GiB", MemoryPercent: 1.02, NetIO: "3.4kB / 2.2kB", BlockIO: "12.3MB / 45.6MB", PIDs: 14},
+				{Service: "acontext-server-redis", Container: "acontext-acontext-server-redis-1", CPUPercent: 0.35, MemoryUsage: "9.5MiB", MemoryLimit: "7.66GiB", MemoryPercent: 0.12, NetIO: "1.2kB / 0B", BlockIO: "0B / 4.1kB", PIDs: 6},
+			},
+		},
+		{
+			name:   "container still starting",
+			output: `{"BlockIO":"--","CPUPerc":"--","MemPerc":"--","MemUsage":"-- / --","Name":"other","NetIO":"--","PIDs":"--"}`,
+			expected: []ServiceStats{
+				{Service: "other", Container: "other", MemoryUsage: "--", MemoryLimit: "--", NetIO: "--", BlockIO: "--"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := parseStats([]byte(tt.output), serviceOf)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stats)
+		})
+	}
+}
+
+func TestParseStatsInvalid(t *testing.T) {
+	_, err := parseStats([]byte("not json"), nil)
+	assert.Error(t, err)
+}
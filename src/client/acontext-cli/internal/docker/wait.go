@@ -0,0 +1,41 @@
+package docker
+
+import (
+	"context"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/update"
+)
+
+// minWaitVersion is the first Compose release whose up supports both --wait
+// and --wait-timeout
+var minWaitVersion = update.Version{Major: 2, Minor: 17}
+
+// SupportsWait reports whether the Compose carried by ctx can wait for
+// services to become healthy itself, with up --wait. Older releases,
+// including every docker-compose v1, need WaitForServices.
+func SupportsWait(ctx context.Context) bool {
+	compose := ComposeFromContext(ctx)
+	if compose.Legacy {
+		return false
+	}
+	version := compose.Version
+	if version == "" {
+		var err error
+		if version, err = compose.version(ctx, runVersion); err != nil {
+			return false
+		}
+	}
+	return supportsWait(version)
+}
+
+// supportsWait reports whether Compose version, as printed by docker compose
+// version --short (e.g., "2.24.5" or "v2.24.5-desktop.1"), supports up --wait
+func supportsWait(version string) bool {
+	v, err := update.ParseVersion(version)
+	if err != nil {
+		return false
+	}
+	// Vendor suffixes such as -desktop.1 are builds of the release, not previews
+	v.Prerelease = ""
+	return v.Compare(minWaitVersion) >= 0
+}
@@ -0,0 +1,27 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupportsWait(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{version: "2.24.5", expected: true},
+		{version: "v2.17.0", expected: true},
+		{version: "2.17.0-desktop.1", expected: true},
+		{version: "2.16.0", expected: false},
+		{version: "1.29.2", expected: false},
+		{version: "dev", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			assert.Equal(t, tt.expected, supportsWait(tt.version))
+		})
+	}
+}
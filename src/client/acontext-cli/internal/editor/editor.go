@@ -0,0 +1,69 @@
+// Package editor finds the user's editor and opens a project in it
+package editor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Code is the command of VS Code, used when neither $VISUAL nor $EDITOR names
+// an editor that is on PATH
+const Code = "code"
+
+// Editor is the command that opens a project, and where it was configured
+type Editor struct {
+	Args   []string // Program and arguments, the project directory is added last
+	Source string   // The setting, environment variable or default it came from
+}
+
+// String returns the editor command as it is configured, e.g., code --wait
+func (e Editor) String() string {
+	return strings.Join(e.Args, " ")
+}
+
+// Resolve returns the editor to open projects with, the first one found on
+// PATH of: configured (e.g., the create.editor setting, which reports an
+// error when it cannot be found), $VISUAL, $EDITOR and code. Commands are
+// split on whitespace, so they may take arguments, e.g., "code --wait". It
+// returns false when none is found.
+func Resolve(configured string) (Editor, bool, error) {
+	if args := strings.Fields(configured); len(args) > 0 {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			return Editor{}, false, fmt.Errorf("editor %q not found on PATH", args[0])
+		}
+		return Editor{Args: args, Source: "create.editor"}, true, nil
+	}
+
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		args := strings.Fields(os.Getenv(name))
+		if len(args) == 0 {
+			continue
+		}
+		if _, err := exec.LookPath(args[0]); err == nil {
+			return Editor{Args: args, Source: "$" + name}, true, nil
+		}
+	}
+	if _, err := exec.LookPath(Code); err == nil {
+		return Editor{Args: []string{Code}, Source: Code}, true, nil
+	}
+	return Editor{}, false, nil
+}
+
+// Open runs the editor on dir, from dir and connected to the standard
+// streams of the CLI, so a terminal editor such as vim takes over the
+// terminal until it exits
+func Open(ctx context.Context, editor Editor, dir string) error {
+	args := append(append([]string{}, editor.Args[1:]...), dir)
+	cmd := exec.CommandContext(ctx, editor.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", editor.Args[0], err)
+	}
+	return nil
+}
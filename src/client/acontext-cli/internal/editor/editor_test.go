@@ -0,0 +1,86 @@
+package editor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeEditor writes a shell script called name into dir
+func writeEditor(t *testing.T, dir, name, script string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755))
+}
+
+func TestResolve(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("editor scripts require a POSIX shell")
+	}
+	withCode, withoutCode := t.TempDir(), t.TempDir()
+	for _, dir := range []string{withCode, withoutCode} {
+		writeEditor(t, dir, "vim", "")
+		writeEditor(t, dir, "subl", "")
+	}
+	writeEditor(t, withCode, Code, "")
+
+	tests := []struct {
+		name       string
+		path       string
+		configured string
+		visual     string
+		editor     string
+		want       Editor
+		wantFound  bool
+		wantErr    string
+	}{
+		{name: "configured", path: withCode, configured: "subl --wait", visual: "vim", want: Editor{Args: []string{"subl", "--wait"}, Source: "create.editor"}, wantFound: true},
+		{name: "configured not found", path: withCode, configured: "idea", wantErr: `editor "idea" not found on PATH`},
+		{name: "visual", path: withCode, visual: "subl -n", editor: "vim", want: Editor{Args: []string{"subl", "-n"}, Source: "$VISUAL"}, wantFound: true},
+		{name: "editor", path: withCode, editor: "vim", want: Editor{Args: []string{"vim"}, Source: "$EDITOR"}, wantFound: true},
+		{name: "missing editors are skipped", path: withCode, visual: "idea", editor: "emacs", want: Editor{Args: []string{Code}, Source: Code}, wantFound: true},
+		{name: "code", path: withCode, want: Editor{Args: []string{Code}, Source: Code}, wantFound: true},
+		{name: "none", path: withoutCode, visual: "idea"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PATH", tt.path)
+			t.Setenv("VISUAL", tt.visual)
+			t.Setenv("EDITOR", tt.editor)
+
+			editor, found, err := Resolve(tt.configured)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.want, editor)
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("editor scripts require a POSIX shell")
+	}
+	bin, project := t.TempDir(), t.TempDir()
+	log := filepath.Join(t.TempDir(), "args")
+	writeEditor(t, bin, "fake-editor", `echo "$(pwd -P) $*" > "`+log+"\"\n")
+	writeEditor(t, bin, "broken-editor", "exit 1\n")
+
+	require.NoError(t, Open(context.Background(), Editor{Args: []string{filepath.Join(bin, "fake-editor"), "--wait"}}, project))
+	data, err := os.ReadFile(log)
+	require.NoError(t, err)
+	projectDir, err := filepath.EvalSymlinks(project)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), projectDir+" --wait "+project)
+
+	err = Open(context.Background(), Editor{Args: []string{filepath.Join(bin, "broken-editor")}}, project)
+	assert.ErrorContains(t, err, "broken-editor failed: exit status 1")
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AddIgnores appends the patterns dir/.gitignore does not list yet under an
+// "# Acontext" heading, creating the file if needed. It returns the patterns
+// it added.
+func AddIgnores(dir string, patterns ...string) ([]string, error) {
+	path := filepath.Join(dir, ".gitignore")
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+
+	listed := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(existing))
+	for scanner.Scan() {
+		listed[strings.TrimSpace(scanner.Text())] = true
+	}
+	var added []string
+	for _, pattern := range patterns {
+		if !listed[pattern] {
+			listed[pattern] = true
+			added = append(added, pattern)
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	var content strings.Builder
+	content.Write(existing)
+	if len(existing) > 0 {
+		if !bytes.HasSuffix(existing, []byte("\n")) {
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+	}
+	content.WriteString("# Acontext\n")
+	for _, pattern := range added {
+		content.WriteString(pattern + "\n")
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write .gitignore: %w", err)
+	}
+	return added, nil
+}
@@ -0,0 +1,59 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddIgnores(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  string
+		wantAdded []string
+		want      string
+	}{
+		{
+			name:      "no gitignore",
+			wantAdded: []string{".env", ".acontext-backup/"},
+			want:      "# Acontext\n.env\n.acontext-backup/\n",
+		},
+		{
+			name:      "some listed",
+			existing:  "node_modules/\n.env\n",
+			wantAdded: []string{".acontext-backup/"},
+			want:      "node_modules/\n.env\n\n# Acontext\n.acontext-backup/\n",
+		},
+		{
+			name:      "no trailing newline",
+			existing:  "dist/",
+			wantAdded: []string{".env", ".acontext-backup/"},
+			want:      "dist/\n\n# Acontext\n.env\n.acontext-backup/\n",
+		},
+		{
+			name:     "all listed",
+			existing: ".env\n  .acontext-backup/\n",
+			want:     ".env\n  .acontext-backup/\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, ".gitignore")
+			if tt.existing != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tt.existing), 0644))
+			}
+
+			added, err := AddIgnores(dir, ".env", ".acontext-backup/")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAdded, added)
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(content))
+		})
+	}
+}
@@ -1,14 +1,21 @@
 package git
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
 )
 
-// Init initializes Git repository
-func Init(projectDir string) error {
+// DefaultBranch is the initial branch name used when none is specified
+const DefaultBranch = "main"
+
+// Init initializes Git repository with the given initial branch
+func Init(ctx context.Context, projectDir string, branch string) error {
 	// Check if already a Git repository
 	gitDir := filepath.Join(projectDir, ".git")
 	if _, err := os.Stat(gitDir); err == nil {
@@ -16,15 +23,27 @@ func Init(projectDir string) error {
 	}
 
 	// Initialize Git repository
-	cmd := exec.Command("git", "init")
+	cmd := exec.CommandContext(ctx, "git", "init")
 	cmd.Dir = projectDir
 	cmd.Stderr = os.Stderr
 	cmd.Stdout = os.Stdout
 
-	if err := cmd.Run(); err != nil {
+	if err := logging.Run(ctx, cmd); err != nil {
 		return fmt.Errorf("failed to initialize git: %w", err)
 	}
 
+	// Set the initial branch instead of relying on the user's init.defaultBranch.
+	// symbolic-ref works on all git versions, unlike `git init -b`.
+	if branch == "" {
+		branch = DefaultBranch
+	}
+	cmd = exec.CommandContext(ctx, "git", "symbolic-ref", "HEAD", "refs/heads/"+branch)
+	cmd.Dir = projectDir
+	cmd.Stderr = os.Stderr
+	if err := logging.Run(ctx, cmd); err != nil {
+		return fmt.Errorf("failed to set initial branch %s: %w", branch, err)
+	}
+
 	// Create or update .gitignore
 	if err := ensureGitignore(projectDir); err != nil {
 		// Non-fatal error, just log warning
@@ -32,18 +51,18 @@ func Init(projectDir string) error {
 	}
 
 	// Create initial commit
-	cmd = exec.Command("git", "add", ".")
+	cmd = exec.CommandContext(ctx, "git", "add", ".")
 	cmd.Dir = projectDir
 	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := logging.Run(ctx, cmd); err != nil {
 		// Non-fatal error, skip initial commit
 		return nil
 	}
 
-	cmd = exec.Command("git", "commit", "-m", "Initial commit from acontext-cli")
+	cmd = exec.CommandContext(ctx, "git", "commit", "-m", "Initial commit from acontext-cli")
 	cmd.Dir = projectDir
 	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := logging.Run(ctx, cmd); err != nil {
 		// Non-fatal error, skip initial commit
 		return nil
 	}
@@ -51,6 +70,17 @@ func Init(projectDir string) error {
 	return nil
 }
 
+// IsInsideWorkTree reports whether dir is inside an existing git work tree
+func IsInsideWorkTree(ctx context.Context, dir string) bool {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--is-inside-work-tree")
+	cmd.Dir = dir
+	output, err := logging.Output(ctx, cmd)
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(output)) == "true"
+}
+
 // ensureGitignore ensures .gitignore file exists
 func ensureGitignore(projectDir string) error {
 	gitignorePath := filepath.Join(projectDir, ".gitignore")
@@ -103,8 +133,10 @@ temp/
 # Build outputs
 *.exe
 *.out
+
+# Acontext
+.acontext-backup/
 `
 
 	return os.WriteFile(gitignorePath, []byte(content), 0644)
 }
-
@@ -1,7 +1,9 @@
 package git
 
 import (
+	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
@@ -63,3 +65,28 @@ func TestEnsureGitignore(t *testing.T) {
 	}
 }
 
+
+func TestInitBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "README.md"), []byte("# Test\n"), 0644))
+
+	err := Init(context.Background(), projectDir, "trunk")
+	require.NoError(t, err)
+	assert.True(t, IsInsideWorkTree(context.Background(), projectDir))
+
+	head, err := os.ReadFile(filepath.Join(projectDir, ".git", "HEAD"))
+	require.NoError(t, err)
+	assert.Equal(t, "ref: refs/heads/trunk\n", string(head))
+}
+
+func TestIsInsideWorkTree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	assert.False(t, IsInsideWorkTree(context.Background(), t.TempDir()))
+}
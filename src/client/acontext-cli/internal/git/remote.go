@@ -0,0 +1,117 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+)
+
+// DefaultRemote is the name of the remote added for a new project
+const DefaultRemote = "origin"
+
+// ErrAuthentication is returned by Push when the remote rejected the
+// credentials, or needed some that could not be asked for
+var ErrAuthentication = errors.New("authentication failed")
+
+// ErrRepositoryNotFound is returned when the remote reports that the
+// repository does not exist. Hosts such as GitHub report repositories the
+// credentials cannot access the same way.
+var ErrRepositoryNotFound = errors.New("repository not found")
+
+// authFailures are git and ssh messages that mean the remote did not
+// authorize the operation
+var authFailures = []string{
+	"authentication failed",
+	"could not read username",
+	"could not read password",
+	"terminal prompts disabled",
+	"invalid username or password",
+	"permission denied",
+	"access denied",
+	"host key verification failed",
+	"the requested url returned error: 401",
+	"the requested url returned error: 403",
+}
+
+// notFoundPattern matches git and git host messages that mean the remote
+// repository does not exist
+var notFoundPattern = regexp.MustCompile(`repository not found|repository '[^']*' (not found|does not exist)|does not appear to be a git repository|the requested url returned error: 404`)
+
+// AddRemote adds the remote name pointing at url to the repository in dir
+func AddRemote(ctx context.Context, dir, name, url string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "remote", "add", name, url)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	if err := logging.Run(ctx, cmd); err != nil {
+		return fmt.Errorf("failed to add remote %s: %w", name, commandError(err, stderr.String()))
+	}
+	return nil
+}
+
+// Push pushes branch to remote and sets it as the upstream. Git never
+// prompts for credentials, so a push that needs them fails with
+// ErrAuthentication instead of hanging.
+func Push(ctx context.Context, dir, remote, branch string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "push", "--set-upstream", remote, branch)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Stderr = &stderr
+	if err := logging.Run(ctx, cmd); err != nil {
+		err = commandError(err, stderr.String())
+		if IsAuthFailure(stderr.String()) {
+			err = fmt.Errorf("%w: %w", ErrAuthentication, err)
+		}
+		return fmt.Errorf("failed to push to %s: %w", remote, err)
+	}
+	return nil
+}
+
+// PushCommand returns the command that pushes branch to remote by hand
+func PushCommand(remote, branch string) string {
+	return fmt.Sprintf("git push --set-upstream %s %s", remote, branch)
+}
+
+// IsAuthFailure reports whether git's stderr says the remote did not
+// authorize the operation
+func IsAuthFailure(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, failure := range authFailures {
+		if strings.Contains(stderr, failure) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound reports whether git's stderr says the remote repository does
+// not exist
+func IsNotFound(stderr string) bool {
+	return notFoundPattern.MatchString(strings.ToLower(stderr))
+}
+
+// commandError adds what git printed on stderr to explain err: its first
+// fatal or error line, or else its last line
+func commandError(err error, stderr string) error {
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	reason := strings.TrimSpace(lines[len(lines)-1])
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "fatal:") || strings.HasPrefix(line, "error:") {
+			reason = line
+			break
+		}
+	}
+	if reason == "" {
+		return err
+	}
+	return fmt.Errorf("%w: %s", err, reason)
+}
@@ -0,0 +1,179 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRemoteAndPush(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	ctx := context.Background()
+
+	remoteDir := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", "--bare", remoteDir).Run())
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "README.md"), []byte("# Test\n"), 0644))
+	require.NoError(t, Init(ctx, projectDir, "trunk"))
+
+	require.NoError(t, AddRemote(ctx, projectDir, DefaultRemote, remoteDir))
+	err := AddRemote(ctx, projectDir, DefaultRemote, remoteDir)
+	assert.ErrorContains(t, err, "failed to add remote origin")
+	assert.ErrorContains(t, err, "error: remote origin already exists")
+
+	require.NoError(t, Push(ctx, projectDir, DefaultRemote, "trunk"))
+	out, err := exec.Command("git", "-C", remoteDir, "log", "--format=%s", "trunk").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "Initial commit from acontext-cli", strings.TrimSpace(string(out)))
+
+	err = Push(ctx, projectDir, DefaultRemote, "missing")
+	assert.ErrorContains(t, err, "failed to push to origin")
+	assert.False(t, errors.Is(err, ErrAuthentication))
+}
+
+func TestIsAuthFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   bool
+	}{
+		{
+			name:   "https credentials",
+			stderr: "fatal: could not read Username for 'https://github.com': terminal prompts disabled\n",
+			want:   true,
+		},
+		{
+			name:   "https rejected",
+			stderr: "remote: Invalid username or password.\nfatal: Authentication failed for 'https://github.com/org/repo.git/'\n",
+			want:   true,
+		},
+		{
+			name:   "ssh key",
+			stderr: "git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository.\n",
+			want:   true,
+		},
+		{
+			name:   "https forbidden",
+			stderr: "fatal: unable to access 'https://github.com/org/repo.git/': The requested URL returned error: 403\n",
+			want:   true,
+		},
+		{
+			name:   "ssh host key",
+			stderr: "Host key verification failed.\nfatal: Could not read from remote repository.\n",
+			want:   true,
+		},
+		{
+			name:   "rejected push",
+			stderr: " ! [rejected]        main -> main (fetch first)\nerror: failed to push some refs to 'github.com:org/repo.git'\n",
+		},
+		{
+			name:   "unknown host",
+			stderr: "fatal: unable to access 'https://example.invalid/repo.git/': Could not resolve host: example.invalid\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsAuthFailure(tt.stderr))
+		})
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   bool
+	}{
+		{
+			name:   "github https",
+			stderr: "remote: Repository not found.\nfatal: repository 'https://github.com/org/missing.git/' not found\n",
+			want:   true,
+		},
+		{
+			name:   "github ssh",
+			stderr: "ERROR: Repository not found.\nfatal: Could not read from remote repository.\n",
+			want:   true,
+		},
+		{
+			name:   "local path",
+			stderr: "fatal: '/tmp/missing' does not appear to be a git repository\n",
+			want:   true,
+		},
+		{
+			name:   "local directory",
+			stderr: "fatal: repository '/tmp/missing' does not exist\n",
+			want:   true,
+		},
+		{
+			name:   "https 404",
+			stderr: "fatal: unable to access 'https://example.com/org/repo.git/': The requested URL returned error: 404\n",
+			want:   true,
+		},
+		{
+			name:   "missing branch",
+			stderr: "warning: Could not find remote branch v9 to clone.\nfatal: Remote branch v9 not found in upstream origin\n",
+		},
+		{
+			name:   "authentication",
+			stderr: "fatal: Authentication failed for 'https://github.com/org/repo.git/'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsNotFound(tt.stderr))
+		})
+	}
+}
+
+func TestCommandError(t *testing.T) {
+	err := errors.New("exit status 128")
+
+	tests := []struct {
+		name   string
+		stderr string
+		want   string
+	}{
+		{
+			name: "no stderr",
+			want: "exit status 128",
+		},
+		{
+			name:   "fatal line",
+			stderr: "remote: Invalid username or password.\nfatal: Authentication failed\nhint: check your token\n",
+			want:   "exit status 128: fatal: Authentication failed",
+		},
+		{
+			name:   "last line",
+			stderr: "To github.com:org/repo.git\n ! [rejected] main -> main (fetch first)\n",
+			want:   "exit status 128: ! [rejected] main -> main (fetch first)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := commandError(err, tt.stderr)
+			assert.EqualError(t, got, tt.want)
+			assert.ErrorIs(t, got, err)
+		})
+	}
+}
+
+func TestPushCommand(t *testing.T) {
+	assert.Equal(t, "git push --set-upstream origin main", PushCommand(DefaultRemote, DefaultBranch))
+}
@@ -0,0 +1,170 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+)
+
+// Command is a dependency install command, e.g. npm install
+type Command struct {
+	Args []string
+	Line string   // Shell command line, run with the system shell instead of Args
+	Env  []string // Added to the environment, as KEY=value
+}
+
+// String returns the command as it would be typed in a shell
+func (c Command) String() string {
+	if c.Line != "" {
+		return c.Line
+	}
+	return logging.QuoteArgs(c.Args)
+}
+
+// argv returns the program and arguments to run
+func (c Command) argv() []string {
+	if c.Line == "" {
+		return c.Args
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", c.Line}
+	}
+	return []string{"sh", "-c", c.Line}
+}
+
+// Detect returns the command that installs the dependencies of the project in
+// dir. A non-empty override, e.g. the install command from the template
+// manifest, takes precedence. Otherwise it is chosen from the project files:
+// npm for package.json, Poetry for a Poetry pyproject.toml, pip for
+// requirements.txt and go mod download for go.mod. The override is run with
+// the system shell. It returns false when there is nothing to install.
+func Detect(dir string, override string) (Command, bool) {
+	if line := strings.TrimSpace(override); line != "" {
+		return Command{Line: line}, true
+	}
+
+	if fileExists(filepath.Join(dir, "package.json")) {
+		return Command{Args: []string{"npm", "install"}}, true
+	}
+	if isPoetryProject(dir) {
+		return Command{Args: []string{"poetry", "install"}}, true
+	}
+	if fileExists(filepath.Join(dir, "requirements.txt")) {
+		return Command{Args: []string{"pip", "install", "-r", "requirements.txt"}}, true
+	}
+	if fileExists(filepath.Join(dir, "go.mod")) {
+		return Command{Args: []string{"go", "mod", "download"}}, true
+	}
+	return Command{}, false
+}
+
+// Languages detected from project files
+const (
+	LanguageTypeScript = "typescript"
+	LanguagePython     = "python"
+	LanguageGo         = "go"
+)
+
+// DetectLanguage returns the language of the project in dir, chosen from its
+// project files in the same order as Detect: package.json, then
+// pyproject.toml or requirements.txt, then go.mod. It returns "" when there
+// are none.
+func DetectLanguage(dir string) string {
+	switch {
+	case fileExists(filepath.Join(dir, "package.json")):
+		return LanguageTypeScript
+	case fileExists(filepath.Join(dir, "pyproject.toml")), fileExists(filepath.Join(dir, "requirements.txt")):
+		return LanguagePython
+	case fileExists(filepath.Join(dir, "go.mod")):
+		return LanguageGo
+	}
+	return ""
+}
+
+// DetectTest returns the command that runs the tests of the project in dir.
+// A non-empty override, e.g. the test command from the template manifest,
+// takes precedence, and is run with the system shell. Otherwise it is chosen
+// from the project's language: npm test, pytest (through Poetry for a
+// Poetry project) or go test ./... . It returns false when the language is
+// unknown.
+func DetectTest(dir string, override string) (Command, bool) {
+	if line := strings.TrimSpace(override); line != "" {
+		return Command{Line: line}, true
+	}
+
+	switch DetectLanguage(dir) {
+	case LanguageTypeScript:
+		return Command{Args: []string{"npm", "test"}}, true
+	case LanguagePython:
+		if isPoetryProject(dir) {
+			return Command{Args: []string{"poetry", "run", "pytest"}}, true
+		}
+		return Command{Args: []string{"pytest"}}, true
+	case LanguageGo:
+		return Command{Args: []string{"go", "test", "./..."}}, true
+	}
+	return Command{}, false
+}
+
+// SDK returns the command that adds the Acontext SDK to the project in dir,
+// with the package manager that manages it. It returns false when there is no
+// SDK for the project's language, e.g., Go, which uses the HTTP API.
+func SDK(dir string) (Command, bool) {
+	switch DetectLanguage(dir) {
+	case LanguageTypeScript:
+		return Command{Args: []string{"npm", "install", "@acontext/acontext"}}, true
+	case LanguagePython:
+		if isPoetryProject(dir) {
+			return Command{Args: []string{"poetry", "add", "acontext"}}, true
+		}
+		return Command{Args: []string{"pip", "install", "acontext"}}, true
+	}
+	return Command{}, false
+}
+
+// Run runs c in dir, streaming its output
+func Run(ctx context.Context, dir string, c Command) error {
+	argv := c.argv()
+	if len(argv) == 0 {
+		return nil
+	}
+	if _, err := exec.LookPath(argv[0]); err != nil {
+		return fmt.Errorf("%s is not installed", argv[0])
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	killProcessGroup(cmd)
+	if err := logging.Run(ctx, cmd); err != nil {
+		return fmt.Errorf("%s failed: %w", c, err)
+	}
+	return nil
+}
+
+// isPoetryProject reports whether the project in dir is managed by Poetry
+func isPoetryProject(dir string) bool {
+	if fileExists(filepath.Join(dir, "poetry.lock")) {
+		return true
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "pyproject.toml"))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(data), "[tool.poetry]")
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
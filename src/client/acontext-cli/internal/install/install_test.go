@@ -0,0 +1,125 @@
+package install
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		override string
+		want     Command
+		wantOK   bool
+	}{
+		{name: "npm", files: map[string]string{"package.json": "{}"}, want: Command{Args: []string{"npm", "install"}}, wantOK: true},
+		{name: "poetry pyproject", files: map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"app\"\n"}, want: Command{Args: []string{"poetry", "install"}}, wantOK: true},
+		{name: "poetry lock", files: map[string]string{"pyproject.toml": "[project]\n", "poetry.lock": ""}, want: Command{Args: []string{"poetry", "install"}}, wantOK: true},
+		{name: "pip", files: map[string]string{"requirements.txt": "openai\n"}, want: Command{Args: []string{"pip", "install", "-r", "requirements.txt"}}, wantOK: true},
+		{name: "go", files: map[string]string{"go.mod": "module my-agent\n"}, want: Command{Args: []string{"go", "mod", "download"}}, wantOK: true},
+		{name: "poetry before pip", files: map[string]string{"poetry.lock": "", "requirements.txt": ""}, want: Command{Args: []string{"poetry", "install"}}, wantOK: true},
+		{name: "manifest override", files: map[string]string{"package.json": "{}"}, override: "pnpm install --frozen-lockfile", want: Command{Line: "pnpm install --frozen-lockfile"}, wantOK: true},
+		{name: "nothing to install", files: map[string]string{"README.md": "# App\n"}},
+		{name: "plain pyproject", files: map[string]string{"pyproject.toml": "[project]\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+			}
+			command, ok := Detect(dir, tt.override)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, command)
+		})
+	}
+}
+
+func TestDetectTest(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		override string
+		want     Command
+		wantOK   bool
+	}{
+		{name: "npm", files: map[string]string{"package.json": "{}"}, want: Command{Args: []string{"npm", "test"}}, wantOK: true},
+		{name: "poetry", files: map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"app\"\n"}, want: Command{Args: []string{"poetry", "run", "pytest"}}, wantOK: true},
+		{name: "pip", files: map[string]string{"requirements.txt": "openai\n"}, want: Command{Args: []string{"pytest"}}, wantOK: true},
+		{name: "go", files: map[string]string{"go.mod": "module my-agent\n"}, want: Command{Args: []string{"go", "test", "./..."}}, wantOK: true},
+		{name: "manifest override", files: map[string]string{"package.json": "{}"}, override: "npm run test:ci", want: Command{Line: "npm run test:ci"}, wantOK: true},
+		{name: "unknown language", files: map[string]string{"README.md": "# App\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+			}
+			command, ok := DetectTest(dir, tt.override)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, command)
+		})
+	}
+}
+
+func TestSDK(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		wantLanguage string
+		want         Command
+		wantOK       bool
+	}{
+		{name: "npm", files: map[string]string{"package.json": "{}"}, wantLanguage: LanguageTypeScript, want: Command{Args: []string{"npm", "install", "@acontext/acontext"}}, wantOK: true},
+		{name: "poetry", files: map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"app\"\n"}, wantLanguage: LanguagePython, want: Command{Args: []string{"poetry", "add", "acontext"}}, wantOK: true},
+		{name: "pip", files: map[string]string{"requirements.txt": "openai\n"}, wantLanguage: LanguagePython, want: Command{Args: []string{"pip", "install", "acontext"}}, wantOK: true},
+		{name: "plain pyproject", files: map[string]string{"pyproject.toml": "[project]\n"}, wantLanguage: LanguagePython, want: Command{Args: []string{"pip", "install", "acontext"}}, wantOK: true},
+		{name: "go has no SDK", files: map[string]string{"go.mod": "module my-agent\n"}, wantLanguage: LanguageGo},
+		{name: "unknown", files: map[string]string{"README.md": "# App\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+			}
+			assert.Equal(t, tt.wantLanguage, DetectLanguage(dir))
+			command, ok := SDK(dir)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, command)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	dir := t.TempDir()
+
+	require.NoError(t, Run(context.Background(), dir, Command{Line: `touch "installed file"`}))
+	assert.FileExists(t, filepath.Join(dir, "installed file"))
+
+	err := Run(context.Background(), dir, Command{Line: "exit 3"})
+	assert.ErrorContains(t, err, "exit 3 failed")
+
+	err = Run(context.Background(), dir, Command{Args: []string{"acontext-missing-installer"}})
+	assert.ErrorContains(t, err, "acontext-missing-installer is not installed")
+}
+
+func TestCommandString(t *testing.T) {
+	assert.Equal(t, "pip install -r requirements.txt", Command{Args: []string{"pip", "install", "-r", "requirements.txt"}}.String())
+	assert.Equal(t, `sh -c "echo hi"`, Command{Args: []string{"sh", "-c", "echo hi"}}.String())
+	assert.Equal(t, "npm ci && npm run build", Command{Line: "npm ci && npm run build"}.String())
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package install
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup runs cmd in its own process group and kills the whole
+// group on cancel, so processes started by an install script (a shell
+// running npm, which runs node, ...) do not outlive an interrupted install
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,7 @@
+package install
+
+import "os/exec"
+
+// killProcessGroup keeps the default cancellation on Windows, which kills
+// only the direct child
+func killProcessGroup(cmd *exec.Cmd) {}
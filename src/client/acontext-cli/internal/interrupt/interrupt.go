@@ -0,0 +1,88 @@
+// Package interrupt turns Ctrl-C and SIGTERM into context cancellation, so
+// commands can stop their subprocesses and clean up before the CLI exits.
+package interrupt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+)
+
+// ErrInterrupted is the cancellation cause of a context stopped by a signal
+var ErrInterrupted = errors.New("interrupted")
+
+var (
+	received  atomic.Bool
+	delegated atomic.Int32
+)
+
+// exit is replaced in tests
+var exit = os.Exit
+
+// Install returns a context that is cancelled with ErrInterrupted on the
+// first SIGINT or SIGTERM. A second signal exits immediately with
+// clierror.Interrupted, skipping any cleanup still in progress. stop
+// releases the signal handler.
+func Install(parent context.Context) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		count := 0
+		for {
+			select {
+			case sig := <-signals:
+				count++
+				received.Store(true)
+				if count > 1 {
+					fmt.Fprintln(os.Stderr, "\n⏹️  Forced exit")
+					exit(clierror.Interrupted)
+					return
+				}
+				if sig == os.Interrupt && delegated.Load() > 0 {
+					// The foreground process got the same Ctrl-C from the terminal
+					continue
+				}
+				fmt.Fprintln(os.Stderr, "\n⏹️  Interrupted, cleaning up (press Ctrl-C again to force quit)...")
+				cancel(ErrInterrupted)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	stop = func() {
+		signal.Stop(signals)
+		close(done)
+		cancel(nil)
+	}
+	return ctx, stop
+}
+
+// Delegate leaves the first Ctrl-C to a foreground subprocess until release
+// is called. The terminal delivers Ctrl-C to the whole process group, so a
+// subprocess such as docker compose already stops gracefully on its own;
+// cancelling the context as well would interrupt it a second time and force
+// an immediate kill. SIGTERM, which only the CLI receives, still cancels.
+func Delegate() (release func()) {
+	delegated.Add(1)
+	return func() { delegated.Add(-1) }
+}
+
+// Received reports whether the CLI received an interrupt
+func Received() bool {
+	return received.Load()
+}
+
+// IsInterrupted reports whether ctx was cancelled by an interrupt
+func IsInterrupted(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrInterrupted)
+}
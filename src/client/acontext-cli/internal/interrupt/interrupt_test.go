@@ -0,0 +1,83 @@
+package interrupt
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendInterrupt(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("sending signals to the current process is not supported on Windows")
+	}
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	require.NoError(t, process.Signal(os.Interrupt))
+}
+
+func installForTest(t *testing.T) (context.Context, chan int) {
+	t.Helper()
+	received.Store(false)
+	exits := make(chan int, 1)
+	exit = func(code int) { exits <- code }
+	ctx, stop := Install(context.Background())
+	t.Cleanup(func() {
+		stop()
+		exit = os.Exit
+		received.Store(false)
+	})
+	return ctx, exits
+}
+
+func TestInstall(t *testing.T) {
+	ctx, exits := installForTest(t)
+
+	sendInterrupt(t)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context was not cancelled")
+	}
+	assert.True(t, IsInterrupted(ctx))
+	assert.True(t, Received())
+
+	// A second interrupt forces the exit
+	sendInterrupt(t)
+	select {
+	case code := <-exits:
+		assert.Equal(t, clierror.Interrupted, code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("second interrupt did not exit")
+	}
+}
+
+func TestDelegate(t *testing.T) {
+	ctx, _ := installForTest(t)
+	release := Delegate()
+	defer release()
+
+	sendInterrupt(t)
+	require.Eventually(t, Received, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, ctx.Err(), "a delegated interrupt must not cancel the context")
+	assert.False(t, IsInterrupted(ctx))
+}
+
+func TestIsInterrupted(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	assert.False(t, IsInterrupted(ctx))
+
+	parent, cancelParent := context.WithCancelCause(context.Background())
+	child, cancelChild := context.WithCancel(parent)
+	defer cancelChild()
+	cancelParent(ErrInterrupted)
+	assert.True(t, IsInterrupted(child))
+}
@@ -0,0 +1,165 @@
+// Package license renders the LICENSE files of new projects from the known
+// SPDX licenses
+package license
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// None is the identifier that asks for no LICENSE file
+const None = "none"
+
+// FileName is the file a license is written to, relative to the project
+const FileName = "LICENSE"
+
+//go:embed texts
+var texts embed.FS
+
+// License is a known SPDX license
+type License struct {
+	ID   string // SPDX identifier, e.g., Apache-2.0
+	Name string // Full name, e.g., Apache License 2.0
+	text string // File under texts, a text/template filled with Year and Author
+}
+
+// Known lists the licenses a LICENSE file can be generated for
+var Known = []License{
+	{ID: "MIT", Name: "MIT License", text: "MIT.txt"},
+	{ID: "Apache-2.0", Name: "Apache License 2.0", text: "Apache-2.0.txt"},
+	{ID: "BSD-2-Clause", Name: `BSD 2-Clause "Simplified" License`, text: "BSD-2-Clause.txt"},
+	{ID: "BSD-3-Clause", Name: `BSD 3-Clause "New" or "Revised" License`, text: "BSD-3-Clause.txt"},
+	{ID: "ISC", Name: "ISC License", text: "ISC.txt"},
+	{ID: "MPL-2.0", Name: "Mozilla Public License 2.0", text: "MPL-2.0.txt"},
+	{ID: "GPL-2.0-only", Name: "GNU General Public License v2.0 only", text: "GPL-2.0.txt"},
+	{ID: "GPL-2.0-or-later", Name: "GNU General Public License v2.0 or later", text: "GPL-2.0.txt"},
+	{ID: "GPL-3.0-only", Name: "GNU General Public License v3.0 only", text: "GPL-3.0.txt"},
+	{ID: "GPL-3.0-or-later", Name: "GNU General Public License v3.0 or later", text: "GPL-3.0.txt"},
+	{ID: "LGPL-2.1-only", Name: "GNU Lesser General Public License v2.1 only", text: "LGPL-2.1.txt"},
+	{ID: "LGPL-2.1-or-later", Name: "GNU Lesser General Public License v2.1 or later", text: "LGPL-2.1.txt"},
+	{ID: "LGPL-3.0-only", Name: "GNU Lesser General Public License v3.0 only", text: "LGPL-3.0.txt"},
+	{ID: "LGPL-3.0-or-later", Name: "GNU Lesser General Public License v3.0 or later", text: "LGPL-3.0.txt"},
+	{ID: "CC0-1.0", Name: "Creative Commons Zero v1.0 Universal", text: "CC0-1.0.txt"},
+	{ID: "Unlicense", Name: "The Unlicense", text: "Unlicense.txt"},
+}
+
+// IDs returns the identifiers of the Known licenses
+func IDs() []string {
+	ids := make([]string, len(Known))
+	for i, license := range Known {
+		ids[i] = license.ID
+	}
+	return ids
+}
+
+// UnknownError is returned by Lookup for an identifier that is not Known
+type UnknownError struct {
+	ID          string
+	Suggestions []string // Known identifiers close to ID, closest first
+}
+
+func (e *UnknownError) Error() string {
+	message := fmt.Sprintf("unknown license %q", e.ID)
+	if len(e.Suggestions) > 0 {
+		return message + ", did you mean " + strings.Join(e.Suggestions, " or ") + "?"
+	}
+	return message + " (known: " + strings.Join(IDs(), ", ") + ", or none)"
+}
+
+// Lookup returns the Known license identified by id, ignoring case, or an
+// *UnknownError suggesting the identifiers close to it
+func Lookup(id string) (License, error) {
+	for _, license := range Known {
+		if strings.EqualFold(license.ID, id) {
+			return license, nil
+		}
+	}
+	return License{}, &UnknownError{ID: id, Suggestions: suggest(id)}
+}
+
+// Render returns the text of the license with the copyright year and
+// holder filled in, where the license has them
+func (l License) Render(author string, year int) (string, error) {
+	tmpl, err := template.ParseFS(texts, "texts/"+l.text)
+	if err != nil {
+		return "", fmt.Errorf("failed to load the %s license: %w", l.ID, err)
+	}
+	var text strings.Builder
+	if err := tmpl.Execute(&text, struct {
+		Year   int
+		Author string
+	}{year, author}); err != nil {
+		return "", fmt.Errorf("failed to render the %s license: %w", l.ID, err)
+	}
+	return text.String(), nil
+}
+
+// maxSuggestionDistance is how many edits away from a typo a suggested
+// identifier may be
+const maxSuggestionDistance = 2
+
+// suggest returns the Known identifiers close to id, ignoring case and
+// punctuation: those it is a prefix of, such as GPL-3.0-only for gpl3, or
+// else the closest ones at most maxSuggestionDistance edits away
+func suggest(id string) []string {
+	id = normalize(id)
+	if id == "" {
+		return nil
+	}
+	var prefixed []string
+	for _, license := range Known {
+		if strings.HasPrefix(normalize(license.ID), id) {
+			prefixed = append(prefixed, license.ID)
+		}
+	}
+	if len(prefixed) > 0 {
+		return prefixed
+	}
+
+	var closest []string
+	best := maxSuggestionDistance + 1
+	for _, license := range Known {
+		switch d := editDistance(id, normalize(license.ID)); {
+		case d < best:
+			best, closest = d, []string{license.ID}
+		case d == best:
+			closest = append(closest, license.ID)
+		}
+	}
+	return closest
+}
+
+// normalize lowercases id and drops its punctuation
+func normalize(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '.', '_', ' ':
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, id)
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	current := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
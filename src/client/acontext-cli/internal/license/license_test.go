@@ -0,0 +1,62 @@
+package license
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	license, err := Lookup("apache-2.0")
+	require.NoError(t, err)
+	assert.Equal(t, "Apache-2.0", license.ID)
+
+	tests := []struct {
+		id          string
+		suggestions []string
+	}{
+		{id: "MTI", suggestions: []string{"MIT"}},
+		{id: "Apache2", suggestions: []string{"Apache-2.0"}},
+		{id: "gpl3", suggestions: []string{"GPL-3.0-only", "GPL-3.0-or-later"}},
+		{id: "BSD-3-Clauses", suggestions: []string{"BSD-3-Clause"}},
+		{id: "proprietary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			_, err := Lookup(tt.id)
+			var unknown *UnknownError
+			require.ErrorAs(t, err, &unknown)
+			assert.Equal(t, tt.suggestions, unknown.Suggestions)
+		})
+	}
+
+	_, err = Lookup("MTI")
+	assert.EqualError(t, err, `unknown license "MTI", did you mean MIT?`)
+	_, err = Lookup("proprietary")
+	assert.ErrorContains(t, err, "(known: MIT, Apache-2.0,")
+}
+
+func TestRender(t *testing.T) {
+	for _, license := range Known {
+		t.Run(license.ID, func(t *testing.T) {
+			text, err := license.Render("Jane Doe", 2026)
+			require.NoError(t, err)
+			assert.NotContains(t, text, "{{")
+			assert.True(t, strings.HasSuffix(text, "\n"))
+		})
+	}
+
+	mit, err := Lookup("MIT")
+	require.NoError(t, err)
+	text, err := mit.Render("Jane Doe", 2026)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Copyright (c) 2026 Jane Doe\n")
+
+	apache, err := Lookup("Apache-2.0")
+	require.NoError(t, err)
+	text, err = apache.Render("Jane Doe", 2026)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Copyright 2026 Jane Doe\n")
+}
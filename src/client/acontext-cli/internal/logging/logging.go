@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Verbosity levels selected with --quiet and --verbose
+const (
+	// Quiet logs errors only
+	Quiet = -1
+	// Normal logs warnings and errors (default)
+	Normal = 0
+	// Verbose also logs the subcommands that are spawned (-v)
+	Verbose = 1
+	// Debug logs everything (-vv)
+	Debug = 2
+)
+
+type contextKey struct{}
+
+// New returns a logger writing to w at the given verbosity
+func New(w io.Writer, verbosity int) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level: levelFor(verbosity),
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			// Timestamps add noise to interactive output
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// levelFor maps a verbosity to the lowest level that is logged
+func levelFor(verbosity int) slog.Level {
+	switch {
+	case verbosity <= Quiet:
+		return slog.LevelError
+	case verbosity == Normal:
+		return slog.LevelWarn
+	case verbosity == Verbose:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
+
+// NewContext returns a copy of ctx carrying logger
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or a logger that discards
+// everything if there is none
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.New(slog.DiscardHandler)
+}
+
+// Command logs the full argv and working directory of cmd before it is run,
+// and starts timing it as subprocess time of the Timings carried by ctx. The
+// returned function stops the timer: call it once cmd has exited.
+func Command(ctx context.Context, cmd *exec.Cmd) (done func()) {
+	done = TimingsFromContext(ctx).StartSubprocess()
+	logger := FromContext(ctx)
+	if !logger.Enabled(ctx, slog.LevelInfo) {
+		return done
+	}
+	attrs := []any{"argv", QuoteArgs(cmd.Args)}
+	if cmd.Dir != "" {
+		attrs = append(attrs, "dir", cmd.Dir)
+	}
+	logger.InfoContext(ctx, "running command", attrs...)
+	return done
+}
+
+// QuoteArgs joins args into a single shell-like string, quoting arguments
+// that contain spaces or quotes
+func QuoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\") {
+			quoted[i] = strconv.Quote(arg)
+		} else {
+			quoted[i] = arg
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Run logs and times cmd with Command, then runs it
+func Run(ctx context.Context, cmd *exec.Cmd) error {
+	defer Command(ctx, cmd)()
+	return cmd.Run()
+}
+
+// Output logs and times cmd with Command, then runs it and returns its
+// standard output
+func Output(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	defer Command(ctx, cmd)()
+	return cmd.Output()
+}
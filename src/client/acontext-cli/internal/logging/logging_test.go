@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLevels(t *testing.T) {
+	tests := []struct {
+		name      string
+		verbosity int
+		want      []string
+		notWant   []string
+	}{
+		{
+			name:      "quiet",
+			verbosity: Quiet,
+			want:      []string{"level=ERROR"},
+			notWant:   []string{"level=WARN", "level=INFO", "level=DEBUG"},
+		},
+		{
+			name:      "normal",
+			verbosity: Normal,
+			want:      []string{"level=ERROR", "level=WARN"},
+			notWant:   []string{"level=INFO", "level=DEBUG"},
+		},
+		{
+			name:      "verbose",
+			verbosity: Verbose,
+			want:      []string{"level=ERROR", "level=WARN", "level=INFO"},
+			notWant:   []string{"level=DEBUG"},
+		},
+		{
+			name:      "debug",
+			verbosity: 3,
+			want:      []string{"level=ERROR", "level=WARN", "level=INFO", "level=DEBUG"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&buf, tt.verbosity)
+			logger.Error("e")
+			logger.Warn("w")
+			logger.Info("i")
+			logger.Debug("d")
+
+			for _, s := range tt.want {
+				assert.Contains(t, buf.String(), s)
+			}
+			for _, s := range tt.notWant {
+				assert.NotContains(t, buf.String(), s)
+			}
+			assert.NotContains(t, buf.String(), "time=")
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Verbose)
+
+	ctx := NewContext(context.Background(), logger)
+	assert.Same(t, logger, FromContext(ctx))
+
+	// Without a logger nothing is written
+	FromContext(context.Background()).Error("dropped")
+	assert.Empty(t, buf.String())
+}
+
+func TestCommand(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := NewContext(context.Background(), New(&buf, Verbose))
+
+	cmd := exec.Command("git", "commit", "-m", "Initial commit")
+	cmd.Dir = "/tmp/project"
+	Command(ctx, cmd)
+
+	assert.Equal(t, `level=INFO msg="running command" argv="git commit -m \"Initial commit\"" dir=/tmp/project`+"\n", buf.String())
+
+	buf.Reset()
+	Command(NewContext(context.Background(), New(&buf, Normal)), cmd)
+	assert.Empty(t, buf.String())
+}
+
+func TestQuoteArgs(t *testing.T) {
+	assert.Equal(t, "docker compose -f a.yaml up -d", QuoteArgs([]string{"docker", "compose", "-f", "a.yaml", "up", "-d"}))
+	assert.Equal(t, `echo "" "a b"`, QuoteArgs([]string{"echo", "", "a b"}))
+}
@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type timingsKey struct{}
+
+// Timings records where the time of a command went: in spawned docker/git
+// processes, waiting on telemetry, or in the CLI itself. A nil *Timings
+// records nothing, so code timed with it does not need to check for one.
+type Timings struct {
+	now   func() time.Time
+	start time.Time
+
+	mu         sync.Mutex
+	running    int       // Subprocesses running now
+	since      time.Time // When running last went above zero
+	subprocess time.Duration
+	telemetry  time.Duration
+}
+
+// Breakdown is the time a command took, split into segments that sum to Total
+type Breakdown struct {
+	Total      time.Duration // From the start of the command until now
+	Subprocess time.Duration // At least one spawned docker/git process was running
+	Telemetry  time.Duration // Sending telemetry and waiting for it
+	CLI        time.Duration // Everything else
+}
+
+// NewTimings returns Timings for a command started at start
+func NewTimings(start time.Time) *Timings {
+	return newTimings(start, time.Now)
+}
+
+// newTimings returns Timings reading the time from now, a fake clock in tests
+func newTimings(start time.Time, now func() time.Time) *Timings {
+	return &Timings{now: now, start: start}
+}
+
+// WithTimings returns a copy of ctx carrying t
+func WithTimings(ctx context.Context, t *Timings) context.Context {
+	return context.WithValue(ctx, timingsKey{}, t)
+}
+
+// TimingsFromContext returns the Timings carried by ctx, or nil if there are
+// none
+func TimingsFromContext(ctx context.Context) *Timings {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(timingsKey{}).(*Timings)
+	return t
+}
+
+// StartSubprocess starts timing a spawned process, until the returned
+// function is called. Processes that run at the same time, e.g., docker
+// compose logs of several services, are counted once, so the subprocess time
+// never exceeds the total.
+func (t *Timings) StartSubprocess() (done func()) {
+	if t == nil {
+		return func() {}
+	}
+	t.mu.Lock()
+	if t.running == 0 {
+		t.since = t.now()
+	}
+	t.running++
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.running--
+			if t.running == 0 {
+				t.subprocess += t.now().Sub(t.since)
+			}
+		})
+	}
+}
+
+// StartTelemetry starts timing the telemetry of the command, until the
+// returned function is called
+func (t *Timings) StartTelemetry() (done func()) {
+	if t == nil {
+		return func() {}
+	}
+	started := t.now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.telemetry += t.now().Sub(started)
+		})
+	}
+}
+
+// Breakdown returns the time the command took so far. Subprocesses that are
+// still running count until now.
+func (t *Timings) Breakdown() Breakdown {
+	if t == nil {
+		return Breakdown{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	b := Breakdown{Total: now.Sub(t.start), Subprocess: t.subprocess, Telemetry: t.telemetry}
+	if t.running > 0 {
+		b.Subprocess += now.Sub(t.since)
+	}
+	b.CLI = max(b.Total-b.Subprocess-b.Telemetry, 0)
+	return b
+}
+
+// Round rounds the segments of b to multiples of m, keeping their sum: CLI
+// takes what is left of the rounded Total
+func (b Breakdown) Round(m time.Duration) Breakdown {
+	r := Breakdown{Total: b.Total.Round(m), Subprocess: b.Subprocess.Round(m), Telemetry: b.Telemetry.Round(m)}
+	r.CLI = max(r.Total-r.Subprocess-r.Telemetry, 0)
+	return r
+}
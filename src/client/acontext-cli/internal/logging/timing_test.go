@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a clock that only moves when advanced
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestTimingsBreakdown(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	timings := newTimings(clock.Now(), clock.Now)
+
+	clock.Advance(100 * time.Millisecond) // CLI
+	pull := timings.StartSubprocess()
+	clock.Advance(2 * time.Second)
+	logs := timings.StartSubprocess() // Overlaps the pull, counted once
+	clock.Advance(time.Second)
+	pull()
+	pull() // Calling done again does nothing
+	clock.Advance(500 * time.Millisecond)
+	logs()
+	clock.Advance(50 * time.Millisecond) // CLI
+	telemetry := timings.StartTelemetry()
+	clock.Advance(300 * time.Millisecond)
+	telemetry()
+	clock.Advance(50 * time.Millisecond) // CLI
+
+	b := timings.Breakdown()
+	assert.Equal(t, Breakdown{
+		Total:      4 * time.Second,
+		Subprocess: 3500 * time.Millisecond,
+		Telemetry:  300 * time.Millisecond,
+		CLI:        200 * time.Millisecond,
+	}, b)
+	assert.Equal(t, b.Total, b.Subprocess+b.Telemetry+b.CLI)
+}
+
+func TestTimingsBreakdownRunning(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	timings := newTimings(clock.Now(), clock.Now)
+
+	clock.Advance(time.Second)
+	timings.StartSubprocess()
+	clock.Advance(2 * time.Second)
+
+	b := timings.Breakdown()
+	assert.Equal(t, 2*time.Second, b.Subprocess, "a running subprocess counts until now")
+	assert.Equal(t, b.Total, b.Subprocess+b.Telemetry+b.CLI)
+}
+
+func TestBreakdownRound(t *testing.T) {
+	b := Breakdown{Total: 1003600 * time.Microsecond, Subprocess: 400600 * time.Microsecond, Telemetry: 200600 * time.Microsecond}
+	b.CLI = b.Total - b.Subprocess - b.Telemetry
+
+	r := b.Round(time.Millisecond)
+	assert.Equal(t, Breakdown{Total: 1004 * time.Millisecond, Subprocess: 401 * time.Millisecond, Telemetry: 201 * time.Millisecond, CLI: 402 * time.Millisecond}, r)
+	assert.Equal(t, r.Total, r.Subprocess+r.Telemetry+r.CLI)
+}
+
+func TestTimingsNil(t *testing.T) {
+	var timings *Timings
+	timings.StartSubprocess()()
+	timings.StartTelemetry()()
+	assert.Equal(t, Breakdown{}, timings.Breakdown())
+	assert.Nil(t, TimingsFromContext(context.Background()))
+}
+
+func TestRunTimesSubprocess(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	timings := newTimings(clock.Now(), clock.Now)
+	ctx := WithTimings(context.Background(), timings)
+
+	// The timer is stopped even when the command cannot be started
+	assert.Error(t, Run(ctx, exec.Command("acontext-no-such-command")))
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+	assert.Zero(t, timings.running, "Run stops the timer once the command exited")
+}
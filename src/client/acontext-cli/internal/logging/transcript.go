@@ -0,0 +1,264 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedactedValue replaces the registered secrets in a transcript
+const RedactedValue = "[redacted]"
+
+// transcriptDrainTimeout is how long Close waits for the output still in
+// flight, which a spawned process that outlives the command may never finish
+const transcriptDrainTimeout = time.Second
+
+var (
+	secretsMu sync.Mutex
+	secrets   []string
+)
+
+// Redact registers values that are never written to a transcript, e.g., the
+// API keys a command prompts for or prints. The console output is unchanged.
+func Redact(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, value := range values {
+		if value != "" {
+			secrets = append(secrets, value)
+		}
+	}
+}
+
+// redact replaces the registered secrets in line
+func redact(line string) string {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, secret := range secrets {
+		line = strings.ReplaceAll(line, secret, RedactedValue)
+	}
+	return line
+}
+
+// ansiEscape matches the color and cursor control sequences stripped from a
+// transcript
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// Transcript tees everything written to os.Stdout and os.Stderr, including
+// the output of spawned processes, to a log file. Each line is prefixed with
+// a timestamp and the stream it was written to, with color codes stripped
+// and registered secrets redacted; the console output is unchanged.
+type Transcript struct {
+	// Stdout and Stderr are the console streams os.Stdout and os.Stderr
+	// replaced
+	Stdout *os.File
+	Stderr *os.File
+
+	file    *os.File
+	mu      sync.Mutex // Serializes writes to file
+	now     func() time.Time
+	streams []*teeStream
+}
+
+// teeStream copies what is written to pipe to the console, if any, and
+// records it in the transcript line by line
+type teeStream struct {
+	name    string
+	pipe    *os.File // Write end, stands in for the console stream
+	console io.Writer
+	partial []byte
+	done    chan struct{}
+}
+
+// OpenTranscript opens the log file at path, truncating it unless appendMode
+// is set, and replaces os.Stdout and os.Stderr with pipes teeing to it.
+// Close restores them.
+func OpenTranscript(path string, appendMode bool) (*Transcript, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendMode {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	file, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	t := &Transcript{Stdout: os.Stdout, Stderr: os.Stderr, file: file, now: time.Now}
+	stdout, err := t.tee("stdout", os.Stdout)
+	if err != nil {
+		_ = t.Close("")
+		return nil, err
+	}
+	stderr, err := t.tee("stderr", os.Stderr)
+	if err != nil {
+		_ = t.Close("")
+		return nil, err
+	}
+	os.Stdout, os.Stderr = stdout, stderr
+	return t, nil
+}
+
+// Note records a line that was not printed, e.g., the command line
+func (t *Transcript) Note(format string, args ...any) {
+	t.writeLine("#", fmt.Sprintf(format, args...))
+}
+
+// Muted returns a stream that is only recorded in the transcript, standing
+// in for os.Stdout when --quiet hides it from the console
+func (t *Transcript) Muted() (*os.File, error) {
+	return t.tee("stdout", nil)
+}
+
+// Logger returns a logger writing to the console stderr at verbosity, and
+// every record, whatever the verbosity, to the transcript
+func (t *Transcript) Logger(verbosity int) *slog.Logger {
+	file := slog.NewTextHandler(transcriptWriter{t: t, name: "log"}, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			// Each transcript line is already timestamped
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	})
+	return slog.New(teeHandler{New(t.Stderr, verbosity).Handler(), file})
+}
+
+// Close stops teeing, records footer (e.g., the exit code) unless it is
+// empty, and restores os.Stdout and os.Stderr. Output still in flight is
+// waited for briefly, so footer comes last.
+func (t *Transcript) Close(footer string) error {
+	for _, stream := range t.streams {
+		_ = stream.pipe.Close()
+	}
+	deadline := time.After(transcriptDrainTimeout)
+	for _, stream := range t.streams {
+		select {
+		case <-stream.done:
+		case <-deadline:
+		}
+	}
+	os.Stdout, os.Stderr = t.Stdout, t.Stderr
+	if footer != "" {
+		t.writeLine("#", footer)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.file.Close()
+}
+
+// tee starts copying a new pipe to console and the transcript, and returns
+// its write end
+func (t *Transcript) tee(name string, console io.Writer) (*os.File, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log file pipe: %w", err)
+	}
+	stream := &teeStream{name: name, pipe: w, console: console, done: make(chan struct{})}
+	t.streams = append(t.streams, stream)
+	go func() {
+		defer close(stream.done)
+		defer func() {
+			_ = r.Close()
+		}()
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				if stream.console != nil {
+					_, _ = stream.console.Write(buf[:n])
+				}
+				t.record(stream, buf[:n])
+			}
+			if err != nil {
+				t.flush(stream)
+				return
+			}
+		}
+	}()
+	return w, nil
+}
+
+// record appends p to the partial line of stream, writing every line it
+// completes
+func (t *Transcript) record(stream *teeStream, p []byte) {
+	stream.partial = append(stream.partial, p...)
+	for {
+		i := bytes.IndexByte(stream.partial, '\n')
+		if i < 0 {
+			return
+		}
+		t.writeLine(stream.name, string(stream.partial[:i]))
+		stream.partial = stream.partial[i+1:]
+	}
+}
+
+// flush writes the last line of stream when it does not end with a newline
+func (t *Transcript) flush(stream *teeStream) {
+	if len(stream.partial) > 0 {
+		t.writeLine(stream.name, string(stream.partial))
+		stream.partial = nil
+	}
+}
+
+// writeLine writes one timestamped line. Only the text after the last
+// carriage return is kept, as on a terminal, so spinners and progress bars
+// leave their final state.
+func (t *Transcript) writeLine(name, line string) {
+	line = strings.TrimSuffix(line, "\r")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+	line = redact(ansiEscape.ReplaceAllString(line, ""))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = fmt.Fprintf(t.file, "%s %-6s %s\n", t.now().UTC().Format("2006-01-02T15:04:05.000Z"), name, line)
+}
+
+// transcriptWriter writes the records of a slog handler to a transcript
+type transcriptWriter struct {
+	t    *Transcript
+	name string
+}
+
+func (w transcriptWriter) Write(p []byte) (int, error) {
+	w.t.writeLine(w.name, strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+// teeHandler sends every record to both handlers
+type teeHandler [2]slog.Handler
+
+func (h teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h[0].Enabled(ctx, level) || h[1].Enabled(ctx, level)
+}
+
+func (h teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var err error
+	for _, handler := range h {
+		if handler.Enabled(ctx, record.Level) {
+			if handleErr := handler.Handle(ctx, record.Clone()); handleErr != nil {
+				err = handleErr
+			}
+		}
+	}
+	return err
+}
+
+func (h teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return teeHandler{h[0].WithAttrs(attrs), h[1].WithAttrs(attrs)}
+}
+
+func (h teeHandler) WithGroup(name string) slog.Handler {
+	return teeHandler{h[0].WithGroup(name), h[1].WithGroup(name)}
+}
@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timestamp matches the prefix of a transcript line
+var timestamp = regexp.MustCompile(`(?m)^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z `)
+
+func readTranscript(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return timestamp.ReplaceAllString(string(data), "")
+}
+
+func TestTranscript(t *testing.T) {
+	t.Cleanup(func() {
+		secretsMu.Lock()
+		secrets = nil
+		secretsMu.Unlock()
+	})
+	path := filepath.Join(t.TempDir(), "acontext.log")
+	stdout, stderr := os.Stdout, os.Stderr
+
+	transcript, err := OpenTranscript(path, false)
+	require.NoError(t, err)
+	transcript.Note("acontext %s", "test")
+	fmt.Fprintln(os.Stdout, "\x1b[36mhello\x1b[0m")
+	fmt.Fprintln(os.Stderr, "warning")
+	fmt.Fprint(os.Stdout, "⠋ working\r⠙ working\r✓ done\n")
+	Redact("sk-secret")
+	fmt.Fprintln(os.Stdout, `API_KEY="sk-secret"`)
+	child := exec.Command("sh", "-c", "echo from child")
+	child.Stdout = os.Stdout
+	require.NoError(t, child.Run())
+	transcript.Logger(Normal).InfoContext(context.Background(), "running command", "argv", "docker compose up")
+	fmt.Fprint(os.Stdout, "no newline")
+	require.NoError(t, transcript.Close("exit code 0"))
+
+	assert.Same(t, stdout, os.Stdout, "stdout is restored")
+	assert.Same(t, stderr, os.Stderr, "stderr is restored")
+
+	content := readTranscript(t, path)
+	assert.Contains(t, content, "#      acontext test\n")
+	assert.Contains(t, content, "stdout hello\n")
+	assert.Contains(t, content, "stderr warning\n")
+	assert.Contains(t, content, "stdout ✓ done\n")
+	assert.Contains(t, content, `stdout API_KEY="[redacted]"`+"\n")
+	assert.Contains(t, content, "stdout from child\n")
+	assert.Contains(t, content, `log    level=INFO msg="running command" argv="docker compose up"`+"\n", "logged whatever the verbosity")
+	assert.Contains(t, content, "stdout no newline\n")
+	assert.NotContains(t, content, "sk-secret")
+	assert.NotContains(t, content, "working")
+	assert.True(t, strings.HasSuffix(content, "#      exit code 0\n"), "the footer comes last")
+}
+
+func TestTranscriptAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acontext.log")
+	for i, appendMode := range []bool{false, true, true} {
+		transcript, err := OpenTranscript(path, appendMode)
+		require.NoError(t, err)
+		transcript.Note("run %d", i)
+		require.NoError(t, transcript.Close(""))
+	}
+	assert.Equal(t, "#      run 0\n#      run 1\n#      run 2\n", readTranscript(t, path))
+
+	transcript, err := OpenTranscript(path, false)
+	require.NoError(t, err)
+	transcript.Note("truncated")
+	require.NoError(t, transcript.Close(""))
+	assert.Equal(t, "#      truncated\n", readTranscript(t, path))
+}
+
+func TestTranscriptMuted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acontext.log")
+	transcript, err := OpenTranscript(path, false)
+	require.NoError(t, err)
+	muted, err := transcript.Muted()
+	require.NoError(t, err)
+	fmt.Fprintln(muted, "quiet prose")
+	require.NoError(t, transcript.Close(""))
+	assert.Contains(t, readTranscript(t, path), "stdout quiet prose\n")
+}
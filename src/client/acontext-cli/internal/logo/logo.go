@@ -1,5 +1,9 @@
+// Package logo renders the banner printed when the CLI starts.
 package logo
 
+import "github.com/spf13/cobra"
+
+// Logo is the banner printed when the CLI starts on a terminal
 const Logo = `╔───────────────────────────────────────────────────────────────────────╗
 │  █████╗  ██████╗ ██████╗ ███╗   ██╗████████╗███████╗██╗  ██╗████████╗ │
 │ ██╔══██╗██╔════╝██╔═══██╗████╗  ██║╚══██╔══╝██╔════╝╚██╗██╔╝╚══██╔══╝ │
@@ -8,3 +12,51 @@ const Logo = `╔─────────────────────
 │ ██║  ██║╚██████╗╚██████╔╝██║ ╚████║   ██║   ███████╗██╔╝ ██╗   ██║    │
 │ ╚═╝  ╚═╝ ╚═════╝ ╚═════╝ ╚═╝  ╚═══╝   ╚═╝   ╚══════╝╚═╝  ╚═╝   ╚═╝    │
 ╚───────────────────────────────────────────────────────────────────────╝`
+
+// NoNoticeFlag is the flag that hides the update notice under the banner
+const NoNoticeFlag = "--no-banner-version-notice"
+
+// Show reports whether the banner is printed for a command run with args,
+// the raw arguments before flag parsing, as --no-logo is detected from them.
+// It is only printed on a terminal, and never for JSON output, help,
+// --version and --quiet, or completion scripts (completion) and cobra's
+// hidden dynamic completion commands (__complete), since it would corrupt
+// their output.
+func Show(args []string, terminal, json bool) bool {
+	if !terminal || json {
+		return false
+	}
+	if len(args) > 0 {
+		switch args[0] {
+		case "--help", "-h", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return false
+		}
+	}
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		switch arg {
+		case "--no-logo", "--no-logo=true", "--quiet", "-q", "--version", "-V":
+			return false
+		}
+	}
+	return true
+}
+
+// ShowNotice reports whether the update notice is printed under the banner
+// for a command run with args, given the update.notice setting
+func ShowNotice(args []string, enabled bool) bool {
+	if !enabled {
+		return false
+	}
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == NoNoticeFlag || arg == NoNoticeFlag+"=true" {
+			return false
+		}
+	}
+	return true
+}
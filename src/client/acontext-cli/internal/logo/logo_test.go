@@ -0,0 +1,56 @@
+package logo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShow(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		terminal bool
+		json     bool
+		expected bool
+	}{
+		{name: "no arguments", terminal: true, expected: true},
+		{name: "command", args: []string{"create", "my-app"}, terminal: true, expected: true},
+		{name: "not a terminal", args: []string{"create"}},
+		{name: "json output", args: []string{"version", "-o", "json"}, terminal: true, json: true},
+		{name: "no logo", args: []string{"create", "--no-logo"}, terminal: true},
+		{name: "no logo true", args: []string{"--no-logo=true", "create"}, terminal: true},
+		{name: "quiet", args: []string{"docker", "up", "-q"}, terminal: true},
+		{name: "version flag", args: []string{"--version"}, terminal: true},
+		{name: "help", args: []string{"--help"}, terminal: true},
+		{name: "completion", args: []string{"completion", "zsh"}, terminal: true},
+		{name: "dynamic completion", args: []string{"__complete", "docker", ""}, terminal: true},
+		{name: "flag after --", args: []string{"docker", "exec", "pg", "--", "--quiet"}, terminal: true, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Show(tt.args, tt.terminal, tt.json))
+		})
+	}
+}
+
+func TestShowNotice(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		enabled  bool
+		expected bool
+	}{
+		{name: "enabled", args: []string{"create"}, enabled: true, expected: true},
+		{name: "disabled by the setting", args: []string{"create"}},
+		{name: "disabled by the flag", args: []string{"create", NoNoticeFlag}, enabled: true},
+		{name: "disabled by the flag set to true", args: []string{NoNoticeFlag + "=true"}, enabled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ShowNotice(tt.args, tt.enabled))
+		})
+	}
+}
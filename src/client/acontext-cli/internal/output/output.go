@@ -0,0 +1,157 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+)
+
+// Format is the output format selected with --output
+type Format string
+
+const (
+	// Text prints human-friendly output (default)
+	Text Format = "text"
+	// JSON prints a single machine-readable JSON object to stdout
+	JSON Format = "json"
+)
+
+var (
+	current Format = Text
+	version        = "dev"
+
+	// stdout is the original stdout, where JSON results are written
+	stdout io.Writer = os.Stdout
+)
+
+// Envelope contains the fields shared by every JSON result
+type Envelope struct {
+	Command string `json:"command"`
+	Version string `json:"version"`
+}
+
+// ErrorResult is the JSON result printed when a command fails
+type ErrorResult struct {
+	Envelope
+	Error string `json:"error"`
+	Hint  string `json:"hint,omitempty"` // How to fix the error, when it is a known failure
+}
+
+// Parse parses an --output flag value
+func Parse(value string) (Format, error) {
+	switch Format(value) {
+	case Text, JSON:
+		return Format(value), nil
+	}
+	return "", fmt.Errorf("invalid output format %q (supported: text, json)", value)
+}
+
+// SetFormat sets the output format. In JSON mode, os.Stdout is redirected to
+// os.Stderr so that human-readable prose, prompts and subprocess output never
+// mix with the JSON result, which is still written to the original stdout.
+func SetFormat(format Format) {
+	if format == JSON && current != JSON {
+		os.Stdout = os.Stderr
+	}
+	current = format
+}
+
+// SetQuiet suppresses human-readable output by redirecting os.Stdout to the
+// null device. Errors are still printed to stderr and JSON results are still
+// written to the original stdout.
+func SetQuiet() error {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+	}
+	os.Stdout = devNull
+	return nil
+}
+
+// RedirectProse redirects os.Stdout to os.Stderr, as JSON mode does, for
+// commands that write machine-readable text (e.g., shell exports) to Stdout.
+// It has no effect once os.Stdout has already been redirected.
+func RedirectProse() {
+	if os.Stdout == stdout {
+		os.Stdout = os.Stderr
+	}
+}
+
+// SetStdout sets the stdout JSON results and machine-readable output are
+// written to, e.g., a pipe teeing it to a log file. Call it before the
+// output format is set.
+func SetStdout(w io.Writer) {
+	stdout = w
+}
+
+// Stdout returns the original stdout, where machine-readable output is written
+func Stdout() io.Writer {
+	return stdout
+}
+
+// SetVersion sets the CLI version reported in JSON results
+func SetVersion(v string) {
+	version = v
+}
+
+// IsJSON reports whether JSON output is selected
+func IsJSON() bool {
+	return current == JSON
+}
+
+// NewEnvelope returns the shared JSON fields for command (e.g., "docker.status")
+func NewEnvelope(command string) Envelope {
+	return Envelope{
+		Command: command,
+		Version: version,
+	}
+}
+
+// PrintJSON writes v as indented JSON to the original stdout
+func PrintJSON(v any) error {
+	return writeJSON(stdout, v)
+}
+
+// PrintError writes err as a JSON error result to the original stdout, with
+// the remediation hint err carries, if any
+func PrintError(command string, err error) error {
+	return PrintJSON(ErrorResult{
+		Envelope: NewEnvelope(command),
+		Error:    err.Error(),
+		Hint:     clierror.Hint(err),
+	})
+}
+
+// reportedError marks an error whose details are already part of the printed JSON result
+type reportedError struct {
+	err error
+}
+
+func (e *reportedError) Error() string { return e.err.Error() }
+func (e *reportedError) Unwrap() error { return e.err }
+
+// Reported wraps err to signal that the command already printed a JSON result
+// describing the failure, so no separate JSON error object should be printed
+func Reported(err error) error {
+	return &reportedError{err: err}
+}
+
+// IsReported reports whether err was wrapped with Reported
+func IsReported(err error) bool {
+	var reported *reportedError
+	return errors.As(err, &reported)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to write JSON output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	format, err := Parse("json")
+	assert.NoError(t, err)
+	assert.Equal(t, JSON, format)
+
+	format, err = Parse("text")
+	assert.NoError(t, err)
+	assert.Equal(t, Text, format)
+
+	_, err = Parse("yaml")
+	assert.Error(t, err)
+}
+
+func TestPrintError(t *testing.T) {
+	var buf bytes.Buffer
+	stdout = &buf
+	SetVersion("1.2.3")
+
+	require.NoError(t, PrintError("docker.up", errors.New("docker daemon is not running")))
+
+	var result map[string]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, map[string]string{
+		"command": "docker.up",
+		"version": "1.2.3",
+		"error":   "docker daemon is not running",
+	}, result)
+
+	buf.Reset()
+	require.NoError(t, PrintError("docker.up", clierror.WithHint(errors.New("docker daemon is not running"), "start Docker")))
+	result = nil
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, "start Docker", result["hint"])
+}
+
+func TestReported(t *testing.T) {
+	err := errors.New("2 service(s) need attention")
+	assert.False(t, IsReported(err))
+
+	reported := Reported(err)
+	assert.True(t, IsReported(reported))
+	assert.True(t, IsReported(fmt.Errorf("status: %w", reported)))
+	assert.ErrorIs(t, reported, err)
+	assert.Equal(t, err.Error(), reported.Error())
+}
@@ -0,0 +1,95 @@
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// HomeEnvVar roots all CLI state (config, cache and logs) under a single
+// directory when set
+const HomeEnvVar = "ACONTEXT_HOME"
+
+// resolveHomeDir returns the ACONTEXT_HOME directory and whether it is set
+func resolveHomeDir() (string, bool) {
+	home := os.Getenv(HomeEnvVar)
+	if home == "" {
+		return "", false
+	}
+	return filepath.Clean(home), true
+}
+
+// CheckHome verifies that ACONTEXT_HOME, when set, is an existing writable
+// directory, so misconfigured setups fail early instead of silently writing
+// state elsewhere
+func CheckHome() error {
+	home, ok := resolveHomeDir()
+	if !ok {
+		return nil
+	}
+
+	info, err := os.Stat(home)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s=%s does not exist", HomeEnvVar, home)
+		}
+		return fmt.Errorf("%s=%s is not accessible: %w", HomeEnvVar, home, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s=%s is not a directory", HomeEnvVar, home)
+	}
+
+	probe, err := os.CreateTemp(home, ".acontext-write-check-*")
+	if err != nil {
+		return fmt.Errorf("%s=%s is not writable: %w", HomeEnvVar, home, err)
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+	return nil
+}
+
+// ConfigDir returns the directory holding the user config file:
+// $ACONTEXT_HOME, $XDG_CONFIG_HOME/acontext or ~/.config/acontext
+func ConfigDir() (string, error) {
+	if home, ok := resolveHomeDir(); ok {
+		return home, nil
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "acontext"), nil
+}
+
+// CacheDir returns the directory for cached data such as downloaded
+// templates: $ACONTEXT_HOME/cache or the user cache directory
+func CacheDir() (string, error) {
+	if home, ok := resolveHomeDir(); ok {
+		return filepath.Join(home, "cache"), nil
+	}
+
+	cacheHome, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+	return filepath.Join(cacheHome, "acontext"), nil
+}
+
+// LogsDir returns the directory for CLI log files: $ACONTEXT_HOME/logs or
+// a logs directory inside the user cache directory
+func LogsDir() (string, error) {
+	if home, ok := resolveHomeDir(); ok {
+		return filepath.Join(home, "logs"), nil
+	}
+
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "logs"), nil
+}
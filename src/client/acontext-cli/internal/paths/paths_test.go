@@ -0,0 +1,79 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirsWithHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(HomeEnvVar, home)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	configDir, err := ConfigDir()
+	require.NoError(t, err)
+	assert.Equal(t, home, configDir)
+
+	cacheDir, err := CacheDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "cache"), cacheDir)
+
+	logsDir, err := LogsDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "logs"), logsDir)
+}
+
+func TestConfigDirWithoutHome(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv(HomeEnvVar, "")
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	configDir, err := ConfigDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(configHome, "acontext"), configDir)
+}
+
+func TestCheckHome(t *testing.T) {
+	t.Run("unset", func(t *testing.T) {
+		t.Setenv(HomeEnvVar, "")
+		assert.NoError(t, CheckHome())
+	})
+
+	t.Run("writable directory", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv(HomeEnvVar, home)
+		assert.NoError(t, CheckHome())
+
+		entries, err := os.ReadDir(home)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		t.Setenv(HomeEnvVar, filepath.Join(t.TempDir(), "missing"))
+		assert.ErrorContains(t, CheckHome(), "does not exist")
+	})
+
+	t.Run("file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(file, nil, 0644))
+		t.Setenv(HomeEnvVar, file)
+		assert.ErrorContains(t, CheckHome(), "is not a directory")
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+			t.Skip("permissions are not enforced")
+		}
+		home := t.TempDir()
+		require.NoError(t, os.Chmod(home, 0555))
+		t.Cleanup(func() { _ = os.Chmod(home, 0755) })
+		t.Setenv(HomeEnvVar, home)
+		assert.ErrorContains(t, CheckHome(), "is not writable")
+	})
+}
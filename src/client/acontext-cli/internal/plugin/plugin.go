@@ -0,0 +1,125 @@
+// Package plugin finds and runs external subcommands. Like git and kubectl
+// plugins, acontext foo runs an acontext-foo executable found on PATH when
+// foo is not a built-in command.
+package plugin
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Prefix is the prefix of the name of plugin executables
+const Prefix = "acontext-"
+
+// Plugin is a plugin executable found on PATH
+type Plugin struct {
+	Name     string   `json:"name"`               // Command name, e.g., "foo" for acontext-foo
+	Path     string   `json:"path"`               // The executable that acontext <name> runs
+	Shadowed []string `json:"shadowed,omitempty"` // Executables of the same name later on PATH, which never run
+}
+
+// Lookup returns the path of the executable of the plugin called name on
+// PATH, or "" if there is none
+func Lookup(name string) string {
+	if !validName(name) {
+		return ""
+	}
+	path, err := exec.LookPath(Prefix + name)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// List returns the plugins on PATH, sorted by name. When several
+// directories have a plugin of the same name, the first one is used, as it
+// is by Lookup.
+func List() []Plugin {
+	var plugins []Plugin
+	index := map[string]int{}
+	seen := map[string]bool{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			dir = "."
+		}
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry)
+			if !ok {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			if i, found := index[name]; found {
+				plugins[i].Shadowed = append(plugins[i].Shadowed, path)
+				continue
+			}
+			index[name] = len(plugins)
+			plugins = append(plugins, Plugin{Name: name, Path: path})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// Run runs the plugin executable at path with args, connected to the
+// standard streams of the CLI. A plugin that exits with a non-zero status
+// returns an *exec.ExitError.
+func Run(ctx context.Context, path string, args []string) error {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// pluginName returns the name of the plugin entry is the executable of,
+// without Prefix and, on Windows, its extension
+func pluginName(entry os.DirEntry) (string, bool) {
+	if entry.IsDir() {
+		return "", false
+	}
+	name, ok := strings.CutPrefix(entry.Name(), Prefix)
+	if !ok {
+		return "", false
+	}
+	if runtime.GOOS == "windows" {
+		ext := filepath.Ext(name)
+		if !strings.EqualFold(ext, ".exe") && !strings.EqualFold(ext, ".bat") && !strings.EqualFold(ext, ".cmd") {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name, validName(name)
+}
+
+// validName reports whether name can be a plugin's command name: not empty,
+// not a flag and not a path
+func validName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "-") && !strings.ContainsAny(name, `/\`)
+}
+
+// isExecutable reports whether path is a file that can be run. On Windows,
+// whether a file can be run depends on its extension, checked by pluginName.
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0
+}
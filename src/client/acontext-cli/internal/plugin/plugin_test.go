@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePlugin writes a shell script called name into dir
+func writePlugin(t *testing.T, dir, name, script string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), perm))
+	return path
+}
+
+func TestList(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts require a POSIX shell")
+	}
+	first, second := t.TempDir(), t.TempDir()
+	foo := writePlugin(t, first, "acontext-foo", "", 0755)
+	shadowed := writePlugin(t, second, "acontext-foo", "", 0755)
+	bar := writePlugin(t, second, "acontext-bar", "", 0755)
+	writePlugin(t, first, "acontext-data", "", 0644)
+	writePlugin(t, first, "acontext-", "", 0755)
+	writePlugin(t, first, "other-tool", "", 0755)
+	require.NoError(t, os.Mkdir(filepath.Join(first, "acontext-dir"), 0755))
+	t.Setenv("PATH", first+string(os.PathListSeparator)+second+string(os.PathListSeparator)+first)
+
+	assert.Equal(t, []Plugin{
+		{Name: "bar", Path: bar},
+		{Name: "foo", Path: foo, Shadowed: []string{shadowed}},
+	}, List())
+}
+
+func TestLookup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts require a POSIX shell")
+	}
+	dir := t.TempDir()
+	foo := writePlugin(t, dir, "acontext-foo", "", 0755)
+	t.Setenv("PATH", dir)
+
+	assert.Equal(t, foo, Lookup("foo"))
+	assert.Empty(t, Lookup("missing"))
+	assert.Empty(t, Lookup("../acontext-foo"))
+	assert.Empty(t, Lookup("--foo"))
+	assert.Empty(t, Lookup(""))
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts require a POSIX shell")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	path := writePlugin(t, dir, "acontext-foo", `echo "$@" > "$OUT"; exit 3`, 0755)
+	t.Setenv("OUT", out)
+
+	err := Run(context.Background(), path, []string{"--flag", "value"})
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, exitErr.ExitCode())
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "--flag value\n", string(data))
+}
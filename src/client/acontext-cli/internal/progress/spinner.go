@@ -0,0 +1,121 @@
+package progress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/tty"
+)
+
+// frames are drawn in turn in front of the spinner message
+var frames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// interval is how often the spinner advances
+const interval = 100 * time.Millisecond
+
+// Spinner shows a message with an animation while a long operation runs.
+// It only animates when stdout is a terminal, JSON output is off and no
+// command logging is enabled; otherwise it never writes control sequences.
+type Spinner struct {
+	w         io.Writer
+	message   string
+	animated  bool
+	transient bool
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Start shows message until Stop is called, after which it stays printed
+// as a regular line. When the spinner cannot animate, the line is printed
+// right away instead.
+func Start(ctx context.Context, message string) *Spinner {
+	return start(ctx, os.Stdout, message, canAnimate(ctx, os.Stdout), false)
+}
+
+// StartTransient shows message until Stop is called, after which it is
+// erased. When the spinner cannot animate, nothing is printed at all.
+func StartTransient(ctx context.Context, message string) *Spinner {
+	return start(ctx, os.Stdout, message, canAnimate(ctx, os.Stdout), true)
+}
+
+// Run shows a spinner with message while fn runs and stops it however fn returns
+func Run(ctx context.Context, message string, fn func() error) error {
+	spinner := Start(ctx, message)
+	defer spinner.Stop()
+	return fn()
+}
+
+// canAnimate reports whether a spinner can be drawn on w without polluting
+// logs, JSON results or the command log on stderr
+func canAnimate(ctx context.Context, w *os.File) bool {
+	if output.IsJSON() || !tty.IsTerminal(w) {
+		return false
+	}
+	return !logging.FromContext(ctx).Enabled(ctx, slog.LevelInfo)
+}
+
+func start(ctx context.Context, w io.Writer, message string, animated, transient bool) *Spinner {
+	s := &Spinner{
+		w:         w,
+		message:   message,
+		animated:  animated,
+		transient: transient,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	if !animated {
+		if !transient {
+			fmt.Fprintln(w, message)
+		}
+		close(s.done)
+		return s
+	}
+
+	go s.animate(ctx)
+	return s
+}
+
+// animate redraws the spinner until it is stopped or ctx is done
+func (s *Spinner) animate(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for i := 0; ; i++ {
+		fmt.Fprintf(s.w, "\r%s %s", frames[i%len(frames)], s.message)
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops the animation and leaves the cursor at the start of a clean
+// line. It is safe to call more than once.
+func (s *Spinner) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		if !s.animated {
+			return
+		}
+		// Erase the spinner line, then print the message for good
+		fmt.Fprint(s.w, "\r\033[K")
+		if !s.transient {
+			fmt.Fprintln(s.w, s.message)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer is a bytes.Buffer that is safe for the spinner goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSpinnerDisabled(t *testing.T) {
+	var buf syncBuffer
+	spinner := start(context.Background(), &buf, "Downloading template...", false, false)
+	spinner.Stop()
+	spinner.Stop()
+	assert.Equal(t, "Downloading template...\n", buf.String())
+
+	var transient syncBuffer
+	spinner = start(context.Background(), &transient, "Checking Docker...", false, true)
+	spinner.Stop()
+	assert.Empty(t, transient.String())
+}
+
+func TestSpinnerAnimated(t *testing.T) {
+	var buf syncBuffer
+	spinner := start(context.Background(), &buf, "Downloading template...", true, false)
+	time.Sleep(2 * interval)
+	spinner.Stop()
+	spinner.Stop()
+
+	out := buf.String()
+	assert.Contains(t, out, "\r"+frames[0]+" Downloading template...")
+	assert.True(t, strings.HasSuffix(out, "\r\033[KDownloading template...\n"), out)
+}
+
+func TestSpinnerTransient(t *testing.T) {
+	var buf syncBuffer
+	spinner := start(context.Background(), &buf, "Checking Docker...", true, true)
+	spinner.Stop()
+
+	assert.True(t, strings.HasSuffix(buf.String(), "\r\033[K"), buf.String())
+}
+
+func TestSpinnerStopsWhenContextIsCancelled(t *testing.T) {
+	var buf syncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	spinner := start(ctx, &buf, "Waiting...", true, false)
+	cancel()
+
+	select {
+	case <-spinner.done:
+	case <-time.After(time.Second):
+		t.Fatal("spinner kept animating after the context was cancelled")
+	}
+	spinner.Stop()
+	assert.True(t, strings.HasSuffix(buf.String(), "\r\033[KWaiting...\n"))
+}
+
+func TestRunStopsSpinnerOnError(t *testing.T) {
+	wantErr := errors.New("clone failed")
+	err := Run(context.Background(), "Downloading template...", func() error {
+		return wantErr
+	})
+	assert.ErrorIs(t, err, wantErr)
+}
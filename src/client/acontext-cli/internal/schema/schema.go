@@ -0,0 +1,260 @@
+// Package schema validates YAML documents against the subset of JSON Schema
+// the CLI's bundled schemas use, reporting where in the document each
+// violation is
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is a JSON Schema using the supported keywords. Parse rejects the
+// others, so a schema never silently validates less than it says.
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Type                 string             `json:"type,omitempty"` // object, array, string, integer, number, boolean or null
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"` // Allowed unless false
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Parse parses a JSON schema, failing on unsupported keywords and invalid
+// patterns
+func Parse(data []byte) (*Schema, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var s Schema
+	if err := decoder.Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	if err := s.compile(""); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// compile compiles the patterns of s and its subschemas
+func (s *Schema) compile(path string) error {
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("schema %s: invalid pattern: %w", displayPath(path), err)
+		}
+		s.pattern = pattern
+	}
+	for name, property := range s.Properties {
+		if err := property.compile(joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "[]")
+	}
+	return nil
+}
+
+// Violation is a place where a document does not conform to a schema
+type Violation struct {
+	Path    string `json:"path"`   // Path of the offending value, e.g., template.commit, empty for the document
+	Line    int    `json:"line"`   // 1-based, 0 if unknown
+	Column  int    `json:"column"` // 1-based, 0 if unknown
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// Validate checks the YAML document data against s and returns its
+// violations, sorted by position, or none if it conforms. An empty document
+// is an empty mapping; a document that is not valid YAML is one violation.
+func (s *Schema) Validate(data []byte) []Violation {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []Violation{syntaxViolation(err)}
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}
+	if len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+
+	var violations []Violation
+	s.validate("", root, &violations)
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Line != violations[j].Line {
+			return violations[i].Line < violations[j].Line
+		}
+		return violations[i].Column < violations[j].Column
+	})
+	return violations
+}
+
+// validate appends the violations of node, at path, to violations
+func (s *Schema) validate(path string, node *yaml.Node, violations *[]Violation) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	report := func(at *yaml.Node, format string, args ...any) {
+		*violations = append(*violations, Violation{Path: path, Line: at.Line, Column: at.Column, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Type != "" && !hasType(node, s.Type) {
+		report(node, "expected %s, got %s", article(s.Type), article(typeOf(node)))
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		declared := map[string]bool{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			declared[key.Value] = true
+			if property, ok := s.Properties[key.Value]; ok {
+				property.validate(joinPath(path, key.Value), value, violations)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*violations = append(*violations, Violation{Path: joinPath(path, key.Value), Line: key.Line, Column: key.Column, Message: "unknown property" + s.knownProperties()})
+			}
+		}
+		for _, name := range s.Required {
+			if !declared[name] {
+				report(node, "missing required property %s", name)
+			}
+		}
+	case yaml.SequenceNode:
+		if s.Items != nil {
+			for i, item := range node.Content {
+				s.Items.validate(path+"["+strconv.Itoa(i)+"]", item, violations)
+			}
+		}
+	case yaml.ScalarNode:
+		s.validateScalar(node, report)
+	}
+}
+
+// validateScalar checks the string constraints of s against a scalar node
+func (s *Schema) validateScalar(node *yaml.Node, report func(at *yaml.Node, format string, args ...any)) {
+	value := node.Value
+	if len(s.Enum) > 0 && !containsString(s.Enum, value) {
+		report(node, "must be one of %s, got %q", strings.Join(s.Enum, ", "), value)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		report(node, "%q does not match the pattern %s", value, s.Pattern)
+	}
+	length := len([]rune(value))
+	if s.MinLength != nil && length < *s.MinLength {
+		report(node, "must be at least %d characters long", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		report(node, "must be at most %d characters long", *s.MaxLength)
+	}
+}
+
+// knownProperties lists the properties s declares, for unknown ones
+func (s *Schema) knownProperties() string {
+	if len(s.Properties) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return " (known: " + strings.Join(names, ", ") + ")"
+}
+
+// hasType reports whether node is a value of the JSON Schema type
+func hasType(node *yaml.Node, schemaType string) bool {
+	actual := typeOf(node)
+	return actual == schemaType || (schemaType == "number" && actual == "integer")
+}
+
+// typeOf returns the JSON Schema type of node
+func typeOf(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+	return "string"
+}
+
+// article prefixes a type name with its indefinite article, e.g., "an object"
+func article(schemaType string) string {
+	switch schemaType {
+	case "object", "array", "integer":
+		return "an " + schemaType
+	case "null":
+		return schemaType
+	}
+	return "a " + schemaType
+}
+
+// yamlLine matches the line number in a YAML syntax error
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// syntaxViolation reports a YAML syntax error at its line, if known
+func syntaxViolation(err error) Violation {
+	message := err.Error()
+	if match := yamlLine.FindStringSubmatch(message); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return Violation{Line: line, Message: "invalid YAML: " + strings.TrimPrefix(message, match[0])}
+	}
+	return Violation{Message: "invalid YAML: " + strings.TrimPrefix(message, "yaml: ")}
+}
+
+// joinPath returns the path of property name under path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// displayPath names path in messages about the schema itself
+func displayPath(path string) string {
+	if path == "" {
+		return "root"
+	}
+	return path
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
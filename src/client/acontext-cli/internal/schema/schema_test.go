@@ -0,0 +1,130 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": "string", "pattern": "^[a-z]+$", "maxLength": 8},
+    "mode": {"type": "string", "enum": ["dev", "prod"]},
+    "port": {"type": "integer"},
+    "ratio": {"type": "number"},
+    "tags": {"type": "array", "items": {"type": "string", "minLength": 2}},
+    "owner": {
+      "type": "object",
+      "required": ["email"],
+      "properties": {"email": {"type": "string"}}
+    }
+  }
+}`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{name: "valid", schema: testSchema},
+		{name: "unsupported keyword", schema: `{"type": "object", "oneOf": []}`, wantErr: `unknown field "oneOf"`},
+		{name: "invalid pattern", schema: `{"properties": {"name": {"pattern": "("}}}`, wantErr: "schema name: invalid pattern"},
+		{name: "not JSON", schema: `type: object`, wantErr: "failed to parse schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.schema))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s, err := Parse([]byte(testSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		doc  string
+		want []Violation
+	}{
+		{name: "empty document", doc: ""},
+		{name: "valid", doc: "name: app\nmode: dev\nport: 8080\nratio: 1\ntags: [ab, cd]\nowner:\n  email: a@b.c\n"},
+		{
+			name: "wrong type",
+			doc:  "name: app\nport: eighty\n",
+			want: []Violation{{Path: "port", Line: 2, Column: 7, Message: "expected an integer, got a string"}},
+		},
+		{
+			name: "wrong root type",
+			doc:  "- app\n",
+			want: []Violation{{Line: 1, Column: 1, Message: "expected an object, got an array"}},
+		},
+		{
+			name: "unknown property",
+			doc:  "name: app\nnmae: app\n",
+			want: []Violation{{Path: "nmae", Line: 2, Column: 1, Message: "unknown property (known: mode, name, owner, port, ratio, tags)"}},
+		},
+		{
+			name: "missing required property",
+			doc:  "owner:\n  name: me\n",
+			want: []Violation{{Path: "owner", Line: 2, Column: 3, Message: "missing required property email"}},
+		},
+		{
+			name: "string constraints",
+			doc:  "name: MyApplication\nmode: staging\n",
+			want: []Violation{
+				{Path: "name", Line: 1, Column: 7, Message: `"MyApplication" does not match the pattern ^[a-z]+$`},
+				{Path: "name", Line: 1, Column: 7, Message: "must be at most 8 characters long"},
+				{Path: "mode", Line: 2, Column: 7, Message: `must be one of dev, prod, got "staging"`},
+			},
+		},
+		{
+			name: "array items",
+			doc:  "tags:\n  - ok\n  - x\n  - 3\n",
+			want: []Violation{
+				{Path: "tags[1]", Line: 3, Column: 5, Message: "must be at least 2 characters long"},
+				{Path: "tags[2]", Line: 4, Column: 5, Message: "expected a string, got an integer"},
+			},
+		},
+		{
+			name: "alias",
+			doc:  "name: &n 42\nmode: *n\n",
+			want: []Violation{
+				{Path: "name", Line: 1, Column: 7, Message: "expected a string, got an integer"},
+				{Path: "mode", Line: 1, Column: 7, Message: "expected a string, got an integer"},
+			},
+		},
+		{
+			name: "null",
+			doc:  "name:\n",
+			want: []Violation{{Path: "name", Line: 1, Column: 6, Message: "expected a string, got null"}},
+		},
+		{
+			name: "invalid YAML",
+			doc:  "name: app\n  mode: dev\n",
+			want: []Violation{{Line: 2, Message: "invalid YAML: mapping values are not allowed in this context"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.Validate([]byte(tt.doc)))
+		})
+	}
+}
+
+func TestViolationString(t *testing.T) {
+	assert.Equal(t, "template.url: expected a string, got an integer", Violation{Path: "template.url", Message: "expected a string, got an integer"}.String())
+	assert.Equal(t, "expected an object, got an array", Violation{Message: "expected an object, got an array"}.String())
+}
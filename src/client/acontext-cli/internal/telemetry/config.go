@@ -0,0 +1,191 @@
+package telemetry
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultEndpoint is where events are sent unless another collector is
+	// configured
+	DefaultEndpoint = "https://telemetry.acontext.io/v1/events"
+
+	// DefaultSendTimeout is how long sending one event may take
+	DefaultSendTimeout = 5 * time.Second
+
+	// DefaultSampleRate is the fraction of successful commands whose event
+	// is sent unless telemetry.sample_rate sets another one
+	DefaultSampleRate = 1.0
+
+	// EndpointEnvVar overrides the telemetry.endpoint setting
+	EndpointEnvVar = "ACONTEXT_TELEMETRY_ENDPOINT"
+
+	// InsecureEnvVar overrides the telemetry.insecure setting
+	InsecureEnvVar = "ACONTEXT_TELEMETRY_INSECURE"
+
+	// DisabledEnvVar turns telemetry off when set to a true value, taking
+	// precedence over every other setting and flag
+	DisabledEnvVar = "ACONTEXT_TELEMETRY_DISABLED"
+
+	// EnvVar turns telemetry off (0, false, off) over the telemetry.enabled
+	// setting, or on (1, true, on) for development builds
+	EnvVar = "ACONTEXT_TELEMETRY"
+
+	// SkipAnnotation marks a cobra command that sends no telemetry, e.g.,
+	// the ones managing telemetry itself
+	SkipAnnotation = "acontext.telemetry.skip"
+
+	// NoArgsAnnotation marks a cobra command whose arguments are not sent,
+	// not even redacted, e.g., the ones passed through to a plugin
+	NoArgsAnnotation = "acontext.telemetry.no-args"
+
+	// CommandPathAnnotation sets the command path reported for a cobra
+	// command, e.g., docker.up for the acontext up shortcut, so events are
+	// not split across the names of one command
+	CommandPathAnnotation = "acontext.telemetry.command-path"
+)
+
+// Config controls where events are sent
+type Config struct {
+	Endpoint string        // Collector URL, https unless Insecure is set
+	Insecure bool          // Allow a plaintext http endpoint
+	Timeout  time.Duration // How long sending one event may take
+
+	// SampleRate is the fraction of successful commands whose event is
+	// sent, from 0 to 1; the events of failed commands are always sent
+	SampleRate float64
+}
+
+// ResolveConfig builds the telemetry config from the environment and the
+// telemetry.endpoint, telemetry.insecure, telemetry.timeout and
+// telemetry.sample_rate settings read with get; environment variables take
+// precedence over settings
+func ResolveConfig(get func(key string) (string, bool)) (Config, error) {
+	config := Config{Endpoint: DefaultEndpoint, Timeout: DefaultSendTimeout, SampleRate: DefaultSampleRate}
+	if value, ok := setting(EndpointEnvVar, "telemetry.endpoint", get); ok {
+		config.Endpoint = value
+	}
+	if value, ok := setting(InsecureEnvVar, "telemetry.insecure", get); ok {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("telemetry.insecure: expected true or false, got %q", value)
+		}
+		config.Insecure = insecure
+	}
+	if value, ok := setting("", "telemetry.timeout", get); ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return Config{}, fmt.Errorf("telemetry.timeout: expected a positive duration (e.g., 5s), got %q", value)
+		}
+		config.Timeout = timeout
+	}
+	if value, ok := setting("", "telemetry.sample_rate", get); ok {
+		rate, err := ParseSampleRate(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("telemetry.sample_rate: %w", err)
+		}
+		config.SampleRate = rate
+	}
+	return config, nil
+}
+
+// setting returns the value of envVar, if it is set, or the setting key
+func setting(envVar, key string, get func(key string) (string, bool)) (string, bool) {
+	if envVar != "" {
+		if value := strings.TrimSpace(os.Getenv(envVar)); value != "" {
+			return value, true
+		}
+	}
+	if get == nil {
+		return "", false
+	}
+	value, ok := get(key)
+	return strings.TrimSpace(value), ok && strings.TrimSpace(value) != ""
+}
+
+// Validate checks the endpoint is an absolute https URL, or http when
+// Insecure is set
+func (c Config) Validate() error {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid telemetry endpoint %q: expected an https URL", c.Endpoint)
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !c.Insecure {
+			return fmt.Errorf("telemetry endpoint %s uses plaintext http: set telemetry.insecure to true (or %s=1) to allow it", c.Endpoint, InsecureEnvVar)
+		}
+	default:
+		return fmt.Errorf("invalid telemetry endpoint %q: expected an https URL", c.Endpoint)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("telemetry timeout must be positive, got %s", c.Timeout)
+	}
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		return fmt.Errorf("telemetry sample rate must be between 0 and 1, got %g", c.SampleRate)
+	}
+	return nil
+}
+
+// Configure validates config and makes it the one events are sent with
+func Configure(config Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	telemetryEndpoint = config.Endpoint
+	sendTimeout = config.Timeout
+	sampleRate = config.SampleRate
+	return nil
+}
+
+// Disabled reports whether DisabledEnvVar turns telemetry off
+func Disabled() bool {
+	disabled, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(DisabledEnvVar)))
+	return err == nil && disabled
+}
+
+// EnvOverride returns "on" or "off" when EnvVar explicitly enables or
+// disables telemetry, and "" otherwise
+func EnvOverride() string {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(EnvVar))) {
+	case "0", "false", "off":
+		return "off"
+	case "1", "true", "on":
+		return "on"
+	}
+	return ""
+}
+
+// Decision is whether telemetry is sent, and what decided it
+type Decision struct {
+	Enabled bool
+	Reason  string // e.g., "telemetry.enabled setting"
+}
+
+// Decide resolves whether a CLI of the given version sends telemetry, in
+// order of precedence: DisabledEnvVar, development builds (off unless EnvVar
+// opts in), the --no-telemetry flag (flagOff), EnvVar turning it off and the
+// telemetry.enabled setting read with get, which defaults to on
+func Decide(version string, flagOff bool, get func(key string) (string, bool)) Decision {
+	switch {
+	case Disabled():
+		return Decision{Reason: DisabledEnvVar + " is set"}
+	case version == "dev" && EnvOverride() != "on":
+		return Decision{Reason: "development build (set " + EnvVar + "=1 to opt in)"}
+	case flagOff:
+		return Decision{Reason: "--no-telemetry flag"}
+	case EnvOverride() == "off":
+		return Decision{Reason: EnvVar + " environment variable"}
+	}
+	if value, ok := get("telemetry.enabled"); ok {
+		if enabled, err := strconv.ParseBool(value); err == nil {
+			return Decision{Enabled: enabled, Reason: "telemetry.enabled setting"}
+		}
+	}
+	return Decision{Enabled: true, Reason: "default"}
+}
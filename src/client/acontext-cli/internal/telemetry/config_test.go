@@ -0,0 +1,126 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func settings(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func TestResolveConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		config, err := ResolveConfig(settings(nil))
+		require.NoError(t, err)
+		assert.Equal(t, Config{Endpoint: DefaultEndpoint, Timeout: DefaultSendTimeout, SampleRate: DefaultSampleRate}, config)
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		config, err := ResolveConfig(settings(map[string]string{
+			"telemetry.endpoint":    "http://collector.internal:8080/events",
+			"telemetry.insecure":    "true",
+			"telemetry.timeout":     "2s",
+			"telemetry.sample_rate": "0.25",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, Config{Endpoint: "http://collector.internal:8080/events", Insecure: true, Timeout: 2 * time.Second, SampleRate: 0.25}, config)
+	})
+
+	t.Run("environment wins", func(t *testing.T) {
+		t.Setenv(EndpointEnvVar, "https://collector.example.com/v1/events")
+		t.Setenv(InsecureEnvVar, "false")
+		config, err := ResolveConfig(settings(map[string]string{
+			"telemetry.endpoint": "http://collector.internal:8080/events",
+			"telemetry.insecure": "true",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "https://collector.example.com/v1/events", config.Endpoint)
+		assert.False(t, config.Insecure)
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		_, err := ResolveConfig(settings(map[string]string{"telemetry.timeout": "0"}))
+		assert.ErrorContains(t, err, "telemetry.timeout: expected a positive duration")
+	})
+
+	t.Run("invalid sample rate", func(t *testing.T) {
+		_, err := ResolveConfig(settings(map[string]string{"telemetry.sample_rate": "1.5"}))
+		assert.ErrorContains(t, err, "telemetry.sample_rate: expected a number from 0 to 1")
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		errMsg string
+	}{
+		{name: "https", config: Config{Endpoint: "https://collector.example.com/v1/events", Timeout: time.Second}},
+		{name: "insecure http", config: Config{Endpoint: "http://localhost:8080", Insecure: true, Timeout: time.Second}},
+		{name: "plaintext http", config: Config{Endpoint: "http://localhost:8080", Timeout: time.Second}, errMsg: "uses plaintext http"},
+		{name: "relative", config: Config{Endpoint: "/v1/events", Timeout: time.Second}, errMsg: "expected an https URL"},
+		{name: "other scheme", config: Config{Endpoint: "ftp://collector.example.com", Timeout: time.Second}, errMsg: "expected an https URL"},
+		{name: "sample rate out of range", config: Config{Endpoint: "https://collector.example.com/v1/events", Timeout: time.Second, SampleRate: -0.1}, errMsg: "sample rate must be between 0 and 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	t.Setenv(DisabledEnvVar, "")
+	assert.False(t, Disabled())
+	t.Setenv(DisabledEnvVar, "1")
+	assert.True(t, Disabled())
+	t.Setenv(DisabledEnvVar, "false")
+	assert.False(t, Disabled())
+}
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		flagOff  bool
+		env      map[string]string
+		values   map[string]string
+		expected bool
+		reason   string
+	}{
+		{name: "default", version: "1.0.0", expected: true, reason: "default"},
+		{name: "setting off", version: "1.0.0", values: map[string]string{"telemetry.enabled": "false"}, reason: "telemetry.enabled setting"},
+		{name: "invalid setting", version: "1.0.0", values: map[string]string{"telemetry.enabled": "maybe"}, expected: true, reason: "default"},
+		{name: "flag", version: "1.0.0", flagOff: true, reason: "--no-telemetry flag"},
+		{name: "env off", version: "1.0.0", env: map[string]string{EnvVar: "off"}, reason: EnvVar + " environment variable"},
+		{name: "dev", version: "dev", reason: "development build (set " + EnvVar + "=1 to opt in)"},
+		{name: "dev opted in", version: "dev", env: map[string]string{EnvVar: "1"}, expected: true, reason: "default"},
+		{name: "disabled trumps opt-in", version: "1.0.0", env: map[string]string{DisabledEnvVar: "1", EnvVar: "1"}, reason: DisabledEnvVar + " is set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(DisabledEnvVar, "")
+			t.Setenv(EnvVar, "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			decision := Decide(tt.version, tt.flagOff, settings(tt.values))
+			assert.Equal(t, tt.expected, decision.Enabled)
+			assert.Equal(t, tt.reason, decision.Reason)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// TimeoutEnvVar overrides how long the CLI waits for telemetry to be
+	// flushed before exiting, as a Go duration (e.g. "2s"). "0" never waits.
+	TimeoutEnvVar = "ACONTEXT_TELEMETRY_TIMEOUT"
+
+	// DefaultFlushTimeout is how long most commands wait for telemetry
+	DefaultFlushTimeout = 800 * time.Millisecond
+
+	// LongRunningAnnotation marks a cobra command as long-running, so its
+	// telemetry is given as long as sending an event may take (the configured
+	// send timeout, DefaultSendTimeout unless telemetry.timeout is set). They
+	// already took a while, so the extra wait is not noticeable and their
+	// duration is worth delivering.
+	LongRunningAnnotation = "acontext.telemetry.long-running"
+)
+
+// FlushTimeout returns how long to wait for telemetry to be sent after a
+// command finishes. TimeoutEnvVar takes precedence; invalid or negative values
+// are ignored.
+func FlushTimeout(longRunning bool) time.Duration {
+	if value := os.Getenv(TimeoutEnvVar); value != "" {
+		if timeout, err := time.ParseDuration(value); err == nil && timeout >= 0 {
+			return timeout
+		}
+	}
+	if longRunning || sendTimeout < DefaultFlushTimeout {
+		return sendTimeout
+	}
+	return DefaultFlushTimeout
+}
+
+// Wait waits for wg until ctx is done and reports whether it completed.
+// Events still in flight are dropped when the process exits, unless they
+// were queued.
+func Wait(ctx context.Context, wg *sync.WaitGroup) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,149 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// waitWithin waits for wg up to timeout
+func waitWithin(wg *sync.WaitGroup, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return Wait(ctx, wg)
+}
+
+func TestFlushTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         string
+		longRunning bool
+		want        time.Duration
+	}{
+		{name: "default", want: DefaultFlushTimeout},
+		{name: "long-running", longRunning: true, want: DefaultSendTimeout},
+		{name: "env override", env: "2s", want: 2 * time.Second},
+		{name: "env override long-running", env: "100ms", longRunning: true, want: 100 * time.Millisecond},
+		{name: "env zero", env: "0", want: 0},
+		{name: "invalid env", env: "soon", want: DefaultFlushTimeout},
+		{name: "negative env", env: "-1s", longRunning: true, want: DefaultSendTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(TimeoutEnvVar, tt.env)
+			assert.Equal(t, tt.want, FlushTimeout(tt.longRunning))
+		})
+	}
+
+	// The configured send timeout is the long-running wait and caps the default one
+	t.Cleanup(func() { sendTimeout = DefaultSendTimeout })
+	sendTimeout = 10 * time.Second
+	assert.Equal(t, 10*time.Second, FlushTimeout(true))
+	assert.Equal(t, DefaultFlushTimeout, FlushTimeout(false))
+	sendTimeout = 500 * time.Millisecond
+	assert.Equal(t, 500*time.Millisecond, FlushTimeout(false))
+}
+
+func TestWaitReturnsPromptlyWhenTransportHangs(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	original := telemetryEndpoint
+	telemetryEndpoint = server.URL
+	defer func() { telemetryEndpoint = original }()
+
+	wg := TrackCommandAsync(context.Background(), "version", nil, nil, true, nil, time.Second, "v0.0.1")
+
+	start := time.Now()
+	completed := waitWithin(wg, 50*time.Millisecond)
+	elapsed := time.Since(start)
+
+	assert.False(t, completed)
+	assert.Less(t, elapsed, time.Second)
+}
+
+func TestWaitCompletes(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go wg.Done()
+
+	assert.True(t, waitWithin(&wg, time.Second))
+}
+
+func TestWaitZeroTimeout(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	defer wg.Done()
+
+	assert.False(t, waitWithin(&wg, 0))
+}
+
+func TestCancelShortensWait(t *testing.T) {
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		<-r.Context().Done()
+		close(cancelled)
+	}))
+	defer server.Close()
+
+	original := telemetryEndpoint
+	telemetryEndpoint = server.URL
+	defer func() { telemetryEndpoint = original }()
+
+	// As when Ctrl-C cancels the command context during the default window
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultSendTimeout)
+	defer cancel()
+	wg := TrackCommandAsync(ctx, "docker.up", nil, nil, false, nil, time.Second, "v0.0.1")
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	assert.False(t, Wait(ctx, wg))
+	assert.Less(t, time.Since(start), time.Second)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the request was not cancelled along with the context")
+	}
+	assert.True(t, waitWithin(wg, time.Second), "the send returns once its request is cancelled")
+}
+
+func TestDeadlineBoundsWait(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	original := telemetryEndpoint
+	telemetryEndpoint = server.URL
+	defer func() { telemetryEndpoint = original }()
+
+	queue := NewQueue(t.TempDir())
+	SetQueue(queue)
+	defer SetQueue(nil)
+
+	// A --timeout deadline that already passed skips the wait, but the event
+	// is queued for a later run
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	wg := TrackCommandAsync(ctx, "docker.up", nil, nil, false, nil, time.Second, "v0.0.1")
+
+	start := time.Now()
+	assert.False(t, Wait(ctx, wg))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, 1, queue.Len())
+}
@@ -0,0 +1,213 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/paths"
+)
+
+const (
+	// DefaultQueueSize is how many undelivered events are kept at most; the
+	// oldest ones are dropped first
+	DefaultQueueSize = 100
+
+	// DefaultQueueMaxAge is how long an undelivered event is kept
+	DefaultQueueMaxAge = 7 * 24 * time.Hour
+)
+
+// Queue persists events on disk until they are delivered, so events from
+// offline runs are sent on a later run. Each event is a separate file named
+// after the time it was queued, so a corrupt entry only loses that event.
+type Queue struct {
+	Dir     string
+	Size    int
+	MaxAge  time.Duration
+	now     func() time.Time
+	flushMu sync.Mutex
+}
+
+// NewQueue returns a queue stored in dir with the default limits
+func NewQueue(dir string) *Queue {
+	return &Queue{Dir: dir, Size: DefaultQueueSize, MaxAge: DefaultQueueMaxAge, now: time.Now}
+}
+
+// DefaultQueue returns the queue in the CLI cache directory
+func DefaultQueue() (*Queue, error) {
+	cacheDir, err := paths.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewQueue(filepath.Join(cacheDir, "telemetry", "queue")), nil
+}
+
+var (
+	queueMu sync.Mutex
+	queue   *Queue
+)
+
+// SetQueue sets the queue undelivered events are persisted to; nil disables
+// queueing
+func SetQueue(q *Queue) {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	queue = q
+}
+
+func currentQueue() *Queue {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	return queue
+}
+
+// Add persists event and returns the path of its entry. The entry is written
+// atomically, and the queue is trimmed to its size afterwards.
+func (q *Queue) Add(event Event) (string, error) {
+	if err := os.MkdirAll(q.Dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create telemetry queue: %w", err)
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(q.Dir, ".event-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to queue event: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer func() {
+		_ = os.Remove(tmpPath) // No-op after a successful rename
+	}()
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return "", fmt.Errorf("failed to queue event: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to queue event: %w", err)
+	}
+
+	// The random part of the temp name keeps concurrent runs from colliding
+	suffix := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(tmpPath), ".event-"), ".tmp")
+	path := filepath.Join(q.Dir, fmt.Sprintf("%d-%s.json", q.now().UnixNano(), suffix))
+	if err := os.Rename(tmpPath, path); err != nil {
+		return "", fmt.Errorf("failed to queue event: %w", err)
+	}
+
+	q.trim()
+	return path, nil
+}
+
+// Remove deletes a delivered entry
+func (q *Queue) Remove(path string) {
+	_ = os.Remove(path)
+}
+
+// Purge deletes every queued entry and returns how many it deleted
+func (q *Queue) Purge() (int, error) {
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+
+	purged := 0
+	for _, entry := range q.entries() {
+		if err := os.Remove(entry.path); err != nil && !os.IsNotExist(err) {
+			return purged, fmt.Errorf("failed to delete queued event: %w", err)
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// Flush sends the queued events oldest first with send, removing each one
+// that is delivered. Expired and malformed entries are dropped. It stops at
+// the first failure, leaving the rest for a later run.
+func (q *Queue) Flush(send func(Event) error) {
+	// Only one flush at a time, so an event is not sent twice by one run
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+
+	for _, entry := range q.entries() {
+		if q.expired(entry) {
+			q.Remove(entry.path)
+			continue
+		}
+		data, err := os.ReadFile(entry.path)
+		if err != nil {
+			continue // Delivered and removed by another run
+		}
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			q.Remove(entry.path)
+			continue
+		}
+		if err := send(event); err != nil {
+			return
+		}
+		q.Remove(entry.path)
+	}
+}
+
+// Len returns the number of queued entries
+func (q *Queue) Len() int {
+	return len(q.entries())
+}
+
+// queueEntry is a queued event file
+type queueEntry struct {
+	path     string
+	queuedAt time.Time
+}
+
+// entries returns the queued entries, oldest first. Files whose name is not
+// a queue entry are ignored.
+func (q *Queue) entries() []queueEntry {
+	files, err := os.ReadDir(q.Dir)
+	if err != nil {
+		return nil
+	}
+	var entries []queueEntry
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		stamp, _, _ := strings.Cut(name, "-")
+		nanos, err := strconv.ParseInt(stamp, 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, queueEntry{path: filepath.Join(q.Dir, name), queuedAt: time.Unix(0, nanos)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].queuedAt.Before(entries[j].queuedAt)
+	})
+	return entries
+}
+
+func (q *Queue) expired(entry queueEntry) bool {
+	return q.MaxAge > 0 && q.now().Sub(entry.queuedAt) > q.MaxAge
+}
+
+// trim drops expired entries and then the oldest ones beyond the queue size
+func (q *Queue) trim() {
+	var kept []queueEntry
+	for _, entry := range q.entries() {
+		if q.expired(entry) {
+			q.Remove(entry.path)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	if q.Size > 0 && len(kept) > q.Size {
+		for _, entry := range kept[:len(kept)-q.Size] {
+			q.Remove(entry.path)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueOfflineThenOnline(t *testing.T) {
+	var mu sync.Mutex
+	online := false
+	var delivered []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !online {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var event Event
+		_ = json.Unmarshal(body, &event)
+		delivered = append(delivered, event.Command)
+	}))
+	defer server.Close()
+
+	original := telemetryEndpoint
+	telemetryEndpoint = server.URL
+	defer func() { telemetryEndpoint = original }()
+
+	queue := NewQueue(t.TempDir())
+	SetQueue(queue)
+	defer SetQueue(nil)
+
+	// Offline: the event stays queued
+	require.True(t, waitWithin(TrackCommandAsync(context.Background(), "docker.up", nil, nil, true, nil, time.Second, "v0.0.1"), 5*time.Second))
+	assert.Equal(t, 1, queue.Len())
+	assert.Empty(t, delivered)
+
+	// Online: the new event is sent, then the queued one
+	mu.Lock()
+	online = true
+	mu.Unlock()
+	require.True(t, waitWithin(TrackCommandAsync(context.Background(), "version", nil, nil, true, nil, time.Second, "v0.0.1"), 5*time.Second))
+	assert.Equal(t, 0, queue.Len())
+	assert.Equal(t, []string{"version", "docker.up"}, delivered)
+}
+
+func newTestQueue(t *testing.T, now *time.Time) *Queue {
+	t.Helper()
+	queue := NewQueue(t.TempDir())
+	queue.now = func() time.Time { return *now }
+	return queue
+}
+
+func TestQueueSizeCap(t *testing.T) {
+	now := time.Now()
+	queue := newTestQueue(t, &now)
+	queue.Size = 2
+
+	for _, command := range []string{"first", "second", "third"} {
+		now = now.Add(time.Second)
+		_, err := queue.Add(Event{Command: command})
+		require.NoError(t, err)
+	}
+
+	var sent []string
+	queue.Flush(func(event Event) error {
+		sent = append(sent, event.Command)
+		return nil
+	})
+	assert.Equal(t, []string{"second", "third"}, sent)
+}
+
+func TestQueueFlush(t *testing.T) {
+	now := time.Now()
+	queue := newTestQueue(t, &now)
+	queue.MaxAge = time.Hour
+
+	_, err := queue.Add(Event{Command: "expired"})
+	require.NoError(t, err)
+	now = now.Add(2 * time.Hour)
+	_, err = queue.Add(Event{Command: "kept"})
+	require.NoError(t, err)
+	// Corrupt entries and unrelated files are skipped
+	require.NoError(t, os.WriteFile(filepath.Join(queue.Dir, "1-corrupt.json"), []byte("{not json"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(queue.Dir, "README"), []byte("hello"), 0644))
+
+	// A failed send keeps the entry for a later run
+	queue.Flush(func(event Event) error { return errors.New("offline") })
+	assert.Equal(t, 1, queue.Len())
+
+	var sent []string
+	queue.Flush(func(event Event) error {
+		sent = append(sent, event.Command)
+		return nil
+	})
+	assert.Equal(t, []string{"kept"}, sent)
+	assert.Equal(t, 0, queue.Len())
+}
+
+func TestQueuePurge(t *testing.T) {
+	now := time.Now()
+	queue := newTestQueue(t, &now)
+
+	purged, err := queue.Purge()
+	require.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	for _, command := range []string{"first", "second"} {
+		now = now.Add(time.Second)
+		_, err := queue.Add(Event{Command: command})
+		require.NoError(t, err)
+	}
+	purged, err = queue.Purge()
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	assert.Equal(t, 0, queue.Len())
+}
@@ -0,0 +1,111 @@
+package telemetry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// plainFlags are the flags and recorded values sent as is: fixed choices,
+// durations, counts, service names and build metadata. Any other value may
+// be a path, URL or name identifying the user or their project (e.g.,
+// --template-url, --author, --config), so it is hashed.
+var plainFlags = map[string]bool{
+	"output":         true,
+	"template":       true,
+	"license":        true,
+	"timeout":        true,
+	"verbose":        true,
+	"since":          true,
+	"until":          true,
+	"tail":           true,
+	"wait-timeout":   true,
+	"service":        true,
+	"exit_code":      true,
+	"git_init":       true,
+	"install_ok":     true,
+	"post_create_ok": true,
+	"project_count":  true,
+	"language":       true,
+	"ref_kind":       true,
+	"wait_mode":      true,
+	"sample_rate":    true,
+	"build_commit":   true,
+	"build_date":     true,
+	"go_version":     true,
+}
+
+// secretFlags are the flags whose values are credentials, or reproduce
+// them, as --seed does the secrets templates generate. They are never sent,
+// not even hashed.
+var secretFlags = map[string]bool{
+	"build-arg": true,
+	"git-token": true,
+	"seed":      true,
+}
+
+// SecretValue replaces the value of a secret flag
+const SecretValue = "[redacted]"
+
+// RedactFlags returns flags with the values of sensitive flags hashed and
+// those of secret flags replaced with SecretValue. The
+// flag names are kept so option usage can still be counted, and boolean
+// values are always kept.
+func RedactFlags(flags map[string]string) map[string]string {
+	if flags == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(flags))
+	for name, value := range flags {
+		redacted[name] = redactValue(name, value)
+	}
+	return redacted
+}
+
+// RedactArgs returns args with positional arguments and flag values, such
+// as project names and paths, hashed. Flag names are kept, and the value of
+// a --name=value argument follows the RedactFlags rules, as does the value
+// after a secret flag.
+func RedactArgs(args []string) []string {
+	if args == nil {
+		return nil
+	}
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		if i > 0 && secretFlags[strings.TrimLeft(args[i-1], "-")] && strings.HasPrefix(args[i-1], "--") {
+			redacted[i] = SecretValue
+			continue
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			redacted[i] = hashValue(arg)
+			continue
+		}
+		if name, value, ok := strings.Cut(arg, "="); ok {
+			redacted[i] = name + "=" + redactValue(strings.TrimLeft(name, "-"), value)
+			continue
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
+
+func redactValue(name, value string) string {
+	if plainFlags[name] || value == "" {
+		return value
+	}
+	if secretFlags[name] {
+		return SecretValue
+	}
+	if _, err := strconv.ParseBool(value); err == nil {
+		return value
+	}
+	return hashValue(value)
+}
+
+// hashValue returns a SHA-256 digest of value, so repeated values can be
+// correlated without being revealed
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
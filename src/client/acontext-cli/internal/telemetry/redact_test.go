@@ -0,0 +1,93 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactFlags(t *testing.T) {
+	flags := RedactFlags(map[string]string{
+		"template-url": "git+https://github.com/acme/internal-template.git",
+		"author":       "Jane Doe",
+		"output":       "json",
+		"no-git":       "true",
+		"exit_code":    "2",
+		"config":       "",
+	})
+
+	assert.Equal(t, hashValue("git+https://github.com/acme/internal-template.git"), flags["template-url"])
+	assert.Equal(t, hashValue("Jane Doe"), flags["author"])
+	assert.Equal(t, "json", flags["output"])
+	assert.Equal(t, "true", flags["no-git"])
+	assert.Equal(t, "2", flags["exit_code"])
+	assert.Equal(t, "", flags["config"])
+	assert.Nil(t, RedactFlags(nil))
+}
+
+func TestRedactArgs(t *testing.T) {
+	args := RedactArgs([]string{"my-app", "./services/my-app", "--author", "Jane", "--template-url=file:///home/jane/tpl", "--output=json", "--no-git"})
+
+	assert.Equal(t, []string{
+		hashValue("my-app"),
+		hashValue("./services/my-app"),
+		"--author",
+		hashValue("Jane"),
+		"--template-url=" + hashValue("file:///home/jane/tpl"),
+		"--output=json",
+		"--no-git",
+	}, args)
+}
+
+func TestRedactSecretFlags(t *testing.T) {
+	const token = "ghp_0123456789abcdef"
+
+	assert.Equal(t, map[string]string{"git-token": SecretValue, "seed": SecretValue}, RedactFlags(map[string]string{"git-token": token, "seed": "42"}))
+	assert.Equal(t,
+		[]string{"--git-token", SecretValue, "--git-token=" + SecretValue, hashValue("my-app")},
+		RedactArgs([]string{"--git-token", token, "--git-token=" + token, "my-app"}),
+	)
+	assert.Equal(t, []string{"--build-arg", SecretValue}, RedactArgs([]string{"--build-arg", "NPM_TOKEN=" + token}))
+}
+
+func TestSensitiveValuesNotSent(t *testing.T) {
+	const templateURL = "git+https://github.com/acme/internal-template.git"
+	const author = "Jane Doe"
+
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	original := telemetryEndpoint
+	telemetryEndpoint = server.URL
+	defer func() { telemetryEndpoint = original }()
+
+	wg := TrackCommandAsync(
+		context.Background(),
+		"create",
+		[]string{"secret-app", "--template-url=" + templateURL, "--author", author},
+		map[string]string{"template-url": templateURL, "author": author, "no-git": "true"},
+		false,
+		nil,
+		time.Second,
+		"v0.0.1",
+	)
+	require.True(t, waitWithin(wg, 5*time.Second))
+
+	body := <-bodies
+	assert.NotContains(t, body, templateURL)
+	assert.NotContains(t, body, author)
+	assert.NotContains(t, body, "secret-app")
+	assert.Contains(t, body, `"template-url":"sha256:`)
+	assert.Contains(t, body, `"author":"sha256:`)
+	assert.Contains(t, body, `"no-git":"true"`)
+}
@@ -0,0 +1,45 @@
+package telemetry
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// sampleRate is set with Configure
+var sampleRate = DefaultSampleRate
+
+// sampleDraw is the random draw, in [0, 1), that decides whether the event
+// of the current invocation is sampled in. It is drawn once, so every
+// decision of the invocation agrees; tests replace it.
+var sampleDraw = sync.OnceValue(rand.Float64)
+
+// ParseSampleRate parses a telemetry.sample_rate value, a number from 0 to 1
+func ParseSampleRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("expected a number from 0 to 1 (e.g., 0.1), got %q", value)
+	}
+	return rate, nil
+}
+
+// SampledIn reports whether the event of the current invocation is sampled
+// in at rate: always at 1, never at 0
+func SampledIn(rate float64) bool {
+	return sampleDraw() < rate
+}
+
+// sampleFlags returns flags with whether the event was sampled in and the
+// rate it was sampled at, so the events of successful commands can be
+// weighted back up
+func sampleFlags(flags map[string]string, sampled bool) map[string]string {
+	withSample := make(map[string]string, len(flags)+2)
+	for name, value := range flags {
+		withSample[name] = value
+	}
+	withSample["sampled"] = strconv.FormatBool(sampled)
+	withSample["sample_rate"] = strconv.FormatFloat(sampleRate, 'g', -1, 64)
+	return withSample
+}
@@ -0,0 +1,112 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withSampling makes the invocation's draw draw and the sample rate rate
+// for the duration of the test
+func withSampling(t *testing.T, rate, draw float64) {
+	t.Helper()
+	originalRate, originalDraw := sampleRate, sampleDraw
+	sampleRate = rate
+	sampleDraw = func() float64 { return draw }
+	t.Cleanup(func() { sampleRate, sampleDraw = originalRate, originalDraw })
+}
+
+func TestSampledIn(t *testing.T) {
+	tests := []struct {
+		name string
+		rate float64
+		draw float64
+		want bool
+	}{
+		{name: "rate 1", rate: 1, draw: 0.999, want: true},
+		{name: "rate 0", rate: 0, draw: 0, want: false},
+		{name: "draw below rate", rate: 0.25, draw: 0.1, want: true},
+		{name: "draw at rate", rate: 0.25, draw: 0.25, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withSampling(t, tt.rate, tt.draw)
+			assert.Equal(t, tt.want, SampledIn(tt.rate))
+		})
+	}
+}
+
+func TestParseSampleRate(t *testing.T) {
+	rate, err := ParseSampleRate(" 0.1 ")
+	require.NoError(t, err)
+	assert.Equal(t, 0.1, rate)
+
+	for _, value := range []string{"-0.5", "1.01", "ten percent", ""} {
+		_, err := ParseSampleRate(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestTrackCommandOnceSampling(t *testing.T) {
+	tests := []struct {
+		name        string
+		rate        float64
+		draw        float64
+		success     bool
+		err         error
+		wantSent    bool
+		wantSampled string
+	}{
+		{name: "rate 0 drops success", rate: 0, draw: 0, success: true},
+		{name: "rate 0 sends errors", rate: 0, draw: 0, err: errors.New("boom"), wantSent: true, wantSampled: "false"},
+		{name: "sampled out drops success", rate: 0.1, draw: 0.5, success: true},
+		{name: "sampled out sends errors", rate: 0.1, draw: 0.5, err: errors.New("boom"), wantSent: true, wantSampled: "false"},
+		{name: "sampled in sends success", rate: 0.1, draw: 0.05, success: true, wantSent: true, wantSampled: "true"},
+		{name: "default rate sends success", rate: DefaultSampleRate, draw: 0.999, success: true, wantSent: true, wantSampled: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var delivered []Event
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				var event Event
+				_ = json.Unmarshal(body, &event)
+				mu.Lock()
+				defer mu.Unlock()
+				delivered = append(delivered, event)
+			}))
+			defer server.Close()
+
+			original := telemetryEndpoint
+			telemetryEndpoint = server.URL
+			defer func() { telemetryEndpoint = original }()
+			t.Cleanup(func() { commandTracked.Store(false) })
+			withSampling(t, tt.rate, tt.draw)
+
+			wg := TrackCommandOnce(context.Background(), "docker.up", nil, map[string]string{"exit_code": "0"}, tt.success, tt.err, 0, "v0.0.1")
+			require.True(t, waitWithin(wg, 5*time.Second))
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !tt.wantSent {
+				assert.Empty(t, delivered)
+				return
+			}
+			require.Len(t, delivered, 1)
+			assert.Equal(t, tt.wantSampled, delivered[0].Flags["sampled"])
+			assert.Equal(t, "0", delivered[0].Flags["exit_code"])
+		})
+	}
+}
@@ -2,18 +2,35 @@ package telemetry
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-const (
-	telemetryEndpoint = "https://telemetry.acontext.io/v1/events"
+// telemetryEndpoint and sendTimeout are set with Configure; tests point the
+// endpoint at a local server
+var (
+	telemetryEndpoint = DefaultEndpoint
+	sendTimeout       = DefaultSendTimeout
 )
 
+// Notice is shown once before the first telemetry event is sent
+const Notice = `Acontext CLI collects anonymous usage telemetry to help improve the tool.
+It records the command name, flags, success or error, duration, startup time,
+CLI version and build, OS and architecture. Values that may identify you or your project,
+such as names, paths and URLs, are hashed. No project contents are collected.
+
+To opt out, use any of:
+  - export ACONTEXT_TELEMETRY=0 (or ACONTEXT_TELEMETRY_DISABLED=1)
+  - acontext --no-telemetry <command>
+  - acontext config set telemetry.enabled false
+`
+
 // telemetryBearerToken is set at build time via ldflags
 var telemetryBearerToken = ""
 
@@ -25,6 +42,7 @@ type Event struct {
 	Success     bool              `json:"success"`
 	Error       string            `json:"error,omitempty"`
 	Duration    int64             `json:"duration_ms"`
+	Startup     int64             `json:"startup_ms"` // From process start until the command was dispatched, 0 if it never was
 	Timestamp   string            `json:"timestamp"`
 	Version     string            `json:"version"`
 	OS          string            `json:"os"`
@@ -32,47 +50,123 @@ type Event struct {
 	CommandPath string            `json:"command_path,omitempty"`
 }
 
+var (
+	recordedMu    sync.Mutex
+	recordedFlags = map[string]string{}
+)
+
+// RecordFlag records an extra value (e.g., whether git init ran) to be sent with
+// the flags of the current command's telemetry event
+func RecordFlag(name, value string) {
+	recordedMu.Lock()
+	defer recordedMu.Unlock()
+	recordedFlags[name] = value
+}
+
+// recordedStartup is the startup overhead recorded with RecordStartup, in
+// nanoseconds
+var recordedStartup atomic.Int64
+
+// RecordStartup records how long the CLI took from process start until the
+// command was dispatched, e.g., to spot slow-start regressions, to be sent as
+// the startup_ms of the current command's telemetry event. It is kept apart
+// from the command's duration, which starts when the command is dispatched.
+func RecordStartup(startup time.Duration) {
+	recordedStartup.Store(int64(startup))
+}
+
+// RecordedFlags returns a copy of the values recorded with RecordFlag
+func RecordedFlags() map[string]string {
+	recordedMu.Lock()
+	defer recordedMu.Unlock()
+	flags := make(map[string]string, len(recordedFlags))
+	for name, value := range recordedFlags {
+		flags[name] = value
+	}
+	return flags
+}
+
 // SendEvent sends a telemetry event asynchronously
 func SendEvent(event Event) {
 	// Send in a goroutine to avoid blocking
 	go func() {
-		_ = sendEvent(event)
+		_ = deliver(context.Background(), event)
 		// Silently fail - telemetry should not affect user experience
 	}()
 }
 
-// SendEventAsync sends a telemetry event asynchronously and returns a WaitGroup to wait for completion
-func SendEventAsync(event Event) *sync.WaitGroup {
+// SendEventAsync sends a telemetry event asynchronously and returns a
+// WaitGroup to wait for completion. The request is cancelled along with ctx;
+// the event is queued first, if a queue is set, so a later run sends it then.
+func SendEventAsync(ctx context.Context, event Event) *sync.WaitGroup {
+	send := enqueue(event)
 	var wg sync.WaitGroup
 	wg.Add(1)
 	// Send in a goroutine to avoid blocking
 	go func() {
 		defer wg.Done()
-		_ = sendEvent(event)
+		_ = send(ctx)
 		// Silently fail - telemetry should not affect user experience
 	}()
 	return &wg
 }
 
 // SendEventSync sends a telemetry event synchronously and waits for completion
-func SendEventSync(event Event) error {
-	return sendEvent(event)
+func SendEventSync(ctx context.Context, event Event) error {
+	return deliver(ctx, event)
 }
 
-// sendEvent actually sends the event to the telemetry endpoint
-func sendEvent(event Event) error {
-	// Set timestamp if not set
+// deliver sends event, keeping it in the queue, if one is set, until it has
+// been delivered
+func deliver(ctx context.Context, event Event) error {
+	return enqueue(event)(ctx)
+}
+
+// enqueue adds event to the queue, if one is set, and returns the function
+// that sends it and removes it from the queue once delivered. Once the
+// endpoint is reachable again, the events queued by earlier runs are sent too.
+func enqueue(event Event) func(ctx context.Context) error {
+	q := currentQueue()
+	if q == nil {
+		return func(ctx context.Context) error {
+			return sendEvent(ctx, event)
+		}
+	}
+
+	event = withDefaults(event)
+	path, queueErr := q.Add(event)
+	return func(ctx context.Context) error {
+		if err := sendEvent(ctx, event); err != nil {
+			return err
+		}
+		if queueErr == nil {
+			q.Remove(path)
+		}
+		q.Flush(func(event Event) error {
+			return sendEvent(ctx, event)
+		})
+		return nil
+	}
+}
+
+// withDefaults fills in the timestamp and system info if they are not set
+func withDefaults(event Event) Event {
 	if event.Timestamp == "" {
 		event.Timestamp = time.Now().UTC().Format(time.RFC3339)
 	}
-
-	// Set system info if not set
 	if event.OS == "" {
 		event.OS = runtime.GOOS
 	}
 	if event.Arch == "" {
 		event.Arch = runtime.GOARCH
 	}
+	return event
+}
+
+// sendEvent actually sends the event to the telemetry endpoint, giving up
+// when ctx is done
+func sendEvent(ctx context.Context, event Event) error {
+	event = withDefaults(event)
 
 	// Marshal event to JSON
 	jsonData, err := json.Marshal(event)
@@ -81,7 +175,7 @@ func sendEvent(event Event) error {
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", telemetryEndpoint, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, telemetryEndpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -96,7 +190,7 @@ func sendEvent(event Event) error {
 
 	// Create HTTP client with timeout
 	client := &http.Client{
-		Timeout: 5 * time.Second,
+		Timeout: sendTimeout,
 	}
 
 	// Send request
@@ -116,14 +210,16 @@ func sendEvent(event Event) error {
 	return nil
 }
 
-// TrackCommand tracks a command execution
-func TrackCommand(command string, args []string, flags map[string]string, success bool, err error, duration time.Duration, version string) {
+// newEvent builds the event for a command execution, redacting flag and
+// argument values that may identify the user or their project
+func newEvent(command string, args []string, flags map[string]string, success bool, err error, duration time.Duration, version string) Event {
 	event := Event{
 		Command:     command,
-		Args:        args,
-		Flags:       flags,
+		Args:        RedactArgs(args),
+		Flags:       RedactFlags(flags),
 		Success:     success,
 		Duration:    duration.Milliseconds(),
+		Startup:     time.Duration(recordedStartup.Load()).Milliseconds(),
 		Version:     version,
 		CommandPath: command,
 	}
@@ -131,44 +227,72 @@ func TrackCommand(command string, args []string, flags map[string]string, succes
 	if err != nil {
 		event.Error = err.Error()
 	}
+	return event
+}
 
+// TrackCommand tracks a command execution
+func TrackCommand(command string, args []string, flags map[string]string, success bool, err error, duration time.Duration, version string) {
+	event := newEvent(command, args, flags, success, err, duration, version)
 	SendEvent(event)
 }
 
-// TrackCommandAsync tracks a command execution asynchronously and returns a WaitGroup to wait for completion
-func TrackCommandAsync(command string, args []string, flags map[string]string, success bool, err error, duration time.Duration, version string) *sync.WaitGroup {
-	event := Event{
-		Command:     command,
-		Args:        args,
-		Flags:       flags,
-		Success:     success,
-		Duration:    duration.Milliseconds(),
-		Version:     version,
-		CommandPath: command,
-	}
+// TrackCommandAsync tracks a command execution asynchronously and returns a
+// WaitGroup to wait for completion. The request is cancelled along with ctx.
+func TrackCommandAsync(ctx context.Context, command string, args []string, flags map[string]string, success bool, err error, duration time.Duration, version string) *sync.WaitGroup {
+	event := newEvent(command, args, flags, success, err, duration, version)
+	return SendEventAsync(ctx, event)
+}
 
-	if err != nil {
-		event.Error = err.Error()
-	}
+// commandTracked is set by the first TrackCommandOnce of the process
+var commandTracked atomic.Bool
 
-	return SendEventAsync(event)
+// TrackCommandOnce is TrackCommandAsync for the event of the current CLI
+// invocation, which is sent at most once: the command's success and its
+// failure are reported from different places, and an invocation must never
+// report both. Later calls, including concurrent ones, send nothing and return
+// a WaitGroup that is already done.
+//
+// The event of a successful command is only sent when the invocation is
+// sampled in at the configured sample rate, while that of a failed one is
+// always sent; either records whether it was sampled in.
+func TrackCommandOnce(ctx context.Context, command string, args []string, flags map[string]string, success bool, err error, duration time.Duration, version string) *sync.WaitGroup {
+	if !commandTracked.CompareAndSwap(false, true) {
+		return &sync.WaitGroup{}
+	}
+	sampled := SampledIn(sampleRate)
+	if !sampled && success && err == nil {
+		return &sync.WaitGroup{}
+	}
+	return TrackCommandAsync(ctx, command, args, sampleFlags(flags, sampled), success, err, duration, version)
 }
 
 // TrackCommandSync tracks a command execution synchronously and waits for completion
-func TrackCommandSync(command string, args []string, flags map[string]string, success bool, err error, duration time.Duration, version string) error {
-	event := Event{
-		Command:     command,
-		Args:        args,
-		Flags:       flags,
-		Success:     success,
-		Duration:    duration.Milliseconds(),
-		Version:     version,
-		CommandPath: command,
+func TrackCommandSync(ctx context.Context, command string, args []string, flags map[string]string, success bool, err error, duration time.Duration, version string) error {
+	event := newEvent(command, args, flags, success, err, duration, version)
+	return SendEventSync(ctx, event)
+}
+
+// CheckEndpoint reports whether the telemetry endpoint can be reached. Any HTTP
+// response counts as reachable, no event is sent.
+func CheckEndpoint(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, telemetryEndpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("User-Agent", "acontext-cli")
 
+	client := &http.Client{
+		Timeout: sendTimeout,
+	}
+	resp, err := client.Do(req)
 	if err != nil {
-		event.Error = err.Error()
+		return fmt.Errorf("telemetry endpoint is not reachable: %w", err)
 	}
+	_ = resp.Body.Close()
+	return nil
+}
 
-	return SendEventSync(event)
+// Endpoint returns the URL telemetry events are sent to
+func Endpoint() string {
+	return telemetryEndpoint
 }
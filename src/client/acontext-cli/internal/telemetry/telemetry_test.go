@@ -0,0 +1,92 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackCommandOnce(t *testing.T) {
+	var mu sync.Mutex
+	var delivered []Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var event Event
+		_ = json.Unmarshal(body, &event)
+		mu.Lock()
+		defer mu.Unlock()
+		delivered = append(delivered, event)
+	}))
+	defer server.Close()
+
+	original := telemetryEndpoint
+	telemetryEndpoint = server.URL
+	defer func() { telemetryEndpoint = original }()
+	t.Cleanup(func() { commandTracked.Store(false) })
+
+	// The error path reports first, then the success path must not send
+	require.True(t, waitWithin(TrackCommandOnce(context.Background(), "docker.up", nil, nil, false, errors.New("boom"), 0, "v0.0.1"), 5*time.Second))
+	require.True(t, waitWithin(TrackCommandOnce(context.Background(), "docker.up", nil, nil, true, nil, time.Second, "v0.0.1"), 5*time.Second))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, delivered, 1)
+	assert.False(t, delivered[0].Success)
+	assert.Equal(t, "boom", delivered[0].Error)
+}
+
+func TestTrackCommandOnceConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	delivered := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		delivered++
+	}))
+	defer server.Close()
+
+	original := telemetryEndpoint
+	telemetryEndpoint = server.URL
+	defer func() { telemetryEndpoint = original }()
+	t.Cleanup(func() { commandTracked.Store(false) })
+
+	var calls sync.WaitGroup
+	for i := range 8 {
+		calls.Add(1)
+		go func() {
+			defer calls.Done()
+			success := i%2 == 0
+			waitWithin(TrackCommandOnce(context.Background(), "version", nil, nil, success, nil, 0, "v0.0.1"), 5*time.Second)
+		}()
+	}
+	calls.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, delivered)
+}
+
+func TestRecordStartup(t *testing.T) {
+	t.Cleanup(func() { RecordStartup(0) })
+
+	event := newEvent("docker.up", nil, nil, true, nil, 2*time.Second, "v0.0.1")
+	assert.Zero(t, event.Startup, "nothing recorded, e.g., the command was never dispatched")
+
+	RecordStartup(42 * time.Millisecond)
+	event = newEvent("docker.up", nil, nil, true, nil, 2*time.Second, "v0.0.1")
+	assert.Equal(t, int64(42), event.Startup)
+	assert.Equal(t, int64(2000), event.Duration, "startup is not part of the duration")
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"duration_ms":2000,"startup_ms":42`)
+}
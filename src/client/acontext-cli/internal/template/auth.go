@@ -0,0 +1,79 @@
+package template
+
+import (
+	"os"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/git"
+)
+
+// GitTokenEnvVar is the environment variable holding the token that
+// authenticates HTTPS clones of remote templates. Templates cannot read it
+// with env.
+const GitTokenEnvVar = "ACONTEXT_GIT_TOKEN"
+
+// ErrAuthentication is returned when cloning a remote template fails
+// because the git host rejected the credentials, or needed some that were
+// not given
+var ErrAuthentication = git.ErrAuthentication
+
+// ErrRepoNotFound is returned when the git host reports that the repository
+// of a remote template does not exist, which hosts such as GitHub also
+// report for private repositories the credentials cannot access
+var ErrRepoNotFound = git.ErrRepositoryNotFound
+
+// Transport is how a template URL is fetched
+type Transport string
+
+const (
+	// TransportFile copies a local file:// directory
+	TransportFile Transport = "file"
+	// TransportHTTPS clones over HTTPS, authenticated by a token or the git
+	// credential helpers
+	TransportHTTPS Transport = "https"
+	// TransportHTTP clones over plain HTTP, which is never sent a token
+	TransportHTTP Transport = "http"
+	// TransportSSH clones over SSH, authenticated by the SSH agent and keys
+	TransportSSH Transport = "ssh"
+)
+
+// URLTransport returns how the template at rawURL is fetched, or "" if
+// rawURL is not a template URL
+func URLTransport(rawURL string) Transport {
+	if isLocalURL(rawURL) {
+		return TransportFile
+	}
+	base, _, _ := strings.Cut(rawURL, "#")
+	cloneURL, err := gitCloneURL(base)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case strings.HasPrefix(cloneURL, "https://"):
+		return TransportHTTPS
+	case strings.HasPrefix(cloneURL, "http://"):
+		return TransportHTTP
+	}
+	return TransportSSH
+}
+
+// credentialHelper answers git's credential requests with the token in
+// GitTokenEnvVar. The token is read from the environment of git, so it
+// never appears in its command line or in logs.
+const credentialHelper = `!f() { test "$1" = get && echo username=x-access-token && echo "password=$` + GitTokenEnvVar + `"; }; f`
+
+// cloneAuth returns the git options and environment that clone cloneURL.
+// An HTTPS clone with a token answers credential requests with it instead
+// of the configured credential helpers; otherwise those helpers, and the
+// SSH agent and keys, are used as is. Git never prompts for credentials, so
+// a clone that needs them fails with an authentication error rather than
+// waiting for input hidden behind the progress spinner.
+func cloneAuth(cloneURL, token string) ([]string, []string) {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if token == "" || URLTransport(cloneURL) != TransportHTTPS {
+		return nil, env
+	}
+	// An empty helper clears the configured ones
+	args := []string{"-c", "credential.helper=", "-c", "credential.helper=" + credentialHelper}
+	return args, append(env, GitTokenEnvVar+"="+token)
+}
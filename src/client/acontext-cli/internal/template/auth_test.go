@@ -0,0 +1,69 @@
+package template
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLTransport(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Transport
+	}{
+		{url: "git+https://github.com/myorg/template.git", want: TransportHTTPS},
+		{url: "https://github.com/myorg/template.git#v1.0", want: TransportHTTPS},
+		{url: "git+http://git.internal/myorg/template.git", want: TransportHTTP},
+		{url: "git+ssh://git@github.com/myorg/template.git", want: TransportSSH},
+		{url: "git@github.com:myorg/template.git", want: TransportSSH},
+		{url: "git+git@github.com:myorg/template.git#main", want: TransportSSH},
+		{url: "file:///path/to/template", want: TransportFile},
+		{url: "ftp://example.com/template", want: ""},
+		{url: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.want, URLTransport(tt.url))
+		})
+	}
+}
+
+func TestCloneAuth(t *testing.T) {
+	const token = "ghp_secret"
+
+	args, env := cloneAuth("https://github.com/myorg/template.git", token)
+	assert.Equal(t, []string{"-c", "credential.helper=", "-c", "credential.helper=" + credentialHelper}, args)
+	assert.NotContains(t, strings.Join(args, " "), token, "the token must not be in argv, which is logged")
+	assert.Contains(t, env, GitTokenEnvVar+"="+token)
+	assert.Contains(t, env, "GIT_TERMINAL_PROMPT=0")
+
+	// SSH uses the agent and keys, and plain HTTP is never sent the token
+	for _, cloneURL := range []string{"git@github.com:myorg/template.git", "ssh://git@github.com/myorg/template.git", "http://git.internal/template.git"} {
+		args, env := cloneAuth(cloneURL, token)
+		assert.Nil(t, args, cloneURL)
+		assert.NotContains(t, env, GitTokenEnvVar+"="+token, cloneURL)
+	}
+
+	// Without a token, the configured credential helpers answer
+	args, env = cloneAuth("https://github.com/myorg/template.git", "")
+	assert.Nil(t, args)
+	assert.Contains(t, env, "GIT_TERMINAL_PROMPT=0")
+}
+
+func TestCredentialHelper(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	args, env := cloneAuth("https://github.com/myorg/template.git", "ghp_secret")
+	cmd := exec.Command("git", append(args, "credential", "fill")...)
+	cmd.Env = env
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=github.com\npath=myorg/template.git\n\n")
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "username=x-access-token\n")
+	assert.Contains(t, string(out), "password=ghp_secret\n")
+}
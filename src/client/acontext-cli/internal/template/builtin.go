@@ -0,0 +1,70 @@
+package template
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// BuiltinSource is the Source of templates embedded in the CLI
+const BuiltinSource = "embedded"
+
+// builtinSuffix is stripped from embedded file names. Go files and go.mod
+// carry it so they are not built as part of the CLI's own module.
+const builtinSuffix = ".tmpl"
+
+//go:embed all:builtin
+var builtinFS embed.FS
+
+// builtinDir returns the embedded template at templatePath, e.g., "go/basic"
+func builtinDir(templatePath string) (fs.FS, error) {
+	dir, err := fs.Sub(builtinFS, path.Join("builtin", templatePath))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.Stat(dir, "."); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, templatePath)
+	}
+	return dir, nil
+}
+
+// extractBuiltin writes the embedded template at templatePath into dir,
+// stripping builtinSuffix from file names
+func extractBuiltin(templatePath, dir string) error {
+	src, err := builtinDir(templatePath)
+	if err != nil {
+		return err
+	}
+	return fs.WalkDir(src, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		destPath := filepath.Join(dir, filepath.FromSlash(strings.TrimSuffix(name, builtinSuffix)))
+		if entry.IsDir() {
+			return os.MkdirAll(destPath, 0755)
+		}
+		data, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(destPath, data, 0644)
+	})
+}
+
+// BuiltinManifest returns the manifest of the embedded template at
+// templatePath without extracting it
+func BuiltinManifest(templatePath string) (*Manifest, error) {
+	src, err := builtinDir(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fs.ReadFile(src, ManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("template manifest %s not found", ManifestFile)
+	}
+	return parseManifest(data)
+}
@@ -0,0 +1,55 @@
+package template
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinManifest(t *testing.T) {
+	manifest, err := BuiltinManifest("go/basic")
+	require.NoError(t, err)
+	assert.Equal(t, "go-basic", manifest.Name)
+	assert.Equal(t, "go", manifest.Language)
+	require.Len(t, manifest.Variables, 1)
+	assert.Equal(t, "module", manifest.Variables[0].Name)
+	assert.NoError(t, manifest.Variables[0].Check("github.com/myorg/my-agent"))
+	assert.Error(t, manifest.Variables[0].Check("github.com/myorg/my agent"))
+	require.Len(t, manifest.Features, 2)
+	assert.Equal(t, "tests", manifest.Features[0].Name)
+	assert.Equal(t, ExamplesFeature, manifest.Features[1].Name)
+	assert.False(t, manifest.Features[1].IncludedByDefault(), "examples are only created on request")
+
+	_, err = BuiltinManifest("go/missing")
+	assert.EqualError(t, err, "template path not found: go/missing")
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
+
+func TestListBuiltinTemplateFiles(t *testing.T) {
+	files, err := ListTemplateFiles(context.Background(), &Config{Path: "go/basic", Embedded: true}, FeatureSelection{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "go.mod", "internal/agent/agent.go", "internal/agent/agent_test.go", "main.go"}, files)
+
+	files, err = ListTemplateFiles(context.Background(), &Config{Path: "go/basic", Embedded: true}, FeatureSelection{Minimal: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "go.mod", "internal/agent/agent.go", "main.go"}, files)
+
+	files, err = ListTemplateFiles(context.Background(), &Config{Path: "go/basic", Embedded: true}, FeatureSelection{Minimal: true, With: []string{ExamplesFeature}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "examples/hello-context/main.go", "go.mod", "internal/agent/agent.go", "main.go"}, files)
+}
+
+func TestExtractBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, extractBuiltin("go/basic", dir))
+
+	goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, string(goMod), "module example.com/go-basic\n")
+	assert.FileExists(t, filepath.Join(dir, ManifestFile))
+	assert.NoFileExists(t, filepath.Join(dir, "main.go"+builtinSuffix))
+}
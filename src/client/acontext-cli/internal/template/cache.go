@@ -0,0 +1,291 @@
+package template
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/paths"
+)
+
+// DefaultCacheTTL is how long a cached template ref is used before it is
+// re-resolved against the remote
+const DefaultCacheTTL = 24 * time.Hour
+
+// ErrNotCached is returned in offline mode when a template is not cached
+var ErrNotCached = errors.New("template is not cached")
+
+// Cache stores remote templates on disk. Each fetched commit is stored once
+// under objects/, keyed by its URL and commit, and refs/ maps a URL and ref
+// to the commit it last resolved to. Entries are written to a temporary path
+// and renamed into place, so concurrent creates never see partial templates.
+type Cache struct {
+	Dir string
+	TTL time.Duration
+
+	now func() time.Time
+}
+
+// cacheEntry records which commit a URL and ref resolved to
+type cacheEntry struct {
+	URL       string    `json:"url"`
+	Ref       string    `json:"ref,omitempty"`
+	Commit    string    `json:"commit"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// NewCache returns a cache rooted at dir using DefaultCacheTTL
+func NewCache(dir string) *Cache {
+	return &Cache{Dir: dir, TTL: DefaultCacheTTL, now: time.Now}
+}
+
+// DefaultCache returns the cache in the CLI cache directory
+func DefaultCache() (*Cache, error) {
+	cacheDir, err := paths.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewCache(filepath.Join(cacheDir, "templates")), nil
+}
+
+// Clear removes every cached template
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.Dir); err != nil {
+		return fmt.Errorf("failed to clear template cache: %w", err)
+	}
+	return nil
+}
+
+// Fetch returns the directory holding the template at rawURL, cloning it
+// into the cache when it is missing or its ref is older than the TTL.
+// refresh always clones; offline never does and fails with ErrNotCached
+// when the template has not been cached before. When an expired ref cannot
+// be refreshed, the cached copy is returned along with the refresh error.
+func (c *Cache) Fetch(ctx context.Context, rawURL string, refresh, offline bool) (dir string, refreshErr error, err error) {
+	fetched, refreshErr, err := c.fetch(ctx, rawURL, FetchOptions{Refresh: refresh, Offline: offline})
+	if err != nil {
+		return "", nil, err
+	}
+	return fetched.dir, refreshErr, nil
+}
+
+// fetchedTemplate is where Fetch found a template, and the ref and commit
+// it was fetched at
+type fetchedTemplate struct {
+	dir    string
+	ref    string
+	commit string
+}
+
+// fetch implements Fetch, cloning with opts.GitToken. Refs naming a commit
+// never expire, since the commit they resolve to cannot change.
+func (c *Cache) fetch(ctx context.Context, rawURL string, opts FetchOptions) (fetchedTemplate, error, error) {
+	refresh, offline := opts.Refresh, opts.Offline
+	cloneURL, ref, err := parseRemoteURL(rawURL)
+	if err != nil {
+		return fetchedTemplate{}, nil, err
+	}
+
+	entry, cachedDir := c.lookup(cloneURL, ref)
+	if entry != nil && !refresh && (offline || IsCommitRef(ref) || c.now().Sub(entry.FetchedAt) < c.TTL) {
+		return fetchedTemplate{dir: cachedDir, ref: ref, commit: entry.Commit}, nil, nil
+	}
+	if offline {
+		return fetchedTemplate{}, nil, fmt.Errorf("%w: %s (run without --offline to fetch it)", ErrNotCached, rawURL)
+	}
+
+	fetchedDir, commit, err := c.store(ctx, rawURL, cloneURL, ref, opts.GitToken)
+	if err != nil {
+		if entry != nil && !refresh && ctx.Err() == nil {
+			// The ref is stale but still usable, so keep working offline
+			return fetchedTemplate{dir: cachedDir, ref: ref, commit: entry.Commit}, err, nil
+		}
+		return fetchedTemplate{}, nil, err
+	}
+	return fetchedTemplate{dir: fetchedDir, ref: ref, commit: commit}, nil, nil
+}
+
+// CachedTemplate is a remote template stored in the cache
+type CachedTemplate struct {
+	URL       string
+	Ref       string
+	Commit    string
+	FetchedAt time.Time
+	Dir       string
+	Manifest  *Manifest
+}
+
+// List returns the cached templates, sorted by name and URL. Entries that
+// are incomplete or no longer valid are skipped.
+func (c *Cache) List() ([]CachedTemplate, error) {
+	files, err := os.ReadDir(filepath.Join(c.Dir, "refs"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read template cache: %w", err)
+	}
+
+	var templates []CachedTemplate
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(c.Dir, "refs", file.Name()))
+		if err != nil {
+			continue
+		}
+		var entry cacheEntry
+		if err := json.Unmarshal(data, &entry); err != nil || entry.URL == "" {
+			continue
+		}
+		cached, dir := c.lookup(entry.URL, entry.Ref)
+		if cached == nil {
+			continue
+		}
+		manifest, err := LoadManifest(dir)
+		if err != nil {
+			continue
+		}
+		templates = append(templates, CachedTemplate{
+			URL:       cached.URL,
+			Ref:       cached.Ref,
+			Commit:    cached.Commit,
+			FetchedAt: cached.FetchedAt,
+			Dir:       dir,
+			Manifest:  manifest,
+		})
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Manifest.Name != templates[j].Manifest.Name {
+			return templates[i].Manifest.Name < templates[j].Manifest.Name
+		}
+		return templates[i].SourceURL() < templates[j].SourceURL()
+	})
+	return templates, nil
+}
+
+// SourceURL returns the --template-url value that fetches the template
+func (t CachedTemplate) SourceURL() string {
+	if t.Ref == "" {
+		return t.URL
+	}
+	return t.URL + "#" + t.Ref
+}
+
+// lookup returns the cache entry for a URL and ref along with its template
+// directory, or nil if it is not cached
+func (c *Cache) lookup(cloneURL, ref string) (*cacheEntry, string) {
+	data, err := os.ReadFile(c.refPath(cloneURL, ref))
+	if err != nil {
+		return nil, ""
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Commit == "" {
+		return nil, ""
+	}
+
+	dir := c.objectPath(cloneURL, entry.Commit)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, ""
+	}
+	return &entry, dir
+}
+
+// store clones the template into the cache, with token if it is not empty,
+// and records the commit its ref resolved to, returning the template's
+// directory and that commit
+func (c *Cache) store(ctx context.Context, rawURL, cloneURL, ref, token string) (string, string, error) {
+	if err := os.MkdirAll(filepath.Join(c.Dir, "objects"), 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create template cache: %w", err)
+	}
+	tempDir, err := os.MkdirTemp(c.Dir, "fetch-*")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create template cache: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tempDir)
+	}()
+
+	cloneDir := filepath.Join(tempDir, "template")
+	commit, err := cloneRepo(ctx, cloneURL, ref, token, cloneDir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to clone template from %s: %w", rawURL, err)
+	}
+	if err := os.RemoveAll(filepath.Join(cloneDir, ".git")); err != nil {
+		return "", "", fmt.Errorf("failed to clean up template clone: %w", err)
+	}
+	if _, err := LoadManifest(cloneDir); err != nil {
+		return "", "", fmt.Errorf("invalid template at %s: %w", rawURL, err)
+	}
+
+	dir := c.objectPath(cloneURL, commit)
+	if err := os.Rename(cloneDir, dir); err != nil {
+		// Another create may have stored the same commit first
+		if info, statErr := os.Stat(dir); statErr != nil || !info.IsDir() {
+			return "", "", fmt.Errorf("failed to store template in cache: %w", err)
+		}
+	}
+
+	entry := cacheEntry{URL: cloneURL, Ref: ref, Commit: commit, FetchedAt: c.now().UTC()}
+	if err := c.writeEntry(c.refPath(cloneURL, ref), entry); err != nil {
+		return "", "", err
+	}
+	return dir, commit, nil
+}
+
+// writeEntry atomically writes a ref entry
+func (c *Cache) writeEntry(path string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create template cache: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".ref-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// refPath returns the path of the entry recording what a URL and ref resolved to
+func (c *Cache) refPath(cloneURL, ref string) string {
+	return filepath.Join(c.Dir, "refs", cacheKey(cloneURL+"#"+ref)+".json")
+}
+
+// objectPath returns the directory holding a URL at a commit
+func (c *Cache) objectPath(cloneURL, commit string) string {
+	return filepath.Join(c.Dir, "objects", cacheKey(cloneURL+"@"+commit))
+}
+
+// cacheKey hashes s into a file name
+func cacheKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,288 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTemplateURL = "git+https://github.com/myorg/acontext-template.git#main"
+
+// fakeClone replaces cloneRepo with a function that writes a template and
+// returns commit, counting how often it is called
+func fakeClone(t *testing.T, commit *string, cloneErr *error) *int {
+	t.Helper()
+	var mu sync.Mutex
+	calls := 0
+	original := cloneRepo
+	cloneRepo = func(ctx context.Context, cloneURL, ref, token, dir string) (string, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		if *cloneErr != nil {
+			return "", *cloneErr
+		}
+		writeTemplateFiles(t, dir, map[string]string{
+			ManifestFile: "name: cached-template\n",
+			"main.py":    "print('" + *commit + "')\n",
+			".git/HEAD":  "ref: refs/heads/" + ref + "\n",
+		})
+		return *commit, nil
+	}
+	t.Cleanup(func() { cloneRepo = original })
+	return &calls
+}
+
+// testCache returns a cache whose clock can be moved forward
+func testCache(t *testing.T) (*Cache, *time.Time) {
+	t.Helper()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewCache(t.TempDir())
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func TestCacheFetch(t *testing.T) {
+	commit := "aaa111"
+	var cloneErr error
+	calls := fakeClone(t, &commit, &cloneErr)
+	cache, now := testCache(t)
+	ctx := context.Background()
+
+	dir, _, err := cache.Fetch(ctx, testTemplateURL, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, *calls)
+	assert.FileExists(t, filepath.Join(dir, "main.py"))
+	assert.NoDirExists(t, filepath.Join(dir, ".git"))
+
+	// Within the TTL the cached copy is used
+	cachedDir, _, err := cache.Fetch(ctx, testTemplateURL, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, dir, cachedDir)
+	assert.Equal(t, 1, *calls)
+
+	// --refresh always clones
+	commit = "bbb222"
+	refreshedDir, _, err := cache.Fetch(ctx, testTemplateURL, true, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+	assert.NotEqual(t, dir, refreshedDir)
+
+	// After the TTL the ref is re-resolved
+	*now = now.Add(DefaultCacheTTL + time.Minute)
+	commit = "ccc333"
+	expiredDir, _, err := cache.Fetch(ctx, testTemplateURL, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, *calls)
+	content, err := os.ReadFile(filepath.Join(expiredDir, "main.py"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "ccc333")
+}
+
+func TestCacheFetchOffline(t *testing.T) {
+	commit := "aaa111"
+	var cloneErr error
+	calls := fakeClone(t, &commit, &cloneErr)
+	cache, now := testCache(t)
+	ctx := context.Background()
+
+	_, _, err := cache.Fetch(ctx, testTemplateURL, false, true)
+	assert.ErrorIs(t, err, ErrNotCached)
+	assert.Equal(t, 0, *calls)
+
+	dir, _, err := cache.Fetch(ctx, testTemplateURL, false, false)
+	require.NoError(t, err)
+
+	// Offline uses the cached copy even after the TTL
+	*now = now.Add(2 * DefaultCacheTTL)
+	offlineDir, _, err := cache.Fetch(ctx, testTemplateURL, false, true)
+	require.NoError(t, err)
+	assert.Equal(t, dir, offlineDir)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestCacheFetchFallsBackToStaleCopy(t *testing.T) {
+	commit := "aaa111"
+	var cloneErr error
+	fakeClone(t, &commit, &cloneErr)
+	cache, now := testCache(t)
+	ctx := context.Background()
+
+	dir, _, err := cache.Fetch(ctx, testTemplateURL, false, false)
+	require.NoError(t, err)
+
+	*now = now.Add(2 * DefaultCacheTTL)
+	cloneErr = errors.New("network is unreachable")
+	staleDir, refreshErr, err := cache.Fetch(ctx, testTemplateURL, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, dir, staleDir)
+	assert.ErrorContains(t, refreshErr, "network is unreachable")
+
+	_, _, err = cache.Fetch(ctx, testTemplateURL, true, false)
+	assert.ErrorContains(t, err, "network is unreachable")
+}
+
+func TestCacheFetchRejectsInvalidTemplate(t *testing.T) {
+	original := cloneRepo
+	cloneRepo = func(ctx context.Context, cloneURL, ref, token, dir string) (string, error) {
+		writeTemplateFiles(t, dir, map[string]string{"main.py": "print('hi')\n"})
+		return "aaa111", nil
+	}
+	t.Cleanup(func() { cloneRepo = original })
+	cache, _ := testCache(t)
+
+	_, _, err := cache.Fetch(context.Background(), testTemplateURL, false, false)
+	assert.ErrorContains(t, err, "invalid template")
+
+	_, _, err = cache.Fetch(context.Background(), testTemplateURL, false, true)
+	assert.ErrorIs(t, err, ErrNotCached)
+}
+
+func TestCacheFetchConcurrent(t *testing.T) {
+	commit := "aaa111"
+	var cloneErr error
+	fakeClone(t, &commit, &cloneErr)
+	cache, _ := testCache(t)
+
+	var wg sync.WaitGroup
+	dirs := make([]string, 8)
+	errs := make([]error, len(dirs))
+	for i := range dirs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dirs[i], _, errs[i] = cache.Fetch(context.Background(), testTemplateURL, true, false)
+		}()
+	}
+	wg.Wait()
+
+	for i := range dirs {
+		require.NoError(t, errs[i])
+		assert.Equal(t, dirs[0], dirs[i])
+		assert.FileExists(t, filepath.Join(dirs[i], "main.py"))
+	}
+}
+
+func TestCacheClear(t *testing.T) {
+	commit := "aaa111"
+	var cloneErr error
+	fakeClone(t, &commit, &cloneErr)
+	cache, _ := testCache(t)
+
+	_, _, err := cache.Fetch(context.Background(), testTemplateURL, false, false)
+	require.NoError(t, err)
+
+	require.NoError(t, cache.Clear())
+	assert.NoDirExists(t, cache.Dir)
+	_, _, err = cache.Fetch(context.Background(), testTemplateURL, false, true)
+	assert.ErrorIs(t, err, ErrNotCached)
+
+	// Clearing an empty cache is not an error
+	assert.NoError(t, cache.Clear())
+}
+
+func TestFetchSourceWithCache(t *testing.T) {
+	commit := "aaa111"
+	var cloneErr error
+	fakeClone(t, &commit, &cloneErr)
+	cache, _ := testCache(t)
+
+	source, err := FetchSourceWithOptions(context.Background(), testTemplateURL, FetchOptions{Cache: cache})
+	require.NoError(t, err)
+	assert.Equal(t, "cached-template", source.Manifest.Name)
+
+	// Closing a cached source keeps the cache intact
+	require.NoError(t, source.Close())
+	assert.DirExists(t, source.Dir)
+
+	_, err = FetchSourceWithOptions(context.Background(), testTemplateURL, FetchOptions{Offline: true})
+	assert.ErrorIs(t, err, ErrNotCached)
+}
+
+func TestCacheList(t *testing.T) {
+	commit := "aaa111"
+	var cloneErr error
+	fakeClone(t, &commit, &cloneErr)
+	cache, _ := testCache(t)
+
+	templates, err := cache.List()
+	require.NoError(t, err)
+	assert.Empty(t, templates)
+
+	_, _, err = cache.Fetch(context.Background(), testTemplateURL, false, false)
+	require.NoError(t, err)
+
+	templates, err = cache.List()
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	assert.Equal(t, "cached-template", templates[0].Manifest.Name)
+	assert.Equal(t, "https://github.com/myorg/acontext-template.git#main", templates[0].SourceURL())
+	assert.Equal(t, "aaa111", templates[0].Commit)
+}
+
+func TestCacheFetchCommit(t *testing.T) {
+	commit := "aaa111"
+	var cloneErr error
+	calls := fakeClone(t, &commit, &cloneErr)
+	cache, now := testCache(t)
+	ctx := context.Background()
+
+	fetched, _, err := cache.fetch(ctx, testTemplateURL, FetchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, fetchedTemplate{dir: fetched.dir, ref: "main", commit: "aaa111"}, fetched)
+
+	// The commit of a cached copy is known too
+	commit = "bbb222"
+	cached, _, err := cache.fetch(ctx, testTemplateURL, FetchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "aaa111", cached.commit)
+
+	// A ref naming a commit never expires
+	pinnedURL := "git+https://github.com/myorg/acontext-template.git#3f2a9c1"
+	_, _, err = cache.fetch(ctx, pinnedURL, FetchOptions{})
+	require.NoError(t, err)
+	*now = now.Add(2 * DefaultCacheTTL)
+	pinned, _, err := cache.fetch(ctx, pinnedURL, FetchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, "3f2a9c1", pinned.ref)
+}
+
+func TestFetchSourceRecordsCommit(t *testing.T) {
+	commit := "aaa111"
+	var cloneErr error
+	fakeClone(t, &commit, &cloneErr)
+	cache, _ := testCache(t)
+
+	source, err := FetchSourceWithOptions(context.Background(), testTemplateURL, FetchOptions{Cache: cache})
+	require.NoError(t, err)
+	assert.Equal(t, "main", source.Ref)
+	assert.Equal(t, "aaa111", source.Commit)
+	assert.NoError(t, source.Close())
+}
+
+func TestFetchSourcePassesGitToken(t *testing.T) {
+	var tokens []string
+	original := cloneRepo
+	cloneRepo = func(ctx context.Context, cloneURL, ref, token, dir string) (string, error) {
+		tokens = append(tokens, token)
+		writeTemplateFiles(t, dir, map[string]string{ManifestFile: "name: private-template\n"})
+		return "aaa111", nil
+	}
+	t.Cleanup(func() { cloneRepo = original })
+	cache, _ := testCache(t)
+
+	for _, opts := range []FetchOptions{{Cache: cache, GitToken: "ghp_cached"}, {GitToken: "ghp_uncached"}} {
+		source, err := FetchSourceWithOptions(context.Background(), testTemplateURL, opts)
+		require.NoError(t, err)
+		assert.NoError(t, source.Close())
+	}
+	assert.Equal(t, []string{"ghp_cached", "ghp_uncached"}, tokens)
+}
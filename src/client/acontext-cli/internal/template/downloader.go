@@ -1,45 +1,146 @@
 package template
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/progress"
 	"github.com/pelletier/go-toml/v2"
 )
 
+// ErrTemplateNotFound is returned when a template's path does not exist in
+// its repository or among the embedded templates
+var ErrTemplateNotFound = errors.New("template path not found")
+
 // Config template configuration (to avoid circular imports)
 type Config struct {
 	Repo        string
 	Path        string
 	Description string
+	Embedded    bool // Shipped with the CLI instead of fetched from Repo
 }
 
 // DownloadTemplate downloads template to target directory
-func DownloadTemplate(template *Config, destDir string) error {
-	return DownloadTemplateWithVars(template, destDir, nil)
+func DownloadTemplate(ctx context.Context, template *Config, destDir string) error {
+	return DownloadTemplateWithVars(ctx, template, destDir, nil, FeatureSelection{}, NewSeed())
+}
+
+// DownloadTemplateWithVars downloads template, leaving out the feature
+// groups sel does not select, renders the files its manifest lists under
+// render with vars and the random values seed generates, and replaces
+// template variables
+func DownloadTemplateWithVars(ctx context.Context, template *Config, destDir string, vars map[string]string, sel FeatureSelection, seed uint64) error {
+	spinner := progress.Start(ctx, "📦 Downloading template...")
+	srcDir, cleanup, err := fetchTemplate(ctx, template)
+	spinner.Stop()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	manifest, err := optionalManifest(srcDir)
+	if err != nil {
+		return err
+	}
+	fmt.Println("📋 Copying template files...")
+	if err := copyDir(srcDir, destDir, manifest, sel); err != nil {
+		return fmt.Errorf("failed to copy template: %w", err)
+	}
+	files, err := listFiles(srcDir, manifest, sel)
+	if err != nil {
+		return err
+	}
+	if err := renderTemplateFiles(destDir, manifest, files, vars, seed); err != nil {
+		return err
+	}
+
+	// Replace template variables if provided
+	if len(vars) > 0 {
+		if err := replaceTemplateVars(destDir, vars); err != nil {
+			return fmt.Errorf("failed to replace template variables: %w", err)
+		}
+	}
+
+	fmt.Println("✅ Template downloaded successfully")
+	return nil
+}
+
+// ListTemplateFiles downloads template to a temporary directory and returns the
+// relative paths of the files it would write with the feature groups sel
+// selects, sorted by path
+func ListTemplateFiles(ctx context.Context, template *Config, sel FeatureSelection) ([]string, error) {
+	spinner := progress.StartTransient(ctx, "📦 Downloading template...")
+	srcDir, cleanup, err := fetchTemplate(ctx, template)
+	spinner.Stop()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	manifest, err := optionalManifest(srcDir)
+	if err != nil {
+		return nil, err
+	}
+	return listFiles(srcDir, manifest, sel)
 }
 
-// DownloadTemplateWithVars downloads template and replaces template variables
-func DownloadTemplateWithVars(template *Config, destDir string, vars map[string]string) error {
-	fmt.Println("📦 Downloading template...")
+// FetchManifest downloads template to a temporary directory and returns its
+// manifest, or nil if the template has none
+func FetchManifest(ctx context.Context, template *Config) (*Manifest, error) {
+	spinner := progress.StartTransient(ctx, "📦 Downloading template...")
+	srcDir, cleanup, err := fetchTemplate(ctx, template)
+	spinner.Stop()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return optionalManifest(srcDir)
+}
 
+// optionalManifest loads the manifest of the template at dir, or returns nil
+// if the template has none
+func optionalManifest(dir string) (*Manifest, error) {
+	if _, err := os.Stat(filepath.Join(dir, ManifestFile)); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return LoadManifest(dir)
+}
+
+// fetchTemplate sparse clones the template repository into a temporary directory
+// and returns the template source directory along with a cleanup function
+func fetchTemplate(ctx context.Context, template *Config) (string, func(), error) {
 	// 1. Create temporary directory
 	tempDir, err := os.MkdirTemp("", "acontext-template-*")
 	if err != nil {
-		return fmt.Errorf("failed to create temp directory: %w", err)
+		return "", nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
-	defer func() {
+	cleanup := func() {
 		_ = os.RemoveAll(tempDir)
-	}()
+	}
+
+	if template.Embedded {
+		if err := extractBuiltin(template.Path, tempDir); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+		return tempDir, cleanup, nil
+	}
 
 	// 2. Sparse clone repository
-	cmd := exec.Command(
+	cmd := exec.CommandContext(ctx,
 		"git", "clone",
 		"--filter=blob:none",
 		"--sparse",
@@ -49,51 +150,119 @@ func DownloadTemplateWithVars(template *Config, destDir string, vars map[string]
 	)
 	cmd.Stdout = io.Discard
 	cmd.Stderr = io.Discard
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to clone repo: %w", err)
+	if err := logging.Run(ctx, cmd); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to clone repo: %w", err)
 	}
 
 	// 3. Enable sparse-checkout
-	cmd = exec.Command("git", "sparse-checkout", "init", "--cone")
+	cmd = exec.CommandContext(ctx, "git", "sparse-checkout", "init", "--cone")
 	cmd.Dir = tempDir
 	cmd.Stdout = io.Discard
 	cmd.Stderr = io.Discard
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to init sparse-checkout: %w", err)
+	if err := logging.Run(ctx, cmd); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to init sparse-checkout: %w", err)
 	}
 
 	// 4. Set checkout path
-	cmd = exec.Command("git", "sparse-checkout", "set", template.Path)
+	cmd = exec.CommandContext(ctx, "git", "sparse-checkout", "set", template.Path)
 	cmd.Dir = tempDir
 	cmd.Stdout = io.Discard
 	cmd.Stderr = io.Discard
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to set sparse-checkout: %w", err)
+	if err := logging.Run(ctx, cmd); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to set sparse-checkout: %w", err)
 	}
 
-	// 5. Extract template content
+	// 5. Locate template content
 	srcDir := filepath.Join(tempDir, template.Path)
 	if _, err := os.Stat(srcDir); os.IsNotExist(err) {
-		return fmt.Errorf("template path not found: %s", template.Path)
+		cleanup()
+		return "", nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, template.Path)
 	}
 
-	fmt.Println("📋 Copying template files...")
-	if err := copyDir(srcDir, destDir); err != nil {
-		return fmt.Errorf("failed to copy template: %w", err)
+	return srcDir, cleanup, nil
+}
+
+// ListFiles returns the relative paths of all regular files under dir that
+// would be copied into a project, sorted by path
+func ListFiles(dir string) ([]string, error) {
+	return listFiles(dir, nil, FeatureSelection{})
+}
+
+// listFiles lists the files under dir like ListFiles, leaving out the
+// feature groups of manifest that sel does not select
+func listFiles(dir string, manifest *Manifest, sel FeatureSelection) ([]string, error) {
+	skip, err := templateFilter(dir, manifest, sel)
+	if err != nil {
+		return nil, err
 	}
 
-	// 6. Replace template variables if provided
-	if len(vars) > 0 {
-		if err := replaceTemplateVars(destDir, vars); err != nil {
-			return fmt.Errorf("failed to replace template variables: %w", err)
+	var files []string
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
 		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if relPath != "." && skip(relPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		files = append(files, relPath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Println("✅ Template downloaded successfully")
-	return nil
+	sort.Strings(files)
+	return files, nil
+}
+
+// copyDir copies the template at src to dst, leaving out the feature groups
+// of manifest that sel does not select
+func copyDir(src, dst string, manifest *Manifest, sel FeatureSelection) error {
+	skip, err := templateFilter(src, manifest, sel)
+	if err != nil {
+		return err
+	}
+	return copyTree(src, dst, skip)
 }
 
-func copyDir(src, dst string) error {
+// templateFilter returns the skip function for the template at dir, which
+// skips template metadata, the paths matched by its IgnoreFile and the files
+// of the feature groups of manifest (nil if it has none) that sel does not
+// select
+func templateFilter(dir string, manifest *Manifest, sel FeatureSelection) (func(relPath string, isDir bool) bool, error) {
+	ignore, err := LoadIgnore(dir)
+	if err != nil {
+		return nil, err
+	}
+	var features []Feature
+	if manifest != nil {
+		features = manifest.Features
+	}
+	excluded, err := featureFilter(features, sel)
+	if err != nil {
+		return nil, err
+	}
+	return func(relPath string, isDir bool) bool {
+		return isTemplateMetadata(relPath, isDir) || ignore.Match(relPath, isDir) || excluded(relPath, isDir)
+	}, nil
+}
+
+// copyTree copies the directory tree at src to dst, skipping entries for which skip returns true
+func copyTree(src, dst string, skip func(relPath string, isDir bool) bool) error {
 	// Ensure target directory exists
 	if err := os.MkdirAll(dst, 0755); err != nil {
 		return err
@@ -111,6 +280,13 @@ func copyDir(src, dst string) error {
 			return err
 		}
 
+		if relPath != "." && skip != nil && skip(filepath.ToSlash(relPath), info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		destPath := filepath.Join(dst, relPath)
 
 		if info.IsDir() {
@@ -128,6 +304,15 @@ func copyDir(src, dst string) error {
 	})
 }
 
+// isTemplateMetadata reports whether a template entry should never be copied
+// into a project: git directories, the manifest and the ignore file
+func isTemplateMetadata(relPath string, isDir bool) bool {
+	if isDir {
+		return path.Base(relPath) == ".git"
+	}
+	return relPath == ManifestFile || relPath == IgnoreFile
+}
+
 func copyFile(src, dst string, mode os.FileMode) error {
 	sourceFile, err := os.Open(src)
 	if err != nil {
@@ -167,6 +352,18 @@ func replaceTemplateVars(projectDir string, vars map[string]string) error {
 		}
 	}
 
+	// Handle go.mod (Go projects)
+	goModPath := filepath.Join(projectDir, "go.mod")
+	if _, err := os.Stat(goModPath); err == nil {
+		modulePath := vars["module"]
+		if modulePath == "" {
+			modulePath = vars["project_name"]
+		}
+		if err := replaceGoModulePath(projectDir, modulePath); err != nil {
+			return fmt.Errorf("failed to update go.mod: %w", err)
+		}
+	}
+
 	// Handle Cargo.toml (Rust projects)
 	cargoTomlPath := filepath.Join(projectDir, "Cargo.toml")
 	if _, err := os.Stat(cargoTomlPath); err == nil {
@@ -286,6 +483,65 @@ func replaceCargoTomlName(filePath, projectName string) error {
 	return os.WriteFile(filePath, updatedData, 0644)
 }
 
+// replaceGoModulePath sets the module path in go.mod and updates the imports
+// of the module's own packages in its Go files to match
+func replaceGoModulePath(projectDir, modulePath string) error {
+	if modulePath == "" {
+		return nil
+	}
+
+	goModPath := filepath.Join(projectDir, "go.mod")
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	oldPath := ""
+	for i, line := range lines {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			oldPath = strings.Trim(strings.TrimSpace(rest), `"`)
+			lines[i] = "module " + modulePath
+			break
+		}
+	}
+	if oldPath == "" {
+		return fmt.Errorf("go.mod has no module directive")
+	}
+	if oldPath == modulePath {
+		return nil
+	}
+	if err := os.WriteFile(goModPath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return err
+	}
+
+	// Rewrite "old" and "old/..." import paths, leaving other modules alone
+	imports := regexp.MustCompile(`"` + regexp.QuoteMeta(oldPath) + `(/[^"]*)?"`)
+	return filepath.Walk(projectDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" || info.Name() == "vendor" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		updated := imports.ReplaceAll(data, []byte(`"`+modulePath+`$1"`))
+		if bytes.Equal(updated, data) {
+			return nil
+		}
+		return os.WriteFile(path, updated, info.Mode())
+	})
+}
+
 // sanitizeProjectNameForPackage converts project name to a valid package name
 // For Python: lowercase, replace spaces/hyphens with underscores
 // For npm: lowercase, replace spaces with hyphens
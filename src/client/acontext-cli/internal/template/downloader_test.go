@@ -181,3 +181,79 @@ version = "0.1.0"
 	require.NoError(t, err)
 	assert.Contains(t, string(packageJsonData), `"name": "my-new-project"`)
 }
+
+func TestReplaceGoModulePath(t *testing.T) {
+	tests := []struct {
+		name        string
+		vars        map[string]string
+		wantModule  string
+		wantImports string
+	}{
+		{
+			name:        "module variable",
+			vars:        map[string]string{"project_name": "my-agent", "module": "github.com/myorg/my-agent"},
+			wantModule:  "module github.com/myorg/my-agent\n",
+			wantImports: `"github.com/myorg/my-agent/internal/agent"`,
+		},
+		{
+			name:        "project name",
+			vars:        map[string]string{"project_name": "my-agent"},
+			wantModule:  "module my-agent\n",
+			wantImports: `"my-agent/internal/agent"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTemplateFiles(t, dir, map[string]string{
+				"go.mod": "module example.com/go-basic\n\ngo 1.22\n",
+				"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/go-basic/internal/agent"
+	"example.com/go-basic-extra/lib"
+)
+`,
+			})
+
+			require.NoError(t, replaceTemplateVars(dir, tt.vars))
+			goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantModule+"\ngo 1.22\n", string(goMod))
+			mainGo, err := os.ReadFile(filepath.Join(dir, "main.go"))
+			require.NoError(t, err)
+			assert.Contains(t, string(mainGo), tt.wantImports)
+			assert.Contains(t, string(mainGo), `"example.com/go-basic-extra/lib"`, "other modules are left alone")
+			assert.Contains(t, string(mainGo), `"fmt"`)
+		})
+	}
+}
+
+func TestReplaceGoModulePathWithoutModule(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFiles(t, dir, map[string]string{"go.mod": "go 1.22\n"})
+	assert.EqualError(t, replaceGoModulePath(dir, "my-agent"), "go.mod has no module directive")
+}
+
+func TestListFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := []string{"README.md", "src/main.py", "pyproject.toml", "src/utils/helpers.py"}
+	for _, file := range files {
+		path := filepath.Join(tempDir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("content"), 0644))
+	}
+
+	result, err := ListFiles(tempDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"README.md",
+		"pyproject.toml",
+		"src/main.py",
+		"src/utils/helpers.py",
+	}, result)
+}
@@ -0,0 +1,145 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ExamplesFeature is the feature group of runnable example code, such as a
+// hello-context script, that create --include-examples adds. Templates
+// usually leave it out by default.
+const ExamplesFeature = "examples"
+
+// Feature is an optional group of template files, such as tests, CI config
+// or Docker files, that projects can be created with or without
+type Feature struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description" json:"description"`
+	Paths       []string `yaml:"paths" json:"paths"`     // Gitignore-style patterns of the group's files and directories
+	Default     *bool    `yaml:"default" json:"default"` // Included unless deselected, true if unset
+}
+
+// IncludedByDefault reports whether the group is part of projects created
+// without --with, --without, --minimal or --full
+func (f Feature) IncludedByDefault() bool {
+	return f.Default == nil || *f.Default
+}
+
+// featureNamePattern restricts group names to what --with and --without can
+// take as a comma-separated list
+var featureNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// validate checks the group's name and paths
+func (f Feature) validate() error {
+	if !featureNamePattern.MatchString(f.Name) {
+		return fmt.Errorf("invalid feature group name %q (use letters, digits, - and _)", f.Name)
+	}
+	if len(f.Paths) == 0 {
+		return fmt.Errorf("feature group %s has no paths", f.Name)
+	}
+	_, err := f.matcher()
+	return err
+}
+
+// matcher compiles the group's paths, one pattern per line
+func (f Feature) matcher() (*Ignore, error) {
+	return parsePatterns("feature group "+f.Name, strings.Join(f.Paths, "\n"))
+}
+
+// FeatureSelection picks the feature groups a project is created with. The
+// zero value selects the groups included by default.
+type FeatureSelection struct {
+	Minimal bool     // Start from no group, only the files outside every group are kept
+	Full    bool     // Start from every group
+	With    []string // Groups to include on top of the starting ones
+	Without []string // Groups to leave out
+}
+
+// IsZero reports whether sel selects the default groups
+func (sel FeatureSelection) IsZero() bool {
+	return !sel.Minimal && !sel.Full && len(sel.With) == 0 && len(sel.Without) == 0
+}
+
+// SelectFeatures returns the names of the groups of features that sel
+// includes, in manifest order. It fails when sel names a group features do
+// not declare.
+func SelectFeatures(features []Feature, sel FeatureSelection) ([]string, error) {
+	if sel.Minimal && sel.Full {
+		return nil, fmt.Errorf("minimal and full are contradictory")
+	}
+	known := map[string]bool{}
+	for _, feature := range features {
+		known[feature.Name] = true
+	}
+	without := map[string]bool{}
+	for _, name := range sel.Without {
+		if !known[name] {
+			return nil, unknownFeatureError(features, name)
+		}
+		without[name] = true
+	}
+	with := map[string]bool{}
+	for _, name := range sel.With {
+		if !known[name] {
+			return nil, unknownFeatureError(features, name)
+		}
+		if without[name] {
+			return nil, fmt.Errorf("feature group %s is both included and left out", name)
+		}
+		with[name] = true
+	}
+
+	selected := []string{}
+	for _, feature := range features {
+		included := sel.Full || (!sel.Minimal && feature.IncludedByDefault())
+		if (included || with[feature.Name]) && !without[feature.Name] {
+			selected = append(selected, feature.Name)
+		}
+	}
+	return selected, nil
+}
+
+func unknownFeatureError(features []Feature, name string) error {
+	if len(features) == 0 {
+		return fmt.Errorf("unknown feature group %s (the template declares no feature groups)", name)
+	}
+	names := make([]string, len(features))
+	for i, feature := range features {
+		names[i] = feature.Name
+	}
+	return fmt.Errorf("unknown feature group %s (available: %s)", name, strings.Join(names, ", "))
+}
+
+// featureFilter returns a function reporting whether a template path is
+// left out by sel: it belongs to a group that is not selected, and to no
+// selected group
+func featureFilter(features []Feature, sel FeatureSelection) (func(relPath string, isDir bool) bool, error) {
+	names, err := SelectFeatures(features, sel)
+	if err != nil {
+		return nil, err
+	}
+	selected := map[string]bool{}
+	for _, name := range names {
+		selected[name] = true
+	}
+	matchers := make([]*Ignore, len(features))
+	for i, feature := range features {
+		if matchers[i], err = feature.matcher(); err != nil {
+			return nil, err
+		}
+	}
+	return func(relPath string, isDir bool) bool {
+		excluded := false
+		for i, feature := range features {
+			if !matchers[i].Match(relPath, isDir) {
+				continue
+			}
+			if selected[feature.Name] {
+				return false
+			}
+			excluded = true
+		}
+		return excluded
+	}, nil
+}
@@ -0,0 +1,196 @@
+package template
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// featureManifest declares three groups, ci left out by default
+const featureManifest = `name: featured
+features:
+  - name: tests
+    description: Unit tests
+    paths: [tests/, "*_test.py"]
+  - name: ci
+    description: GitHub Actions workflows
+    paths: [/.github/]
+    default: false
+  - name: docker
+    description: Dockerfile and compose file
+    paths: [Dockerfile, docker-compose.yaml, .dockerignore]
+`
+
+func TestSelectFeatures(t *testing.T) {
+	manifest, err := parseManifest([]byte(featureManifest))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		sel    FeatureSelection
+		want   []string
+		errMsg string
+	}{
+		{name: "defaults", want: []string{"tests", "docker"}},
+		{name: "minimal", sel: FeatureSelection{Minimal: true}, want: []string{}},
+		{name: "full", sel: FeatureSelection{Full: true}, want: []string{"tests", "ci", "docker"}},
+		{name: "with", sel: FeatureSelection{With: []string{"ci"}}, want: []string{"tests", "ci", "docker"}},
+		{name: "without", sel: FeatureSelection{Without: []string{"tests"}}, want: []string{"docker"}},
+		{name: "minimal with", sel: FeatureSelection{Minimal: true, With: []string{"docker"}}, want: []string{"docker"}},
+		{name: "full without", sel: FeatureSelection{Full: true, Without: []string{"docker"}}, want: []string{"tests", "ci"}},
+		{name: "unknown group", sel: FeatureSelection{With: []string{"lint"}}, errMsg: "unknown feature group lint (available: tests, ci, docker)"},
+		{name: "with and without", sel: FeatureSelection{With: []string{"ci"}, Without: []string{"ci"}}, errMsg: "feature group ci is both included and left out"},
+		{name: "minimal and full", sel: FeatureSelection{Minimal: true, Full: true}, errMsg: "minimal and full are contradictory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectFeatures(manifest.Features, tt.sel)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err = SelectFeatures(nil, FeatureSelection{Without: []string{"tests"}})
+	assert.EqualError(t, err, "unknown feature group tests (the template declares no feature groups)")
+	got, err := SelectFeatures(nil, FeatureSelection{Minimal: true})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestRenderFeatures(t *testing.T) {
+	templateDir := t.TempDir()
+	writeTemplateFiles(t, templateDir, map[string]string{
+		ManifestFile:               featureManifest,
+		"README.md":                "# App\n",
+		"app/main.py":              "print('hello')\n",
+		"app/main_test.py":         "def test_main(): pass\n",
+		"tests/test_app.py":        "def test_app(): pass\n",
+		".github/workflows/ci.yml": "on: push\n",
+		"Dockerfile":               "FROM python:3.12\n",
+		".dockerignore":            ".venv\n",
+	})
+
+	source, err := FetchSource(context.Background(), "file://"+templateDir)
+	require.NoError(t, err)
+	defer func() {
+		_ = source.Close()
+	}()
+
+	tests := []struct {
+		name string
+		sel  FeatureSelection
+		want []string
+	}{
+		{
+			name: "minimal omits every group",
+			sel:  FeatureSelection{Minimal: true},
+			want: []string{"README.md", "app/main.py"},
+		},
+		{
+			name: "defaults omit ci",
+			want: []string{".dockerignore", "Dockerfile", "README.md", "app/main.py", "app/main_test.py", "tests/test_app.py"},
+		},
+		{
+			name: "full includes every group",
+			sel:  FeatureSelection{Full: true},
+			want: []string{".dockerignore", ".github/workflows/ci.yml", "Dockerfile", "README.md", "app/main.py", "app/main_test.py", "tests/test_app.py"},
+		},
+		{
+			name: "minimal with docker",
+			sel:  FeatureSelection{Minimal: true, With: []string{"docker"}},
+			want: []string{".dockerignore", "Dockerfile", "README.md", "app/main.py"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := source.Files(tt.sel)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, files)
+
+			destDir := filepath.Join(t.TempDir(), "my-app")
+			require.NoError(t, source.Render(destDir, nil, tt.sel, 1))
+			rendered, err := ListFiles(destDir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rendered)
+			if tt.sel.Minimal {
+				_, err := os.Stat(filepath.Join(destDir, "tests"))
+				assert.True(t, os.IsNotExist(err), "directories of omitted groups should not be created")
+			}
+		})
+	}
+
+	err = source.Render(filepath.Join(t.TempDir(), "my-app"), nil, FeatureSelection{Without: []string{"lint"}}, 1)
+	assert.ErrorContains(t, err, "unknown feature group lint")
+}
+
+func TestFeatureShared(t *testing.T) {
+	// A file in a selected group is kept even if another group is left out
+	features := []Feature{
+		{Name: "docker", Paths: []string{"Dockerfile", ".dockerignore"}},
+		{Name: "devcontainer", Paths: []string{".devcontainer/", ".dockerignore"}},
+	}
+	skip, err := featureFilter(features, FeatureSelection{Without: []string{"devcontainer"}})
+	require.NoError(t, err)
+	assert.False(t, skip(".dockerignore", false))
+	assert.True(t, skip(".devcontainer", true))
+	assert.False(t, skip("main.py", false))
+}
+
+func TestLoadManifestFeatures(t *testing.T) {
+	manifest, err := parseManifest([]byte(featureManifest))
+	require.NoError(t, err)
+	require.Len(t, manifest.Features, 3)
+	assert.True(t, manifest.Features[0].IncludedByDefault())
+	require.NotNil(t, manifest.Features[0].Default, "an unset default is filled in")
+	assert.False(t, manifest.Features[1].IncludedByDefault())
+
+	tests := []struct {
+		name     string
+		features string
+		errMsg   string
+	}{
+		{
+			name:     "missing name",
+			features: "  - paths: [tests/]\n",
+			errMsg:   `invalid feature group name ""`,
+		},
+		{
+			name:     "name with a comma",
+			features: "  - name: tests,ci\n    paths: [tests/]\n",
+			errMsg:   `invalid feature group name "tests,ci"`,
+		},
+		{
+			name:     "no paths",
+			features: "  - name: tests\n",
+			errMsg:   "feature group tests has no paths",
+		},
+		{
+			name:     "invalid path",
+			features: "  - name: tests\n    paths: [\"[z-a]\"]\n",
+			errMsg:   "feature group tests line 1: invalid pattern",
+		},
+		{
+			name:     "duplicate name",
+			features: "  - name: tests\n    paths: [tests/]\n  - name: tests\n    paths: [spec/]\n",
+			errMsg:   `declares feature group "tests" more than once`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseManifest([]byte("name: custom\nfeatures:\n" + tt.features))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
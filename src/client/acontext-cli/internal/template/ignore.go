@@ -0,0 +1,156 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is the name of the file in the template root listing files that
+// are not copied into projects, using gitignore-style patterns
+const IgnoreFile = ".acontextignore"
+
+// Ignore matches template paths against the patterns of an IgnoreFile
+type Ignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool // The pattern starts with "!" and re-includes matching paths
+	dirOnly bool // The pattern ends with "/" and only matches directories
+}
+
+// LoadIgnore loads the IgnoreFile from the template root directory. A template
+// without one ignores nothing.
+func LoadIgnore(dir string) (*Ignore, error) {
+	data, err := os.ReadFile(filepath.Join(dir, IgnoreFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Ignore{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFile, err)
+	}
+	return ParseIgnore(string(data))
+}
+
+// ParseIgnore parses gitignore-style patterns, one per line. Blank lines and
+// lines starting with "#" are skipped, "!" negates a pattern, a trailing "/"
+// only matches directories, and "**" matches any number of directories.
+// Patterns without a "/" (other than a trailing one) match at any depth,
+// others are relative to the template root.
+func ParseIgnore(content string) (*Ignore, error) {
+	return parsePatterns(IgnoreFile, content)
+}
+
+// parsePatterns parses patterns like ParseIgnore, naming origin in errors
+func parsePatterns(origin, content string) (*Ignore, error) {
+	ignore := &Ignore{}
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		expr := globToRegexp(line)
+		if !anchored {
+			expr = "(.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid pattern %q: %w", origin, i+1, line, err)
+		}
+		rule.re = re
+		ignore.rules = append(ignore.rules, rule)
+	}
+	return ignore, nil
+}
+
+// Match reports whether the slash-separated relPath is ignored. As in git, a
+// path inside an ignored directory is ignored too, and cannot be re-included
+// by a negated pattern.
+func (i *Ignore) Match(relPath string, isDir bool) bool {
+	if i == nil || len(i.rules) == 0 {
+		return false
+	}
+	parts := strings.Split(relPath, "/")
+	for n := 1; n < len(parts); n++ {
+		if i.matchPath(strings.Join(parts[:n], "/"), true) {
+			return true
+		}
+	}
+	return i.matchPath(relPath, isDir)
+}
+
+// matchPath applies the rules to relPath alone; the last matching rule wins
+func (i *Ignore) matchPath(relPath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range i.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(relPath) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp translates a gitignore glob into a regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			// Zero or more leading directories
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**") && i+2 == len(glob):
+			// Everything inside
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
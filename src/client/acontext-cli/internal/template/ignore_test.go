@@ -0,0 +1,96 @@
+package template
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{name: "glob matches at any depth", patterns: "*.pyc", path: "src/pkg/mod.pyc", expected: true},
+		{name: "glob does not cross directories", patterns: "src/*.py", path: "src/pkg/mod.py", expected: false},
+		{name: "anchored glob", patterns: "src/*.py", path: "src/main.py", expected: true},
+		{name: "leading slash anchors to the root", patterns: "/build", path: "sub/build", isDir: true, expected: false},
+		{name: "leading slash matches at the root", patterns: "/build", path: "build", isDir: true, expected: true},
+		{name: "question mark", patterns: "file?.txt", path: "file1.txt", expected: true},
+		{name: "character class", patterns: "*.[oa]", path: "lib.a", expected: true},
+		{name: "negated character class", patterns: "*.[!oa]", path: "lib.a", expected: false},
+		{name: "double star prefix", patterns: "**/fixtures", path: "a/b/fixtures", isDir: true, expected: true},
+		{name: "double star in the middle", patterns: "docs/**/draft.md", path: "docs/draft.md", expected: true},
+		{name: "double star suffix", patterns: "internal/**", path: "internal/ci/deploy.yml", expected: true},
+		{name: "double star suffix keeps the directory", patterns: "internal/**", path: "internal", isDir: true, expected: false},
+		{name: "directory pattern matches directories", patterns: "__pycache__/", path: "src/__pycache__", isDir: true, expected: true},
+		{name: "directory pattern skips files", patterns: "__pycache__/", path: "src/__pycache__", expected: false},
+		{name: "files inside an ignored directory", patterns: "__pycache__/", path: "src/__pycache__/mod.pyc", expected: true},
+		{name: "negation re-includes", patterns: "*.md\n!README.md", path: "README.md", expected: false},
+		{name: "negation keeps others ignored", patterns: "*.md\n!README.md", path: "CHANGELOG.md", expected: true},
+		{name: "last match wins", patterns: "!README.md\n*.md", path: "README.md", expected: true},
+		{name: "negation cannot re-include inside an ignored directory", patterns: "ci/\n!ci/keep.yml", path: "ci/keep.yml", expected: true},
+		{name: "negation inside a double star suffix", patterns: "ci/**\n!ci/keep.yml", path: "ci/keep.yml", expected: false},
+		{name: "comments and blank lines", patterns: "# *.go\n\n", path: "main.go", expected: false},
+		{name: "escaped hash", patterns: `\#notes`, path: "#notes", expected: true},
+		{name: "trailing spaces are ignored", patterns: ".DS_Store  ", path: "a/.DS_Store", expected: true},
+		{name: "no patterns", patterns: "", path: "main.go", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ignore, err := ParseIgnore(tt.patterns)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ignore.Match(tt.path, tt.isDir))
+		})
+	}
+}
+
+func TestLoadIgnoreMissing(t *testing.T) {
+	ignore, err := LoadIgnore(t.TempDir())
+	require.NoError(t, err)
+	assert.False(t, ignore.Match("anything", false))
+}
+
+func TestRenderHonorsIgnoreFile(t *testing.T) {
+	templateDir := t.TempDir()
+	writeTemplateFiles(t, templateDir, map[string]string{
+		ManifestFile:                "name: org-template\n",
+		IgnoreFile:                  "# editor and build artifacts\n*.swp\n__pycache__/\n/.github/\n*.md\n!README.md\n",
+		"README.md":                 "# App\n",
+		"NOTES.md":                  "internal notes\n",
+		"main.py":                   "print('hello')\n",
+		".main.py.swp":              "swap\n",
+		"pkg/__pycache__/mod.pyc":   "bytecode\n",
+		"pkg/mod.py":                "x = 1\n",
+		".github/workflows/ci.yml":  "on: push\n",
+		"vendor/.git/HEAD":          "ref: refs/heads/main\n",
+		"vendor/lib/.github/ok.yml": "kept\n",
+	})
+
+	source, err := FetchSource(context.Background(), "file://"+templateDir)
+	require.NoError(t, err)
+	defer func() {
+		_ = source.Close()
+	}()
+
+	expected := []string{"README.md", "main.py", "pkg/mod.py", "vendor/lib/.github/ok.yml"}
+	files, err := source.Files(FeatureSelection{})
+	require.NoError(t, err)
+	assert.Equal(t, expected, files)
+
+	destDir := filepath.Join(t.TempDir(), "my-app")
+	require.NoError(t, source.Render(destDir, nil, FeatureSelection{}, 1))
+	rendered, err := ListFiles(destDir)
+	require.NoError(t, err)
+	assert.Equal(t, expected, rendered)
+	_, err = os.Stat(filepath.Join(destDir, IgnoreFile))
+	assert.True(t, os.IsNotExist(err), "ignore file should not be copied into the project")
+}
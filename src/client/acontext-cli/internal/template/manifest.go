@@ -0,0 +1,116 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/license"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the name of the manifest every custom template must contain
+const ManifestFile = "acontext.template.yaml"
+
+// Manifest describes a custom template
+type Manifest struct {
+	Name        string     `yaml:"name"`
+	Description string     `yaml:"description"`
+	Language    string     `yaml:"language"`
+	Install     string     `yaml:"install"`     // Dependency install command, detected from the project files if empty
+	Test        string     `yaml:"test"`        // Test command run by the generated CI workflow, detected from the project files if empty
+	PostCreate  string     `yaml:"post_create"` // Script run in new projects, relative to the project root
+	Variables   []Variable `yaml:"variables"`   // Template-specific variables, in addition to StandardVariables
+	Features    []Feature  `yaml:"features"`    // Optional groups of files, see FeatureSelection
+	Render      []string   `yaml:"render"`      // Gitignore-style patterns of the files rendered with text/template, see renderTemplateFiles
+	Defaults    Defaults   `yaml:"defaults"`    // Defaults the template suggests for create settings
+}
+
+// Defaults are the create settings a template suggests. Each is used unless
+// its flag, the spec file or the user config sets it, and the hardcoded
+// default applies when the template leaves it empty.
+type Defaults struct {
+	GitBranch string `yaml:"git_branch"` // Initial Git branch name
+	License   string `yaml:"license"`    // SPDX identifier, or none for no LICENSE
+	Install   *bool  `yaml:"install"`    // Whether dependencies are installed after create
+}
+
+// renderMatcher compiles the Render patterns, one per line
+func (m *Manifest) renderMatcher() (*Ignore, error) {
+	return parsePatterns("render", strings.Join(m.Render, "\n"))
+}
+
+// LoadManifest loads and validates the template manifest from the template root directory
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("template manifest %s not found", ManifestFile)
+		}
+		return nil, fmt.Errorf("failed to read template manifest: %w", err)
+	}
+	return parseManifest(data)
+}
+
+// parseManifest parses and validates the contents of a template manifest
+func parseManifest(data []byte) (*Manifest, error) {
+	var manifest Manifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse template manifest: %w", err)
+	}
+
+	if manifest.Name == "" {
+		return nil, fmt.Errorf("template manifest %s is missing required field: name", ManifestFile)
+	}
+
+	if manifest.PostCreate != "" {
+		if clean := filepath.ToSlash(filepath.Clean(manifest.PostCreate)); filepath.IsAbs(manifest.PostCreate) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return nil, fmt.Errorf("template manifest %s: post_create must be a path inside the project, got %q", ManifestFile, manifest.PostCreate)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, variable := range StandardVariables {
+		seen[variable.Name] = true
+	}
+	for _, variable := range manifest.Variables {
+		if variable.Name == "" {
+			return nil, fmt.Errorf("template manifest %s has a variable without a name", ManifestFile)
+		}
+		if seen[variable.Name] {
+			return nil, fmt.Errorf("template manifest %s declares variable %q more than once or redefines a standard variable", ManifestFile, variable.Name)
+		}
+		seen[variable.Name] = true
+		if err := variable.validate(); err != nil {
+			return nil, fmt.Errorf("template manifest %s: %w", ManifestFile, err)
+		}
+	}
+
+	groups := map[string]bool{}
+	for i, feature := range manifest.Features {
+		if err := feature.validate(); err != nil {
+			return nil, fmt.Errorf("template manifest %s: %w", ManifestFile, err)
+		}
+		if groups[feature.Name] {
+			return nil, fmt.Errorf("template manifest %s declares feature group %q more than once", ManifestFile, feature.Name)
+		}
+		groups[feature.Name] = true
+		if feature.Default == nil {
+			included := true
+			manifest.Features[i].Default = &included
+		}
+	}
+
+	if id := manifest.Defaults.License; id != "" && !strings.EqualFold(id, license.None) {
+		if _, err := license.Lookup(id); err != nil {
+			return nil, fmt.Errorf("template manifest %s: defaults.license: %w", ManifestFile, err)
+		}
+	}
+
+	if _, err := manifest.renderMatcher(); err != nil {
+		return nil, fmt.Errorf("template manifest %s: %w", ManifestFile, err)
+	}
+
+	return &manifest, nil
+}
@@ -0,0 +1,72 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifestDefaults(t *testing.T) {
+	install := true
+	tests := []struct {
+		name     string
+		manifest string
+		expected Defaults
+		wantErr  string
+	}{
+		{name: "none", manifest: "name: custom\n"},
+		{
+			name:     "all",
+			manifest: "name: custom\ndefaults:\n  git_branch: trunk\n  license: Apache-2.0\n  install: true\n",
+			expected: Defaults{GitBranch: "trunk", License: "Apache-2.0", Install: &install},
+		},
+		{
+			name:     "no license",
+			manifest: "name: custom\ndefaults:\n  license: none\n",
+			expected: Defaults{License: "none"},
+		},
+		{
+			name:     "unknown license",
+			manifest: "name: custom\ndefaults:\n  license: Apache2\n",
+			wantErr:  `defaults.license: unknown license "Apache2", did you mean Apache-2.0?`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := parseManifest([]byte(tt.manifest))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, manifest.Defaults)
+		})
+	}
+}
+
+func TestParseManifestPostCreate(t *testing.T) {
+	tests := []struct {
+		postCreate string
+		wantErr    bool
+	}{
+		{postCreate: "scripts/setup.sh"},
+		{postCreate: "./setup.sh"},
+		{postCreate: "../setup.sh", wantErr: true},
+		{postCreate: "scripts/../../setup.sh", wantErr: true},
+		{postCreate: "/usr/local/bin/setup.sh", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.postCreate, func(t *testing.T) {
+			manifest, err := parseManifest([]byte("name: custom\npost_create: " + tt.postCreate + "\n"))
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "post_create must be a path inside the project")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.postCreate, manifest.PostCreate)
+		})
+	}
+}
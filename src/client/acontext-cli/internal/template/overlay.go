@@ -0,0 +1,65 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BackupDir is the directory inside a project that holds files overwritten by
+// a forced create
+const BackupDir = ".acontext-backup"
+
+// Overlay copies every file in srcDir into dstDir. Existing files in dstDir
+// that would be overwritten are first moved to backupDir, keeping their
+// relative paths. It returns the relative paths of the overwritten files,
+// sorted by path.
+func Overlay(srcDir, dstDir, backupDir string) ([]string, error) {
+	var overwritten []string
+	err := filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		destPath := filepath.Join(dstDir, relPath)
+
+		if info.IsDir() {
+			// Keep any existing directory, only files are overwritten
+			if existing, err := os.Stat(destPath); err == nil && existing.IsDir() {
+				return nil
+			}
+		}
+
+		if _, err := os.Lstat(destPath); err == nil {
+			backupPath := filepath.Join(backupDir, relPath)
+			if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+				return err
+			}
+			if err := os.Rename(destPath, backupPath); err != nil {
+				return fmt.Errorf("failed to back up %s: %w", relPath, err)
+			}
+			overwritten = append(overwritten, filepath.ToSlash(relPath))
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+
+		if info.IsDir() {
+			return os.MkdirAll(destPath, info.Mode())
+		}
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return err
+		}
+		return copyFile(path, destPath, info.Mode())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(overwritten)
+	return overwritten, nil
+}
@@ -0,0 +1,66 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlay(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	backupDir := filepath.Join(dstDir, BackupDir)
+
+	writeTemplateFiles(t, srcDir, map[string]string{
+		"README.md":   "new readme",
+		"src/main.py": "new main",
+		"src/util.py": "util",
+	})
+	writeTemplateFiles(t, dstDir, map[string]string{
+		"README.md":   "old readme",
+		"src/main.py": "old main",
+		"notes.txt":   "keep me",
+	})
+
+	overwritten, err := Overlay(srcDir, dstDir, backupDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "src/main.py"}, overwritten)
+
+	for path, want := range map[string]string{
+		"README.md":   "new readme",
+		"src/main.py": "new main",
+		"src/util.py": "util",
+		"notes.txt":   "keep me",
+	} {
+		content, err := os.ReadFile(filepath.Join(dstDir, path))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content), path)
+	}
+
+	for path, want := range map[string]string{
+		"README.md":   "old readme",
+		"src/main.py": "old main",
+	} {
+		content, err := os.ReadFile(filepath.Join(backupDir, path))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content), path)
+	}
+	assert.NoFileExists(t, filepath.Join(backupDir, "notes.txt"))
+}
+
+func TestOverlayNoConflicts(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	backupDir := filepath.Join(dstDir, BackupDir)
+
+	writeTemplateFiles(t, srcDir, map[string]string{"main.py": "main"})
+
+	overwritten, err := Overlay(srcDir, dstDir, backupDir)
+	require.NoError(t, err)
+	assert.Empty(t, overwritten)
+	assert.NoDirExists(t, backupDir)
+	assert.FileExists(t, filepath.Join(dstDir, "main.py"))
+}
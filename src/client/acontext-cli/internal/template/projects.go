@@ -0,0 +1,138 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectSpec is one project of a projects manifest: the answers of a spec
+// file, plus where the project is created
+type ProjectSpec struct {
+	Spec `yaml:",inline"`
+	Path string `yaml:"path"` // Slash-separated, relative to the manifest's root; defaults to the name
+}
+
+// Dir returns the project's path relative to the root, cleaned
+func (p *ProjectSpec) Dir() string {
+	if p.Path == "" {
+		return p.Name
+	}
+	return path.Clean(p.Path)
+}
+
+// TemplateSource returns the template key, path or URL the project is
+// created from
+func (p *ProjectSpec) TemplateSource() string {
+	switch {
+	case p.TemplateURL != "":
+		return p.TemplateURL
+	case p.TemplatePath != "":
+		return p.TemplatePath
+	}
+	return p.Template
+}
+
+// Projects is a manifest listing several projects created at once by
+// acontext create --projects
+type Projects struct {
+	Projects []ProjectSpec `yaml:"projects"`
+}
+
+// projectKeys are the keys accepted for each project of a manifest
+var projectKeys = append(append([]string{}, specKeys...), "path")
+
+// LoadProjects loads a projects manifest, rejecting unknown keys and
+// projects without a name or template, or that share a name or overlap
+func LoadProjects(file string) (*Projects, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read projects manifest: %w", err)
+	}
+
+	var raw struct {
+		Projects []map[string]yaml.Node `yaml:"projects"`
+	}
+	var top map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &top); err != nil {
+		return nil, fmt.Errorf("failed to parse projects manifest %s: %w", file, err)
+	}
+	for key := range top {
+		if key != "projects" {
+			return nil, fmt.Errorf("projects manifest %s has unknown key %s (expected projects)", file, key)
+		}
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse projects manifest %s: %w", file, err)
+	}
+	for i, project := range raw.Projects {
+		var unknown []string
+		for key := range project {
+			if !contains(projectKeys, key) {
+				unknown = append(unknown, key)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			return nil, fmt.Errorf("projects manifest %s: project %d has unknown keys: %s (expected %s)", file, i+1, strings.Join(unknown, ", "), strings.Join(projectKeys, ", "))
+		}
+	}
+
+	var projects Projects
+	if err := yaml.Unmarshal(data, &projects); err != nil {
+		return nil, fmt.Errorf("failed to parse projects manifest %s: %w", file, err)
+	}
+	if err := projects.validate(); err != nil {
+		return nil, fmt.Errorf("projects manifest %s: %w", file, err)
+	}
+	return &projects, nil
+}
+
+// validate checks each project has a name, one template and a path inside
+// the root that no other project's path contains
+func (p *Projects) validate() error {
+	if len(p.Projects) == 0 {
+		return fmt.Errorf("no projects listed")
+	}
+	names := map[string]bool{}
+	dirs := map[string]string{}
+	for i, project := range p.Projects {
+		if project.Name == "" {
+			return fmt.Errorf("project %d has no name", i+1)
+		}
+		if names[project.Name] {
+			return fmt.Errorf("project %s is listed more than once", project.Name)
+		}
+		names[project.Name] = true
+
+		switch project.templateSources() {
+		case 0:
+			return fmt.Errorf("project %s sets none of template, template_path and template_url", project.Name)
+		case 1:
+		default:
+			return fmt.Errorf("project %s sets more than one of template, template_path and template_url", project.Name)
+		}
+
+		dir := project.Dir()
+		if path.IsAbs(dir) || dir == "." || dir == ".." || strings.HasPrefix(dir, "../") {
+			return fmt.Errorf("project %s: path %s must be inside the root directory", project.Name, project.Path)
+		}
+		if other, ok := dirs[dir]; ok {
+			return fmt.Errorf("projects %s and %s share the path %s", other, project.Name, dir)
+		}
+		for other, name := range dirs {
+			if strings.HasPrefix(dir, other+"/") {
+				return fmt.Errorf("project %s: path %s is inside project %s", project.Name, dir, name)
+			}
+			if strings.HasPrefix(other, dir+"/") {
+				return fmt.Errorf("project %s: path %s is inside project %s", name, other, project.Name)
+			}
+		}
+		dirs[dir] = project.Name
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProjects(t *testing.T) {
+	path := writeSpec(t, `projects:
+  - name: api
+    path: services/api/
+    template: python.openai
+    variables:
+      port: 8080
+  - name: web
+    template_url: file:///templates/web
+`)
+
+	projects, err := LoadProjects(path)
+	require.NoError(t, err)
+	require.Len(t, projects.Projects, 2)
+
+	api := projects.Projects[0]
+	assert.Equal(t, "services/api", api.Dir())
+	assert.Equal(t, "python.openai", api.TemplateSource())
+	assert.Equal(t, map[string]string{"port": "8080"}, api.Variables)
+
+	web := projects.Projects[1]
+	assert.Equal(t, "web", web.Dir())
+	assert.Equal(t, "file:///templates/web", web.TemplateSource())
+}
+
+func TestLoadProjectsInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "unknown top-level key",
+			content:  "projects: []\nroot: .\n",
+			expected: "unknown key root",
+		},
+		{
+			name:     "unknown project key",
+			content:  "projects:\n  - name: api\n    template: python.openai\n    dir: api\n",
+			expected: "project 1 has unknown keys: dir",
+		},
+		{
+			name:     "empty",
+			content:  "projects: []\n",
+			expected: "no projects listed",
+		},
+		{
+			name:     "no name",
+			content:  "projects:\n  - template: python.openai\n",
+			expected: "project 1 has no name",
+		},
+		{
+			name:     "no template",
+			content:  "projects:\n  - name: api\n",
+			expected: "project api sets none of template",
+		},
+		{
+			name:     "two templates",
+			content:  "projects:\n  - name: api\n    template: python.openai\n    template_path: python/custom\n",
+			expected: "project api sets more than one of template",
+		},
+		{
+			name:     "duplicate name",
+			content:  "projects:\n  - name: api\n    template: python.openai\n  - name: api\n    template: python.openai\n    path: other\n",
+			expected: "project api is listed more than once",
+		},
+		{
+			name:     "shared path",
+			content:  "projects:\n  - name: api\n    template: python.openai\n  - name: web\n    template: python.openai\n    path: ./api\n",
+			expected: "projects api and web share the path api",
+		},
+		{
+			name:     "nested path",
+			content:  "projects:\n  - name: api\n    template: python.openai\n  - name: worker\n    template: python.openai\n    path: api/worker\n",
+			expected: "project worker: path api/worker is inside project api",
+		},
+		{
+			name:     "outside the root",
+			content:  "projects:\n  - name: api\n    template: python.openai\n    path: ../api\n",
+			expected: "must be inside the root directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadProjects(writeSpec(t, tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
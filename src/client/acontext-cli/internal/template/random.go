@@ -0,0 +1,82 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"text/template"
+)
+
+// maxRandomLength is the longest value the random template functions
+// generate
+const maxRandomLength = 1024
+
+// randomAlphabet is the characters randomString picks from
+const randomAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// NewSeed returns a random, non-zero seed for the values rendered files
+// generate with randomHex, randomString and uuid
+func NewSeed() uint64 {
+	for {
+		// The default source of math/rand/v2 is seeded by the runtime
+		if seed := rand.Uint64(); seed != 0 {
+			return seed
+		}
+	}
+}
+
+// randomFuncs returns the template functions generating random values in
+// the file at relPath. ChaCha8 keyed with seed and relPath makes the values
+// of a file depend on nothing else, not even the other files rendered, so
+// the same seed renders the same files.
+func randomFuncs(seed uint64, relPath string) template.FuncMap {
+	var key [8]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	rng := rand.New(rand.NewChaCha8(sha256.Sum256(append(key[:], relPath...))))
+
+	return template.FuncMap{
+		// randomHex returns n random bytes, hex-encoded, e.g., for a secret key
+		"randomHex": func(n int) (string, error) {
+			if err := checkRandomLength("randomHex", n); err != nil {
+				return "", err
+			}
+			data := make([]byte, n)
+			for i := range data {
+				data[i] = byte(rng.Uint32())
+			}
+			return hex.EncodeToString(data), nil
+		},
+		// randomString returns n random letters and digits
+		"randomString": func(n int) (string, error) {
+			if err := checkRandomLength("randomString", n); err != nil {
+				return "", err
+			}
+			text := make([]byte, n)
+			for i := range text {
+				text[i] = randomAlphabet[rng.IntN(len(randomAlphabet))]
+			}
+			return string(text), nil
+		},
+		// uuid returns a random (version 4) UUID, e.g., for a project ID
+		"uuid": func() string {
+			var id [16]byte
+			binary.LittleEndian.PutUint64(id[:8], rng.Uint64())
+			binary.LittleEndian.PutUint64(id[8:], rng.Uint64())
+			id[6] = id[6]&0x0f | 0x40
+			id[8] = id[8]&0x3f | 0x80
+			text := hex.EncodeToString(id[:])
+			return text[:8] + "-" + text[8:12] + "-" + text[12:16] + "-" + text[16:20] + "-" + text[20:]
+		},
+	}
+}
+
+// checkRandomLength rejects a length the random function name cannot
+// generate
+func checkRandomLength(name string, n int) error {
+	if n < 1 || n > maxRandomLength {
+		return fmt.Errorf("%s takes a length between 1 and %d, got %d", name, maxRandomLength, n)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package template
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomFuncs(t *testing.T) {
+	templateDir := t.TempDir()
+	writeTemplateFiles(t, templateDir, map[string]string{
+		ManifestFile:  "name: org-template\nrender: [.env, config.yaml]\n",
+		".env":        "SECRET_KEY={{ randomHex 32 }}\nPASSWORD={{ randomString 20 }}\n",
+		"config.yaml": "project_id: {{ uuid }}\nother_id: {{ uuid }}\n",
+	})
+	source, err := FetchSource(context.Background(), "file://"+templateDir)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = source.Close()
+	})
+	render := func(seed uint64) (string, string) {
+		destDir := filepath.Join(t.TempDir(), "my-app")
+		require.NoError(t, source.Render(destDir, nil, FeatureSelection{}, seed))
+		return readRendered(t, destDir, ".env"), readRendered(t, destDir, "config.yaml")
+	}
+
+	env, config := render(42)
+	assert.Regexp(t, regexp.MustCompile(`^SECRET_KEY=[0-9a-f]{64}\nPASSWORD=[a-zA-Z0-9]{20}\n$`), env)
+	assert.Regexp(t, regexp.MustCompile(`^project_id: [0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}\nother_id: [0-9a-f-]{36}\n$`), config)
+
+	sameEnv, sameConfig := render(42)
+	assert.Equal(t, env, sameEnv, "the same seed renders the same files")
+	assert.Equal(t, config, sameConfig)
+
+	otherEnv, otherConfig := render(43)
+	assert.NotEqual(t, env, otherEnv)
+	assert.NotEqual(t, config, otherConfig)
+}
+
+func TestRandomFuncsLength(t *testing.T) {
+	for _, content := range []string{"{{ randomHex 0 }}", "{{ randomString 4096 }}"} {
+		_, err := renderTemplate(t, map[string]string{
+			ManifestFile: "name: org-template\nrender: [README.md]\n",
+			"README.md":  content,
+		}, nil)
+		assert.ErrorContains(t, err, "takes a length between 1 and 1024", content)
+	}
+}
+
+func TestNewSeed(t *testing.T) {
+	assert.NotZero(t, NewSeed())
+	assert.NotEqual(t, NewSeed(), NewSeed())
+}
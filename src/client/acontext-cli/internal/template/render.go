@@ -0,0 +1,161 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// EnvPrefix is the prefix of the environment variables rendered files may
+// read with env. Other variables, which may hold unrelated secrets, are not
+// available to templates.
+const EnvPrefix = "ACONTEXT_"
+
+// UndefinedError is returned when a rendered file references a variable
+// that has no value: one neither declared nor given with --set, or an
+// environment variable that is not set
+type UndefinedError struct {
+	File string // Relative to the template root
+	Line int    // 0 if unknown
+	Name string
+	Env  bool // Name is an environment variable read with env
+
+	available []string // Variables the file could reference instead
+}
+
+func (e *UndefinedError) Error() string {
+	position := e.File
+	if e.Line > 0 {
+		position += ":" + strconv.Itoa(e.Line)
+	}
+	if e.Env {
+		return fmt.Sprintf("%s: environment variable %s is not set (set it, or give a default: {{ env %q \"value\" }})", position, e.Name, e.Name)
+	}
+	return fmt.Sprintf("%s: undefined variable %q (available: %s; pass others with --set %s=value)", position, e.Name, strings.Join(e.available, ", "), e.Name)
+}
+
+// templateLine matches the line a text/template error is at, e.g.,
+// template: README.md:3:5: executing "README.md" at <.x>: ...
+var templateLine = regexp.MustCompile(`^template: [^:]+:(\d+):`)
+
+// missingKey matches the text/template error for a reference to an
+// undefined variable
+var missingKey = regexp.MustCompile(`map has no entry for key "([^"]*)"`)
+
+// renderTemplateFiles renders the files of the template copied into dir
+// that the manifest's Render patterns match, in place, with text/template.
+// The data is every variable of manifest, empty unless set in vars, plus
+// the other names in vars; env reads the EnvPrefix environment variables,
+// and randomHex, randomString and uuid generate values from seed. A
+// reference to anything else fails with an *UndefinedError rather than
+// rendering as empty.
+func renderTemplateFiles(dir string, manifest *Manifest, files []string, vars map[string]string, seed uint64) error {
+	if manifest == nil || len(manifest.Render) == 0 {
+		return nil
+	}
+	matcher, err := manifest.renderMatcher()
+	if err != nil {
+		return err
+	}
+
+	data := map[string]string{}
+	for _, variable := range Variables(manifest) {
+		data[variable.Name] = ""
+	}
+	for name, value := range vars {
+		data[name] = value
+	}
+	available := make([]string, 0, len(data))
+	for name := range data {
+		available = append(available, name)
+	}
+	sort.Strings(available)
+
+	for _, file := range files {
+		if !matchesPathOrParent(matcher, file) {
+			continue
+		}
+		if err := renderTemplateFile(dir, file, data, available, seed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderTemplateFile renders the file at relPath under dir in place
+func renderTemplateFile(dir, relPath string, data map[string]string, available []string, seed uint64) error {
+	path := filepath.Join(dir, filepath.FromSlash(relPath))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New(relPath).Option("missingkey=error").Funcs(template.FuncMap{"env": env}).Funcs(randomFuncs(seed, relPath)).Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", relPath, err)
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		var line int
+		if match := templateLine.FindStringSubmatch(err.Error()); match != nil {
+			line, _ = strconv.Atoi(match[1])
+		}
+		var undefined *UndefinedError
+		if errors.As(err, &undefined) {
+			undefined.File, undefined.Line = relPath, line
+			return undefined
+		}
+		if match := missingKey.FindStringSubmatch(err.Error()); match != nil {
+			return &UndefinedError{File: relPath, Line: line, Name: match[1], available: available}
+		}
+		return fmt.Errorf("failed to render %s: %w", relPath, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, rendered.Bytes(), info.Mode().Perm())
+}
+
+// env returns the value of an EnvPrefix environment variable, or the
+// default given after its name when it is not set
+func env(name string, fallback ...string) (string, error) {
+	if !strings.HasPrefix(name, EnvPrefix) {
+		return "", fmt.Errorf("only %s* environment variables can be read, not %s", EnvPrefix, name)
+	}
+	if name == GitTokenEnvVar {
+		return "", fmt.Errorf("%s holds a credential and cannot be read", name)
+	}
+	if len(fallback) > 1 {
+		return "", fmt.Errorf("env takes a name and at most one default, got %d defaults", len(fallback))
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, nil
+	}
+	if len(fallback) == 1 {
+		return fallback[0], nil
+	}
+	return "", &UndefinedError{Name: name, Env: true}
+}
+
+// matchesPathOrParent reports whether matcher matches relPath, a file, or
+// one of the directories it is in
+func matchesPathOrParent(matcher *Ignore, relPath string) bool {
+	if matcher.Match(relPath, false) {
+		return true
+	}
+	for dir := filepath.ToSlash(filepath.Dir(relPath)); dir != "."; dir = filepath.ToSlash(filepath.Dir(dir)) {
+		if matcher.Match(dir, true) {
+			return true
+		}
+	}
+	return false
+}
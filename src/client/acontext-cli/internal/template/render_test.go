@@ -0,0 +1,109 @@
+package template
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderTemplate renders a template made of files with vars and returns the
+// project directory
+func renderTemplate(t *testing.T, files map[string]string, vars map[string]string) (string, error) {
+	t.Helper()
+	templateDir := t.TempDir()
+	writeTemplateFiles(t, templateDir, files)
+	source, err := FetchSource(context.Background(), "file://"+templateDir)
+	require.NoError(t, err)
+	defer func() {
+		_ = source.Close()
+	}()
+	destDir := filepath.Join(t.TempDir(), "my-app")
+	return destDir, source.Render(destDir, vars, FeatureSelection{}, 1)
+}
+
+func readRendered(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestRenderTemplateFiles(t *testing.T) {
+	t.Setenv("ACONTEXT_REGION", "eu-west-1")
+	manifest := "name: org-template\nrender:\n  - README.md\n  - config/\nvariables:\n  - name: port\n    default: \"8000\"\n  - name: description\n"
+	destDir, err := renderTemplate(t, map[string]string{
+		ManifestFile:          manifest,
+		"README.md":           "# {{ .project_name }}\n{{ .description }}by {{ .author }} in {{ env \"ACONTEXT_REGION\" }}\n",
+		"config/app.yaml":     "port: {{ .port }}\nzone: {{ env \"ACONTEXT_ZONE\" \"a\" }}\nteam: {{ .team }}\n",
+		"config/sub/env.yaml": "name: {{ .project_name }}\n",
+		"web/index.html":      "<p>{{ .project_name }}</p>\n",
+	}, map[string]string{"project_name": "my-app", "port": "9000", "team": "core"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "# my-app\nby  in eu-west-1\n", readRendered(t, destDir, "README.md"), "declared variables without a value are empty")
+	assert.Equal(t, "port: 9000\nzone: a\nteam: core\n", readRendered(t, destDir, "config/app.yaml"))
+	assert.Equal(t, "name: my-app\n", readRendered(t, destDir, "config/sub/env.yaml"))
+	assert.Equal(t, "<p>{{ .project_name }}</p>\n", readRendered(t, destDir, "web/index.html"), "files not listed under render are copied as is")
+}
+
+func TestRenderTemplateFilesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "undefined variable",
+			content: "name: {{ .project_name }}\nport: {{ .prot }}\n",
+			wantErr: `app.yaml:2: undefined variable "prot" (available: author, license, project_name; pass others with --set prot=value)`,
+		},
+		{
+			name:    "unset environment variable",
+			content: "\nregion: {{ env \"ACONTEXT_UNSET_REGION\" }}\n",
+			wantErr: `app.yaml:2: environment variable ACONTEXT_UNSET_REGION is not set`,
+		},
+		{
+			name:    "environment variable without the prefix",
+			content: "home: {{ env \"HOME\" }}\n",
+			wantErr: "only ACONTEXT_* environment variables can be read, not HOME",
+		},
+		{
+			name:    "git token",
+			content: "token: {{ env \"ACONTEXT_GIT_TOKEN\" \"none\" }}\n",
+			wantErr: "ACONTEXT_GIT_TOKEN holds a credential and cannot be read",
+		},
+		{
+			name:    "syntax error",
+			content: "name: {{ .project_name\n",
+			wantErr: "failed to parse app.yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := renderTemplate(t, map[string]string{
+				ManifestFile: "name: org-template\nrender: [app.yaml]\n",
+				"app.yaml":   tt.content,
+			}, map[string]string{"project_name": "my-app"})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("undefined error", func(t *testing.T) {
+		_, err := renderTemplate(t, map[string]string{
+			ManifestFile: "name: org-template\nrender: [app.yaml]\n",
+			"app.yaml":   "{{ .missing }}",
+		}, map[string]string{"project_name": "my-app"})
+		var undefined *UndefinedError
+		require.ErrorAs(t, err, &undefined)
+		assert.Equal(t, "app.yaml", undefined.File)
+		assert.Equal(t, 1, undefined.Line)
+		assert.Equal(t, "missing", undefined.Name)
+		assert.False(t, undefined.Env)
+	})
+}
@@ -0,0 +1,131 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LocalTemplate is a template found in a directory of the template search
+// path
+type LocalTemplate struct {
+	Name     string    // Key create --template takes: <dir> for <root>/<dir>, <language>.<dir> for <root>/<language>/<dir>
+	Dir      string    // Template directory, holding its manifest
+	Root     string    // Search path directory it was found in
+	Manifest *Manifest // The template's manifest
+	Shadowed []string  // Directories of templates of the same name later on the search path, which are never used
+}
+
+// SplitSearchPath splits a template search path, a list of directories
+// separated like PATH (by colons, semicolons on Windows), leaving out empty
+// entries
+func SplitSearchPath(value string) []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(value) {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// SearchTemplates lists the templates in the directories of the search path,
+// sorted by name. A template is a directory with a manifest directly in a
+// search path directory, or in a language directory of one, as in
+// ExamplesRepo (e.g., python/openai is python.openai). When several have the
+// same name, the first one on the search path is used and the others are
+// recorded as Shadowed. Directories that cannot be read and templates with an
+// invalid manifest are skipped and reported as problems, so the others can
+// still be used.
+func SearchTemplates(searchPath []string) ([]LocalTemplate, []error) {
+	var templates []LocalTemplate
+	var problems []error
+	found := map[string]int{}
+	add := func(tmpl LocalTemplate) {
+		if i, ok := found[tmpl.Name]; ok {
+			templates[i].Shadowed = append(templates[i].Shadowed, tmpl.Dir)
+			return
+		}
+		found[tmpl.Name] = len(templates)
+		templates = append(templates, tmpl)
+	}
+
+	for _, root := range searchPath {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("invalid template search path directory %s: %w", root, err))
+			continue
+		}
+		entries, err := os.ReadDir(absRoot)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("cannot read template search path directory %s: %w", root, err))
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			dir := filepath.Join(absRoot, entry.Name())
+			if hasManifest(dir) {
+				tmpl, err := loadLocalTemplate(absRoot, dir, entry.Name())
+				if err != nil {
+					problems = append(problems, err)
+					continue
+				}
+				add(tmpl)
+				continue
+			}
+
+			// A language directory, holding templates of that language
+			children, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, child := range children {
+				childDir := filepath.Join(dir, child.Name())
+				if !child.IsDir() || strings.HasPrefix(child.Name(), ".") || !hasManifest(childDir) {
+					continue
+				}
+				tmpl, err := loadLocalTemplate(absRoot, childDir, entry.Name()+"."+child.Name())
+				if err != nil {
+					problems = append(problems, err)
+					continue
+				}
+				add(tmpl)
+			}
+		}
+	}
+
+	sort.SliceStable(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, problems
+}
+
+// FindLocalTemplate returns the template of the search path named name,
+// which may also be written as language/template
+func FindLocalTemplate(searchPath []string, name string) (LocalTemplate, bool, []error) {
+	key := strings.Replace(name, "/", ".", 1)
+	templates, problems := SearchTemplates(searchPath)
+	for _, tmpl := range templates {
+		if tmpl.Name == key {
+			return tmpl, true, problems
+		}
+	}
+	return LocalTemplate{}, false, problems
+}
+
+// hasManifest reports whether dir holds a template manifest
+func hasManifest(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, ManifestFile))
+	return err == nil && !info.IsDir()
+}
+
+// loadLocalTemplate loads the manifest of the template named name in dir
+func loadLocalTemplate(root, dir, name string) (LocalTemplate, error) {
+	manifest, err := LoadManifest(dir)
+	if err != nil {
+		return LocalTemplate{}, fmt.Errorf("skipping invalid template at %s: %w", dir, err)
+	}
+	return LocalTemplate{Name: name, Dir: dir, Root: root, Manifest: manifest}, nil
+}
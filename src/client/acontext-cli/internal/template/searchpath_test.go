@@ -0,0 +1,66 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSearchPath(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	assert.Equal(t, []string{"/srv/templates", "./templates"}, SplitSearchPath("/srv/templates"+sep+sep+" ./templates "+sep))
+	assert.Nil(t, SplitSearchPath(""))
+}
+
+func TestSearchTemplates(t *testing.T) {
+	team, personal := t.TempDir(), t.TempDir()
+	writeTemplateFiles(t, team, map[string]string{
+		"service/" + ManifestFile:       "name: service\ndescription: Team service\n",
+		"python/openai/" + ManifestFile: "name: team-openai\nlanguage: python\n",
+		"python/notes.txt":              "not a template",
+		"broken/" + ManifestFile:        "description: no name\n",
+		".hidden/" + ManifestFile:       "name: hidden\n",
+		"README.md":                     "# Templates",
+	})
+	writeTemplateFiles(t, personal, map[string]string{
+		"service/" + ManifestFile: "name: my-service\n",
+		"worker/" + ManifestFile:  "name: worker\n",
+	})
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	templates, problems := SearchTemplates([]string{team, missing, personal})
+	require.Len(t, problems, 2)
+	assert.Contains(t, problems[0].Error(), "skipping invalid template at "+filepath.Join(team, "broken"))
+	assert.Contains(t, problems[1].Error(), "cannot read template search path directory "+missing)
+
+	var names []string
+	for _, tmpl := range templates {
+		names = append(names, tmpl.Name)
+	}
+	assert.Equal(t, []string{"python.openai", "service", "worker"}, names)
+	assert.Equal(t, filepath.Join(team, "python", "openai"), templates[0].Dir)
+	assert.Equal(t, "team-openai", templates[0].Manifest.Name)
+	assert.Equal(t, team, templates[1].Root, "the first directory on the search path wins")
+	assert.Equal(t, "service", templates[1].Manifest.Name)
+	assert.Equal(t, []string{filepath.Join(personal, "service")}, templates[1].Shadowed)
+}
+
+func TestFindLocalTemplate(t *testing.T) {
+	root := t.TempDir()
+	writeTemplateFiles(t, root, map[string]string{
+		"python/openai/" + ManifestFile: "name: team-openai\n",
+	})
+
+	for _, name := range []string{"python.openai", "python/openai"} {
+		tmpl, ok, problems := FindLocalTemplate([]string{root}, name)
+		assert.Empty(t, problems)
+		require.True(t, ok, name)
+		assert.True(t, strings.HasSuffix(tmpl.Dir, filepath.Join("python", "openai")))
+	}
+	_, ok, _ := FindLocalTemplate([]string{root}, "python.anthropic")
+	assert.False(t, ok)
+}
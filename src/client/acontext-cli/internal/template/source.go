@@ -0,0 +1,306 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/git"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
+)
+
+// Source is a custom template fetched into a temporary directory or the template cache
+type Source struct {
+	URL      string
+	Dir      string
+	Manifest *Manifest
+
+	// Ref is the branch, tag or commit of a remote template given after "#"
+	// in URL, empty for its default branch, and Commit the commit it
+	// resolved to. Both are empty for local templates.
+	Ref    string
+	Commit string
+
+	// RefreshErr is set when an expired cached copy was used because the
+	// template could not be fetched again
+	RefreshErr error
+
+	tempDir string
+}
+
+// FetchOptions controls how remote templates are fetched
+type FetchOptions struct {
+	Cache    *Cache // Cache for remote templates, nil to always clone
+	Refresh  bool   // Re-fetch remote templates even if they are cached
+	Offline  bool   // Only use cached remote templates, never clone
+	GitToken string // Token that authenticates HTTPS clones, instead of the git credential helpers
+}
+
+// FetchSource fetches a custom template from a git URL (e.g., git+https://...) or a
+// local file:// path into a temporary directory and validates its manifest.
+// The returned Source must be closed to remove the temporary directory.
+func FetchSource(ctx context.Context, rawURL string) (*Source, error) {
+	return FetchSourceWithOptions(ctx, rawURL, FetchOptions{})
+}
+
+// FetchSourceWithOptions fetches a custom template like FetchSource. Remote
+// templates are served from opts.Cache when one is given, in which case the
+// Source points into the cache and Close leaves it in place.
+func FetchSourceWithOptions(ctx context.Context, rawURL string, opts FetchOptions) (*Source, error) {
+	source := &Source{URL: rawURL}
+
+	if opts.Cache != nil && !isLocalURL(rawURL) {
+		fetched, refreshErr, err := opts.Cache.fetch(ctx, rawURL, opts)
+		if err != nil {
+			return nil, err
+		}
+		source.Dir = fetched.dir
+		source.Ref = fetched.ref
+		source.Commit = fetched.commit
+		source.RefreshErr = refreshErr
+	} else {
+		if opts.Offline && !isLocalURL(rawURL) {
+			return nil, fmt.Errorf("%w: %s (run without --offline to fetch it)", ErrNotCached, rawURL)
+		}
+
+		tempDir, err := os.MkdirTemp("", "acontext-template-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		source.Dir = tempDir
+		source.tempDir = tempDir
+
+		if err := source.fetchInto(ctx, tempDir, opts.GitToken); err != nil {
+			_ = source.Close()
+			return nil, err
+		}
+	}
+
+	manifest, err := LoadManifest(source.Dir)
+	if err != nil {
+		_ = source.Close()
+		return nil, fmt.Errorf("invalid template at %s: %w", rawURL, err)
+	}
+	source.Manifest = manifest
+
+	return source, nil
+}
+
+// OpenDir opens the template in the local directory dir in place and
+// validates its manifest. Unlike a file:// URL, it is rendered from dir
+// itself, without first copying it into a temporary directory, so changes to
+// the template show up in the next project. Like every template, its
+// manifest, IgnoreFile and .git directories are never copied into projects.
+// Close leaves dir in place.
+func OpenDir(dir string) (*Source, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template directory %s: %w", dir, err)
+	}
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("template directory %s is not accessible: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("template directory %s is not a directory", dir)
+	}
+	manifest, err := LoadManifest(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template at %s: %w", dir, err)
+	}
+	return &Source{URL: "file://" + filepath.ToSlash(absDir), Dir: absDir, Manifest: manifest}, nil
+}
+
+// Close removes the temporary directory holding the template
+func (s *Source) Close() error {
+	if s.tempDir == "" {
+		return nil
+	}
+	return os.RemoveAll(s.tempDir)
+}
+
+// Render copies the template into destDir, leaving out the feature groups
+// sel does not select, renders the files its manifest lists under render
+// with vars and the random values seed generates, and replaces template
+// variables. It prints nothing, so it can be used outside of the CLI.
+func (s *Source) Render(destDir string, vars map[string]string, sel FeatureSelection, seed uint64) error {
+	if err := copyDir(s.Dir, destDir, s.Manifest, sel); err != nil {
+		return fmt.Errorf("failed to copy template: %w", err)
+	}
+	files, err := s.Files(sel)
+	if err != nil {
+		return err
+	}
+	if err := renderTemplateFiles(destDir, s.Manifest, files, vars, seed); err != nil {
+		return err
+	}
+
+	if len(vars) > 0 {
+		if err := replaceTemplateVars(destDir, vars); err != nil {
+			return fmt.Errorf("failed to replace template variables: %w", err)
+		}
+	}
+	return nil
+}
+
+// Files returns the relative paths of the files the template would write
+// with the feature groups sel selects, sorted by path
+func (s *Source) Files(sel FeatureSelection) ([]string, error) {
+	return listFiles(s.Dir, s.Manifest, sel)
+}
+
+// fetchInto clones or copies the template at s.URL into dir, recording the
+// cloned ref and commit
+func (s *Source) fetchInto(ctx context.Context, dir, token string) error {
+	rawURL := s.URL
+	if isLocalURL(rawURL) {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return fmt.Errorf("invalid template URL %s: %w", rawURL, err)
+		}
+		path := u.Path
+		if u.Host != "" && u.Host != "localhost" {
+			// Treat file://relative/path as a path relative to the current directory
+			path = filepath.Join(u.Host, u.Path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("template path %s is not accessible: %w", path, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("template path %s is not a directory", path)
+		}
+		skipGit := func(relPath string, isDir bool) bool {
+			return isDir && relPath == ".git"
+		}
+		if err := copyTree(path, dir, skipGit); err != nil {
+			return fmt.Errorf("failed to copy template from %s: %w", path, err)
+		}
+		return nil
+	}
+
+	cloneURL, ref, err := parseRemoteURL(rawURL)
+	if err != nil {
+		return err
+	}
+	commit, err := cloneRepo(ctx, cloneURL, ref, token, dir)
+	if err != nil {
+		return fmt.Errorf("failed to clone template from %s: %w", rawURL, err)
+	}
+	s.Ref, s.Commit = ref, commit
+	return nil
+}
+
+// cloneRepo shallow clones ref (a branch or tag, or the default branch when
+// empty) of a git repository into dir and returns the checked out commit.
+// A ref that looks like a commit hash cannot be shallow cloned, so the
+// repository is cloned in full and the commit checked out instead. See
+// cloneAuth for how the clone is authenticated, with token if it is not
+// empty. It is a variable so tests can avoid the network.
+var cloneRepo = func(ctx context.Context, cloneURL, ref, token, dir string) (string, error) {
+	authArgs, env := cloneAuth(cloneURL, token)
+	if IsCommitRef(ref) {
+		args := append(authArgs, "clone", "--no-checkout", "--quiet", cloneURL, dir)
+		if err := runGit(ctx, "", env, args...); err != nil {
+			return "", err
+		}
+		if err := runGit(ctx, dir, nil, "checkout", "--quiet", "--detach", ref); err != nil {
+			return "", fmt.Errorf("failed to check out %s: %w", ref, err)
+		}
+	} else {
+		args := append(authArgs, "clone", "--depth=1", "--quiet")
+		if ref != "" {
+			args = append(args, "--branch", ref)
+		}
+		if err := runGit(ctx, "", env, append(args, cloneURL, dir)...); err != nil {
+			return "", err
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	output, err := logging.Output(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve cloned commit: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// runGit runs git in dir (the current directory if empty) with env (the
+// CLI's environment if nil), returning its stderr as the error when it
+// fails. An error saying the remote did not authorize the clone wraps
+// ErrAuthentication, and one saying the repository does not exist wraps
+// ErrRepoNotFound.
+func runGit(ctx context.Context, dir string, env []string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stderr = &stderr
+	if err := logging.Run(ctx, cmd); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		switch {
+		case git.IsAuthFailure(msg):
+			return fmt.Errorf("%w: %s", ErrAuthentication, msg)
+		case git.IsNotFound(msg):
+			return fmt.Errorf("%w: %s", ErrRepoNotFound, msg)
+		}
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// commitRefPattern matches abbreviated and full commit hashes
+var commitRefPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// IsCommitRef reports whether ref looks like a commit hash rather than a
+// branch or tag name. The commit a hash names never changes.
+func IsCommitRef(ref string) bool {
+	return commitRefPattern.MatchString(ref)
+}
+
+// IsRemoteURL reports whether rawURL is a template URL that is cloned with
+// git, as opposed to a local file:// directory
+func IsRemoteURL(rawURL string) bool {
+	_, err := gitCloneURL(rawURL)
+	return err == nil
+}
+
+// isLocalURL reports whether rawURL points at a local directory
+func isLocalURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "file://")
+}
+
+// parseRemoteURL splits a template URL into a URL git can clone and the
+// optional ref after "#", e.g. git+https://github.com/org/repo.git#v1.0
+func parseRemoteURL(rawURL string) (string, string, error) {
+	base, ref, _ := strings.Cut(rawURL, "#")
+	cloneURL, err := gitCloneURL(base)
+	if err != nil {
+		return "", "", err
+	}
+	return cloneURL, ref, nil
+}
+
+// gitCloneURL converts a template URL into a URL git can clone
+func gitCloneURL(rawURL string) (string, error) {
+	cloneURL := strings.TrimPrefix(rawURL, "git+")
+	switch {
+	case strings.HasPrefix(cloneURL, "https://"),
+		strings.HasPrefix(cloneURL, "http://"),
+		strings.HasPrefix(cloneURL, "ssh://"),
+		strings.HasPrefix(cloneURL, "git@"):
+		return cloneURL, nil
+	}
+	return "", fmt.Errorf("unsupported template URL: %s (use git+https://, git+ssh:// or file://)", rawURL)
+}
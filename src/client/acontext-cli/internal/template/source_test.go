@@ -0,0 +1,271 @@
+package template
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplateFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestFetchSourceFromLocalPath(t *testing.T) {
+	templateDir := t.TempDir()
+	writeTemplateFiles(t, templateDir, map[string]string{
+		ManifestFile:     "name: org-template\ndescription: Internal template\n",
+		"package.json":   `{"name": "org-template"}`,
+		"src/index.ts":   "console.log('hello')\n",
+		".git/HEAD":      "ref: refs/heads/main\n",
+		".gitignore":     "node_modules/\n",
+		"docs/README.md": "# Docs\n",
+	})
+
+	source, err := FetchSource(context.Background(), "file://"+templateDir)
+	require.NoError(t, err)
+	defer func() {
+		_ = source.Close()
+	}()
+
+	assert.Equal(t, "org-template", source.Manifest.Name)
+
+	files, err := source.Files(FeatureSelection{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".gitignore", "docs/README.md", "package.json", "src/index.ts"}, files)
+
+	destDir := filepath.Join(t.TempDir(), "my-app")
+	require.NoError(t, source.Render(destDir, map[string]string{"project_name": "My App"}, FeatureSelection{}, 1))
+
+	data, err := os.ReadFile(filepath.Join(destDir, "package.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"name": "my-app"`)
+
+	_, err = os.Stat(filepath.Join(destDir, ManifestFile))
+	assert.True(t, os.IsNotExist(err), "manifest should not be copied into the project")
+	_, err = os.Stat(filepath.Join(destDir, ".git"))
+	assert.True(t, os.IsNotExist(err), ".git should not be copied into the project")
+
+	tempDir := source.Dir
+	require.NoError(t, source.Close())
+	_, err = os.Stat(tempDir)
+	assert.True(t, os.IsNotExist(err), "temp dir should be removed on close")
+}
+
+func TestOpenDir(t *testing.T) {
+	templateDir := t.TempDir()
+	writeTemplateFiles(t, templateDir, map[string]string{
+		ManifestFile:       "name: org-template\n",
+		"package.json":     `{"name": "org-template"}`,
+		".git/HEAD":        "ref: refs/heads/main\n",
+		"vendor/.git/HEAD": "ref: refs/heads/main\n",
+	})
+
+	source, err := OpenDir(templateDir)
+	require.NoError(t, err)
+	assert.Equal(t, "org-template", source.Manifest.Name)
+	assert.Equal(t, templateDir, source.Dir, "the template is not copied")
+	assert.Equal(t, "file://"+filepath.ToSlash(templateDir), source.URL)
+
+	destDir := filepath.Join(t.TempDir(), "my-app")
+	require.NoError(t, source.Render(destDir, map[string]string{"project_name": "my-app"}, FeatureSelection{}, 1))
+	files, err := ListFiles(destDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"package.json"}, files)
+
+	require.NoError(t, source.Close())
+	assert.FileExists(t, filepath.Join(templateDir, ManifestFile), "close leaves the directory in place")
+	data, err := os.ReadFile(filepath.Join(templateDir, "package.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"name": "org-template"}`, string(data), "rendering leaves the template untouched")
+}
+
+func TestOpenDirErrors(t *testing.T) {
+	noManifest := t.TempDir()
+	writeTemplateFiles(t, noManifest, map[string]string{"README.md": "# Template\n"})
+	file := filepath.Join(t.TempDir(), "template.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("name: x\n"), 0644))
+
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr string
+	}{
+		{name: "missing", dir: filepath.Join(t.TempDir(), "missing"), wantErr: "is not accessible"},
+		{name: "file", dir: file, wantErr: "is not a directory"},
+		{name: "no manifest", dir: noManifest, wantErr: "template manifest " + ManifestFile + " not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := OpenDir(tt.dir)
+			assert.Nil(t, source)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestFetchSourceMissingManifest(t *testing.T) {
+	templateDir := t.TempDir()
+	writeTemplateFiles(t, templateDir, map[string]string{
+		"README.md": "# Template\n",
+	})
+
+	source, err := FetchSource(context.Background(), "file://"+templateDir)
+	assert.Nil(t, source)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ManifestFile)
+}
+
+func TestFetchSourceUnreachable(t *testing.T) {
+	source, err := FetchSource(context.Background(), "file://"+filepath.Join(t.TempDir(), "missing"))
+	assert.Nil(t, source)
+	assert.Error(t, err)
+}
+
+func TestGitCloneURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "git+https",
+			input:    "git+https://github.com/myorg/acontext-template.git",
+			expected: "https://github.com/myorg/acontext-template.git",
+		},
+		{
+			name:     "plain https",
+			input:    "https://github.com/myorg/acontext-template.git",
+			expected: "https://github.com/myorg/acontext-template.git",
+		},
+		{
+			name:     "git+ssh",
+			input:    "git+ssh://git@github.com/myorg/acontext-template.git",
+			expected: "ssh://git@github.com/myorg/acontext-template.git",
+		},
+		{
+			name:     "scp-like ssh",
+			input:    "git@github.com:myorg/acontext-template.git",
+			expected: "git@github.com:myorg/acontext-template.git",
+		},
+		{
+			name:    "unsupported scheme",
+			input:   "ftp://example.com/template",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := gitCloneURL(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseRemoteURL(t *testing.T) {
+	cloneURL, ref, err := parseRemoteURL("git+https://github.com/myorg/acontext-template.git#v1.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/myorg/acontext-template.git", cloneURL)
+	assert.Equal(t, "v1.2.0", ref)
+
+	cloneURL, ref, err = parseRemoteURL("git+https://github.com/myorg/acontext-template.git")
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/myorg/acontext-template.git", cloneURL)
+	assert.Empty(t, ref)
+}
+
+func TestIsCommitRef(t *testing.T) {
+	for _, ref := range []string{"3f2a9c1", "3f2a9c1d0b7e4a5f6b8c9d0e1f2a3b4c5d6e7f80"} {
+		assert.True(t, IsCommitRef(ref), ref)
+	}
+	for _, ref := range []string{"", "main", "v1.2.0", "3f2a9c", "3F2A9C1", "release-3f2a9c1"} {
+		assert.False(t, IsCommitRef(ref), ref)
+	}
+}
+
+func TestIsRemoteURL(t *testing.T) {
+	assert.True(t, IsRemoteURL("git+https://github.com/myorg/acontext-template.git"))
+	assert.True(t, IsRemoteURL("git+ssh://git@github.com/myorg/acontext-template.git"))
+	assert.False(t, IsRemoteURL("file:///path/to/template"))
+	assert.False(t, IsRemoteURL(""))
+}
+
+// gitRepo creates a repository whose main branch has two commits, the first
+// tagged v1, and returns its path and the commits in order
+func gitRepo(t *testing.T) (string, []string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--quiet", "--initial-branch=main")
+	var commits []string
+	for _, version := range []string{"v1", "v2"} {
+		writeTemplateFiles(t, dir, map[string]string{"VERSION": version + "\n"})
+		git("add", "VERSION")
+		git("commit", "--quiet", "-m", version)
+		commits = append(commits, git("rev-parse", "HEAD"))
+	}
+	git("tag", "v1", commits[0])
+	return dir, commits
+}
+
+func TestCloneRepo(t *testing.T) {
+	repo, commits := gitRepo(t)
+
+	tests := []struct {
+		name    string
+		ref     string
+		version string
+		commit  string
+	}{
+		{name: "default branch", version: "v2", commit: commits[1]},
+		{name: "tag", ref: "v1", version: "v1", commit: commits[0]},
+		{name: "full commit", ref: commits[0], version: "v1", commit: commits[0]},
+		{name: "abbreviated commit", ref: commits[0][:7], version: "v1", commit: commits[0]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "clone")
+			commit, err := cloneRepo(context.Background(), repo, tt.ref, "", dir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.commit, commit)
+			content, err := os.ReadFile(filepath.Join(dir, "VERSION"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.version+"\n", string(content))
+		})
+	}
+
+	_, err := cloneRepo(context.Background(), repo, "0000000", "", filepath.Join(t.TempDir(), "clone"))
+	assert.ErrorContains(t, err, "failed to check out 0000000")
+
+	_, err = cloneRepo(context.Background(), filepath.Join(t.TempDir(), "missing"), "", "", filepath.Join(t.TempDir(), "clone"))
+	assert.ErrorIs(t, err, ErrRepoNotFound)
+}
@@ -0,0 +1,138 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec holds the answers for acontext create read from a YAML spec file, so
+// projects can be generated declaratively
+type Spec struct {
+	Name         string            `yaml:"name"`
+	Template     string            `yaml:"template"`      // Built-in template key, e.g., python.openai
+	TemplatePath string            `yaml:"template_path"` // Template folder in Acontext-Examples
+	TemplateURL  string            `yaml:"template_url"`  // Custom template source
+	Author       string            `yaml:"author"`
+	License      string            `yaml:"license"`
+	GitBranch    string            `yaml:"git_branch"` // Initial Git branch name
+	Install      *bool             `yaml:"install"`    // Whether dependencies are installed after create
+	Variables    map[string]string `yaml:"variables"`  // Template-specific variables
+}
+
+// specKeys are the top-level keys accepted in a spec file
+var specKeys = []string{"name", "template", "template_path", "template_url", "author", "license", "git_branch", "install", "variables"}
+
+// LoadSpec loads a spec file, rejecting unknown top-level keys
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec file: %w", err)
+	}
+
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse spec file %s: %w", path, err)
+	}
+	var unknown []string
+	for key := range raw {
+		if !contains(specKeys, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("spec file %s has unknown keys: %s (expected %s)", path, strings.Join(unknown, ", "), strings.Join(specKeys, ", "))
+	}
+
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec file %s: %w", path, err)
+	}
+
+	if spec.templateSources() > 1 {
+		return nil, fmt.Errorf("spec file %s sets more than one of template, template_path and template_url", path)
+	}
+
+	return &spec, nil
+}
+
+// templateSources counts which of template, template_path and template_url
+// are set
+func (s *Spec) templateSources() int {
+	set := 0
+	for _, value := range []string{s.Template, s.TemplatePath, s.TemplateURL} {
+		if value != "" {
+			set++
+		}
+	}
+	return set
+}
+
+// Validate checks the spec's variables against a template's variables,
+// reporting every missing, unknown and invalid key at once. Variables without
+// a default are required, and values must satisfy the variable constraints;
+// the standard variables are set with top-level keys.
+func (s *Spec) Validate(variables []Variable) error {
+	declared := map[string]bool{}
+	var missing, invalid []string
+	for _, variable := range variables {
+		if isStandardVariable(variable.Name) {
+			continue
+		}
+		declared[variable.Name] = true
+		value, ok := s.Variables[variable.Name]
+		if !ok {
+			if variable.Default == "" {
+				missing = append(missing, variable.Name)
+			}
+			continue
+		}
+		if err := variable.Check(value); err != nil {
+			invalid = append(invalid, fmt.Sprintf("variable %s: %v", variable.Name, err))
+		}
+	}
+
+	var unknown []string
+	for name := range s.Variables {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "missing variables: "+strings.Join(missing, ", "))
+	}
+	if len(unknown) > 0 {
+		problems = append(problems, "unknown variables: "+strings.Join(unknown, ", "))
+	}
+	problems = append(problems, invalid...)
+	if len(problems) > 0 {
+		return fmt.Errorf("spec does not match the template: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// isStandardVariable reports whether name is one of StandardVariables
+func isStandardVariable(name string) bool {
+	for _, variable := range StandardVariables {
+		if variable.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
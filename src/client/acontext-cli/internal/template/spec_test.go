@@ -0,0 +1,119 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSpec(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadSpec(t *testing.T) {
+	path := writeSpec(t, `name: my-app
+template: python.openai
+author: Jane Doe
+license: MIT
+git_branch: trunk
+install: false
+variables:
+  model: gpt-4o
+  port: 8080
+`)
+
+	spec, err := LoadSpec(path)
+	require.NoError(t, err)
+	install := false
+	assert.Equal(t, &Spec{
+		Name:      "my-app",
+		Template:  "python.openai",
+		Author:    "Jane Doe",
+		License:   "MIT",
+		GitBranch: "trunk",
+		Install:   &install,
+		Variables: map[string]string{"model": "gpt-4o", "port": "8080"},
+	}, spec)
+}
+
+func TestLoadSpecInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{
+			name:    "unknown keys",
+			content: "name: my-app\nauthr: Jane\nlicence: MIT\n",
+			errMsg:  "unknown keys: authr, licence",
+		},
+		{
+			name:    "several templates",
+			content: "template: python.openai\ntemplate_url: file:///tmp/template\n",
+			errMsg:  "more than one of template, template_path and template_url",
+		},
+		{
+			name:    "not a mapping",
+			content: "- name\n",
+			errMsg:  "failed to parse spec file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSpec(writeSpec(t, tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestSpecValidate(t *testing.T) {
+	variables := Variables(&Manifest{
+		Name: "custom",
+		Variables: []Variable{
+			{Name: "model", Type: "string"},
+			{Name: "port", Type: "integer", Default: "8000", Min: float(1024)},
+		},
+	})
+
+	tests := []struct {
+		name      string
+		variables map[string]string
+		errMsg    string
+	}{
+		{
+			name:      "required set",
+			variables: map[string]string{"model": "gpt-4o"},
+		},
+		{
+			name:      "missing and unknown",
+			variables: map[string]string{"modle": "gpt-4o", "author": "Jane"},
+			errMsg:    "missing variables: model; unknown variables: author, modle",
+		},
+		{
+			name:      "constraint failure",
+			variables: map[string]string{"model": "gpt-4o", "port": "80"},
+			errMsg:    "variable port: 80 is less than the minimum 1024",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &Spec{Variables: tt.variables}
+			err := spec.Validate(variables)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
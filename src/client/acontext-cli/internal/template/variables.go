@@ -0,0 +1,164 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Variable describes a value a template is rendered with. Manifest variables
+// may constrain their values: every set constraint must hold.
+type Variable struct {
+	Name        string   `yaml:"name" json:"name"`
+	Type        string   `yaml:"type" json:"type"` // string, integer or number
+	Default     string   `yaml:"default,omitempty" json:"default,omitempty"`
+	Description string   `yaml:"description" json:"description"`
+	Required    bool     `yaml:"required,omitempty" json:"required,omitempty"`   // The value must not be empty
+	Pattern     string   `yaml:"pattern,omitempty" json:"pattern,omitempty"`     // Regular expression the whole value must match
+	Enum        []string `yaml:"enum,omitempty" json:"enum,omitempty"`           // Allowed values
+	Min         *float64 `yaml:"min,omitempty" json:"min,omitempty"`             // Smallest allowed number
+	Max         *float64 `yaml:"max,omitempty" json:"max,omitempty"`             // Largest allowed number
+	Sensitive   bool     `yaml:"sensitive,omitempty" json:"sensitive,omitempty"` // The value is a secret, redacted where create records it
+}
+
+// StandardVariables are the variables acontext create passes to every template
+var StandardVariables = []Variable{
+	{Name: "project_name", Type: "string", Description: "Project name, also used as the Python/npm/Cargo package name and the default Go module path"},
+	{Name: "author", Type: "string", Description: "Author name, from --author or the create.author setting"},
+	{Name: "license", Type: "string", Description: "License identifier, from --license"},
+}
+
+// Variables returns every variable a template is rendered with: the standard
+// ones followed by those declared in its manifest, if any
+func Variables(manifest *Manifest) []Variable {
+	variables := append([]Variable{}, StandardVariables...)
+	if manifest != nil {
+		variables = append(variables, manifest.Variables...)
+	}
+	return variables
+}
+
+// Check reports the first constraint value fails. An empty value only fails
+// Required, since unset optional variables render as empty.
+func (v Variable) Check(value string) error {
+	if value == "" {
+		if v.Required {
+			return fmt.Errorf("a value is required")
+		}
+		return nil
+	}
+	if len(v.Enum) > 0 && !contains(v.Enum, value) {
+		return fmt.Errorf("%q is not one of %s", value, strings.Join(v.Enum, ", "))
+	}
+	if v.Pattern != "" {
+		re, err := compilePattern(v.Pattern)
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%q does not match pattern %s", value, v.Pattern)
+		}
+	}
+	if v.isNumeric() {
+		number, err := v.parseNumber(value)
+		if err != nil {
+			return err
+		}
+		if v.Min != nil && number < *v.Min {
+			return fmt.Errorf("%s is less than the minimum %s", value, formatNumber(*v.Min))
+		}
+		if v.Max != nil && number > *v.Max {
+			return fmt.Errorf("%s is greater than the maximum %s", value, formatNumber(*v.Max))
+		}
+	}
+	return nil
+}
+
+// Constraints summarizes the variable's constraints for display, e.g.,
+// "required, 1024..65535"
+func (v Variable) Constraints() string {
+	var parts []string
+	if v.Required {
+		parts = append(parts, "required")
+	}
+	if len(v.Enum) > 0 {
+		parts = append(parts, "one of "+strings.Join(v.Enum, "|"))
+	}
+	if v.Pattern != "" {
+		parts = append(parts, "pattern "+v.Pattern)
+	}
+	switch {
+	case v.Min != nil && v.Max != nil:
+		parts = append(parts, formatNumber(*v.Min)+".."+formatNumber(*v.Max))
+	case v.Min != nil:
+		parts = append(parts, ">= "+formatNumber(*v.Min))
+	case v.Max != nil:
+		parts = append(parts, "<= "+formatNumber(*v.Max))
+	}
+	if v.Sensitive {
+		parts = append(parts, "sensitive")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// validate checks the constraints of a manifest variable are consistent and
+// that its default satisfies them
+func (v Variable) validate() error {
+	switch v.Type {
+	case "", "string", "integer", "number":
+	default:
+		return fmt.Errorf("variable %s has unknown type %q (expected string, integer or number)", v.Name, v.Type)
+	}
+	if v.Type == "string" && (v.Min != nil || v.Max != nil) {
+		return fmt.Errorf("variable %s sets min or max, which need type integer or number", v.Name)
+	}
+	if v.Pattern != "" {
+		if _, err := compilePattern(v.Pattern); err != nil {
+			return fmt.Errorf("variable %s: %w", v.Name, err)
+		}
+	}
+	if v.Min != nil && v.Max != nil && *v.Min > *v.Max {
+		return fmt.Errorf("variable %s has min %s greater than max %s", v.Name, formatNumber(*v.Min), formatNumber(*v.Max))
+	}
+	if v.Default != "" {
+		if err := v.Check(v.Default); err != nil {
+			return fmt.Errorf("variable %s has an invalid default: %w", v.Name, err)
+		}
+	}
+	return nil
+}
+
+// isNumeric reports whether values must be numbers: min and max imply a
+// number when the type is not set
+func (v Variable) isNumeric() bool {
+	return v.Type == "integer" || v.Type == "number" || v.Min != nil || v.Max != nil
+}
+
+func (v Variable) parseNumber(value string) (float64, error) {
+	if v.Type == "integer" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an integer", value)
+		}
+		return float64(n), nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", value)
+	}
+	return n, nil
+}
+
+// compilePattern compiles a variable pattern anchored to the whole value
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+	return re, nil
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
@@ -0,0 +1,159 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func float(n float64) *float64 {
+	return &n
+}
+
+func TestVariableCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		variable Variable
+		value    string
+		errMsg   string
+	}{
+		{
+			name:     "required set",
+			variable: Variable{Name: "model", Required: true},
+			value:    "gpt-4o",
+		},
+		{
+			name:     "required empty",
+			variable: Variable{Name: "model", Required: true},
+			errMsg:   "a value is required",
+		},
+		{
+			name:     "optional empty",
+			variable: Variable{Name: "port", Type: "integer", Min: float(1024)},
+		},
+		{
+			name:     "pattern match",
+			variable: Variable{Name: "slug", Pattern: "[a-z][a-z0-9-]*"},
+			value:    "my-app",
+		},
+		{
+			name:     "pattern must match the whole value",
+			variable: Variable{Name: "slug", Pattern: "[a-z][a-z0-9-]*"},
+			value:    "My app",
+			errMsg:   `"My app" does not match pattern [a-z][a-z0-9-]*`,
+		},
+		{
+			name:     "enum member",
+			variable: Variable{Name: "region", Enum: []string{"us-east-1", "eu-west-1"}},
+			value:    "eu-west-1",
+		},
+		{
+			name:     "enum non-member",
+			variable: Variable{Name: "region", Enum: []string{"us-east-1", "eu-west-1"}},
+			value:    "mars-1",
+			errMsg:   `"mars-1" is not one of us-east-1, eu-west-1`,
+		},
+		{
+			name:     "within range",
+			variable: Variable{Name: "port", Type: "integer", Min: float(1024), Max: float(65535)},
+			value:    "8000",
+		},
+		{
+			name:     "below min",
+			variable: Variable{Name: "port", Type: "integer", Min: float(1024), Max: float(65535)},
+			value:    "80",
+			errMsg:   "80 is less than the minimum 1024",
+		},
+		{
+			name:     "above max",
+			variable: Variable{Name: "port", Type: "integer", Min: float(1024), Max: float(65535)},
+			value:    "70000",
+			errMsg:   "70000 is greater than the maximum 65535",
+		},
+		{
+			name:     "not an integer",
+			variable: Variable{Name: "port", Type: "integer"},
+			value:    "80.5",
+			errMsg:   `"80.5" is not an integer`,
+		},
+		{
+			name:     "min implies a number",
+			variable: Variable{Name: "temperature", Max: float(2)},
+			value:    "hot",
+			errMsg:   `"hot" is not a number`,
+		},
+		{
+			name:     "number",
+			variable: Variable{Name: "temperature", Type: "number", Min: float(0), Max: float(2)},
+			value:    "0.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.variable.Check(tt.value)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestVariableConstraints(t *testing.T) {
+	variable := Variable{Name: "port", Type: "integer", Required: true, Min: float(1024), Max: float(65535)}
+	assert.Equal(t, "required, 1024..65535", variable.Constraints())
+	assert.Equal(t, "one of a|b", Variable{Enum: []string{"a", "b"}}.Constraints())
+	assert.Equal(t, "", Variable{Name: "model"}.Constraints())
+	assert.Equal(t, "required, sensitive", Variable{Name: "api_key", Required: true, Sensitive: true}.Constraints())
+}
+
+func TestLoadManifestInvalidConstraints(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables string
+		errMsg    string
+	}{
+		{
+			name:      "invalid pattern",
+			variables: "  - name: slug\n    pattern: \"[a-z\"\n",
+			errMsg:    "variable slug: invalid pattern [a-z",
+		},
+		{
+			name:      "min greater than max",
+			variables: "  - name: port\n    type: integer\n    min: 10\n    max: 1\n",
+			errMsg:    "variable port has min 10 greater than max 1",
+		},
+		{
+			name:      "bounds on a string",
+			variables: "  - name: model\n    type: string\n    min: 1\n",
+			errMsg:    "variable model sets min or max, which need type integer or number",
+		},
+		{
+			name:      "unknown type",
+			variables: "  - name: debug\n    type: bool\n",
+			errMsg:    `variable debug has unknown type "bool"`,
+		},
+		{
+			name:      "default outside enum",
+			variables: "  - name: region\n    enum: [us-east-1, eu-west-1]\n    default: mars-1\n",
+			errMsg:    `variable region has an invalid default: "mars-1" is not one of us-east-1, eu-west-1`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			manifest := "name: custom\nvariables:\n" + tt.variables
+			require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestFile), []byte(manifest), 0644))
+
+			_, err := LoadManifest(dir)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package tty
+
+import "context"
+
+type noInputKey struct{}
+
+// isStdinTerminal reports whether stdin is a terminal; tests replace it
+var isStdinTerminal = IsStdinTerminal
+
+// WithNoInput returns a copy of ctx in which every prompt is disabled, as
+// with --no-input
+func WithNoInput(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noInputKey{}, true)
+}
+
+// NoInput reports whether ctx disables prompts (--no-input)
+func NoInput(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	noInput, _ := ctx.Value(noInputKey{}).(bool)
+	return noInput
+}
+
+// CanPrompt reports whether the CLI may prompt for input: stdin is a
+// terminal and ctx does not disable prompts. Without prompts, a value that
+// has a default falls back to it, and one that has none is an error.
+func CanPrompt(ctx context.Context) bool {
+	return !NoInput(ctx) && isStdinTerminal()
+}
+
+// NoPromptReason says why CanPrompt is false, e.g., for "a service is
+// required when " + NoPromptReason(ctx)
+func NoPromptReason(ctx context.Context) string {
+	if NoInput(ctx) {
+		return "--no-input is set"
+	}
+	return "stdin is not a terminal"
+}
@@ -0,0 +1,41 @@
+package tty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanPrompt(t *testing.T) {
+	t.Cleanup(func() { isStdinTerminal = IsStdinTerminal })
+
+	tests := []struct {
+		name       string
+		terminal   bool
+		noInput    bool
+		want       bool
+		wantReason string
+	}{
+		{name: "terminal", terminal: true, want: true},
+		{name: "no input", terminal: true, noInput: true, wantReason: "--no-input is set"},
+		{name: "not a terminal", wantReason: "stdin is not a terminal"},
+		{name: "neither", noInput: true, wantReason: "--no-input is set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isStdinTerminal = func() bool { return tt.terminal }
+			ctx := context.Background()
+			if tt.noInput {
+				ctx = WithNoInput(ctx)
+			}
+			assert.Equal(t, tt.noInput, NoInput(ctx))
+			assert.Equal(t, tt.want, CanPrompt(ctx))
+			if !tt.want {
+				assert.Equal(t, tt.wantReason, NoPromptReason(ctx))
+			}
+		})
+	}
+	assert.False(t, NoInput(nil))
+}
@@ -0,0 +1,36 @@
+package tty
+
+import (
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// consoles maps the pipes standing in for console streams to them
+var consoles sync.Map
+
+// SetConsole makes IsTerminal report for f whether console is a terminal,
+// for a pipe f that stands in for console, e.g., one teeing output to a log
+// file
+func SetConsole(f, console *os.File) {
+	consoles.Store(f, console)
+}
+
+// IsTerminal reports whether f is connected to a terminal
+func IsTerminal(f *os.File) bool {
+	if console, ok := consoles.Load(f); ok {
+		f = console.(*os.File)
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// IsStdoutTerminal reports whether stdout is connected to a terminal
+func IsStdoutTerminal() bool {
+	return IsTerminal(os.Stdout)
+}
+
+// IsStdinTerminal reports whether stdin is connected to a terminal
+func IsStdinTerminal() bool {
+	return IsTerminal(os.Stdin)
+}
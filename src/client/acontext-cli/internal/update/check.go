@@ -0,0 +1,138 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/paths"
+)
+
+// CheckInterval is how long the latest release found by Check is reused
+// before GitHub is queried again
+const CheckInterval = 24 * time.Hour
+
+// now is replaced in tests
+var now = time.Now
+
+// CheckResult compares the running version with the latest release
+type CheckResult struct {
+	Current         string    `json:"current"`
+	Latest          string    `json:"latest"`
+	URL             string    `json:"url"`
+	UpdateAvailable bool      `json:"update_available"`
+	CheckedAt       time.Time `json:"checked_at"`
+}
+
+// checkCache is the latest release last found, kept in the cache directory
+type checkCache struct {
+	Latest    string    `json:"latest"`
+	URL       string    `json:"url"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// CheckCachePath returns where the result of the last release check is kept
+func CheckCachePath() (string, error) {
+	cacheDir, err := paths.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "update-check.json"), nil
+}
+
+// Check reports whether a release newer than current is available. The
+// latest release is cached in cachePath for CheckInterval, so repeated checks
+// do not query GitHub every time; an unreadable cache is refreshed.
+func Check(ctx context.Context, current Version, cachePath string) (*CheckResult, error) {
+	cache, ok := loadCheckCache(cachePath)
+	if !ok {
+		release, err := LatestRelease(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cache = &checkCache{Latest: release.Version.String(), URL: release.HTMLURL, CheckedAt: now()}
+		_ = saveCheckCache(cachePath, cache) // Only costs a request next time
+	}
+	return cache.result(current)
+}
+
+// Cached returns the result of the last Check from cachePath without
+// querying GitHub, or nil when no release was cached in the last
+// CheckInterval
+func Cached(current Version, cachePath string) *CheckResult {
+	cache, ok := loadCheckCache(cachePath)
+	if !ok {
+		return nil
+	}
+	result, err := cache.result(current)
+	if err != nil {
+		return nil
+	}
+	return result
+}
+
+// result compares current with the cached latest release
+func (c *checkCache) result(current Version) (*CheckResult, error) {
+	latest, err := ParseVersion(c.Latest)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckResult{
+		Current:         current.String(),
+		Latest:          latest.String(),
+		URL:             c.URL,
+		UpdateAvailable: latest.Compare(current) > 0,
+		CheckedAt:       c.CheckedAt,
+	}, nil
+}
+
+// loadCheckCache returns the cached release check unless it is missing,
+// malformed or older than CheckInterval
+func loadCheckCache(path string) (*checkCache, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var cache checkCache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.Latest == "" {
+		return nil, false
+	}
+	age := now().Sub(cache.CheckedAt)
+	if age < 0 || age > CheckInterval {
+		return nil, false
+	}
+	return &cache, true
+}
+
+func saveCheckCache(path string, cache *checkCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Write atomically so concurrent runs never read a partial result
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".update-check-*.json")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
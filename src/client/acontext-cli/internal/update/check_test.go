@@ -0,0 +1,103 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_ = json.NewEncoder(w).Encode([]Release{
+			{TagName: "cli/v0.3.0", HTMLURL: "https://example.com/cli/v0.3.0"},
+		})
+	}))
+	defer server.Close()
+
+	originalURL, originalNow := releasesURL, now
+	releasesURL = server.URL
+	clock := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() {
+		releasesURL, now = originalURL, originalNow
+	}()
+
+	cachePath := filepath.Join(t.TempDir(), "update-check.json")
+	current, err := ParseVersion("cli/v0.1.0")
+	require.NoError(t, err)
+
+	result, err := Check(context.Background(), current, cachePath)
+	require.NoError(t, err)
+	assert.True(t, result.UpdateAvailable)
+	assert.Equal(t, "v0.1.0", result.Current)
+	assert.Equal(t, "v0.3.0", result.Latest)
+	assert.Equal(t, "https://example.com/cli/v0.3.0", result.URL)
+	assert.Equal(t, 1, requests)
+
+	// Within the interval the cached release is used, also after an upgrade
+	clock = clock.Add(CheckInterval - time.Minute)
+	upgraded, err := ParseVersion("cli/v0.3.0")
+	require.NoError(t, err)
+	result, err = Check(context.Background(), upgraded, cachePath)
+	require.NoError(t, err)
+	assert.False(t, result.UpdateAvailable)
+	assert.Equal(t, 1, requests)
+
+	// Once it expires, GitHub is queried again
+	clock = clock.Add(2 * time.Minute)
+	_, err = Check(context.Background(), current, cachePath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestCheckNetworkFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	original := releasesURL
+	releasesURL = server.URL
+	defer func() {
+		releasesURL = original
+	}()
+
+	current, err := ParseVersion("cli/v0.1.0")
+	require.NoError(t, err)
+	cachePath := filepath.Join(t.TempDir(), "update-check.json")
+	_, err = Check(context.Background(), current, cachePath)
+	require.Error(t, err)
+	assert.NoFileExists(t, cachePath)
+}
+
+func TestCached(t *testing.T) {
+	originalNow := now
+	clock := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() {
+		now = originalNow
+	}()
+
+	cachePath := filepath.Join(t.TempDir(), "update-check.json")
+	current, err := ParseVersion("cli/v0.1.0")
+	require.NoError(t, err)
+	assert.Nil(t, Cached(current, cachePath), "nothing was checked yet")
+
+	require.NoError(t, saveCheckCache(cachePath, &checkCache{Latest: "v0.3.0", URL: "https://example.com/cli/v0.3.0", CheckedAt: clock}))
+	result := Cached(current, cachePath)
+	require.NotNil(t, result)
+	assert.True(t, result.UpdateAvailable)
+	assert.Equal(t, "v0.3.0", result.Latest)
+
+	clock = clock.Add(CheckInterval + time.Minute)
+	assert.Nil(t, Cached(current, cachePath), "an expired check is not reused")
+}
@@ -0,0 +1,294 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	repo            = "memodb-io/Acontext"
+	binaryName      = "acontext-cli"
+	maxAssetSize    = 200 << 20
+	apiTimeout      = 15 * time.Second
+	downloadTimeout = 5 * time.Minute
+)
+
+// releasesURL is the GitHub API endpoint listing releases (overridable in tests)
+var releasesURL = "https://api.github.com/repos/" + repo + "/releases"
+
+// Release is a GitHub release of the CLI
+type Release struct {
+	TagName    string  `json:"tag_name"`
+	HTMLURL    string  `json:"html_url"`
+	Draft      bool    `json:"draft"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []Asset `json:"assets"`
+
+	Version Version `json:"-"`
+}
+
+// Asset is a downloadable file attached to a release
+type Asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// LatestRelease returns the newest stable CLI release
+func LatestRelease(ctx context.Context) (*Release, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, releasesURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "acontext-cli")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query releases: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query releases: unexpected status code: %d", resp.StatusCode)
+	}
+
+	var releases []Release
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to parse releases: %w", err)
+	}
+
+	return latestCLIRelease(releases)
+}
+
+// latestCLIRelease picks the highest stable cli/v* release
+func latestCLIRelease(releases []Release) (*Release, error) {
+	var latest *Release
+	for i := range releases {
+		release := &releases[i]
+		if release.Draft || release.Prerelease || !strings.HasPrefix(release.TagName, TagPrefix) {
+			continue
+		}
+		v, err := ParseVersion(release.TagName)
+		if err != nil || v.Prerelease != "" {
+			continue
+		}
+		release.Version = v
+		if latest == nil || v.Compare(latest.Version) > 0 {
+			latest = release
+		}
+	}
+
+	if latest == nil {
+		return nil, fmt.Errorf("no CLI release found")
+	}
+	return latest, nil
+}
+
+// AssetName returns the release archive name for the current platform
+func AssetName() string {
+	ext := ".tar.gz"
+	if runtime.GOOS == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("%s_%s%s", runtime.GOOS, runtime.GOARCH, ext)
+}
+
+// findAsset returns the asset with the given name
+func (r *Release) findAsset(name string) *Asset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// Install downloads the release archive for the current platform, verifies its
+// checksum and atomically replaces the binary at execPath
+func Install(ctx context.Context, release *Release, execPath string) error {
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
+	defer cancel()
+
+	assetName := AssetName()
+	asset := release.findAsset(assetName)
+	if asset == nil {
+		return fmt.Errorf("release %s has no asset for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+
+	archive, err := download(ctx, asset.BrowserDownloadURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", assetName, err)
+	}
+
+	if err := verifyChecksum(ctx, release, assetName, archive); err != nil {
+		return err
+	}
+
+	binary, err := extractBinary(assetName, archive)
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", assetName, err)
+	}
+
+	return replaceBinary(execPath, binary)
+}
+
+// verifyChecksum verifies archive against the .sha256 or .md5 asset published with it
+func verifyChecksum(ctx context.Context, release *Release, assetName string, archive []byte) error {
+	checksums := []struct {
+		ext string
+		sum func([]byte) string
+	}{
+		{".sha256", func(b []byte) string { s := sha256.Sum256(b); return hex.EncodeToString(s[:]) }},
+		{".md5", func(b []byte) string { s := md5.Sum(b); return hex.EncodeToString(s[:]) }},
+	}
+
+	for _, checksum := range checksums {
+		asset := release.findAsset(assetName + checksum.ext)
+		if asset == nil {
+			continue
+		}
+		data, err := download(ctx, asset.BrowserDownloadURL)
+		if err != nil {
+			return fmt.Errorf("failed to download checksum: %w", err)
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) == 0 {
+			return fmt.Errorf("checksum file %s is empty", asset.Name)
+		}
+		if !strings.EqualFold(fields[0], checksum.sum(archive)) {
+			return fmt.Errorf("checksum mismatch for %s", assetName)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("release %s has no checksum for %s", release.TagName, assetName)
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "acontext-cli")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxAssetSize))
+}
+
+// extractBinary returns the CLI binary from a .tar.gz or .zip release archive
+func extractBinary(assetName string, archive []byte) ([]byte, error) {
+	name := binaryName
+	if strings.HasSuffix(assetName, ".zip") {
+		name += ".exe"
+		reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range reader.File {
+			if filepath.Base(file.Name) != name {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer func() {
+				_ = rc.Close()
+			}()
+			return io.ReadAll(io.LimitReader(rc, maxAssetSize))
+		}
+		return nil, fmt.Errorf("%s not found in archive", name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = gz.Close()
+	}()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == name {
+			return io.ReadAll(io.LimitReader(tr, maxAssetSize))
+		}
+	}
+	return nil, fmt.Errorf("%s not found in archive", name)
+}
+
+// replaceBinary writes binary next to execPath and renames it into place
+func replaceBinary(execPath string, binary []byte) error {
+	dir := filepath.Dir(execPath)
+	tmpFile, err := os.CreateTemp(dir, ".acontext-upgrade-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file in %s: %w", dir, err)
+	}
+	tmpPath := tmpFile.Name()
+	defer func() {
+		_ = os.Remove(tmpPath) // No-op after a successful rename
+	}()
+
+	if _, err := tmpFile.Write(binary); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		return fmt.Errorf("failed to make new binary executable: %w", err)
+	}
+
+	// Windows cannot overwrite a running executable, but it can rename it
+	if runtime.GOOS == "windows" {
+		oldPath := execPath + ".old"
+		_ = os.Remove(oldPath)
+		if err := os.Rename(execPath, oldPath); err != nil {
+			return fmt.Errorf("failed to move current binary: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmpPath, execPath); err != nil {
+		return fmt.Errorf("failed to replace binary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package update
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestCLIRelease(t *testing.T) {
+	releases := []Release{
+		{TagName: "api/v9.0.0"},
+		{TagName: "cli/v0.2.0"},
+		{TagName: "cli/v0.10.0"},
+		{TagName: "cli/v0.11.0", Draft: true},
+		{TagName: "cli/v0.12.0-rc.1"},
+		{TagName: "cli/v0.13.0", Prerelease: true},
+	}
+
+	latest, err := latestCLIRelease(releases)
+	require.NoError(t, err)
+	assert.Equal(t, "cli/v0.10.0", latest.TagName)
+
+	_, err = latestCLIRelease([]Release{{TagName: "core/v1.0.0"}})
+	assert.Error(t, err)
+}
+
+func makeTarGz(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+	_, err := tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestInstall(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("tar.gz assets are not used on windows")
+	}
+
+	newBinary := []byte("#!/bin/sh\necho new\n")
+	archive := makeTarGz(t, binaryName, newBinary)
+	sum := sha256.Sum256(archive)
+	assetName := AssetName()
+
+	checksum := hex.EncodeToString(sum[:])
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + assetName:
+			_, _ = w.Write(archive)
+		case "/" + assetName + ".sha256":
+			_, _ = w.Write([]byte(checksum + "  " + assetName + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	release := &Release{
+		TagName: "cli/v1.0.0",
+		Assets: []Asset{
+			{Name: assetName, BrowserDownloadURL: server.URL + "/" + assetName},
+			{Name: assetName + ".sha256", BrowserDownloadURL: server.URL + "/" + assetName + ".sha256"},
+		},
+	}
+
+	execPath := filepath.Join(t.TempDir(), "acontext")
+	require.NoError(t, os.WriteFile(execPath, []byte("old"), 0755))
+
+	require.NoError(t, Install(context.Background(), release, execPath))
+
+	data, err := os.ReadFile(execPath)
+	require.NoError(t, err)
+	assert.Equal(t, newBinary, data)
+
+	// A bad checksum must leave the current binary untouched
+	checksum = "deadbeef"
+	require.NoError(t, os.WriteFile(execPath, []byte("old"), 0755))
+	err = Install(context.Background(), release, execPath)
+	assert.ErrorContains(t, err, "checksum mismatch")
+	data, err = os.ReadFile(execPath)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("old"), data)
+}
+
+func TestLatestRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]Release{
+			{TagName: "cli/v0.1.0", HTMLURL: "https://example.com/cli/v0.1.0"},
+			{TagName: "cli/v0.3.0", HTMLURL: "https://example.com/cli/v0.3.0"},
+		})
+	}))
+	defer server.Close()
+
+	original := releasesURL
+	releasesURL = server.URL
+	defer func() {
+		releasesURL = original
+	}()
+
+	latest, err := LatestRelease(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "v0.3.0", latest.Version.String())
+	assert.Equal(t, "https://example.com/cli/v0.3.0", latest.HTMLURL)
+}
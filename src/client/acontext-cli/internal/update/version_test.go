@@ -0,0 +1,87 @@
+package update
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Version
+		wantErr  bool
+	}{
+		{
+			name:     "plain",
+			input:    "0.1.2",
+			expected: Version{Major: 0, Minor: 1, Patch: 2},
+		},
+		{
+			name:     "v prefix",
+			input:    "v1.2.3",
+			expected: Version{Major: 1, Minor: 2, Patch: 3},
+		},
+		{
+			name:     "release tag",
+			input:    "cli/v1.2.3",
+			expected: Version{Major: 1, Minor: 2, Patch: 3},
+		},
+		{
+			name:     "prerelease and build metadata",
+			input:    "v1.2.3-rc.1+abc123",
+			expected: Version{Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1"},
+		},
+		{
+			name:    "dev",
+			input:   "dev",
+			wantErr: true,
+		},
+		{
+			name:    "missing patch",
+			input:   "v1.2",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := ParseVersion(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, v)
+			}
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"v1.0.0", "v1.0.0", 0},
+		{"v1.0.0", "v1.0.1", -1},
+		{"v1.2.0", "v1.1.9", 1},
+		{"v2.0.0", "v1.9.9", 1},
+		{"v0.10.0", "v0.9.0", 1},
+		{"v1.0.0-rc.1", "v1.0.0", -1},
+		{"v1.0.0-rc.2", "v1.0.0-rc.10", -1},
+		{"v1.0.0-alpha", "v1.0.0-beta", -1},
+		{"v1.0.0-alpha", "v1.0.0-alpha.1", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			a, err := ParseVersion(tt.a)
+			require.NoError(t, err)
+			b, err := ParseVersion(tt.b)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, a.Compare(b))
+		})
+	}
+}
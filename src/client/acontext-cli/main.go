@@ -2,14 +2,27 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/memodb-io/Acontext/acontext-cli/cmd"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/color"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/config"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/interrupt"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/logging"
 	"github.com/memodb-io/Acontext/acontext-cli/internal/logo"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/output"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/paths"
 	"github.com/memodb-io/Acontext/acontext-cli/internal/telemetry"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/tty"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/update"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -18,36 +31,346 @@ type contextKey string
 
 const startTimeKey contextKey = "start_time"
 
-var version = "dev"
+// Build metadata, set at build time via ldflags
+var (
+	version   = "dev"
+	commit    = "none"
+	buildDate = "unknown"
+)
+
+var (
+	noTelemetry      bool
+	noTelemetryQueue bool
+	outputFormat     string
+	colorMode        string
+	verbosity        int
+	quiet            bool
+	noInput          bool
+	timeout          time.Duration
+	configFile       string
+	workingDir       string
+	logFile          string
+	logAppend        bool
+)
+
+// flagArgs are the raw arguments the CLI's own flags are read from before
+// cobra parses them, none when running a plugin: its arguments are its own
+var flagArgs []string
+
+// transcript tees the output to --log-file, if set
+var transcript *logging.Transcript
+
+// processStart is when main started, before the banner and flag parsing,
+// read with the monotonic clock; the startup overhead is measured from it
+var processStart time.Time
+
+// cancelTimeout releases the --timeout deadline once the command has finished
+var cancelTimeout context.CancelFunc = func() {}
 
 func main() {
-	// Print logo on first run
-	if len(os.Args) > 1 && os.Args[1] != "--help" && os.Args[1] != "-h" {
-		fmt.Println(logo.Logo)
+	processStart = time.Now()
+	output.SetVersion(version)
+	cmd.SetVersion(version)
+
+	// acontext foo runs the acontext-foo plugin when foo is not a built-in
+	// command, without the logo banner
+	args := os.Args[1:]
+	flagArgs = args
+	pluginArgs := cmd.PluginArgs(rootCmd, args)
+	if pluginArgs != nil {
+		args, flagArgs = pluginArgs, nil
+		rootCmd.SetArgs(args)
+	}
+
+	// Tee to --log-file before anything is printed, so the transcript is complete
+	if path := earlyFlagValue(flagArgs, "log-file", ""); path != "" {
+		if err := openTranscript(path, earlyBoolFlag(flagArgs, "log-append")); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --log-file: %v\n", err)
+			os.Exit(clierror.Usage)
+		}
+	}
+
+	// Select JSON mode before anything is printed, so prose goes to stderr
+	if format, err := output.Parse(earlyFlagValue(flagArgs, "output", "o")); err == nil {
+		output.SetFormat(format)
+	}
+
+	// Select the color mode before the logo is printed
+	mode, err := color.Parse(earlyFlagValue(flagArgs, "color", ""))
+	if err != nil {
+		mode = color.Auto
+	}
+	color.SetMode(mode)
+
+	// Point telemetry at the --config file even when cobra fails before parsing flags
+	if path := earlyFlagValue(flagArgs, "config", ""); path != "" {
+		_ = config.SetUserConfigPath(path)
+	}
+
+	if pluginArgs == nil {
+		printBanner(flagArgs)
+	}
+
+	// Ctrl-C cancels the command context so spawned processes are stopped and
+	// partial work is cleaned up, and the telemetry wait is cut short; the
+	// handler stays installed until exit so a second Ctrl-C forces the exit
+	ctx, stopInterrupt := interrupt.Install(context.Background())
+	defer stopInterrupt()
+
+	cmdErr := rootCmd.ExecuteContext(ctx)
+	if cmdErr != nil {
+		executedCmd, cmdArgs, findErr := rootCmd.Find(args)
+		if executedCmd == nil || findErr != nil {
+			executedCmd, cmdArgs = rootCmd, args
+		}
+		if interrupt.Received() {
+			if !errors.Is(cmdErr, interrupt.ErrInterrupted) {
+				cmdErr = fmt.Errorf("interrupted: %w", cmdErr)
+			}
+			cmdErr = clierror.WithCode(clierror.Interrupted, cmdErr)
+		} else {
+			cmdErr = timeoutError(executedCmd, cmdErr)
+		}
+		if output.IsJSON() {
+			if !output.IsReported(cmdErr) {
+				_ = output.PrintError(buildCommandPath(executedCmd), cmdErr)
+			}
+		} else {
+			// Commands whose exit codes are not their own, such as plugin exec, opt
+			// out with SilenceUsage
+			if clierror.Code(cmdErr) == clierror.Usage && !executedCmd.SilenceUsage && !quiet {
+				fmt.Fprintln(os.Stderr, executedCmd.UsageString())
+			}
+			fmt.Fprint(os.Stderr, formatError(cmdErr))
+		}
+		cmdCtx := executedCmd.Context()
+		if cmdCtx == nil {
+			cmdCtx = ctx
+		}
+		trackCommandAndWait(cmdCtx, executedCmd, cmdArgs, cmdErr, false)
+		logTimings(cmdCtx, clierror.Code(cmdErr))
+		cancelTimeout()
+		closeTranscript(clierror.Code(cmdErr))
+		os.Exit(clierror.Code(cmdErr))
+	}
+	cancelTimeout()
+	closeTranscript(0)
+}
+
+// formatError renders err for the terminal, followed by its remediation
+// hint, if any
+func formatError(err error) string {
+	message := fmt.Sprintf("Error: %v\n", err)
+	if hint := clierror.Hint(err); hint != "" {
+		message += "Hint: " + hint + "\n"
+	}
+	return message
+}
+
+// openTranscript starts teeing stdout and stderr, and the output of spawned
+// processes, to the log file at path, appending to it with appendMode
+func openTranscript(path string, appendMode bool) error {
+	t, err := logging.OpenTranscript(path, appendMode)
+	if err != nil {
+		return err
+	}
+	transcript = t
+	// Prompts, colors and the logo still target the console
+	tty.SetConsole(os.Stdout, t.Stdout)
+	tty.SetConsole(os.Stderr, t.Stderr)
+	output.SetStdout(os.Stdout)
+	t.Note("acontext %s", version)
+	return nil
+}
+
+// closeTranscript records the exit code in the --log-file transcript, if
+// any, and stops teeing to it
+func closeTranscript(code int) {
+	if transcript == nil {
+		return
+	}
+	if err := transcript.Close(fmt.Sprintf("exit code %d", code)); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: Failed to write the log file: %v\n", err)
+	}
+	transcript = nil
+}
+
+// commandLine describes how cmd was run for the --log-file transcript, with
+// the values of sensitive flags and positional arguments hashed as they are
+// in telemetry
+func commandLine(cmd *cobra.Command, args []string) string {
+	flags := map[string]string{}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		flags[flag.Name] = flag.Value.String()
+	})
+	parts := []string{cmd.CommandPath()}
+	redacted := telemetry.RedactFlags(flags)
+	names := make([]string, 0, len(redacted))
+	for name := range redacted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, "--"+name+"="+redacted[name])
+	}
+	parts = append(parts, telemetry.RedactArgs(args)...)
+	return strings.Join(parts, " ")
+}
+
+// earlyFlagValue returns the value of a persistent flag from raw args before cobra
+// parses them, supporting --name value, --name=value, -s value and -svalue forms
+func earlyFlagValue(args []string, name, shorthand string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--"+name+"="); ok {
+			return value
+		}
+		if arg == "--"+name || (shorthand != "" && arg == "-"+shorthand) {
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+			return ""
+		}
+		if shorthand != "" && strings.HasPrefix(arg, "-"+shorthand) && !strings.HasPrefix(arg, "--") {
+			return strings.TrimPrefix(strings.TrimPrefix(arg, "-"+shorthand), "=")
+		}
 	}
+	return ""
+}
 
-	if cmdErr := rootCmd.Execute(); cmdErr != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", cmdErr)
-		executedCmd, _, _ := rootCmd.Find(os.Args[1:])
-		if executedCmd == nil {
-			executedCmd = rootCmd
+// earlyBoolFlag reports whether a boolean persistent flag is set in raw args
+// before cobra parses them
+func earlyBoolFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--"+name || arg == "--"+name+"=true" {
+			return true
 		}
-		trackCommandAndWait(executedCmd, os.Args[1:], cmdErr, false)
-		os.Exit(1)
 	}
+	return false
 }
 
-// trackCommandAndWait tracks a command execution asynchronously and waits for completion
-func trackCommandAndWait(cmd *cobra.Command, args []string, err error, success bool) {
-	// Skip telemetry for dev version
+// printBanner prints the logo banner, when logo.Show allows it for args, and
+// under it a notice when the last release check found a newer version
+func printBanner(args []string) {
+	if !logo.Show(args, tty.IsStdoutTerminal(), output.IsJSON()) {
+		return
+	}
+	fmt.Println(color.Cyan(logo.Logo))
+	if notice := updateNotice(args); notice != "" {
+		fmt.Println(notice)
+	}
+	fmt.Println()
+}
+
+// updateNotice returns the notice printed under the banner when the cached
+// result of the last release check, which it never refreshes, found a newer
+// version, or "" when logo.ShowNotice hides it
+func updateNotice(args []string) string {
 	if version == "dev" {
+		return ""
+	}
+	userConfig, err := config.LoadUserConfig()
+	if err != nil {
+		userConfig = &config.UserConfig{}
+	}
+	if !logo.ShowNotice(args, userConfig.GetBool("update.notice", true)) {
+		return ""
+	}
+	current, err := update.ParseVersion(version)
+	if err != nil {
+		return ""
+	}
+	cachePath, err := update.CheckCachePath()
+	if err != nil {
+		return ""
+	}
+	check := update.Cached(current, cachePath)
+	if check == nil || !check.UpdateAvailable {
+		return ""
+	}
+	return fmt.Sprintf("⬆️  A new version is available: %s (current: %s). Run 'acontext upgrade' to install it.", check.Latest, check.Current)
+}
+
+// changeWorkingDir makes dir the working directory, which every command
+// resolves the project directory, compose files and Git repository from
+func changeWorkingDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("--working-dir: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("--working-dir: %s is not a directory", dir)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("--working-dir: %w", err)
+	}
+	return nil
+}
+
+// timeoutError reports err as a timeout when cmd was stopped because its
+// --timeout deadline passed
+func timeoutError(cmd *cobra.Command, err error) error {
+	ctx := cmd.Context()
+	if ctx == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return clierror.WithCode(clierror.Timeout, fmt.Errorf("%s timed out after %s: %w", cmd.CommandPath(), timeout, err))
+}
+
+// trackCommandAndWait tracks a command execution asynchronously and waits for
+// completion, at most until ctx, the command's context, is done: a --timeout
+// deadline or Ctrl-C bounds the wait and cancels the request, leaving the
+// event queued for a later run. The wait is capped by telemetry.FlushTimeout
+// either way.
+func trackCommandAndWait(ctx context.Context, cmd *cobra.Command, args []string, err error, success bool) {
+	// The telemetry commands report on telemetry, they are not part of it
+	if _, skip := cmd.Annotations[telemetry.SkipAnnotation]; skip {
 		return
 	}
 
+	userConfig, cfgErr := config.LoadUserConfig()
+	if cfgErr != nil {
+		userConfig = &config.UserConfig{}
+	}
+
+	// Skip telemetry if disabled, for dev versions unless ACONTEXT_TELEMETRY=1
+	// opts in, or if the user opted out
+	if !telemetry.Decide(version, noTelemetryFlag(flagArgs), userConfig.Get).Enabled {
+		return
+	}
+
+	// Never fall back to the default endpoint when another one is misconfigured
+	telemetryConfig, configErr := telemetry.ResolveConfig(userConfig.Get)
+	if configErr == nil {
+		configErr = telemetry.Configure(telemetryConfig)
+	}
+	if configErr != nil {
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "⚠️  Warning: telemetry not sent: %v\n", configErr)
+		}
+		return
+	}
+
+	if !quiet {
+		showTelemetryNotice(userConfig)
+	}
+
+	// Keep undelivered events on disk so they are sent by a later run
+	if telemetryQueueEnabled(flagArgs) {
+		if queue, err := telemetry.DefaultQueue(); err == nil {
+			telemetry.SetQueue(queue)
+		}
+	}
+
 	// Get start time from context and calculate duration
 	var duration time.Duration
 	if success {
-		startTime, ok := cmd.Context().Value(startTimeKey).(time.Time)
+		startTime, ok := ctx.Value(startTimeKey).(time.Time)
 		if !ok {
 			startTime = time.Now()
 		}
@@ -57,11 +380,27 @@ func trackCommandAndWait(cmd *cobra.Command, args []string, err error, success b
 	// Build command path, collect flags, and filter args
 	commandPath := buildCommandPath(cmd)
 	flags := collectFlags(cmd)
+	flags["exit_code"] = strconv.Itoa(clierror.Code(err))
+	if interrupt.Received() {
+		flags["interrupted"] = "true"
+	}
 	filteredArgs := filterArgs(args)
+	if _, private := cmd.Annotations[telemetry.NoArgsAnnotation]; private {
+		filteredArgs = nil
+	}
+
+	_, longRunning := cmd.Annotations[telemetry.LongRunningAnnotation]
+	ctx, cancel := flushContext(ctx, longRunning)
+	defer cancel()
 
 	// Start async telemetry tracking and wait for completion
-	// This ensures telemetry is sent even for blocking commands
-	wg := telemetry.TrackCommandAsync(
+	// This ensures telemetry is sent even for blocking commands. Only the
+	// first call of the invocation sends an event, so a command that succeeded
+	// is never also reported as failed, or the other way around.
+	telemetryDone := logging.TimingsFromContext(ctx).StartTelemetry()
+	defer telemetryDone()
+	wg := telemetry.TrackCommandOnce(
+		ctx,
 		commandPath,
 		filteredArgs,
 		flags,
@@ -70,31 +409,89 @@ func trackCommandAndWait(cmd *cobra.Command, args []string, err error, success b
 		duration,
 		version,
 	)
+	telemetry.Wait(ctx, wg)
+}
 
-	// Wait for telemetry to complete (with timeout to avoid hanging forever)
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
+// flushContext returns the context telemetry is sent with once the command
+// with ctx finished. It waits briefly so fast commands are not held up by a
+// slow network, and long-running commands wait the full window. It outlives
+// ctx, which is done after --timeout or Ctrl-C, so the failure of exactly
+// those runs is still reported, waiting briefly then.
+func flushContext(ctx context.Context, longRunning bool) (context.Context, context.CancelFunc) {
+	stopped := ctx.Err() != nil
+	return context.WithTimeout(context.WithoutCancel(ctx), telemetry.FlushTimeout(longRunning && !stopped))
+}
+
+// logTimings logs, at verbose level, the exit code of the command and where
+// its time went: in spawned docker/git processes, waiting on telemetry, and
+// in the CLI itself
+func logTimings(ctx context.Context, exitCode int) {
+	b := logging.TimingsFromContext(ctx).Breakdown().Round(time.Millisecond)
+	logging.FromContext(ctx).InfoContext(ctx, "command timing",
+		"exit_code", exitCode,
+		"total", b.Total,
+		"subprocess", b.Subprocess,
+		"telemetry", b.Telemetry,
+		"cli", b.CLI,
+	)
+}
 
-	// Wait up to 5 seconds for telemetry to complete
-	select {
-	case <-done:
-		// Telemetry completed successfully
-	case <-time.After(5 * time.Second):
-		// Timeout - don't block forever
+// noTelemetryFlag reports whether --no-telemetry is set. The raw args are
+// also checked because flags are not parsed when cobra fails early.
+func noTelemetryFlag(args []string) bool {
+	if noTelemetry {
+		return true
 	}
+	for _, arg := range args {
+		if arg == "--no-telemetry" || arg == "--no-telemetry=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// telemetryQueueEnabled reports whether undelivered telemetry is queued, which
+// --no-telemetry-queue disables. The raw args are checked for the same reason
+// as in noTelemetryFlag.
+func telemetryQueueEnabled(args []string) bool {
+	if noTelemetryQueue {
+		return false
+	}
+	for _, arg := range args {
+		if arg == "--no-telemetry-queue" || arg == "--no-telemetry-queue=true" {
+			return false
+		}
+	}
+	return true
 }
 
-// buildCommandPath builds the full command path (e.g., "docker.up", "create")
+// showTelemetryNotice prints the telemetry notice to stderr the first time
+// telemetry is sent and records that it was shown in the user config
+func showTelemetryNotice(userConfig *config.UserConfig) {
+	if userConfig.GetBool("telemetry.notice_shown", false) {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprint(os.Stderr, telemetry.Notice)
+
+	if err := userConfig.Set("telemetry.notice_shown", "true"); err == nil {
+		_ = userConfig.Save()
+	}
+}
+
+// buildCommandPath builds the full command path (e.g., "docker.up", "create").
+// A shortcut such as acontext up reports the path of the command it runs.
 func buildCommandPath(cmd *cobra.Command) string {
+	if path, ok := cmd.Annotations[telemetry.CommandPathAnnotation]; ok {
+		return path
+	}
 	var parts []string
 
 	// Walk up the command tree
 	current := cmd
-	for current != nil && current.Use != "acontext" {
-		parts = append([]string{current.Use}, parts...)
+	for current != nil && current.HasParent() {
+		parts = append([]string{current.Name()}, parts...)
 		current = current.Parent()
 	}
 
@@ -113,6 +510,16 @@ func collectFlags(cmd *cobra.Command) map[string]string {
 		flags[flag.Name] = flag.Value.String()
 	})
 
+	// Include values recorded by the command itself
+	for name, value := range telemetry.RecordedFlags() {
+		flags[name] = value
+	}
+
+	// Include build metadata so adoption can be tracked per build
+	flags["build_commit"] = commit
+	flags["build_date"] = buildDate
+	flags["go_version"] = runtime.Version()
+
 	return flags
 }
 
@@ -133,32 +540,140 @@ var rootCmd = &cobra.Command{
 	Long: `Acontext CLI is a command-line tool for quickly creating Acontext projects.
 	
 It helps you:
-  - Create projects with templates for Python or TypeScript
+  - Create projects with templates for Python, TypeScript or Go
   - Initialize Git repositories
   - Deploy local development environments with Docker
 
 Get started by running: acontext create
+
+Exit codes:
+    0  Success
+    1  Unclassified error
+    2  Invalid flags, arguments or flag combinations
+    3  Missing dependency (e.g., git or docker not installed, Docker daemon not running)
+    4  A docker compose command failed or services are unhealthy
+    5  The command exceeded its --timeout
+    6  acontext.yaml does not conform to its schema (see acontext validate)
+  130  Interrupted by Ctrl-C or SIGTERM (press Ctrl-C twice to quit immediately)
 `,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		// Store start time for telemetry
-		ctx := context.WithValue(cmd.Context(), startTimeKey, time.Now())
+	Args:          unknownCommandArgs,
+	SilenceErrors: true, // Errors are rendered by main (as text or JSON)
+	SilenceUsage:  true, // Printed by main, for usage errors only
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Store start time for telemetry, and time the command from it for -v;
+		// what came before it is the startup overhead
+		start := time.Now()
+		telemetry.RecordStartup(start.Sub(processStart))
+		ctx := context.WithValue(cmd.Context(), startTimeKey, start)
+		ctx = logging.WithTimings(ctx, logging.NewTimings(start))
 		cmd.SetContext(ctx)
+
+		format, err := output.Parse(outputFormat)
+		if err != nil {
+			return clierror.WithCode(clierror.Usage, err)
+		}
+		output.SetFormat(format)
+
+		// Fail early instead of writing state outside a misconfigured ACONTEXT_HOME
+		if err := paths.CheckHome(); err != nil {
+			return err
+		}
+
+		// A missing --config file is empty, but one that cannot be read is an error
+		if configFile != "" {
+			if err := config.SetUserConfigPath(configFile); err != nil {
+				return clierror.WithCode(clierror.Usage, err)
+			}
+			if _, err := config.LoadUserConfig(); err != nil {
+				return clierror.WithCode(clierror.Usage, fmt.Errorf("--config: %w", err))
+			}
+		}
+
+		// Run from --working-dir, after resolving --config against the
+		// directory the CLI was started in
+		if workingDir != "" {
+			if err := changeWorkingDir(workingDir); err != nil {
+				return clierror.WithCode(clierror.Usage, err)
+			}
+		}
+
+		if logAppend && logFile == "" {
+			return clierror.UsageError("--log-append needs --log-file")
+		}
+
+		// Thread the leveled logger through the command context
+		if quiet && verbosity > 0 {
+			return clierror.UsageError("--quiet and --verbose cannot be used together")
+		}
+		level := verbosity
+		if quiet {
+			level = logging.Quiet
+			if transcript != nil {
+				// Still record what --quiet hides from the console
+				muted, err := transcript.Muted()
+				if err != nil {
+					return err
+				}
+				os.Stdout = muted
+			} else if err := output.SetQuiet(); err != nil {
+				return err
+			}
+		}
+		logger := logging.New(os.Stderr, level)
+		if transcript != nil {
+			transcript.Note("%s", commandLine(cmd, args))
+			logger = transcript.Logger(level)
+		}
+		cmd.SetContext(logging.NewContext(cmd.Context(), logger))
+
+		// Subcommands ask the context whether they may prompt
+		if noInput {
+			cmd.SetContext(tty.WithNoInput(cmd.Context()))
+		}
+
+		// Color is decided once stdout has been redirected, as prose goes there
+		mode, err := color.Parse(colorMode)
+		if err != nil {
+			return clierror.WithCode(clierror.Usage, err)
+		}
+		color.SetMode(mode)
+
+		// Apply the --timeout deadline, which also stops spawned docker/git processes
+		if timeout < 0 {
+			return clierror.UsageError("--timeout must not be negative")
+		}
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+			cancelTimeout = cancel
+			cmd.SetContext(ctx)
+		}
+		return nil
 	},
 	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
 		// Track successful command execution
 		// This is called after the command's Run/RunE completes successfully
-		trackCommandAndWait(cmd, args, nil, true)
+		trackCommandAndWait(cmd.Context(), cmd, args, nil, true)
+		logTimings(cmd.Context(), 0)
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println(logo.Logo)
-		fmt.Println()
 		fmt.Println("Welcome to Acontext CLI!")
 		fmt.Println()
 		fmt.Println("Quick Commands:")
 		fmt.Println("  acontext create     Create a new project")
+		fmt.Println("  acontext init       Add Acontext to an existing project")
 		fmt.Println("  acontext docker     Manage Docker services (up/down/status/logs/env)")
-		fmt.Println("  acontext version    Show version information")
+		fmt.Println("  acontext up         Shortcuts for acontext docker up, down, logs and status")
+		fmt.Println("  acontext deploy     Package the project for deployment")
+		fmt.Println("  acontext validate   Check acontext.yaml against its schema")
+		fmt.Println("  acontext config     Manage persistent CLI settings")
+		fmt.Println("  acontext telemetry  Inspect and clear usage telemetry (status/purge)")
+		fmt.Println("  acontext template   Manage the template cache")
+		fmt.Println("  acontext completion Generate shell completion scripts")
+		fmt.Println("  acontext version    Show version information (or acontext --version)")
+		fmt.Println("  acontext upgrade    Upgrade to the latest release")
+		fmt.Println("  acontext doctor     Check your environment for common problems")
+		fmt.Println("  acontext plugin     List external subcommands (acontext-<name> on PATH)")
 		fmt.Println("  acontext help       Show help information")
 		fmt.Println()
 		fmt.Println("Get started: acontext create")
@@ -166,15 +681,221 @@ Get started by running: acontext create
 }
 
 func init() {
+	// The banner is printed before flags are parsed, these are read from the raw args
+	rootCmd.PersistentFlags().Bool("no-logo", false, "Do not print the logo banner")
+	rootCmd.PersistentFlags().Bool(strings.TrimPrefix(logo.NoNoticeFlag, "--"), false, "Do not print the update notice under the logo banner (also the update.notice setting)")
+	rootCmd.PersistentFlags().BoolVar(&noTelemetry, "no-telemetry", false, "Disable anonymous usage telemetry for this invocation")
+	rootCmd.PersistentFlags().BoolVar(&noTelemetryQueue, "no-telemetry-queue", false, "Do not keep undelivered telemetry on disk to send it later")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", string(output.Text), "Output format: text or json")
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", string(color.Auto), "Color output: auto, always or never (auto honors NO_COLOR)")
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Log spawned docker/git commands (repeat for more detail, e.g., -vv)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress all output except errors")
+	rootCmd.PersistentFlags().BoolVar(&noInput, "no-input", false, "Never prompt: use the defaults, and fail when a value without one is not given (e.g., in CI)")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file to use instead of the default one")
+	rootCmd.PersistentFlags().StringVarP(&workingDir, "working-dir", "C", "", "Run as if started in this directory: projects, compose files and Git repositories are looked up from it")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Also write all output, including that of spawned docker/git commands, to this file with timestamps")
+	rootCmd.PersistentFlags().BoolVar(&logAppend, "log-append", false, "Append to --log-file instead of truncating it")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Abort the command after this long, e.g., 10m (0 for no limit)")
+
+	// cobra prints the version for --version before any hook runs, so it
+	// skips the checks and telemetry of PersistentPreRunE and PersistentPostRunE
+	rootCmd.Version = version
+	rootCmd.Flags().BoolP("version", "V", false, "Print the version and exit")
+	cobra.AddTemplateFunc("rootVersion", rootVersion)
+	rootCmd.SetVersionTemplate("{{rootVersion}}")
+
+	versionCmd.Flags().BoolVar(&versionShort, "short", false, "Print only the version number")
+	versionCmd.Flags().BoolVar(&versionCheck, "check", false, "Also check whether a newer release is available")
+
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(cmd.CreateCmd)
+	rootCmd.AddCommand(cmd.InitCmd)
 	rootCmd.AddCommand(cmd.DockerCmd)
+	rootCmd.AddCommand(cmd.DockerShortcuts...)
+	rootCmd.AddCommand(cmd.ConfigCmd)
+	rootCmd.AddCommand(cmd.TelemetryCmd)
+	rootCmd.AddCommand(cmd.CompletionCmd)
+	rootCmd.AddCommand(cmd.UpgradeCmd)
+	rootCmd.AddCommand(cmd.DoctorCmd)
+	rootCmd.AddCommand(cmd.TemplateCmd)
+	rootCmd.AddCommand(cmd.DeployCmd)
+	rootCmd.AddCommand(cmd.ValidateCmd)
+	rootCmd.AddCommand(cmd.PluginCmd)
+
+	// Use the explicit completion command instead of cobra's default one
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	markUsageErrors(rootCmd)
 }
 
+// unknownCommandArgs rejects arguments to the root command, which are
+// misspelled or unknown subcommands
+func unknownCommandArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	message := fmt.Sprintf("unknown command %q for %q", args[0], cmd.CommandPath())
+	if suggestions := cmd.SuggestionsFor(args[0]); len(suggestions) > 0 {
+		message += "\n\nDid you mean this?\n\t" + strings.Join(suggestions, "\n\t")
+	}
+	return errors.New(message)
+}
+
+// markUsageErrors makes flag parsing and argument validation errors of cmd
+// and its subcommands exit with clierror.Usage
+func markUsageErrors(cmd *cobra.Command) {
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return clierror.WithCode(clierror.Usage, err)
+	})
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			return clierror.WithCode(clierror.Usage, validate(cmd, args))
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		markUsageErrors(sub)
+	}
+}
+
+var (
+	versionShort bool
+	versionCheck bool
+)
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version information",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Show the CLI version together with the commit and date it was built from,
+the Go version and the target platform.
+
+Use --short to print only the semantic version, e.g., for scripting.
+
+Use --check to also report whether a newer release is available, with its
+URL. The latest release is cached for 24 hours, and a failed check only
+prints a warning. Development builds are not checked. Run acontext upgrade
+to install the update.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The check runs before JSON is printed, and after the text so a slow
+		// network does not hold the version back
+		var check *update.CheckResult
+		if versionCheck && output.IsJSON() {
+			check = checkForUpdate(cmd.Context())
+		}
+
+		if versionShort {
+			if output.IsJSON() {
+				return output.PrintJSON(versionShortInfo{
+					Envelope:    output.NewEnvelope("version"),
+					UpdateCheck: check,
+				})
+			}
+			fmt.Println(shortVersion())
+			if versionCheck {
+				printUpdateCheck(checkForUpdate(cmd.Context()))
+			}
+			return nil
+		}
+
+		info := versionInfo{
+			Envelope:    output.NewEnvelope("version"),
+			Commit:      commit,
+			BuildDate:   buildDate,
+			GoVersion:   runtime.Version(),
+			OS:          runtime.GOOS,
+			Arch:        runtime.GOARCH,
+			UpdateCheck: check,
+		}
+		if output.IsJSON() {
+			return output.PrintJSON(info)
+		}
+
 		fmt.Printf("Acontext CLI version %s\n", version)
+		fmt.Printf("  commit:     %s\n", info.Commit)
+		fmt.Printf("  built:      %s\n", info.BuildDate)
+		fmt.Printf("  go version: %s\n", info.GoVersion)
+		fmt.Printf("  platform:   %s/%s\n", info.OS, info.Arch)
+		if versionCheck {
+			printUpdateCheck(checkForUpdate(cmd.Context()))
+		}
+		return nil
 	},
 }
+
+// versionInfo is the JSON result of version
+type versionInfo struct {
+	output.Envelope
+	Commit      string              `json:"commit"`
+	BuildDate   string              `json:"build_date"`
+	GoVersion   string              `json:"go_version"`
+	OS          string              `json:"os"`
+	Arch        string              `json:"arch"`
+	UpdateCheck *update.CheckResult `json:"update_check,omitempty"`
+}
+
+// versionShortInfo is the JSON result of version --short
+type versionShortInfo struct {
+	output.Envelope
+	UpdateCheck *update.CheckResult `json:"update_check,omitempty"`
+}
+
+// checkForUpdate compares the running version with the latest release. The
+// check is best effort: it is skipped for development builds, and failures
+// are reported as warnings.
+func checkForUpdate(ctx context.Context) *update.CheckResult {
+	if version == "dev" {
+		fmt.Fprintln(os.Stderr, "ℹ️  Skipping update check: this is a development build")
+		return nil
+	}
+	current, err := update.ParseVersion(version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: could not check for updates: %v\n", err)
+		return nil
+	}
+	cachePath, err := update.CheckCachePath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: could not check for updates: %v\n", err)
+		return nil
+	}
+	check, err := update.Check(ctx, current, cachePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: could not check for updates: %v\n", err)
+		return nil
+	}
+	return check
+}
+
+// printUpdateCheck prints the outcome of version --check
+func printUpdateCheck(check *update.CheckResult) {
+	if check == nil {
+		return
+	}
+	fmt.Println()
+	if !check.UpdateAvailable {
+		fmt.Printf("✓ You are running the latest version (%s)\n", check.Current)
+		return
+	}
+	fmt.Printf("⬆️  A new version is available: %s (current: %s)\n", check.Latest, check.Current)
+	fmt.Printf("   Release notes: %s\n", check.URL)
+	fmt.Println("   Run 'acontext upgrade' to install it.")
+}
+
+// rootVersion renders acontext --version: "acontext version v0.1.0", or the
+// version --short JSON result in JSON mode
+func rootVersion() string {
+	if output.IsJSON() {
+		// The JSON result goes to the original stdout, like every other one
+		_ = output.PrintJSON(versionShortInfo{Envelope: output.NewEnvelope("version")})
+		return ""
+	}
+	return fmt.Sprintf("acontext version %s\n", shortVersion())
+}
+
+// shortVersion returns the semantic version without the release tag prefix,
+// e.g., "v0.1.0" for "cli/v0.1.0", or the raw version for dev builds
+func shortVersion() string {
+	v, err := update.ParseVersion(version)
+	if err != nil {
+		return version
+	}
+	return v.String()
+}
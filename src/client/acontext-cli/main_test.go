@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/clierror"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/telemetry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlushContext(t *testing.T) {
+	t.Setenv(telemetry.TimeoutEnvVar, "")
+	type key struct{}
+	live := context.WithValue(context.Background(), key{}, "value")
+	stopped, cancel := context.WithCancel(live)
+	cancel()
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		longRunning bool
+		want        time.Duration
+	}{
+		{name: "fast command", ctx: live, want: telemetry.DefaultFlushTimeout},
+		{name: "long-running command", ctx: live, longRunning: true, want: telemetry.DefaultSendTimeout},
+		{name: "stopped command", ctx: stopped, want: telemetry.DefaultFlushTimeout},
+		{name: "stopped long-running command", ctx: stopped, longRunning: true, want: telemetry.DefaultFlushTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := flushContext(tt.ctx, tt.longRunning)
+			defer cancel()
+
+			assert.NoError(t, ctx.Err(), "the flush outlives the command context")
+			assert.Equal(t, "value", ctx.Value(key{}))
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(tt.want), deadline, 100*time.Millisecond)
+		})
+	}
+}
+
+// captureStdout returns what fn prints to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stdout")
+	require.NoError(t, err)
+	defer f.Close()
+	stdout := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = stdout }()
+	fn()
+	content, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestVersionShort(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{name: "release build", version: "cli/v0.1.0", want: "v0.1.0\n"},
+		{name: "development build", version: "dev", want: "dev\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := version
+			version = tt.version
+			versionShort = true
+			t.Cleanup(func() { version, versionShort = saved, false })
+
+			out := captureStdout(t, func() {
+				require.NoError(t, versionCmd.RunE(versionCmd, nil))
+			})
+			assert.Equal(t, tt.want, out)
+			assert.Equal(t, "acontext version "+tt.want, rootVersion())
+		})
+	}
+}
+
+func TestEarlyFlagValue(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "separate value", args: []string{"--working-dir", "apps", "up"}, want: "apps"},
+		{name: "equals value", args: []string{"up", "--working-dir=apps"}, want: "apps"},
+		{name: "shorthand", args: []string{"-C", "apps", "up"}, want: "apps"},
+		{name: "attached shorthand", args: []string{"-Capps", "up"}, want: "apps"},
+		{name: "shorthand with equals", args: []string{"-C=apps", "up"}, want: "apps"},
+		{name: "missing value", args: []string{"up", "--working-dir"}, want: ""},
+		{name: "not set", args: []string{"up", "--config", "acontext.yaml"}, want: ""},
+		{name: "after the terminator", args: []string{"exec", "--", "--working-dir", "apps"}, want: ""},
+		{name: "similar flag", args: []string{"--working-directory", "apps"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, earlyFlagValue(tt.args, "working-dir", "C"))
+		})
+	}
+}
+
+func TestEarlyBoolFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{name: "set", args: []string{"--version"}, want: true},
+		{name: "set to true", args: []string{"--version=true"}, want: true},
+		{name: "set to false", args: []string{"--version=false"}, want: false},
+		{name: "not set", args: []string{"version", "--short"}, want: false},
+		{name: "after the terminator", args: []string{"exec", "--", "--version"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, earlyBoolFlag(tt.args, "version"))
+		})
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "without a hint",
+			err:  errors.New("docker is not running"),
+			want: "Error: docker is not running\n",
+		},
+		{
+			name: "with a hint",
+			err:  clierror.WithHint(errors.New("docker is not running"), "start Docker Desktop and retry"),
+			want: "Error: docker is not running\nHint: start Docker Desktop and retry\n",
+		},
+		{
+			name: "with a wrapped hint",
+			err:  clierror.WithCode(clierror.Docker, clierror.WithHint(errors.New("docker is not running"), "start Docker Desktop and retry")),
+			want: "Error: docker is not running\nHint: start Docker Desktop and retry\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatError(tt.err))
+		})
+	}
+}
+
+// parseFlags parses args for the command they run, with the persistent flags
+// of rootCmd, as Execute does
+func parseFlags(t *testing.T, args []string) *cobra.Command {
+	t.Helper()
+	cmd, flags, err := rootCmd.Find(args)
+	require.NoError(t, err)
+	err = cmd.ParseFlags(flags)
+	resetFlags(t, cmd)
+	require.NoError(t, err)
+	return cmd
+}
+
+// resetFlags restores the flags set on cmd once the test ends
+func resetFlags(t *testing.T, cmd *cobra.Command) {
+	t.Helper()
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		t.Cleanup(func() {
+			if slice, ok := flag.Value.(pflag.SliceValue); ok {
+				_ = slice.Replace(nil)
+			} else {
+				_ = flag.Value.Set(flag.DefValue)
+			}
+			flag.Changed = false
+		})
+	})
+}
+
+func TestDockerDownTimeouts(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		timeout     time.Duration
+		stopTimeout string
+	}{
+		{name: "global timeout", args: []string{"docker", "down", "--timeout", "1m"}, timeout: time.Minute, stopTimeout: "0"},
+		{name: "global timeout first", args: []string{"--timeout", "1m", "docker", "down"}, timeout: time.Minute, stopTimeout: "0"},
+		{name: "shortcut", args: []string{"down", "--timeout", "1m"}, timeout: time.Minute, stopTimeout: "0"},
+		{name: "stop timeout", args: []string{"docker", "down", "--stop-timeout", "60"}, stopTimeout: "60"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := parseFlags(t, tt.args)
+			assert.Equal(t, tt.timeout, timeout)
+			assert.Equal(t, tt.stopTimeout, cmd.Flags().Lookup("stop-timeout").Value.String())
+		})
+	}
+}
+
+// executeRoot runs rootCmd with args, as main does, and returns what it
+// printed to stdout
+func executeRoot(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Setenv("ACONTEXT_HOME", t.TempDir())
+	t.Setenv(telemetry.EnvVar, "0")
+	var err error
+	out := captureStdout(t, func() {
+		rootCmd.SetArgs(args)
+		defer rootCmd.SetArgs(nil)
+		var cmd *cobra.Command
+		cmd, err = rootCmd.ExecuteContextC(context.Background())
+		resetFlags(t, cmd)
+		t.Cleanup(func() { cmd.SetContext(nil) })
+	})
+	return out, err
+}
+
+func TestDockerPortWithRootFlags(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	// A fake docker with one running service, which publishes 5432 on 15432
+	bin := t.TempDir()
+	script := `#!/bin/sh
+case "$*" in
+  "compose version --short") echo 2.29.0;;
+  *"ps --all --format json") echo '{"Name":"app-pg-1","Service":"acontext-server-pg","State":"running","Publishers":[{"URL":"0.0.0.0","TargetPort":5432,"PublishedPort":15432,"Protocol":"tcp"}]}';;
+esac
+`
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile(".env", []byte("LLM_SDK=openai\n"), 0600))
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "address", args: []string{"docker", "port", "acontext-server-pg", "5432"}, want: "0.0.0.0:15432\n"},
+		{name: "port only", args: []string{"docker", "port", "acontext-server-pg", "5432", "--port-only"}, want: "15432\n"},
+		{name: "global quiet", args: []string{"-q", "docker", "port", "acontext-server-pg", "5432", "--port-only"}, want: ""},
+		{name: "global quiet after the command", args: []string{"docker", "port", "acontext-server-pg", "5432", "--quiet"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := executeRoot(t, tt.args...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, out)
+		})
+	}
+}
+
+func TestDockerDownVerboseKeepsVolumes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	// A fake docker that logs its arguments, one invocation per line
+	bin := t.TempDir()
+	log := filepath.Join(bin, "docker.log")
+	script := "#!/bin/sh\necho \"$*\" >> " + log + "\ncase \"$*\" in \"compose version --short\") echo 2.29.0;; esac\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile(".env", []byte("LLM_SDK=openai\n"), 0600))
+
+	out, err := executeRoot(t, "docker", "down", "-v")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Keeping the named volumes: -v is --verbose, pass --volumes to also delete them")
+	content, err := os.ReadFile(log)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), " down")
+	assert.NotContains(t, string(content), "--volumes")
+}
+
+func TestLocalFlagsDoNotShadowRootFlags(t *testing.T) {
+	var visit func(c *cobra.Command)
+	visit = func(c *cobra.Command) {
+		c.LocalNonPersistentFlags().VisitAll(func(flag *pflag.Flag) {
+			if root := rootCmd.PersistentFlags().Lookup(flag.Name); root != nil {
+				assert.Failf(t, "flag shadows a global flag", "%s --%s hides the global --%s", c.CommandPath(), flag.Name, root.Name)
+			}
+			if flag.Shorthand != "" {
+				if root := rootCmd.PersistentFlags().ShorthandLookup(flag.Shorthand); root != nil {
+					assert.Failf(t, "flag shadows a global flag", "%s -%s hides the global --%s", c.CommandPath(), flag.Shorthand, root.Name)
+				}
+			}
+		})
+		for _, child := range c.Commands() {
+			visit(child)
+		}
+	}
+	visit(rootCmd)
+}
@@ -0,0 +1,170 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// maxDiffCells bounds the line pairs compared to find a minimal diff; larger
+// files are shown as replaced as a whole
+const maxDiffCells = 4_000_000
+
+// diffOp is one line of a diff: kept (' '), removed ('-') or added ('+')
+type diffOp struct {
+	kind byte
+	line string // With its trailing newline, if any
+}
+
+// unifiedDiff returns the unified diff from old, labeled from, to new,
+// labeled to, or "" if they are equal. Binary files are only reported as
+// differing.
+func unifiedDiff(from, to string, old, new []byte) string {
+	if bytes.Equal(old, new) {
+		return ""
+	}
+	if isBinary(old) || isBinary(new) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", from, to)
+	}
+
+	ops := diffLines(splitLines(old), splitLines(new))
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", from, to)
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+		// Extend the hunk over changes separated by few enough kept lines
+		start := max(k-diffContext, 0)
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			kept := end
+			for kept < len(ops) && ops[kept].kind == ' ' {
+				kept++
+			}
+			if kept == len(ops) || kept-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+			end = kept
+		}
+		writeHunk(&out, ops, start, end)
+		k = end
+	}
+	return out.String()
+}
+
+// writeHunk writes ops[start:end] as a hunk, with the line ranges it covers
+func writeHunk(out *strings.Builder, ops []diffOp, start, end int) {
+	oldStart, newStart := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			oldStart++
+		}
+		if op.kind != '-' {
+			newStart++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	// An empty range starts at the line before it
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+	for _, op := range ops[start:end] {
+		out.WriteByte(op.kind)
+		out.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats a hunk line range, leaving out a count of 1
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// diffLines returns the edits turning a into b, keeping their longest common
+// subsequence of lines
+func diffLines(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && common[i+1][j] >= common[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// splitLines splits data into lines, each with its trailing newline
+func splitLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// isBinary reports whether data looks like the content of a binary file
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
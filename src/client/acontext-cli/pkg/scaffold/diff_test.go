@@ -0,0 +1,71 @@
+package scaffold
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+		},
+		{
+			name:     "changed line",
+			old:      "a\nb\nc\n",
+			new:      "a\nB\nc\n",
+			expected: "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "added file",
+			new:      "a\n",
+			expected: "--- a/f\n+++ b/f\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:     "missing newline",
+			old:      "a",
+			new:      "a\n",
+			expected: "--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n",
+		},
+		{
+			name:     "distant changes in two hunks",
+			old:      "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			new:      "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			expected: "--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			name:     "close changes in one hunk",
+			old:      "1\n2\n3\n4\n5\n",
+			new:      "one\n2\n3\n4\nfive\n",
+			expected: "--- a/f\n+++ b/f\n@@ -1,5 +1,5 @@\n-1\n+one\n 2\n 3\n 4\n-5\n+five\n",
+		},
+		{
+			name:     "binary",
+			old:      "a\x00",
+			new:      "b\x00",
+			expected: "Binary files a/f and b/f differ\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, unifiedDiff("a/f", "b/f", []byte(tt.old), []byte(tt.new)))
+		})
+	}
+}
+
+func TestUnifiedDiffLargeFile(t *testing.T) {
+	old := strings.Repeat("line\n", 3000)
+	diff := unifiedDiff("a/f", "b/f", []byte(old), []byte(old+"more\n"))
+	// Too large to compare line by line, so shown as replaced
+	assert.Contains(t, diff, "@@ -1,3000 +1,3001 @@\n")
+}
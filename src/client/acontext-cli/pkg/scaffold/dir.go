@@ -0,0 +1,214 @@
+package scaffold
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/template"
+)
+
+// BackupDir is the directory inside a project that holds the files
+// overwritten by a forced scaffold
+const BackupDir = template.BackupDir
+
+// maxListedConflicts is the number of existing entries listed when refusing
+// to scaffold into a non-empty directory
+const maxListedConflicts = 10
+
+// systemDirs are directories a project must never be created in or under
+var systemDirs = []string{
+	"/bin", "/boot", "/dev", "/etc", "/lib", "/lib32", "/lib64", "/proc",
+	"/sbin", "/sys", "/usr", "/System", "/private/etc",
+}
+
+// NotEmptyError is returned when scaffolding into a non-empty directory
+// without Force
+type NotEmptyError struct {
+	Dir     string
+	Entries []string // Existing entries, directories with a trailing "/"
+}
+
+func (e *NotEmptyError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "directory %s already exists and is not empty:", filepath.Base(e.Dir))
+	for i, entry := range e.Entries {
+		if i == maxListedConflicts {
+			fmt.Fprintf(&b, "\n  ... and %d more", len(e.Entries)-maxListedConflicts)
+			break
+		}
+		fmt.Fprintf(&b, "\n  - %s", entry)
+	}
+	return b.String()
+}
+
+// ValidateDir rejects project directories that are a filesystem root or
+// inside a system directory. dir must be absolute.
+func ValidateDir(dir string) error {
+	candidates := []string{filepath.Clean(dir)}
+	if resolved, err := resolveExistingPrefix(dir); err == nil && resolved != candidates[0] {
+		candidates = append(candidates, resolved)
+	}
+
+	for _, candidate := range candidates {
+		if candidate == filepath.VolumeName(candidate)+string(filepath.Separator) {
+			return fmt.Errorf("refusing to create a project in the filesystem root %s", candidate)
+		}
+		for _, systemDir := range platformSystemDirs() {
+			if isWithinDir(candidate, systemDir) {
+				return fmt.Errorf("refusing to create a project in system directory %s", systemDir)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckDir fails when dir already exists and is not a directory, or is a
+// non-empty directory and force is not set, with a *NotEmptyError
+func CheckDir(dir string, force bool) error {
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check directory %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s already exists and is not a directory", filepath.Base(dir))
+	}
+	if force {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	notEmpty := &NotEmptyError{Dir: dir}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		notEmpty.Entries = append(notEmpty.Entries, name)
+	}
+	return notEmpty
+}
+
+// writeProject creates projectDir and writes the template into it with
+// render. When projectDir already has files and force is set, the template is
+// rendered into a staging directory and overlaid on top of the existing files,
+// backing up every overwritten file. It returns the overwritten files. When
+// rendering fails or ctx is cancelled meanwhile, nothing is kept: a directory
+// it created is removed, unless keepPartial is set, and existing files are
+// left untouched.
+func writeProject(ctx context.Context, projectDir string, force, keepPartial bool, render func(dir string) error) ([]string, error) {
+	entries, err := os.ReadDir(projectDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read project directory: %w", err)
+	}
+	created := os.IsNotExist(err)
+
+	if len(entries) == 0 {
+		if err := os.MkdirAll(projectDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create project directory: %w", err)
+		}
+		if err := renderUncancelled(ctx, render, projectDir); err != nil {
+			if created && !keepPartial {
+				_ = os.RemoveAll(projectDir)
+			}
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	if !force {
+		return nil, fmt.Errorf("directory %s already exists and is not empty", filepath.Base(projectDir))
+	}
+
+	stagingDir, err := os.MkdirTemp("", "acontext-create-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(stagingDir)
+	}()
+
+	if err := renderUncancelled(ctx, render, stagingDir); err != nil {
+		return nil, err
+	}
+
+	backupDir := filepath.Join(projectDir, BackupDir, time.Now().Format("20060102-150405"))
+	overwritten, err := template.Overlay(stagingDir, projectDir, backupDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write template files: %w", err)
+	}
+	return overwritten, nil
+}
+
+// renderUncancelled runs render into dir and fails if ctx was cancelled
+// meanwhile, since a render racing an interrupt may be incomplete
+func renderUncancelled(ctx context.Context, render func(dir string) error, dir string) error {
+	if err := render(dir); err != nil {
+		return err
+	}
+	if cause := context.Cause(ctx); cause != nil {
+		return fmt.Errorf("project was not created: %w", cause)
+	}
+	return nil
+}
+
+// platformSystemDirs returns the system directories for the current OS
+func platformSystemDirs() []string {
+	if runtime.GOOS != "windows" {
+		return systemDirs
+	}
+	var dirs []string
+	for _, env := range []string{"SystemRoot", "ProgramFiles", "ProgramFiles(x86)", "ProgramData"} {
+		if dir := os.Getenv(env); dir != "" {
+			dirs = append(dirs, filepath.Clean(dir))
+		}
+	}
+	return dirs
+}
+
+// resolveExistingPrefix resolves symlinks in the longest existing prefix of
+// dir, so a path through a symlink is checked where it really points
+func resolveExistingPrefix(dir string) (string, error) {
+	existing := dir
+	var rest []string
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return dir, nil
+		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{resolved}, rest...)...), nil
+}
+
+// isWithinDir reports whether path is dir or inside it
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,171 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/interrupt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses Unix system directories")
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr string
+	}{
+		{name: "temp dir", dir: filepath.Join(t.TempDir(), "services", "my-app")},
+		{name: "root", dir: "/", wantErr: "filesystem root"},
+		{name: "etc", dir: "/etc/my-app", wantErr: "system directory /etc"},
+		{name: "usr", dir: "/usr/local/src/my-app", wantErr: "system directory /usr"},
+		{name: "similar prefix", dir: "/usrdata/my-app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDir(tt.dir)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDirFollowsSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses Unix system directories")
+	}
+
+	link := filepath.Join(t.TempDir(), "etc-link")
+	require.NoError(t, os.Symlink("/etc", link))
+
+	assert.ErrorContains(t, ValidateDir(filepath.Join(link, "my-app")), "system directory")
+}
+
+func TestWriteProject(t *testing.T) {
+	render := func(dir string) error {
+		writeFile(t, filepath.Join(dir, "README.md"), "new readme")
+		writeFile(t, filepath.Join(dir, "src", "main.py"), "new main")
+		return nil
+	}
+
+	t.Run("missing directory", func(t *testing.T) {
+		projectDir := filepath.Join(t.TempDir(), "app")
+
+		overwritten, err := writeProject(context.Background(), projectDir, false, false, render)
+		require.NoError(t, err)
+		assert.Empty(t, overwritten)
+		assert.FileExists(t, filepath.Join(projectDir, "src", "main.py"))
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		projectDir := t.TempDir()
+
+		overwritten, err := writeProject(context.Background(), projectDir, false, false, render)
+		require.NoError(t, err)
+		assert.Empty(t, overwritten)
+		assert.FileExists(t, filepath.Join(projectDir, "README.md"))
+		assert.NoDirExists(t, filepath.Join(projectDir, ".acontext-backup"))
+	})
+
+	t.Run("non-empty directory without force", func(t *testing.T) {
+		projectDir := t.TempDir()
+		writeFile(t, filepath.Join(projectDir, "README.md"), "old readme")
+
+		_, err := writeProject(context.Background(), projectDir, false, false, render)
+		assert.ErrorContains(t, err, "not empty")
+		assertFileContent(t, filepath.Join(projectDir, "README.md"), "old readme")
+		assert.NoFileExists(t, filepath.Join(projectDir, "src", "main.py"))
+	})
+
+	t.Run("non-empty directory with force", func(t *testing.T) {
+		projectDir := t.TempDir()
+		writeFile(t, filepath.Join(projectDir, "README.md"), "old readme")
+		writeFile(t, filepath.Join(projectDir, "notes.txt"), "keep me")
+
+		overwritten, err := writeProject(context.Background(), projectDir, true, false, render)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"README.md"}, overwritten)
+		assertFileContent(t, filepath.Join(projectDir, "README.md"), "new readme")
+		assertFileContent(t, filepath.Join(projectDir, "notes.txt"), "keep me")
+		assertFileContent(t, filepath.Join(projectDir, "src", "main.py"), "new main")
+
+		backups, err := filepath.Glob(filepath.Join(projectDir, ".acontext-backup", "*", "README.md"))
+		require.NoError(t, err)
+		require.Len(t, backups, 1)
+		assertFileContent(t, backups[0], "old readme")
+	})
+
+	t.Run("render failure removes created directory", func(t *testing.T) {
+		projectDir := filepath.Join(t.TempDir(), "app")
+
+		_, err := writeProject(context.Background(), projectDir, false, false, func(string) error {
+			return errors.New("boom")
+		})
+		assert.EqualError(t, err, "boom")
+		assert.NoDirExists(t, projectDir)
+	})
+
+	t.Run("render failure keeps partial directory", func(t *testing.T) {
+		projectDir := filepath.Join(t.TempDir(), "app")
+
+		_, err := writeProject(context.Background(), projectDir, false, true, func(dir string) error {
+			writeFile(t, filepath.Join(dir, "README.md"), "new readme")
+			return errors.New("boom")
+		})
+		assert.EqualError(t, err, "boom")
+		assertFileContent(t, filepath.Join(projectDir, "README.md"), "new readme")
+	})
+
+	t.Run("interrupt removes created directory", func(t *testing.T) {
+		projectDir := filepath.Join(t.TempDir(), "app")
+		ctx, cancel := context.WithCancelCause(context.Background())
+
+		_, err := writeProject(ctx, projectDir, false, false, func(dir string) error {
+			writeFile(t, filepath.Join(dir, "README.md"), "new readme")
+			cancel(interrupt.ErrInterrupted)
+			return nil
+		})
+		assert.ErrorIs(t, err, interrupt.ErrInterrupted)
+		assert.NoDirExists(t, projectDir)
+	})
+
+	t.Run("interrupt leaves existing files untouched", func(t *testing.T) {
+		projectDir := t.TempDir()
+		writeFile(t, filepath.Join(projectDir, "README.md"), "old readme")
+		ctx, cancel := context.WithCancelCause(context.Background())
+
+		_, err := writeProject(ctx, projectDir, true, false, func(dir string) error {
+			writeFile(t, filepath.Join(dir, "README.md"), "new readme")
+			cancel(interrupt.ErrInterrupted)
+			return nil
+		})
+		assert.ErrorIs(t, err, interrupt.ErrInterrupted)
+		assertFileContent(t, filepath.Join(projectDir, "README.md"), "old readme")
+		assert.NoDirExists(t, filepath.Join(projectDir, ".acontext-backup"))
+	})
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func assertFileContent(t *testing.T, path, want string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(content))
+}
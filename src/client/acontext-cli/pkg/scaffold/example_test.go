@@ -0,0 +1,70 @@
+package scaffold_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/memodb-io/Acontext/acontext-cli/pkg/scaffold"
+)
+
+func Example() {
+	// A local template; git+https:// and git+ssh:// URLs work the same way
+	templateDir, err := os.MkdirTemp("", "template-*")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(templateDir)
+	}()
+	files := map[string]string{
+		"acontext.template.yaml": "name: starter\nvariables:\n  - name: model\n    default: gpt-4o-mini\n",
+		"package.json":           `{"name": "starter"}`,
+		"src/index.ts":           "console.log('hello')\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	tmpl, err := scaffold.OpenTemplate(ctx, scaffold.TemplateRef{URL: "file://" + templateDir})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		_ = tmpl.Close()
+	}()
+
+	projectsDir, err := os.MkdirTemp("", "projects-*")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(projectsDir)
+	}()
+
+	result, err := scaffold.Scaffold(ctx, scaffold.Options{
+		Name:     "my-agent",
+		Dir:      filepath.Join(projectsDir, "my-agent"),
+		Template: tmpl,
+		Vars:     map[string]string{"model": "gpt-4o"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, file := range result.Files {
+		fmt.Println(file)
+	}
+	// Output:
+	// acontext.yaml
+	// package.json
+	// src/index.ts
+}
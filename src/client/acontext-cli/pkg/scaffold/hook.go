@@ -0,0 +1,107 @@
+package scaffold
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/install"
+)
+
+// HookEnvPrefix prefixes the environment variables that pass the template
+// variables to the post-create script, e.g., ACONTEXT_VAR_PROJECT_NAME
+const HookEnvPrefix = "ACONTEXT_VAR_"
+
+// ProjectDirEnv is the environment variable that passes the absolute project
+// directory to the post-create script
+const ProjectDirEnv = "ACONTEXT_PROJECT_DIR"
+
+// HookError is returned when the post-create script fails
+type HookError struct {
+	Script string // Absolute path of the script
+	Dir    string // Project directory
+	Kept   bool   // The project was kept, as it was written into a non-empty directory or Options.KeepPartial is set
+	Err    error
+}
+
+func (e *HookError) Error() string {
+	if e.Kept {
+		return fmt.Sprintf("post-create script: %v (the project was kept in %s)", e.Err, e.Dir)
+	}
+	return fmt.Sprintf("post-create script: %v (the project was removed)", e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// HookEnvName returns the environment variable that passes the template
+// variable name to the post-create script: name upper-cased, with every
+// character other than a letter, digit or underscore replaced by an
+// underscore, after HookEnvPrefix
+func HookEnvName(name string) string {
+	mapped := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+	return HookEnvPrefix + mapped
+}
+
+// hookEnv returns the environment added for the post-create script run in
+// dir, sorted by name
+func hookEnv(dir string, vars map[string]string) []string {
+	env := make([]string, 0, len(vars)+1)
+	for name, value := range vars {
+		env = append(env, HookEnvName(name)+"="+value)
+	}
+	sort.Strings(env)
+	return append(env, ProjectDirEnv+"="+dir)
+}
+
+// postCreateScript returns the absolute path of the script to run in the
+// project in dir: opts.PostCreate, relative to the working directory, or the
+// one the template declares, relative to the project root. It returns ""
+// when there is none.
+func postCreateScript(opts Options, dir string) (string, error) {
+	if opts.PostCreate != "" {
+		script, err := filepath.Abs(opts.PostCreate)
+		if err != nil {
+			return "", fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		return script, nil
+	}
+	if opts.Template.PostCreate != "" {
+		return filepath.Join(dir, filepath.FromSlash(opts.Template.PostCreate)), nil
+	}
+	return "", nil
+}
+
+// runPostCreate runs script in dir, streaming its output, with vars in its
+// environment. Scripts that are not executable are run with sh, except on
+// Windows.
+func runPostCreate(ctx context.Context, dir, script string, vars map[string]string) error {
+	info, err := os.Stat(script)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("post-create script %s not found", script)
+		}
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("post-create script %s is a directory", script)
+	}
+	command := install.Command{Args: []string{script}, Env: hookEnv(dir, vars)}
+	if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+		command.Args = []string{"sh", script}
+	}
+	return install.Run(ctx, dir, command)
+}
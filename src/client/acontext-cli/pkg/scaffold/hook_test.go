@@ -0,0 +1,102 @@
+package scaffold
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hookTemplate declares a post-create script that records its environment,
+// then exits with $HOOK_EXIT
+var hookTemplate = map[string]string{
+	"acontext.template.yaml": "name: hooked\npost_create: scripts/setup.sh\nvariables:\n  - name: api-key\n    default: sk-test\n",
+	"README.md":              "# hooked\n",
+	"scripts/setup.sh":       "echo \"$ACONTEXT_VAR_PROJECT_NAME $ACONTEXT_VAR_API_KEY $ACONTEXT_PROJECT_DIR\" > hook.txt\nexit ${HOOK_EXIT:-0}\n",
+}
+
+func TestHookEnvName(t *testing.T) {
+	assert.Equal(t, "ACONTEXT_VAR_PROJECT_NAME", HookEnvName("project_name"))
+	assert.Equal(t, "ACONTEXT_VAR_API_KEY", HookEnvName("api-key"))
+	assert.Equal(t, "ACONTEXT_VAR_DB_URL_2", HookEnvName("db.url 2"))
+}
+
+func TestScaffoldPostCreate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+
+	result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: openFilesTemplate(t, hookTemplate)})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(projectDir, "scripts", "setup.sh"), result.PostCreate)
+	assertFileContent(t, filepath.Join(projectDir, "hook.txt"), "my-agent sk-test "+projectDir+"\n")
+	assert.Contains(t, result.Files, "hook.txt", "files the script writes are part of the project")
+}
+
+func TestScaffoldPostCreateFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	t.Setenv("HOOK_EXIT", "3")
+
+	t.Run("removes the project", func(t *testing.T) {
+		projectDir := filepath.Join(t.TempDir(), "my-agent")
+		result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: openFilesTemplate(t, hookTemplate)})
+		var hookErr *HookError
+		require.ErrorAs(t, err, &hookErr)
+		assert.False(t, hookErr.Kept)
+		assert.Nil(t, result)
+		assert.NoDirExists(t, projectDir)
+	})
+
+	t.Run("keeps a non-empty directory", func(t *testing.T) {
+		projectDir := t.TempDir()
+		writeFile(t, filepath.Join(projectDir, "notes.txt"), "mine\n")
+		_, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: openFilesTemplate(t, hookTemplate), Force: true})
+		var hookErr *HookError
+		require.ErrorAs(t, err, &hookErr)
+		assert.True(t, hookErr.Kept)
+		assertFileContent(t, filepath.Join(projectDir, "notes.txt"), "mine\n")
+	})
+
+	t.Run("keeps a partial project", func(t *testing.T) {
+		projectDir := filepath.Join(t.TempDir(), "my-agent")
+		result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: openFilesTemplate(t, hookTemplate), KeepPartial: true})
+		var hookErr *HookError
+		require.ErrorAs(t, err, &hookErr)
+		assert.True(t, hookErr.Kept)
+		assert.ErrorContains(t, err, "exit status 3 (the project was kept in "+projectDir+")")
+		require.NotNil(t, result)
+		assert.FileExists(t, filepath.Join(projectDir, "hook.txt"))
+	})
+
+	t.Run("ignored", func(t *testing.T) {
+		projectDir := filepath.Join(t.TempDir(), "my-agent")
+		result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: openFilesTemplate(t, hookTemplate), IgnoreHookErrors: true})
+		require.NoError(t, err)
+		assert.ErrorContains(t, result.HookErr, "exit status 3")
+		assert.FileExists(t, filepath.Join(projectDir, "hook.txt"))
+	})
+}
+
+func TestScaffoldPostCreateOverride(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	script := filepath.Join(t.TempDir(), "custom.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\ntouch custom.txt\n"), 0755))
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+
+	_, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: openFilesTemplate(t, hookTemplate), PostCreate: script})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(projectDir, "custom.txt"))
+	assert.NoFileExists(t, filepath.Join(projectDir, "hook.txt"), "the given script replaces the template's")
+
+	_, err = Scaffold(context.Background(), Options{Name: "other", Dir: filepath.Join(t.TempDir(), "other"), Template: openFilesTemplate(t, hookTemplate), PostCreate: script + ".missing"})
+	assert.ErrorContains(t, err, "post-create script")
+}
@@ -0,0 +1,115 @@
+package scaffold
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxNameLength is the longest name accepted by npm
+const maxNameLength = 214
+
+// windowsReservedNames cannot be used as file or directory names on Windows
+var windowsReservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true,
+	"com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true,
+	"lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// NameError describes why a project name was rejected
+type NameError struct {
+	Name       string
+	Rules      []string // Rules the name violates
+	Suggestion string   // Sanitized alternative, empty if none could be derived
+}
+
+func (e *NameError) Error() string {
+	msg := fmt.Sprintf("invalid project name %q: %s", e.Name, strings.Join(e.Rules, "; "))
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(" (try %q)", e.Suggestion)
+	}
+	return msg
+}
+
+// ValidateName checks that name is usable as a directory name and as both a
+// Python and npm package name. It returns a *NameError otherwise.
+func ValidateName(name string) error {
+	if name == "" {
+		return &NameError{Name: name, Rules: []string{"project name cannot be empty"}}
+	}
+
+	if name == "." || name == ".." {
+		return &NameError{Name: name, Rules: []string{fmt.Sprintf("'%s' is a reserved name and cannot be used", name)}}
+	}
+
+	var rules []string
+	if strings.ContainsAny(name, `/\`) {
+		rules = append(rules, "project name cannot contain path separators")
+	}
+	if len(name) > maxNameLength {
+		rules = append(rules, fmt.Sprintf("project name must be at most %d characters", maxNameLength))
+	}
+	if strings.ToLower(name) != name {
+		rules = append(rules, "project name must be lowercase")
+	}
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || strings.HasPrefix(name, "-") {
+		rules = append(rules, "project name cannot start with '.', '_' or '-'")
+	}
+	for _, r := range name {
+		if !isNameChar(unicode.ToLower(r)) && r != '/' && r != '\\' {
+			rules = append(rules, "project name may only contain lowercase letters, digits, '-' and '_'")
+			break
+		}
+	}
+	if isWindowsReservedName(name) {
+		rules = append(rules, fmt.Sprintf("'%s' is a reserved name on Windows", name))
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+	suggestion := SanitizeName(name)
+	if ValidateName(suggestion) != nil {
+		suggestion = ""
+	}
+	return &NameError{Name: name, Rules: rules, Suggestion: suggestion}
+}
+
+// SanitizeName derives a valid project name from name, replacing unsupported
+// characters with '-'
+func SanitizeName(name string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(name) {
+		if isNameChar(r) && r != '-' {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash {
+			b.WriteRune('-')
+			lastDash = true
+		}
+	}
+
+	sanitized := strings.Trim(b.String(), "-_")
+	if len(sanitized) > maxNameLength {
+		sanitized = strings.TrimRight(sanitized[:maxNameLength], "-_")
+	}
+	if isWindowsReservedName(sanitized) {
+		sanitized += "-app"
+	}
+	return sanitized
+}
+
+// isNameChar reports whether r is allowed in a project name
+func isNameChar(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_'
+}
+
+// isWindowsReservedName reports whether name is a reserved device name on
+// Windows, with or without an extension (e.g., "con" or "nul.txt")
+func isWindowsReservedName(name string) bool {
+	base, _, _ := strings.Cut(strings.ToLower(name), ".")
+	return windowsReservedNames[base]
+}
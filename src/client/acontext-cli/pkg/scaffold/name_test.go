@@ -0,0 +1,240 @@
+package scaffold
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name:    "valid project name",
+			input:   "my-project",
+			wantErr: false,
+		},
+		{
+			name:    "valid with numbers",
+			input:   "project123",
+			wantErr: false,
+		},
+		{
+			name:    "valid with underscores",
+			input:   "my_project",
+			wantErr: false,
+		},
+		{
+			name:    "valid with hyphens",
+			input:   "my-acontext-app",
+			wantErr: false,
+		},
+		{
+			name:    "empty name",
+			input:   "",
+			wantErr: true,
+		},
+		{
+			name:    "contains slash",
+			input:   "project/name",
+			wantErr: true,
+		},
+		{
+			name:    "contains backslash",
+			input:   "project\\name",
+			wantErr: true,
+		},
+		{
+			name:    "reserved name .git",
+			input:   ".git",
+			wantErr: true,
+		},
+		{
+			name:    "reserved name .env",
+			input:   ".env",
+			wantErr: true,
+		},
+		{
+			name:    "reserved name .",
+			input:   ".",
+			wantErr: true,
+		},
+		{
+			name:    "reserved name ..",
+			input:   "..",
+			wantErr: true,
+		},
+		{
+			name:    "contains colon",
+			input:   "project:name",
+			wantErr: true,
+		},
+		{
+			name:    "contains asterisk",
+			input:   "project*name",
+			wantErr: true,
+		},
+		{
+			name:    "contains space",
+			input:   "my project",
+			wantErr: true,
+		},
+		{
+			name:    "uppercase",
+			input:   "MyProject",
+			wantErr: true,
+		},
+		{
+			name:    "leading dot",
+			input:   ".hidden",
+			wantErr: true,
+		},
+		{
+			name:    "leading underscore",
+			input:   "_private",
+			wantErr: true,
+		},
+		{
+			name:    "contains dot",
+			input:   "my.project",
+			wantErr: true,
+		},
+		{
+			name:    "unicode letters",
+			input:   "café",
+			wantErr: true,
+		},
+		{
+			name:    "emoji",
+			input:   "rocket🚀",
+			wantErr: true,
+		},
+		{
+			name:    "windows reserved name CON",
+			input:   "CON",
+			wantErr: true,
+		},
+		{
+			name:    "windows reserved name nul",
+			input:   "nul",
+			wantErr: true,
+		},
+		{
+			name:    "windows reserved name lpt1",
+			input:   "lpt1",
+			wantErr: true,
+		},
+		{
+			name:    "contains reserved name",
+			input:   "console",
+			wantErr: false,
+		},
+		{
+			name:    "maximum length",
+			input:   strings.Repeat("a", maxNameLength),
+			wantErr: false,
+		},
+		{
+			name:    "too long",
+			input:   strings.Repeat("a", maxNameLength+1),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateName(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateNameErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		wantRules      []string
+		wantSuggestion string
+	}{
+		{
+			name:           "space and uppercase",
+			input:          "My Project",
+			wantRules:      []string{"must be lowercase", "may only contain"},
+			wantSuggestion: "my-project",
+		},
+		{
+			name:           "path separator",
+			input:          "org/app",
+			wantRules:      []string{"path separators"},
+			wantSuggestion: "org-app",
+		},
+		{
+			name:           "leading dot",
+			input:          ".app",
+			wantRules:      []string{"cannot start with", "may only contain"},
+			wantSuggestion: "app",
+		},
+		{
+			name:           "unicode",
+			input:          "café app",
+			wantRules:      []string{"may only contain"},
+			wantSuggestion: "caf-app",
+		},
+		{
+			name:           "windows reserved",
+			input:          "CON",
+			wantRules:      []string{"must be lowercase", "reserved name on Windows"},
+			wantSuggestion: "con-app",
+		},
+		{
+			name:           "no usable characters",
+			input:          "🚀",
+			wantRules:      []string{"may only contain"},
+			wantSuggestion: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateName(tt.input)
+			var nameErr *NameError
+			require.ErrorAs(t, err, &nameErr)
+			assert.Len(t, nameErr.Rules, len(tt.wantRules))
+			for _, rule := range tt.wantRules {
+				assert.Contains(t, err.Error(), rule)
+			}
+			assert.Equal(t, tt.wantSuggestion, nameErr.Suggestion)
+		})
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "my-project", want: "my-project"},
+		{input: "My  Project", want: "my-project"},
+		{input: "__init__", want: "init"},
+		{input: "a.b.c", want: "a-b-c"},
+		{input: "nul", want: "nul-app"},
+		{input: strings.Repeat("a", maxNameLength+10), want: strings.Repeat("a", maxNameLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := SanitizeName(tt.input)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, ValidateName(got))
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/template"
+)
+
+// ProvenanceFile is where Scaffold records how a project was created,
+// relative to the project directory
+const ProvenanceFile = ".acontext/provenance.json"
+
+// ProvenanceSchemaVersion is the version of the ProvenanceFile format
+const ProvenanceSchemaVersion = 1
+
+// RedactedValue replaces the values of sensitive variables in ProvenanceFile
+const RedactedValue = "[redacted]"
+
+// Provenance records how a project was created: by which version of the
+// CLI, from which template and with which answers, e.g., to re-apply later
+// changes to the template
+type Provenance struct {
+	SchemaVersion int                `json:"schema_version"`
+	CLIVersion    string             `json:"cli_version"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpgradedAt    *time.Time         `json:"upgraded_at,omitempty"` // Last time the project was upgraded to a newer template version, see PlanUpgrade
+	Template      ProvenanceTemplate `json:"template"`
+	Features      []string           `json:"features"`              // Feature groups the project was created with, null if the template's are not known
+	Variables     map[string]string  `json:"variables"`             // Values the template was rendered with, sensitive ones redacted
+	Seed          uint64             `json:"seed,string,omitempty"` // Seed of the random values rendered files generate, 0 if not recorded
+}
+
+// ProvenanceTemplate identifies the template a project was created from
+type ProvenanceTemplate struct {
+	Name   string `json:"name"`             // Manifest name of a URL template, or its path in ExamplesRepo
+	Source string `json:"source"`           // Its URL without the ref, ExamplesRepo or "embedded"
+	Ref    string `json:"ref,omitempty"`    // Branch, tag or commit of a remote URL template, empty for its default branch
+	Commit string `json:"commit,omitempty"` // Commit a remote URL template was fetched at
+}
+
+// LoadProvenance reads the ProvenanceFile of the project in dir
+func LoadProvenance(dir string) (*Provenance, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ProvenanceFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ProvenanceFile, err)
+	}
+	var provenance Provenance
+	if err := json.Unmarshal(data, &provenance); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ProvenanceFile, err)
+	}
+	return &provenance, nil
+}
+
+// TemplateRef returns the reference that opens the latest version of the
+// template the project was created from: the default branch of a remote URL
+// template, unless Ref is set on it
+func (p *Provenance) TemplateRef() TemplateRef {
+	switch {
+	case strings.HasPrefix(p.Template.Source, "git+") || strings.HasPrefix(p.Template.Source, "file://"):
+		return TemplateRef{URL: p.Template.Source}
+	case p.Template.Source == ExamplesRepo:
+		return TemplateRef{Path: p.Template.Name}
+	default:
+		// Built-in templates are named after their path, which is a valid key
+		return TemplateRef{Key: p.Template.Name}
+	}
+}
+
+// BaseRef returns the reference that opens the exact version of the template
+// the project was created from. It is only known for remote URL templates.
+func (p *Provenance) BaseRef() (TemplateRef, bool) {
+	if p.Template.Commit == "" || !template.IsRemoteURL(p.Template.Source) {
+		return TemplateRef{}, false
+	}
+	return TemplateRef{URL: p.Template.Source, Ref: p.Template.Commit}, true
+}
+
+// Answers returns the recorded values of the given variables, leaving out
+// the redacted ones and those that were not recorded
+func (p *Provenance) Answers(variables []Variable) map[string]string {
+	answers := map[string]string{}
+	for _, variable := range variables {
+		if value, ok := p.Variables[variable.Name]; ok && value != RedactedValue {
+			answers[variable.Name] = value
+		}
+	}
+	return answers
+}
+
+// extraVariables returns the recorded values of the variables that are
+// neither standard nor among the given ones: those passed as
+// Options.ExtraVars, or declared by an older version of the template
+func (p *Provenance) extraVariables(variables []Variable) map[string]string {
+	known := map[string]bool{}
+	for _, variable := range template.Variables(&template.Manifest{Variables: variables}) {
+		known[variable.Name] = true
+	}
+	extra := map[string]string{}
+	for name, value := range p.Variables {
+		if !known[name] && value != RedactedValue {
+			extra[name] = value
+		}
+	}
+	return extra
+}
+
+// newProvenance records the creation of a project from tmpl with vars, the
+// values of the variables the template is rendered with, and seed
+func newProvenance(tmpl *Template, version string, vars map[string]string, sel FeatureSelection, seed uint64) *Provenance {
+	sensitive := map[string]bool{}
+	for _, variable := range tmpl.Variables {
+		sensitive[variable.Name] = variable.Sensitive
+	}
+	recorded := make(map[string]string, len(vars))
+	for name, value := range vars {
+		if sensitive[name] {
+			value = RedactedValue
+		}
+		recorded[name] = value
+	}
+
+	source, _, _ := strings.Cut(tmpl.Source, "#")
+	provenance := &Provenance{
+		SchemaVersion: ProvenanceSchemaVersion,
+		CLIVersion:    version,
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+		Template: ProvenanceTemplate{
+			Name:   tmpl.Name,
+			Source: source,
+			Ref:    tmpl.Ref,
+			Commit: tmpl.Commit,
+		},
+		Variables: recorded,
+		Seed:      seed,
+	}
+	if tmpl.featuresLoaded {
+		// The selection was checked before rendering
+		provenance.Features, _ = template.SelectFeatures(tmpl.Features, sel)
+	}
+	return provenance
+}
+
+// write writes the provenance into the project in dir
+func (p *Provenance) write(dir string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", ProvenanceFile, err)
+	}
+	path := filepath.Join(dir, ProvenanceFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(ProvenanceFile), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ProvenanceFile, err)
+	}
+	return nil
+}
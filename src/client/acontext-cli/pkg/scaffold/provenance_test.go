@@ -0,0 +1,106 @@
+package scaffold
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const provenanceVariables = `variables:
+  - name: model
+    default: gpt-4o-mini
+  - name: api_key
+    description: OpenAI API key
+    sensitive: true
+features:
+  - name: tests
+    paths: [tests/]
+`
+
+func TestScaffoldProvenance(t *testing.T) {
+	tmpl := openTestTemplate(t, provenanceVariables)
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+
+	result, err := Scaffold(context.Background(), Options{
+		Name:       "my-agent",
+		Dir:        projectDir,
+		Template:   tmpl,
+		Vars:       map[string]string{"api_key": "sk-secret"},
+		Author:     "Jane Doe",
+		Provenance: true,
+		Version:    "v1.2.3",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, result.Files, ProvenanceFile)
+
+	// The file has exactly the documented fields
+	data, err := os.ReadFile(filepath.Join(projectDir, ProvenanceFile))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "sk-secret")
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.ElementsMatch(t, []string{"schema_version", "cli_version", "created_at", "template", "features", "variables", "seed"}, keys(fields))
+	assert.Equal(t, `"`+strconv.FormatUint(result.Seed, 10)+`"`, string(fields["seed"]), "the seed is a string, so JSON parsers read it exactly")
+	var templateFields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(fields["template"], &templateFields))
+	assert.ElementsMatch(t, []string{"name", "source"}, keys(templateFields), "a local template has no ref or commit")
+
+	provenance, err := LoadProvenance(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, ProvenanceSchemaVersion, provenance.SchemaVersion)
+	assert.Equal(t, "v1.2.3", provenance.CLIVersion)
+	assert.WithinDuration(t, time.Now(), provenance.CreatedAt, time.Minute)
+	assert.Equal(t, ProvenanceTemplate{Name: "starter", Source: tmpl.Source}, provenance.Template)
+	assert.Equal(t, []string{"tests"}, provenance.Features)
+	assert.Equal(t, map[string]string{
+		"project_name": "my-agent",
+		"author":       "Jane Doe",
+		"model":        "gpt-4o-mini",
+		"api_key":      RedactedValue,
+	}, provenance.Variables)
+	assert.NotZero(t, provenance.Seed)
+	assert.Equal(t, result.Seed, provenance.Seed)
+}
+
+func TestScaffoldNoProvenance(t *testing.T) {
+	tmpl := openTestTemplate(t, "")
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+
+	result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl})
+	require.NoError(t, err)
+	assert.NotContains(t, result.Files, ProvenanceFile)
+	_, err = LoadProvenance(projectDir)
+	assert.ErrorContains(t, err, "failed to read "+ProvenanceFile)
+}
+
+func TestNewProvenanceRemoteTemplate(t *testing.T) {
+	tmpl := &Template{
+		Name:   "starter",
+		Source: "git+https://github.com/myorg/template.git#v1.2.0",
+		Ref:    "v1.2.0",
+		Commit: "3f2a9c1d0b7e",
+	}
+	provenance := newProvenance(tmpl, "v1.2.3", map[string]string{"project_name": "my-agent"}, FeatureSelection{}, 7)
+	assert.Equal(t, ProvenanceTemplate{
+		Name:   "starter",
+		Source: "git+https://github.com/myorg/template.git",
+		Ref:    "v1.2.0",
+		Commit: "3f2a9c1d0b7e",
+	}, provenance.Template)
+	assert.Nil(t, provenance.Features, "the feature groups of a template whose manifest was not fetched are unknown")
+}
+
+func keys(fields map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,413 @@
+// Package scaffold creates Acontext projects from templates, as acontext
+// create does, for use in other Go programs. It never prompts: every answer
+// is passed in Options, and missing ones fall back to their defaults or fail.
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/ci"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/deploy"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/git"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/install"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/license"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/template"
+)
+
+// DefaultGitBranch is the initial Git branch used when Options.GitBranch is empty
+const DefaultGitBranch = git.DefaultBranch
+
+// Options describes the project to scaffold
+type Options struct {
+	Name      string            // Project name, see ValidateName
+	Dir       string            // Project directory, ./<Name> if empty
+	Template  *Template         // Template opened with OpenTemplate
+	Vars      map[string]string // Values of the template's own variables
+	ExtraVars map[string]string // Values of variables the template does not declare, for its rendered files to use
+	Features  FeatureSelection  // Feature groups to create the project with, the template's defaults if zero
+	Seed      uint64            // Seed of the random values rendered files generate (randomHex, randomString, uuid), so the same one renders the same files; a random one if zero
+	Author    string            // Author name passed to the template
+	License   string            // SPDX identifier of the project's license, e.g., MIT: written to LICENSE, recorded in acontext.yaml and passed to the template (none if empty)
+	CI        bool              // Write a GitHub Actions workflow to ci.WorkflowFile that installs the dependencies and runs the tests
+	Force     bool              // Overwrite files in a non-empty directory, backing them up to BackupDir
+	Git       bool              // Initialize a Git repository with an initial commit
+	GitBranch string            // Initial Git branch, DefaultGitBranch if empty
+	GitRemote string            // URL of the origin remote to add, needs Git
+	GitPush   bool              // Push the initial commit to GitRemote
+
+	Provenance bool   // Record how the project was created in ProvenanceFile
+	Version    string // Version of the program creating the project, recorded in ProvenanceFile
+
+	PostCreate       string // Script run in the project once it is written, before Git initialization, instead of the template's
+	IgnoreHookErrors bool   // Keep the project when the post-create script fails, reporting it in Result.HookErr
+
+	// Leave what was written in place when writing the project or its
+	// post-create script fails, for the caller to inspect or roll back
+	KeepPartial bool
+}
+
+// Result describes a scaffolded project
+type Result struct {
+	Dir            string   // Absolute project directory
+	Files          []string // Relative paths of the project's files, sorted by path
+	Seed           uint64   // Seed the template was rendered with, Options.Seed or the random one picked
+	Overwritten    []string // Files that existed and were backed up, sorted by path
+	PostCreate     string   // Absolute path of the post-create script that was run, if any
+	HookErr        error    // Why the post-create script failed, with Options.IgnoreHookErrors
+	GitInitialized bool     // A Git repository was initialized
+	GitErr         error    // Why Git initialization failed, the project is kept
+	RemoteAdded    bool     // The origin remote was added
+	RemoteErr      error    // Why the origin remote could not be added
+	Pushed         bool     // The initial commit was pushed to the origin remote
+	PushErr        error    // Why the push failed, the local repository is kept
+}
+
+// MissingVariablesError is returned when template variables without a
+// default are not given a value
+type MissingVariablesError struct {
+	Names []string
+}
+
+func (e *MissingVariablesError) Error() string {
+	return "missing template variables: " + strings.Join(e.Names, ", ")
+}
+
+// Scaffold creates the project described by opts and returns what it
+// created. When writing the template fails or ctx is cancelled meanwhile,
+// nothing is kept, unless Options.KeepPartial is set. Once the project is
+// written, a cancelled ctx skips the remaining steps but keeps the project,
+// returning the Result along with the error. A failed Git initialization also
+// keeps the project, and is reported in Result.GitErr, for the caller to roll
+// it back or not, and so do failures to add the remote or push to it, in
+// Result.RemoteErr and Result.PushErr.
+//
+// The post-create script, if any, runs in the project directory with the
+// template variables in its environment, see HookEnvName. When it fails, a
+// *HookError is returned and the project is removed, unless it was written
+// into a non-empty directory or Options.IgnoreHookErrors or
+// Options.KeepPartial is set.
+func Scaffold(ctx context.Context, opts Options) (*Result, error) {
+	if opts.Template == nil {
+		return nil, errors.New("a template is required")
+	}
+	if opts.GitRemote != "" && !opts.Git {
+		return nil, errors.New("a Git remote needs Git initialization")
+	}
+	if opts.GitPush && opts.GitRemote == "" {
+		return nil, errors.New("pushing needs a Git remote")
+	}
+	if err := ValidateName(opts.Name); err != nil {
+		return nil, err
+	}
+	target := opts.Dir
+	if target == "" {
+		target = opts.Name
+	}
+	dir, err := filepath.Abs(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	if err := ValidateDir(dir); err != nil {
+		return nil, err
+	}
+	if err := CheckDir(dir, opts.Force); err != nil {
+		return nil, err
+	}
+
+	if !opts.Features.IsZero() {
+		features, err := opts.Template.ListFeatures(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := template.SelectFeatures(features, opts.Features); err != nil {
+			return nil, err
+		}
+	}
+
+	var projectLicense *license.License
+	if opts.License != "" {
+		found, err := license.Lookup(opts.License)
+		if err != nil {
+			return nil, err
+		}
+		projectLicense = &found
+	}
+
+	values, err := ResolveVariables(DefaultVariables(opts.Template.Variables, opts.Name), opts.Vars)
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]string{"project_name": opts.Name}
+	if opts.Author != "" {
+		vars["author"] = opts.Author
+	}
+	if projectLicense != nil {
+		vars["license"] = projectLicense.ID
+	}
+	for name, value := range values {
+		vars[name] = value
+	}
+	if err := CheckExtraVariables(opts.Template.Variables, opts.ExtraVars); err != nil {
+		return nil, err
+	}
+	for name, value := range opts.ExtraVars {
+		vars[name] = value
+	}
+
+	script, err := postCreateScript(opts, dir)
+	if err != nil {
+		return nil, err
+	}
+	if opts.PostCreate != "" {
+		// Fail before writing anything when the given script is missing
+		if _, err := os.Stat(script); err != nil {
+			return nil, fmt.Errorf("post-create script: %w", err)
+		}
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = template.NewSeed()
+	}
+	entries, _ := os.ReadDir(dir)
+	fresh := len(entries) == 0
+
+	overwritten, err := writeProject(ctx, dir, opts.Force, opts.KeepPartial, func(dir string) error {
+		if err := opts.Template.render(ctx, dir, vars, opts.Features, seed); err != nil {
+			return err
+		}
+		project := &deploy.Project{Name: opts.Name, Template: opts.Template.version()}
+		if projectLicense != nil {
+			if err := writeLicense(dir, *projectLicense, opts); err != nil {
+				return err
+			}
+			project.License = projectLicense.ID
+		}
+		if opts.CI {
+			if err := writeWorkflow(dir, opts.Template); err != nil {
+				return err
+			}
+		}
+		// Mark the directory as a project acontext deploy can package,
+		// and record which version of a remote template it was created from
+		if err := deploy.InitProject(dir, project); err != nil {
+			return err
+		}
+		if opts.Provenance {
+			return newProvenance(opts.Template, opts.Version, vars, opts.Features, seed).write(dir)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Dir: dir, Seed: seed, Overwritten: overwritten}
+	if result.Files, err = template.ListFiles(dir); err != nil {
+		return result, fmt.Errorf("failed to list project files: %w", err)
+	}
+	if err := stopped(ctx, target); err != nil {
+		return result, err
+	}
+
+	if script != "" {
+		result.PostCreate = script
+		if err := runPostCreate(ctx, dir, script, vars); err != nil {
+			if err := stopped(ctx, target); err != nil {
+				return result, err
+			}
+			if !opts.IgnoreHookErrors {
+				if fresh && !opts.KeepPartial {
+					_ = os.RemoveAll(dir)
+					return nil, &HookError{Script: script, Dir: dir, Err: err}
+				}
+				return result, &HookError{Script: script, Dir: dir, Kept: true, Err: err}
+			}
+			result.HookErr = err
+		}
+		// The script may have added or removed files
+		if result.Files, err = template.ListFiles(dir); err != nil {
+			return result, fmt.Errorf("failed to list project files: %w", err)
+		}
+	}
+
+	if opts.Git {
+		branch := opts.GitBranch
+		if branch == "" {
+			branch = DefaultGitBranch
+		}
+		if err := git.Init(ctx, dir, branch); err != nil {
+			result.GitErr = err
+		} else {
+			result.GitInitialized = true
+		}
+		if err := stopped(ctx, target); err != nil {
+			return result, err
+		}
+		if result.GitInitialized && opts.GitRemote != "" {
+			setupRemote(ctx, result, branch, opts)
+			if err := stopped(ctx, target); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// writeWorkflow writes the CI workflow of the project in dir, replacing the
+// template's, with the install and test commands the template declares, or
+// else the ones detected from the project files
+func writeWorkflow(dir string, tmpl *Template) error {
+	workflow := ci.Workflow{Language: install.DetectLanguage(dir)}
+	if command, ok := install.Detect(dir, tmpl.Install); ok {
+		workflow.Install = command.String()
+	}
+	command, ok := install.DetectTest(dir, tmpl.Test)
+	if !ok {
+		return fmt.Errorf("cannot write %s: the template declares no test command and the project language is unknown", ci.WorkflowFile)
+	}
+	workflow.Test = command.String()
+	text, err := workflow.Render()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, filepath.FromSlash(ci.WorkflowFile))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ci.WorkflowFile, err)
+	}
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ci.WorkflowFile, err)
+	}
+	return nil
+}
+
+// writeLicense writes the LICENSE file of the project in dir, replacing the
+// template's, with the current year and the author, or else the project's
+// authors, as the copyright holder
+func writeLicense(dir string, projectLicense license.License, opts Options) error {
+	holder := opts.Author
+	if holder == "" {
+		holder = fmt.Sprintf("The %s Authors", opts.Name)
+	}
+	text, err := projectLicense.Render(holder, time.Now().Year())
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, license.FileName), []byte(text), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", license.FileName, err)
+	}
+	return nil
+}
+
+// setupRemote adds the origin remote to the new repository and pushes the
+// initial commit to it, recording the outcome in result
+func setupRemote(ctx context.Context, result *Result, branch string, opts Options) {
+	if err := git.AddRemote(ctx, result.Dir, git.DefaultRemote, opts.GitRemote); err != nil {
+		result.RemoteErr = err
+		return
+	}
+	result.RemoteAdded = true
+	if !opts.GitPush {
+		return
+	}
+	if err := git.Push(ctx, result.Dir, git.DefaultRemote, branch); err != nil {
+		result.PushErr = err
+		return
+	}
+	result.Pushed = true
+}
+
+// ModuleVariable is the template variable holding the Go module path of a
+// Go template, which defaults to the project name
+const ModuleVariable = "module"
+
+// DefaultVariables returns a copy of variables with the defaults that depend
+// on the project name filled in, for variables that have none: the Go
+// module path defaults to the project name.
+func DefaultVariables(variables []Variable, name string) []Variable {
+	defaulted := make([]Variable, len(variables))
+	for i, variable := range variables {
+		if variable.Name == ModuleVariable && variable.Default == "" {
+			variable.Default = name
+		}
+		defaulted[i] = variable
+	}
+	return defaulted
+}
+
+// CheckExtraVariables fails when extra, ad-hoc values for the files a
+// template renders, names a standard variable or one of the template's own
+// variables, which are given their own way
+func CheckExtraVariables(variables []Variable, extra map[string]string) error {
+	known := map[string]bool{}
+	for _, variable := range template.Variables(&template.Manifest{Variables: variables}) {
+		known[variable.Name] = true
+	}
+	var declared []string
+	for name := range extra {
+		if known[name] {
+			declared = append(declared, name)
+		}
+	}
+	if len(declared) > 0 {
+		sort.Strings(declared)
+		return fmt.Errorf("extra variables redefine declared template variables: %s", strings.Join(declared, ", "))
+	}
+	return nil
+}
+
+// ResolveVariables checks values against the template's own variables and
+// returns the value of each of them: the given one, or else its default.
+// Values for undeclared variables and values that break a constraint fail,
+// and so do variables without a value or default, with a
+// *MissingVariablesError.
+func ResolveVariables(variables []Variable, values map[string]string) (map[string]string, error) {
+	declared := map[string]bool{}
+	resolved := map[string]string{}
+	var missing []string
+	for _, variable := range variables {
+		declared[variable.Name] = true
+		value, ok := values[variable.Name]
+		if !ok {
+			if variable.Default == "" {
+				missing = append(missing, variable.Name)
+				continue
+			}
+			value = variable.Default
+		}
+		if err := variable.Check(value); err != nil {
+			return nil, fmt.Errorf("variable %s: %w", variable.Name, err)
+		}
+		resolved[variable.Name] = value
+	}
+
+	var unknown []string
+	for name := range values {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown template variables: %s", strings.Join(unknown, ", "))
+	}
+	if len(missing) > 0 {
+		return nil, &MissingVariablesError{Names: missing}
+	}
+	return resolved, nil
+}
+
+// stopped returns an error when ctx was cancelled after the project was
+// written, so the remaining steps are skipped but the project is kept
+func stopped(ctx context.Context, dir string) error {
+	if cause := context.Cause(ctx); cause != nil {
+		return fmt.Errorf("project created in %s, remaining setup skipped: %w", dir, cause)
+	}
+	return nil
+}
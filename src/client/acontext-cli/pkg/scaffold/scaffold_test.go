@@ -0,0 +1,541 @@
+package scaffold
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/ci"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/deploy"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestTemplate opens a local template with a manifest declaring variables
+func openTestTemplate(t *testing.T, variables string) *Template {
+	t.Helper()
+	templateDir := t.TempDir()
+	writeFile(t, filepath.Join(templateDir, "acontext.template.yaml"), "name: starter\n"+variables)
+	writeFile(t, filepath.Join(templateDir, "package.json"), `{"name": "starter"}`)
+	writeFile(t, filepath.Join(templateDir, "src", "index.ts"), "console.log('hello')\n")
+
+	tmpl, err := OpenTemplate(context.Background(), TemplateRef{URL: "file://" + templateDir})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = tmpl.Close()
+	})
+	return tmpl
+}
+
+func TestScaffold(t *testing.T) {
+	tmpl := openTestTemplate(t, "variables:\n  - name: model\n    default: gpt-4o-mini\n")
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+
+	result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl})
+	require.NoError(t, err)
+	assert.Equal(t, projectDir, result.Dir)
+	assert.Equal(t, []string{"acontext.yaml", "package.json", "src/index.ts"}, result.Files)
+	assert.Empty(t, result.Overwritten)
+	assert.False(t, result.GitInitialized)
+	assertFileContent(t, filepath.Join(projectDir, "package.json"), "{\n  \"name\": \"my-agent\"\n}\n")
+}
+
+func TestScaffoldLicense(t *testing.T) {
+	tmpl := openTestTemplate(t, "")
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+
+	result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl, Author: "Jane Doe", License: "mit"})
+	require.NoError(t, err)
+	assert.Contains(t, result.Files, "LICENSE")
+	data, err := os.ReadFile(filepath.Join(projectDir, "LICENSE"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), fmt.Sprintf("Copyright (c) %d Jane Doe\n", time.Now().Year()))
+	project, err := deploy.LoadProject(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, "MIT", project.License, "the identifier is canonicalized")
+
+	projectDir = filepath.Join(t.TempDir(), "my-agent")
+	_, err = Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl, License: "MTI"})
+	assert.EqualError(t, err, `unknown license "MTI", did you mean MIT?`)
+	assert.NoDirExists(t, projectDir, "nothing is written")
+}
+
+func TestScaffoldCI(t *testing.T) {
+	tests := []struct {
+		name         string
+		manifest     string
+		ci           bool
+		features     FeatureSelection
+		wantWorkflow bool
+		wantCommands []string
+	}{
+		{name: "not requested", manifest: "", wantWorkflow: false},
+		{name: "not requested with minimal", manifest: "", features: FeatureSelection{Minimal: true}, wantWorkflow: false},
+		{name: "detected commands", manifest: "", ci: true, wantWorkflow: true, wantCommands: []string{"actions/setup-node@v4", "npm install\n", "npm test\n"}},
+		{name: "requested with minimal", manifest: "", ci: true, features: FeatureSelection{Minimal: true}, wantWorkflow: true},
+		{name: "manifest commands", manifest: "install: npm ci\ntest: npm run test:ci\n", ci: true, wantWorkflow: true, wantCommands: []string{"npm ci\n", "npm run test:ci\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := openTestTemplate(t, tt.manifest)
+			projectDir := filepath.Join(t.TempDir(), "my-agent")
+
+			result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl, CI: tt.ci, Features: tt.features})
+			require.NoError(t, err)
+			if !tt.wantWorkflow {
+				assert.NotContains(t, result.Files, ci.WorkflowFile)
+				assert.NoFileExists(t, filepath.Join(projectDir, ".github", "workflows", "ci.yml"))
+				return
+			}
+			assert.Contains(t, result.Files, ci.WorkflowFile)
+			data, err := os.ReadFile(filepath.Join(projectDir, ".github", "workflows", "ci.yml"))
+			require.NoError(t, err)
+			for _, command := range tt.wantCommands {
+				assert.Contains(t, string(data), command)
+			}
+		})
+	}
+}
+
+func TestScaffoldCIUnknownLanguage(t *testing.T) {
+	templateDir := t.TempDir()
+	writeFile(t, filepath.Join(templateDir, "acontext.template.yaml"), "name: docs\n")
+	writeFile(t, filepath.Join(templateDir, "README.md"), "# Docs\n")
+	tmpl, err := OpenTemplate(context.Background(), TemplateRef{URL: "file://" + templateDir})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = tmpl.Close()
+	})
+
+	projectDir := filepath.Join(t.TempDir(), "my-docs")
+	_, err = Scaffold(context.Background(), Options{Name: "my-docs", Dir: projectDir, Template: tmpl, CI: true})
+	assert.ErrorContains(t, err, "the template declares no test command")
+	assert.NoDirExists(t, projectDir, "nothing is kept")
+}
+
+func TestScaffoldSeed(t *testing.T) {
+	tmpl := openFilesTemplate(t, map[string]string{
+		"acontext.template.yaml": "name: starter\nrender: [.env, config/app.yaml]\n",
+		".env":                   "SECRET_KEY={{ randomHex 32 }}\n",
+		"config/app.yaml":        "project_id: {{ uuid }}\npassword: {{ randomString 16 }}\n",
+	})
+	create := func(seed uint64) (*Result, map[string]string) {
+		projectDir := filepath.Join(t.TempDir(), "my-agent")
+		result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl, Seed: seed})
+		require.NoError(t, err)
+		files := map[string]string{}
+		for _, path := range result.Files {
+			data, err := os.ReadFile(filepath.Join(projectDir, filepath.FromSlash(path)))
+			require.NoError(t, err)
+			files[path] = string(data)
+		}
+		return result, files
+	}
+
+	first, files := create(42)
+	assert.Equal(t, uint64(42), first.Seed)
+	_, again := create(42)
+	assert.Equal(t, files, again, "the same seed creates byte-identical files")
+
+	random, other := create(0)
+	assert.NotZero(t, random.Seed, "a random seed is picked and reported")
+	assert.NotEqual(t, files[".env"], other[".env"])
+	_, replayed := create(random.Seed)
+	assert.Equal(t, other, replayed, "the reported seed reproduces the project")
+}
+
+func TestScaffoldTemplateDir(t *testing.T) {
+	templateDir := t.TempDir()
+	writeFile(t, filepath.Join(templateDir, "acontext.template.yaml"), "name: starter\nrender: [README.md]\nvariables:\n  - name: model\n    default: gpt-4o-mini\n")
+	writeFile(t, filepath.Join(templateDir, "package.json"), `{"name": "starter"}`)
+	writeFile(t, filepath.Join(templateDir, "README.md"), "# {{ .project_name }} uses {{ .model }}\n")
+	writeFile(t, filepath.Join(templateDir, ".git", "HEAD"), "ref: refs/heads/main\n")
+
+	tmpl, err := OpenTemplate(context.Background(), TemplateRef{Dir: templateDir})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = tmpl.Close()
+	})
+	assert.Equal(t, "starter", tmpl.Name)
+	assert.Equal(t, "file://"+filepath.ToSlash(templateDir), tmpl.Source)
+
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+	result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl, Vars: map[string]string{"model": "o3"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "acontext.yaml", "package.json"}, result.Files)
+	assertFileContent(t, filepath.Join(projectDir, "README.md"), "# my-agent uses o3\n")
+	assertFileContent(t, filepath.Join(projectDir, "package.json"), "{\n  \"name\": \"my-agent\"\n}\n")
+
+	// Edits to the template show up in the next project
+	writeFile(t, filepath.Join(templateDir, "README.md"), "# {{ .project_name }}\n")
+	projectDir = filepath.Join(t.TempDir(), "other-agent")
+	_, err = Scaffold(context.Background(), Options{Name: "other-agent", Dir: projectDir, Template: tmpl})
+	require.NoError(t, err)
+	assertFileContent(t, filepath.Join(projectDir, "README.md"), "# other-agent\n")
+}
+
+func TestScaffoldRenderedFiles(t *testing.T) {
+	tmpl := openTestTemplate(t, "render: [README.md]\nvariables:\n  - name: model\n    default: gpt-4o-mini\n")
+	writeFile(t, filepath.Join(tmpl.source.Dir, "README.md"), "# {{ .project_name }} ({{ .model }}, {{ .team }})\n")
+
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+	_, err := Scaffold(context.Background(), Options{
+		Name:      "my-agent",
+		Dir:       projectDir,
+		Template:  tmpl,
+		ExtraVars: map[string]string{"team": "core"},
+	})
+	require.NoError(t, err)
+	assertFileContent(t, filepath.Join(projectDir, "README.md"), "# my-agent (gpt-4o-mini, core)\n")
+
+	projectDir = filepath.Join(t.TempDir(), "my-agent")
+	_, err = Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl})
+	assert.ErrorContains(t, err, `README.md:1: undefined variable "team"`)
+	assert.NoDirExists(t, projectDir, "a project that fails to render is removed")
+
+	_, err = Scaffold(context.Background(), Options{
+		Name:      "my-agent",
+		Dir:       filepath.Join(t.TempDir(), "my-agent"),
+		Template:  tmpl,
+		ExtraVars: map[string]string{"model": "o3", "project_name": "other"},
+	})
+	assert.EqualError(t, err, "extra variables redefine declared template variables: model, project_name")
+}
+
+func TestScaffoldFeatures(t *testing.T) {
+	features := "features:\n  - name: tests\n    paths: [tests/]\n  - name: ci\n    paths: [.github/]\n    default: false\n"
+	tmpl := openTestTemplate(t, features)
+	templateDir := tmpl.source.Dir
+	writeFile(t, filepath.Join(templateDir, "tests", "index.test.ts"), "test('hello')\n")
+	writeFile(t, filepath.Join(templateDir, ".github", "workflows", "ci.yml"), "on: push\n")
+	require.Len(t, tmpl.Features, 2)
+
+	tests := []struct {
+		name      string
+		features  FeatureSelection
+		wantFiles []string
+	}{
+		{
+			name:      "defaults",
+			wantFiles: []string{"acontext.yaml", "package.json", "src/index.ts", "tests/index.test.ts"},
+		},
+		{
+			name:      "minimal",
+			features:  FeatureSelection{Minimal: true},
+			wantFiles: []string{"acontext.yaml", "package.json", "src/index.ts"},
+		},
+		{
+			name:      "full",
+			features:  FeatureSelection{Full: true},
+			wantFiles: []string{".github/workflows/ci.yml", "acontext.yaml", "package.json", "src/index.ts", "tests/index.test.ts"},
+		},
+		{
+			name:      "without tests",
+			features:  FeatureSelection{With: []string{"ci"}, Without: []string{"tests"}},
+			wantFiles: []string{".github/workflows/ci.yml", "acontext.yaml", "package.json", "src/index.ts"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := filepath.Join(t.TempDir(), "my-agent")
+			result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl, Features: tt.features})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFiles, result.Files)
+
+			files, err := tmpl.Files(context.Background(), tt.features)
+			require.NoError(t, err)
+			assert.Subset(t, tt.wantFiles, files, "the dry-run listing matches")
+		})
+	}
+}
+
+func TestScaffoldGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	tmpl := openTestTemplate(t, "")
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+
+	result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl, Git: true, GitBranch: "trunk"})
+	require.NoError(t, err)
+	require.NoError(t, result.GitErr)
+	assert.True(t, result.GitInitialized)
+	assert.NotContains(t, result.Files, ".git/HEAD")
+	assertFileContent(t, filepath.Join(projectDir, ".git", "HEAD"), "ref: refs/heads/trunk\n")
+}
+
+func TestScaffoldGitRemote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	tmpl := openTestTemplate(t, "")
+	remoteDir := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", "--bare", remoteDir).Run())
+
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+	result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl, Git: true, GitRemote: remoteDir, GitPush: true})
+	require.NoError(t, err)
+	require.NoError(t, result.RemoteErr)
+	require.NoError(t, result.PushErr)
+	assert.True(t, result.RemoteAdded)
+	assert.True(t, result.Pushed)
+	assert.NoError(t, exec.Command("git", "-C", remoteDir, "rev-parse", "--verify", "refs/heads/main").Run())
+
+	// A failed push keeps the project and its local repository
+	projectDir = filepath.Join(t.TempDir(), "my-agent")
+	missingRemote := filepath.Join(t.TempDir(), "missing.git")
+	result, err = Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl, Git: true, GitRemote: missingRemote, GitPush: true})
+	require.NoError(t, err)
+	assert.True(t, result.GitInitialized)
+	assert.True(t, result.RemoteAdded)
+	assert.False(t, result.Pushed)
+	assert.ErrorContains(t, result.PushErr, "failed to push to origin")
+	assert.DirExists(t, filepath.Join(projectDir, ".git"))
+}
+
+func TestScaffoldGoTemplate(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	tmpl, err := OpenTemplate(context.Background(), TemplateRef{Key: "go.basic"})
+	require.NoError(t, err)
+	assert.Equal(t, "embedded", tmpl.Source)
+
+	tests := []struct {
+		name       string
+		vars       map[string]string
+		features   FeatureSelection
+		wantModule string
+		wantFiles  []string
+	}{
+		{
+			name:       "module path",
+			vars:       map[string]string{ModuleVariable: "github.com/myorg/my-agent"},
+			wantModule: "github.com/myorg/my-agent",
+			wantFiles:  []string{"README.md", "acontext.yaml", "go.mod", "internal/agent/agent.go", "internal/agent/agent_test.go", "main.go"},
+		},
+		{
+			name:       "project name",
+			wantModule: "my-agent",
+			wantFiles:  []string{"README.md", "acontext.yaml", "go.mod", "internal/agent/agent.go", "internal/agent/agent_test.go", "main.go"},
+		},
+		{
+			name:       "minimal",
+			features:   FeatureSelection{Minimal: true},
+			wantModule: "my-agent",
+			wantFiles:  []string{"README.md", "acontext.yaml", "go.mod", "internal/agent/agent.go", "main.go"},
+		},
+		{
+			name:       "examples",
+			vars:       map[string]string{ModuleVariable: "github.com/myorg/my-agent"},
+			features:   FeatureSelection{With: []string{template.ExamplesFeature}},
+			wantModule: "github.com/myorg/my-agent",
+			wantFiles:  []string{"README.md", "acontext.yaml", "examples/hello-context/main.go", "go.mod", "internal/agent/agent.go", "internal/agent/agent_test.go", "main.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := filepath.Join(t.TempDir(), "my-agent")
+			result, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: tmpl, Vars: tt.vars, Features: tt.features})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFiles, result.Files)
+			assertFileContent(t, filepath.Join(projectDir, "go.mod"), "module "+tt.wantModule+"\n\ngo 1.22\n")
+
+			build := exec.Command(goBin, "build", "-o", os.DevNull, "./...")
+			build.Dir = projectDir
+			build.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+			out, err := build.CombinedOutput()
+			require.NoError(t, err, "go build: %s", out)
+
+			// The example is a main package of its own, importing the
+			// project's agent package by its module path
+			example, err := os.ReadFile(filepath.Join(projectDir, "examples", "hello-context", "main.go"))
+			if !slices.Contains(tt.wantFiles, "examples/hello-context/main.go") {
+				assert.ErrorIs(t, err, fs.ErrNotExist)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, string(example), "package main\n")
+			assert.Contains(t, string(example), "func main() {")
+			assert.Contains(t, string(example), `"`+tt.wantModule+`/internal/agent"`)
+			assert.Contains(t, string(example), "agent.ConfigFromEnv(os.Getenv)")
+		})
+	}
+}
+
+func TestDefaultVariables(t *testing.T) {
+	variables := []Variable{{Name: "model", Default: "gpt-4o-mini"}, {Name: ModuleVariable}}
+
+	defaulted := DefaultVariables(variables, "my-agent")
+	assert.Equal(t, []Variable{{Name: "model", Default: "gpt-4o-mini"}, {Name: ModuleVariable, Default: "my-agent"}}, defaulted)
+	assert.Empty(t, variables[1].Default, "the given variables are not changed")
+}
+
+func TestScaffoldErrors(t *testing.T) {
+	tmpl := openTestTemplate(t, "variables:\n  - name: api_base\n")
+
+	missingDir := filepath.Join(t.TempDir(), "app")
+	nonEmptyDir := t.TempDir()
+	writeFile(t, filepath.Join(nonEmptyDir, "main.py"), "print()\n")
+
+	tests := []struct {
+		name    string
+		opts    Options
+		target  any
+		wantErr string
+	}{
+		{
+			name:    "no template",
+			opts:    Options{Name: "my-agent"},
+			wantErr: "a template is required",
+		},
+		{
+			name:    "remote without git",
+			opts:    Options{Name: "my-agent", Template: tmpl, GitRemote: "git@github.com:org/my-agent.git"},
+			wantErr: "a Git remote needs Git initialization",
+		},
+		{
+			name:    "push without remote",
+			opts:    Options{Name: "my-agent", Template: tmpl, Git: true, GitPush: true},
+			wantErr: "pushing needs a Git remote",
+		},
+		{
+			name:    "invalid name",
+			opts:    Options{Name: "My Agent", Template: tmpl},
+			target:  new(*NameError),
+			wantErr: `invalid project name "My Agent"`,
+		},
+		{
+			name:    "non-empty directory",
+			opts:    Options{Name: "my-agent", Dir: nonEmptyDir, Template: tmpl, Vars: map[string]string{"api_base": "http://localhost"}},
+			target:  new(*NotEmptyError),
+			wantErr: "already exists and is not empty:\n  - main.py",
+		},
+		{
+			name:    "unknown feature group",
+			opts:    Options{Name: "my-agent", Dir: missingDir, Template: tmpl, Features: FeatureSelection{Without: []string{"tests"}}},
+			wantErr: "unknown feature group tests (the template declares no feature groups)",
+		},
+		{
+			name:    "missing variable",
+			opts:    Options{Name: "my-agent", Dir: missingDir, Template: tmpl},
+			target:  new(*MissingVariablesError),
+			wantErr: "missing template variables: api_base",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Scaffold(context.Background(), tt.opts)
+			assert.Nil(t, result)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			if tt.target != nil {
+				assert.ErrorAs(t, err, tt.target)
+			}
+		})
+	}
+	assert.NoDirExists(t, missingDir)
+	_, err := os.Stat(filepath.Join(nonEmptyDir, "package.json"))
+	assert.True(t, os.IsNotExist(err), "a non-empty directory is left untouched")
+}
+
+func TestResolveVariables(t *testing.T) {
+	maxPort := 65535.0
+	variables := []Variable{
+		{Name: "model", Default: "gpt-4o-mini"},
+		{Name: "port", Type: "integer", Max: &maxPort, Default: "8000"},
+		{Name: "api_base"},
+	}
+
+	tests := []struct {
+		name    string
+		values  map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "defaults",
+			values: map[string]string{"api_base": "http://localhost:8029"},
+			want:   map[string]string{"model": "gpt-4o-mini", "port": "8000", "api_base": "http://localhost:8029"},
+		},
+		{
+			name:   "overrides",
+			values: map[string]string{"api_base": "http://localhost:8029", "port": "9000"},
+			want:   map[string]string{"model": "gpt-4o-mini", "port": "9000", "api_base": "http://localhost:8029"},
+		},
+		{
+			name:    "missing",
+			wantErr: "missing template variables: api_base",
+		},
+		{
+			name:    "unknown",
+			values:  map[string]string{"api_base": "x", "regoin": "eu"},
+			wantErr: "unknown template variables: regoin",
+		},
+		{
+			name:    "constraint",
+			values:  map[string]string{"api_base": "x", "port": "70000"},
+			wantErr: "variable port: 70000 is greater than the maximum 65535",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := ResolveVariables(variables, tt.values)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, values)
+		})
+	}
+}
+
+func TestOpenTemplate(t *testing.T) {
+	_, err := OpenTemplate(context.Background(), TemplateRef{})
+	assert.EqualError(t, err, "exactly one of the template key, path, URL and directory must be set")
+	_, err = OpenTemplate(context.Background(), TemplateRef{Key: "python.openai", Path: "python/openai"})
+	assert.Error(t, err)
+
+	tmpl, err := OpenTemplate(context.Background(), TemplateRef{Path: "python/custom"})
+	require.NoError(t, err)
+	assert.Equal(t, "python/custom", tmpl.Name)
+	assert.Equal(t, ExamplesRepo, tmpl.Source)
+	assert.NoError(t, tmpl.Close())
+}
+
+func TestOpenTemplateRef(t *testing.T) {
+	_, err := OpenTemplate(context.Background(), TemplateRef{URL: "file:///path/to/template", Ref: "v1.2.0"})
+	assert.EqualError(t, err, "a template ref needs a remote template URL (git+https:// or git+ssh://)")
+	_, err = OpenTemplate(context.Background(), TemplateRef{Key: "python.openai", Ref: "v1.2.0"})
+	assert.Error(t, err)
+	_, err = OpenTemplate(context.Background(), TemplateRef{URL: "git+https://github.com/myorg/template.git#main", Ref: "v1.2.0"})
+	assert.EqualError(t, err, "the template URL git+https://github.com/myorg/template.git#main already selects a ref after #")
+}
+
+func TestTemplateVersion(t *testing.T) {
+	tmpl := &Template{Source: "git+https://github.com/myorg/template.git#v1.2.0", Ref: "v1.2.0", Commit: "3f2a9c1d0b7e"}
+	assert.Equal(t, &deploy.TemplateVersion{URL: "git+https://github.com/myorg/template.git", Ref: "v1.2.0", Commit: "3f2a9c1d0b7e"}, tmpl.version())
+	assert.Nil(t, (&Template{Source: "file:///path/to/template"}).version(), "only remote templates have a version")
+}
@@ -0,0 +1,272 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/config"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/deploy"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/template"
+)
+
+// ExamplesRepo is the repository built-in templates and TemplateRef.Path
+// templates are fetched from
+const ExamplesRepo = "https://github.com/memodb-io/Acontext-Examples"
+
+// Variable describes a value a template is rendered with, and the
+// constraints its value must satisfy
+type Variable = template.Variable
+
+// Feature is an optional group of template files, such as tests or CI config
+type Feature = template.Feature
+
+// FeatureSelection picks the feature groups a project is created with
+type FeatureSelection = template.FeatureSelection
+
+// Defaults are the create settings a template suggests, see Manifest.Defaults
+type Defaults = template.Defaults
+
+// TemplateRef selects the template to scaffold from; exactly one of Key,
+// Path, URL and Dir must be set
+type TemplateRef struct {
+	Key      string // Built-in template key, e.g., "python.openai"
+	Path     string // Template folder in ExamplesRepo, e.g., "python/custom-template"
+	URL      string // Custom template source: git+https://, git+ssh:// or file://
+	Dir      string // Local template directory, rendered in place without cloning or caching it
+	Ref      string // Branch, tag or commit of a remote URL template, its default branch if empty
+	Refresh  bool   // Re-fetch a cached URL template
+	Offline  bool   // Only use a cached URL template, fail if it is not cached
+	GitToken string // Authenticates the clone of an https:// URL template, instead of the git credential helpers
+}
+
+// Template is a template opened with OpenTemplate. It must be closed once
+// it is no longer needed.
+type Template struct {
+	Name       string     // Manifest name of a URL or directory template, or its path in ExamplesRepo
+	Source     string     // Where the template comes from: its URL (file:// for a directory), ExamplesRepo or "embedded"
+	Variables  []Variable // Variables declared by the manifest of a URL or embedded template
+	Features   []Feature  // Feature groups declared by the manifest of a URL or embedded template
+	Install    string     // Install command declared by the manifest, if any
+	Test       string     // Test command declared by the manifest, if any
+	PostCreate string     // Post-create script declared by the manifest, relative to the project root
+	Defaults   Defaults   // Create settings defaulted by the manifest of a URL or embedded template
+	Ref        string     // Branch, tag or commit a remote URL template was fetched at, empty for its default branch
+	Commit     string     // Commit a remote URL template was fetched at
+	RefreshErr error      // Why a cached URL template could not be refreshed, if it was used anyway
+
+	config         *template.Config // Template fetched from a repository at render time
+	source         *template.Source // Template fetched by OpenTemplate
+	featuresLoaded bool             // Features holds the manifest's feature groups
+}
+
+// OpenTemplate resolves ref. A URL template is fetched, from the template
+// cache when possible, so its manifest is available, and so is the manifest
+// of a template embedded in the CLI or in a local directory, which is
+// rendered in place; the others are fetched when rendering.
+func OpenTemplate(ctx context.Context, ref TemplateRef) (*Template, error) {
+	set := 0
+	for _, value := range []string{ref.Key, ref.Path, ref.URL, ref.Dir} {
+		if value != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("exactly one of the template key, path, URL and directory must be set")
+	}
+	if ref.Ref != "" {
+		if !template.IsRemoteURL(ref.URL) {
+			return nil, errors.New("a template ref needs a remote template URL (git+https:// or git+ssh://)")
+		}
+		if strings.Contains(ref.URL, "#") {
+			return nil, fmt.Errorf("the template URL %s already selects a ref after #", ref.URL)
+		}
+		ref.URL += "#" + ref.Ref
+	}
+
+	switch {
+	case ref.URL != "":
+		cache, err := template.DefaultCache()
+		if err != nil {
+			return nil, err
+		}
+		source, err := template.FetchSourceWithOptions(ctx, ref.URL, template.FetchOptions{
+			Cache:    cache,
+			Refresh:  ref.Refresh,
+			Offline:  ref.Offline,
+			GitToken: ref.GitToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch template: %w", err)
+		}
+		return sourceTemplate(source), nil
+	case ref.Dir != "":
+		source, err := template.OpenDir(ref.Dir)
+		if err != nil {
+			return nil, err
+		}
+		return sourceTemplate(source), nil
+	case ref.Key != "":
+		templateConfig, err := resolveTemplateKey(ref.Key)
+		if err != nil {
+			return nil, err
+		}
+		tmpl := &Template{Name: templateConfig.Path, Source: templateConfig.Repo, config: templateConfig}
+		if templateConfig.Embedded {
+			// Embedded templates are at hand, so their manifest is too
+			manifest, err := template.BuiltinManifest(templateConfig.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load template %s: %w", ref.Key, err)
+			}
+			tmpl.Source = template.BuiltinSource
+			tmpl.Variables = manifest.Variables
+			tmpl.Features = manifest.Features
+			tmpl.Install = manifest.Install
+			tmpl.Test = manifest.Test
+			tmpl.PostCreate = manifest.PostCreate
+			tmpl.Defaults = manifest.Defaults
+			tmpl.featuresLoaded = true
+		}
+		return tmpl, nil
+	default:
+		return &Template{
+			Name:   ref.Path,
+			Source: ExamplesRepo,
+			config: &template.Config{
+				Repo:        ExamplesRepo,
+				Path:        ref.Path,
+				Description: fmt.Sprintf("Custom template from %s", ref.Path),
+			},
+		}, nil
+	}
+}
+
+// sourceTemplate returns the Template of a URL or directory template, whose
+// manifest is at hand
+func sourceTemplate(source *template.Source) *Template {
+	return &Template{
+		Name:       source.Manifest.Name,
+		Source:     source.URL,
+		Variables:  source.Manifest.Variables,
+		Features:   source.Manifest.Features,
+		Install:    source.Manifest.Install,
+		Test:       source.Manifest.Test,
+		PostCreate: source.Manifest.PostCreate,
+		Defaults:   source.Manifest.Defaults,
+		Ref:        source.Ref,
+		Commit:     source.Commit,
+		RefreshErr: source.RefreshErr,
+		source:     source,
+
+		featuresLoaded: true,
+	}
+}
+
+// version returns the version of a remote URL template to record in the
+// project's acontext.yaml, or nil for other templates
+func (t *Template) version() *deploy.TemplateVersion {
+	if t.Commit == "" {
+		return nil
+	}
+	url, _, _ := strings.Cut(t.Source, "#")
+	return &deploy.TemplateVersion{URL: url, Ref: t.Ref, Commit: t.Commit}
+}
+
+// Close removes the files fetched for the template
+func (t *Template) Close() error {
+	if t.source == nil {
+		return nil
+	}
+	return t.source.Close()
+}
+
+// Files returns the relative paths of the files the template would write
+// with the feature groups sel selects, sorted by path
+func (t *Template) Files(ctx context.Context, sel FeatureSelection) ([]string, error) {
+	if t.source != nil {
+		return t.source.Files(sel)
+	}
+	return template.ListTemplateFiles(ctx, t.config, sel)
+}
+
+// ListVariables returns every variable the template is rendered with, the
+// standard ones first. Unlike Variables, it fetches the manifest of
+// templates from ExamplesRepo.
+func (t *Template) ListVariables(ctx context.Context) ([]Variable, error) {
+	if t.source != nil {
+		return template.Variables(t.source.Manifest), nil
+	}
+	if t.config.Embedded {
+		return template.Variables(&template.Manifest{Variables: t.Variables}), nil
+	}
+	manifest, err := template.FetchManifest(ctx, t.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template %s: %w", t.Name, err)
+	}
+	return template.Variables(manifest), nil
+}
+
+// ListFeatures returns the feature groups the template declares. Unlike
+// Features, it fetches the manifest of templates from ExamplesRepo, once.
+func (t *Template) ListFeatures(ctx context.Context) ([]Feature, error) {
+	if t.featuresLoaded {
+		return t.Features, nil
+	}
+	manifest, err := template.FetchManifest(ctx, t.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template %s: %w", t.Name, err)
+	}
+	if manifest != nil {
+		t.Features = manifest.Features
+	}
+	t.featuresLoaded = true
+	return t.Features, nil
+}
+
+// render writes the template into dir with vars, the feature groups sel
+// selects and the random values seed generates
+func (t *Template) render(ctx context.Context, dir string, vars map[string]string, sel FeatureSelection, seed uint64) error {
+	if t.source != nil {
+		if err := t.source.Render(dir, vars, sel, seed); err != nil {
+			return fmt.Errorf("failed to render template: %w", err)
+		}
+		return nil
+	}
+	if err := template.DownloadTemplateWithVars(ctx, t.config, dir, vars, sel, seed); err != nil {
+		return fmt.Errorf("failed to download template: %w", err)
+	}
+	return nil
+}
+
+// resolveTemplateKey resolves a built-in template key (e.g., "python.openai" or
+// "python/openai") to its template config
+func resolveTemplateKey(key string) (*template.Config, error) {
+	// Parse template key (e.g., "python.openai")
+	parts := strings.SplitN(strings.Replace(key, "/", ".", 1), ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid template key: %s (expected <language>.<template>, e.g., python.openai)", key)
+	}
+
+	// Try to get template from config first
+	tmpl, err := config.GetTemplate(parts[0], parts[1])
+	if err == nil {
+		return &template.Config{
+			Repo:        tmpl.Repo,
+			Path:        tmpl.Path,
+			Description: tmpl.Description,
+			Embedded:    tmpl.Embedded,
+		}, nil
+	}
+
+	// If not found in config, construct path dynamically
+	cfg, err := config.LoadTemplatesConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates config: %w", err)
+	}
+	return &template.Config{
+		Repo:        cfg.Repo,
+		Path:        fmt.Sprintf("%s/%s", parts[0], parts[1]),
+		Description: fmt.Sprintf("%s template", key),
+	}, nil
+}
@@ -0,0 +1,423 @@
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/memodb-io/Acontext/acontext-cli/internal/deploy"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/template"
+)
+
+// ConflictSuffix is appended to the path of a file changed both in the
+// project and in the newer template version, to write the template's file
+// next to the project's for manual resolution
+const ConflictSuffix = ".acontext-new"
+
+// ChangeKind tells how upgrading a project changes one of its files
+type ChangeKind string
+
+const (
+	// ChangeAdded is a file new in the template, created
+	ChangeAdded ChangeKind = "added"
+	// ChangeUpdated is a file changed in the template but not in the project, overwritten
+	ChangeUpdated ChangeKind = "updated"
+	// ChangeRemoved is a file removed from the template but not changed in the project, deleted
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeConflict is a file changed both in the template and in the project,
+	// left as it is: the template's file is written to its path plus
+	// ConflictSuffix, unless the template removes it
+	ChangeConflict ChangeKind = "conflict"
+)
+
+// FileChange is how upgrading a project changes one of its files
+type FileChange struct {
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+	Diff string     `json:"diff"` // Unified diff from the project's file to the newer template's
+
+	file *renderedFile // The newer template's file, nil if it removes it
+}
+
+// UpgradeOptions describes the project to upgrade and the template version
+// to upgrade it to
+type UpgradeOptions struct {
+	Dir       string            // Project directory, holding a ProvenanceFile
+	Template  *Template         // Newer template version, opened with Provenance.TemplateRef
+	Base      *Template         // Template version the project was created from, opened with Provenance.BaseRef, nil if unknown
+	Vars      map[string]string // Values of the template's own variables, overriding the recorded ones
+	ExtraVars map[string]string // Values of variables the template does not declare, overriding the recorded ones
+	Version   string            // Version of the program upgrading the project, recorded in ProvenanceFile
+}
+
+// UpgradePlan is how upgrading a project to a newer template version changes
+// its files. Nothing is written until it is applied.
+type UpgradePlan struct {
+	Dir     string             // Absolute project directory
+	From    ProvenanceTemplate // Template version the project was created from
+	To      ProvenanceTemplate // Template version the project is upgraded to
+	Merged  bool               // The version the project was created from was known, so changes made in the project are kept
+	Changes []FileChange       // Files that change, sorted by path
+
+	provenance *Provenance
+	version    *deploy.TemplateVersion
+}
+
+// renderedFile is a file rendered from a template
+type renderedFile struct {
+	content []byte
+	mode    os.FileMode
+}
+
+// PlanUpgrade compares the project in opts.Dir with opts.Template rendered
+// with the variables and feature groups recorded in its ProvenanceFile, and
+// returns the changes upgrading it makes. Redacted variables have no value
+// to render with, so they must be in opts.Vars, like variables without a
+// default the newer version adds; otherwise it fails with a
+// *MissingVariablesError.
+//
+// Given opts.Base, a file is only overwritten or deleted when the project
+// still has it as it was created, and is a conflict otherwise. Without it,
+// every project file the newer version differs from is a conflict.
+func PlanUpgrade(ctx context.Context, opts UpgradeOptions) (*UpgradePlan, error) {
+	if opts.Template == nil {
+		return nil, errors.New("a template is required")
+	}
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	recorded, err := LoadProvenance(dir)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := recorded.upgradeVariables(opts.Template, opts.Vars, opts.ExtraVars)
+	if err != nil {
+		return nil, err
+	}
+	sel, baseSel, err := recorded.upgradeFeatures(ctx, opts.Template, opts.Base)
+	if err != nil {
+		return nil, err
+	}
+
+	// The seed the project was created with generates the same random
+	// values again, so they are not changes
+	seed := recorded.Seed
+	if seed == 0 {
+		seed = template.NewSeed()
+	}
+
+	newer, err := renderFiles(ctx, opts.Template, vars, sel, seed)
+	if err != nil {
+		return nil, err
+	}
+	var base map[string]*renderedFile
+	if opts.Base != nil {
+		if base, err = renderFiles(ctx, opts.Base, vars, baseSel, seed); err != nil {
+			return nil, err
+		}
+	}
+
+	provenance := newProvenance(opts.Template, opts.Version, vars, sel, seed)
+	upgradedAt := provenance.CreatedAt
+	provenance.CreatedAt, provenance.UpgradedAt = recorded.CreatedAt, &upgradedAt
+	plan := &UpgradePlan{
+		Dir:        dir,
+		From:       recorded.Template,
+		To:         provenance.Template,
+		Merged:     opts.Base != nil,
+		provenance: provenance,
+		version:    opts.Template.version(),
+	}
+
+	paths := map[string]bool{}
+	for path := range newer {
+		paths[path] = true
+	}
+	for path := range base {
+		paths[path] = true
+	}
+	// Files create writes itself are not the template's
+	delete(paths, deploy.ProjectFile)
+	delete(paths, ProvenanceFile)
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	for _, path := range sorted {
+		change, err := planChange(dir, path, base, newer, plan.Merged)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			plan.Changes = append(plan.Changes, *change)
+		}
+	}
+	return plan, nil
+}
+
+// planChange returns how upgrading changes the project file at path, or nil
+// if it is left as it is. base is nil unless merged.
+func planChange(dir, path string, base, newer map[string]*renderedFile, merged bool) (*FileChange, error) {
+	current, err := os.ReadFile(filepath.Join(dir, path))
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	original, created := base[path]
+	unchanged := exists && created && bytes.Equal(current, original.content)
+	file := newer[path]
+
+	if file == nil {
+		// Only a known base tells that the newer version removes the file
+		switch {
+		case !exists:
+			return nil, nil
+		case unchanged:
+			return &FileChange{Path: path, Kind: ChangeRemoved, Diff: unifiedDiff("a/"+path, "/dev/null", current, nil)}, nil
+		default:
+			return &FileChange{Path: path, Kind: ChangeConflict, Diff: unifiedDiff("a/"+path, "/dev/null", current, nil)}, nil
+		}
+	}
+
+	switch {
+	case exists && bytes.Equal(current, file.content):
+		return nil, nil
+	case created && bytes.Equal(original.content, file.content):
+		// Only the project changed the file, or deleted it
+		return nil, nil
+	case !exists && created:
+		// The project deleted a file the newer version changes
+		return &FileChange{Path: path, Kind: ChangeConflict, Diff: unifiedDiff("/dev/null", "b/"+path, nil, file.content), file: file}, nil
+	case !exists:
+		return &FileChange{Path: path, Kind: ChangeAdded, Diff: unifiedDiff("/dev/null", "b/"+path, nil, file.content), file: file}, nil
+	case unchanged:
+		return &FileChange{Path: path, Kind: ChangeUpdated, Diff: unifiedDiff("a/"+path, "b/"+path, current, file.content), file: file}, nil
+	default:
+		return &FileChange{Path: path, Kind: ChangeConflict, Diff: unifiedDiff("a/"+path, "b/"+path, current, file.content), file: file}, nil
+	}
+}
+
+// ConflictPath returns where Apply writes the newer template's version of a
+// conflicting file, or "" if the newer version removes it
+func (c FileChange) ConflictPath() string {
+	if c.Kind != ChangeConflict || c.file == nil {
+		return ""
+	}
+	return c.Path + ConflictSuffix
+}
+
+// Conflicts returns the changes left for manual resolution
+func (p *UpgradePlan) Conflicts() []FileChange {
+	var conflicts []FileChange
+	for _, change := range p.Changes {
+		if change.Kind == ChangeConflict {
+			conflicts = append(conflicts, change)
+		}
+	}
+	return conflicts
+}
+
+// Apply writes the changes into the project: added and updated files are
+// written, removed ones deleted, and the newer template's version of
+// conflicting files is written next to them with ConflictSuffix. Every file
+// it overwrites or deletes is first moved to BackupDir. The ProvenanceFile
+// and the template version in acontext.yaml are updated to the newer
+// version. It returns the files that were backed up, sorted by path.
+func (p *UpgradePlan) Apply() ([]string, error) {
+	stagingDir, err := os.MkdirTemp("", "acontext-upgrade-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(stagingDir)
+	}()
+
+	backupDir := filepath.Join(p.Dir, BackupDir, time.Now().Format("20060102-150405"))
+	var removed []string
+	for _, change := range p.Changes {
+		path := change.Path
+		switch {
+		case change.Kind == ChangeRemoved:
+			if err := moveToBackup(p.Dir, backupDir, path); err != nil {
+				return nil, err
+			}
+			removed = append(removed, path)
+			continue
+		case change.file == nil:
+			continue
+		case change.Kind == ChangeConflict:
+			path += ConflictSuffix
+		}
+		staged := filepath.Join(stagingDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(staged), 0755); err != nil {
+			return nil, fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+		if err := os.WriteFile(staged, change.file.content, change.file.mode); err != nil {
+			return nil, fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+	}
+
+	overwritten, err := template.Overlay(stagingDir, p.Dir, backupDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write template files: %w", err)
+	}
+	if err := p.provenance.write(p.Dir); err != nil {
+		return nil, err
+	}
+	if p.version != nil {
+		if err := deploy.SetTemplateVersion(p.Dir, p.version); err != nil {
+			return nil, err
+		}
+	}
+
+	backedUp := append(overwritten, removed...)
+	sort.Strings(backedUp)
+	return slices.Compact(backedUp), nil
+}
+
+// moveToBackup moves the project file at path into backupDir, keeping its
+// relative path
+func moveToBackup(dir, backupDir, path string) error {
+	backupPath := filepath.Join(backupDir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := os.Rename(filepath.Join(dir, filepath.FromSlash(path)), backupPath); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return nil
+}
+
+// upgradeVariables returns the values to render the newer template tmpl
+// with: the recorded standard variables, the template's own variables from
+// vars, the recorded answers or their defaults, and the extra variables from
+// extra or the recorded ones
+func (p *Provenance) upgradeVariables(tmpl *Template, vars, extra map[string]string) (map[string]string, error) {
+	name := p.Variables["project_name"]
+	if name == "" {
+		return nil, fmt.Errorf("%s does not record the project name", ProvenanceFile)
+	}
+
+	given := p.Answers(tmpl.Variables)
+	var redacted []string
+	for _, variable := range tmpl.Variables {
+		if p.Variables[variable.Name] == RedactedValue {
+			if _, ok := vars[variable.Name]; !ok {
+				redacted = append(redacted, variable.Name)
+			}
+		}
+	}
+	for name, value := range vars {
+		given[name] = value
+	}
+
+	values, err := ResolveVariables(DefaultVariables(tmpl.Variables, name), given)
+	var missing *MissingVariablesError
+	if errors.As(err, &missing) {
+		redacted = append(redacted, missing.Names...)
+	} else if err != nil {
+		return nil, err
+	}
+	if len(redacted) > 0 {
+		// A redacted variable would otherwise silently take its default
+		sort.Strings(redacted)
+		return nil, &MissingVariablesError{Names: redacted}
+	}
+
+	resolved := map[string]string{}
+	for _, variable := range template.StandardVariables {
+		if value, ok := p.Variables[variable.Name]; ok {
+			resolved[variable.Name] = value
+		}
+	}
+	for name, value := range values {
+		resolved[name] = value
+	}
+
+	if err := CheckExtraVariables(tmpl.Variables, extra); err != nil {
+		return nil, err
+	}
+	for name, value := range p.extraVariables(tmpl.Variables) {
+		resolved[name] = value
+	}
+	for name, value := range extra {
+		resolved[name] = value
+	}
+	return resolved, nil
+}
+
+// upgradeFeatures returns the selections rendering the newer template tmpl
+// and base with the feature groups the project was created with. Groups new
+// in tmpl are included when they are by default; without base, which groups
+// are new is unknown, so none is.
+func (p *Provenance) upgradeFeatures(ctx context.Context, tmpl, base *Template) (FeatureSelection, FeatureSelection, error) {
+	if p.Features == nil {
+		return FeatureSelection{}, FeatureSelection{}, nil
+	}
+	baseSel := FeatureSelection{Minimal: true, With: p.Features}
+	known := map[string]bool{}
+	if base != nil {
+		features, err := base.ListFeatures(ctx)
+		if err != nil {
+			return FeatureSelection{}, FeatureSelection{}, err
+		}
+		for _, feature := range features {
+			known[feature.Name] = true
+		}
+	}
+
+	features, err := tmpl.ListFeatures(ctx)
+	if err != nil {
+		return FeatureSelection{}, FeatureSelection{}, err
+	}
+	sel := FeatureSelection{Minimal: true}
+	for _, feature := range features {
+		if slices.Contains(p.Features, feature.Name) || (base != nil && !known[feature.Name] && feature.IncludedByDefault()) {
+			sel.With = append(sel.With, feature.Name)
+		}
+	}
+	return sel, baseSel, nil
+}
+
+// renderFiles renders tmpl with vars, the feature groups sel selects and
+// seed, and returns its files by relative path
+func renderFiles(ctx context.Context, tmpl *Template, vars map[string]string, sel FeatureSelection, seed uint64) (map[string]*renderedFile, error) {
+	dir, err := os.MkdirTemp("", "acontext-upgrade-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	if err := tmpl.render(ctx, dir, vars, sel, seed); err != nil {
+		return nil, err
+	}
+
+	paths, err := template.ListFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template files: %w", err)
+	}
+	files := make(map[string]*renderedFile, len(paths))
+	for _, path := range paths {
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		info, err := os.Stat(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file %s: %w", path, err)
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file %s: %w", path, err)
+		}
+		files[path] = &renderedFile{content: content, mode: info.Mode().Perm()}
+	}
+	return files, nil
+}
@@ -0,0 +1,283 @@
+package scaffold
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const upgradeManifest = `name: starter
+variables:
+  - name: model
+    default: gpt-4o-mini
+  - name: api_key
+    sensitive: true
+    default: changeme
+features:
+  - name: tests
+    paths: [tests/]
+`
+
+// openFilesTemplate opens a local template made of files, by relative path
+func openFilesTemplate(t *testing.T, files map[string]string) *Template {
+	t.Helper()
+	templateDir := t.TempDir()
+	for path, content := range files {
+		writeFile(t, filepath.Join(templateDir, filepath.FromSlash(path)), content)
+	}
+	tmpl, err := OpenTemplate(context.Background(), TemplateRef{URL: "file://" + templateDir})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = tmpl.Close()
+	})
+	return tmpl
+}
+
+// beforeTemplate is the version of the template projects are created from
+var beforeTemplate = map[string]string{
+	"acontext.template.yaml": upgradeManifest,
+	"README.md":              "# starter\n",
+	"src/index.ts":           "console.log('hello')\n",
+	"src/config.ts":          "export const model = 'gpt-4o-mini'\n",
+	"old.txt":                "obsolete\n",
+	"legacy.txt":             "obsolete\n",
+	"tests/index.test.ts":    "test('hello')\n",
+}
+
+// afterTemplate is a newer version of beforeTemplate: it changes README.md
+// and src/index.ts, adds docs/guide.md and a lint group on by default, and
+// removes old.txt and legacy.txt
+var afterTemplate = map[string]string{
+	"acontext.template.yaml": upgradeManifest + "  - name: lint\n    paths: [.eslintrc]\n",
+	"README.md":              "# starter\n\nSee docs/guide.md.\n",
+	"src/index.ts":           "console.log('hello, world')\n",
+	"src/config.ts":          "export const model = 'gpt-4o-mini'\n",
+	"docs/guide.md":          "# Guide\n",
+	"tests/index.test.ts":    "test('hello')\n",
+	".eslintrc":              "{}\n",
+}
+
+// createUpgradeProject creates a project from beforeTemplate, then edits
+// src/index.ts, src/config.ts and legacy.txt the way a user would
+func createUpgradeProject(t *testing.T) string {
+	t.Helper()
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+	_, err := Scaffold(context.Background(), Options{
+		Name:       "my-agent",
+		Dir:        projectDir,
+		Template:   openFilesTemplate(t, beforeTemplate),
+		Vars:       map[string]string{"api_key": "sk-secret"},
+		Provenance: true,
+		Version:    "v1.0.0",
+	})
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(projectDir, "src", "index.ts"), "console.log('hi')\n")
+	writeFile(t, filepath.Join(projectDir, "src", "config.ts"), "export const model = 'gpt-4o'\n")
+	writeFile(t, filepath.Join(projectDir, "legacy.txt"), "still used\n")
+	return projectDir
+}
+
+func TestPlanUpgrade(t *testing.T) {
+	projectDir := createUpgradeProject(t)
+	base := openFilesTemplate(t, beforeTemplate)
+	newer := openFilesTemplate(t, afterTemplate)
+
+	_, err := PlanUpgrade(context.Background(), UpgradeOptions{Dir: projectDir, Template: newer, Base: base})
+	var missing *MissingVariablesError
+	require.ErrorAs(t, err, &missing, "a redacted variable must be given again")
+	assert.Equal(t, []string{"api_key"}, missing.Names)
+
+	plan, err := PlanUpgrade(context.Background(), UpgradeOptions{
+		Dir:      projectDir,
+		Template: newer,
+		Base:     base,
+		Vars:     map[string]string{"api_key": "sk-secret"},
+		Version:  "v1.1.0",
+	})
+	require.NoError(t, err)
+	assert.True(t, plan.Merged)
+
+	kinds := map[string]ChangeKind{}
+	for _, change := range plan.Changes {
+		kinds[change.Path] = change.Kind
+	}
+	assert.Equal(t, map[string]ChangeKind{
+		".eslintrc":     ChangeAdded,
+		"README.md":     ChangeUpdated,
+		"docs/guide.md": ChangeAdded,
+		"legacy.txt":    ChangeConflict,
+		"old.txt":       ChangeRemoved,
+		"src/index.ts":  ChangeConflict,
+	}, kinds, "src/config.ts is only changed in the project and tests/ in neither")
+	assert.Len(t, plan.Conflicts(), 2)
+	assert.Equal(t, "--- a/README.md\n+++ b/README.md\n@@ -1 +1,3 @@\n # starter\n+\n+See docs/guide.md.\n", plan.Changes[1].Diff)
+
+	backedUp, err := plan.Apply()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "old.txt"}, backedUp)
+
+	assertFileContent(t, filepath.Join(projectDir, "README.md"), "# starter\n\nSee docs/guide.md.\n")
+	assertFileContent(t, filepath.Join(projectDir, "docs", "guide.md"), "# Guide\n")
+	assertFileContent(t, filepath.Join(projectDir, ".eslintrc"), "{}\n")
+	assert.NoFileExists(t, filepath.Join(projectDir, "old.txt"))
+	// Conflicts keep the project's file, next to the template's
+	assertFileContent(t, filepath.Join(projectDir, "src", "index.ts"), "console.log('hi')\n")
+	assertFileContent(t, filepath.Join(projectDir, "src", "index.ts"+ConflictSuffix), "console.log('hello, world')\n")
+	assertFileContent(t, filepath.Join(projectDir, "legacy.txt"), "still used\n")
+	assert.NoFileExists(t, filepath.Join(projectDir, "legacy.txt"+ConflictSuffix))
+	assertFileContent(t, filepath.Join(projectDir, "src", "config.ts"), "export const model = 'gpt-4o'\n")
+
+	backups, err := filepath.Glob(filepath.Join(projectDir, BackupDir, "*", "old.txt"))
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+
+	provenance, err := LoadProvenance(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", provenance.CLIVersion)
+	require.NotNil(t, provenance.UpgradedAt)
+	assert.False(t, provenance.UpgradedAt.Before(provenance.CreatedAt))
+	assert.Equal(t, []string{"tests", "lint"}, provenance.Features)
+	assert.Equal(t, RedactedValue, provenance.Variables["api_key"])
+	assert.Equal(t, newer.Source, provenance.Template.Source)
+}
+
+func TestPlanUpgradeExtraVariables(t *testing.T) {
+	manifest := "name: starter\nrender: [README.md]\n"
+	before := map[string]string{"acontext.template.yaml": manifest, "README.md": "# {{ .project_name }}\n"}
+	after := map[string]string{"acontext.template.yaml": manifest, "README.md": "# {{ .project_name }}\n\nOwned by {{ .team }}.\n"}
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+	_, err := Scaffold(context.Background(), Options{
+		Name:       "my-agent",
+		Dir:        projectDir,
+		Template:   openFilesTemplate(t, before),
+		ExtraVars:  map[string]string{"team": "core"},
+		Provenance: true,
+	})
+	require.NoError(t, err)
+	base, newer := openFilesTemplate(t, before), openFilesTemplate(t, after)
+
+	plan, err := PlanUpgrade(context.Background(), UpgradeOptions{Dir: projectDir, Template: newer, Base: base})
+	require.NoError(t, err)
+	_, err = plan.Apply()
+	require.NoError(t, err)
+	assertFileContent(t, filepath.Join(projectDir, "README.md"), "# my-agent\n\nOwned by core.\n")
+
+	plan, err = PlanUpgrade(context.Background(), UpgradeOptions{Dir: projectDir, Template: newer, ExtraVars: map[string]string{"team": "infra"}})
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	assert.Contains(t, plan.Changes[0].Diff, "+Owned by infra.")
+}
+
+func TestPlanUpgradeWithoutBase(t *testing.T) {
+	projectDir := createUpgradeProject(t)
+
+	plan, err := PlanUpgrade(context.Background(), UpgradeOptions{
+		Dir:      projectDir,
+		Template: openFilesTemplate(t, afterTemplate),
+		Vars:     map[string]string{"api_key": "sk-secret"},
+	})
+	require.NoError(t, err)
+	assert.False(t, plan.Merged)
+
+	kinds := map[string]ChangeKind{}
+	for _, change := range plan.Changes {
+		kinds[change.Path] = change.Kind
+	}
+	// Without the version the project was created from, project changes
+	// cannot be told from template changes, and removed files are unknown
+	assert.Equal(t, map[string]ChangeKind{
+		"README.md":     ChangeConflict,
+		"docs/guide.md": ChangeAdded,
+		"src/config.ts": ChangeConflict,
+		"src/index.ts":  ChangeConflict,
+	}, kinds)
+}
+
+func TestPlanUpgradeUpToDate(t *testing.T) {
+	projectDir := createUpgradeProject(t)
+
+	plan, err := PlanUpgrade(context.Background(), UpgradeOptions{
+		Dir:      projectDir,
+		Template: openFilesTemplate(t, beforeTemplate),
+		Base:     openFilesTemplate(t, beforeTemplate),
+		Vars:     map[string]string{"api_key": "sk-secret"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, plan.Changes)
+}
+
+func TestPlanUpgradeNoProvenance(t *testing.T) {
+	_, err := PlanUpgrade(context.Background(), UpgradeOptions{Dir: t.TempDir(), Template: openFilesTemplate(t, afterTemplate)})
+	assert.ErrorContains(t, err, "failed to read "+ProvenanceFile)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestProvenanceTemplateRef(t *testing.T) {
+	tests := []struct {
+		name     string
+		template ProvenanceTemplate
+		wantRef  TemplateRef
+		wantBase TemplateRef
+	}{
+		{
+			name:     "remote",
+			template: ProvenanceTemplate{Name: "starter", Source: "git+https://github.com/myorg/template.git", Ref: "v1.2.0", Commit: "3f2a9c1d0b7e"},
+			wantRef:  TemplateRef{URL: "git+https://github.com/myorg/template.git"},
+			wantBase: TemplateRef{URL: "git+https://github.com/myorg/template.git", Ref: "3f2a9c1d0b7e"},
+		},
+		{
+			name:     "local",
+			template: ProvenanceTemplate{Name: "starter", Source: "file:///path/to/template"},
+			wantRef:  TemplateRef{URL: "file:///path/to/template"},
+		},
+		{
+			name:     "examples repository",
+			template: ProvenanceTemplate{Name: "python/custom-template", Source: ExamplesRepo},
+			wantRef:  TemplateRef{Path: "python/custom-template"},
+		},
+		{
+			name:     "built-in",
+			template: ProvenanceTemplate{Name: "go/basic", Source: "embedded"},
+			wantRef:  TemplateRef{Key: "go/basic"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provenance := &Provenance{Template: tt.template}
+			assert.Equal(t, tt.wantRef, provenance.TemplateRef())
+			base, ok := provenance.BaseRef()
+			assert.Equal(t, tt.wantBase != TemplateRef{}, ok)
+			assert.Equal(t, tt.wantBase, base)
+		})
+	}
+}
+
+func TestPlanUpgradeSeed(t *testing.T) {
+	before := map[string]string{
+		"acontext.template.yaml": "name: starter\nrender: [.env]\n",
+		".env":                   "SECRET_KEY={{ randomHex 32 }}\n",
+		"README.md":              "# starter\n",
+	}
+	projectDir := filepath.Join(t.TempDir(), "my-agent")
+	_, err := Scaffold(context.Background(), Options{Name: "my-agent", Dir: projectDir, Template: openFilesTemplate(t, before), Provenance: true})
+	require.NoError(t, err)
+
+	after := map[string]string{}
+	for path, content := range before {
+		after[path] = content
+	}
+	after["README.md"] = "# starter\n\nUpdated.\n"
+	plan, err := PlanUpgrade(context.Background(), UpgradeOptions{
+		Dir:      projectDir,
+		Template: openFilesTemplate(t, after),
+		Base:     openFilesTemplate(t, before),
+	})
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1, "the recorded seed generates the same secret key again")
+	assert.Equal(t, "README.md", plan.Changes[0].Path)
+}